@@ -0,0 +1,34 @@
+// Command mockidp runs a local OpenID Connect provider for trying the hospital SSO login
+// without the real identity provider. Every login is signed in with the given claims.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+
+	"medscreen/internal/oidc/oidctest"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:9000", "listen address")
+	issuer := flag.String("issuer", "http://localhost:9000", "issuer URL (OIDC_ISSUER)")
+	clientID := flag.String("client-id", "medscreen", "client id (OIDC_CLIENT_ID)")
+	clientSecret := flag.String("client-secret", "", "client secret (OIDC_CLIENT_SECRET)")
+	claims := flag.String("claims", `{"sub":"P000001"}`, "ID token claims of the logged in user, as JSON")
+	flag.Parse()
+
+	var login map[string]interface{}
+	if err := json.Unmarshal([]byte(*claims), &login); err != nil {
+		log.Fatalf("Invalid -claims: %v", err)
+	}
+
+	provider := oidctest.NewProvider(*issuer, *clientID, *clientSecret)
+	provider.Login(login)
+
+	log.Printf("Mock OpenID provider %s logging in as %s", *issuer, *claims)
+	if err := http.ListenAndServe(*addr, provider); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -4,18 +4,30 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"medscreen/internal/allergy"
+	"medscreen/internal/audit"
 	"medscreen/internal/config"
+	"medscreen/internal/critical"
 	"medscreen/internal/database"
 	"medscreen/internal/handler"
+	"medscreen/internal/hl7"
+	"medscreen/internal/masking"
+	"medscreen/internal/models"
+	"medscreen/internal/oidc"
+	"medscreen/internal/policy"
+	"medscreen/internal/ratelimit"
 	"medscreen/internal/repository"
 	"medscreen/internal/routes"
 	"medscreen/internal/service"
+	"medscreen/internal/stream"
 	"medscreen/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -28,8 +40,13 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Set JWT secret key
-	utils.SetJWTSecretKey()
+	// Install the JWT signing and verification keys
+	if err := utils.ConfigureJWT(&cfg.JWT); err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+	if kid := utils.CurrentKeySet().SigningKID(); kid != "" {
+		log.Printf("Signing tokens with key %s", kid)
+	}
 
 	// Set Gin mode
 	gin.SetMode(cfg.Server.GinMode)
@@ -40,8 +57,11 @@ func main() {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
-	// Note: No migrations run - VEM 2.0 tables already exist in the database
-	// The database.RunMigrations call has been removed for read-only mode
+	// Note: VEM 2.0 tables already exist and are never migrated.
+	// Only MedScreen-owned tables (token revocation list) are created, in their own schema.
+	if err := database.MigrateMedScreen(db); err != nil {
+		log.Fatalf("Failed to migrate MedScreen tables: %v", err)
+	}
 
 	// Initialize VEM 2.0 repositories (read-only)
 	personelRepo := repository.NewPersonelRepository(db)
@@ -62,6 +82,47 @@ func main() {
 	riskSkorlamaRepo := repository.NewRiskSkorlamaRepository(db)
 	basvuruYemekRepo := repository.NewBasvuruYemekRepository(db)
 	randevuRepo := repository.NewRandevuRepository(db)
+	klinikOlayRepo := repository.NewKlinikOlayRepository(db)
+
+	// Initialize MedScreen-owned repositories
+	tokenIptalRepo := repository.NewTokenIptalRepository(db)
+	girisEngeliRepo := repository.NewGirisEngeliRepository(db)
+	kritikSonucOnayRepo := repository.NewKritikSonucOnayRepository(db)
+	cihazKaydiRepo := repository.NewCihazKaydiRepository(db)
+	cihazKayitKoduRepo := repository.NewCihazKayitKoduRepository(db)
+	yatakKisitiKaldirmaRepo := repository.NewYatakKisitiKaldirmaRepository(db)
+	acilErisimRepo := repository.NewAcilErisimRepository(db)
+	ikinciFaktorRepo := repository.NewIkinciFaktorRepository(db)
+	apiAnahtariRepo := repository.NewAPIAnahtariRepository(db)
+	oturumRepo := repository.NewOturumRepository(db)
+	hl7MesajRepo := repository.NewHL7MesajRepository(db)
+
+	// Patient data access audit trail (KVKK)
+	var auditSink repository.ErisimKaydiRepository
+	switch cfg.Audit.Sink {
+	case "file":
+		auditSink, err = audit.NewFileSink(cfg.Audit.FilePath)
+		if err != nil {
+			log.Fatalf("Failed to open audit file: %v", err)
+		}
+	case "postgres":
+		auditSink = repository.NewErisimKaydiRepository(db)
+	default:
+		log.Fatalf("Unknown AUDIT_SINK %q (use postgres or file)", cfg.Audit.Sink)
+	}
+	auditSink = audit.WithHastaKodu(auditSink, hastaBasvuruRepo)
+
+	// Drug/allergen mapping for the drug-allergy conflict check
+	alerjiEsleme, err := allergy.Load(cfg.Allergy.EslemeFile)
+	if err != nil {
+		log.Fatalf("Failed to load allergy mapping: %v", err)
+	}
+
+	// Critical (panic) limits of laboratory tests
+	kritikEsikler, err := critical.Load(cfg.Lab.KritikEsikFile)
+	if err != nil {
+		log.Fatalf("Failed to load critical limits: %v", err)
+	}
 
 	// Initialize VEM 2.0 services (read-only)
 	personelService := service.NewPersonelService(personelRepo, nfcKartRepo)
@@ -74,7 +135,7 @@ func main() {
 	hastaVitalFizikiBulguService := service.NewHastaVitalFizikiBulguService(hastaVitalFizikiBulguRepo)
 	klinikSeyirService := service.NewKlinikSeyirService(klinikSeyirRepo)
 	tibbiOrderService := service.NewTibbiOrderService(tibbiOrderRepo)
-	tetkikSonucService := service.NewTetkikSonucService(tetkikSonucRepo)
+	tetkikSonucService := service.NewTetkikSonucService(tetkikSonucRepo, anlikYatanHastaRepo, kritikSonucOnayRepo, kritikEsikler)
 	receteService := service.NewReceteService(receteRepo)
 	basvuruTaniService := service.NewBasvuruTaniService(basvuruTaniRepo)
 	hastaTibbiBilgiService := service.NewHastaTibbiBilgiService(hastaTibbiBilgiRepo)
@@ -82,40 +143,199 @@ func main() {
 	riskSkorlamaService := service.NewRiskSkorlamaService(riskSkorlamaRepo)
 	basvuruYemekService := service.NewBasvuruYemekService(basvuruYemekRepo)
 	randevuService := service.NewRandevuService(randevuRepo)
+	erisimKaydiService := service.NewErisimKaydiService(auditSink)
+	oturumService := service.NewOturumService(oturumRepo, nfcKartRepo, cfg.Auth.OturumZamanAsimi)
+	authService := service.NewAuthService(personelService, nfcKartRepo, tabletCihazRepo, tokenIptalRepo, girisEngeliRepo, yatakKisitiKaldirmaRepo, oturumService, cfg.JWT.AccessTokenTTL, cfg.JWT.RefreshTokenTTL)
+	news2Service := service.NewNews2Service(hastaVitalFizikiBulguRepo, anlikYatanHastaRepo)
+	ilacAlerjiService := service.NewIlacAlerjiService(receteRepo, hastaBasvuruRepo, hastaTibbiBilgiRepo, alerjiEsleme)
+	acilErisimService := service.NewAcilErisimService(acilErisimRepo, auditSink, cfg.Auth.AcilErisimSuresi)
+	ikinciFaktorService := service.NewIkinciFaktorService(ikinciFaktorRepo, personelService, nfcKartRepo, cfg.Auth.IkinciFaktorSuresi, cfg.Auth.TOTPIssuer)
+	cihazService := service.NewCihazService(tabletCihazRepo, cihazKaydiRepo, cihazKayitKoduRepo, anlikYatanHastaRepo, cfg.Device.HeartbeatInterval, cfg.Device.OfflineAfter, cfg.Device.EnrollmentCodeTTL)
+	marService := service.NewMarService(tibbiOrderRepo, anlikYatanHastaRepo, cfg.Mar.Tolerans, cfg.Mar.KacirmaSuresi)
+	hastaBasvuruOzetService := service.NewHastaBasvuruOzetService(service.HastaBasvuruOzetRepositories{
+		HastaBasvuru:          hastaBasvuruRepo,
+		AnlikYatanHasta:       anlikYatanHastaRepo,
+		HastaVitalFizikiBulgu: hastaVitalFizikiBulguRepo,
+		HastaUyari:            hastaUyariRepo,
+		HastaTibbiBilgi:       hastaTibbiBilgiRepo,
+		BasvuruTani:           basvuruTaniRepo,
+		TibbiOrder:            tibbiOrderRepo,
+		Recete:                receteRepo,
+		RiskSkorlama:          riskSkorlamaRepo,
+		BasvuruYemek:          basvuruYemekRepo,
+	})
+
+	// Clinical event streams poll the VEM 2.0 tables for new rows
+	izleyici := stream.NewIzleyici(anlikYatanHastaRepo, klinikOlayRepo, stream.RealClock, cfg.Stream.PollInterval, cfg.Stream.Keepalive)
+
+	// Failed NFC logins are counted per verified tablet in memory; a shared ratelimit.Store
+	// is needed once several instances serve the same wards
+	nfcLimiter := ratelimit.New(ratelimit.NewMemoryStore(cfg.NFCLimit.LockoutDuration+cfg.NFCLimit.ResetAfter), ratelimit.Ayarlar{
+		Serbest:     cfg.NFCLimit.FreeAttempts,
+		Taban:       cfg.NFCLimit.BackoffBase,
+		KilitEsigi:  cfg.NFCLimit.LockoutAfter,
+		KilitSuresi: cfg.NFCLimit.LockoutDuration,
+		Sifirlama:   cfg.NFCLimit.ResetAfter,
+	})
+
+	// Load the role- and purpose-based masking of personal data in responses
+	maskingPolicy, err := masking.Load(cfg.Auth.MaskingFile)
+	if err != nil {
+		log.Fatalf("Failed to load masking policy: %v", err)
+	}
 
 	// Initialize VEM 2.0 handlers (read-only, GET endpoints only)
 	handlers := &routes.Handlers{
+		Auth:                  handler.NewAuthHandler(authService, cihazService),
+		Oturum:                handler.NewOturumHandler(oturumService),
+		ErisimKaydi:           handler.NewErisimKaydiHandler(erisimKaydiService),
 		Personel:              handler.NewPersonelHandler(personelService),
 		NFCKart:               handler.NewNFCKartHandler(nfcKartService),
 		Hasta:                 handler.NewHastaHandler(hastaService),
 		HastaBasvuru:          handler.NewHastaBasvuruHandler(hastaBasvuruService),
+		HastaBasvuruOzet:      handler.NewHastaBasvuruOzetHandler(hastaBasvuruOzetService),
 		Yatak:                 handler.NewYatakHandler(yatakService),
 		TabletCihaz:           handler.NewTabletCihazHandler(tabletCihazService),
+		Cihaz:                 handler.NewCihazHandler(cihazService),
+		AcilErisim:            handler.NewAcilErisimHandler(acilErisimService),
+		IkinciFaktor:          handler.NewIkinciFaktorHandler(ikinciFaktorService),
+		JWKS:                  handler.NewJWKSHandler(utils.CurrentKeySet),
+		Engel:                 handler.NewEngelHandler(nfcLimiter),
 		AnlikYatanHasta:       handler.NewAnlikYatanHastaHandler(anlikYatanHastaService),
 		HastaVitalFizikiBulgu: handler.NewHastaVitalFizikiBulguHandler(hastaVitalFizikiBulguService),
+		News2:                 handler.NewNews2Handler(news2Service),
+		Mar:                   handler.NewMarHandler(marService, cfg.Mar.Pencere),
 		KlinikSeyir:           handler.NewKlinikSeyirHandler(klinikSeyirService),
 		TibbiOrder:            handler.NewTibbiOrderHandler(tibbiOrderService),
 		TetkikSonuc:           handler.NewTetkikSonucHandler(tetkikSonucService),
 		Recete:                handler.NewReceteHandler(receteService),
+		IlacAlerji:            handler.NewIlacAlerjiHandler(ilacAlerjiService),
 		BasvuruTani:           handler.NewBasvuruTaniHandler(basvuruTaniService),
 		HastaTibbiBilgi:       handler.NewHastaTibbiBilgiHandler(hastaTibbiBilgiService),
 		HastaUyari:            handler.NewHastaUyariHandler(hastaUyariService),
 		RiskSkorlama:          handler.NewRiskSkorlamaHandler(riskSkorlamaService),
 		BasvuruYemek:          handler.NewBasvuruYemekHandler(basvuruYemekService),
 		Randevu:               handler.NewRandevuHandler(randevuService),
+		Stream:                handler.NewStreamHandler(izleyici, auditSink, maskingPolicy, authService, cfg.Stream.MaxReplay, cfg.Stream.PollInterval),
+		FHIR: handler.NewFHIRHandler(handler.FHIRServices{
+			Hasta:                 hastaService,
+			HastaBasvuru:          hastaBasvuruService,
+			HastaVitalFizikiBulgu: hastaVitalFizikiBulguService,
+			TetkikSonuc:           tetkikSonucService,
+			BasvuruTani:           basvuruTaniService,
+			HastaTibbiBilgi:       hastaTibbiBilgiService,
+			Recete:                receteService,
+			Personel:              personelService,
+		}, maskingPolicy),
+	}
+
+	// Hospital SSO for desktop users without an NFC reader
+	if cfg.OIDC.Issuer != "" {
+		oidcClient := oidc.NewClient(oidc.Config{
+			Issuer:       cfg.OIDC.Issuer,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Scopes:       cfg.OIDC.Scopes,
+		}, &http.Client{Timeout: 10 * time.Second})
+		ssoService := service.NewSSOService(oidcClient, authService, cfg.OIDC.PersonelClaim)
+		handlers.SSO = handler.NewSSOHandler(ssoService, strings.HasPrefix(cfg.OIDC.RedirectURL, "https://"))
 	}
 
+	// Load the role- and unit-based authorization policy
+	authzPolicy, err := policy.Load(cfg.Auth.PolicyFile)
+	if err != nil {
+		log.Fatalf("Failed to load authorization policy: %v", err)
+	}
+	authz := policy.NewEngine(authzPolicy)
+
+	authz.SetIkinciFaktorSuresi(cfg.Auth.IkinciFaktorSuresi)
+	authz.RegisterAnlikYatanHastaResolvers(anlikYatanHastaRepo, yatakRepo)
+	authz.RegisterStreamResolvers(yatakRepo)
+	authz.RegisterHastaBirimResolvers(anlikYatanHastaRepo, yatakRepo)
+	authz.RegisterYatakResolvers(policy.YatakRepositories{
+		Hasta:                 hastaRepo,
+		AnlikYatanHasta:       anlikYatanHastaRepo,
+		HastaVitalFizikiBulgu: hastaVitalFizikiBulguRepo,
+		KlinikSeyir:           klinikSeyirRepo,
+		TibbiOrder:            tibbiOrderRepo,
+		TetkikSonuc:           tetkikSonucRepo,
+		Recete:                receteRepo,
+		BasvuruTani:           basvuruTaniRepo,
+		HastaTibbiBilgi:       hastaTibbiBilgiRepo,
+		HastaUyari:            hastaUyariRepo,
+		RiskSkorlama:          riskSkorlamaRepo,
+		BasvuruYemek:          basvuruYemekRepo,
+		Randevu:               randevuRepo,
+	})
+
+	// Dead letters of the HL7 v2 feed can be inspected and requeued even when the feed is off
+	handlers.HL7Mesaj = handler.NewHL7MesajHandler(service.NewHL7MesajService(hl7MesajRepo))
+
+	// API keys of integrations may be scoped to reading any resource of the policy
+	apiAnahtariService := service.NewAPIAnahtariService(apiAnahtariRepo, authzPolicy.ReadScopes())
+	handlers.APIAnahtari = handler.NewAPIAnahtariHandler(apiAnahtariService)
+
 	// Set up Gin router
 	router := gin.Default()
+	// Without configured proxies no X-Forwarded-For is trusted, so the client IP used for
+	// rate limiting is the address of the connection
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid SERVER_TRUSTED_PROXIES: %v", err)
+	}
 
 	// Register all VEM 2.0 routes with middleware (GET only)
-	routes.SetupRoutes(router, handlers, cfg.CORS.AllowedOrigins, cfg.CORS.AllowedMethods, cfg.CORS.AllowedHeaders)
+	routes.SetupRoutes(router, handlers, routes.Options{
+		CORSOrigins:               cfg.CORS.AllowedOrigins,
+		CORSMethods:               cfg.CORS.AllowedMethods,
+		CORSHeaders:               cfg.CORS.AllowedHeaders,
+		Revocations:               authService,
+		APIKeys:                   apiAnahtariService,
+		Policy:                    authz,
+		AuditSink:                 auditSink,
+		AdminPersonelKodlari:      cfg.Auth.AdminPersonelKodlari,
+		AuditorPersonelKodlari:    cfg.Auth.AuditorPersonelKodlari,
+		SupervisorPersonelKodlari: cfg.Auth.SupervisorPersonelKodlari,
+		NFCLimiter:                nfcLimiter,
+		Masking:                   maskingPolicy,
+	})
 
 	// Create HTTP server
 	serverAddr := fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port)
+	// Open event streams and the HL7 feed never finish on their own; they end when baseCtx is cancelled
+	baseCtx, cancelStreams := context.WithCancel(context.Background())
 	srv := &http.Server{
-		Addr:    serverAddr,
-		Handler: router,
+		Addr:        serverAddr,
+		Handler:     router,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
+	}
+
+	// HL7 v2 feed to the legacy monitoring and nurse-call systems. It must run on a single
+	// instance, as every instance would otherwise deliver the same queue.
+	if len(cfg.HL7.Endpoints) > 0 {
+		hedefler, err := hl7.ParseHedefler(cfg.HL7.Endpoints)
+		if err != nil {
+			log.Fatalf("Invalid HL7_ENDPOINTS: %v", err)
+		}
+		uretici := hl7.NewUretici(repository.NewHL7KaynakRepository(db), hl7MesajRepo, hedefler, hl7.Basliklar{
+			GonderenUygulama: cfg.HL7.SendingApplication,
+			GonderenKurum:    cfg.HL7.SendingFacility,
+			IslemeKodu:       cfg.HL7.ProcessingID,
+		}, func(sonuc *models.TetkikSonuc) *models.TetkikDegerlendirmesi {
+			return service.DegerlendirTetkikSonuc(sonuc, kritikEsikler)
+		}, stream.RealClock, cfg.HL7.PollInterval)
+		go uretici.Calistir(baseCtx)
+		for _, hedef := range hedefler {
+			gonderici := hl7.NewGonderici(hedef, hl7MesajRepo, hl7.Ayarlar{
+				PollInterval: cfg.HL7.PollInterval,
+				AckTimeout:   cfg.HL7.AckTimeout,
+				RetryBase:    cfg.HL7.RetryBase,
+				RetryMax:     cfg.HL7.RetryMax,
+				MaxDeneme:    cfg.HL7.MaxAttempts,
+			}, stream.RealClock)
+			go gonderici.Calistir(baseCtx)
+			log.Printf("HL7 feed delivering to %s at %s", hedef.Ad, hedef.Adres)
+		}
 	}
 
 	// Start server in a goroutine
@@ -136,6 +356,7 @@ func main() {
 	// Graceful shutdown with 5 second timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+	cancelStreams()
 
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Printf("Server forced to shutdown: %v", err)
@@ -0,0 +1,109 @@
+// Package allergy cross-checks prescribed drugs against the allergies recorded for a patient.
+//
+// VEM 2.0 only stores the barcode and the name of a prescribed drug and free text for an
+// allergy, so the check relies on a local mapping (Esleme): allergen groups with their
+// member ingredients and cross-reactivity, and optionally the ingredients of each product
+// keyed by barcode. The built-in mapping has the groups only; the product list is expected
+// to come from the hospital formulary through a mapping file.
+package allergy
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+//go:embed default_esleme.json
+var defaultEslemeJSON []byte
+
+// Grup is an allergen group such as penicillins
+type Grup struct {
+	// Terimler are the words that name the group in an allergy record
+	Terimler      []string `json:"terimler"`
+	EtkenMaddeler []string `json:"etken_maddeler"`
+	// CaprazReaksiyon lists the groups a patient allergic to this group may also react to
+	CaprazReaksiyon []string `json:"capraz_reaksiyon,omitempty"`
+}
+
+// Ilac is a product of the formulary
+type Ilac struct {
+	IlacAdi       string   `json:"ilac_adi"`
+	EtkenMaddeler []string `json:"etken_maddeler"`
+}
+
+// Esleme maps products (by barcode) to ingredients and ingredients to allergen groups
+type Esleme struct {
+	Gruplar map[string]Grup `json:"gruplar"`
+	Ilaclar map[string]Ilac `json:"ilaclar"`
+
+	// grupByEtkenMadde indexes the normalized ingredients of every group
+	grupByEtkenMadde map[string][]string
+	// grupAdlari are the group names in order, so checks are deterministic
+	grupAdlari []string
+}
+
+// Default returns the built-in mapping shipped with MedScreen
+func Default() *Esleme {
+	e, err := Parse(defaultEslemeJSON)
+	if err != nil {
+		panic("invalid built-in allergy mapping: " + err.Error())
+	}
+	return e
+}
+
+// Load reads a mapping from a JSON file; an empty path returns the built-in mapping
+func Load(path string) (*Esleme, error) {
+	if path == "" {
+		return Default(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read allergy mapping file: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates a JSON mapping
+func Parse(data []byte) (*Esleme, error) {
+	var e Esleme
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("failed to parse allergy mapping: %w", err)
+	}
+	if err := e.validate(); err != nil {
+		return nil, err
+	}
+
+	e.grupByEtkenMadde = make(map[string][]string)
+	for ad := range e.Gruplar {
+		e.grupAdlari = append(e.grupAdlari, ad)
+	}
+	sort.Strings(e.grupAdlari)
+	for _, ad := range e.grupAdlari {
+		for _, madde := range e.Gruplar[ad].EtkenMaddeler {
+			n := normalize(madde)
+			e.grupByEtkenMadde[n] = append(e.grupByEtkenMadde[n], ad)
+		}
+	}
+	return &e, nil
+}
+
+func (e *Esleme) validate() error {
+	for ad, grup := range e.Gruplar {
+		if normalize(ad) == "" {
+			return fmt.Errorf("allergen group with an empty name")
+		}
+		for _, diger := range grup.CaprazReaksiyon {
+			if _, ok := e.Gruplar[diger]; !ok {
+				return fmt.Errorf("group %q: unknown cross-reacting group %q", ad, diger)
+			}
+		}
+	}
+	for barkod, ilac := range e.Ilaclar {
+		if len(ilac.EtkenMaddeler) == 0 {
+			return fmt.Errorf("drug %s: no active ingredients", barkod)
+		}
+	}
+	return nil
+}
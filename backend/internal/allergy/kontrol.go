@@ -0,0 +1,251 @@
+package allergy
+
+import (
+	"fmt"
+	"medscreen/internal/models"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Siddet is the severity of a conflict
+type Siddet string
+
+// Severities, most severe first
+const (
+	SiddetYuksek Siddet = "YUKSEK" // the patient is allergic to an ingredient or its group
+	SiddetOrta   Siddet = "ORTA"   // cross-reactivity with a group the patient is allergic to
+	SiddetDusuk  Siddet = "DUSUK"  // the drug could not be mapped but its name appears in an allergy
+)
+
+// AtLeast reports whether s is at least as severe as other
+func (s Siddet) AtLeast(other Siddet) bool {
+	return s.derece() >= other.derece()
+}
+
+func (s Siddet) derece() int {
+	switch s {
+	case SiddetYuksek:
+		return 3
+	case SiddetOrta:
+		return 2
+	case SiddetDusuk:
+		return 1
+	}
+	return 0
+}
+
+// Uyumsuzluk is a conflict between a prescribed drug and a recorded allergy
+type Uyumsuzluk struct {
+	ReceteKodu          string `json:"recete_kodu"`
+	ReceteIlacKodu      string `json:"recete_ilac_kodu"`
+	Barkod              string `json:"barkod"`
+	IlacAdi             string `json:"ilac_adi,omitempty"`
+	HastaTibbiBilgiKodu string `json:"hasta_tibbi_bilgi_kodu"`
+	// Alerjen is the ingredient or group that caused the conflict
+	Alerjen string `json:"alerjen"`
+	Sebep   string `json:"sebep"`
+	Siddet  Siddet `json:"siddet"`
+}
+
+// Sonuc is the outcome of checking a drug
+type Sonuc struct {
+	Uyumsuzluklar []Uyumsuzluk
+	// Eslesti is false when the ingredients of the drug could not be determined, so
+	// only its name was compared against the allergies
+	Eslesti bool
+}
+
+// Kontrol checks a prescribed drug against the allergy records of a patient. Records of
+// other types are ignored. At most one conflict, the most severe, is reported per record.
+func (e *Esleme) Kontrol(ilac *models.ReceteIlac, alerjiler []models.HastaTibbiBilgi) Sonuc {
+	ilacAdi := ""
+	if ilac.IlacAdi != nil {
+		ilacAdi = *ilac.IlacAdi
+	}
+	maddeler := e.etkenMaddeler(ilac.Barkod, ilacAdi)
+	gruplar := e.gruplar(maddeler)
+	sonuc := Sonuc{Eslesti: len(maddeler) > 0}
+
+	for i := range alerjiler {
+		alerji := &alerjiler[i]
+		if alerji.TibbiBilgiTuruKodu != string(models.TibbiBilgiAlerji) {
+			continue
+		}
+		metin := alerjiMetni(alerji)
+		if strings.TrimSpace(metin) == "" {
+			continue
+		}
+
+		var en *Uyumsuzluk
+		aday := func(alerjen, sebep string, siddet Siddet) {
+			if en != nil && en.Siddet.AtLeast(siddet) {
+				return
+			}
+			en = &Uyumsuzluk{
+				ReceteKodu:          ilac.ReceteKodu,
+				ReceteIlacKodu:      ilac.ReceteIlacKodu,
+				Barkod:              ilac.Barkod,
+				IlacAdi:             ilacAdi,
+				HastaTibbiBilgiKodu: alerji.HastaTibbiBilgiKodu,
+				Alerjen:             alerjen,
+				Sebep:               sebep,
+				Siddet:              siddet,
+			}
+		}
+
+		for _, madde := range maddeler {
+			if icerir(metin, madde) {
+				aday(madde, fmt.Sprintf("Etken madde %s hastanın alerji kaydında geçiyor", madde), SiddetYuksek)
+			}
+		}
+		for _, grup := range gruplar {
+			if e.grupAdiGeciyor(metin, grup) {
+				aday(grup, fmt.Sprintf("İlaç, hastanın alerjisi olan %s grubunda", grup), SiddetYuksek)
+			}
+		}
+		for _, ad := range e.grupAdlari {
+			grup := e.Gruplar[ad]
+			if !e.grupAdiGeciyor(metin, ad) && !e.grupUyesiGeciyor(metin, grup) {
+				continue
+			}
+			for _, capraz := range grup.CaprazReaksiyon {
+				if contains(gruplar, capraz) {
+					aday(ad, fmt.Sprintf("İlaç %s grubunda; hastanın alerjisi olan %s grubu ile çapraz reaksiyon gösterebilir", capraz, ad), SiddetOrta)
+				}
+			}
+		}
+		if !sonuc.Eslesti {
+			if marka := ilkKelime(ilacAdi); marka != "" && icerir(metin, marka) {
+				aday(marka, fmt.Sprintf("İlacın etken maddeleri bilinmiyor; ilaç adı (%s) alerji kaydında geçiyor", marka), SiddetDusuk)
+			}
+		}
+
+		if en != nil {
+			sonuc.Uyumsuzluklar = append(sonuc.Uyumsuzluklar, *en)
+		}
+	}
+	return sonuc
+}
+
+// etkenMaddeler returns the ingredients of a product from the formulary, or the known
+// ingredients that appear in its name when the barcode is not mapped
+func (e *Esleme) etkenMaddeler(barkod, ilacAdi string) []string {
+	if ilac, ok := e.Ilaclar[barkod]; ok {
+		return ilac.EtkenMaddeler
+	}
+	var maddeler []string
+	for madde := range e.grupByEtkenMadde {
+		if icerir(ilacAdi, madde) {
+			maddeler = append(maddeler, madde)
+		}
+	}
+	sort.Strings(maddeler)
+	return maddeler
+}
+
+// gruplar returns the allergen groups of the given ingredients
+func (e *Esleme) gruplar(maddeler []string) []string {
+	var gruplar []string
+	for _, madde := range maddeler {
+		for _, grup := range e.grupByEtkenMadde[normalize(madde)] {
+			if !contains(gruplar, grup) {
+				gruplar = append(gruplar, grup)
+			}
+		}
+	}
+	sort.Strings(gruplar)
+	return gruplar
+}
+
+func (e *Esleme) grupAdiGeciyor(metin, ad string) bool {
+	if icerir(metin, ad) {
+		return true
+	}
+	for _, terim := range e.Gruplar[ad].Terimler {
+		if icerir(metin, terim) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Esleme) grupUyesiGeciyor(metin string, grup Grup) bool {
+	for _, madde := range grup.EtkenMaddeler {
+		if icerir(metin, madde) {
+			return true
+		}
+	}
+	return false
+}
+
+// alerjiMetni joins the coded and free-text parts of an allergy record
+func alerjiMetni(alerji *models.HastaTibbiBilgi) string {
+	var parcalar []string
+	if alerji.TibbiBilgiAltTuruKodu != nil {
+		parcalar = append(parcalar, *alerji.TibbiBilgiAltTuruKodu)
+	}
+	if alerji.Aciklama != nil {
+		parcalar = append(parcalar, *alerji.Aciklama)
+	}
+	return strings.Join(parcalar, " ")
+}
+
+// icerir reports whether terim occurs in metin as whole words, ignoring case and
+// Turkish diacritics; Turkish suffixes are allowed ("penisiline", "amoksisiline")
+func icerir(metin, terim string) bool {
+	t := normalize(terim)
+	if t == "" {
+		return false
+	}
+	m := " " + normalize(metin) + " "
+	return strings.Contains(m, " "+t)
+}
+
+// normalize lowercases, folds Turkish letters to ASCII and collapses punctuation to spaces
+func normalize(s string) string {
+	var b strings.Builder
+	bosluk := true
+	for _, r := range s {
+		switch r {
+		case 'İ', 'I', 'ı':
+			r = 'i'
+		case 'Ş', 'ş':
+			r = 's'
+		case 'Ğ', 'ğ':
+			r = 'g'
+		case 'Ü', 'ü':
+			r = 'u'
+		case 'Ö', 'ö':
+			r = 'o'
+		case 'Ç', 'ç':
+			r = 'c'
+		}
+		r = unicode.ToLower(r)
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			bosluk = false
+		} else if !bosluk {
+			b.WriteRune(' ')
+			bosluk = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func ilkKelime(s string) string {
+	alanlar := strings.Fields(normalize(s))
+	if len(alanlar) == 0 || len(alanlar[0]) < 4 {
+		return ""
+	}
+	return alanlar[0]
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
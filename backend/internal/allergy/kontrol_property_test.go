@@ -0,0 +1,104 @@
+package allergy
+
+import (
+	"medscreen/internal/models"
+	"strings"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// Feature: drug-allergy-check, Property 1: Allergens Are Found However They Are Written
+// *For any* drug whose name contains a known ingredient and any allergy record naming
+// that ingredient or its group in upper or lower case, with or without Turkish letters
+// and suffixes, the check SHALL report a YUKSEK conflict; an allergy to a cross-reacting
+// group SHALL be reported as ORTA and an unrelated allergy SHALL NOT be reported.
+
+func alerjiKaydi(kodu, turu, aciklama string) models.HastaTibbiBilgi {
+	return models.HastaTibbiBilgi{HastaTibbiBilgiKodu: kodu, TibbiBilgiTuruKodu: turu, Aciklama: &aciklama}
+}
+
+func receteIlaci(barkod, ad string) *models.ReceteIlac {
+	return &models.ReceteIlac{ReceteKodu: "R1", ReceteIlacKodu: "RI1", Barkod: barkod, IlacAdi: &ad}
+}
+
+// yaz writes s the way it might be typed on the ward
+func yaz(t *rapid.T, s string) string {
+	switch rapid.IntRange(0, 2).Draw(t, "yazim") {
+	case 1:
+		return strings.ToUpper(s)
+	case 2:
+		return strings.NewReplacer("s", "ş", "i", "ı", "c", "ç").Replace(s)
+	}
+	return s
+}
+
+// TestProperty_AllergensFoundHoweverWritten tests Property 1
+func TestProperty_AllergensFoundHoweverWritten(t *testing.T) {
+	e := Default()
+	rapid.Check(t, func(rt *rapid.T) {
+		grupAdi := rapid.SampledFrom(e.grupAdlari).Draw(rt, "grup")
+		grup := e.Gruplar[grupAdi]
+		madde := rapid.SampledFrom(grup.EtkenMaddeler).Draw(rt, "madde")
+		ilac := receteIlaci("0000000000000", strings.ToUpper(madde)+" 500 MG FILM TABLET")
+
+		var alerjen string
+		switch rapid.IntRange(0, 2).Draw(rt, "alerjen") {
+		case 0:
+			alerjen = madde
+		case 1:
+			alerjen = grupAdi
+		default:
+			alerjen = rapid.SampledFrom(grup.Terimler).Draw(rt, "terim")
+		}
+		metin := yaz(rt, alerjen) + rapid.SampledFrom([]string{"", "e", " alerjisi", "'e karşı döküntü"}).Draw(rt, "ek")
+
+		sonuc := e.Kontrol(ilac, []models.HastaTibbiBilgi{
+			alerjiKaydi("A1", string(models.TibbiBilgiAlerji), metin),
+			alerjiKaydi("A2", string(models.TibbiBilgiAmeliyat), metin),
+			alerjiKaydi("A3", string(models.TibbiBilgiAlerji), "polen"),
+		})
+		if !sonuc.Eslesti {
+			rt.Fatalf("Ingredient %s not found in %q", madde, *ilac.IlacAdi)
+		}
+		if len(sonuc.Uyumsuzluklar) != 1 || sonuc.Uyumsuzluklar[0].HastaTibbiBilgiKodu != "A1" || sonuc.Uyumsuzluklar[0].Siddet != SiddetYuksek {
+			rt.Fatalf("Expected one YUKSEK conflict with A1 for %s against %q, got %+v", madde, metin, sonuc.Uyumsuzluklar)
+		}
+
+		for _, capraz := range grup.CaprazReaksiyon {
+			sonuc := e.Kontrol(ilac, []models.HastaTibbiBilgi{alerjiKaydi("A4", string(models.TibbiBilgiAlerji), yaz(rt, capraz))})
+			if len(sonuc.Uyumsuzluklar) != 1 || sonuc.Uyumsuzluklar[0].Siddet != SiddetOrta || sonuc.Uyumsuzluklar[0].Alerjen != capraz {
+				rt.Fatalf("Expected an ORTA cross-reaction of %s with %s, got %+v", madde, capraz, sonuc.Uyumsuzluklar)
+			}
+		}
+	})
+}
+
+// TestKontrol_FormularyAndUnmappedDrugs verifies barcode mappings, name-only matches and validation
+func TestKontrol_FormularyAndUnmappedDrugs(t *testing.T) {
+	e, err := Parse([]byte(`{
+		"gruplar": {"penisilin": {"terimler": ["penisilin"], "etken_maddeler": ["amoksisilin"]}},
+		"ilaclar": {"8690000000001": {"ilac_adi": "Klavamoks", "etken_maddeler": ["amoksisilin", "klavulanik asit"]}}
+	}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	alerjiler := []models.HastaTibbiBilgi{alerjiKaydi("A1", string(models.TibbiBilgiAlerji), "Penisilin - anafilaksi öyküsü"), alerjiKaydi("A2", string(models.TibbiBilgiAlerji), "Majezik kullanınca kurdeşen")}
+
+	sonuc := e.Kontrol(receteIlaci("8690000000001", "KLAVAMOKS BID 1000 MG"), alerjiler)
+	if !sonuc.Eslesti || len(sonuc.Uyumsuzluklar) != 1 || sonuc.Uyumsuzluklar[0].Alerjen != "penisilin" {
+		t.Errorf("Expected a group conflict through the barcode mapping, got %+v", sonuc)
+	}
+
+	sonuc = e.Kontrol(receteIlaci("8690000000099", "MAJEZIK 100 MG"), alerjiler)
+	if sonuc.Eslesti || len(sonuc.Uyumsuzluklar) != 1 || sonuc.Uyumsuzluklar[0].Siddet != SiddetDusuk || sonuc.Uyumsuzluklar[0].HastaTibbiBilgiKodu != "A2" {
+		t.Errorf("Expected a DUSUK name match for an unmapped drug, got %+v", sonuc)
+	}
+
+	if _, err := Parse([]byte(`{"gruplar": {"penisilin": {"capraz_reaksiyon": ["yok"]}}}`)); err == nil {
+		t.Error("Expected an error for an unknown cross-reacting group")
+	}
+	if _, err := Parse([]byte(`{"ilaclar": {"1": {"ilac_adi": "X"}}}`)); err == nil {
+		t.Error("Expected an error for a drug without ingredients")
+	}
+}
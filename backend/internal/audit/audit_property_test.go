@@ -0,0 +1,335 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"medscreen/internal/middleware"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"medscreen/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"pgregory.net/rapid"
+)
+
+// Feature: kvkk-audit, Property 1: Every Patient Read Is Recorded
+// *For any* protected GET returning patient records, the audit sink SHALL contain one
+// record per distinct patient with the caller, route, tablet and result count, and the
+// response SHALL NOT be sent if the access could not be recorded.
+
+// failingSink rejects every write
+type failingSink struct{}
+
+func (failingSink) Create([]models.ErisimKaydi) error { return errors.New("disk full") }
+
+func (failingSink) FindByFilter(repository.ErisimKaydiFiltresi, int, int) ([]models.ErisimKaydi, int64, error) {
+	return nil, 0, nil
+}
+
+// setupAuditRouter builds a router that authenticates every request as P000001 on TBL1
+func setupAuditRouter(sink repository.ErisimKaydiRepository, data func(c *gin.Context) interface{}) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	fakeAuth := func(c *gin.Context) {
+		c.Set(middleware.ContextKeyPersonelKodu, "P000001")
+		c.Set(middleware.ContextKeyUserRole, "HEKIM")
+		c.Set(middleware.ContextKeyTabletCihazKodu, "TBL1")
+		c.Next()
+	}
+
+	protected := router.Group("/api/v1", fakeAuth, Middleware(sink))
+	protected.GET("/anlik-yatan-hasta/birim/:birim_kodu", func(c *gin.Context) {
+		utils.SendSuccessResponse(c, http.StatusOK, "OK", "ok", data(c))
+	})
+	protected.GET("/hasta/:kodu", func(c *gin.Context) {
+		utils.SendErrorResponse(c, http.StatusNotFound, "HASTA_NOT_FOUND", "Patient not found", nil)
+	})
+	return router
+}
+
+func newTestFileSink(t interface {
+	Fatalf(string, ...any)
+	TempDir() string
+}) repository.ErisimKaydiRepository {
+	sink, err := NewFileSink(filepath.Join(t.TempDir(), "audit", "erisim.jsonl"))
+	if err != nil {
+		t.Fatalf("Failed to open file sink: %v", err)
+	}
+	return sink
+}
+
+// TestProperty_EveryPatientReadIsRecorded tests Property 1
+func TestProperty_EveryPatientReadIsRecorded(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		hastalar := rapid.SliceOfN(rapid.SampledFrom([]string{"H1", "H2", "H3", "H4"}), 0, 8).Draw(rt, "hastalar")
+
+		sink := newTestFileSink(t)
+		router := setupAuditRouter(sink, func(c *gin.Context) interface{} {
+			items := make([]gin.H, 0, len(hastalar))
+			for i, h := range hastalar {
+				items = append(items, gin.H{
+					"anlik_yatan_hasta_kodu": fmt.Sprintf("AYH%d", i),
+					"hasta_kodu":             h,
+					"hasta_basvuru_kodu":     "B-" + h,
+					"hasta":                  gin.H{"hasta_kodu": h, "ad": "Test"},
+				})
+			}
+			return items
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/anlik-yatan-hasta/birim/DAHILIYE", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		if resp.Code != http.StatusOK {
+			rt.Fatalf("Expected 200, got %d", resp.Code)
+		}
+
+		beklenen := make(map[string]bool)
+		for _, h := range hastalar {
+			beklenen[h] = true
+		}
+
+		kayitlar, total, err := sink.FindByFilter(repository.ErisimKaydiFiltresi{PersonelKodu: "P000001"}, 1, 100)
+		if err != nil {
+			rt.Fatalf("Failed to query sink: %v", err)
+		}
+		if len(beklenen) == 0 {
+			// No patient in the result still leaves one record of the access
+			if total != 1 || kayitlar[0].HastaKodu != nil {
+				rt.Fatalf("Expected a single record without patient, got %d", total)
+			}
+			return
+		}
+		if int(total) != len(beklenen) {
+			rt.Fatalf("Expected %d records, got %d", len(beklenen), total)
+		}
+		for _, k := range kayitlar {
+			if k.HastaKodu == nil || !beklenen[*k.HastaKodu] {
+				rt.Fatalf("Unexpected patient in audit record: %+v", k)
+			}
+			if k.HastaBasvuruKodu == nil || *k.HastaBasvuruKodu != "B-"+*k.HastaKodu {
+				rt.Fatalf("Visit code not resolved: %+v", k)
+			}
+			if k.SonucSayisi != len(hastalar) || k.Rota != "/api/v1/anlik-yatan-hasta/birim/:birim_kodu" ||
+				k.TabletCihazKodu == nil || *k.TabletCihazKodu != "TBL1" || k.IstekKodu != kayitlar[0].IstekKodu {
+				rt.Fatalf("Unexpected audit record: %+v", k)
+			}
+		}
+	})
+}
+
+// TestAudit_FailedRequestsUseRouteParams verifies that denied or failed reads are still attributed
+func TestAudit_FailedRequestsUseRouteParams(t *testing.T) {
+	sink := newTestFileSink(t)
+	router := setupAuditRouter(sink, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/hasta/H9", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404, got %d", resp.Code)
+	}
+
+	kayitlar, total, err := sink.FindByFilter(repository.ErisimKaydiFiltresi{HastaKodu: "H9"}, 1, 10)
+	if err != nil || total != 1 {
+		t.Fatalf("Expected one record for H9, got %d (%v)", total, err)
+	}
+	if kayitlar[0].DurumKodu != http.StatusNotFound || kayitlar[0].SonucSayisi != 0 {
+		t.Errorf("Unexpected audit record: %+v", kayitlar[0])
+	}
+}
+
+// TestAudit_FHIRReadsAreRecorded verifies that patients are resolved from FHIR resources,
+// search Bundles and, for failed reads, the FHIR route and search parameters
+func TestAudit_FHIRReadsAreRecorded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sink := newTestFileSink(t)
+	router := gin.New()
+	fakeAuth := func(c *gin.Context) {
+		c.Set(middleware.ContextKeyPersonelKodu, "P000001")
+		c.Next()
+	}
+	observation := func(id, hasta, basvuru string) gin.H {
+		return gin.H{"resource": gin.H{
+			"resourceType": "Observation", "id": id,
+			"subject":   gin.H{"reference": "Patient/" + hasta},
+			"encounter": gin.H{"reference": "Encounter/" + basvuru},
+			"performer": []gin.H{{"reference": "Practitioner/N1"}},
+		}}
+	}
+	fhirGroup := router.Group("/fhir/R4", fakeAuth, Middleware(sink))
+	fhirGroup.GET("/Observation", func(c *gin.Context) {
+		if c.Query("patient") == "Patient/H3" {
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "Denied", nil)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"resourceType": "Bundle", "type": "searchset", "entry": []gin.H{
+			observation("V1-heart-rate", "H1", "B1"), observation("V1-body-temperature", "H1", "B1"), observation("lab-T1", "H2", "B2"),
+		}})
+	})
+	fhirGroup.GET("/Encounter/:id", func(c *gin.Context) {
+		utils.SendErrorResponse(c, http.StatusNotFound, "HASTA_BASVURU_NOT_FOUND", "Encounter not found", nil)
+	})
+
+	for _, path := range []string{"/fhir/R4/Observation?category=vital-signs", "/fhir/R4/Observation?patient=Patient/H3", "/fhir/R4/Encounter/B9"} {
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	kayitlar, total, err := sink.FindByFilter(repository.ErisimKaydiFiltresi{PersonelKodu: "P000001"}, 1, 10)
+	if err != nil || total != 4 {
+		t.Fatalf("Expected 4 records, got %d (%v)", total, err)
+	}
+	found := make(map[string]int)
+	for _, k := range kayitlar {
+		var hasta, basvuru string
+		if k.HastaKodu != nil {
+			hasta = *k.HastaKodu
+		}
+		if k.HastaBasvuruKodu != nil {
+			basvuru = *k.HastaBasvuruKodu
+		}
+		found[hasta+"/"+basvuru] = k.SonucSayisi
+	}
+	for ref, sonucSayisi := range map[string]int{"H1/B1": 3, "H2/B2": 3, "H3/": 0, "/B9": 0} {
+		if n, ok := found[ref]; !ok || n != sonucSayisi {
+			t.Errorf("Expected a record of %s with %d results, got %v", ref, sonucSayisi, found)
+		}
+	}
+}
+
+// TestAudit_SinkFailureWithholdsData verifies that data is not sent when the access cannot be recorded
+func TestAudit_SinkFailureWithholdsData(t *testing.T) {
+	router := setupAuditRouter(failingSink{}, func(c *gin.Context) interface{} {
+		return []gin.H{{"hasta_kodu": "SECRET-PATIENT"}}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/anlik-yatan-hasta/birim/DAHILIYE", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500, got %d", resp.Code)
+	}
+	if strings.Contains(resp.Body.String(), "SECRET-PATIENT") {
+		t.Error("Patient data leaked although the access was not recorded")
+	}
+}
+
+// TestAudit_FileSinkPagination verifies newest-first paging of the file sink
+func TestAudit_FileSinkPagination(t *testing.T) {
+	sink := newTestFileSink(t)
+	hastaKodu := "H1"
+	for i := 0; i < 5; i++ {
+		if err := sink.Create([]models.ErisimKaydi{{IstekKodu: fmt.Sprint(i), PersonelKodu: "P1", HastaKodu: &hastaKodu}}); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+	}
+
+	sayfa, total, err := sink.FindByFilter(repository.ErisimKaydiFiltresi{HastaKodu: "H1"}, 2, 2)
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	if total != 5 || len(sayfa) != 2 || sayfa[0].IstekKodu != "2" || sayfa[1].IstekKodu != "1" {
+		t.Errorf("Unexpected page: total=%d %+v", total, sayfa)
+	}
+
+	sayfa, _, _ = sink.FindByFilter(repository.ErisimKaydiFiltresi{HastaKodu: "H1"}, 4, 2)
+	if len(sayfa) != 0 {
+		t.Errorf("Expected an empty page past the end, got %d records", len(sayfa))
+	}
+}
+
+// TestAudit_StreamsRecordEachBatch verifies that stream routes bypass the buffer and
+// record the patients of each batch through RecordStream
+func TestAudit_StreamsRecordEachBatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sink := newTestFileSink(t)
+	router := gin.New()
+	router.GET("/api/v1/stream/birim/:birim_kodu", Middleware(sink, "/api/v1/stream/"), func(c *gin.Context) {
+		if err := RecordStream(c, sink, nil); err != nil {
+			t.Fatalf("Failed to record stream: %v", err)
+		}
+		c.Status(http.StatusOK)
+		c.Writer.WriteString("retry: 5000\n\n")
+		c.Writer.Flush()
+		if err := RecordStream(c, sink, []gin.H{{"hasta_basvuru_kodu": "B1"}, {"hasta_basvuru_kodu": "B2"}}); err != nil {
+			t.Fatalf("Failed to record batch: %v", err)
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/stream/birim/DAHILIYE", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if !resp.Flushed || resp.Body.String() != "retry: 5000\n\n" {
+		t.Fatalf("Expected the stream to be written through, got %q", resp.Body.String())
+	}
+
+	_, total, err := sink.FindByFilter(repository.ErisimKaydiFiltresi{}, 1, 10)
+	if err != nil || total != 3 {
+		t.Fatalf("Expected the opening and one record per visit, got %d (%v)", total, err)
+	}
+	if _, total, _ := sink.FindByFilter(repository.ErisimKaydiFiltresi{HastaBasvuruKodu: "B2"}, 1, 10); total != 1 {
+		t.Errorf("Expected one record for B2, got %d", total)
+	}
+}
+
+// stubBasvurular resolves the test visits B1 and B2 to their patients
+type stubBasvurular struct {
+	repository.HastaBasvuruRepository
+	sorgular int
+}
+
+func (s *stubBasvurular) FindByKodu(kodu string) (*models.HastaBasvuru, error) {
+	s.sorgular++
+	hastalar := map[string]string{"B1": "H1", "B2": "H2"}
+	if hastaKodu, ok := hastalar[kodu]; ok {
+		return &models.HastaBasvuru{HastaBasvuruKodu: kodu, HastaKodu: hastaKodu}, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// TestAudit_VisitOnlyRecordsFindPatient verifies that records naming only a visit are
+// written with the visit's patient, so per-patient queries find them
+func TestAudit_VisitOnlyRecordsFindPatient(t *testing.T) {
+	basvurular := &stubBasvurular{}
+	sink := WithHastaKodu(newTestFileSink(t), basvurular)
+	router := setupAuditRouter(sink, func(c *gin.Context) interface{} {
+		return []gin.H{
+			{"hasta_basvuru_kodu": "B1", "klinik_seyir_kodu": "KS1"},
+			{"hasta_basvuru_kodu": "B1", "klinik_seyir_kodu": "KS2", "hasta_kodu": "H1"},
+			{"hasta_basvuru_kodu": "B2", "klinik_seyir_kodu": "KS3"},
+			{"hasta_basvuru_kodu": "B9", "klinik_seyir_kodu": "KS4"},
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/anlik-yatan-hasta/birim/DAHILIYE", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", resp.Code)
+	}
+
+	for _, tc := range []struct {
+		hastaKodu string
+		want      int64
+	}{{"H1", 2}, {"H2", 1}} {
+		if _, total, _ := sink.FindByFilter(repository.ErisimKaydiFiltresi{HastaKodu: tc.hastaKodu}, 1, 10); total != tc.want {
+			t.Errorf("Expected %d records for %s, got %d", tc.want, tc.hastaKodu, total)
+		}
+	}
+	kayitlar, _, _ := sink.FindByFilter(repository.ErisimKaydiFiltresi{HastaBasvuruKodu: "B9"}, 1, 10)
+	if len(kayitlar) != 1 || kayitlar[0].HastaKodu != nil {
+		t.Errorf("Expected the unknown visit to be recorded without a patient, got %+v", kayitlar)
+	}
+	if basvurular.sorgular != 3 {
+		t.Errorf("Expected one lookup per visit without a patient, got %d", basvurular.sorgular)
+	}
+}
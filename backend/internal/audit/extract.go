@@ -0,0 +1,165 @@
+package audit
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// hastaRef identifies the patient and visit a returned record belongs to
+type hastaRef struct {
+	hastaKodu   string
+	basvuruKodu string
+}
+
+// extractRefs reads a standard success response, or a FHIR resource or search Bundle, and
+// returns the distinct patients it contains and the number of records in data (entries)
+func extractRefs(body []byte) ([]hastaRef, int) {
+	var resp map[string]interface{}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, 0
+	}
+
+	var items []interface{}
+	refOf := findRef
+	if data := resp["data"]; data != nil {
+		switch data := data.(type) {
+		case []interface{}:
+			items = data
+		default:
+			items = []interface{}{data}
+		}
+	} else if resourceType, _ := resp["resourceType"].(string); resourceType == "Bundle" {
+		entries, _ := resp["entry"].([]interface{})
+		for _, entry := range entries {
+			if entry, ok := entry.(map[string]interface{}); ok {
+				items = append(items, entry["resource"])
+			}
+		}
+		refOf = fhirRef
+	} else if resourceType != "" {
+		items = []interface{}{resp}
+		refOf = fhirRef
+	} else {
+		return nil, 0
+	}
+
+	seen := make(map[hastaRef]bool)
+	var refs []hastaRef
+	for _, item := range items {
+		ref := refOf(item)
+		if ref == (hastaRef{}) || seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		refs = append(refs, ref)
+	}
+	return refs, len(items)
+}
+
+// fhirRef resolves the patient of a FHIR resource from its id (Patient, Encounter) or from
+// its subject, patient and encounter references
+func fhirRef(item interface{}) hastaRef {
+	resource, _ := item.(map[string]interface{})
+	var ref hastaRef
+	id, _ := resource["id"].(string)
+	switch resource["resourceType"] {
+	case "Patient":
+		ref.hastaKodu = id
+	case "Encounter":
+		ref.basvuruKodu = id
+	}
+	for _, name := range []string{"subject", "patient"} {
+		if ref.hastaKodu == "" {
+			ref.hastaKodu = referenceID(resource[name], "Patient")
+		}
+	}
+	if ref.basvuruKodu == "" {
+		ref.basvuruKodu = referenceID(resource["encounter"], "Encounter")
+	}
+	return ref
+}
+
+// referenceID returns the id of a FHIR reference to a resource of the given type
+func referenceID(v interface{}, resourceType string) string {
+	reference, _ := v.(map[string]interface{})
+	s, _ := reference["reference"].(string)
+	id, found := strings.CutPrefix(s, resourceType+"/")
+	if !found {
+		return ""
+	}
+	return id
+}
+
+// findRef searches a record breadth-first, so the record's own codes win over nested ones
+func findRef(item interface{}) hastaRef {
+	var ref hastaRef
+	queue := []interface{}{item}
+	for len(queue) > 0 && (ref.hastaKodu == "" || ref.basvuruKodu == "") {
+		node := queue[0]
+		queue = queue[1:]
+
+		switch v := node.(type) {
+		case map[string]interface{}:
+			if s, ok := v["hasta_kodu"].(string); ok && ref.hastaKodu == "" {
+				ref.hastaKodu = s
+			}
+			if s, ok := v["hasta_basvuru_kodu"].(string); ok && ref.basvuruKodu == "" {
+				ref.basvuruKodu = s
+			}
+			for _, child := range v {
+				switch child.(type) {
+				case map[string]interface{}, []interface{}:
+					queue = append(queue, child)
+				}
+			}
+		case []interface{}:
+			queue = append(queue, v...)
+		}
+	}
+	return ref
+}
+
+// paramRef resolves the patient from route parameters, for requests that returned no data
+// (denied, not found or failed)
+func paramRef(c *gin.Context) hastaRef {
+	ref := hastaRef{
+		hastaKodu:   c.Param("hasta_kodu"),
+		basvuruKodu: c.Param("basvuru_kodu"),
+	}
+
+	if strings.HasPrefix(c.FullPath(), "/fhir/R4/") {
+		return fhirParamRef(c, ref)
+	}
+
+	kodu := c.Param("kodu")
+	if kodu == "" {
+		return ref
+	}
+	switch route := c.FullPath(); {
+	case strings.HasPrefix(route, "/api/v1/hasta/"):
+		ref.hastaKodu = kodu
+	case strings.HasPrefix(route, "/api/v1/hasta-basvuru/"):
+		ref.basvuruKodu = kodu
+	}
+	return ref
+}
+
+// fhirParamRef resolves the patient of a FHIR request from the id it read or from the
+// patient= and encounter= of its search
+func fhirParamRef(c *gin.Context, ref hastaRef) hastaRef {
+	switch route := c.FullPath(); {
+	case strings.HasPrefix(route, "/fhir/R4/Patient/"):
+		ref.hastaKodu = c.Param("id")
+	case strings.HasPrefix(route, "/fhir/R4/Encounter/"):
+		ref.basvuruKodu = c.Param("id")
+	}
+	if ref.hastaKodu == "" {
+		ref.hastaKodu = strings.TrimPrefix(c.Query("patient"), "Patient/")
+	}
+	if ref.basvuruKodu == "" {
+		ref.basvuruKodu = strings.TrimPrefix(c.Query("encounter"), "Encounter/")
+	}
+	return ref
+}
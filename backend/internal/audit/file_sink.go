@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// fileSink appends access records as JSON lines to a local file.
+// The file is opened append-only and is never rewritten.
+type fileSink struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// NewFileSink opens (or creates) an append-only JSON lines audit file
+func NewFileSink(path string) (repository.ErisimKaydiRepository, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create audit directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	return &fileSink{path: path, file: file}, nil
+}
+
+// Create appends the records of a single request with one write
+func (s *fileSink) Create(kayitlar []models.ErisimKaydi) error {
+	if len(kayitlar) == 0 {
+		return nil
+	}
+
+	var buf []byte
+	for i := range kayitlar {
+		line, err := json.Marshal(&kayitlar[i])
+		if err != nil {
+			return err
+		}
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.file.Write(buf)
+	return err
+}
+
+// FindByFilter scans the file and returns matching records, newest first, with pagination
+func (s *fileSink) FindByFilter(filtre repository.ErisimKaydiFiltresi, page, limit int) ([]models.ErisimKaydi, int64, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	var eslesenler []models.ErisimKaydi
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var kayit models.ErisimKaydi
+		if err := json.Unmarshal(scanner.Bytes(), &kayit); err != nil {
+			// A torn last line after a crash must not hide the rest of the trail
+			continue
+		}
+		if filtre.Matches(&kayit) {
+			eslesenler = append(eslesenler, kayit)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	total := int64(len(eslesenler))
+	offset := (page - 1) * limit
+	if offset >= len(eslesenler) {
+		return []models.ErisimKaydi{}, total, nil
+	}
+
+	// The file is in chronological order; return newest first like the database sink
+	end := len(eslesenler) - offset
+	start := end - limit
+	if start < 0 {
+		start = 0
+	}
+	sayfa := make([]models.ErisimKaydi, 0, end-start)
+	for i := end - 1; i >= start; i-- {
+		sayfa = append(sayfa, eslesenler[i])
+	}
+	return sayfa, total, nil
+}
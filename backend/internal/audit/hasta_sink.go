@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"errors"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// hastaSink completes records that only name a visit with the visit's patient, so that
+// per-patient queries (GET /erisim-kaydi/hasta/:hasta_kodu) find every access
+type hastaSink struct {
+	repository.ErisimKaydiRepository
+	basvurular repository.HastaBasvuruRepository
+}
+
+// WithHastaKodu wraps a sink so that records with a hasta_basvuru_kodu but no hasta_kodu
+// are written with the patient of the visit. A failed lookup fails the write, as any other
+// sink error does.
+func WithHastaKodu(sink repository.ErisimKaydiRepository, basvurular repository.HastaBasvuruRepository) repository.ErisimKaydiRepository {
+	return &hastaSink{ErisimKaydiRepository: sink, basvurular: basvurular}
+}
+
+// Create looks each visit up once per request before writing the records
+func (s *hastaSink) Create(kayitlar []models.ErisimKaydi) error {
+	hastalar := make(map[string]string)
+	for i := range kayitlar {
+		kayit := &kayitlar[i]
+		if kayit.HastaKodu != nil || kayit.HastaBasvuruKodu == nil {
+			continue
+		}
+
+		basvuruKodu := *kayit.HastaBasvuruKodu
+		hastaKodu, ok := hastalar[basvuruKodu]
+		if !ok {
+			basvuru, err := s.basvurular.FindByKodu(basvuruKodu)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if basvuru != nil {
+				hastaKodu = basvuru.HastaKodu
+			}
+			hastalar[basvuruKodu] = hastaKodu
+		}
+		if hastaKodu != "" {
+			kayit.HastaKodu = &hastaKodu
+		}
+	}
+	return s.ErisimKaydiRepository.Create(kayitlar)
+}
@@ -0,0 +1,174 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"medscreen/internal/constants"
+	"medscreen/internal/middleware"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"medscreen/internal/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bufferedWriter holds the response back until the access has been recorded
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body    bytes.Buffer
+	status  int
+	written bool
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+	w.written = true
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
+
+// Middleware records every protected GET in the audit sink (KVKK access log).
+// It must run after middleware.AuthMiddleware. The response is only sent once the
+// access has been recorded; if the sink fails the caller gets an error instead of the data.
+//
+// Responses under streamPrefixes are long-lived and cannot be buffered; their handlers
+// record each batch with RecordStream before writing it.
+func Middleware(sink repository.ErisimKaydiRepository, streamPrefixes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+		for _, prefix := range streamPrefixes {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		original := c.Writer
+		buffered := &bufferedWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = buffered
+
+		c.Next()
+
+		c.Writer = original
+		kayitlar, err := buildKayitlar(c, buffered.status, buffered.body.Bytes())
+		if err == nil {
+			err = sink.Create(kayitlar)
+		}
+		if err != nil {
+			log.Printf("Audit: failed to record access to %s: %v", c.Request.URL.Path, err)
+			original.Header().Del("Content-Length")
+			utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_AUDIT_FAILED, "Access could not be recorded", nil)
+			return
+		}
+
+		original.WriteHeader(buffered.status)
+		if buffered.body.Len() > 0 {
+			_, _ = original.Write(buffered.body.Bytes())
+		}
+	}
+}
+
+// RecordStream records the patients in data before a streaming handler sends it.
+// A nil data records the opening of the stream against its route parameters.
+func RecordStream(c *gin.Context, sink repository.ErisimKaydiRepository, data interface{}) error {
+	var body []byte
+	if data != nil {
+		var err error
+		if body, err = json.Marshal(gin.H{"data": data}); err != nil {
+			return err
+		}
+	}
+	kayitlar, err := buildKayitlar(c, http.StatusOK, body)
+	if err != nil {
+		return err
+	}
+	return sink.Create(kayitlar)
+}
+
+// buildKayitlar creates one record per patient in the response, or a single record
+// when no patient could be resolved
+func buildKayitlar(c *gin.Context, status int, body []byte) ([]models.ErisimKaydi, error) {
+	istekKodu, err := utils.NewRandomID()
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []hastaRef
+	sonucSayisi := 0
+	if status >= 200 && status < 300 {
+		refs, sonucSayisi = extractRefs(body)
+	}
+	if len(refs) == 0 {
+		refs = []hastaRef{paramRef(c)}
+	}
+
+	route := c.FullPath()
+	if route == "" {
+		route = c.Request.URL.Path
+	}
+
+	base := models.ErisimKaydi{
+		IstekKodu:    istekKodu,
+		ErisimZamani: time.Now(),
+		PersonelKodu: c.GetString(middleware.ContextKeyPersonelKodu),
+		PersonelRolu: c.GetString(middleware.ContextKeyUserRole),
+		IPAdresi:     c.ClientIP(),
+		Metot:        c.Request.Method,
+		Rota:         route,
+		Yol:          c.Request.URL.RequestURI(),
+		DurumKodu:    status,
+		SonucSayisi:  sonucSayisi,
+	}
+	if tablet := c.GetString(middleware.ContextKeyTabletCihazKodu); tablet != "" {
+		base.TabletCihazKodu = &tablet
+	}
+	if acilErisim := c.GetString(middleware.ContextKeyAcilErisimKodu); acilErisim != "" {
+		base.AcilErisimKodu = &acilErisim
+	}
+
+	kayitlar := make([]models.ErisimKaydi, 0, len(refs))
+	for _, ref := range refs {
+		kayit := base
+		if ref.hastaKodu != "" {
+			hastaKodu := ref.hastaKodu
+			kayit.HastaKodu = &hastaKodu
+		}
+		if ref.basvuruKodu != "" {
+			basvuruKodu := ref.basvuruKodu
+			kayit.HastaBasvuruKodu = &basvuruKodu
+		}
+		kayitlar = append(kayitlar, kayit)
+	}
+	return kayitlar, nil
+}
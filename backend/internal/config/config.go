@@ -1,9 +1,12 @@
 package config
 
 import (
+	"errors"
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,12 +16,25 @@ type Config struct {
 	Database DatabaseConfig
 	CORS     CORSConfig
 	JWT      JWTConfig
+	Auth     AuthConfig
+	Audit    AuditConfig
+	Stream   StreamConfig
+	Mar      MarConfig
+	HL7      HL7Config
+	Allergy  AllergyConfig
+	Lab      LabConfig
+	Device   DeviceConfig
+	NFCLimit RateLimitConfig
+	OIDC     OIDCConfig
 }
 
 type ServerConfig struct {
 	Host    string
 	Port    string
 	GinMode string
+	// TrustedProxies are the proxies whose X-Forwarded-For header is trusted for the client IP;
+	// empty trusts none
+	TrustedProxies []string
 }
 
 type DatabaseConfig struct {
@@ -36,8 +52,128 @@ type CORSConfig struct {
 	AllowedHeaders []string
 }
 
+// DefaultJWTSecretKey is the development fallback for JWT_SECRET_KEY; it is refused in release mode
+const DefaultJWTSecretKey = "default-secret-key"
+
 type JWTConfig struct {
+	// SecretKey signs HS256 tokens when no SigningKeyFile is set
 	SecretKey string
+	// SigningKeyFile is a PEM RSA or Ed25519 private key that signs new tokens (RS256/EdDSA)
+	SigningKeyFile string
+	// VerificationKeyFiles are PEM keys whose tokens are still accepted, e.g. the previous
+	// signing key during a rotation
+	VerificationKeyFiles []string
+	AccessTokenTTL       time.Duration
+	RefreshTokenTTL      time.Duration
+}
+
+type AuthConfig struct {
+	// AdminPersonelKodlari lists the personnel allowed to use administrative endpoints
+	AdminPersonelKodlari []string
+	// AuditorPersonelKodlari lists the personnel allowed to query the access audit trail
+	AuditorPersonelKodlari []string
+	// SupervisorPersonelKodlari lists the personnel allowed to review break-the-glass accesses
+	SupervisorPersonelKodlari []string
+	// AcilErisimSuresi is how long a break-the-glass token stays valid
+	AcilErisimSuresi time.Duration
+	// OturumZamanAsimi ends a session after this long without a request
+	OturumZamanAsimi time.Duration
+	// IkinciFaktorSuresi is how long a PIN/TOTP step-up is honoured for sensitive roles and endpoints
+	IkinciFaktorSuresi time.Duration
+	// TOTPIssuer names MedScreen in authenticator apps
+	TOTPIssuer string
+	// PolicyFile is the JSON authorization policy; empty uses the built-in policy
+	PolicyFile string
+	// MaskingFile is the JSON personal data masking policy; empty uses the built-in policy
+	MaskingFile string
+}
+
+// OIDCConfig configures the hospital SSO login; it is disabled while Issuer is empty
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is MedScreen's callback as registered with the provider (.../api/v1/auth/sso/callback)
+	RedirectURL string
+	Scopes      []string
+	// PersonelClaim names the ID token claim holding Personel.PersonelKodu
+	PersonelClaim string
+}
+
+type AllergyConfig struct {
+	// EslemeFile is the JSON drug/allergen mapping; empty uses the built-in allergen groups
+	EslemeFile string
+}
+
+type LabConfig struct {
+	// KritikEsikFile is the JSON table of critical limits per test; empty uses the built-in limits
+	KritikEsikFile string
+}
+
+type AuditConfig struct {
+	// Sink selects where access records go: "postgres" (medscreen schema) or "file"
+	Sink     string
+	FilePath string
+}
+
+type DeviceConfig struct {
+	// HeartbeatInterval is how often an enrolled tablet is told to send a heartbeat
+	HeartbeatInterval time.Duration
+	// OfflineAfter is how long without a heartbeat before a tablet is shown offline
+	OfflineAfter time.Duration
+	// EnrollmentCodeTTL is how long an enrollment code issued by an admin can be used
+	EnrollmentCodeTTL time.Duration
+}
+
+type RateLimitConfig struct {
+	// FreeAttempts is the number of failed logins a source may make before backoff starts
+	FreeAttempts int
+	// BackoffBase is the first backoff; it doubles with every further failure
+	BackoffBase time.Duration
+	// LockoutAfter is the number of failed logins after which a source is locked out
+	LockoutAfter int
+	// LockoutDuration is how long a lockout lasts
+	LockoutDuration time.Duration
+	// ResetAfter is the quiet time after which the failures of a source are forgotten
+	ResetAfter time.Duration
+}
+
+type StreamConfig struct {
+	// PollInterval is how often the clinical tables are polled for new rows
+	PollInterval time.Duration
+	// Keepalive is the idle time after which a comment is sent to keep proxies from closing the stream
+	Keepalive time.Duration
+	// MaxReplay limits how far into the past a client may resume
+	MaxReplay time.Duration
+}
+
+type MarConfig struct {
+	// Pencere is the default window before and after the current time
+	Pencere time.Duration
+	// Tolerans is how far from the planned time an administration counts as due now
+	Tolerans time.Duration
+	// KacirmaSuresi is how late a pending administration becomes missed
+	KacirmaSuresi time.Duration
+}
+
+type HL7Config struct {
+	// Endpoints are the MLLP endpoints of the outbound feed as name=host:port; the feed is
+	// off when there is none
+	Endpoints []string
+	// SendingApplication and SendingFacility identify MedScreen in MSH-3 and MSH-4
+	SendingApplication string
+	SendingFacility    string
+	// ProcessingID is MSH-11: P (production), T (training) or D (debugging)
+	ProcessingID string
+	// PollInterval is how often the VEM tables are polled for changes and idle queues are looked at
+	PollInterval time.Duration
+	// AckTimeout bounds connecting to an endpoint and waiting for an acknowledgement
+	AckTimeout time.Duration
+	// RetryBase is the first retry delay; it doubles with every further attempt up to RetryMax
+	RetryBase time.Duration
+	RetryMax  time.Duration
+	// MaxAttempts is the number of attempts after which a message becomes a dead letter
+	MaxAttempts int
 }
 
 func LoadConfig() (*Config, error) {
@@ -48,9 +184,10 @@ func LoadConfig() (*Config, error) {
 
 	config := &Config{
 		Server: ServerConfig{
-			Host:    getEnv("SERVER_HOST", "0.0.0.0"),
-			Port:    getEnv("SERVER_PORT", "8080"),
-			GinMode: getEnv("GIN_MODE", "debug"),
+			Host:           getEnv("SERVER_HOST", "0.0.0.0"),
+			Port:           getEnv("SERVER_PORT", "8080"),
+			GinMode:        getEnv("GIN_MODE", "debug"),
+			TrustedProxies: getEnvList("SERVER_TRUSTED_PROXIES"),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -63,19 +200,151 @@ func LoadConfig() (*Config, error) {
 		CORS: CORSConfig{
 			AllowedOrigins: strings.Split(getEnv("CORS_ALLOWED_ORIGINS", "*"), ","),
 			AllowedMethods: strings.Split(getEnv("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE,OPTIONS"), ","),
-			AllowedHeaders: strings.Split(getEnv("CORS_ALLOWED_HEADERS", "Origin,Content-Type,Accept,Authorization"), ","),
+			AllowedHeaders: strings.Split(getEnv("CORS_ALLOWED_HEADERS", "Origin,Content-Type,Accept,Authorization,X-Tablet-Cihaz-Kodu,X-Cihaz-Anahtari,X-API-Key"), ","),
 		},
 		JWT: JWTConfig{
-			SecretKey: getEnv("JWT_SECRET_KEY", "default-secret-key"),
+			SecretKey:            getEnv("JWT_SECRET_KEY", DefaultJWTSecretKey),
+			SigningKeyFile:       getEnv("JWT_SIGNING_KEY_FILE", ""),
+			VerificationKeyFiles: getEnvList("JWT_VERIFICATION_KEY_FILES"),
+			AccessTokenTTL:       getEnvDuration("JWT_ACCESS_TOKEN_TTL", 15*time.Minute),
+			RefreshTokenTTL:      getEnvDuration("JWT_REFRESH_TOKEN_TTL", 12*time.Hour),
+		},
+		Auth: AuthConfig{
+			AdminPersonelKodlari:      getEnvList("AUTH_ADMIN_PERSONEL_KODLARI"),
+			AuditorPersonelKodlari:    getEnvList("AUTH_AUDITOR_PERSONEL_KODLARI"),
+			SupervisorPersonelKodlari: getEnvList("AUTH_SUPERVISOR_PERSONEL_KODLARI"),
+			AcilErisimSuresi:          getEnvDuration("AUTH_ACIL_ERISIM_SURESI", 30*time.Minute),
+			OturumZamanAsimi:          getEnvDuration("AUTH_OTURUM_ZAMAN_ASIMI", 30*time.Minute),
+			IkinciFaktorSuresi:        getEnvDuration("AUTH_IKINCI_FAKTOR_SURESI", 5*time.Minute),
+			TOTPIssuer:                getEnv("AUTH_TOTP_ISSUER", "MedScreen"),
+			PolicyFile:                getEnv("AUTH_POLICY_FILE", ""),
+			MaskingFile:               getEnv("AUTH_MASKING_FILE", ""),
+		},
+		Audit: AuditConfig{
+			Sink:     getEnv("AUDIT_SINK", "postgres"),
+			FilePath: getEnv("AUDIT_FILE_PATH", "logs/erisim_kaydi.jsonl"),
+		},
+		Stream: StreamConfig{
+			PollInterval: getEnvDuration("STREAM_POLL_INTERVAL", 5*time.Second),
+			Keepalive:    getEnvDuration("STREAM_KEEPALIVE", 20*time.Second),
+			MaxReplay:    getEnvDuration("STREAM_MAX_REPLAY", 24*time.Hour),
+		},
+		Allergy: AllergyConfig{
+			EslemeFile: getEnv("ALERJI_ESLEME_FILE", ""),
+		},
+		Lab: LabConfig{
+			KritikEsikFile: getEnv("KRITIK_ESIK_FILE", ""),
+		},
+		Mar: MarConfig{
+			Pencere:       getEnvDuration("MAR_PENCERE", 12*time.Hour),
+			Tolerans:      getEnvDuration("MAR_TOLERANS", 30*time.Minute),
+			KacirmaSuresi: getEnvDuration("MAR_KACIRMA_SURESI", 2*time.Hour),
+		},
+		HL7: HL7Config{
+			Endpoints:          getEnvList("HL7_ENDPOINTS"),
+			SendingApplication: getEnv("HL7_SENDING_APPLICATION", "MEDSCREEN"),
+			SendingFacility:    getEnv("HL7_SENDING_FACILITY", ""),
+			ProcessingID:       getEnv("HL7_PROCESSING_ID", "P"),
+			PollInterval:       getEnvDuration("HL7_POLL_INTERVAL", 5*time.Second),
+			AckTimeout:         getEnvDuration("HL7_ACK_TIMEOUT", 30*time.Second),
+			RetryBase:          getEnvDuration("HL7_RETRY_BASE", 10*time.Second),
+			RetryMax:           getEnvDuration("HL7_RETRY_MAX", 10*time.Minute),
+			MaxAttempts:        getEnvInt("HL7_MAX_ATTEMPTS", 20),
+		},
+		Device: DeviceConfig{
+			HeartbeatInterval: getEnvDuration("DEVICE_HEARTBEAT_INTERVAL", time.Minute),
+			OfflineAfter:      getEnvDuration("DEVICE_OFFLINE_AFTER", 5*time.Minute),
+			EnrollmentCodeTTL: getEnvDuration("DEVICE_ENROLLMENT_CODE_TTL", 24*time.Hour),
+		},
+		NFCLimit: RateLimitConfig{
+			FreeAttempts:    getEnvInt("NFC_LIMIT_FREE_ATTEMPTS", 5),
+			BackoffBase:     getEnvDuration("NFC_LIMIT_BACKOFF_BASE", 2*time.Second),
+			LockoutAfter:    getEnvInt("NFC_LIMIT_LOCKOUT_AFTER", 20),
+			LockoutDuration: getEnvDuration("NFC_LIMIT_LOCKOUT_DURATION", time.Hour),
+			ResetAfter:      getEnvDuration("NFC_LIMIT_RESET_AFTER", 15*time.Minute),
+		},
+		OIDC: OIDCConfig{
+			Issuer:        getEnv("OIDC_ISSUER", ""),
+			ClientID:      getEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret:  getEnv("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:   getEnv("OIDC_REDIRECT_URL", ""),
+			Scopes:        getEnvList("OIDC_SCOPES"),
+			PersonelClaim: getEnv("OIDC_PERSONEL_CLAIM", "sub"),
 		},
 	}
 
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
 	return config, nil
 }
 
+// validate rejects settings that must never reach production
+func (c *Config) validate() error {
+	if c.Server.GinMode == "release" && c.JWT.SigningKeyFile == "" &&
+		(c.JWT.SecretKey == "" || c.JWT.SecretKey == DefaultJWTSecretKey) {
+		return errors.New("GIN_MODE=release requires JWT_SIGNING_KEY_FILE or a JWT_SECRET_KEY other than the default")
+	}
+	if c.OIDC.Issuer != "" {
+		if c.OIDC.ClientID == "" || c.OIDC.RedirectURL == "" {
+			return errors.New("OIDC_ISSUER requires OIDC_CLIENT_ID and OIDC_REDIRECT_URL")
+		}
+		if c.Server.GinMode == "release" && !strings.HasPrefix(c.OIDC.Issuer, "https://") {
+			return errors.New("GIN_MODE=release requires an https OIDC_ISSUER")
+		}
+	}
+	if len(c.HL7.Endpoints) > 0 {
+		switch c.HL7.ProcessingID {
+		case "P", "T", "D":
+		default:
+			return errors.New("HL7_PROCESSING_ID must be P, T or D")
+		}
+	}
+	return nil
+}
+
 func getEnv(key, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
 		return value
 	}
 	return fallback
 }
+
+// getEnvDuration reads a Go duration (e.g. "15m") and falls back on a missing or invalid value
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid duration for %s: %q, using %s", key, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+// getEnvInt reads a positive integer and falls back on a missing or invalid value
+func getEnvInt(key string, fallback int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Printf("Invalid integer for %s: %q, using %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
+
+// getEnvList reads a comma-separated list, dropping empty entries
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(getEnv(key, ""), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
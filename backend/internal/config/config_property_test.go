@@ -0,0 +1,54 @@
+package config
+
+import "testing"
+
+// TestConfig_ReleaseRefusesDefaultSecret verifies that release mode does not start with the
+// default JWT secret unless asymmetric signing is configured
+func TestConfig_ReleaseRefusesDefaultSecret(t *testing.T) {
+	tests := []struct {
+		ginMode, secret, keyFile string
+		ok                       bool
+	}{
+		{"debug", DefaultJWTSecretKey, "", true},
+		{"release", DefaultJWTSecretKey, "", false},
+		{"release", "", "", false},
+		{"release", "uzun-ve-gizli", "", true},
+		{"release", DefaultJWTSecretKey, "/etc/medscreen/jwt.pem", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("GIN_MODE", tt.ginMode)
+		t.Setenv("JWT_SECRET_KEY", tt.secret)
+		t.Setenv("JWT_SIGNING_KEY_FILE", tt.keyFile)
+		_, err := LoadConfig()
+		if (err == nil) != tt.ok {
+			t.Errorf("GIN_MODE=%s secret=%q key=%q: expected ok=%v, got %v", tt.ginMode, tt.secret, tt.keyFile, tt.ok, err)
+		}
+	}
+}
+
+// TestConfig_OIDCRequiresClient verifies that an SSO issuer is only accepted with a client
+// registration, and only over https in release mode
+func TestConfig_OIDCRequiresClient(t *testing.T) {
+	tests := []struct {
+		ginMode, issuer, clientID, redirect string
+		ok                                  bool
+	}{
+		{"debug", "", "", "", true},
+		{"debug", "http://localhost:9000", "medscreen", "http://localhost:8080/api/v1/auth/sso/callback", true},
+		{"debug", "http://localhost:9000", "", "http://localhost:8080/api/v1/auth/sso/callback", false},
+		{"debug", "http://localhost:9000", "medscreen", "", false},
+		{"release", "http://idp.hastane.local", "medscreen", "https://medscreen.hastane.local/api/v1/auth/sso/callback", false},
+		{"release", "https://idp.hastane.local", "medscreen", "https://medscreen.hastane.local/api/v1/auth/sso/callback", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("GIN_MODE", tt.ginMode)
+		t.Setenv("JWT_SECRET_KEY", "uzun-ve-gizli")
+		t.Setenv("OIDC_ISSUER", tt.issuer)
+		t.Setenv("OIDC_CLIENT_ID", tt.clientID)
+		t.Setenv("OIDC_REDIRECT_URL", tt.redirect)
+		_, err := LoadConfig()
+		if (err == nil) != tt.ok {
+			t.Errorf("GIN_MODE=%s issuer=%q client=%q redirect=%q: expected ok=%v, got %v", tt.ginMode, tt.issuer, tt.clientID, tt.redirect, tt.ok, err)
+		}
+	}
+}
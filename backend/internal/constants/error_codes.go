@@ -7,6 +7,7 @@ const (
 	ERROR_UNAUTHORIZED    = "UNAUTHORIZED"
 	ERROR_FORBIDDEN       = "FORBIDDEN"
 	ERROR_NOT_FOUND       = "NOT_FOUND"
+	ERROR_INVALID_QUERY   = "INVALID_QUERY"
 )
 
 // User-related error codes
@@ -134,3 +135,69 @@ const (
 	ERROR_RANDEVU_NOT_FOUND              = "RANDEVU_NOT_FOUND"
 	ERROR_INVALID_RANDEVU_KODU           = "INVALID_RANDEVU_KODU"
 )
+
+// Authentication error codes
+const (
+	ERROR_INVALID_TOKEN           = "INVALID_TOKEN"
+	ERROR_TOKEN_REVOKED           = "TOKEN_REVOKED"
+	ERROR_TOKEN_EXPIRED           = "TOKEN_EXPIRED"
+	ERROR_TOKEN_REVOCATION_FAILED = "TOKEN_REVOCATION_FAILED"
+	ERROR_NOT_BED_BOUND           = "NOT_BED_BOUND"
+	ERROR_TOO_MANY_ATTEMPTS       = "TOO_MANY_ATTEMPTS"
+	ERROR_LOGIN_BLOCK_NOT_FOUND   = "LOGIN_BLOCK_NOT_FOUND"
+)
+
+// Second factor error codes
+const (
+	ERROR_SECOND_FACTOR_REQUIRED     = "SECOND_FACTOR_REQUIRED"
+	ERROR_SECOND_FACTOR_INVALID      = "SECOND_FACTOR_INVALID"
+	ERROR_SECOND_FACTOR_LOCKED       = "SECOND_FACTOR_LOCKED"
+	ERROR_SECOND_FACTOR_NOT_ENROLLED = "SECOND_FACTOR_NOT_ENROLLED"
+	ERROR_INVALID_PIN                = "INVALID_PIN"
+)
+
+// Hospital SSO (OpenID Connect) error codes
+const (
+	ERROR_SSO_FAILED               = "SSO_FAILED"
+	ERROR_SSO_STATE_MISMATCH       = "SSO_STATE_MISMATCH"
+	ERROR_SSO_PROVIDER_UNAVAILABLE = "SSO_PROVIDER_UNAVAILABLE"
+)
+
+// Session error codes
+const (
+	ERROR_OTURUM_NOT_FOUND = "OTURUM_NOT_FOUND"
+)
+
+// API key error codes
+const (
+	ERROR_API_ANAHTARI_NOT_FOUND = "API_ANAHTARI_NOT_FOUND"
+)
+
+// Critical test result error codes
+const (
+	ERROR_TETKIK_SONUC_NOT_CRITICAL = "TETKIK_SONUC_NOT_CRITICAL"
+)
+
+// Emergency access error codes
+const (
+	ERROR_ACIL_ERISIM_NOT_FOUND      = "ACIL_ERISIM_NOT_FOUND"
+	ERROR_ACIL_ERISIM_NOT_REVIEWABLE = "ACIL_ERISIM_NOT_REVIEWABLE"
+)
+
+// Audit trail error codes
+const (
+	ERROR_AUDIT_FAILED = "AUDIT_FAILED"
+)
+
+// Device enrollment error codes
+const (
+	ERROR_INVALID_CIHAZ_KAYIT_KODU = "INVALID_CIHAZ_KAYIT_KODU"
+	ERROR_INVALID_CIHAZ_CREDENTIAL = "INVALID_CIHAZ_CREDENTIAL"
+	ERROR_INVALID_FILO_SORUNU      = "INVALID_FILO_SORUNU"
+)
+
+// HL7 feed error codes
+const (
+	ERROR_INVALID_HL7_MESAJ_DURUMU = "INVALID_HL7_MESAJ_DURUMU"
+	ERROR_HL7_MESAJ_NOT_FOUND      = "HL7_MESAJ_NOT_FOUND"
+)
@@ -95,6 +95,8 @@ const (
 	SUCCESS_HASTALAR_RETRIEVED             = "HASTALAR_RETRIEVED"
 	SUCCESS_HASTA_BASVURU_RETRIEVED        = "HASTA_BASVURU_RETRIEVED"
 	SUCCESS_HASTA_BASVURULAR_RETRIEVED     = "HASTA_BASVURULAR_RETRIEVED"
+	SUCCESS_HASTA_BASVURU_OZET_RETRIEVED   = "HASTA_BASVURU_OZET_RETRIEVED"
+	SUCCESS_HASTA_BASVURU_OZET_PARTIAL     = "HASTA_BASVURU_OZET_PARTIAL"
 	SUCCESS_YATAK_RETRIEVED                = "YATAK_RETRIEVED"
 	SUCCESS_YATAKLAR_RETRIEVED             = "YATAKLAR_RETRIEVED"
 	SUCCESS_TABLET_CIHAZ_RETRIEVED         = "TABLET_CIHAZ_RETRIEVED"
@@ -126,3 +128,88 @@ const (
 	SUCCESS_RANDEVU_RETRIEVED              = "RANDEVU_RETRIEVED"
 	SUCCESS_RANDEVULAR_RETRIEVED           = "RANDEVULAR_RETRIEVED"
 )
+
+// Early warning score success codes
+const (
+	SUCCESS_NEWS2_RETRIEVED               = "NEWS2_RETRIEVED"
+	SUCCESS_NEWS2_ESKALASYONLAR_RETRIEVED = "NEWS2_ESKALASYONLAR_RETRIEVED"
+)
+
+// Critical test result success codes
+const (
+	SUCCESS_KRITIK_TETKIK_SONUCLAR_RETRIEVED = "KRITIK_TETKIK_SONUCLAR_RETRIEVED"
+	SUCCESS_KRITIK_SONUC_ACKNOWLEDGED        = "KRITIK_SONUC_ACKNOWLEDGED"
+)
+
+// Medication administration record success codes
+const (
+	SUCCESS_MAR_RETRIEVED       = "MAR_RETRIEVED"
+	SUCCESS_MAR_BIRIM_RETRIEVED = "MAR_BIRIM_RETRIEVED"
+)
+
+// Drug-allergy conflict check success codes
+const (
+	SUCCESS_ILAC_ALERJI_KONTROLU = "ILAC_ALERJI_KONTROLU"
+)
+
+// Device enrollment success codes
+const (
+	SUCCESS_CIHAZ_ENROLLED           = "CIHAZ_ENROLLED"
+	SUCCESS_CIHAZ_KAYIT_KODU_CREATED = "CIHAZ_KAYIT_KODU_CREATED"
+	SUCCESS_CIHAZ_HEARTBEAT          = "CIHAZ_HEARTBEAT_RECORDED"
+	SUCCESS_CIHAZ_REVOKED            = "CIHAZ_ENROLLMENT_REVOKED"
+	SUCCESS_CIHAZ_FILO_RETRIEVED     = "CIHAZ_FILO_RETRIEVED"
+)
+
+// Authentication success codes
+const (
+	SUCCESS_TOKEN_REFRESHED  = "TOKEN_REFRESHED"
+	SUCCESS_LOGOUT           = "LOGOUT_SUCCESSFUL"
+	SUCCESS_TOKENS_REVOKED   = "TOKENS_REVOKED"
+	SUCCESS_ESCALATED        = "BED_BINDING_LIFTED"
+	SUCCESS_BLOCKED_SOURCES  = "BLOCKED_SOURCES_RETRIEVED"
+	SUCCESS_SOURCE_UNBLOCKED = "SOURCE_UNBLOCKED"
+	SUCCESS_SSO_LOGIN        = "SSO_LOGIN_SUCCESSFUL"
+	SUCCESS_LOGIN_UNBLOCKED  = "LOGIN_UNBLOCKED"
+)
+
+// Second factor success codes
+const (
+	SUCCESS_STEPPED_UP              = "SECOND_FACTOR_VERIFIED"
+	SUCCESS_IKINCI_FAKTOR_RETRIEVED = "IKINCI_FAKTOR_RETRIEVED"
+	SUCCESS_PIN_ENROLLED            = "PIN_ENROLLED"
+	SUCCESS_TOTP_ENROLLED           = "TOTP_ENROLLED"
+	SUCCESS_IKINCI_FAKTOR_RESET     = "IKINCI_FAKTOR_RESET"
+)
+
+// Session success codes
+const (
+	SUCCESS_OTURUMLAR_RETRIEVED = "OTURUMLAR_RETRIEVED"
+	SUCCESS_OTURUMLAR_ENDED     = "OTURUMLAR_ENDED"
+)
+
+// API key success codes
+const (
+	SUCCESS_API_ANAHTARLARI_RETRIEVED = "API_ANAHTARLARI_RETRIEVED"
+	SUCCESS_API_ANAHTARI_CREATED      = "API_ANAHTARI_CREATED"
+	SUCCESS_API_ANAHTARI_REVOKED      = "API_ANAHTARI_REVOKED"
+)
+
+// Emergency access success codes
+const (
+	SUCCESS_ACIL_ERISIM_GRANTED      = "ACIL_ERISIM_GRANTED"
+	SUCCESS_ACIL_ERISIM_RETRIEVED    = "ACIL_ERISIM_RETRIEVED"
+	SUCCESS_ACIL_ERISIMLER_RETRIEVED = "ACIL_ERISIMLER_RETRIEVED"
+	SUCCESS_ACIL_ERISIM_REVIEWED     = "ACIL_ERISIM_REVIEWED"
+)
+
+// Audit trail success codes
+const (
+	SUCCESS_ERISIM_KAYITLARI_RETRIEVED = "ERISIM_KAYITLARI_RETRIEVED"
+)
+
+// HL7 feed success codes
+const (
+	SUCCESS_HL7_MESAJLAR_RETRIEVED = "HL7_MESAJLAR_RETRIEVED"
+	SUCCESS_HL7_MESAJ_REQUEUED     = "HL7_MESAJ_REQUEUED"
+)
@@ -0,0 +1,128 @@
+// Package critical holds the critical (panic) limits of laboratory tests.
+//
+// VEM 2.0 stores a single range per result (tetkik_sonuc.kritik_deger_araligi) that, when it
+// has two bounds, is the reference range: a result outside it is low or high but not
+// necessarily life-threatening. Whether a result must be acted on at once is decided by the
+// critical limits of its test, kept in a local table (Esikler) keyed by the test's name.
+package critical
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+//go:embed default_esikler.json
+var defaultEsiklerJSON []byte
+
+// Esik is the critical limits of a test: results below Alt or above Ust are critical.
+// Birim is the unit the limits are written in; results in another unit are not compared.
+type Esik struct {
+	// Terimler are the test names (tetkik_adi) the limits apply to
+	Terimler []string `json:"terimler"`
+	Alt      *float64 `json:"alt,omitempty"`
+	Ust      *float64 `json:"ust,omitempty"`
+	Birim    string   `json:"birim,omitempty"`
+}
+
+// Esikler maps tests to their critical limits
+type Esikler struct {
+	Tetkikler map[string]Esik `json:"tetkikler"`
+
+	// esikByTerim indexes the normalized test names
+	esikByTerim map[string]*Esik
+}
+
+// Default returns the built-in limits shipped with MedScreen
+func Default() *Esikler {
+	e, err := Parse(defaultEsiklerJSON)
+	if err != nil {
+		panic("invalid built-in critical limits: " + err.Error())
+	}
+	return e
+}
+
+// Load reads limits from a JSON file; an empty path returns the built-in limits
+func Load(path string) (*Esikler, error) {
+	if path == "" {
+		return Default(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read critical limits file: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates JSON limits
+func Parse(data []byte) (*Esikler, error) {
+	var e Esikler
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("failed to parse critical limits: %w", err)
+	}
+
+	e.esikByTerim = make(map[string]*Esik)
+	for ad, esik := range e.Tetkikler {
+		if esik.Alt == nil && esik.Ust == nil {
+			return nil, fmt.Errorf("test %q: no critical limit", ad)
+		}
+		if esik.Alt != nil && esik.Ust != nil && *esik.Alt > *esik.Ust {
+			return nil, fmt.Errorf("test %q: lower limit above upper limit", ad)
+		}
+		esik := esik
+		for _, terim := range append([]string{ad}, esik.Terimler...) {
+			n := normalize(terim)
+			if n == "" {
+				return nil, fmt.Errorf("test %q: empty name", ad)
+			}
+			if diger, ok := e.esikByTerim[n]; ok && diger != &esik {
+				return nil, fmt.Errorf("test %q: name %q is used by another test", ad, terim)
+			}
+			e.esikByTerim[n] = &esik
+		}
+	}
+	return &e, nil
+}
+
+// Bul returns the critical limits of a test by its name
+func (e *Esikler) Bul(tetkikAdi string) (*Esik, bool) {
+	if e == nil {
+		return nil, false
+	}
+	esik, ok := e.esikByTerim[normalize(tetkikAdi)]
+	return esik, ok
+}
+
+// normalize lowercases, folds Turkish letters to ASCII and collapses punctuation to spaces
+func normalize(s string) string {
+	var b strings.Builder
+	bosluk := true
+	for _, r := range s {
+		switch r {
+		case 'İ', 'I', 'ı':
+			r = 'i'
+		case 'Ş', 'ş':
+			r = 's'
+		case 'Ğ', 'ğ':
+			r = 'g'
+		case 'Ü', 'ü':
+			r = 'u'
+		case 'Ö', 'ö':
+			r = 'o'
+		case 'Ç', 'ç':
+			r = 'c'
+		}
+		r = unicode.ToLower(r)
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			bosluk = false
+		} else if !bosluk {
+			b.WriteRune(' ')
+			bosluk = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -13,3 +13,6 @@ package database
 
 // Note: RegisterAuditCallbacks has been removed as there are no write operations
 // to audit in a read-only system.
+//
+// Reads of patient data are audited for KVKK compliance by the audit.Middleware
+// (internal/audit); those records are stored outside the VEM 2.0 schema.
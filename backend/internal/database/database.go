@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"medscreen/internal/config"
+	"medscreen/internal/models"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -87,3 +88,37 @@ func CloseDatabase(db *gorm.DB) error {
 	log.Println("Database connection closed successfully")
 	return nil
 }
+
+// MedScreenSchema is the schema holding tables owned by MedScreen itself.
+// VEM 2.0 tables are never migrated; only models listed in medScreenModels are.
+const MedScreenSchema = "medscreen"
+
+// medScreenModels lists the MedScreen-owned models created by MigrateMedScreen
+var medScreenModels = []interface{}{
+	&models.TokenIptal{},
+	&models.GirisEngeli{},
+	&models.ErisimKaydi{},
+	&models.KritikSonucOnay{},
+	&models.CihazKaydi{},
+	&models.CihazKayitKodu{},
+	&models.YatakKisitiKaldirma{},
+	&models.AcilErisim{},
+	&models.IkinciFaktor{},
+	&models.APIAnahtari{},
+	&models.Oturum{},
+	&models.HL7Mesaj{},
+}
+
+// MigrateMedScreen creates the MedScreen schema and its tables if they do not exist.
+// The database user needs CREATE permission on the database for the first run.
+func MigrateMedScreen(db *gorm.DB) error {
+	if err := db.Exec("CREATE SCHEMA IF NOT EXISTS " + MedScreenSchema).Error; err != nil {
+		return fmt.Errorf("failed to create %s schema: %w", MedScreenSchema, err)
+	}
+	if err := db.AutoMigrate(medScreenModels...); err != nil {
+		return fmt.Errorf("failed to migrate %s tables: %w", MedScreenSchema, err)
+	}
+
+	log.Printf("MedScreen tables migrated in schema %q", MedScreenSchema)
+	return nil
+}
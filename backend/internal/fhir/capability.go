@@ -0,0 +1,127 @@
+package fhir
+
+import "time"
+
+// SearchParam is a search parameter of a resource type
+type SearchParam struct {
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	Documentation string `json:"documentation,omitempty"`
+}
+
+// ResourceInfo declares what the facade supports for a resource type
+type ResourceInfo struct {
+	Type         string
+	Profiles     []string
+	SearchParams []SearchParam
+}
+
+var (
+	patientParam   = SearchParam{Name: "patient", Type: "reference", Documentation: "The patient (hasta_kodu), e.g. patient=H0001 or patient=Patient/H0001"}
+	encounterParam = SearchParam{Name: "encounter", Type: "reference", Documentation: "The encounter (hasta_basvuru_kodu)"}
+	idParam        = SearchParam{Name: "_id", Type: "token", Documentation: "The VEM code of the record"}
+)
+
+func dateParam(name, documentation string) SearchParam {
+	return SearchParam{Name: name, Type: "date", Documentation: documentation + "; prefixes eq, gt, ge, lt and le"}
+}
+
+// Resources lists the resource types of the facade and their search parameters. Searches
+// reject parameters not listed here.
+var Resources = []ResourceInfo{
+	{Type: "Patient", SearchParams: []SearchParam{idParam}},
+	{Type: "Encounter", SearchParams: []SearchParam{idParam, patientParam, dateParam("date", "The admission time (hasta_kabul_zamani)")}},
+	{
+		Type:     "Observation",
+		Profiles: []string{ProfileVitalSigns},
+		SearchParams: []SearchParam{patientParam, encounterParam,
+			dateParam("date", "The time of measurement (islem_zamani) or of the result (kayit_zamani)"),
+			{Name: "category", Type: "token", Documentation: "vital-signs or laboratory; both when not given"}},
+	},
+	{Type: "Condition", SearchParams: []SearchParam{idParam, patientParam, encounterParam, dateParam("recorded-date", "The time of diagnosis (tani_zamani)")}},
+	{Type: "AllergyIntolerance", SearchParams: []SearchParam{idParam, patientParam, dateParam("date", "The time the allergy was recorded (kayit_zamani)")}},
+	{Type: "MedicationRequest", SearchParams: []SearchParam{patientParam, encounterParam, dateParam("authoredon", "The time of the prescription (recete_zamani)")}},
+	{Type: "Practitioner", SearchParams: []SearchParam{idParam}},
+}
+
+// ResourceInfoOf returns the declaration of a resource type of the facade
+func ResourceInfoOf(resourceType string) (ResourceInfo, bool) {
+	for _, info := range Resources {
+		if info.Type == resourceType {
+			return info, true
+		}
+	}
+	return ResourceInfo{}, false
+}
+
+// CapabilityStatement is the FHIR CapabilityStatement resource served at /metadata
+type CapabilityStatement struct {
+	ResourceType   string           `json:"resourceType"`
+	Status         string           `json:"status"`
+	Date           string           `json:"date"`
+	Kind           string           `json:"kind"`
+	Software       *Software        `json:"software,omitempty"`
+	Implementation *Implementation  `json:"implementation,omitempty"`
+	FHIRVersion    string           `json:"fhirVersion"`
+	Format         []string         `json:"format"`
+	Rest           []CapabilityRest `json:"rest"`
+}
+
+// Software names the server software
+type Software struct {
+	Name string `json:"name"`
+}
+
+// Implementation is the server instance a CapabilityStatement describes
+type Implementation struct {
+	Description string `json:"description"`
+	URL         string `json:"url,omitempty"`
+}
+
+// CapabilityRest describes the RESTful interface of the server
+type CapabilityRest struct {
+	Mode          string               `json:"mode"`
+	Documentation string               `json:"documentation,omitempty"`
+	Resource      []CapabilityResource `json:"resource"`
+}
+
+// CapabilityResource describes what the server supports for a resource type
+type CapabilityResource struct {
+	Type             string        `json:"type"`
+	SupportedProfile []string      `json:"supportedProfile,omitempty"`
+	Interaction      []Interaction `json:"interaction"`
+	SearchParam      []SearchParam `json:"searchParam,omitempty"`
+}
+
+// Interaction is an operation the server supports on a resource type
+type Interaction struct {
+	Code string `json:"code"`
+}
+
+// NewCapabilityStatement describes the facade served at base
+func NewCapabilityStatement(base string) CapabilityStatement {
+	rest := CapabilityRest{
+		Mode: "server",
+		Documentation: "Read-only. Requests need a MedScreen access token or API key; " +
+			"personal data is masked by the caller's role like the VEM-shaped API.",
+	}
+	for _, info := range Resources {
+		rest.Resource = append(rest.Resource, CapabilityResource{
+			Type:             info.Type,
+			SupportedProfile: info.Profiles,
+			Interaction:      []Interaction{{Code: "read"}, {Code: "search-type"}},
+			SearchParam:      info.SearchParams,
+		})
+	}
+	return CapabilityStatement{
+		ResourceType:   "CapabilityStatement",
+		Status:         "active",
+		Date:           dateTime(time.Now()),
+		Kind:           "instance",
+		Software:       &Software{Name: "MedScreen"},
+		Implementation: &Implementation{Description: "MedScreen FHIR R4 facade over VEM 2.0", URL: base},
+		FHIRVersion:    Version,
+		Format:         []string{"json"},
+		Rest:           []CapabilityRest{rest},
+	}
+}
@@ -0,0 +1,552 @@
+package fhir
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"medscreen/internal/masking"
+	"medscreen/internal/middleware"
+	"medscreen/internal/models"
+	"medscreen/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pgregory.net/rapid"
+)
+
+// Generators of VEM records, with values shaped like those of the sample database
+
+func kodu(rt *rapid.T, label, prefix string) string {
+	return rapid.StringMatching(prefix+`[0-9]{1,8}`).Draw(rt, label)
+}
+
+func optional[T any](rt *rapid.T, label string, gen *rapid.Generator[T]) *T {
+	if !rapid.Bool().Draw(rt, label+"_var") {
+		return nil
+	}
+	v := gen.Draw(rt, label)
+	return &v
+}
+
+func zaman(rt *rapid.T, label string) time.Time {
+	return time.Unix(rapid.Int64Range(946684800, 1893456000).Draw(rt, label), 0).In(time.FixedZone("TRT", 3*60*60))
+}
+
+func metin() *rapid.Generator[string] {
+	return rapid.SampledFrom([]string{"Ayşe Nur", "Mehmet", "Yılmaz", "Öztürk", "hemolizli numune", "Penisilin alerjisi, döküntü"})
+}
+
+func genHasta(rt *rapid.T) *models.Hasta {
+	return &models.Hasta{
+		HastaKodu:        kodu(rt, "hasta_kodu", "H"),
+		TCKimlikNumarasi: optional(rt, "tc", rapid.StringMatching(`[1-9][0-9]{10}`)),
+		Ad:               metin().Draw(rt, "ad"),
+		Soyadi:           metin().Draw(rt, "soyadi"),
+		DogumTarihi:      zaman(rt, "dogum_tarihi"),
+		Cinsiyet:         optional(rt, "cinsiyet", rapid.SampledFrom([]string{"E", "K", "B"})),
+		HastaTipi:        optional(rt, "hasta_tipi", rapid.SampledFrom([]string{"Yatan", "Ayaktan"})),
+		KayitZamani:      zaman(rt, "kayit_zamani"),
+	}
+}
+
+func genBasvuru(rt *rapid.T) *models.HastaBasvuru {
+	hasta := genHasta(rt)
+	b := &models.HastaBasvuru{
+		HastaBasvuruKodu:        kodu(rt, "hasta_basvuru_kodu", "B"),
+		HastaKodu:               hasta.HastaKodu,
+		Hasta:                   hasta,
+		BasvuruProtokolNumarasi: kodu(rt, "protokol", "P"),
+		HastaKabulZamani:        zaman(rt, "hasta_kabul_zamani"),
+		HekimKodu:               optional(rt, "hekim_kodu", rapid.StringMatching(`D[0-9]{1,4}`)),
+		BasvuruDurumu:           optional(rt, "basvuru_durumu", rapid.SampledFrom([]string{"YATIS", "YOGUN_BAKIM", "TABURCU", "ACIL"})),
+		KayitZamani:             zaman(rt, "kayit_zamani"),
+	}
+	if rapid.Bool().Draw(rt, "taburcu") {
+		cikis := b.HastaKabulZamani.Add(time.Duration(rapid.IntRange(1, 500).Draw(rt, "saat")) * time.Hour)
+		b.CikisZamani = &cikis
+	}
+	return b
+}
+
+// olcum draws a measurement as nurses write it, sometimes with a unit, a comma or garbage
+func olcum(rt *rapid.T, label string) *string {
+	return optional(rt, label, rapid.SampledFrom([]string{"36.8", "37,5 °C", "88", "120 mmHg", "80", "18", "%97", "97 %", "172 cm", "68,5 kg", "<50", "ölçülemedi", "999"}))
+}
+
+func genVitalBulgu(rt *rapid.T) *models.HastaVitalFizikiBulgu {
+	basvuru := genBasvuru(rt)
+	b := &models.HastaVitalFizikiBulgu{
+		HastaVitalFizikiBulguKodu: kodu(rt, "vital_kodu", "V"),
+		HastaBasvuruKodu:          basvuru.HastaBasvuruKodu,
+		HastaBasvuru:              basvuru,
+		IslemZamani:               zaman(rt, "islem_zamani"),
+		Ates:                      olcum(rt, "ates"),
+		Nabiz:                     olcum(rt, "nabiz"),
+		SistolikKanBasinciDegeri:  olcum(rt, "sistolik"),
+		DiastolikKanBasinciDegeri: olcum(rt, "diastolik"),
+		Solunum:                   olcum(rt, "solunum"),
+		Saturasyon:                olcum(rt, "saturasyon"),
+		Boy:                       olcum(rt, "boy"),
+		Agirlik:                   olcum(rt, "agirlik"),
+		HemsireKodu:               optional(rt, "hemsire_kodu", rapid.StringMatching(`N[0-9]{1,4}`)),
+		KayitZamani:               zaman(rt, "kayit_zamani"),
+	}
+	b.ParseSayisalDegerler()
+	return b
+}
+
+func genTetkikSonuc(rt *rapid.T) *models.TetkikSonuc {
+	basvuru := genBasvuru(rt)
+	t := &models.TetkikSonuc{
+		TetkikSonucKodu:    kodu(rt, "tetkik_sonuc_kodu", "T"),
+		HastaBasvuruKodu:   basvuru.HastaBasvuruKodu,
+		HastaBasvuru:       basvuru,
+		TetkikAdi:          rapid.SampledFrom([]string{"Hemoglobin", "Potasyum", "CRP", "Kültür"}).Draw(rt, "tetkik_adi"),
+		SonucDegeri:        optional(rt, "sonuc", rapid.SampledFrom([]string{"13,2 g/dL", "5.9", "<0,5 mg/L", "üreme yok", ">1000"})),
+		KritikDegerAraligi: optional(rt, "aralik", rapid.SampledFrom([]string{"3,5-5,1 mmol/L", "12-16", "<5", "bakınız rapor"})),
+		KayitZamani:        zaman(rt, "kayit_zamani"),
+	}
+	if rapid.Bool().Draw(rt, "onayli") {
+		onay := t.KayitZamani.Add(time.Hour)
+		t.OnayZamani = &onay
+	}
+	t.ParseSayisalDegerler()
+	if rapid.Bool().Draw(rt, "degerlendirilmis") {
+		t.Degerlendirme = &models.TetkikDegerlendirmesi{Durum: rapid.SampledFrom([]models.TetkikDurumu{
+			models.TetkikDurumuNormal, models.TetkikDurumuDusuk, models.TetkikDurumuYuksek, models.TetkikDurumuBelirsiz,
+		}).Draw(rt, "durum")}
+	}
+	return t
+}
+
+func genBasvuruTani(rt *rapid.T) *models.BasvuruTani {
+	return &models.BasvuruTani{
+		BasvuruTaniKodu:  kodu(rt, "basvuru_tani_kodu", "BT"),
+		HastaKodu:        kodu(rt, "hasta_kodu", "H"),
+		HastaBasvuruKodu: kodu(rt, "hasta_basvuru_kodu", "B"),
+		TaniKodu:         rapid.SampledFrom([]string{"J18.9", "I10", "E11.9", "N17.9"}).Draw(rt, "tani_kodu"),
+		TaniTuru:         optional(rt, "tani_turu", rapid.SampledFrom([]string{"KESIN", "ON_TANI", "AYIRICI"})),
+		TaniZamani:       zaman(rt, "tani_zamani"),
+		HekimKodu:        optional(rt, "hekim_kodu", rapid.StringMatching(`D[0-9]{1,4}`)),
+		KayitZamani:      zaman(rt, "kayit_zamani"),
+	}
+}
+
+func genAlerji(rt *rapid.T) *models.HastaTibbiBilgi {
+	return &models.HastaTibbiBilgi{
+		HastaTibbiBilgiKodu:   kodu(rt, "hasta_tibbi_bilgi_kodu", "TB"),
+		HastaKodu:             kodu(rt, "hasta_kodu", "H"),
+		TibbiBilgiTuruKodu:    string(models.TibbiBilgiAlerji),
+		TibbiBilgiAltTuruKodu: optional(rt, "alt_turu", rapid.SampledFrom([]string{"PENISILIN", "LATEKS", "  "})),
+		Aciklama:              optional(rt, "aciklama", metin()),
+		KayitZamani:           zaman(rt, "kayit_zamani"),
+	}
+}
+
+func genRecete(rt *rapid.T) *models.Recete {
+	basvuru := genBasvuru(rt)
+	r := &models.Recete{
+		ReceteKodu:            kodu(rt, "recete_kodu", "R"),
+		HastaBasvuruKodu:      basvuru.HastaBasvuruKodu,
+		HastaBasvuru:          basvuru,
+		MedulaEReceteNumarasi: optional(rt, "e_recete", rapid.StringMatching(`[0-9A-Z]{7}`)),
+		HekimKodu:             kodu(rt, "hekim_kodu", "D"),
+		ReceteZamani:          zaman(rt, "recete_zamani"),
+		KayitZamani:           zaman(rt, "kayit_zamani"),
+		AktiflikBilgisi:       rapid.IntRange(0, 1).Draw(rt, "aktif"),
+	}
+	for i := range rapid.IntRange(1, 4).Draw(rt, "ilac_sayisi") {
+		r.Ilaclar = append(r.Ilaclar, models.ReceteIlac{
+			ReceteIlacKodu:             fmt.Sprintf("%s-%d", r.ReceteKodu, i),
+			ReceteKodu:                 r.ReceteKodu,
+			Barkod:                     rapid.StringMatching(`869[0-9]{10}`).Draw(rt, "barkod"),
+			IlacAdi:                    optional(rt, "ilac_adi", rapid.SampledFrom([]string{"PAROL 500 MG TABLET", "AUGMENTIN BID 1000 MG"})),
+			IlacKullanimDozu:           rapid.SampledFrom([]string{"500", "1", "0,5", "1/2"}).Draw(rt, "doz"),
+			DozBirim:                   rapid.SampledFrom([]string{"mg", "tablet", "ml"}).Draw(rt, "doz_birim"),
+			IlacKullanimPeriyodu:       optional(rt, "periyot", rapid.IntRange(1, 24)),
+			IlacKullanimPeriyoduBirimi: rapid.SampledFrom([]string{"SAAT", "GUN", "HAFTA", "KUR"}).Draw(rt, "periyot_birimi"),
+			IlacKullanimSekli:          optional(rt, "kullanim_sekli", rapid.SampledFrom([]string{"PO", "IV", "IM"})),
+			KutuAdeti:                  optional(rt, "kutu", rapid.IntRange(1, 5)),
+			KayitZamani:                r.KayitZamani,
+		})
+	}
+	return r
+}
+
+func genPersonel(rt *rapid.T) *models.Personel {
+	return &models.Personel{
+		PersonelKodu:     kodu(rt, "personel_kodu", "D"),
+		Ad:               metin().Draw(rt, "ad"),
+		Soyadi:           metin().Draw(rt, "soyadi"),
+		MedulaBransKodu:  optional(rt, "brans", rapid.SampledFrom([]string{"1300", "1800"})),
+		TescilNumarasi:   optional(rt, "tescil", rapid.StringMatching(`[0-9]{6}`)),
+		TCKimlikNumarasi: optional(rt, "tc", rapid.StringMatching(`[1-9][0-9]{10}`)),
+		AktiflikBilgisi:  rapid.IntRange(0, 1).Draw(rt, "aktif"),
+		KayitZamani:      zaman(rt, "kayit_zamani"),
+	}
+}
+
+// genResources maps a random record of a random mapped type
+func genResources(rt *rapid.T) []Resource {
+	switch rapid.IntRange(0, 7).Draw(rt, "tur") {
+	case 0:
+		return []Resource{NewPatient(genHasta(rt), Unmasked)}
+	case 1:
+		return []Resource{NewEncounter(genBasvuru(rt))}
+	case 2:
+		var resources []Resource
+		for _, o := range NewVitalSigns(genVitalBulgu(rt)) {
+			resources = append(resources, o)
+		}
+		return resources
+	case 3:
+		return []Resource{NewLabResult(genTetkikSonuc(rt))}
+	case 4:
+		return []Resource{NewCondition(genBasvuruTani(rt))}
+	case 5:
+		return []Resource{NewAllergyIntolerance(genAlerji(rt))}
+	case 6:
+		var resources []Resource
+		for _, r := range NewMedicationRequests(genRecete(rt)) {
+			resources = append(resources, r)
+		}
+		return resources
+	default:
+		return []Resource{NewPractitioner(genPersonel(rt), Unmasked)}
+	}
+}
+
+// Feature: fhir-facade, Property 1: Mapped Resources Conform to FHIR R4
+// *For any* VEM record of a mapped type, the resources it is mapped to SHALL validate
+// against the FHIR R4 definitions, alone and as the entries of a searchset Bundle.
+
+// TestProperty_MappedResourcesConformToFHIR tests Property 1
+func TestProperty_MappedResourcesConformToFHIR(t *testing.T) {
+	r4 := requireR4(t)
+	self, _ := url.Parse("/fhir/R4/Observation?patient=H1")
+
+	rapid.Check(t, func(rt *rapid.T) {
+		resources := genResources(rt)
+		for _, r := range resources {
+			r4.validate(rt, r)
+		}
+
+		s := Search{values: self.Query(), Page: rapid.IntRange(1, 5).Draw(rt, "page"), Count: DefaultCount}
+		total := int64(len(resources))
+		r4.validate(rt, Searchset("https://medscreen.example"+BasePath, self, s, &total, true, resources))
+	})
+}
+
+// TestCapabilityStatementConformsToFHIR checks the /metadata resource and an empty search
+func TestCapabilityStatementConformsToFHIR(t *testing.T) {
+	r4 := requireR4(t)
+	r4.validate(t, NewCapabilityStatement("https://medscreen.example"+BasePath))
+
+	self, _ := url.Parse("/fhir/R4/Patient")
+	r4.validate(t, Searchset("https://medscreen.example"+BasePath, self, Search{Page: 1, Count: DefaultCount}, nil, false, nil))
+}
+
+// Feature: fhir-facade, Property 2: A Vital Signs Record Yields One Observation per Measurement
+// *For any* vital signs record, NewVitalSigns SHALL yield one Observation per recorded
+// measurement (the systolic and diastolic pressures together), each with exactly one of a
+// value, a data absent reason or components, an id that ParseObservationID maps back to
+// the record, and a UCUM code only for the unit MedScreen stores.
+
+// TestProperty_VitalSignsOnePerMeasurement tests Property 2
+func TestProperty_VitalSignsOnePerMeasurement(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		b := genVitalBulgu(rt)
+		observations := NewVitalSigns(b)
+
+		want := 0
+		for _, raw := range []*string{b.Ates, b.Nabiz, b.Solunum, b.Saturasyon, b.Boy, b.Agirlik} {
+			if raw != nil {
+				want++
+			}
+		}
+		if b.SistolikKanBasinciDegeri != nil || b.DiastolikKanBasinciDegeri != nil {
+			want++
+		}
+		if len(observations) != want {
+			rt.Fatalf("Expected %d observations, got %d", want, len(observations))
+		}
+
+		for _, o := range observations {
+			kodu, slug, lab, ok := ParseObservationID(o.ID)
+			if !ok || lab || kodu != b.HastaVitalFizikiBulguKodu || o.ID != kodu+"-"+slug {
+				rt.Fatalf("Id %q does not map back to %q", o.ID, b.HastaVitalFizikiBulguKodu)
+			}
+			if o.Subject == nil || o.Subject.Reference != "Patient/"+b.HastaBasvuru.HastaKodu {
+				rt.Fatalf("Observation %s has no patient", o.ID)
+			}
+
+			kinds := 0
+			for _, present := range []bool{o.ValueQuantity != nil, o.DataAbsentReason != nil, len(o.Component) > 0} {
+				if present {
+					kinds++
+				}
+			}
+			if kinds != 1 {
+				rt.Fatalf("Observation %s has %d of value, data absent reason and components", o.ID, kinds)
+			}
+
+			quantities := []*Quantity{o.ValueQuantity}
+			for _, c := range o.Component {
+				if (c.ValueQuantity == nil) == (c.DataAbsentReason == nil) {
+					rt.Fatalf("A component of %s must have either a value or a data absent reason", o.ID)
+				}
+				quantities = append(quantities, c.ValueQuantity)
+			}
+			for _, q := range quantities {
+				if q != nil && q.Code != "" && !slices.ContainsFunc(append(vitalSigns, systolic), func(s vitalSign) bool {
+					return s.ucum == q.Code && s.birim == q.Unit
+				}) {
+					rt.Fatalf("Observation %s has UCUM code %q for unit %q", o.ID, q.Code, q.Unit)
+				}
+			}
+		}
+	})
+}
+
+// Feature: fhir-facade, Property 3: Personal Data Is Masked While Resources Are Built
+// *For any* patient and staff member, under the default masking policy a DIGER caller
+// SHALL see the partial TC kimlik numarası and only the birth year, a bedside caller
+// neither of them and the initials of the patient, a HEKIM everything; the masked
+// resources SHALL still conform to FHIR R4.
+
+// TestProperty_PersonalDataMaskedWhileBuilding tests Property 3
+func TestProperty_PersonalDataMaskedWhileBuilding(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r4 := loadR4(t)
+	policy := masking.Default()
+
+	rapid.Check(t, func(rt *rapid.T) {
+		hasta := genHasta(rt)
+		tc := rapid.StringMatching(`[1-9][0-9]{10}`).Draw(rt, "tc_kimlik")
+		hasta.TCKimlikNumarasi = &tc
+		personel := genPersonel(rt)
+		role := rapid.SampledFrom([]string{"HEKIM", "DIGER", "bedside"}).Draw(rt, "rol")
+
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Set(middleware.ContextKeyUserRole, role)
+		if role == "bedside" {
+			c.Set(middleware.ContextKeyUserRole, "HEMSIRE")
+			c.Set(masking.ContextKeyPurpose, masking.PurposeBedside)
+		}
+		mask := func(entity, field, value string) (string, bool) {
+			return policy.Field(c, entity, field, value)
+		}
+
+		p := NewPatient(hasta, mask)
+		r4.validate(rt, p)
+		r4.validate(rt, NewPractitioner(personel, mask))
+
+		var tcs []string
+		for _, id := range p.Identifier {
+			if id.System == SystemTCKimlik {
+				tcs = append(tcs, id.Value)
+			}
+		}
+		year := hasta.DogumTarihi.Format("2006")
+		switch role {
+		case "HEKIM":
+			if !slices.Equal(tcs, []string{tc}) || p.BirthDate != hasta.DogumTarihi.Format(time.DateOnly) {
+				rt.Fatalf("HEKIM must see the patient in full, got %v %q", tcs, p.BirthDate)
+			}
+		case "DIGER":
+			if !slices.Equal(tcs, []string{masking.Partial(tc)}) || p.BirthDate != year {
+				rt.Fatalf("DIGER must see the partial TC and the birth year, got %v %q", tcs, p.BirthDate)
+			}
+		default:
+			if len(tcs) != 0 || p.BirthDate != "" {
+				rt.Fatalf("A bedside tablet must not see the TC or the birth date, got %v %q", tcs, p.BirthDate)
+			}
+			if got := p.Name[0].Family; got != masking.Initials(hasta.Soyadi) {
+				rt.Fatalf("A bedside tablet must see the initials of the family name, got %q", got)
+			}
+		}
+	})
+}
+
+// Feature: fhir-facade, Property 4: Date Parameters Cover Their Precision
+// *For any* time and precision (year, month, day, second), ParseDate SHALL parse the
+// time written at that precision, with any supported prefix, to the range of that
+// precision which contains it; other prefixes and values SHALL be rejected.
+
+// TestProperty_DateParametersCoverTheirPrecision tests Property 4
+func TestProperty_DateParametersCoverTheirPrecision(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		at := zaman(rt, "zaman").UTC()
+		precision := rapid.SampledFrom([]struct {
+			layout string
+			next   func(time.Time) time.Time
+		}{
+			{"2006", func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }},
+			{"2006-01", func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }},
+			{time.DateOnly, func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }},
+			{time.RFC3339, func(t time.Time) time.Time { return t.Add(time.Second) }},
+		}).Draw(rt, "hassasiyet")
+		prefix := rapid.SampledFrom(append([]string{""}, datePrefixes...)).Draw(rt, "onek")
+
+		d, err := ParseDate(prefix + at.Format(precision.layout))
+		if err != nil {
+			rt.Fatalf("Failed to parse %s%s: %v", prefix, at.Format(precision.layout), err)
+		}
+		if want := cmpOr(prefix, "eq"); d.prefix != want {
+			rt.Fatalf("Expected prefix %q, got %q", want, d.prefix)
+		}
+		if at.Before(d.start) || !at.Before(d.end) || !d.end.Equal(precision.next(d.start)) {
+			rt.Fatalf("%s does not cover %v: [%v, %v)", at.Format(precision.layout), at, d.start, d.end)
+		}
+
+		invalid := rapid.SampledFrom([]string{"ne", "sa", "eb", "ap"}).Draw(rt, "gecersiz_onek") + at.Format(time.DateOnly)
+		if _, err := ParseDate(invalid); !errors.Is(err, ErrInvalidSearch) {
+			rt.Fatalf("Expected %q to be rejected, got %v", invalid, err)
+		}
+	})
+
+	for _, value := range []string{"", "yesterday", "2026-13", "2026-02-30", "12/03/2026"} {
+		if _, err := ParseDate(value); !errors.Is(err, ErrInvalidSearch) {
+			t.Errorf("Expected %q to be rejected, got %v", value, err)
+		}
+	}
+}
+
+func cmpOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+// TestParseSearchRejectsUnknownParameters checks that a misspelt parameter cannot widen a search
+func TestParseSearchRejectsUnknownParameters(t *testing.T) {
+	for _, query := range []string{"patinet=H1", "subject=Patient/H1", "_format=xml", "category=vital-signs"} {
+		values, _ := url.ParseQuery(query)
+		if _, err := ParseSearch(values, "Encounter"); !errors.Is(err, ErrInvalidSearch) {
+			t.Errorf("Expected %q to be rejected for Encounter, got %v", query, err)
+		}
+	}
+
+	values, _ := url.ParseQuery("patient=Patient/H1&date=ge2026-01-01&_count=500&page=0&_format=json")
+	s, err := ParseSearch(values, "Encounter")
+	if err != nil {
+		t.Fatalf("Failed to parse a valid search: %v", err)
+	}
+	if s.Page != 1 || s.Count != MaxCount {
+		t.Errorf("Expected page 1 and count %d, got %d and %d", MaxCount, s.Page, s.Count)
+	}
+	if patient, err := s.Reference("patient", "Patient"); err != nil || patient != "H1" {
+		t.Errorf("Expected patient H1, got %q (%v)", patient, err)
+	}
+	if _, err := s.Reference("patient", "Encounter"); !errors.Is(err, ErrInvalidSearch) {
+		t.Errorf("Expected a reference to another resource type to be rejected, got %v", err)
+	}
+}
+
+// Feature: fhir-facade, Property 5: Searchsets Link Their Neighbouring Pages
+// *For any* page of a search, the Bundle SHALL link itself, the next page exactly when
+// more results exist and the previous page exactly when it is not the first, keeping
+// the search parameters, and give every entry its absolute URL.
+
+// TestProperty_SearchsetsLinkNeighbouringPages tests Property 5
+func TestProperty_SearchsetsLinkNeighbouringPages(t *testing.T) {
+	base := "https://medscreen.example" + BasePath
+	rapid.Check(t, func(rt *rapid.T) {
+		self, _ := url.Parse(BasePath + "/Condition?patient=H1&recorded-date=ge2026-01-01")
+		s := Search{
+			values: self.Query(),
+			Page:   rapid.IntRange(1, 20).Draw(rt, "page"),
+			Count:  rapid.IntRange(1, MaxCount).Draw(rt, "count"),
+		}
+		more := rapid.Bool().Draw(rt, "more")
+		resources := []Resource{NewCondition(genBasvuruTani(rt))}
+
+		b := Searchset(base, self, s, nil, more, resources)
+		links := make(map[string]string)
+		for _, l := range b.Link {
+			links[l.Relation] = l.URL
+		}
+		_, hasNext := links["next"]
+		_, hasPrevious := links["previous"]
+		if hasNext != more || hasPrevious != (s.Page > 1) {
+			rt.Fatalf("Page %d (more %v) has links %v", s.Page, more, links)
+		}
+		for relation, link := range links {
+			u, err := url.Parse(link)
+			if err != nil || !strings.HasPrefix(link, base+"/Condition?") {
+				rt.Fatalf("The %s link %q is not an absolute URL of the search", relation, link)
+			}
+			if u.Query().Get("patient") != "H1" || u.Query().Get("recorded-date") != "ge2026-01-01" {
+				rt.Fatalf("The %s link %q lost the search parameters", relation, link)
+			}
+		}
+		if b.Entry[0].FullURL != base+"/Condition/"+resources[0].(Condition).ID {
+			rt.Fatalf("Unexpected fullUrl %q", b.Entry[0].FullURL)
+		}
+	})
+}
+
+// Feature: fhir-facade, Property 6: Errors Are OperationOutcomes
+// *For any* error a handler or middleware behind Middleware sends as a standard error
+// response, the client SHALL receive an OperationOutcome with the FHIR media type, the
+// same status and the issue type of that status; successful responses pass unchanged.
+
+// TestProperty_ErrorsAreOperationOutcomes tests Property 6
+func TestProperty_ErrorsAreOperationOutcomes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r4 := loadR4(t)
+
+	rapid.Check(t, func(rt *rapid.T) {
+		status := rapid.SampledFrom([]int{200, 400, 401, 403, 404, 405, 429, 500, 503}).Draw(rt, "status")
+		fhirFirst := rapid.Bool().Draw(rt, "fhir_content_type")
+
+		router := gin.New()
+		router.GET("/fhir/R4/Patient/:id", Middleware(), func(c *gin.Context) {
+			if status == http.StatusOK {
+				Write(c, status, NewPatient(&models.Hasta{HastaKodu: c.Param("id")}, Unmasked))
+				return
+			}
+			if fhirFirst {
+				// As when the audit trail fails after the handler wrote its resource
+				c.Header("Content-Type", ContentType)
+			}
+			utils.SendErrorResponse(c, status, "SOME_ERROR", "Something went wrong", nil)
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fhir/R4/Patient/H1", nil))
+
+		if w.Code != status || w.Header().Get("Content-Type") != ContentType {
+			rt.Fatalf("Expected status %d as FHIR JSON, got %d %q", status, w.Code, w.Header().Get("Content-Type"))
+		}
+		var resource map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &resource); err != nil {
+			rt.Fatalf("Response is not JSON: %s", w.Body.String())
+		}
+		r4.validate(rt, resource)
+
+		if status == http.StatusOK {
+			if resource["resourceType"] != "Patient" {
+				rt.Fatalf("A success must pass unchanged, got %s", w.Body.String())
+			}
+			return
+		}
+		var outcome OperationOutcome
+		_ = json.Unmarshal(w.Body.Bytes(), &outcome)
+		want, ok := issueCodes[status]
+		if !ok {
+			want = "processing"
+		}
+		if outcome.ResourceType != "OperationOutcome" || len(outcome.Issue) != 1 || outcome.Issue[0].Code != want ||
+			outcome.Issue[0].Details == nil || outcome.Issue[0].Details.Text != "SOME_ERROR" {
+			rt.Fatalf("Expected an OperationOutcome with issue %q, got %s", want, w.Body.String())
+		}
+	})
+}
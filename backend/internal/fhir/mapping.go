@@ -0,0 +1,509 @@
+package fhir
+
+import (
+	"fmt"
+	"medscreen/internal/measurement"
+	"medscreen/internal/models"
+	"strings"
+	"time"
+)
+
+// Masker masks a field of a VEM entity ("hasta", "personel") for the caller; keep is false
+// when the field must be left out. masking.Policy.Field provides one per request.
+type Masker func(entity, field, value string) (masked string, keep bool)
+
+// Unmasked is the Masker that leaves every field as it is
+func Unmasked(entity, field, value string) (string, bool) {
+	return value, true
+}
+
+// NewPatient maps a patient
+func NewPatient(h *models.Hasta, mask Masker) Patient {
+	p := Patient{
+		ResourceType: "Patient",
+		ID:           h.HastaKodu,
+		Meta:         lastUpdated(h.KayitZamani, h.GuncellemeZamani),
+		Identifier:   []Identifier{{Use: "usual", System: SystemHastaKodu, Value: h.HastaKodu}},
+		Gender:       gender(h.Cinsiyet),
+	}
+	if h.TCKimlikNumarasi != nil && *h.TCKimlikNumarasi != "" {
+		if tc, keep := mask("hasta", "tc_kimlik_numarasi", *h.TCKimlikNumarasi); keep {
+			p.Identifier = append(p.Identifier, Identifier{Use: "official", System: SystemTCKimlik, Value: tc})
+		}
+	}
+	if name, ok := humanName(mask, "hasta", h.Ad, h.Soyadi); ok {
+		p.Name = []HumanName{name}
+	}
+	if !h.DogumTarihi.IsZero() {
+		// The year rule keeps "1984" of "1984-05-12", which is still a valid FHIR date
+		if birthDate, keep := mask("hasta", "dogum_tarihi", h.DogumTarihi.Format(time.DateOnly)); keep {
+			p.BirthDate = birthDate
+		}
+	}
+	return p
+}
+
+// NewEncounter maps a patient visit
+func NewEncounter(b *models.HastaBasvuru) Encounter {
+	e := Encounter{
+		ResourceType: "Encounter",
+		ID:           b.HastaBasvuruKodu,
+		Meta:         lastUpdated(b.KayitZamani, b.GuncellemeZamani),
+		Identifier:   []Identifier{{Use: "official", System: SystemProtokolNumarasi, Value: b.BasvuruProtokolNumarasi}},
+		Status:       "in-progress",
+		Class:        encounterClass(b),
+		Subject:      reference("Patient", b.HastaKodu),
+		Period:       &Period{Start: dateTime(b.HastaKabulZamani)},
+	}
+	if b.CikisZamani != nil {
+		e.Status = "finished"
+		e.Period.End = dateTime(*b.CikisZamani)
+	}
+	if b.HekimKodu != nil && *b.HekimKodu != "" {
+		e.Participant = []EncounterParticipant{{
+			Type:       []CodeableConcept{{Coding: []Coding{{System: SystemParticipationType, Code: "ATND", Display: "attender"}}}},
+			Individual: reference("Practitioner", *b.HekimKodu),
+		}}
+	}
+	return e
+}
+
+// encounterClass derives the class of a visit from its state, falling back to the type of
+// the patient when the visit was loaded with it
+func encounterClass(b *models.HastaBasvuru) Coding {
+	inpatient := Coding{System: SystemActCode, Code: "IMP", Display: "inpatient encounter"}
+	ambulatory := Coding{System: SystemActCode, Code: "AMB", Display: "ambulatory"}
+
+	switch strings.ToUpper(deref(b.BasvuruDurumu)) {
+	case "YATIS", "YOGUN_BAKIM":
+		return inpatient
+	case "ACIL":
+		return Coding{System: SystemActCode, Code: "EMER", Display: "emergency"}
+	}
+	if b.Hasta != nil && strings.EqualFold(deref(b.Hasta.HastaTipi), "Yatan") {
+		return inpatient
+	}
+	return ambulatory
+}
+
+// vitalSign is a measurement of a vital signs record and its LOINC coding
+type vitalSign struct {
+	slug    string // suffix of the Observation id
+	loinc   string
+	display string
+	profile string
+	birim   string // unit MedScreen stores the measurement in
+	ucum    string // UCUM code of that unit
+	value   func(*models.HastaVitalFizikiBulgu) *measurement.Sayisal
+}
+
+var vitalSigns = []vitalSign{
+	{"body-temperature", "8310-5", "Body temperature", ProfileBodyTemperature, "°C", "Cel",
+		func(b *models.HastaVitalFizikiBulgu) *measurement.Sayisal { return b.AtesSayisal }},
+	{"heart-rate", "8867-4", "Heart rate", ProfileHeartRate, "/dk", "/min",
+		func(b *models.HastaVitalFizikiBulgu) *measurement.Sayisal { return b.NabizSayisal }},
+	{"respiratory-rate", "9279-1", "Respiratory rate", ProfileRespiratoryRate, "/dk", "/min",
+		func(b *models.HastaVitalFizikiBulgu) *measurement.Sayisal { return b.SolunumSayisal }},
+	{"oxygen-saturation", "2708-6", "Oxygen saturation in Arterial blood", ProfileOxygenSaturation, "%", "%",
+		func(b *models.HastaVitalFizikiBulgu) *measurement.Sayisal { return b.SaturasyonSayisal }},
+	{"body-height", "8302-2", "Body height", ProfileBodyHeight, "cm", "cm",
+		func(b *models.HastaVitalFizikiBulgu) *measurement.Sayisal { return b.BoySayisal }},
+	{"body-weight", "29463-7", "Body weight", ProfileBodyWeight, "kg", "kg",
+		func(b *models.HastaVitalFizikiBulgu) *measurement.Sayisal { return b.AgirlikSayisal }},
+}
+
+// The blood pressure panel and its components
+var (
+	bloodPressure = vitalSign{slug: "blood-pressure", loinc: "85354-9", display: "Blood pressure panel with all children optional", profile: ProfileBloodPressure}
+	systolic      = vitalSign{loinc: "8480-6", display: "Systolic blood pressure", birim: "mmHg", ucum: "mm[Hg]"}
+	diastolic     = vitalSign{loinc: "8462-4", display: "Diastolic blood pressure", birim: "mmHg", ucum: "mm[Hg]"}
+)
+
+// NewVitalSigns maps a vital signs record to one Observation per measurement it holds, with
+// ids "<hasta_vital_fiziki_bulgu_kodu>-<measurement>". The systolic and diastolic pressures
+// are the components of a single blood pressure Observation. The measurements must have
+// been parsed (HastaVitalFizikiBulgu.ParseSayisalDegerler).
+func NewVitalSigns(b *models.HastaVitalFizikiBulgu) []Observation {
+	var observations []Observation
+	for _, sign := range vitalSigns {
+		value := sign.value(b)
+		if value == nil {
+			continue
+		}
+		o := vitalSignObservation(b, sign)
+		o.ValueQuantity, o.DataAbsentReason = sign.quantity(value)
+		observations = append(observations, o)
+	}
+
+	sis, dia := b.SistolikKanBasinciDegeriSayisal, b.DiastolikKanBasinciDegeriSayisal
+	if sis != nil || dia != nil {
+		o := vitalSignObservation(b, bloodPressure)
+		for _, c := range []struct {
+			sign  vitalSign
+			value *measurement.Sayisal
+		}{{systolic, sis}, {diastolic, dia}} {
+			component := ObservationComponent{Code: c.sign.code()}
+			component.ValueQuantity, component.DataAbsentReason = c.sign.quantity(c.value)
+			o.Component = append(o.Component, component)
+		}
+		observations = append(observations, o)
+	}
+	return observations
+}
+
+func vitalSignObservation(b *models.HastaVitalFizikiBulgu, sign vitalSign) Observation {
+	o := Observation{
+		ResourceType: "Observation",
+		ID:           b.HastaVitalFizikiBulguKodu + "-" + sign.slug,
+		Meta:         lastUpdated(b.KayitZamani, b.GuncellemeZamani),
+		Status:       "final",
+		Category: []CodeableConcept{{Coding: []Coding{{
+			System: SystemObservationCategory, Code: CategoryVitalSigns, Display: "Vital Signs",
+		}}}},
+		Code:              sign.code(),
+		Encounter:         reference("Encounter", b.HastaBasvuruKodu),
+		EffectiveDateTime: dateTime(b.IslemZamani),
+	}
+	if o.Meta == nil {
+		o.Meta = &Meta{}
+	}
+	o.Meta.Profile = []string{ProfileVitalSigns, sign.profile}
+	if b.HastaBasvuru != nil {
+		o.Subject = reference("Patient", b.HastaBasvuru.HastaKodu)
+	}
+	if b.HemsireKodu != nil && *b.HemsireKodu != "" {
+		o.Performer = []Reference{*reference("Practitioner", *b.HemsireKodu)}
+	}
+	return o
+}
+
+func (sign vitalSign) code() CodeableConcept {
+	c := CodeableConcept{
+		Coding: []Coding{{System: SystemLOINC, Code: sign.loinc, Display: sign.display}},
+		Text:   sign.display,
+	}
+	// Saturation is measured at the bedside by pulse oximetry
+	if sign.loinc == "2708-6" {
+		c.Coding = append(c.Coding, Coding{System: SystemLOINC, Code: "59408-5", Display: "Oxygen saturation in Arterial blood by Pulse oximetry"})
+	}
+	return c
+}
+
+// quantity maps a parsed measurement; one that is missing or could not be parsed has a
+// data absent reason instead. Units other than the one MedScreen stores are passed on as
+// text, without a UCUM code.
+func (sign vitalSign) quantity(value *measurement.Sayisal) (*Quantity, *CodeableConcept) {
+	if value == nil {
+		return nil, &CodeableConcept{Coding: []Coding{{System: SystemDataAbsentReason, Code: "unknown", Display: "Unknown"}}}
+	}
+	if value.Deger == nil {
+		return nil, &CodeableConcept{
+			Coding: []Coding{{System: SystemDataAbsentReason, Code: "error", Display: "Error"}},
+			Text:   value.Hata,
+		}
+	}
+	q := &Quantity{Value: value.Deger, Comparator: value.Isaret, Unit: value.Birim}
+	if value.Birim == sign.birim {
+		q.System, q.Code = SystemUCUM, sign.ucum
+	}
+	return q, nil
+}
+
+// VitalSignSlugs returns the suffixes of the ids of vital signs Observations
+func VitalSignSlugs() []string {
+	slugs := []string{bloodPressure.slug}
+	for _, sign := range vitalSigns {
+		slugs = append(slugs, sign.slug)
+	}
+	return slugs
+}
+
+// Observation categories of the facade, the values of the category search parameter
+const (
+	CategoryVitalSigns = "vital-signs"
+	CategoryLaboratory = "laboratory"
+)
+
+// labPrefix starts the ids of laboratory Observations, so they cannot clash with vital signs
+const labPrefix = "lab-"
+
+// ParseObservationID returns the VEM code an Observation id was made from and, for vital
+// signs, the measurement. ok is false for ids the facade does not issue.
+func ParseObservationID(id string) (kodu, slug string, lab, ok bool) {
+	if kodu, found := strings.CutPrefix(id, labPrefix); found {
+		return kodu, "", true, kodu != ""
+	}
+	for _, slug := range VitalSignSlugs() {
+		if kodu, found := strings.CutSuffix(id, "-"+slug); found && kodu != "" {
+			return kodu, slug, false, true
+		}
+	}
+	return "", "", false, false
+}
+
+// NewLabResult maps a test result. Laboratories write free text units, so quantities carry
+// them as text only; the critical value range becomes the reference range.
+func NewLabResult(t *models.TetkikSonuc) Observation {
+	o := Observation{
+		ResourceType: "Observation",
+		ID:           labPrefix + t.TetkikSonucKodu,
+		Meta:         lastUpdated(t.KayitZamani, nil),
+		Status:       "preliminary",
+		Category: []CodeableConcept{{Coding: []Coding{{
+			System: SystemObservationCategory, Code: CategoryLaboratory, Display: "Laboratory",
+		}}}},
+		Code:              CodeableConcept{Text: t.TetkikAdi},
+		Encounter:         reference("Encounter", t.HastaBasvuruKodu),
+		EffectiveDateTime: dateTime(t.KayitZamani),
+	}
+	if t.OnayZamani != nil {
+		o.Status = "final"
+		o.Issued = dateTime(*t.OnayZamani)
+	}
+	if t.HastaBasvuru != nil {
+		o.Subject = reference("Patient", t.HastaBasvuru.HastaKodu)
+	}
+
+	switch {
+	case t.SonucDegeriSayisal.Gecerli():
+		s := t.SonucDegeriSayisal
+		o.ValueQuantity = &Quantity{Value: s.Deger, Comparator: s.Isaret, Unit: s.Birim}
+	case t.SonucDegeri != nil && strings.TrimSpace(*t.SonucDegeri) != "":
+		o.ValueString = *t.SonucDegeri
+	}
+
+	if t.KritikDegerAraligi != nil && strings.TrimSpace(*t.KritikDegerAraligi) != "" {
+		r := ReferenceRange{Text: *t.KritikDegerAraligi}
+		if aralik, ok := t.KritikDegerAraligiSayisal.Aralik(); ok {
+			if aralik.Alt != nil {
+				r.Low = &Quantity{Value: aralik.Alt, Unit: aralik.Birim}
+			}
+			if aralik.Ust != nil {
+				r.High = &Quantity{Value: aralik.Ust, Unit: aralik.Birim}
+			}
+		}
+		o.ReferenceRange = []ReferenceRange{r}
+	}
+
+	if t.Degerlendirme != nil {
+		if coding, ok := interpretations[t.Degerlendirme.Durum]; ok {
+			o.Interpretation = []CodeableConcept{{Coding: []Coding{coding}}}
+		}
+	}
+	return o
+}
+
+var interpretations = map[models.TetkikDurumu]Coding{
+	models.TetkikDurumuNormal: {System: SystemInterpretation, Code: "N", Display: "Normal"},
+	models.TetkikDurumuDusuk:  {System: SystemInterpretation, Code: "L", Display: "Low"},
+	models.TetkikDurumuYuksek: {System: SystemInterpretation, Code: "H", Display: "High"},
+}
+
+// NewCondition maps a diagnosis; tani_kodu is an ICD-10 code
+func NewCondition(t *models.BasvuruTani) Condition {
+	c := Condition{
+		ResourceType: "Condition",
+		ID:           t.BasvuruTaniKodu,
+		Meta:         lastUpdated(t.KayitZamani, t.GuncellemeZamani),
+		Category: []CodeableConcept{{Coding: []Coding{{
+			System: SystemConditionCategory, Code: "encounter-diagnosis", Display: "Encounter Diagnosis",
+		}}}},
+		Code:         &CodeableConcept{Coding: []Coding{{System: SystemICD10, Code: t.TaniKodu}}},
+		Subject:      *reference("Patient", t.HastaKodu),
+		Encounter:    reference("Encounter", t.HastaBasvuruKodu),
+		RecordedDate: dateTime(t.TaniZamani),
+	}
+	switch strings.ToUpper(deref(t.TaniTuru)) {
+	case "KESIN":
+		c.VerificationStatus = &CodeableConcept{Coding: []Coding{{System: SystemConditionVerStatus, Code: "confirmed", Display: "Confirmed"}}}
+	case "ON_TANI":
+		c.VerificationStatus = &CodeableConcept{Coding: []Coding{{System: SystemConditionVerStatus, Code: "provisional", Display: "Provisional"}}}
+	}
+	if t.HekimKodu != nil && *t.HekimKodu != "" {
+		c.Recorder = reference("Practitioner", *t.HekimKodu)
+	}
+	return c
+}
+
+// NewAllergyIntolerance maps a medical information record of type ALERJI. VEM records the
+// allergen as a local code (tibbi_bilgi_alt_turu_kodu, e.g. PENISILIN), so it is sent as text.
+func NewAllergyIntolerance(t *models.HastaTibbiBilgi) AllergyIntolerance {
+	a := AllergyIntolerance{
+		ResourceType: "AllergyIntolerance",
+		ID:           t.HastaTibbiBilgiKodu,
+		Meta:         lastUpdated(t.KayitZamani, t.GuncellemeZamani),
+		ClinicalStatus: &CodeableConcept{Coding: []Coding{{
+			System: SystemAllergyClinical, Code: "active", Display: "Active",
+		}}},
+		Patient:      *reference("Patient", t.HastaKodu),
+		RecordedDate: dateTime(t.KayitZamani),
+	}
+	if allergen := deref(t.TibbiBilgiAltTuruKodu); allergen != "" {
+		a.Code = &CodeableConcept{Text: allergen}
+	} else if aciklama := deref(t.Aciklama); aciklama != "" {
+		a.Code = &CodeableConcept{Text: aciklama}
+	}
+	if aciklama := deref(t.Aciklama); aciklama != "" {
+		a.Note = []Annotation{{Text: aciklama}}
+	}
+	return a
+}
+
+// periodUnits maps the VEM units of ilac_kullanim_periyodu to the units of Timing
+var periodUnits = map[string]string{
+	"DAKIKA": "min",
+	"SAAT":   "h",
+	"GUN":    "d",
+	"HAFTA":  "wk",
+	"AY":     "mo",
+	"YIL":    "a",
+}
+
+// NewMedicationRequests maps the drugs of a prescription, one MedicationRequest each
+func NewMedicationRequests(r *models.Recete) []MedicationRequest {
+	requests := make([]MedicationRequest, 0, len(r.Ilaclar))
+	for i := range r.Ilaclar {
+		requests = append(requests, NewMedicationRequest(r, &r.Ilaclar[i]))
+	}
+	return requests
+}
+
+// NewMedicationRequest maps a drug of a prescription; the prescription is its group
+func NewMedicationRequest(r *models.Recete, ilac *models.ReceteIlac) MedicationRequest {
+	m := MedicationRequest{
+		ResourceType: "MedicationRequest",
+		ID:           ilac.ReceteIlacKodu,
+		Meta:         lastUpdated(ilac.KayitZamani, ilac.GuncellemeZamani),
+		Status:       "active",
+		Intent:       "order",
+		MedicationCodeableConcept: CodeableConcept{
+			Coding: []Coding{{System: SystemGTIN, Code: ilac.Barkod}},
+			Text:   deref(ilac.IlacAdi),
+		},
+		Encounter:       reference("Encounter", r.HastaBasvuruKodu),
+		AuthoredOn:      dateTime(r.ReceteZamani),
+		Requester:       reference("Practitioner", r.HekimKodu),
+		GroupIdentifier: &Identifier{System: SystemReceteKodu, Value: r.ReceteKodu},
+	}
+	if r.AktiflikBilgisi != int(models.Aktif) {
+		m.Status = "cancelled"
+	}
+	if r.HastaBasvuru != nil {
+		m.Subject = *reference("Patient", r.HastaBasvuru.HastaKodu)
+	}
+	if numara := deref(r.MedulaEReceteNumarasi); numara != "" {
+		m.Identifier = []Identifier{{System: SystemEReceteNumarasi, Value: numara}}
+	}
+
+	dosage := Dosage{Text: dosageText(ilac)}
+	if deger, err := measurement.ParseDeger(ilac.IlacKullanimDozu); err == nil {
+		sayi := deger.Sayi
+		dosage.DoseAndRate = []DoseAndRate{{DoseQuantity: &Quantity{Value: &sayi, Unit: ilac.DozBirim}}}
+	}
+	if unit, ok := periodUnits[strings.ToUpper(ilac.IlacKullanimPeriyoduBirimi)]; ok && ilac.IlacKullanimPeriyodu != nil {
+		period := float64(*ilac.IlacKullanimPeriyodu)
+		dosage.Timing = &Timing{Repeat: &TimingRepeat{Frequency: 1, Period: &period, PeriodUnit: unit}}
+	}
+	if sekil := deref(ilac.IlacKullanimSekli); sekil != "" {
+		dosage.Route = &CodeableConcept{Text: sekil}
+	}
+	m.DosageInstruction = []Dosage{dosage}
+
+	if ilac.KutuAdeti != nil {
+		kutu := float64(*ilac.KutuAdeti)
+		m.DispenseRequest = &DispenseRequest{Quantity: &Quantity{Value: &kutu, Unit: "kutu"}}
+	}
+	return m
+}
+
+// dosageText writes the dosage as the prescription gives it, e.g. "500 mg, her 8 SAAT, PO"
+func dosageText(ilac *models.ReceteIlac) string {
+	parts := []string{strings.TrimSpace(ilac.IlacKullanimDozu + " " + ilac.DozBirim)}
+	if ilac.IlacKullanimPeriyodu != nil {
+		parts = append(parts, fmt.Sprintf("her %d %s", *ilac.IlacKullanimPeriyodu, ilac.IlacKullanimPeriyoduBirimi))
+	}
+	if sekil := deref(ilac.IlacKullanimSekli); sekil != "" {
+		parts = append(parts, sekil)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// NewPractitioner maps a staff member
+func NewPractitioner(p *models.Personel, mask Masker) Practitioner {
+	pr := Practitioner{
+		ResourceType: "Practitioner",
+		ID:           p.PersonelKodu,
+		Meta:         lastUpdated(p.KayitZamani, p.GuncellemeZamani),
+		Identifier:   []Identifier{{Use: "usual", System: SystemPersonelKodu, Value: p.PersonelKodu}},
+		Active:       p.AktiflikBilgisi == int(models.Aktif),
+	}
+	if tescil := deref(p.TescilNumarasi); tescil != "" {
+		pr.Identifier = append(pr.Identifier, Identifier{Use: "official", System: SystemTescilNumarasi, Value: tescil})
+	}
+	if tc := deref(p.TCKimlikNumarasi); tc != "" {
+		if tc, keep := mask("personel", "tc_kimlik_numarasi", tc); keep {
+			pr.Identifier = append(pr.Identifier, Identifier{Use: "official", System: SystemTCKimlik, Value: tc})
+		}
+	}
+	if name, ok := humanName(mask, "personel", p.Ad, p.Soyadi); ok {
+		pr.Name = []HumanName{name}
+	}
+	if brans := deref(p.MedulaBransKodu); brans != "" {
+		pr.Qualification = []PractitionerQualification{{Code: CodeableConcept{Coding: []Coding{{System: SystemMedulaBrans, Code: brans}}}}}
+	}
+	return pr
+}
+
+// humanName masks and maps a name; ok is false when nothing of it may be shown
+func humanName(mask Masker, entity, ad, soyadi string) (HumanName, bool) {
+	name := HumanName{Use: "official"}
+	if ad != "" {
+		if masked, keep := mask(entity, "ad", ad); keep {
+			name.Given = strings.Fields(masked)
+		}
+	}
+	if soyadi != "" {
+		if masked, keep := mask(entity, "soyadi", soyadi); keep {
+			name.Family = masked
+		}
+	}
+	return name, name.Family != "" || len(name.Given) > 0
+}
+
+// gender maps the VEM cinsiyet codes (E, K)
+func gender(cinsiyet *string) string {
+	switch strings.ToUpper(deref(cinsiyet)) {
+	case "":
+		return ""
+	case "E":
+		return "male"
+	case "K":
+		return "female"
+	}
+	return "unknown"
+}
+
+func reference(resourceType, id string) *Reference {
+	return &Reference{Reference: resourceType + "/" + id}
+}
+
+func lastUpdated(kayit time.Time, guncelleme *time.Time) *Meta {
+	if guncelleme != nil {
+		return &Meta{LastUpdated: dateTime(*guncelleme)}
+	}
+	if kayit.IsZero() {
+		return nil
+	}
+	return &Meta{LastUpdated: dateTime(kayit)}
+}
+
+func dateTime(t time.Time) string {
+	return t.Format(time.RFC3339)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return strings.TrimSpace(*s)
+}
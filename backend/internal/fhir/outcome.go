@@ -0,0 +1,116 @@
+package fhir
+
+import (
+	"bytes"
+	"encoding/json"
+	"medscreen/internal/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OperationOutcome is the FHIR resource errors are reported in
+type OperationOutcome struct {
+	ResourceType string  `json:"resourceType"`
+	Issue        []Issue `json:"issue"`
+}
+
+// Issue is a problem an OperationOutcome reports
+type Issue struct {
+	Severity    string           `json:"severity"`
+	Code        string           `json:"code"`
+	Details     *CodeableConcept `json:"details,omitempty"`
+	Diagnostics string           `json:"diagnostics,omitempty"`
+}
+
+// issueCodes maps HTTP statuses to the FHIR issue types
+var issueCodes = map[int]string{
+	http.StatusBadRequest:          "invalid",
+	http.StatusUnauthorized:        "login",
+	http.StatusForbidden:           "forbidden",
+	http.StatusNotFound:            "not-found",
+	http.StatusMethodNotAllowed:    "not-supported",
+	http.StatusTooManyRequests:     "throttled",
+	http.StatusInternalServerError: "exception",
+}
+
+// NewOperationOutcome reports an error; code is the MedScreen error code (constants.ERROR_*)
+func NewOperationOutcome(status int, code, message string) OperationOutcome {
+	issueCode, ok := issueCodes[status]
+	if !ok {
+		issueCode = "processing"
+	}
+	issue := Issue{Severity: "error", Code: issueCode, Diagnostics: message}
+	if code != "" {
+		issue.Details = &CodeableConcept{Text: code}
+	}
+	return OperationOutcome{ResourceType: "OperationOutcome", Issue: []Issue{issue}}
+}
+
+// Write sends a resource as FHIR JSON
+func Write(c *gin.Context, status int, resource interface{}) {
+	c.Header("Content-Type", ContentType)
+	c.JSON(status, resource)
+}
+
+// outcomeWriter turns the standard MedScreen error responses of the middlewares in front
+// of the facade (authentication, authorization, audit) into OperationOutcomes. Other
+// responses pass through unbuffered.
+type outcomeWriter struct {
+	gin.ResponseWriter
+	decided    bool
+	converting bool
+	body       bytes.Buffer
+}
+
+func (w *outcomeWriter) decide() {
+	if !w.decided {
+		w.decided = true
+		// A handler may have set the FHIR type before the audit failed
+		contentType := w.Header().Get("Content-Type")
+		w.converting = w.Status() >= 400 &&
+			(strings.HasPrefix(contentType, "application/json") || strings.HasPrefix(contentType, "application/fhir+json"))
+	}
+}
+
+func (w *outcomeWriter) Write(b []byte) (int, error) {
+	w.decide()
+	if w.converting {
+		return w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *outcomeWriter) WriteString(s string) (int, error) {
+	w.decide()
+	if w.converting {
+		return w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Middleware makes every error of the facade an OperationOutcome. It must run before the
+// authentication middlewares so that their errors are converted too.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := &outcomeWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		c.Writer = writer.ResponseWriter
+		if !writer.converting {
+			return
+		}
+		var resp utils.ErrorResponse
+		_ = json.Unmarshal(writer.body.Bytes(), &resp)
+		outcome, err := json.Marshal(NewOperationOutcome(writer.Status(), resp.Code, resp.Message))
+		if err != nil {
+			return
+		}
+		c.Writer.Header().Set("Content-Type", ContentType)
+		c.Writer.Header().Del("Content-Length")
+		_, _ = c.Writer.Write(outcome)
+	}
+}
@@ -0,0 +1,443 @@
+package fhir
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"unicode"
+)
+
+// Conformance is checked against the definitions HL7 publishes for FHIR R4 (4.0.1), used
+// unmodified. They are not vendored: together they are tens of megabytes and the tests cannot
+// download them. Set FHIR_R4_DEFINITIONS to a directory holding
+//
+//	fhir.schema.json          from https://hl7.org/fhir/R4/fhir.schema.json.zip
+//	profiles-resources.json   from https://hl7.org/fhir/R4/definitions.json.zip
+//	profiles-types.json       from the same archive
+//
+// Every resource is validated against the JSON schema (elements, value types, patterns
+// and required value sets) and against the snapshots of the StructureDefinitions (the
+// minimum and maximum cardinality of every element, which the JSON schema leaves out).
+// The files are checked to be the complete R4 release, not hashed. Without the variable
+// the tests that only check conformance are skipped and the others check the mappings alone.
+const r4DefinitionsEnv = "FHIR_R4_DEFINITIONS"
+
+// r4MinResources is fewer than the resource types of FHIR R4 (about 145); a schema or
+// bundle with fewer is a trimmed copy
+const r4MinResources = 140
+
+// r4Definitions are the loaded FHIR R4 definitions
+type r4Definitions struct {
+	schema    *jsonSchema
+	resources map[string]*structureDefinition
+	types     map[string]*structureDefinition
+}
+
+var (
+	r4Once sync.Once
+	r4Defs *r4Definitions
+	r4Err  error
+)
+
+// loadR4 returns the FHIR R4 definitions, or nil if FHIR_R4_DEFINITIONS is not set
+func loadR4(t *testing.T) *r4Definitions {
+	t.Helper()
+	dir := os.Getenv(r4DefinitionsEnv)
+	if dir == "" {
+		t.Logf("%s is not set; resources are not validated against the FHIR R4 definitions", r4DefinitionsEnv)
+		return nil
+	}
+	r4Once.Do(func() { r4Defs, r4Err = readR4(dir) })
+	if r4Err != nil {
+		t.Fatalf("Failed to load the FHIR R4 definitions from %s: %v", dir, r4Err)
+	}
+	return r4Defs
+}
+
+// requireR4 returns the FHIR R4 definitions or skips a test that only checks conformance
+func requireR4(t *testing.T) *r4Definitions {
+	t.Helper()
+	defs := loadR4(t)
+	if defs == nil {
+		t.Skipf("Skipping: set %s to validate against the FHIR R4 definitions", r4DefinitionsEnv)
+	}
+	return defs
+}
+
+func readR4(dir string) (*r4Definitions, error) {
+	var schema jsonSchema
+	if err := readJSON(filepath.Join(dir, "fhir.schema.json"), &schema); err != nil {
+		return nil, err
+	}
+	if schema.ID != "http://hl7.org/fhir/json-schema/4.0" {
+		return nil, fmt.Errorf("fhir.schema.json is %q, not the FHIR R4 schema", schema.ID)
+	}
+	if list, ok := schema.Definitions["ResourceList"]; !ok || len(list.OneOf) < r4MinResources {
+		return nil, fmt.Errorf("fhir.schema.json does not define every R4 resource; use the published file")
+	}
+	schema.patterns = make(map[string]*regexp.Regexp)
+
+	resources, err := readStructureDefinitions(filepath.Join(dir, "profiles-resources.json"), "resource")
+	if err != nil {
+		return nil, err
+	}
+	if len(resources) < r4MinResources {
+		return nil, fmt.Errorf("profiles-resources.json defines %d resources; use the published file", len(resources))
+	}
+	types, err := readStructureDefinitions(filepath.Join(dir, "profiles-types.json"), "complex-type")
+	if err != nil {
+		return nil, err
+	}
+	return &r4Definitions{schema: &schema, resources: resources, types: types}, nil
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// validate checks a resource, as the facade encodes it, against the FHIR R4 definitions.
+// It does nothing without definitions.
+func (d *r4Definitions) validate(t interface{ Fatalf(string, ...any) }, resource interface{}) {
+	if d == nil {
+		return
+	}
+	data, err := json.Marshal(resource)
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	errs := d.schema.validate(d.schema, v, "$")
+	if obj, ok := v.(map[string]interface{}); ok {
+		errs = append(errs, d.checkResource(obj, "$")...)
+	}
+	if len(errs) > 0 {
+		t.Fatalf("%s does not conform to FHIR R4:\n%s\n%s", v.(map[string]interface{})["resourceType"], strings.Join(errs, "\n"), data)
+	}
+}
+
+// jsonSchema is the part of JSON Schema (draft-06) the FHIR R4 schema uses
+type jsonSchema struct {
+	ID                   string                 `json:"id"`
+	Ref                  string                 `json:"$ref"`
+	Type                 string                 `json:"type"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Required             []string               `json:"required"`
+	Enum                 []interface{}          `json:"enum"`
+	Const                *string                `json:"const"`
+	Pattern              string                 `json:"pattern"`
+	Minimum              *float64               `json:"minimum"`
+	Items                *jsonSchema            `json:"items"`
+	OneOf                []*jsonSchema          `json:"oneOf"`
+	Definitions          map[string]*jsonSchema `json:"definitions"`
+
+	// patterns caches the compiled patterns; it is only set on the root
+	patterns map[string]*regexp.Regexp
+}
+
+// validate returns the violations of v against s; root holds the definitions. As in JSON
+// Schema, object, array and string keywords apply to values of that kind whether or not
+// the schema names a type (the FHIR schema mostly does not).
+func (root *jsonSchema) validate(s *jsonSchema, v interface{}, path string) []string {
+	if s.Ref != "" {
+		def, ok := root.Definitions[strings.TrimPrefix(s.Ref, "#/definitions/")]
+		if !ok {
+			return []string{path + ": unknown $ref " + s.Ref}
+		}
+		return root.validate(def, v, path)
+	}
+
+	var errs []string
+	if len(s.OneOf) > 0 {
+		matches := 0
+		var first []string
+		for _, option := range s.OneOf {
+			optionErrs := root.validate(option, v, path)
+			if len(optionErrs) == 0 {
+				matches++
+			} else if first == nil || len(optionErrs) < len(first) {
+				first = optionErrs
+			}
+		}
+		if matches != 1 {
+			errs = append(errs, fmt.Sprintf("%s: matches %d of oneOf, closest: %v", path, matches, first))
+		}
+	}
+	if s.Const != nil && v != *s.Const {
+		errs = append(errs, fmt.Sprintf("%s: %v is not %q", path, v, *s.Const))
+	}
+	if len(s.Enum) > 0 && !slices.Contains(s.Enum, v) {
+		errs = append(errs, fmt.Sprintf("%s: %v is not one of %v", path, v, s.Enum))
+	}
+	if s.Type != "" && !hasJSONType(v, s.Type) {
+		return append(errs, fmt.Sprintf("%s: not of type %s", path, s.Type))
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		// A resource of another type fails on its resourceType alone
+		if rt, ok := s.Properties["resourceType"]; ok && rt.Const != nil && v["resourceType"] != *rt.Const {
+			return append(errs, fmt.Sprintf("%s: resourceType %v is not %q", path, v["resourceType"], *rt.Const))
+		}
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				errs = append(errs, path+": missing "+name)
+			}
+		}
+		for name, value := range v {
+			prop, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					errs = append(errs, path+": unexpected "+name)
+				}
+				continue
+			}
+			errs = append(errs, root.validate(prop, value, path+"."+name)...)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			// FHIR forbids empty arrays
+			errs = append(errs, path+": empty array")
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				errs = append(errs, root.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case string:
+		if s.Pattern != "" {
+			re, err := root.pattern(s.Pattern)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: pattern %s cannot be checked: %v", path, s.Pattern, err))
+			} else if !re.MatchString(v) {
+				errs = append(errs, fmt.Sprintf("%s: %q does not match %s", path, v, s.Pattern))
+			}
+		}
+	case float64:
+		if s.Type == "integer" && v != math.Trunc(v) {
+			errs = append(errs, fmt.Sprintf("%s: %v is not an integer", path, v))
+		}
+		if s.Minimum != nil && v < *s.Minimum {
+			errs = append(errs, fmt.Sprintf("%s: %v is below %v", path, v, *s.Minimum))
+		}
+	}
+	return errs
+}
+
+// pattern compiles a pattern of the schema once
+func (root *jsonSchema) pattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := root.patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	root.patterns[pattern] = re
+	return re, nil
+}
+
+func hasJSONType(v interface{}, typ string) bool {
+	switch typ {
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number", "integer":
+		_, ok := v.(float64)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	}
+	return true
+}
+
+// structureDefinition is the part of a StructureDefinition the cardinality check uses
+type structureDefinition struct {
+	ResourceType string `json:"resourceType"`
+	Type         string `json:"type"`
+	Kind         string `json:"kind"`
+	Derivation   string `json:"derivation"`
+	FHIRVersion  string `json:"fhirVersion"`
+	Snapshot     struct {
+		Element []elementDefinition `json:"element"`
+	} `json:"snapshot"`
+
+	// children maps an element path to the elements directly below it
+	children map[string][]elementDefinition
+}
+
+type elementDefinition struct {
+	Path             string `json:"path"`
+	Min              int    `json:"min"`
+	Max              string `json:"max"`
+	ContentReference string `json:"contentReference"`
+	Type             []struct {
+		Code string `json:"code"`
+	} `json:"type"`
+}
+
+// readStructureDefinitions reads the base definitions of a kind from a published bundle
+func readStructureDefinitions(path, kind string) (map[string]*structureDefinition, error) {
+	var bundle struct {
+		Entry []struct {
+			Resource json.RawMessage `json:"resource"`
+		} `json:"entry"`
+	}
+	if err := readJSON(path, &bundle); err != nil {
+		return nil, err
+	}
+
+	definitions := make(map[string]*structureDefinition)
+	for _, entry := range bundle.Entry {
+		var sd structureDefinition
+		if err := json.Unmarshal(entry.Resource, &sd); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		// Profiles constrain a base type; only the base definitions apply to every resource
+		if sd.ResourceType != "StructureDefinition" || sd.Kind != kind || sd.Derivation == "constraint" {
+			continue
+		}
+		if sd.FHIRVersion != "4.0.1" {
+			return nil, fmt.Errorf("%s: %s is FHIR %s, not 4.0.1", path, sd.Type, sd.FHIRVersion)
+		}
+		sd.children = make(map[string][]elementDefinition)
+		for _, e := range sd.Snapshot.Element {
+			if parent, _, ok := cutLast(e.Path); ok {
+				sd.children[parent] = append(sd.children[parent], e)
+			}
+		}
+		definitions[sd.Type] = &sd
+	}
+	return definitions, nil
+}
+
+// cutLast splits an element path before its last name
+func cutLast(path string) (parent, name string, ok bool) {
+	i := strings.LastIndex(path, ".")
+	if i < 0 {
+		return "", "", false
+	}
+	return path[:i], path[i+1:], true
+}
+
+// checkResource checks the cardinalities of a resource and of everything in it
+func (d *r4Definitions) checkResource(obj map[string]interface{}, at string) []string {
+	resourceType, _ := obj["resourceType"].(string)
+	sd, ok := d.resources[resourceType]
+	if !ok {
+		return []string{fmt.Sprintf("%s: unknown resourceType %q", at, resourceType)}
+	}
+	return d.checkElements(sd, resourceType, obj, at)
+}
+
+// checkElements checks the elements below path of sd against an object
+func (d *r4Definitions) checkElements(sd *structureDefinition, path string, obj map[string]interface{}, at string) []string {
+	var errs []string
+	for _, e := range sd.children[path] {
+		_, name, _ := cutLast(e.Path)
+		values, present := elementValues(obj, name)
+		if present < e.Min {
+			errs = append(errs, fmt.Sprintf("%s: %s is required (min %d)", at, name, e.Min))
+		}
+		if max, err := strconv.Atoi(e.Max); err == nil && present > max {
+			errs = append(errs, fmt.Sprintf("%s: %s occurs %d times (max %d)", at, name, present, max))
+		}
+		for _, value := range values {
+			child, ok := value.value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			errs = append(errs, d.checkValue(sd, e, value.key, child, at+"."+value.key)...)
+		}
+	}
+	return errs
+}
+
+// checkValue checks a complex value of an element: a backbone element, a reused element,
+// a data type or a contained resource
+func (d *r4Definitions) checkValue(sd *structureDefinition, e elementDefinition, key string, obj map[string]interface{}, at string) []string {
+	if e.ContentReference != "" {
+		return d.checkElements(sd, strings.TrimPrefix(e.ContentReference, "#"), obj, at)
+	}
+	if len(sd.children[e.Path]) > 0 {
+		return d.checkElements(sd, e.Path, obj, at)
+	}
+	if _, ok := obj["resourceType"]; ok {
+		return d.checkResource(obj, at)
+	}
+
+	_, name, _ := cutLast(e.Path)
+	suffix := strings.TrimPrefix(key, strings.TrimSuffix(name, "[x]"))
+	for _, t := range e.Type {
+		if len(e.Type) > 1 && !strings.EqualFold(t.Code, suffix) {
+			continue
+		}
+		if typeSD, ok := d.types[t.Code]; ok {
+			return d.checkElements(typeSD, typeSD.Type, obj, at)
+		}
+	}
+	return nil
+}
+
+type elementValue struct {
+	key   string
+	value interface{}
+}
+
+// elementValues returns the values of an element in an object, with the key each was found
+// under (valueQuantity for value[x]), and how many times the element occurs. A primitive
+// that has only an extension (_birthDate) occurs without a value.
+func elementValues(obj map[string]interface{}, name string) ([]elementValue, int) {
+	var keys []string
+	if base, choice := strings.CutSuffix(name, "[x]"); choice {
+		for key := range obj {
+			if rest, ok := strings.CutPrefix(key, base); ok && rest != "" && unicode.IsUpper(rune(rest[0])) {
+				keys = append(keys, key)
+			}
+		}
+	} else if _, ok := obj[name]; ok {
+		keys = append(keys, name)
+	}
+
+	var values []elementValue
+	for _, key := range keys {
+		if arr, ok := obj[key].([]interface{}); ok {
+			for _, item := range arr {
+				values = append(values, elementValue{key: key, value: item})
+			}
+		} else {
+			values = append(values, elementValue{key: key, value: obj[key]})
+		}
+	}
+	if len(values) == 0 {
+		if extension, ok := obj["_"+name]; ok {
+			if arr, ok := extension.([]interface{}); ok {
+				return nil, len(arr)
+			}
+			return nil, 1
+		}
+	}
+	return values, len(values)
+}
@@ -0,0 +1,287 @@
+// Package fhir maps VEM 2.0 records to HL7 FHIR R4 resources for the read-only /fhir/R4
+// facade. Resource ids are the VEM codes of the records they are mapped from, so that
+// clients can follow references (Observation.subject -> Patient/H0001) without a lookup
+// table; a vital signs record yields one Observation per measurement.
+//
+// Only the elements MedScreen can fill are declared. Personal data is masked while the
+// resources are built, by the same policy that masks the VEM-shaped responses.
+package fhir
+
+// Version is the FHIR version the facade implements
+const Version = "4.0.1"
+
+// BasePath is the path the facade is served under
+const BasePath = "/fhir/R4"
+
+// ContentType is the media type of FHIR JSON responses
+const ContentType = "application/fhir+json; charset=utf-8"
+
+// Code systems and identifier namespaces used by the mappings. The urn:medscreen:vem
+// namespaces are local to MedScreen: they identify VEM 2.0 codes, not national registries.
+const (
+	SystemLOINC               = "http://loinc.org"
+	SystemUCUM                = "http://unitsofmeasure.org"
+	SystemICD10               = "http://hl7.org/fhir/sid/icd-10"
+	SystemGTIN                = "https://www.gs1.org/gtin"
+	SystemObservationCategory = "http://terminology.hl7.org/CodeSystem/observation-category"
+	SystemInterpretation      = "http://terminology.hl7.org/CodeSystem/v3-ObservationInterpretation"
+	SystemDataAbsentReason    = "http://terminology.hl7.org/CodeSystem/data-absent-reason"
+	SystemConditionCategory   = "http://terminology.hl7.org/CodeSystem/condition-category"
+	SystemConditionVerStatus  = "http://terminology.hl7.org/CodeSystem/condition-ver-status"
+	SystemAllergyClinical     = "http://terminology.hl7.org/CodeSystem/allergyintolerance-clinical"
+	SystemActCode             = "http://terminology.hl7.org/CodeSystem/v3-ActCode"
+	SystemParticipationType   = "http://terminology.hl7.org/CodeSystem/v3-ParticipationType"
+
+	SystemHastaKodu        = "urn:medscreen:vem:hasta_kodu"
+	SystemTCKimlik         = "urn:medscreen:vem:tc_kimlik_numarasi"
+	SystemProtokolNumarasi = "urn:medscreen:vem:basvuru_protokol_numarasi"
+	SystemPersonelKodu     = "urn:medscreen:vem:personel_kodu"
+	SystemTescilNumarasi   = "urn:medscreen:vem:tescil_numarasi"
+	SystemMedulaBrans      = "urn:medscreen:vem:medula_brans_kodu"
+	SystemReceteKodu       = "urn:medscreen:vem:recete_kodu"
+	SystemEReceteNumarasi  = "urn:medscreen:vem:medula_e_recete_numarasi"
+)
+
+// Profiles of the FHIR vital signs observations
+const (
+	ProfileVitalSigns       = "http://hl7.org/fhir/StructureDefinition/vitalsigns"
+	ProfileBloodPressure    = "http://hl7.org/fhir/StructureDefinition/bp"
+	ProfileBodyTemperature  = "http://hl7.org/fhir/StructureDefinition/bodytemp"
+	ProfileHeartRate        = "http://hl7.org/fhir/StructureDefinition/heartrate"
+	ProfileRespiratoryRate  = "http://hl7.org/fhir/StructureDefinition/resprate"
+	ProfileOxygenSaturation = "http://hl7.org/fhir/StructureDefinition/oxygensat"
+	ProfileBodyHeight       = "http://hl7.org/fhir/StructureDefinition/bodyheight"
+	ProfileBodyWeight       = "http://hl7.org/fhir/StructureDefinition/bodyweight"
+)
+
+// Meta is the metadata of a resource
+type Meta struct {
+	LastUpdated string   `json:"lastUpdated,omitempty"`
+	Profile     []string `json:"profile,omitempty"`
+}
+
+// Coding is a code from a code system
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+// CodeableConcept is a concept given by codes and/or text
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+// Identifier is a business identifier of a resource
+type Identifier struct {
+	Use    string `json:"use,omitempty"`
+	System string `json:"system,omitempty"`
+	Value  string `json:"value,omitempty"`
+}
+
+// Reference refers to another resource, e.g. "Patient/H0001"
+type Reference struct {
+	Reference string `json:"reference,omitempty"`
+	Display   string `json:"display,omitempty"`
+}
+
+// HumanName is the name of a person
+type HumanName struct {
+	Use    string   `json:"use,omitempty"`
+	Family string   `json:"family,omitempty"`
+	Given  []string `json:"given,omitempty"`
+}
+
+// Period is a time range; an open end means it is ongoing
+type Period struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+// Quantity is a measured amount
+type Quantity struct {
+	Value      *float64 `json:"value,omitempty"`
+	Comparator string   `json:"comparator,omitempty"`
+	Unit       string   `json:"unit,omitempty"`
+	System     string   `json:"system,omitempty"`
+	Code       string   `json:"code,omitempty"`
+}
+
+// Annotation is a free text note
+type Annotation struct {
+	Text string `json:"text"`
+}
+
+// Patient is the FHIR Patient resource, mapped from Hasta
+type Patient struct {
+	ResourceType string       `json:"resourceType"`
+	ID           string       `json:"id"`
+	Meta         *Meta        `json:"meta,omitempty"`
+	Identifier   []Identifier `json:"identifier,omitempty"`
+	Name         []HumanName  `json:"name,omitempty"`
+	Gender       string       `json:"gender,omitempty"`
+	BirthDate    string       `json:"birthDate,omitempty"`
+}
+
+// EncounterParticipant is a practitioner involved in an encounter
+type EncounterParticipant struct {
+	Type       []CodeableConcept `json:"type,omitempty"`
+	Individual *Reference        `json:"individual,omitempty"`
+}
+
+// Encounter is the FHIR Encounter resource, mapped from HastaBasvuru
+type Encounter struct {
+	ResourceType string                 `json:"resourceType"`
+	ID           string                 `json:"id"`
+	Meta         *Meta                  `json:"meta,omitempty"`
+	Identifier   []Identifier           `json:"identifier,omitempty"`
+	Status       string                 `json:"status"`
+	Class        Coding                 `json:"class"`
+	Subject      *Reference             `json:"subject,omitempty"`
+	Participant  []EncounterParticipant `json:"participant,omitempty"`
+	Period       *Period                `json:"period,omitempty"`
+}
+
+// ObservationComponent is a part of a panel observation, e.g. the systolic pressure
+type ObservationComponent struct {
+	Code             CodeableConcept  `json:"code"`
+	ValueQuantity    *Quantity        `json:"valueQuantity,omitempty"`
+	DataAbsentReason *CodeableConcept `json:"dataAbsentReason,omitempty"`
+	ReferenceRange   []ReferenceRange `json:"referenceRange,omitempty"`
+}
+
+// ReferenceRange is the range a result is evaluated against
+type ReferenceRange struct {
+	Low  *Quantity `json:"low,omitempty"`
+	High *Quantity `json:"high,omitempty"`
+	Text string    `json:"text,omitempty"`
+}
+
+// Observation is the FHIR Observation resource, mapped from HastaVitalFizikiBulgu
+// (vital-signs) and TetkikSonuc (laboratory)
+type Observation struct {
+	ResourceType      string                 `json:"resourceType"`
+	ID                string                 `json:"id"`
+	Meta              *Meta                  `json:"meta,omitempty"`
+	Status            string                 `json:"status"`
+	Category          []CodeableConcept      `json:"category,omitempty"`
+	Code              CodeableConcept        `json:"code"`
+	Subject           *Reference             `json:"subject,omitempty"`
+	Encounter         *Reference             `json:"encounter,omitempty"`
+	EffectiveDateTime string                 `json:"effectiveDateTime,omitempty"`
+	Issued            string                 `json:"issued,omitempty"`
+	Performer         []Reference            `json:"performer,omitempty"`
+	ValueQuantity     *Quantity              `json:"valueQuantity,omitempty"`
+	ValueString       string                 `json:"valueString,omitempty"`
+	DataAbsentReason  *CodeableConcept       `json:"dataAbsentReason,omitempty"`
+	Interpretation    []CodeableConcept      `json:"interpretation,omitempty"`
+	ReferenceRange    []ReferenceRange       `json:"referenceRange,omitempty"`
+	Component         []ObservationComponent `json:"component,omitempty"`
+}
+
+// Condition is the FHIR Condition resource, mapped from BasvuruTani
+type Condition struct {
+	ResourceType       string            `json:"resourceType"`
+	ID                 string            `json:"id"`
+	Meta               *Meta             `json:"meta,omitempty"`
+	VerificationStatus *CodeableConcept  `json:"verificationStatus,omitempty"`
+	Category           []CodeableConcept `json:"category,omitempty"`
+	Code               *CodeableConcept  `json:"code,omitempty"`
+	Subject            Reference         `json:"subject"`
+	Encounter          *Reference        `json:"encounter,omitempty"`
+	RecordedDate       string            `json:"recordedDate,omitempty"`
+	Recorder           *Reference        `json:"recorder,omitempty"`
+}
+
+// AllergyIntolerance is the FHIR AllergyIntolerance resource, mapped from HastaTibbiBilgi
+// records of type ALERJI
+type AllergyIntolerance struct {
+	ResourceType   string           `json:"resourceType"`
+	ID             string           `json:"id"`
+	Meta           *Meta            `json:"meta,omitempty"`
+	ClinicalStatus *CodeableConcept `json:"clinicalStatus,omitempty"`
+	Code           *CodeableConcept `json:"code,omitempty"`
+	Patient        Reference        `json:"patient"`
+	RecordedDate   string           `json:"recordedDate,omitempty"`
+	Note           []Annotation     `json:"note,omitempty"`
+}
+
+// TimingRepeat is how often a dose is taken
+type TimingRepeat struct {
+	Frequency  int      `json:"frequency,omitempty"`
+	Period     *float64 `json:"period,omitempty"`
+	PeriodUnit string   `json:"periodUnit,omitempty"`
+}
+
+// Timing is the schedule of a dosage
+type Timing struct {
+	Repeat *TimingRepeat `json:"repeat,omitempty"`
+}
+
+// DoseAndRate is the amount of a dose
+type DoseAndRate struct {
+	DoseQuantity *Quantity `json:"doseQuantity,omitempty"`
+}
+
+// Dosage is how a medication is to be taken
+type Dosage struct {
+	Text        string           `json:"text,omitempty"`
+	Timing      *Timing          `json:"timing,omitempty"`
+	Route       *CodeableConcept `json:"route,omitempty"`
+	DoseAndRate []DoseAndRate    `json:"doseAndRate,omitempty"`
+}
+
+// DispenseRequest is the amount of a medication to dispense
+type DispenseRequest struct {
+	Quantity *Quantity `json:"quantity,omitempty"`
+}
+
+// MedicationRequest is the FHIR MedicationRequest resource, mapped from a ReceteIlac
+// together with its Recete
+type MedicationRequest struct {
+	ResourceType              string           `json:"resourceType"`
+	ID                        string           `json:"id"`
+	Meta                      *Meta            `json:"meta,omitempty"`
+	Identifier                []Identifier     `json:"identifier,omitempty"`
+	Status                    string           `json:"status"`
+	Intent                    string           `json:"intent"`
+	MedicationCodeableConcept CodeableConcept  `json:"medicationCodeableConcept"`
+	Subject                   Reference        `json:"subject"`
+	Encounter                 *Reference       `json:"encounter,omitempty"`
+	AuthoredOn                string           `json:"authoredOn,omitempty"`
+	Requester                 *Reference       `json:"requester,omitempty"`
+	GroupIdentifier           *Identifier      `json:"groupIdentifier,omitempty"`
+	DosageInstruction         []Dosage         `json:"dosageInstruction,omitempty"`
+	DispenseRequest           *DispenseRequest `json:"dispenseRequest,omitempty"`
+}
+
+// PractitionerQualification is a specialty of a practitioner
+type PractitionerQualification struct {
+	Code CodeableConcept `json:"code"`
+}
+
+// Practitioner is the FHIR Practitioner resource, mapped from Personel
+type Practitioner struct {
+	ResourceType  string                      `json:"resourceType"`
+	ID            string                      `json:"id"`
+	Meta          *Meta                       `json:"meta,omitempty"`
+	Identifier    []Identifier                `json:"identifier,omitempty"`
+	Active        bool                        `json:"active"`
+	Name          []HumanName                 `json:"name,omitempty"`
+	Qualification []PractitionerQualification `json:"qualification,omitempty"`
+}
+
+// Resource is a FHIR resource the facade returns
+type Resource interface {
+	// Ref returns the relative reference of the resource, e.g. "Patient/H0001"
+	Ref() string
+}
+
+func (r Patient) Ref() string            { return r.ResourceType + "/" + r.ID }
+func (r Encounter) Ref() string          { return r.ResourceType + "/" + r.ID }
+func (r Observation) Ref() string        { return r.ResourceType + "/" + r.ID }
+func (r Condition) Ref() string          { return r.ResourceType + "/" + r.ID }
+func (r AllergyIntolerance) Ref() string { return r.ResourceType + "/" + r.ID }
+func (r MedicationRequest) Ref() string  { return r.ResourceType + "/" + r.ID }
+func (r Practitioner) Ref() string       { return r.ResourceType + "/" + r.ID }
@@ -0,0 +1,224 @@
+package fhir
+
+import (
+	"errors"
+	"fmt"
+	"medscreen/internal/query"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSearch is returned for search parameters the facade does not accept
+var ErrInvalidSearch = errors.New("invalid search parameter")
+
+// Paging parameters every search accepts, besides the parameters of its resource type
+const (
+	ParamCount  = "_count"
+	ParamPage   = "page"
+	ParamFormat = "_format"
+)
+
+// Paging defaults, the same as those of the VEM-shaped list endpoints
+const (
+	DefaultCount = 10
+	MaxCount     = 100
+)
+
+// Search is the parsed query string of a search
+type Search struct {
+	values url.Values
+	Page   int
+	Count  int
+}
+
+// ParseSearch checks the parameters of a search against those its resource type supports.
+// Unknown parameters are rejected rather than ignored, so that a misspelt patient= cannot
+// return the records of every patient. _count and page are clamped like limit and page of
+// the VEM-shaped lists.
+func ParseSearch(values url.Values, resourceType string) (Search, error) {
+	info, ok := ResourceInfoOf(resourceType)
+	if !ok {
+		return Search{}, fmt.Errorf("%w: %s cannot be searched", ErrInvalidSearch, resourceType)
+	}
+	for name := range values {
+		if name == ParamCount || name == ParamPage || name == ParamFormat {
+			continue
+		}
+		if !slices.ContainsFunc(info.SearchParams, func(p SearchParam) bool { return p.Name == name }) {
+			return Search{}, fmt.Errorf("%w: %s does not support the %q search parameter", ErrInvalidSearch, resourceType, name)
+		}
+	}
+	if format := values.Get(ParamFormat); format != "" && !slices.Contains([]string{"json", "application/json", "application/fhir+json"}, format) {
+		return Search{}, fmt.Errorf("%w: only JSON is supported, not _format=%s", ErrInvalidSearch, format)
+	}
+
+	s := Search{values: values, Page: 1, Count: DefaultCount}
+	if n, err := strconv.Atoi(values.Get(ParamPage)); err == nil && n > 0 {
+		s.Page = n
+	}
+	if n, err := strconv.Atoi(values.Get(ParamCount)); err == nil && n > 0 {
+		s.Count = min(n, MaxCount)
+	}
+	return s, nil
+}
+
+// Reference returns the id a reference parameter refers to: patient=H0001 and
+// patient=Patient/H0001 both return H0001
+func (s Search) Reference(name, resourceType string) (string, error) {
+	value := strings.TrimSpace(s.values.Get(name))
+	if value == "" {
+		return "", nil
+	}
+	if typ, id, found := strings.Cut(value, "/"); found {
+		if typ != resourceType || id == "" || strings.Contains(id, "/") {
+			return "", fmt.Errorf("%w: %s must refer to a %s, got %q", ErrInvalidSearch, name, resourceType, value)
+		}
+		value = id
+	}
+	return value, nil
+}
+
+// Token returns the value of a token parameter, without a system
+func (s Search) Token(name string) string {
+	value := strings.TrimSpace(s.values.Get(name))
+	if _, code, found := strings.Cut(value, "|"); found {
+		return code
+	}
+	return value
+}
+
+// Dates parses every value of a date parameter; date=ge2026-01-01&date=lt2026-02-01 is a range
+func (s Search) Dates(name string) ([]DateParam, error) {
+	var dates []DateParam
+	for _, value := range s.values[name] {
+		d, err := ParseDate(value)
+		if err != nil {
+			return nil, err
+		}
+		dates = append(dates, d)
+	}
+	return dates, nil
+}
+
+// DateParam is a parsed date search parameter. A date stands for the whole of its
+// precision: 2026-03 is [2026-03-01, 2026-04-01).
+type DateParam struct {
+	prefix string
+	start  time.Time
+	end    time.Time // exclusive
+}
+
+// datePrefixes are the comparison prefixes the facade supports
+var datePrefixes = []string{"eq", "gt", "ge", "lt", "le"}
+
+// ParseDate parses an optionally prefixed date (YYYY, YYYY-MM, YYYY-MM-DD, UTC) or an
+// RFC 3339 time, e.g. ge2026-03-01 or lt2026-03-01T12:00:00+03:00
+func ParseDate(value string) (DateParam, error) {
+	d := DateParam{prefix: "eq"}
+	raw := strings.TrimSpace(value)
+	if len(raw) > 2 && raw[0] >= 'a' && raw[0] <= 'z' {
+		d.prefix, raw = raw[:2], raw[2:]
+		if !slices.Contains(datePrefixes, d.prefix) {
+			return DateParam{}, fmt.Errorf("%w: date prefix %q is not supported (use eq, gt, ge, lt or le)", ErrInvalidSearch, d.prefix)
+		}
+	}
+
+	layouts := []struct {
+		layout string
+		next   func(time.Time) time.Time
+	}{
+		{"2006", func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }},
+		{"2006-01", func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }},
+		{"2006-01-02", func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }},
+		{time.RFC3339, func(t time.Time) time.Time { return t.Add(time.Second) }},
+	}
+	for _, l := range layouts {
+		if t, err := time.Parse(l.layout, raw); err == nil {
+			d.start, d.end = t, l.next(t)
+			return d, nil
+		}
+	}
+	return DateParam{}, fmt.Errorf("%w: %q is not a date (YYYY, YYYY-MM, YYYY-MM-DD) or an RFC 3339 time", ErrInvalidSearch, value)
+}
+
+// Apply restricts q to the records whose time column matches the parameter
+func (d DateParam) Apply(q query.Query, column string) query.Query {
+	switch d.prefix {
+	case "gt":
+		return q.Compare(column, query.OpGte, d.end)
+	case "ge":
+		return q.Compare(column, query.OpGte, d.start)
+	case "lt":
+		return q.Compare(column, query.OpLt, d.start)
+	case "le":
+		return q.Compare(column, query.OpLt, d.end)
+	}
+	return q.Compare(column, query.OpGte, d.start).Compare(column, query.OpLt, d.end)
+}
+
+// Bundle is the FHIR Bundle resource a search returns
+type Bundle struct {
+	ResourceType string        `json:"resourceType"`
+	Meta         *Meta         `json:"meta,omitempty"`
+	Type         string        `json:"type"`
+	Total        *int64        `json:"total,omitempty"`
+	Link         []BundleLink  `json:"link,omitempty"`
+	Entry        []BundleEntry `json:"entry,omitempty"`
+}
+
+// BundleLink is a link to this or a neighbouring page of a search
+type BundleLink struct {
+	Relation string `json:"relation"`
+	URL      string `json:"url"`
+}
+
+// BundleEntry is a resource of a Bundle
+type BundleEntry struct {
+	FullURL  string       `json:"fullUrl,omitempty"`
+	Resource interface{}  `json:"resource"`
+	Search   *EntrySearch `json:"search,omitempty"`
+}
+
+// EntrySearch tells why an entry is in a search result
+type EntrySearch struct {
+	Mode string `json:"mode"`
+}
+
+// Searchset builds a page of search results. base is the absolute URL of the facade
+// (https://host/fhir/R4) and self the URL of the search. total is nil when it is not the
+// number of entries (MedicationRequest pages over prescriptions); more tells whether a
+// next page exists.
+func Searchset(base string, self *url.URL, s Search, total *int64, more bool, entries []Resource) Bundle {
+	b := Bundle{
+		ResourceType: "Bundle",
+		Meta:         &Meta{LastUpdated: dateTime(time.Now())},
+		Type:         "searchset",
+		Total:        total,
+		Link:         []BundleLink{{Relation: "self", URL: pageURL(base, self, s.Page, s.Count)}},
+		Entry:        make([]BundleEntry, 0, len(entries)),
+	}
+	if more {
+		b.Link = append(b.Link, BundleLink{Relation: "next", URL: pageURL(base, self, s.Page+1, s.Count)})
+	}
+	if s.Page > 1 {
+		b.Link = append(b.Link, BundleLink{Relation: "previous", URL: pageURL(base, self, s.Page-1, s.Count)})
+	}
+	for _, r := range entries {
+		b.Entry = append(b.Entry, BundleEntry{
+			FullURL:  base + "/" + r.Ref(),
+			Resource: r,
+			Search:   &EntrySearch{Mode: "match"},
+		})
+	}
+	return b
+}
+
+func pageURL(base string, self *url.URL, page, count int) string {
+	values := self.Query()
+	values.Set(ParamPage, strconv.Itoa(page))
+	values.Set(ParamCount, strconv.Itoa(count))
+	return base + "/" + self.Path[strings.LastIndex(self.Path, "/")+1:] + "?" + values.Encode()
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"errors"
+	"medscreen/internal/constants"
+	"medscreen/internal/middleware"
+	"medscreen/internal/models"
+	"medscreen/internal/service"
+	"medscreen/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AcilErisimHandler handles HTTP requests for break-the-glass access and its review queue
+type AcilErisimHandler struct {
+	service service.AcilErisimService
+}
+
+// NewAcilErisimHandler creates a new AcilErisimHandler instance
+func NewAcilErisimHandler(service service.AcilErisimService) *AcilErisimHandler {
+	return &AcilErisimHandler{service: service}
+}
+
+type acilErisimRequest struct {
+	SebepKodu models.AcilErisimSebebi `json:"sebep_kodu" binding:"required"`
+	Gerekce   string                  `json:"gerekce" binding:"required"`
+}
+
+type acilErisimIncelemeRequest struct {
+	Not string `json:"not"`
+}
+
+// BreakGlass handles POST /api/v1/auth/break-glass
+func (h *AcilErisimHandler) BreakGlass(c *gin.Context) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, constants.ERROR_UNAUTHORIZED, "Authentication required", nil)
+		return
+	}
+
+	var req acilErisimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "sebep_kodu and gerekce are required", err)
+		return
+	}
+
+	sonuc, err := h.service.Baslat(claims, req.SebepKodu, req.Gerekce)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAcilErisimSebebi), errors.Is(err, service.ErrAcilErisimGerekcesi), errors.Is(err, service.ErrAcilErisimAktif):
+			utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, err.Error(), nil)
+		case errors.Is(err, service.ErrAcilErisimYetkisi):
+			utils.SendErrorResponse(c, http.StatusForbidden, constants.ERROR_FORBIDDEN, "Your role may not use emergency access", nil)
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to grant emergency access", err)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_ACIL_ERISIM_GRANTED, "Emergency access granted; every access will be reviewed", sonuc)
+}
+
+// GetAll handles GET /api/v1/acil-erisim
+// ?durum=bekleyen lists grants awaiting review, ?durum=incelendi the signed-off ones
+func (h *AcilErisimHandler) GetAll(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	var incelendi *bool
+	switch c.Query("durum") {
+	case "":
+	case "bekleyen":
+		incelendi = new(bool)
+	case "incelendi":
+		incelendi = new(bool)
+		*incelendi = true
+	default:
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "durum must be bekleyen or incelendi", nil)
+		return
+	}
+
+	erisimler, total, err := h.service.GetAll(incelendi, page, limit)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve emergency accesses", err)
+		return
+	}
+
+	meta := utils.CalculateMeta(page, limit, total)
+	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_ACIL_ERISIMLER_RETRIEVED, "Emergency accesses retrieved successfully", erisimler, meta)
+}
+
+// GetByKodu handles GET /api/v1/acil-erisim/:kodu
+func (h *AcilErisimHandler) GetByKodu(c *gin.Context) {
+	erisim, err := h.service.GetByKodu(c.Param("kodu"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_ACIL_ERISIM_NOT_FOUND, "Emergency access not found", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_ACIL_ERISIM_RETRIEVED, "Emergency access retrieved successfully", erisim)
+}
+
+// GetErisimler handles GET /api/v1/acil-erisim/:kodu/erisimler
+func (h *AcilErisimHandler) GetErisimler(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	kayitlar, total, err := h.service.GetErisimler(c.Param("kodu"), page, limit)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_ACIL_ERISIM_NOT_FOUND, "Emergency access not found", err)
+		return
+	}
+
+	meta := utils.CalculateMeta(page, limit, total)
+	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_ERISIM_KAYITLARI_RETRIEVED, "Access records retrieved successfully", kayitlar, meta)
+}
+
+// Incele handles POST /api/v1/acil-erisim/:kodu/incele
+func (h *AcilErisimHandler) Incele(c *gin.Context) {
+	var req acilErisimIncelemeRequest
+	_ = c.ShouldBindJSON(&req)
+
+	erisim, err := h.service.Incele(c.Param("kodu"), c.GetString(middleware.ContextKeyPersonelKodu), req.Not)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAcilErisimIncelendi), errors.Is(err, service.ErrAcilErisimSuruyor):
+			utils.SendErrorResponse(c, http.StatusConflict, constants.ERROR_ACIL_ERISIM_NOT_REVIEWABLE, err.Error(), nil)
+		case errors.Is(err, service.ErrKendiAcilErisimi):
+			utils.SendErrorResponse(c, http.StatusForbidden, constants.ERROR_FORBIDDEN, err.Error(), nil)
+		default:
+			utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_ACIL_ERISIM_NOT_FOUND, "Emergency access not found", err)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_ACIL_ERISIM_REVIEWED, "Emergency access signed off successfully", erisim)
+}
@@ -2,10 +2,10 @@ package handler
 
 import (
 	"medscreen/internal/constants"
+	"medscreen/internal/models"
 	"medscreen/internal/service"
 	"medscreen/internal/utils"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -45,23 +45,18 @@ func (h *AnlikYatanHastaHandler) GetByYatak(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.AnlikYatanHastaSorgusu)
+	if !ok {
+		return
 	}
 
-	yatanHastalar, total, err := h.service.GetByYatakKodu(yatakKodu, page, limit)
+	yatanHastalar, total, err := h.service.List(q.With("yatak_kodu", yatakKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve inpatients", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, yatanHastalar)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_ANLIK_YATAN_HASTALAR_RETRIEVED, "Inpatients retrieved successfully", yatanHastalar, meta)
 }
 
@@ -73,23 +68,18 @@ func (h *AnlikYatanHastaHandler) GetByHasta(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.AnlikYatanHastaSorgusu)
+	if !ok {
+		return
 	}
 
-	yatanHastalar, total, err := h.service.GetByHastaKodu(hastaKodu, page, limit)
+	yatanHastalar, total, err := h.service.List(q.With("hasta_kodu", hastaKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve inpatients", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, yatanHastalar)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_ANLIK_YATAN_HASTALAR_RETRIEVED, "Inpatients retrieved successfully", yatanHastalar, meta)
 }
 
@@ -101,22 +91,17 @@ func (h *AnlikYatanHastaHandler) GetByBirim(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.AnlikYatanHastaSorgusu)
+	if !ok {
+		return
 	}
 
-	yatanHastalar, total, err := h.service.GetByBirimKodu(birimKodu, page, limit)
+	yatanHastalar, total, err := h.service.List(q.With("birim_kodu", birimKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve inpatients", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, yatanHastalar)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_ANLIK_YATAN_HASTALAR_RETRIEVED, "Inpatients retrieved successfully", yatanHastalar, meta)
 }
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"errors"
+	"medscreen/internal/constants"
+	"medscreen/internal/middleware"
+	"medscreen/internal/service"
+	"medscreen/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIAnahtariHandler handles HTTP requests for managing the API keys of integrations
+type APIAnahtariHandler struct {
+	service service.APIAnahtariService
+}
+
+// NewAPIAnahtariHandler creates a new APIAnahtariHandler instance
+func NewAPIAnahtariHandler(service service.APIAnahtariService) *APIAnahtariHandler {
+	return &APIAnahtariHandler{service: service}
+}
+
+// GetAll handles GET /api/v1/auth/api-anahtari
+func (h *APIAnahtariHandler) GetAll(c *gin.Context) {
+	anahtarlar, err := h.service.GetAll()
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve API keys", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_API_ANAHTARLARI_RETRIEVED, "API keys retrieved successfully", anahtarlar)
+}
+
+// Create handles POST /api/v1/auth/api-anahtari
+func (h *APIAnahtariHandler) Create(c *gin.Context) {
+	var req service.APIAnahtariIstegi
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "Invalid request body", err)
+		return
+	}
+
+	sonuc, err := h.service.Olustur(req, c.GetString(middleware.ContextKeyPersonelKodu))
+	if err != nil {
+		if errors.Is(err, service.ErrGecersizAPIAnahtari) {
+			utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, err.Error(), nil)
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to create API key", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, constants.SUCCESS_API_ANAHTARI_CREATED, "API key created; it will not be shown again", sonuc)
+}
+
+// Revoke handles POST /api/v1/auth/api-anahtari/:anahtar_kodu/revoke
+func (h *APIAnahtariHandler) Revoke(c *gin.Context) {
+	if err := h.service.Iptal(c.Param("anahtar_kodu"), c.GetString(middleware.ContextKeyPersonelKodu)); err != nil {
+		if errors.Is(err, service.ErrAPIAnahtariBulunamadi) {
+			utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_API_ANAHTARI_NOT_FOUND, "API key not found or already revoked", nil)
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to revoke API key", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_API_ANAHTARI_REVOKED, "API key revoked successfully", nil)
+}
@@ -0,0 +1,305 @@
+package handler
+
+import (
+	"errors"
+	"medscreen/internal/constants"
+	"medscreen/internal/middleware"
+	"medscreen/internal/service"
+	"medscreen/internal/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TabletCihazHeader carries the code of the tablet a login comes from
+const TabletCihazHeader = "X-Tablet-Cihaz-Kodu"
+
+// contextKeyTabletCihaz holds the tablet whose device credential was verified
+const contextKeyTabletCihaz = "tablet_cihaz_kodu"
+
+// AuthHandler handles HTTP requests for NFC login, token refresh and revocation
+type AuthHandler struct {
+	service  service.AuthService
+	cihazlar service.CihazService
+}
+
+// NewAuthHandler creates a new AuthHandler instance
+func NewAuthHandler(service service.AuthService, cihazlar service.CihazService) *AuthHandler {
+	return &AuthHandler{service: service, cihazlar: cihazlar}
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type logoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type revokeRequest struct {
+	Sebep string `json:"sebep"`
+}
+
+type escalateRequest struct {
+	Sebep string `json:"sebep" binding:"required"`
+}
+
+// TabletDogrula authenticates the tablet an NFC login comes from. Every login must name its
+// tablet in the X-Tablet-Cihaz-Kodu header and present the tablet's device credential in
+// X-Cihaz-Anahtari; a login that cannot prove its tablet is refused, so no NFC token is
+// ever issued without a bed. Desktop users sign in through SSO instead.
+func (h *AuthHandler) TabletDogrula(c *gin.Context) {
+	tabletCihazKodu := c.GetHeader(TabletCihazHeader)
+	if tabletCihazKodu == "" {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, constants.ERROR_INVALID_CIHAZ_CREDENTIAL, "A verified bedside tablet is required", nil)
+		c.Abort()
+		return
+	}
+
+	if err := h.cihazlar.Dogrula(tabletCihazKodu, c.GetHeader(CihazAnahtariHeader)); err != nil {
+		if errors.Is(err, service.ErrCihazAnahtariGecersiz) {
+			utils.SendErrorResponse(c, http.StatusUnauthorized, constants.ERROR_INVALID_CIHAZ_CREDENTIAL, "Invalid device credential", nil)
+		} else {
+			utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to verify device credential", err)
+		}
+		c.Abort()
+		return
+	}
+	c.Set(contextKeyTabletCihaz, tabletCihazKodu)
+	c.Next()
+}
+
+// LoginWithNFC handles GET /api/v1/nfc-kart/authenticate/:kart_uid
+// The tablet is the one TabletDogrula verified, so the tokens are always bound to its bed
+func (h *AuthHandler) LoginWithNFC(c *gin.Context) {
+	kartUID := c.Param("kart_uid")
+	if kartUID == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "Card UID is required", nil)
+		return
+	}
+
+	tabletCihazKodu := c.GetString(contextKeyTabletCihaz)
+
+	sonuc, err := h.service.LoginWithNFC(kartUID, tabletCihazKodu)
+	if err != nil {
+		if errors.Is(err, service.ErrTokenGeneration) {
+			utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to generate authentication token", err)
+			return
+		}
+		// Unknown, inactive and expired cards get the same response so UIDs cannot be
+		// enumerated; the reason is kept for the security log only
+		_ = c.Error(err)
+		utils.SendErrorResponse(c, http.StatusUnauthorized, constants.ERROR_NFC_AUTHENTICATION_FAILED, "NFC authentication failed", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_NFC_AUTHENTICATION, "NFC authentication successful", sonuc)
+}
+
+// NFCKaynaklari returns the rate limit source of an NFC login: the tablet TabletDogrula
+// verified, or the client IP when there is none. A verified tablet is not counted by IP,
+// since a ward's tablets often share one behind NAT and a single bad client would otherwise
+// lock out all of them; an unverified tablet code is never a source, so a client cannot
+// spread its guesses over made-up tablets or block a real one.
+func NFCKaynaklari(c *gin.Context) []string {
+	if tabletCihazKodu := c.GetString(contextKeyTabletCihaz); tabletCihazKodu != "" {
+		return []string{"cihaz:" + tabletCihazKodu}
+	}
+	return []string{"ip:" + c.ClientIP()}
+}
+
+// NFCSifirlanir reports whether a successful NFC login clears a source: the tablet does,
+// the client IP does not
+func NFCSifirlanir(anahtar string) bool {
+	return strings.HasPrefix(anahtar, "cihaz:")
+}
+
+// Refresh handles POST /api/v1/auth/refresh
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "refresh_token is required", err)
+		return
+	}
+
+	tokens, err := h.service.Refresh(req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTokenRevoked):
+			utils.SendErrorResponse(c, http.StatusUnauthorized, constants.ERROR_TOKEN_REVOKED, "Refresh token has been revoked", nil)
+		case errors.Is(err, service.ErrTokenGeneration):
+			utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to generate authentication token", err)
+		default:
+			utils.SendErrorResponse(c, http.StatusUnauthorized, constants.ERROR_INVALID_TOKEN, "Invalid refresh token", err)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_TOKEN_REFRESHED, "Token refreshed successfully", tokens)
+}
+
+// Logout handles POST /api/v1/auth/logout
+func (h *AuthHandler) Logout(c *gin.Context) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, constants.ERROR_UNAUTHORIZED, "Authentication required", nil)
+		return
+	}
+
+	// The body is optional; without it only the access token is revoked
+	var req logoutRequest
+	_ = c.ShouldBindJSON(&req)
+
+	if err := h.service.Logout(claims, req.RefreshToken); err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_TOKEN_REVOCATION_FAILED, "Failed to log out", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_LOGOUT, "Logged out successfully", nil)
+}
+
+// LogoutEverywhere handles POST /api/v1/auth/logout-everywhere
+// Every session of the caller ends, on all tablets and browsers
+func (h *AuthHandler) LogoutEverywhere(c *gin.Context) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, constants.ERROR_UNAUTHORIZED, "Authentication required", nil)
+		return
+	}
+
+	if err := h.service.LogoutEverywhere(claims); err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_TOKEN_REVOCATION_FAILED, "Failed to log out everywhere", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_LOGOUT, "Logged out of all sessions successfully", nil)
+}
+
+// RevokePersonel handles POST /api/v1/auth/revoke/personel/:personel_kodu
+func (h *AuthHandler) RevokePersonel(c *gin.Context) {
+	personelKodu := c.Param("personel_kodu")
+	if personelKodu == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_PERSONEL_KODU, "Personnel code is required", nil)
+		return
+	}
+
+	var req revokeRequest
+	_ = c.ShouldBindJSON(&req)
+
+	if err := h.service.RevokePersonel(personelKodu, c.GetString(middleware.ContextKeyPersonelKodu), req.Sebep); err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_PERSONEL_NOT_FOUND, "Failed to revoke personnel tokens", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_TOKENS_REVOKED, "Personnel tokens revoked successfully", nil)
+}
+
+// RevokeNFCKart handles POST /api/v1/auth/revoke/nfc-kart/:nfc_kart_kodu
+func (h *AuthHandler) RevokeNFCKart(c *gin.Context) {
+	nfcKartKodu := c.Param("nfc_kart_kodu")
+	if nfcKartKodu == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_NFC_KART_KODU, "NFC card code is required", nil)
+		return
+	}
+
+	var req revokeRequest
+	_ = c.ShouldBindJSON(&req)
+
+	if err := h.service.RevokeNFCKart(nfcKartKodu, c.GetString(middleware.ContextKeyPersonelKodu), req.Sebep); err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_NFC_KART_NOT_FOUND, "Failed to revoke NFC card tokens", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_TOKENS_REVOKED, "NFC card tokens revoked successfully", nil)
+}
+
+// UnblockPersonel handles POST /api/v1/auth/revoke/personel/:personel_kodu/unblock
+func (h *AuthHandler) UnblockPersonel(c *gin.Context) {
+	h.unblock(c, h.service.UnblockPersonel(c.Param("personel_kodu"), c.GetString(middleware.ContextKeyPersonelKodu)))
+}
+
+// UnblockNFCKart handles POST /api/v1/auth/revoke/nfc-kart/:nfc_kart_kodu/unblock
+func (h *AuthHandler) UnblockNFCKart(c *gin.Context) {
+	h.unblock(c, h.service.UnblockNFCKart(c.Param("nfc_kart_kodu"), c.GetString(middleware.ContextKeyPersonelKodu)))
+}
+
+func (h *AuthHandler) unblock(c *gin.Context, err error) {
+	if err != nil {
+		if errors.Is(err, service.ErrGirisEngeliYok) {
+			utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_LOGIN_BLOCK_NOT_FOUND, "No login block to lift", nil)
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to lift login block", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_LOGIN_UNBLOCKED, "Login block lifted successfully", nil)
+}
+
+// Escalate handles POST /api/v1/auth/escalate
+// A HEKIM on a bedside tablet gets a token that is no longer limited to the patient in the bed
+func (h *AuthHandler) Escalate(c *gin.Context) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, constants.ERROR_UNAUTHORIZED, "Authentication required", nil)
+		return
+	}
+
+	var req escalateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "sebep is required", err)
+		return
+	}
+
+	sonuc, err := h.service.Escalate(claims, req.Sebep)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrYatakKisitiYok):
+			utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_NOT_BED_BOUND, "Token is not bound to a bed", nil)
+		case errors.Is(err, service.ErrEskalasyonYetkisi):
+			utils.SendErrorResponse(c, http.StatusForbidden, constants.ERROR_FORBIDDEN, "Only a HEKIM may lift the bed binding", nil)
+		case errors.Is(err, service.ErrTokenGeneration):
+			utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to generate authentication token", err)
+		default:
+			utils.SendErrorResponse(c, http.StatusUnauthorized, constants.ERROR_UNAUTHORIZED, "Escalation failed", err)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_ESCALATED, "Bed binding lifted successfully", sonuc)
+}
+
+// RevokeOturum handles POST /api/v1/auth/revoke/oturum/:oturum_kodu
+func (h *AuthHandler) RevokeOturum(c *gin.Context) {
+	var req revokeRequest
+	_ = c.ShouldBindJSON(&req)
+
+	if err := h.service.RevokeOturum(c.Param("oturum_kodu"), c.GetString(middleware.ContextKeyPersonelKodu), req.Sebep); err != nil {
+		h.sendOturumError(c, err, "Failed to end session")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_OTURUMLAR_ENDED, "Session ended successfully", nil)
+}
+
+// RevokeTabletCihaz handles POST /api/v1/auth/revoke/tablet-cihaz/:tablet_cihaz_kodu
+func (h *AuthHandler) RevokeTabletCihaz(c *gin.Context) {
+	var req revokeRequest
+	_ = c.ShouldBindJSON(&req)
+
+	if err := h.service.RevokeTabletCihaz(c.Param("tablet_cihaz_kodu"), c.GetString(middleware.ContextKeyPersonelKodu), req.Sebep); err != nil {
+		h.sendOturumError(c, err, "Failed to end tablet sessions")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_OTURUMLAR_ENDED, "Tablet sessions ended successfully", nil)
+}
+
+func (h *AuthHandler) sendOturumError(c *gin.Context, err error, message string) {
+	if errors.Is(err, service.ErrOturumBulunamadi) {
+		utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_OTURUM_NOT_FOUND, "No active session found", nil)
+		return
+	}
+	utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_TOKEN_REVOCATION_FAILED, message, err)
+}
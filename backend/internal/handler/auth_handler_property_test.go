@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"errors"
+	"medscreen/internal/ratelimit"
+	"medscreen/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Feature: nfc-login, Property 1: Tablets Prove Their Identity
+// *For any* NFC login, the tokens SHALL be bound to the tablet the request names only when
+// it carries that tablet's device credential; a login without a verified tablet SHALL be
+// refused.
+
+type girisAuthService struct {
+	service.AuthService
+	cagrildi bool
+	tablet   string
+}
+
+func (s *girisAuthService) LoginWithNFC(kartUID, tabletCihazKodu string) (*service.NFCGirisSonucu, error) {
+	s.cagrildi = true
+	s.tablet = tabletCihazKodu
+	if kartUID == "YANLIS" {
+		return nil, errors.New("card not found")
+	}
+	return &service.NFCGirisSonucu{}, nil
+}
+
+type girisCihazService struct {
+	service.CihazService
+}
+
+// girisAnahtarlari holds the device credential of each tablet the stub knows
+var girisAnahtarlari = map[string]string{"TBL1": "dogru-anahtar", "TBL3": "ucuncu-anahtar"}
+
+func (girisCihazService) Dogrula(tabletCihazKodu, anahtar string) error {
+	if beklenen, ok := girisAnahtarlari[tabletCihazKodu]; !ok || anahtar != beklenen {
+		return service.ErrCihazAnahtariGecersiz
+	}
+	return nil
+}
+
+// nfcGiris sends an NFC login with the given headers and returns the status and the auth service
+func nfcGiris(path string, headers map[string]string) (int, *girisAuthService) {
+	gin.SetMode(gin.TestMode)
+	auth := &girisAuthService{}
+	h := NewAuthHandler(auth, girisCihazService{})
+
+	router := gin.New()
+	router.GET("/api/v1/nfc-kart/authenticate/:kart_uid", h.TabletDogrula, h.LoginWithNFC)
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp.Code, auth
+}
+
+// TestNFCLogin_TabletRequiresDeviceCredential verifies that no login goes through without a
+// verified tablet
+func TestNFCLogin_TabletRequiresDeviceCredential(t *testing.T) {
+	cases := map[string]map[string]string{
+		"missing tablet":     {CihazAnahtariHeader: "dogru-anahtar"},
+		"missing credential": {TabletCihazHeader: "TBL1"},
+		"wrong credential":   {TabletCihazHeader: "TBL1", CihazAnahtariHeader: "yanlis-anahtar"},
+		"wrong tablet":       {TabletCihazHeader: "TBL2", CihazAnahtariHeader: "dogru-anahtar"},
+	}
+	for ad, headers := range cases {
+		code, auth := nfcGiris("/api/v1/nfc-kart/authenticate/AABBCCDD", headers)
+		if code != http.StatusUnauthorized || auth.cagrildi {
+			t.Errorf("%s: expected 401 without a login, got %d (login called: %t)", ad, code, auth.cagrildi)
+		}
+	}
+}
+
+// TestNFCLogin_VerifiedTabletIsBound verifies that a verified tablet reaches the login
+func TestNFCLogin_VerifiedTabletIsBound(t *testing.T) {
+	code, auth := nfcGiris("/api/v1/nfc-kart/authenticate/AABBCCDD", map[string]string{
+		TabletCihazHeader:   "TBL1",
+		CihazAnahtariHeader: "dogru-anahtar",
+	})
+	if code != http.StatusOK || auth.tablet != "TBL1" {
+		t.Errorf("Expected a login bound to TBL1, got %d with tablet %q", code, auth.tablet)
+	}
+}
+
+// TestNFCLogin_QueryTabletIgnored verifies that the tablet cannot be named in the query string
+func TestNFCLogin_QueryTabletIgnored(t *testing.T) {
+	code, auth := nfcGiris("/api/v1/nfc-kart/authenticate/AABBCCDD?tablet_cihaz_kodu=TBL1", nil)
+	if code != http.StatusUnauthorized || auth.cagrildi {
+		t.Errorf("Expected 401 without a login, got %d (login called: %t)", code, auth.cagrildi)
+	}
+}
+
+// TestNFCKaynaklari_OnlyVerifiedTablet verifies that the rate limiter keys on a tablet only
+// after its device credential was verified, and that a success clears only the tablet
+func TestNFCKaynaklari_OnlyVerifiedTablet(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewAuthHandler(&girisAuthService{}, girisCihazService{})
+
+	kaynaklarOf := func(handlers ...gin.HandlerFunc) []string {
+		var kaynaklar []string
+		router := gin.New()
+		router.GET("/login", append(handlers, func(c *gin.Context) { kaynaklar = NFCKaynaklari(c) })...)
+		req := httptest.NewRequest(http.MethodGet, "/login", nil)
+		req.RemoteAddr = "10.0.0.5:1234"
+		req.Header.Set(TabletCihazHeader, "TBL1")
+		req.Header.Set(CihazAnahtariHeader, "dogru-anahtar")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		return kaynaklar
+	}
+
+	if got := kaynaklarOf(); len(got) != 1 || got[0] != "ip:10.0.0.5" {
+		t.Errorf("Expected only the IP for an unverified tablet, got %v", got)
+	}
+	if got := kaynaklarOf(h.TabletDogrula); len(got) != 1 || got[0] != "cihaz:TBL1" {
+		t.Errorf("Expected only the verified tablet, got %v", got)
+	}
+
+	if NFCSifirlanir("ip:10.0.0.5") || !NFCSifirlanir("cihaz:TBL1") {
+		t.Error("Expected a success to clear the tablet but not the IP")
+	}
+}
+
+// TestNFCLimit_SharedIPDoesNotBlockOtherTablets verifies that a tablet failing behind a
+// ward's NAT is blocked without blocking the other tablets on the same IP
+func TestNFCLimit_SharedIPDoesNotBlockOtherTablets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewAuthHandler(&girisAuthService{}, girisCihazService{})
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(time.Hour), ratelimit.Ayarlar{
+		Serbest:     1,
+		Taban:       time.Minute,
+		KilitEsigi:  3,
+		KilitSuresi: time.Hour,
+		Sifirlama:   time.Hour,
+	})
+
+	router := gin.New()
+	router.GET("/api/v1/nfc-kart/authenticate/:kart_uid", h.TabletDogrula,
+		ratelimit.Middleware(limiter, "nfc_authenticate", NFCKaynaklari, NFCSifirlanir), h.LoginWithNFC)
+
+	giris := func(kartUID, tablet string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/nfc-kart/authenticate/"+kartUID, nil)
+		req.RemoteAddr = "10.0.0.5:1234"
+		req.Header.Set(TabletCihazHeader, tablet)
+		req.Header.Set(CihazAnahtariHeader, girisAnahtarlari[tablet])
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	for i := 0; i < 5; i++ {
+		giris("YANLIS", "TBL3")
+	}
+	if code := giris("AABBCCDD", "TBL3"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected the failing tablet to be blocked, got %d", code)
+	}
+	if code := giris("AABBCCDD", "TBL1"); code != http.StatusOK {
+		t.Errorf("Expected another tablet on the same IP to log in, got %d", code)
+	}
+}
@@ -2,10 +2,10 @@ package handler
 
 import (
 	"medscreen/internal/constants"
+	"medscreen/internal/models"
 	"medscreen/internal/service"
 	"medscreen/internal/utils"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -45,23 +45,18 @@ func (h *BasvuruTaniHandler) GetByHasta(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.BasvuruTaniSorgusu)
+	if !ok {
+		return
 	}
 
-	tanilar, total, err := h.service.GetByHastaKodu(hastaKodu, page, limit)
+	tanilar, total, err := h.service.List(q.With("hasta_kodu", hastaKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve diagnoses", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, tanilar)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_BASVURU_TANILAR_RETRIEVED, "Diagnoses retrieved successfully", tanilar, meta)
 }
 
@@ -73,22 +68,17 @@ func (h *BasvuruTaniHandler) GetByBasvuru(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.BasvuruTaniSorgusu)
+	if !ok {
+		return
 	}
 
-	tanilar, total, err := h.service.GetByBasvuruKodu(basvuruKodu, page, limit)
+	tanilar, total, err := h.service.List(q.With("hasta_basvuru_kodu", basvuruKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve diagnoses", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, tanilar)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_BASVURU_TANILAR_RETRIEVED, "Diagnoses retrieved successfully", tanilar, meta)
 }
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"medscreen/internal/constants"
+	"medscreen/internal/middleware"
+	"medscreen/internal/repository"
+	"medscreen/internal/service"
+	"medscreen/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CihazAnahtariHeader carries the credential a tablet received when it enrolled
+const CihazAnahtariHeader = "X-Cihaz-Anahtari"
+
+// CihazHandler handles HTTP requests for tablet enrollment, heartbeats and fleet status
+type CihazHandler struct {
+	service service.CihazService
+}
+
+// NewCihazHandler creates a new CihazHandler instance
+func NewCihazHandler(service service.CihazService) *CihazHandler {
+	return &CihazHandler{service: service}
+}
+
+type cihazKayitRequest struct {
+	SeriNumarasi string `json:"seri_numarasi"`
+	// DeviceID is the field name used by the mobile app
+	DeviceID string `json:"device_id"`
+	// KayitKodu is the one-time code an admin issued for the tablet
+	KayitKodu string `json:"kayit_kodu"`
+}
+
+type cihazNabizRequest struct {
+	UygulamaSurumu *string `json:"uygulama_surumu"`
+	PilSeviyesi    *int    `json:"pil_seviyesi"`
+}
+
+// Register handles POST /api/v1/devices/register
+func (h *CihazHandler) Register(c *gin.Context) {
+	var req cihazKayitRequest
+	_ = c.ShouldBindJSON(&req)
+	seriNumarasi := req.SeriNumarasi
+	if seriNumarasi == "" {
+		seriNumarasi = req.DeviceID
+	}
+	if seriNumarasi == "" || req.KayitKodu == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "seri_numarasi and kayit_kodu are required", nil)
+		return
+	}
+
+	sonuc, err := h.service.Register(seriNumarasi, req.KayitKodu, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, service.ErrCihazKaydiGecersiz) {
+			utils.SendErrorResponse(c, http.StatusUnauthorized, constants.ERROR_INVALID_CIHAZ_KAYIT_KODU, "Invalid serial number or enrollment code", nil)
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to enroll device", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, constants.SUCCESS_CIHAZ_ENROLLED, "Device enrolled successfully", sonuc)
+}
+
+// KayitKoduOlustur handles POST /api/v1/devices/:tablet_cihaz_kodu/kayit-kodu
+func (h *CihazHandler) KayitKoduOlustur(c *gin.Context) {
+	sonuc, err := h.service.KayitKoduOlustur(c.Param("tablet_cihaz_kodu"), c.GetString(middleware.ContextKeyPersonelKodu))
+	if err != nil {
+		if errors.Is(err, service.ErrCihazBulunamadi) {
+			utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_TABLET_CIHAZ_NOT_FOUND, "Device not found or inactive", nil)
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to issue enrollment code", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, constants.SUCCESS_CIHAZ_KAYIT_KODU_CREATED, "Enrollment code issued; it will not be shown again", sonuc)
+}
+
+// Heartbeat handles POST /api/v1/devices/heartbeat
+// The tablet is identified by the X-Tablet-Cihaz-Kodu and X-Cihaz-Anahtari headers
+func (h *CihazHandler) Heartbeat(c *gin.Context) {
+	var req cihazNabizRequest
+	_ = c.ShouldBindJSON(&req)
+	if req.PilSeviyesi != nil && (*req.PilSeviyesi < 0 || *req.PilSeviyesi > 100) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "pil_seviyesi must be between 0 and 100", nil)
+		return
+	}
+
+	nabiz := repository.CihazNabzi{
+		IPAdresi:       c.ClientIP(),
+		UygulamaSurumu: req.UygulamaSurumu,
+		PilSeviyesi:    req.PilSeviyesi,
+	}
+	if err := h.service.Nabiz(c.GetHeader(TabletCihazHeader), c.GetHeader(CihazAnahtariHeader), nabiz); err != nil {
+		if errors.Is(err, service.ErrCihazAnahtariGecersiz) {
+			utils.SendErrorResponse(c, http.StatusUnauthorized, constants.ERROR_INVALID_CIHAZ_CREDENTIAL, "Invalid device credential", nil)
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to record heartbeat", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_CIHAZ_HEARTBEAT, "Heartbeat recorded successfully", nil)
+}
+
+// Revoke handles POST /api/v1/devices/:tablet_cihaz_kodu/revoke
+func (h *CihazHandler) Revoke(c *gin.Context) {
+	kodu := c.Param("tablet_cihaz_kodu")
+	if kodu == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_TABLET_CIHAZ_KODU, "Device code is required", nil)
+		return
+	}
+
+	if err := h.service.Iptal(kodu); err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_TABLET_CIHAZ_NOT_FOUND, "Device enrollment not found", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_CIHAZ_REVOKED, "Device enrollment revoked successfully", nil)
+}
+
+// GetFilo handles GET /api/v1/tablet-cihaz/filo
+// By default only tablets with a problem are listed; ?sorun= narrows to one problem and ?tumu=true lists every tablet
+func (h *CihazHandler) GetFilo(c *gin.Context) {
+	sorun := service.FiloSorunu(c.Query("sorun"))
+	switch sorun {
+	case "", service.FiloSorunuKayitsiz, service.FiloSorunuCevrimdisi, service.FiloSorunuAtanmamis, service.FiloSorunuBosYatak:
+	default:
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_FILO_SORUNU, "sorun must be one of KAYITSIZ, CEVRIMDISI, ATANMAMIS, BOS_YATAK", nil)
+		return
+	}
+
+	filo, err := h.service.GetFilo(sorun, c.Query("tumu") == "true")
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve fleet status", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_CIHAZ_FILO_RETRIEVED, "Fleet status retrieved successfully", filo)
+}
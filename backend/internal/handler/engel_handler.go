@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"medscreen/internal/constants"
+	"medscreen/internal/ratelimit"
+	"medscreen/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EngelHandler handles HTTP requests for the sources blocked after failed logins
+type EngelHandler struct {
+	limiter *ratelimit.Limiter
+}
+
+// NewEngelHandler creates a new EngelHandler instance
+func NewEngelHandler(limiter *ratelimit.Limiter) *EngelHandler {
+	return &EngelHandler{limiter: limiter}
+}
+
+type engelKaldirRequest struct {
+	Anahtar string `json:"anahtar" binding:"required"`
+}
+
+// GetAll handles GET /api/v1/auth/blocked-sources
+func (h *EngelHandler) GetAll(c *gin.Context) {
+	engelliler, err := h.limiter.Engelliler()
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve blocked sources", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_BLOCKED_SOURCES, "Blocked sources retrieved successfully", engelliler)
+}
+
+// Unblock handles POST /api/v1/auth/blocked-sources/unblock
+func (h *EngelHandler) Unblock(c *gin.Context) {
+	var req engelKaldirRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "anahtar is required", err)
+		return
+	}
+
+	if err := h.limiter.Kaldir(req.Anahtar); err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to unblock source", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_SOURCE_UNBLOCKED, "Source unblocked successfully", gin.H{"anahtar": req.Anahtar})
+}
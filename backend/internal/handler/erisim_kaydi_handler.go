@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"medscreen/internal/constants"
+	"medscreen/internal/repository"
+	"medscreen/internal/service"
+	"medscreen/internal/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErisimKaydiHandler handles HTTP requests for the patient data access audit trail (auditors only)
+type ErisimKaydiHandler struct {
+	service service.ErisimKaydiService
+}
+
+// NewErisimKaydiHandler creates a new ErisimKaydiHandler instance
+func NewErisimKaydiHandler(service service.ErisimKaydiService) *ErisimKaydiHandler {
+	return &ErisimKaydiHandler{service: service}
+}
+
+// GetByHasta handles GET /api/v1/erisim-kaydi/hasta/:hasta_kodu
+func (h *ErisimKaydiHandler) GetByHasta(c *gin.Context) {
+	hastaKodu := c.Param("hasta_kodu")
+	if hastaKodu == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_HASTA_KODU, "Patient code is required", nil)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	kayitlar, total, err := h.service.GetByHastaKodu(hastaKodu, page, limit)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve access records", err)
+		return
+	}
+
+	meta := utils.CalculateMeta(page, limit, total)
+	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_ERISIM_KAYITLARI_RETRIEVED, "Access records retrieved successfully", kayitlar, meta)
+}
+
+// GetByFilters handles GET /api/v1/erisim-kaydi
+// Query parameters: hasta_kodu, hasta_basvuru_kodu, personel_kodu, acil_erisim_kodu, start_date, end_date (YYYY-MM-DD, inclusive)
+func (h *ErisimKaydiHandler) GetByFilters(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	filtre := repository.ErisimKaydiFiltresi{
+		HastaKodu:        c.Query("hasta_kodu"),
+		HastaBasvuruKodu: c.Query("hasta_basvuru_kodu"),
+		PersonelKodu:     c.Query("personel_kodu"),
+		AcilErisimKodu:   c.Query("acil_erisim_kodu"),
+	}
+
+	if start := c.Query("start_date"); start != "" {
+		t, err := time.Parse("2006-01-02", start)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_DATE_RANGE, "Invalid start date format (use YYYY-MM-DD)", err)
+			return
+		}
+		filtre.StartDate = &t
+	}
+
+	if end := c.Query("end_date"); end != "" {
+		t, err := time.Parse("2006-01-02", end)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_DATE_RANGE, "Invalid end date format (use YYYY-MM-DD)", err)
+			return
+		}
+		// Include the whole end day
+		t = t.AddDate(0, 0, 1)
+		filtre.EndDate = &t
+	}
+
+	if filtre.HastaKodu == "" && filtre.HastaBasvuruKodu == "" && filtre.PersonelKodu == "" && filtre.AcilErisimKodu == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "At least one filter (hasta_kodu, hasta_basvuru_kodu, personel_kodu or acil_erisim_kodu) is required", nil)
+		return
+	}
+
+	kayitlar, total, err := h.service.GetByFilter(filtre, page, limit)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve access records", err)
+		return
+	}
+
+	meta := utils.CalculateMeta(page, limit, total)
+	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_ERISIM_KAYITLARI_RETRIEVED, "Access records retrieved successfully", kayitlar, meta)
+}
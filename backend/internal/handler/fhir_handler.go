@@ -0,0 +1,511 @@
+package handler
+
+import (
+	"errors"
+	"medscreen/internal/constants"
+	"medscreen/internal/fhir"
+	"medscreen/internal/masking"
+	"medscreen/internal/models"
+	"medscreen/internal/query"
+	"medscreen/internal/service"
+	"medscreen/internal/utils"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FHIRServices groups the services the FHIR facade reads from
+type FHIRServices struct {
+	Hasta                 service.HastaService
+	HastaBasvuru          service.HastaBasvuruService
+	HastaVitalFizikiBulgu service.HastaVitalFizikiBulguService
+	TetkikSonuc           service.TetkikSonucService
+	BasvuruTani           service.BasvuruTaniService
+	HastaTibbiBilgi       service.HastaTibbiBilgiService
+	Recete                service.ReceteService
+	Personel              service.PersonelService
+}
+
+// FHIRHandler handles HTTP requests for the read-only FHIR R4 facade
+type FHIRHandler struct {
+	services FHIRServices
+	masking  *masking.Policy
+}
+
+// NewFHIRHandler creates a new FHIRHandler instance
+func NewFHIRHandler(services FHIRServices, maskingPolicy *masking.Policy) *FHIRHandler {
+	return &FHIRHandler{services: services, masking: maskingPolicy}
+}
+
+// Metadata handles GET /fhir/R4/metadata
+func (h *FHIRHandler) Metadata(c *gin.Context) {
+	fhir.Write(c, http.StatusOK, fhir.NewCapabilityStatement(fhirBase(c)))
+}
+
+// ReadPatient handles GET /fhir/R4/Patient/:id
+func (h *FHIRHandler) ReadPatient(c *gin.Context) {
+	hasta, err := h.services.Hasta.GetByKodu(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_HASTA_NOT_FOUND, "Patient not found", err)
+		return
+	}
+	fhir.Write(c, http.StatusOK, fhir.NewPatient(hasta, h.mask(c)))
+}
+
+// SearchPatient handles GET /fhir/R4/Patient
+// Search parameters: _id
+func (h *FHIRHandler) SearchPatient(c *gin.Context) {
+	s, ok := parseFHIRSearch(c, "Patient")
+	if !ok {
+		return
+	}
+	q := defaultQuery(models.HastaSorgusu)
+	if id := s.Token("_id"); id != "" {
+		q = q.With("hasta_kodu", id)
+	}
+
+	hastalar, total, err := h.services.Hasta.List(q, s.Page, s.Count)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to search patients", err)
+		return
+	}
+	entries := make([]fhir.Resource, 0, len(hastalar))
+	for i := range hastalar {
+		entries = append(entries, fhir.NewPatient(&hastalar[i], h.mask(c)))
+	}
+	sendSearchset(c, s, &total, hasMore(s, total), entries)
+}
+
+// ReadEncounter handles GET /fhir/R4/Encounter/:id
+func (h *FHIRHandler) ReadEncounter(c *gin.Context) {
+	basvuru, err := h.services.HastaBasvuru.GetByKodu(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_HASTA_BASVURU_NOT_FOUND, "Encounter not found", err)
+		return
+	}
+	fhir.Write(c, http.StatusOK, fhir.NewEncounter(basvuru))
+}
+
+// SearchEncounter handles GET /fhir/R4/Encounter
+// Search parameters: _id, patient, date
+func (h *FHIRHandler) SearchEncounter(c *gin.Context) {
+	s, ok := parseFHIRSearch(c, "Encounter")
+	if !ok {
+		return
+	}
+	patient, err := s.Reference("patient", "Patient")
+	if err != nil {
+		sendInvalidSearch(c, err)
+		return
+	}
+	q, ok := applyDates(c, s, "date", defaultQuery(models.HastaBasvuruSorgusu), "hasta_kabul_zamani")
+	if !ok {
+		return
+	}
+	if id := s.Token("_id"); id != "" {
+		q = q.With("hasta_basvuru_kodu", id)
+	}
+	if patient != "" {
+		q = q.With("hasta_kodu", patient)
+	}
+
+	basvurular, total, err := h.services.HastaBasvuru.List(q, s.Page, s.Count)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to search encounters", err)
+		return
+	}
+	entries := make([]fhir.Resource, 0, len(basvurular))
+	for i := range basvurular {
+		entries = append(entries, fhir.NewEncounter(&basvurular[i]))
+	}
+	sendSearchset(c, s, &total, hasMore(s, total), entries)
+}
+
+// ReadObservation handles GET /fhir/R4/Observation/:id
+// Vital signs have ids "<hasta_vital_fiziki_bulgu_kodu>-<measurement>", laboratory
+// results "lab-<tetkik_sonuc_kodu>".
+func (h *FHIRHandler) ReadObservation(c *gin.Context) {
+	id := c.Param("id")
+	kodu, slug, lab, ok := fhir.ParseObservationID(id)
+	if !ok {
+		utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_NOT_FOUND, "Observation not found", nil)
+		return
+	}
+
+	if lab {
+		sonuc, err := h.services.TetkikSonuc.GetByKodu(kodu)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_TETKIK_SONUC_NOT_FOUND, "Observation not found", err)
+			return
+		}
+		fhir.Write(c, http.StatusOK, fhir.NewLabResult(sonuc))
+		return
+	}
+
+	bulgu, err := h.services.HastaVitalFizikiBulgu.GetByKodu(kodu)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_VITAL_BULGU_NOT_FOUND, "Observation not found", err)
+		return
+	}
+	for _, o := range fhir.NewVitalSigns(bulgu) {
+		if o.ID == id {
+			fhir.Write(c, http.StatusOK, o)
+			return
+		}
+	}
+	// The record exists but does not hold this measurement
+	utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_VITAL_BULGU_NOT_FOUND, "Observation not found", errors.New(slug+" was not measured"))
+}
+
+// SearchObservation handles GET /fhir/R4/Observation
+// Search parameters: patient, encounter, date, category (vital-signs or laboratory)
+//
+// Without a category a page holds a page of vital signs records and a page of laboratory
+// results. A vital signs record yields several Observations, so Bundle.total is only
+// given for laboratory searches.
+func (h *FHIRHandler) SearchObservation(c *gin.Context) {
+	s, ok := parseFHIRSearch(c, "Observation")
+	if !ok {
+		return
+	}
+	category := s.Token("category")
+	if category != "" && category != fhir.CategoryVitalSigns && category != fhir.CategoryLaboratory {
+		sendInvalidSearch(c, errors.New("category must be vital-signs or laboratory"))
+		return
+	}
+	scope, ok := h.visitScope(c, s)
+	if !ok {
+		return
+	}
+	vitals := scope(defaultQuery(models.HastaVitalFizikiBulguSorgusu))
+	labs := scope(defaultQuery(models.TetkikSonucSorgusu))
+	if vitals, ok = applyDates(c, s, "date", vitals, "islem_zamani"); !ok {
+		return
+	}
+	if labs, ok = applyDates(c, s, "date", labs, "kayit_zamani"); !ok {
+		return
+	}
+
+	var entries []fhir.Resource
+	var total *int64
+	more := false
+	if category != fhir.CategoryLaboratory {
+		bulgular, n, err := h.services.HastaVitalFizikiBulgu.List(vitals, s.Page, s.Count)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to search observations", err)
+			return
+		}
+		for i := range bulgular {
+			for _, o := range fhir.NewVitalSigns(&bulgular[i]) {
+				entries = append(entries, o)
+			}
+		}
+		more = more || hasMore(s, n)
+	}
+	if category != fhir.CategoryVitalSigns {
+		sonuclar, n, err := h.services.TetkikSonuc.List(labs, s.Page, s.Count)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to search observations", err)
+			return
+		}
+		for i := range sonuclar {
+			entries = append(entries, fhir.NewLabResult(&sonuclar[i]))
+		}
+		more = more || hasMore(s, n)
+		if category == fhir.CategoryLaboratory {
+			total = &n
+		}
+	}
+	sendSearchset(c, s, total, more, entries)
+}
+
+// ReadCondition handles GET /fhir/R4/Condition/:id
+func (h *FHIRHandler) ReadCondition(c *gin.Context) {
+	tani, err := h.services.BasvuruTani.GetByKodu(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_BASVURU_TANI_NOT_FOUND, "Condition not found", err)
+		return
+	}
+	fhir.Write(c, http.StatusOK, fhir.NewCondition(tani))
+}
+
+// SearchCondition handles GET /fhir/R4/Condition
+// Search parameters: _id, patient, encounter, recorded-date
+func (h *FHIRHandler) SearchCondition(c *gin.Context) {
+	s, ok := parseFHIRSearch(c, "Condition")
+	if !ok {
+		return
+	}
+	patient, err := s.Reference("patient", "Patient")
+	if err != nil {
+		sendInvalidSearch(c, err)
+		return
+	}
+	encounter, err := s.Reference("encounter", "Encounter")
+	if err != nil {
+		sendInvalidSearch(c, err)
+		return
+	}
+	q, ok := applyDates(c, s, "recorded-date", defaultQuery(models.BasvuruTaniSorgusu), "tani_zamani")
+	if !ok {
+		return
+	}
+	if id := s.Token("_id"); id != "" {
+		q = q.With("basvuru_tani_kodu", id)
+	}
+	if patient != "" {
+		q = q.With("hasta_kodu", patient)
+	}
+	if encounter != "" {
+		q = q.With("hasta_basvuru_kodu", encounter)
+	}
+
+	tanilar, total, err := h.services.BasvuruTani.List(q, s.Page, s.Count)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to search conditions", err)
+		return
+	}
+	entries := make([]fhir.Resource, 0, len(tanilar))
+	for i := range tanilar {
+		entries = append(entries, fhir.NewCondition(&tanilar[i]))
+	}
+	sendSearchset(c, s, &total, hasMore(s, total), entries)
+}
+
+// ReadAllergyIntolerance handles GET /fhir/R4/AllergyIntolerance/:id
+// Only medical information records of type ALERJI are allergies.
+func (h *FHIRHandler) ReadAllergyIntolerance(c *gin.Context) {
+	bilgi, err := h.services.HastaTibbiBilgi.GetByKodu(c.Param("id"))
+	if err == nil && bilgi.TibbiBilgiTuruKodu != string(models.TibbiBilgiAlerji) {
+		err = errors.New("medical information is not an allergy")
+	}
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_HASTA_TIBBI_BILGI_NOT_FOUND, "AllergyIntolerance not found", err)
+		return
+	}
+	fhir.Write(c, http.StatusOK, fhir.NewAllergyIntolerance(bilgi))
+}
+
+// SearchAllergyIntolerance handles GET /fhir/R4/AllergyIntolerance
+// Search parameters: _id, patient, date
+func (h *FHIRHandler) SearchAllergyIntolerance(c *gin.Context) {
+	s, ok := parseFHIRSearch(c, "AllergyIntolerance")
+	if !ok {
+		return
+	}
+	patient, err := s.Reference("patient", "Patient")
+	if err != nil {
+		sendInvalidSearch(c, err)
+		return
+	}
+	q, ok := applyDates(c, s, "date", defaultQuery(models.HastaTibbiBilgiSorgusu), "kayit_zamani")
+	if !ok {
+		return
+	}
+	q = q.With("tibbi_bilgi_turu_kodu", string(models.TibbiBilgiAlerji))
+	if id := s.Token("_id"); id != "" {
+		q = q.With("hasta_tibbi_bilgi_kodu", id)
+	}
+	if patient != "" {
+		q = q.With("hasta_kodu", patient)
+	}
+
+	bilgiler, total, err := h.services.HastaTibbiBilgi.List(q, s.Page, s.Count)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to search allergies", err)
+		return
+	}
+	entries := make([]fhir.Resource, 0, len(bilgiler))
+	for i := range bilgiler {
+		entries = append(entries, fhir.NewAllergyIntolerance(&bilgiler[i]))
+	}
+	sendSearchset(c, s, &total, hasMore(s, total), entries)
+}
+
+// ReadMedicationRequest handles GET /fhir/R4/MedicationRequest/:id
+// The id is the recete_ilac_kodu of the prescribed drug.
+func (h *FHIRHandler) ReadMedicationRequest(c *gin.Context) {
+	id := c.Param("id")
+	ilaclar, _, err := h.services.Recete.ListIlac(query.Query{}.With("recete_ilac_kodu", id), 1, 1)
+	if err == nil && len(ilaclar) == 0 {
+		err = errors.New("prescription drug not found")
+	}
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_RECETE_NOT_FOUND, "MedicationRequest not found", err)
+		return
+	}
+
+	recete, err := h.services.Recete.GetByKodu(ilaclar[0].ReceteKodu)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_RECETE_NOT_FOUND, "MedicationRequest not found", err)
+		return
+	}
+	fhir.Write(c, http.StatusOK, fhir.NewMedicationRequest(recete, &ilaclar[0]))
+}
+
+// SearchMedicationRequest handles GET /fhir/R4/MedicationRequest
+// Search parameters: patient, encounter, authoredon
+//
+// Pages are pages of prescriptions, each yielding one MedicationRequest per drug, so
+// Bundle.total is not given.
+func (h *FHIRHandler) SearchMedicationRequest(c *gin.Context) {
+	s, ok := parseFHIRSearch(c, "MedicationRequest")
+	if !ok {
+		return
+	}
+	scope, ok := h.visitScope(c, s)
+	if !ok {
+		return
+	}
+	q, ok := applyDates(c, s, "authoredon", scope(defaultQuery(models.ReceteSorgusu)), "recete_zamani")
+	if !ok {
+		return
+	}
+
+	receteler, total, err := h.services.Recete.List(q, s.Page, s.Count)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to search medication requests", err)
+		return
+	}
+	var entries []fhir.Resource
+	for i := range receteler {
+		for _, r := range fhir.NewMedicationRequests(&receteler[i]) {
+			entries = append(entries, r)
+		}
+	}
+	sendSearchset(c, s, nil, hasMore(s, total), entries)
+}
+
+// ReadPractitioner handles GET /fhir/R4/Practitioner/:id
+func (h *FHIRHandler) ReadPractitioner(c *gin.Context) {
+	personel, err := h.services.Personel.GetByKodu(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_PERSONEL_NOT_FOUND, "Practitioner not found", err)
+		return
+	}
+	fhir.Write(c, http.StatusOK, fhir.NewPractitioner(personel, h.mask(c)))
+}
+
+// SearchPractitioner handles GET /fhir/R4/Practitioner
+// Search parameters: _id
+func (h *FHIRHandler) SearchPractitioner(c *gin.Context) {
+	s, ok := parseFHIRSearch(c, "Practitioner")
+	if !ok {
+		return
+	}
+	q := defaultQuery(models.PersonelSorgusu)
+	if id := s.Token("_id"); id != "" {
+		q = q.With("personel_kodu", id)
+	}
+
+	personeller, total, err := h.services.Personel.List(q, s.Page, s.Count)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to search practitioners", err)
+		return
+	}
+	entries := make([]fhir.Resource, 0, len(personeller))
+	for i := range personeller {
+		entries = append(entries, fhir.NewPractitioner(&personeller[i], h.mask(c)))
+	}
+	sendSearchset(c, s, &total, hasMore(s, total), entries)
+}
+
+// mask masks personal data for the caller of c, by the policy of the VEM-shaped responses
+func (h *FHIRHandler) mask(c *gin.Context) fhir.Masker {
+	return func(entity, field, value string) (string, bool) {
+		return h.masking.Field(c, entity, field, value)
+	}
+}
+
+// visitScope returns what restricts a query, on a table that refers to patients only
+// through their visits, to the patient= and encounter= of a search. A patient without
+// visits matches nothing.
+func (h *FHIRHandler) visitScope(c *gin.Context, s fhir.Search) (func(query.Query) query.Query, bool) {
+	patient, err := s.Reference("patient", "Patient")
+	if err != nil {
+		sendInvalidSearch(c, err)
+		return nil, false
+	}
+	encounter, err := s.Reference("encounter", "Encounter")
+	if err != nil {
+		sendInvalidSearch(c, err)
+		return nil, false
+	}
+
+	var kodlar []interface{}
+	for page := 1; patient != ""; page++ {
+		basvurular, total, err := h.services.HastaBasvuru.List(query.Query{}.With("hasta_kodu", patient), page, fhir.MaxCount)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve the patient's encounters", err)
+			return nil, false
+		}
+		for _, b := range basvurular {
+			kodlar = append(kodlar, b.HastaBasvuruKodu)
+		}
+		if len(basvurular) == 0 || int64(len(kodlar)) >= total {
+			break
+		}
+	}
+
+	return func(q query.Query) query.Query {
+		if encounter != "" {
+			q = q.With("hasta_basvuru_kodu", encounter)
+		}
+		if patient != "" {
+			q = q.In("hasta_basvuru_kodu", kodlar...)
+		}
+		return q
+	}, true
+}
+
+// parseFHIRSearch parses the query string of a search; an invalid search is answered
+func parseFHIRSearch(c *gin.Context, resourceType string) (fhir.Search, bool) {
+	s, err := fhir.ParseSearch(c.Request.URL.Query(), resourceType)
+	if err != nil {
+		sendInvalidSearch(c, err)
+		return s, false
+	}
+	return s, true
+}
+
+// applyDates restricts q by every value of a date search parameter
+func applyDates(c *gin.Context, s fhir.Search, name string, q query.Query, column string) (query.Query, bool) {
+	dates, err := s.Dates(name)
+	if err != nil {
+		sendInvalidSearch(c, err)
+		return q, false
+	}
+	for _, d := range dates {
+		q = d.Apply(q, column)
+	}
+	return q, true
+}
+
+func sendInvalidSearch(c *gin.Context, err error) {
+	utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_QUERY, err.Error(), err)
+}
+
+// defaultQuery is the list query of a record type without parameters: its default sort
+// and includes
+func defaultQuery(schema query.Schema) query.Query {
+	q, _ := query.Parse(url.Values{}, schema)
+	return q
+}
+
+func hasMore(s fhir.Search, total int64) bool {
+	return int64(s.Page)*int64(s.Count) < total
+}
+
+func sendSearchset(c *gin.Context, s fhir.Search, total *int64, more bool, entries []fhir.Resource) {
+	fhir.Write(c, http.StatusOK, fhir.Searchset(fhirBase(c), c.Request.URL, s, total, more, entries))
+}
+
+// fhirBase returns the absolute URL of the facade, for the links and fullUrls of responses
+func fhirBase(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + fhir.BasePath
+}
@@ -26,7 +26,6 @@ var vem20Endpoints = []string{
 	"/api/v1/personel",
 	"/api/v1/personel/test-kodu",
 	"/api/v1/personel/gorev/test-gorev",
-	"/api/v1/personel/authenticate/test-uid",
 	"/api/v1/nfc-kart",
 	"/api/v1/nfc-kart/test-kodu",
 	"/api/v1/nfc-kart/uid/test-uid",
@@ -37,6 +36,7 @@ var vem20Endpoints = []string{
 	"/api/v1/hasta/search",
 	"/api/v1/hasta-basvuru",
 	"/api/v1/hasta-basvuru/test-kodu",
+	"/api/v1/hasta-basvuru/test-kodu/ozet",
 	"/api/v1/hasta-basvuru/hasta/test-hasta",
 	"/api/v1/hasta-basvuru/hekim/test-hekim",
 	"/api/v1/hasta-basvuru/filter",
@@ -46,14 +46,18 @@ var vem20Endpoints = []string{
 	"/api/v1/tablet-cihaz",
 	"/api/v1/tablet-cihaz/test-kodu",
 	"/api/v1/tablet-cihaz/yatak/test-yatak",
+	"/api/v1/tablet-cihaz/filo",
 	"/api/v1/anlik-yatan-hasta",
 	"/api/v1/anlik-yatan-hasta/test-kodu",
 	"/api/v1/anlik-yatan-hasta/yatak/test-yatak",
 	"/api/v1/anlik-yatan-hasta/hasta/test-hasta",
 	"/api/v1/anlik-yatan-hasta/birim/test-birim",
+	"/api/v1/anlik-yatan-hasta/birim/test-birim/news2",
+	"/api/v1/anlik-yatan-hasta/birim/test-birim/mar",
 	"/api/v1/vital-bulgu",
 	"/api/v1/vital-bulgu/test-kodu",
 	"/api/v1/vital-bulgu/basvuru/test-basvuru",
+	"/api/v1/vital-bulgu/basvuru/test-basvuru/news2",
 	"/api/v1/vital-bulgu/date-range",
 	"/api/v1/klinik-seyir",
 	"/api/v1/klinik-seyir/test-kodu",
@@ -62,13 +66,19 @@ var vem20Endpoints = []string{
 	"/api/v1/tibbi-order",
 	"/api/v1/tibbi-order/test-kodu",
 	"/api/v1/tibbi-order/basvuru/test-basvuru",
+	"/api/v1/tibbi-order/basvuru/test-basvuru/mar",
 	"/api/v1/tibbi-order/test-kodu/detay",
 	"/api/v1/tetkik-sonuc",
 	"/api/v1/tetkik-sonuc/test-kodu",
 	"/api/v1/tetkik-sonuc/basvuru/test-basvuru",
+	"/api/v1/tetkik-sonuc/kritik",
+	"/api/v1/stream/yatak/test-yatak",
+	"/api/v1/stream/birim/test-birim",
 	"/api/v1/recete",
 	"/api/v1/recete/test-kodu",
 	"/api/v1/recete/basvuru/test-basvuru",
+	"/api/v1/recete/basvuru/test-basvuru/uyumsuzluk",
+	"/api/v1/recete/test-kodu/uyumsuzluk",
 	"/api/v1/recete/hekim/test-hekim",
 	"/api/v1/recete/test-kodu/ilaclar",
 	"/api/v1/basvuru-tani",
@@ -91,6 +101,8 @@ var vem20Endpoints = []string{
 	"/api/v1/basvuru-yemek/test-kodu",
 	"/api/v1/basvuru-yemek/basvuru/test-basvuru",
 	"/api/v1/basvuru-yemek/turu/test-turu",
+	"/api/v1/erisim-kaydi",
+	"/api/v1/erisim-kaydi/hasta/test-hasta",
 }
 
 // MethodNotAllowedMiddleware is a middleware that rejects write operations
@@ -2,10 +2,10 @@ package handler
 
 import (
 	"medscreen/internal/constants"
+	"medscreen/internal/models"
 	"medscreen/internal/service"
 	"medscreen/internal/utils"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -46,23 +46,18 @@ func (h *HastaBasvuruHandler) GetByHasta(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.HastaBasvuruSorgusu)
+	if !ok {
+		return
 	}
 
-	basvurular, total, err := h.service.GetByHastaKodu(hastaKodu, page, limit)
+	basvurular, total, err := h.service.List(q.With("hasta_kodu", hastaKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve visits", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, basvurular)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_HASTA_BASVURULAR_RETRIEVED, "Visits retrieved successfully", basvurular, meta)
 }
 
@@ -74,43 +69,34 @@ func (h *HastaBasvuruHandler) GetByHekim(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.HastaBasvuruSorgusu)
+	if !ok {
+		return
 	}
 
-	basvurular, total, err := h.service.GetByHekimKodu(hekimKodu, page, limit)
+	basvurular, total, err := h.service.List(q.With("hekim_kodu", hekimKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve visits", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, basvurular)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_HASTA_BASVURULAR_RETRIEVED, "Visits retrieved successfully", basvurular, meta)
 }
 
 // GetByFilters handles GET /api/v1/hasta-basvuru/filter
 func (h *HastaBasvuruHandler) GetByFilters(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.HastaBasvuruSorgusu)
+	if !ok {
+		return
 	}
 
-	var durum *string
-	if d := c.Query("durum"); d != "" {
-		durum = &d
-	} else if d := c.Query("basvuru_durumu"); d != "" {
-		durum = &d
+	durum := c.Query("durum")
+	if durum == "" {
+		durum = c.Query("basvuru_durumu")
+	}
+	if durum != "" {
+		q = q.With("basvuru_durumu", durum)
 	}
 
 	var startDate, endDate *time.Time
@@ -131,18 +117,25 @@ func (h *HastaBasvuruHandler) GetByFilters(c *gin.Context) {
 		}
 		endDate = &t
 	}
+	if startDate != nil && endDate != nil {
+		if startDate.After(*endDate) {
+			utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_DATE_RANGE, "Start date must be before end date", nil)
+			return
+		}
+		q = q.Between("hasta_kabul_zamani", *startDate, *endDate)
+	}
 
-	if durum == nil && (startDate == nil || endDate == nil) {
-		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "At least one filter (durum/basvuru_durumu or start_date+end_date) is required", nil)
+	if !q.Filtered() {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "At least one filter (durum/basvuru_durumu, start_date+end_date or filter) is required", nil)
 		return
 	}
 
-	basvurular, total, err := h.service.GetByFilters(durum, startDate, endDate, page, limit)
+	basvurular, total, err := h.service.List(q, page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve visits", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, basvurular)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_HASTA_BASVURULAR_RETRIEVED, "Visits retrieved successfully", basvurular, meta)
 }
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"errors"
+	"medscreen/internal/constants"
+	"medscreen/internal/service"
+	"medscreen/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HastaBasvuruOzetHandler handles HTTP requests for the bedside visit summary (read-only)
+type HastaBasvuruOzetHandler struct {
+	service service.HastaBasvuruOzetService
+}
+
+// NewHastaBasvuruOzetHandler creates a new HastaBasvuruOzetHandler instance
+func NewHastaBasvuruOzetHandler(service service.HastaBasvuruOzetService) *HastaBasvuruOzetHandler {
+	return &HastaBasvuruOzetHandler{service: service}
+}
+
+// GetOzet handles GET /api/v1/hasta-basvuru/:kodu/ozet
+func (h *HastaBasvuruOzetHandler) GetOzet(c *gin.Context) {
+	kodu := c.Param("kodu")
+	if kodu == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_HASTA_BASVURU_KODU, "Visit code is required", nil)
+		return
+	}
+
+	ozet, err := h.service.GetOzet(kodu)
+	if err != nil {
+		if errors.Is(err, service.ErrBasvuruBulunamadi) {
+			utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_HASTA_BASVURU_NOT_FOUND, "Visit not found", err)
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve visit summary", err)
+		return
+	}
+
+	if len(ozet.Hatalar) > 0 {
+		utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_HASTA_BASVURU_OZET_PARTIAL, "Visit summary retrieved with missing sections", ozet)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_HASTA_BASVURU_OZET_RETRIEVED, "Visit summary retrieved successfully", ozet)
+}
@@ -2,10 +2,11 @@ package handler
 
 import (
 	"medscreen/internal/constants"
+	"medscreen/internal/masking"
+	"medscreen/internal/models"
 	"medscreen/internal/service"
 	"medscreen/internal/utils"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -38,12 +39,18 @@ func (h *HastaHandler) GetByKodu(c *gin.Context) {
 }
 
 // GetByTCKimlik handles GET /api/v1/hasta/tc/:tc_kimlik
+// Callers for whom the TC Kimlik number is masked are refused, or the lookup would confirm
+// which numbers belong to a patient
 func (h *HastaHandler) GetByTCKimlik(c *gin.Context) {
 	tcKimlik := c.Param("tc_kimlik")
 	if tcKimlik == "" {
 		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "TC Kimlik number is required", nil)
 		return
 	}
+	if policy, ok := masking.PolicyOf(c); ok && policy.Masked(c, models.HastaSorgusu.Entity, "tc_kimlik_numarasi") {
+		utils.SendErrorResponse(c, http.StatusForbidden, constants.ERROR_FORBIDDEN, "Cannot look up by tc_kimlik_numarasi, which is masked for you", nil)
+		return
+	}
 
 	hasta, err := h.service.GetByTCKimlik(tcKimlik)
 	if err != nil {
@@ -56,23 +63,18 @@ func (h *HastaHandler) GetByTCKimlik(c *gin.Context) {
 
 // GetAll handles GET /api/v1/hasta
 func (h *HastaHandler) GetAll(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.HastaSorgusu)
+	if !ok {
+		return
 	}
 
-	hastalar, total, err := h.service.GetAll(page, limit)
+	hastalar, total, err := h.service.List(q, page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve patients", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, hastalar)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_HASTALAR_RETRIEVED, "Patients retrieved successfully", hastalar, meta)
 }
 
@@ -85,22 +87,26 @@ func (h *HastaHandler) Search(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
+	q, page, limit, ok := parseListQuery(c, models.HastaSorgusu)
+	if !ok {
+		return
+	}
+	if ad != "" {
+		q = q.Like("ad", ad)
 	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	if soyadi != "" {
+		q = q.Like("soyadi", soyadi)
+	}
+	if !maskesizSorgu(c, models.HastaSorgusu, q) {
+		return
 	}
 
-	hastalar, total, err := h.service.SearchByAdSoyadi(ad, soyadi, page, limit)
+	hastalar, total, err := h.service.List(q, page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to search patients", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, hastalar)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_HASTALAR_RETRIEVED, "Patients search results retrieved successfully", hastalar, meta)
 }
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"medscreen/internal/masking"
+	"medscreen/internal/middleware"
+	"medscreen/internal/models"
+	"medscreen/internal/query"
+	"medscreen/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"pgregory.net/rapid"
+)
+
+// Feature: pii-masking, Property 2: Masked Fields Cannot Be Queried
+// *For any* role and purpose, a patient list SHALL refuse to filter, sort or search by a
+// field the masking policy masks for that caller, so its value cannot be recovered by
+// narrowing the query, and SHALL accept fields shown in full. A lookup by TC Kimlik number
+// SHALL likewise be refused where the number is masked.
+
+type sorguHastaService struct {
+	service.HastaService
+	listed bool
+}
+
+func (s *sorguHastaService) List(query.Query, int, int) ([]models.Hasta, int64, error) {
+	s.listed = true
+	return nil, 0, nil
+}
+
+func (s *sorguHastaService) GetByTCKimlik(tcKimlik string) (*models.Hasta, error) {
+	s.listed = true
+	return &models.Hasta{TCKimlikNumarasi: &tcKimlik}, nil
+}
+
+// hastaSorgula lists patients as a caller with role, on a bedside tablet if bedside is set
+func hastaSorgula(role string, bedside bool, path string) (int, bool) {
+	gin.SetMode(gin.TestMode)
+	svc := &sorguHastaService{}
+	h := NewHastaHandler(svc)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(middleware.ContextKeyUserRole, role)
+		if bedside {
+			c.Set(masking.ContextKeyPurpose, masking.PurposeBedside)
+		}
+		c.Next()
+	}, masking.Default().Middleware())
+	router.GET("/api/v1/hasta", h.GetAll)
+	router.GET("/api/v1/hasta/search", h.Search)
+	router.GET("/api/v1/hasta/tc/:tc_kimlik", h.GetByTCKimlik)
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, path, nil))
+	return resp.Code, svc.listed
+}
+
+// TestProperty_MaskedFieldsCannotBeQueried tests Property 2
+func TestProperty_MaskedFieldsCannotBeQueried(t *testing.T) {
+	policy := masking.Default()
+	rapid.Check(t, func(rt *rapid.T) {
+		role := rapid.SampledFrom([]string{"HEKIM", "HEMSIRE", "DIGER", "API"}).Draw(rt, "role")
+		bedside := rapid.Bool().Draw(rt, "bedside")
+		field := rapid.SampledFrom([]string{"ad", "soyadi", "dogum_tarihi", "hasta_kodu", "kayit_zamani"}).Draw(rt, "field")
+		path := rapid.SampledFrom([]string{
+			"/api/v1/hasta?filter=" + field + ":null:false",
+			"/api/v1/hasta?sort=-" + field,
+		}).Draw(rt, "path")
+
+		purpose := masking.PurposeDefault
+		if bedside {
+			purpose = masking.PurposeBedside
+		}
+		rule, ok := policy.RulesFor(purpose, role)["hasta."+field]
+		masked := ok && rule != masking.RuleFull
+
+		code, listed := hastaSorgula(role, bedside, path)
+		if masked && (code != http.StatusForbidden || listed) {
+			rt.Fatalf("%s (%s, %s): expected 403 for masked %s, got %d (listed: %t)", path, role, purpose, field, code, listed)
+		}
+		if !masked && (code != http.StatusOK || !listed) {
+			rt.Fatalf("%s (%s, %s): expected 200 for %s, got %d", path, role, purpose, field, code)
+		}
+	})
+}
+
+// TestHasta_SearchByMaskedName checks that the name search is refused where names are masked
+func TestHasta_SearchByMaskedName(t *testing.T) {
+	if code, listed := hastaSorgula("HEMSIRE", true, "/api/v1/hasta/search?soyadi=Yil"); code != http.StatusForbidden || listed {
+		t.Errorf("Expected a bedside name search to be refused, got %d (listed: %t)", code, listed)
+	}
+	if code, listed := hastaSorgula("HEMSIRE", false, "/api/v1/hasta/search?soyadi=Yil"); code != http.StatusOK || !listed {
+		t.Errorf("Expected a name search to be allowed where names are shown, got %d", code)
+	}
+	if code, _ := hastaSorgula("DIGER", false, "/api/v1/hasta?filter=dogum_tarihi:gte:1985-04-12T00:00:00Z"); code != http.StatusForbidden {
+		t.Errorf("Expected a birth date filter to be refused where only the year is shown, got %d", code)
+	}
+}
+
+// TestHasta_TCLookupWhereMasked checks that only callers shown the full TC Kimlik number
+// can look a patient up by it
+func TestHasta_TCLookupWhereMasked(t *testing.T) {
+	policy := masking.Default()
+	for _, role := range []string{"HEKIM", "HEMSIRE", "DIGER", "API"} {
+		for _, bedside := range []bool{false, true} {
+			purpose := masking.PurposeDefault
+			if bedside {
+				purpose = masking.PurposeBedside
+			}
+			rule, ok := policy.RulesFor(purpose, role)["hasta.tc_kimlik_numarasi"]
+			masked := ok && rule != masking.RuleFull
+
+			code, found := hastaSorgula(role, bedside, "/api/v1/hasta/tc/12345678901")
+			if masked && (code != http.StatusForbidden || found) {
+				t.Errorf("%s (%s): expected 403 for a masked TC Kimlik number, got %d (looked up: %t)", role, purpose, code, found)
+			}
+			if !masked && (code != http.StatusOK || !found) {
+				t.Errorf("%s (%s): expected 200 for a TC Kimlik number shown in full, got %d", role, purpose, code)
+			}
+		}
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"medscreen/internal/constants"
+	"medscreen/internal/models"
 	"medscreen/internal/service"
 	"medscreen/internal/utils"
 	"net/http"
@@ -45,71 +46,59 @@ func (h *HastaUyariHandler) GetByBasvuru(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.HastaUyariSorgusu)
+	if !ok {
+		return
 	}
 
-	uyarilar, total, err := h.service.GetByBasvuruKodu(basvuruKodu, page, limit)
+	uyarilar, total, err := h.service.List(q.With("hasta_basvuru_kodu", basvuruKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve warnings", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, uyarilar)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_HASTA_UYARILAR_RETRIEVED, "Warnings retrieved successfully", uyarilar, meta)
 }
 
 // GetByFilters handles GET /api/v1/hasta-uyari/filter
 func (h *HastaUyariHandler) GetByFilters(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.HastaUyariSorgusu)
+	if !ok {
+		return
 	}
 
-	var uyariTuru *string
 	if turu := c.Query("uyari_turu"); turu != "" {
-		uyariTuru = &turu
+		q = q.With("uyari_turu", turu)
 	}
 
-	var aktiflik *int
 	if aktifStr := c.Query("aktiflik"); aktifStr != "" {
 		aktifVal, err := strconv.Atoi(aktifStr)
 		if err != nil {
 			utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "Invalid aktiflik value", err)
 			return
 		}
-		aktiflik = &aktifVal
+		q = q.With("aktiflik_bilgisi", aktifVal)
 	} else if aktifStr := c.Query("aktiflik_bilgisi"); aktifStr != "" {
 		aktifVal, err := strconv.Atoi(aktifStr)
 		if err != nil {
 			utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "Invalid aktiflik_bilgisi value", err)
 			return
 		}
-		aktiflik = &aktifVal
+		q = q.With("aktiflik_bilgisi", aktifVal)
 	}
 
-	if uyariTuru == nil && aktiflik == nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "At least one filter (uyari_turu or aktiflik/aktiflik_bilgisi) is required", nil)
+	if !q.Filtered() {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "At least one filter (uyari_turu, aktiflik/aktiflik_bilgisi or filter) is required", nil)
 		return
 	}
 
-	uyarilar, total, err := h.service.GetByFilters(uyariTuru, aktiflik, page, limit)
+	uyarilar, total, err := h.service.List(q, page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve warnings", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, uyarilar)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_HASTA_UYARILAR_RETRIEVED, "Warnings retrieved successfully", uyarilar, meta)
 }
@@ -2,10 +2,10 @@ package handler
 
 import (
 	"medscreen/internal/constants"
+	"medscreen/internal/models"
 	"medscreen/internal/service"
 	"medscreen/internal/utils"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -46,23 +46,18 @@ func (h *HastaVitalFizikiBulguHandler) GetByBasvuru(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.HastaVitalFizikiBulguSorgusu)
+	if !ok {
+		return
 	}
 
-	bulgular, total, err := h.service.GetByBasvuruKodu(basvuruKodu, page, limit)
+	bulgular, total, err := h.service.List(q.With("hasta_basvuru_kodu", basvuruKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve vital signs", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, bulgular)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_VITAL_BULGULAR_RETRIEVED, "Vital signs retrieved successfully", bulgular, meta)
 }
 
@@ -88,22 +83,22 @@ func (h *HastaVitalFizikiBulguHandler) GetByDateRange(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
+	if startDate.After(endDate) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_DATE_RANGE, "Start date must be before end date", nil)
+		return
 	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+
+	q, page, limit, ok := parseListQuery(c, models.HastaVitalFizikiBulguSorgusu)
+	if !ok {
+		return
 	}
 
-	bulgular, total, err := h.service.GetByDateRange(startDate, endDate, page, limit)
+	bulgular, total, err := h.service.List(q.Between("islem_zamani", startDate, endDate), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve vital signs", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, bulgular)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_VITAL_BULGULAR_RETRIEVED, "Vital signs retrieved successfully", bulgular, meta)
 }
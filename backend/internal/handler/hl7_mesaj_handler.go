@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"errors"
+	"medscreen/internal/constants"
+	"medscreen/internal/models"
+	"medscreen/internal/service"
+	"medscreen/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HL7MesajHandler handles HTTP requests for the outbox and dead letters of the HL7 v2 feed
+type HL7MesajHandler struct {
+	service service.HL7MesajService
+}
+
+// NewHL7MesajHandler creates a new HL7MesajHandler instance
+func NewHL7MesajHandler(service service.HL7MesajService) *HL7MesajHandler {
+	return &HL7MesajHandler{service: service}
+}
+
+// GetAll handles GET /api/v1/hl7/mesajlar
+// Dead letters are listed by default; ?durum= selects another state and ?hedef= an endpoint
+func (h *HL7MesajHandler) GetAll(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	durum := models.HL7MesajDurumu(c.DefaultQuery("durum", string(models.HL7MesajOlu)))
+	mesajlar, total, err := h.service.GetAll(c.Query("hedef"), durum, page, limit)
+	if err != nil {
+		if errors.Is(err, service.ErrHL7MesajDurumu) {
+			utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_HL7_MESAJ_DURUMU, err.Error(), nil)
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve HL7 messages", err)
+		return
+	}
+
+	meta := utils.CalculateMeta(page, limit, total)
+	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_HL7_MESAJLAR_RETRIEVED, "HL7 messages retrieved successfully", mesajlar, meta)
+}
+
+// Requeue handles POST /api/v1/hl7/mesajlar/:hl7_mesaj_id/requeue
+func (h *HL7MesajHandler) Requeue(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("hl7_mesaj_id"), 10, 0)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "hl7_mesaj_id must be a number", nil)
+		return
+	}
+
+	if err := h.service.YenidenGonder(uint(id)); err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_HL7_MESAJ_NOT_FOUND, "Dead letter not found", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_HL7_MESAJ_REQUEUED, "Message queued for delivery again", nil)
+}
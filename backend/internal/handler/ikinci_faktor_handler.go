@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"errors"
+	"medscreen/internal/constants"
+	"medscreen/internal/middleware"
+	"medscreen/internal/service"
+	"medscreen/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IkinciFaktorHandler handles HTTP requests for the PIN/TOTP step-up and its administration
+type IkinciFaktorHandler struct {
+	service service.IkinciFaktorService
+}
+
+// NewIkinciFaktorHandler creates a new IkinciFaktorHandler instance
+func NewIkinciFaktorHandler(service service.IkinciFaktorService) *IkinciFaktorHandler {
+	return &IkinciFaktorHandler{service: service}
+}
+
+type stepUpRequest struct {
+	PIN  string `json:"pin"`
+	TOTP string `json:"totp"`
+}
+
+type pinRequest struct {
+	PIN string `json:"pin" binding:"required"`
+}
+
+// StepUp handles POST /api/v1/auth/step-up
+func (h *IkinciFaktorHandler) StepUp(c *gin.Context) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, constants.ERROR_UNAUTHORIZED, "Authentication required", nil)
+		return
+	}
+
+	var req stepUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.PIN == "" && req.TOTP == "") {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "pin or totp is required", err)
+		return
+	}
+
+	sonuc, err := h.service.StepUp(claims, req.PIN, req.TOTP)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrIkinciFaktorHatali):
+			utils.SendErrorResponse(c, http.StatusUnauthorized, constants.ERROR_SECOND_FACTOR_INVALID, "Invalid PIN or TOTP code", nil)
+		case errors.Is(err, service.ErrIkinciFaktorKilitli):
+			utils.SendErrorResponse(c, http.StatusForbidden, constants.ERROR_SECOND_FACTOR_LOCKED, "Too many failed attempts; the second factor is locked for a while", nil)
+		case errors.Is(err, service.ErrIkinciFaktorYok):
+			utils.SendErrorResponse(c, http.StatusForbidden, constants.ERROR_SECOND_FACTOR_NOT_ENROLLED, "No PIN or TOTP is enrolled; ask an administrator", nil)
+		case errors.Is(err, service.ErrTokenGeneration):
+			utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to generate authentication token", err)
+		default:
+			utils.SendErrorResponse(c, http.StatusUnauthorized, constants.ERROR_UNAUTHORIZED, "Step-up failed", err)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_STEPPED_UP, "Second factor verified", sonuc)
+}
+
+// GetDurum handles GET /api/v1/auth/ikinci-faktor/:personel_kodu
+func (h *IkinciFaktorHandler) GetDurum(c *gin.Context) {
+	durum, err := h.service.GetDurum(c.Param("personel_kodu"))
+	if err != nil {
+		h.sendError(c, err, "Failed to retrieve second factor")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_IKINCI_FAKTOR_RETRIEVED, "Second factor retrieved successfully", durum)
+}
+
+// PinBelirle handles POST /api/v1/auth/ikinci-faktor/:personel_kodu/pin
+func (h *IkinciFaktorHandler) PinBelirle(c *gin.Context) {
+	var req pinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "pin is required", err)
+		return
+	}
+
+	if err := h.service.PinBelirle(c.Param("personel_kodu"), req.PIN, c.GetString(middleware.ContextKeyPersonelKodu)); err != nil {
+		h.sendError(c, err, "Failed to set PIN")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_PIN_ENROLLED, "PIN set successfully", nil)
+}
+
+// TOTPOlustur handles POST /api/v1/auth/ikinci-faktor/:personel_kodu/totp
+func (h *IkinciFaktorHandler) TOTPOlustur(c *gin.Context) {
+	kayit, err := h.service.TOTPOlustur(c.Param("personel_kodu"), c.GetString(middleware.ContextKeyPersonelKodu))
+	if err != nil {
+		h.sendError(c, err, "Failed to enroll TOTP")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_TOTP_ENROLLED, "TOTP secret generated; it will not be shown again", kayit)
+}
+
+// Sifirla handles POST /api/v1/auth/ikinci-faktor/:personel_kodu/reset
+func (h *IkinciFaktorHandler) Sifirla(c *gin.Context) {
+	if err := h.service.Sifirla(c.Param("personel_kodu")); err != nil {
+		h.sendError(c, err, "Failed to reset second factor")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_IKINCI_FAKTOR_RESET, "Second factor reset successfully", nil)
+}
+
+func (h *IkinciFaktorHandler) sendError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrGecersizPIN):
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_PIN, err.Error(), nil)
+	case errors.Is(err, service.ErrIkinciFaktorPersoneli):
+		utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_PERSONEL_NOT_FOUND, "Personnel not found", err)
+	case errors.Is(err, service.ErrIkinciFaktorYok):
+		utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_SECOND_FACTOR_NOT_ENROLLED, "No second factor is enrolled", nil)
+	default:
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, message, err)
+	}
+}
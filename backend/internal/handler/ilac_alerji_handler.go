@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"medscreen/internal/constants"
+	"medscreen/internal/service"
+	"medscreen/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IlacAlerjiHandler handles HTTP requests for drug-allergy conflict checks (read-only)
+type IlacAlerjiHandler struct {
+	service service.IlacAlerjiService
+}
+
+// NewIlacAlerjiHandler creates a new IlacAlerjiHandler instance
+func NewIlacAlerjiHandler(service service.IlacAlerjiService) *IlacAlerjiHandler {
+	return &IlacAlerjiHandler{service: service}
+}
+
+// GetByRecete handles GET /api/v1/recete/:kodu/uyumsuzluk
+func (h *IlacAlerjiHandler) GetByRecete(c *gin.Context) {
+	kodu := c.Param("kodu")
+	if kodu == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_RECETE_KODU, "Prescription code is required", nil)
+		return
+	}
+
+	rapor, err := h.service.GetByReceteKodu(kodu)
+	if err != nil {
+		if errors.Is(err, service.ErrReceteBulunamadi) {
+			utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_RECETE_NOT_FOUND, "Prescription not found", err)
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to check drug-allergy conflicts", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_ILAC_ALERJI_KONTROLU, "Drug-allergy conflicts checked successfully", rapor)
+}
+
+// GetByBasvuru handles GET /api/v1/recete/basvuru/:basvuru_kodu/uyumsuzluk
+// It checks every active prescription of the visit.
+func (h *IlacAlerjiHandler) GetByBasvuru(c *gin.Context) {
+	basvuruKodu := c.Param("basvuru_kodu")
+	if basvuruKodu == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_HASTA_BASVURU_KODU, "Visit code is required", nil)
+		return
+	}
+
+	raporlar, err := h.service.GetByBasvuruKodu(basvuruKodu)
+	if err != nil {
+		if errors.Is(err, service.ErrBasvuruBulunamadi) {
+			utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_HASTA_BASVURU_NOT_FOUND, "Visit not found", err)
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to check drug-allergy conflicts", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_ILAC_ALERJI_KONTROLU, "Drug-allergy conflicts checked successfully", raporlar)
+}
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"medscreen/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSHandler publishes the public keys MedScreen tokens can be verified with
+type JWKSHandler struct {
+	keys func() *utils.KeySet
+}
+
+// NewJWKSHandler creates a new JWKSHandler instance
+func NewJWKSHandler(keys func() *utils.KeySet) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// GetJWKS handles GET /.well-known/jwks.json
+// The document is served bare (RFC 7517), not in the API response envelope, so standard
+// JWT libraries of other hospital services can consume it directly.
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys().JWKS())
+}
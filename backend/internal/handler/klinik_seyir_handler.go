@@ -2,6 +2,7 @@ package handler
 
 import (
 	"medscreen/internal/constants"
+	"medscreen/internal/models"
 	"medscreen/internal/service"
 	"medscreen/internal/utils"
 	"net/http"
@@ -46,51 +47,39 @@ func (h *KlinikSeyirHandler) GetByBasvuru(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.KlinikSeyirSorgusu)
+	if !ok {
+		return
 	}
 
-	seyirler, total, err := h.service.GetByBasvuruKodu(basvuruKodu, page, limit)
+	seyirler, total, err := h.service.List(q.With("hasta_basvuru_kodu", basvuruKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve clinical notes", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, seyirler)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_KLINIK_SEYIRLER_RETRIEVED, "Clinical notes retrieved successfully", seyirler, meta)
 }
 
 // GetByFilters handles GET /api/v1/klinik-seyir/filter
 func (h *KlinikSeyirHandler) GetByFilters(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.KlinikSeyirSorgusu)
+	if !ok {
+		return
 	}
 
-	var seyirTipi *string
 	if tipi := c.Query("seyir_tipi"); tipi != "" {
-		seyirTipi = &tipi
+		q = q.With("seyir_tipi", tipi)
 	}
 
-	var sepsisDurumu *int
 	if sepsis := c.Query("sepsis_durumu"); sepsis != "" {
 		parsed, err := strconv.Atoi(sepsis)
 		if err != nil {
 			utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "Invalid sepsis_durumu (use 0 or 1)", err)
 			return
 		}
-		sepsisDurumu = &parsed
+		q = q.With("sepsis_durumu", parsed)
 	}
 
 	var startDate, endDate *time.Time
@@ -111,18 +100,25 @@ func (h *KlinikSeyirHandler) GetByFilters(c *gin.Context) {
 		}
 		endDate = &t
 	}
+	if startDate != nil && endDate != nil {
+		if startDate.After(*endDate) {
+			utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_DATE_RANGE, "Start date must be before end date", nil)
+			return
+		}
+		q = q.Between("seyir_zamani", *startDate, *endDate)
+	}
 
-	if seyirTipi == nil && sepsisDurumu == nil && (startDate == nil || endDate == nil) {
-		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "At least one filter (seyir_tipi, sepsis_durumu, or start_date+end_date) is required", nil)
+	if !q.Filtered() {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "At least one filter (seyir_tipi, sepsis_durumu, start_date+end_date or filter) is required", nil)
 		return
 	}
 
-	seyirler, total, err := h.service.GetByFilters(seyirTipi, sepsisDurumu, startDate, endDate, page, limit)
+	seyirler, total, err := h.service.List(q, page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve clinical notes", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, seyirler)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_KLINIK_SEYIRLER_RETRIEVED, "Clinical notes retrieved successfully", seyirler, meta)
 }
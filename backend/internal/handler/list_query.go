@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"medscreen/internal/constants"
+	"medscreen/internal/masking"
+	"medscreen/internal/query"
+	"medscreen/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseListQuery reads the page, limit, filter, sort, fields, include and cursor parameters
+// of a list endpoint. It sends 400 and returns false if the list does not accept them, and
+// 403 if they filter or sort by a field masked for the caller.
+func parseListQuery(c *gin.Context, schema query.Schema) (query.Query, int, int, bool) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	q, err := query.Parse(c.Request.URL.Query(), schema)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_QUERY, "Invalid filter, sort, fields or include parameter", err)
+		return query.Query{}, 0, 0, false
+	}
+	if !maskesizSorgu(c, schema, q) {
+		return query.Query{}, 0, 0, false
+	}
+	query.Set(c, q)
+	return q, page, limit, true
+}
+
+// maskesizSorgu sends 403 and returns false if q filters or sorts by a field of the listed
+// entity that is masked for the caller. Handlers that add conditions of their own after
+// parseListQuery must check the query again.
+func maskesizSorgu(c *gin.Context, schema query.Schema, q query.Query) bool {
+	policy, ok := masking.PolicyOf(c)
+	if !ok || schema.Entity == "" {
+		return true
+	}
+	for _, column := range q.Columns() {
+		if policy.Masked(c, schema.Entity, column) {
+			utils.SendErrorResponse(c, http.StatusForbidden, constants.ERROR_FORBIDDEN, "Cannot filter or sort by "+column+", which is masked for you", nil)
+			return false
+		}
+	}
+	return true
+}
+
+// listMeta returns the pagination metadata of a page of records listed with q
+func listMeta[T any](q query.Query, page, limit int, total int64, records []T) *utils.Meta {
+	if !q.Cursor() {
+		return utils.CalculateMeta(page, limit, total)
+	}
+	var totalItems *int64
+	if q.Counted() {
+		totalItems = &total
+	}
+	return utils.CalculateCursorMeta(limit, totalItems, query.Next(q, records, limit))
+}
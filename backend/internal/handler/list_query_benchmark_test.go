@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"fmt"
+	"medscreen/internal/models"
+	"medscreen/internal/query"
+	"medscreen/internal/repository"
+	"medscreen/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// Benchmarks of include= and sideload= on a page of 100 vital sign records of one
+// admission, taken by four nurses. Run with
+//
+//	go test ./internal/handler -run '^$' -bench ListInclude -benchmem
+//
+// The records are read from a seeded PostgreSQL database (see benchDB). bytes/resp is the
+// size of the response; queries/op is the number of SQL statements gorm ran for the page.
+
+func seedVitals() []models.HastaVitalFizikiBulgu {
+	tc := "10000000146"
+	hekimKodu := "P-HEKIM"
+	hasta := &models.Hasta{
+		HastaKodu: "H1", TCKimlikNumarasi: &tc, Ad: "Ayşe", Soyadi: "Yılmaz",
+		DogumTarihi: time.Date(1985, 4, 12, 0, 0, 0, 0, time.UTC), EkleyenKullaniciKodu: "SISTEM",
+	}
+	basvuru := &models.HastaBasvuru{
+		HastaBasvuruKodu: "B1", HastaKodu: "H1", Hasta: hasta, BasvuruProtokolNumarasi: "2026-000123",
+		HastaKabulZamani: time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC), HekimKodu: &hekimKodu,
+		EkleyenKullaniciKodu: "SISTEM",
+	}
+	var hemsireler []*models.Personel
+	for i := 0; i < 4; i++ {
+		hemsireler = append(hemsireler, &models.Personel{
+			PersonelKodu: fmt.Sprintf("P-HEMSIRE-%d", i), Ad: "Zeynep", Soyadi: "Kaya",
+			PersonelGorevKodu: "HEMSIRE", AktiflikBilgisi: 1, EkleyenKullaniciKodu: "SISTEM",
+		})
+	}
+
+	ates, nabiz, saturasyon := "37.2", "84", "97"
+	vitals := make([]models.HastaVitalFizikiBulgu, 100)
+	for i := range vitals {
+		hemsire := hemsireler[i%len(hemsireler)]
+		vitals[i] = models.HastaVitalFizikiBulgu{
+			HastaVitalFizikiBulguKodu: fmt.Sprintf("V%03d", i),
+			HastaBasvuruKodu:          "B1",
+			HastaBasvuru:              basvuru,
+			IslemZamani:               basvuru.HastaKabulZamani.Add(time.Duration(i) * time.Hour),
+			Ates:                      &ates,
+			Nabiz:                     &nabiz,
+			Saturasyon:                &saturasyon,
+			HemsireKodu:               &hemsire.PersonelKodu,
+			Hemsire:                   hemsire,
+			EkleyenKullaniciKodu:      "SISTEM",
+		}
+	}
+	return vitals
+}
+
+// benchDB opens the benchmark database (BENCH_DATABASE_DSN, or the test database of the
+// repository tests) and seeds one admission with 100 vital signs into a schema of its own.
+// Everything happens in a transaction that is rolled back when the benchmark ends, so the
+// database is left as it was. The returned counter is incremented by every SQL statement
+// gorm runs. Without a database the benchmark is skipped.
+func benchDB(b *testing.B) (*gorm.DB, *int) {
+	b.Helper()
+	dsn := os.Getenv("BENCH_DATABASE_DSN")
+	if dsn == "" {
+		dsn = "host=localhost user=test password=test dbname=medscreen_test port=5432 sslmode=disable"
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		b.Skipf("Skipping benchmark: no database connection available: %v", err)
+	}
+
+	// Counts, selects and preloads run through the query callbacks, raw reads through the row callbacks
+	statements := new(int)
+	count := func(tx *gorm.DB) {
+		if tx.Statement.SQL.Len() > 0 {
+			*statements++
+		}
+	}
+	if err := db.Callback().Query().After("gorm:query").Register("bench:count_query", count); err != nil {
+		b.Fatal(err)
+	}
+	if err := db.Callback().Row().After("gorm:row").Register("bench:count_row", count); err != nil {
+		b.Fatal(err)
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		b.Fatal(tx.Error)
+	}
+	b.Cleanup(func() { tx.Rollback() })
+
+	if err := tx.Exec("CREATE SCHEMA medscreen_bench").Error; err != nil {
+		b.Fatal(err)
+	}
+	if err := tx.Exec("SET LOCAL search_path TO medscreen_bench").Error; err != nil {
+		b.Fatal(err)
+	}
+	if err := tx.AutoMigrate(&models.Personel{}, &models.Hasta{}, &models.HastaBasvuru{}, &models.HastaVitalFizikiBulgu{}); err != nil {
+		b.Fatal(err)
+	}
+
+	vitals := seedVitals()
+	basvuru := vitals[0].HastaBasvuru
+	hemsireler := make(map[string]*models.Personel)
+	for _, v := range vitals {
+		hemsireler[v.Hemsire.PersonelKodu] = v.Hemsire
+	}
+	kayitlar := []interface{}{basvuru.Hasta, basvuru, &vitals}
+	for _, hemsire := range hemsireler {
+		kayitlar = append(kayitlar, hemsire)
+	}
+	for _, kayit := range kayitlar {
+		if err := tx.Omit(clause.Associations).Create(kayit).Error; err != nil {
+			b.Fatal(err)
+		}
+	}
+	return tx, statements
+}
+
+func BenchmarkListInclude(b *testing.B) {
+	gin.SetMode(gin.TestMode)
+	db, statements := benchDB(b)
+
+	h := NewHastaVitalFizikiBulguHandler(service.NewHastaVitalFizikiBulguService(repository.NewHastaVitalFizikiBulguRepository(db)))
+	router := gin.New()
+	router.Use(query.Middleware())
+	router.GET("/vital-bulgu/basvuru/:basvuru_kodu", h.GetByBasvuru)
+
+	cases := []struct {
+		name  string
+		query string
+	}{
+		{"Default", ""},
+		{"NestedPatient", "include=hasta_basvuru.hasta,hemsire"},
+		{"Sideload", "include=hasta_basvuru.hasta,hemsire&sideload=true"},
+		{"NoRelations", "include="},
+	}
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			var size int
+			*statements = 0
+			for i := 0; i < b.N; i++ {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/vital-bulgu/basvuru/B1?limit=100&"+tc.query, nil))
+				if w.Code != http.StatusOK {
+					b.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+				}
+				size = w.Body.Len()
+			}
+			b.ReportMetric(float64(size), "bytes/resp")
+			b.ReportMetric(float64(*statements)/float64(b.N), "queries/op")
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"medscreen/internal/constants"
+	"medscreen/internal/service"
+	"medscreen/internal/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MarHandler handles HTTP requests for the medication administration record (read-only)
+type MarHandler struct {
+	service service.MarService
+	pencere time.Duration
+}
+
+// NewMarHandler creates a new MarHandler instance. Without baslangic and bitis the record
+// covers pencere before and after the current time.
+func NewMarHandler(service service.MarService, pencere time.Duration) *MarHandler {
+	return &MarHandler{service: service, pencere: pencere}
+}
+
+// GetByBasvuru handles GET /api/v1/tibbi-order/basvuru/:basvuru_kodu/mar
+// Query parameters: baslangic, bitis (optional, RFC 3339; at most 48 hours apart)
+func (h *MarHandler) GetByBasvuru(c *gin.Context) {
+	basvuruKodu := c.Param("basvuru_kodu")
+	if basvuruKodu == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_HASTA_BASVURU_KODU, "Visit code is required", nil)
+		return
+	}
+
+	baslangic, bitis, ok := h.pencereOku(c)
+	if !ok {
+		return
+	}
+
+	cizelge, err := h.service.GetByBasvuruKodu(basvuruKodu, baslangic, bitis)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve medication administration record", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_MAR_RETRIEVED, "Medication administration record retrieved successfully", cizelge)
+}
+
+// GetByBirim handles GET /api/v1/anlik-yatan-hasta/birim/:birim_kodu/mar
+// Query parameters: baslangic, bitis (optional, RFC 3339; at most 48 hours apart)
+func (h *MarHandler) GetByBirim(c *gin.Context) {
+	birimKodu := c.Param("birim_kodu")
+	if birimKodu == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "Unit code is required", nil)
+		return
+	}
+
+	baslangic, bitis, ok := h.pencereOku(c)
+	if !ok {
+		return
+	}
+
+	cizelgeler, err := h.service.GetByBirimKodu(birimKodu, baslangic, bitis)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve medication administration records", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_MAR_BIRIM_RETRIEVED, "Medication administration records retrieved successfully", cizelgeler)
+}
+
+// pencereOku reads the time window, sending an error response if it is invalid
+func (h *MarHandler) pencereOku(c *gin.Context) (time.Time, time.Time, bool) {
+	now := time.Now()
+	baslangic, bitis := now.Add(-h.pencere), now.Add(h.pencere)
+
+	if s := c.Query("baslangic"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "Invalid baslangic format (use RFC 3339)", err)
+			return time.Time{}, time.Time{}, false
+		}
+		baslangic = t
+	}
+	if s := c.Query("bitis"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "Invalid bitis format (use RFC 3339)", err)
+			return time.Time{}, time.Time{}, false
+		}
+		bitis = t
+	}
+
+	if !bitis.After(baslangic) || bitis.Sub(baslangic) > service.MarPenceresiEnFazla {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "bitis must be after baslangic and at most 48 hours later", nil)
+		return time.Time{}, time.Time{}, false
+	}
+	return baslangic, bitis, true
+}
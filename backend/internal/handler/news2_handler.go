@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"medscreen/internal/constants"
+	"medscreen/internal/service"
+	"medscreen/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// News2Handler handles HTTP requests for NEWS2 early warning scores (read-only)
+type News2Handler struct {
+	service service.News2Service
+}
+
+// NewNews2Handler creates a new News2Handler instance
+func NewNews2Handler(service service.News2Service) *News2Handler {
+	return &News2Handler{service: service}
+}
+
+// GetByBasvuru handles GET /api/v1/vital-bulgu/basvuru/:basvuru_kodu/news2
+// Query parameters: limit (number of most recent measurements, default 10, max 100)
+func (h *News2Handler) GetByBasvuru(c *gin.Context) {
+	basvuruKodu := c.Param("basvuru_kodu")
+	if basvuruKodu == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_HASTA_BASVURU_KODU, "Visit code is required", nil)
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	seyri, err := h.service.GetByBasvuruKodu(basvuruKodu, limit)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to compute NEWS2 scores", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_NEWS2_RETRIEVED, "NEWS2 scores computed successfully", seyri)
+}
+
+// GetBirimEskalasyonlari handles GET /api/v1/anlik-yatan-hasta/birim/:birim_kodu/news2
+// Query parameters: min_puan (optional total score threshold; by default every patient
+// above the low risk band is listed)
+func (h *News2Handler) GetBirimEskalasyonlari(c *gin.Context) {
+	birimKodu := c.Param("birim_kodu")
+	if birimKodu == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "Unit code is required", nil)
+		return
+	}
+
+	minPuan := 0
+	if s := c.Query("min_puan"); s != "" {
+		var err error
+		minPuan, err = strconv.Atoi(s)
+		if err != nil || minPuan < 0 {
+			utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "min_puan must be a non-negative integer", err)
+			return
+		}
+	}
+
+	eskalasyonlar, err := h.service.GetBirimEskalasyonlari(birimKodu, minPuan)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to compute NEWS2 scores", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_NEWS2_ESKALASYONLAR_RETRIEVED, "Patients requiring escalation retrieved successfully", eskalasyonlar)
+}
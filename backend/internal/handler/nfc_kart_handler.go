@@ -2,10 +2,10 @@ package handler
 
 import (
 	"medscreen/internal/constants"
+	"medscreen/internal/models"
 	"medscreen/internal/service"
 	"medscreen/internal/utils"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -37,8 +37,8 @@ func (h *NFCKartHandler) GetByKodu(c *gin.Context) {
 	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_NFC_KART_RETRIEVED, "NFC card retrieved successfully", nfcKart)
 }
 
-// GetByKartUID handles GET /api/v1/nfc-kart/authenticate/:kart_uid
-// This endpoint now returns a JWT token for successful authentication
+// GetByKartUID handles GET /api/v1/nfc-kart/uid/:kart_uid
+// Login is handled by AuthHandler.LoginWithNFC; this endpoint only looks the card up
 func (h *NFCKartHandler) GetByKartUID(c *gin.Context) {
 	kartUID := c.Param("kart_uid")
 	if kartUID == "" {
@@ -52,28 +52,7 @@ func (h *NFCKartHandler) GetByKartUID(c *gin.Context) {
 		return
 	}
 
-	// Check if card is active
-	if nfcKart.AktiflikBilgisi != 1 {
-		utils.SendErrorResponse(c, http.StatusUnauthorized, constants.ERROR_NFC_AUTHENTICATION_FAILED, "NFC card is inactive", nil)
-		return
-	}
-
-	// Generate JWT token for the authenticated user
-	// Use a simple user ID (we'll use 1 for now since this is read-only)
-	token, err := utils.GenerateJWT(1, nfcKart.Personel.PersonelGorevKodu)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to generate authentication token", err)
-		return
-	}
-
-	// Return both the NFC card info and the token
-	response := gin.H{
-		"nfc_kart": nfcKart,
-		"token":    token,
-		"personel": nfcKart.Personel,
-	}
-
-	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_NFC_AUTHENTICATION, "NFC authentication successful", response)
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_NFC_KART_RETRIEVED, "NFC card retrieved successfully", nfcKart)
 }
 
 // GetByPersonelKodu handles GET /api/v1/nfc-kart/personel/:personel_kodu
@@ -84,22 +63,17 @@ func (h *NFCKartHandler) GetByPersonelKodu(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.NFCKartSorgusu)
+	if !ok {
+		return
 	}
 
-	nfcKartlar, total, err := h.service.GetByPersonelKodu(personelKodu, page, limit)
+	nfcKartlar, total, err := h.service.List(q.With("personel_kodu", personelKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve NFC cards", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, nfcKartlar)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_NFC_KARTLAR_RETRIEVED, "NFC cards retrieved successfully", nfcKartlar, meta)
 }
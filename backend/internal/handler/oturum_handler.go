@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"medscreen/internal/constants"
+	"medscreen/internal/repository"
+	"medscreen/internal/service"
+	"medscreen/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OturumHandler handles HTTP requests for listing the active login sessions (admins only)
+type OturumHandler struct {
+	service service.OturumService
+}
+
+// NewOturumHandler creates a new OturumHandler instance
+func NewOturumHandler(service service.OturumService) *OturumHandler {
+	return &OturumHandler{service: service}
+}
+
+// GetAktif handles GET /api/v1/auth/oturumlar
+// Query parameters: birim_kodu, personel_kodu, tablet_cihaz_kodu, nfc_kart_kodu
+func (h *OturumHandler) GetAktif(c *gin.Context) {
+	h.getAktif(c, repository.OturumFiltresi{
+		BirimKodu:       c.Query("birim_kodu"),
+		PersonelKodu:    c.Query("personel_kodu"),
+		TabletCihazKodu: c.Query("tablet_cihaz_kodu"),
+		NFCKartKodu:     c.Query("nfc_kart_kodu"),
+	})
+}
+
+// GetByBirim handles GET /api/v1/auth/oturumlar/birim/:birim_kodu
+func (h *OturumHandler) GetByBirim(c *gin.Context) {
+	birimKodu := c.Param("birim_kodu")
+	if birimKodu == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "Unit code is required", nil)
+		return
+	}
+	h.getAktif(c, repository.OturumFiltresi{BirimKodu: birimKodu})
+}
+
+func (h *OturumHandler) getAktif(c *gin.Context, filtre repository.OturumFiltresi) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	oturumlar, total, err := h.service.GetAktif(filtre, page, limit)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve sessions", err)
+		return
+	}
+
+	meta := utils.CalculateMeta(page, limit, total)
+	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_OTURUMLAR_RETRIEVED, "Active sessions retrieved successfully", oturumlar, meta)
+}
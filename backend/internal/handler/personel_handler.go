@@ -2,10 +2,10 @@ package handler
 
 import (
 	"medscreen/internal/constants"
+	"medscreen/internal/models"
 	"medscreen/internal/service"
 	"medscreen/internal/utils"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -39,23 +39,18 @@ func (h *PersonelHandler) GetByKodu(c *gin.Context) {
 
 // GetAll handles GET /api/v1/personel
 func (h *PersonelHandler) GetAll(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.PersonelSorgusu)
+	if !ok {
+		return
 	}
 
-	personeller, total, err := h.service.GetAll(page, limit)
+	personeller, total, err := h.service.List(q, page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve personnel", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, personeller)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_PERSONELLER_RETRIEVED, "Personnel list retrieved successfully", personeller, meta)
 }
 
@@ -67,54 +62,17 @@ func (h *PersonelHandler) GetByGorev(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.PersonelSorgusu)
+	if !ok {
+		return
 	}
 
-	personeller, total, err := h.service.GetByGorevKodu(gorevKodu, page, limit)
+	personeller, total, err := h.service.List(q.With("personel_gorev_kodu", gorevKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve personnel by role", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, personeller)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_PERSONELLER_RETRIEVED, "Personnel by role retrieved successfully", personeller, meta)
 }
-
-// Authenticate handles GET /api/v1/personel/authenticate/:kart_uid
-// This endpoint now returns a JWT token for successful authentication
-func (h *PersonelHandler) Authenticate(c *gin.Context) {
-	kartUID := c.Param("kart_uid")
-	if kartUID == "" {
-		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "Card UID is required", nil)
-		return
-	}
-
-	personel, err := h.service.AuthenticateByNFC(kartUID)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusUnauthorized, constants.ERROR_NFC_AUTHENTICATION_FAILED, "NFC authentication failed", err)
-		return
-	}
-
-	// Generate JWT token for the authenticated user
-	// Use a simple user ID (we'll use 1 for now since this is read-only)
-	token, err := utils.GenerateJWT(1, personel.PersonelGorevKodu)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to generate authentication token", err)
-		return
-	}
-
-	// Return both the personel info and the token
-	response := gin.H{
-		"personel": personel,
-		"token":    token,
-	}
-
-	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_NFC_AUTHENTICATION, "Authentication successful", response)
-}
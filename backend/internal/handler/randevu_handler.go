@@ -2,10 +2,10 @@ package handler
 
 import (
 	"medscreen/internal/constants"
+	"medscreen/internal/models"
 	"medscreen/internal/service"
 	"medscreen/internal/utils"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -46,23 +46,18 @@ func (h *RandevuHandler) GetByHasta(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.RandevuSorgusu)
+	if !ok {
+		return
 	}
 
-	randevular, total, err := h.service.GetByHastaKodu(hastaKodu, page, limit)
+	randevular, total, err := h.service.List(q.With("hasta_kodu", hastaKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve appointments", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, randevular)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_RANDEVULAR_RETRIEVED, "Appointments retrieved successfully", randevular, meta)
 }
 
@@ -74,23 +69,18 @@ func (h *RandevuHandler) GetByBasvuru(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.RandevuSorgusu)
+	if !ok {
+		return
 	}
 
-	randevular, total, err := h.service.GetByBasvuruKodu(basvuruKodu, page, limit)
+	randevular, total, err := h.service.List(q.With("hasta_basvuru_kodu", basvuruKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve appointments", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, randevular)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_RANDEVULAR_RETRIEVED, "Appointments retrieved successfully", randevular, meta)
 }
 
@@ -102,23 +92,18 @@ func (h *RandevuHandler) GetByHekim(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.RandevuSorgusu)
+	if !ok {
+		return
 	}
 
-	randevular, total, err := h.service.GetByHekimKodu(hekimKodu, page, limit)
+	randevular, total, err := h.service.List(q.With("hekim_kodu", hekimKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve appointments", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, randevular)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_RANDEVULAR_RETRIEVED, "Appointments retrieved successfully", randevular, meta)
 }
 
@@ -130,23 +115,18 @@ func (h *RandevuHandler) GetByTuru(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.RandevuSorgusu)
+	if !ok {
+		return
 	}
 
-	randevular, total, err := h.service.GetByTuru(randevuTuru, page, limit)
+	randevular, total, err := h.service.List(q.With("randevu_turu", randevuTuru), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve appointments", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, randevular)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_RANDEVULAR_RETRIEVED, "Appointments retrieved successfully", randevular, meta)
 }
 
@@ -172,22 +152,22 @@ func (h *RandevuHandler) GetByDateRange(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
+	if startDate.After(endDate) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_DATE_RANGE, "Start date must be before end date", nil)
+		return
 	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+
+	q, page, limit, ok := parseListQuery(c, models.RandevuSorgusu)
+	if !ok {
+		return
 	}
 
-	randevular, total, err := h.service.GetByDateRange(startDate, endDate, page, limit)
+	randevular, total, err := h.service.List(q.Between("randevu_zamani", startDate, endDate), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve appointments", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, randevular)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_RANDEVULAR_RETRIEVED, "Appointments retrieved successfully", randevular, meta)
 }
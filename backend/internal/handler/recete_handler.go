@@ -1,11 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"medscreen/internal/constants"
+	"medscreen/internal/models"
 	"medscreen/internal/service"
 	"medscreen/internal/utils"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -30,7 +31,11 @@ func (h *ReceteHandler) GetByKodu(c *gin.Context) {
 
 	recete, err := h.service.GetByKodu(kodu)
 	if err != nil {
-		utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_RECETE_NOT_FOUND, "Prescription not found", err)
+		if errors.Is(err, service.ErrReceteBulunamadi) {
+			utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_RECETE_NOT_FOUND, "Prescription not found", err)
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve prescription", err)
 		return
 	}
 
@@ -45,23 +50,18 @@ func (h *ReceteHandler) GetByBasvuru(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.ReceteSorgusu)
+	if !ok {
+		return
 	}
 
-	receteler, total, err := h.service.GetByBasvuruKodu(basvuruKodu, page, limit)
+	receteler, total, err := h.service.List(q.With("hasta_basvuru_kodu", basvuruKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve prescriptions", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, receteler)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_RECETELER_RETRIEVED, "Prescriptions retrieved successfully", receteler, meta)
 }
 
@@ -73,23 +73,18 @@ func (h *ReceteHandler) GetByHekim(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.ReceteSorgusu)
+	if !ok {
+		return
 	}
 
-	receteler, total, err := h.service.GetByHekimKodu(hekimKodu, page, limit)
+	receteler, total, err := h.service.List(q.With("hekim_kodu", hekimKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve prescriptions", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, receteler)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_RECETELER_RETRIEVED, "Prescriptions retrieved successfully", receteler, meta)
 }
 
@@ -101,22 +96,17 @@ func (h *ReceteHandler) GetIlaclar(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.ReceteIlacSorgusu)
+	if !ok {
+		return
 	}
 
-	ilaclar, total, err := h.service.GetIlaclar(receteKodu, page, limit)
+	ilaclar, total, err := h.service.ListIlac(q.With("recete_kodu", receteKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve medications", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, ilaclar)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_RECETE_ILACLAR_RETRIEVED, "Medications retrieved successfully", ilaclar, meta)
 }
@@ -2,10 +2,10 @@ package handler
 
 import (
 	"medscreen/internal/constants"
+	"medscreen/internal/models"
 	"medscreen/internal/service"
 	"medscreen/internal/utils"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -45,23 +45,18 @@ func (h *RiskSkorlamaHandler) GetByBasvuru(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.RiskSkorlamaSorgusu)
+	if !ok {
+		return
 	}
 
-	skorlamalar, total, err := h.service.GetByBasvuruKodu(basvuruKodu, page, limit)
+	skorlamalar, total, err := h.service.List(q.With("hasta_basvuru_kodu", basvuruKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve risk scores", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, skorlamalar)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_RISK_SKORLAMALAR_RETRIEVED, "Risk scores retrieved successfully", skorlamalar, meta)
 }
 
@@ -73,22 +68,17 @@ func (h *RiskSkorlamaHandler) GetByTuru(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.RiskSkorlamaSorgusu)
+	if !ok {
+		return
 	}
 
-	skorlamalar, total, err := h.service.GetByTuru(turu, page, limit)
+	skorlamalar, total, err := h.service.List(q.With("risk_skorlama_turu", turu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve risk scores", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, skorlamalar)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_RISK_SKORLAMALAR_RETRIEVED, "Risk scores retrieved successfully", skorlamalar, meta)
 }
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"errors"
+	"medscreen/internal/constants"
+	"medscreen/internal/oidc"
+	"medscreen/internal/service"
+	"medscreen/internal/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ssoCookie keeps the state, nonce and PKCE verifier of a login between the redirect to the
+// identity provider and the callback, so no server-side session is needed
+const (
+	ssoCookie       = "medscreen_sso"
+	ssoCookiePath   = "/api/v1/auth/sso"
+	ssoCookieMaxAge = 10 * time.Minute
+)
+
+// SSOHandler handles the OpenID Connect login for desktop users without an NFC reader
+type SSOHandler struct {
+	service      service.SSOService
+	secureCookie bool
+}
+
+// NewSSOHandler creates a new SSOHandler instance.
+// secureCookie should be set whenever MedScreen is served over HTTPS.
+func NewSSOHandler(service service.SSOService, secureCookie bool) *SSOHandler {
+	return &SSOHandler{service: service, secureCookie: secureCookie}
+}
+
+// Login handles GET /api/v1/auth/sso/login
+// It redirects the browser to the identity provider.
+func (h *SSOHandler) Login(c *gin.Context) {
+	baslangic, err := h.service.Baslat(c.Request.Context())
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadGateway, constants.ERROR_SSO_PROVIDER_UNAVAILABLE, "Identity provider is unavailable", err)
+		return
+	}
+
+	h.setCookie(c, strings.Join([]string{baslangic.State, baslangic.Nonce, baslangic.Verifier}, "."), int(ssoCookieMaxAge.Seconds()))
+	c.Redirect(http.StatusFound, baslangic.URL)
+}
+
+// Callback handles GET /api/v1/auth/sso/callback
+// The identity provider redirects here with a code; MedScreen tokens are returned as for an NFC login.
+func (h *SSOHandler) Callback(c *gin.Context) {
+	cookie, _ := c.Cookie(ssoCookie)
+	// A login state is used once, whatever the outcome
+	h.setCookie(c, "", -1)
+
+	if idpError := c.Query("error"); idpError != "" {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, constants.ERROR_SSO_FAILED, "Identity provider refused the login: "+idpError, nil)
+		return
+	}
+
+	var baslangic service.SSOBaslangic
+	if parts := strings.Split(cookie, "."); len(parts) == 3 {
+		baslangic = service.SSOBaslangic{State: parts[0], Nonce: parts[1], Verifier: parts[2]}
+	}
+
+	sonuc, err := h.service.Tamamla(c.Request.Context(), baslangic, c.Query("code"), c.Query("state"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrSSOState):
+			utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_SSO_STATE_MISMATCH, err.Error(), nil)
+		case errors.Is(err, oidc.ErrDiscovery):
+			utils.SendErrorResponse(c, http.StatusBadGateway, constants.ERROR_SSO_PROVIDER_UNAVAILABLE, "Identity provider is unavailable", err)
+		case errors.Is(err, oidc.ErrTokenExchange), errors.Is(err, oidc.ErrInvalidToken), errors.Is(err, service.ErrSSOPersonel):
+			utils.SendErrorResponse(c, http.StatusUnauthorized, constants.ERROR_SSO_FAILED, "Login through the identity provider failed", err)
+		case errors.Is(err, service.ErrTokenGeneration):
+			utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to generate authentication token", err)
+		default:
+			utils.SendErrorResponse(c, http.StatusUnauthorized, constants.ERROR_UNAUTHORIZED, "Personnel is not allowed to log in", err)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_SSO_LOGIN, "Login successful", sonuc)
+}
+
+// setCookie writes the login state cookie; Lax lets it travel on the provider's redirect back
+func (h *SSOHandler) setCookie(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(ssoCookie, value, maxAge, ssoCookiePath, "", h.secureCookie, true)
+}
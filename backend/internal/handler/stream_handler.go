@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"medscreen/internal/audit"
+	"medscreen/internal/constants"
+	"medscreen/internal/masking"
+	"medscreen/internal/middleware"
+	"medscreen/internal/repository"
+	"medscreen/internal/stream"
+	"medscreen/internal/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errYetkiBitti ends a stream whose token expired or was revoked while it was open
+var errYetkiBitti = errors.New("token is no longer valid")
+
+// StreamHandler handles Server-Sent Events streams of new clinical events (read-only)
+type StreamHandler struct {
+	izleyici    *stream.Izleyici
+	auditSink   repository.ErisimKaydiRepository
+	masking     *masking.Policy
+	revocations middleware.TokenRevocationChecker
+	maxReplay   time.Duration
+	retry       time.Duration
+}
+
+// NewStreamHandler creates a new StreamHandler instance. Clients may resume at most
+// maxReplay into the past; retry is the reconnection delay sent to the browser.
+// Events are masked with the same policy as the buffered responses, and the token is
+// checked against revocations again before every batch and keepalive.
+func NewStreamHandler(izleyici *stream.Izleyici, auditSink repository.ErisimKaydiRepository, maskingPolicy *masking.Policy, revocations middleware.TokenRevocationChecker, maxReplay, retry time.Duration) *StreamHandler {
+	return &StreamHandler{izleyici: izleyici, auditSink: auditSink, masking: maskingPolicy, revocations: revocations, maxReplay: maxReplay, retry: retry}
+}
+
+// GetByYatak handles GET /api/v1/stream/yatak/:yatak_kodu
+// Query parameters: since (optional, RFC 3339; the Last-Event-ID header takes precedence)
+func (h *StreamHandler) GetByYatak(c *gin.Context) {
+	yatakKodu := c.Param("yatak_kodu")
+	if yatakKodu == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_YATAK_KODU, "Bed code is required", nil)
+		return
+	}
+	h.stream(c, stream.Konu{YatakKodu: yatakKodu})
+}
+
+// GetByBirim handles GET /api/v1/stream/birim/:birim_kodu
+// Query parameters: since (optional, RFC 3339; the Last-Event-ID header takes precedence)
+func (h *StreamHandler) GetByBirim(c *gin.Context) {
+	birimKodu := c.Param("birim_kodu")
+	if birimKodu == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "Unit code is required", nil)
+		return
+	}
+	h.stream(c, stream.Konu{BirimKodu: birimKodu})
+}
+
+// stream writes one SSE message per event. The event id is the event's watermark, so a
+// reconnecting browser resumes from it through Last-Event-ID. Every batch is recorded in
+// the audit trail before it is written; if that fails the stream ends.
+//
+// A stream lives no longer than the token (or API key) that opened it, and ends with an
+// error event as soon as the token is found revoked, so the client has to authenticate
+// again to resume.
+func (h *StreamHandler) stream(c *gin.Context, konu stream.Konu) {
+	now := time.Now()
+	since := now
+	resume := c.GetHeader("Last-Event-ID")
+	if resume == "" {
+		resume = c.Query("since")
+	}
+	if resume != "" {
+		t, err := time.Parse(time.RFC3339Nano, resume)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "Invalid since format (use RFC 3339)", err)
+			return
+		}
+		since = t
+	}
+	if earliest := now.Add(-h.maxReplay); since.Before(earliest) {
+		since = earliest
+	}
+
+	if err := audit.RecordStream(c, h.auditSink, nil); err != nil {
+		log.Printf("Audit: failed to record stream %s: %v", c.Request.URL.Path, err)
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_AUDIT_FAILED, "Access could not be recorded", nil)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", h.retry.Milliseconds())
+	c.Writer.Flush()
+
+	ctx := c.Request.Context()
+	if bitis, ok := yetkiSonu(c); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, bitis)
+		defer cancel()
+	}
+
+	err := h.izleyici.Izle(ctx, konu, since, func(olaylar []repository.KlinikOlay) error {
+		if code := h.yetkiKontrol(c); code != "" {
+			fmt.Fprintf(c.Writer, "event: error\ndata: {\"code\":%q}\n\n", code)
+			c.Writer.Flush()
+			return errYetkiBitti
+		}
+		if olaylar == nil {
+			if _, err := fmt.Fprint(c.Writer, ": keepalive\n\n"); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+			return nil
+		}
+
+		if err := audit.RecordStream(c, h.auditSink, olaylar); err != nil {
+			fmt.Fprintf(c.Writer, "event: error\ndata: {\"code\":%q}\n\n", constants.ERROR_AUDIT_FAILED)
+			c.Writer.Flush()
+			return err
+		}
+		for _, olay := range olaylar {
+			data, err := h.masking.Marshal(c, olay)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", olay.Zaman.Format(time.RFC3339Nano), olay.Tur, data); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		fmt.Fprintf(c.Writer, "event: error\ndata: {\"code\":%q}\n\n", constants.ERROR_TOKEN_EXPIRED)
+		c.Writer.Flush()
+		err = errYetkiBitti
+	}
+	if err != nil {
+		log.Printf("Stream %s ended: %v", c.Request.URL.Path, err)
+	}
+}
+
+// yetkiSonu returns when the token or API key of the request stops being valid
+func yetkiSonu(c *gin.Context) (time.Time, bool) {
+	if claims, ok := middleware.GetClaims(c); ok && claims.ExpiresAt != nil {
+		return claims.ExpiresAt.Time, true
+	}
+	if anahtar, ok := middleware.GetAPIAnahtari(c); ok && anahtar.SonGecerlilikZamani != nil {
+		return *anahtar.SonGecerlilikZamani, true
+	}
+	return time.Time{}, false
+}
+
+// yetkiKontrol returns the error code to end the stream with when its token has expired
+// or has been revoked since the stream was opened, or "" while it is still valid
+func (h *StreamHandler) yetkiKontrol(c *gin.Context) string {
+	if bitis, ok := yetkiSonu(c); ok && !time.Now().Before(bitis) {
+		return constants.ERROR_TOKEN_EXPIRED
+	}
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		return ""
+	}
+	revoked, err := h.revocations.IsRevoked(claims)
+	if err != nil {
+		log.Printf("Stream %s: failed to check token revocation: %v", c.Request.URL.Path, err)
+		return constants.ERROR_TOKEN_REVOCATION_FAILED
+	}
+	if revoked {
+		return constants.ERROR_TOKEN_REVOKED
+	}
+	return ""
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"medscreen/internal/masking"
+	"medscreen/internal/middleware"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"medscreen/internal/stream"
+	"medscreen/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Feature: clinical-event-stream, Property 2: Streams End With Their Token
+// *For any* open stream, the stream SHALL end with an error event once its token expires
+// or is revoked, instead of outliving the credential that opened it.
+
+type streamYatanRepo struct {
+	repository.AnlikYatanHastaRepository
+}
+
+func (streamYatanRepo) FindByYatakKodu(string, int, int) ([]models.AnlikYatanHasta, int64, error) {
+	return []models.AnlikYatanHasta{{HastaBasvuruKodu: "B1"}}, 1, nil
+}
+
+type streamOlayRepo struct{}
+
+func (streamOlayRepo) FindSince([]string, time.Time) ([]repository.KlinikOlay, error) {
+	return nil, nil
+}
+
+type streamAuditSink struct {
+	repository.ErisimKaydiRepository
+}
+
+func (streamAuditSink) Create([]models.ErisimKaydi) error { return nil }
+
+// revokeAfter reports a token revoked from the given check on
+type revokeAfter struct {
+	mu     sync.Mutex
+	checks int
+	after  int
+}
+
+func (r *revokeAfter) IsRevoked(*utils.Claims) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks++
+	return r.after > 0 && r.checks >= r.after, nil
+}
+
+// openStream serves a bed stream for a token expiring after ttl and returns its body
+func openStream(t *testing.T, revocations middleware.TokenRevocationChecker, ttl time.Duration) string {
+	gin.SetMode(gin.TestMode)
+	izleyici := stream.NewIzleyici(streamYatanRepo{}, streamOlayRepo{}, stream.RealClock, 5*time.Millisecond, 10*time.Millisecond)
+	h := NewStreamHandler(izleyici, streamAuditSink{}, masking.Default(), revocations, time.Hour, time.Second)
+
+	router := gin.New()
+	router.GET("/api/v1/stream/yatak/:yatak_kodu", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyClaims, &utils.Claims{
+			PersonelKodu:     "P000001",
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: &jwt.NumericDate{Time: time.Now().Add(ttl)}},
+		})
+		c.Next()
+	}, h.GetByYatak)
+
+	done := make(chan string, 1)
+	go func() {
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/api/v1/stream/yatak/Y1", nil))
+		done <- resp.Body.String()
+	}()
+	select {
+	case body := <-done:
+		return body
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stream did not end")
+		return ""
+	}
+}
+
+// TestStream_EndsWhenTokenRevoked verifies that revocation is checked again while the stream is open
+func TestStream_EndsWhenTokenRevoked(t *testing.T) {
+	revocations := &revokeAfter{after: 3}
+	body := openStream(t, revocations, time.Hour)
+
+	if !strings.Contains(body, ": keepalive") || !strings.HasSuffix(body, "event: error\ndata: {\"code\":\"TOKEN_REVOKED\"}\n\n") {
+		t.Errorf("Expected keepalives and a TOKEN_REVOKED error event, got %q", body)
+	}
+	if revocations.checks != 3 {
+		t.Errorf("Expected the stream to end at the first revoked check, got %d checks", revocations.checks)
+	}
+}
+
+// TestStream_EndsWhenTokenExpires verifies that a stream does not outlive its token
+func TestStream_EndsWhenTokenExpires(t *testing.T) {
+	basla := time.Now()
+	body := openStream(t, &revokeAfter{}, 100*time.Millisecond)
+
+	if !strings.HasSuffix(body, "event: error\ndata: {\"code\":\"TOKEN_EXPIRED\"}\n\n") {
+		t.Errorf("Expected a TOKEN_EXPIRED error event, got %q", body)
+	}
+	if sure := time.Since(basla); sure < 100*time.Millisecond {
+		t.Errorf("Stream ended before the token expired, after %v", sure)
+	}
+}
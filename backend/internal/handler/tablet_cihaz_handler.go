@@ -2,10 +2,10 @@ package handler
 
 import (
 	"medscreen/internal/constants"
+	"medscreen/internal/models"
 	"medscreen/internal/service"
 	"medscreen/internal/utils"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -45,44 +45,34 @@ func (h *TabletCihazHandler) GetByYatak(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.TabletCihazSorgusu)
+	if !ok {
+		return
 	}
 
-	cihazlar, total, err := h.service.GetByYatakKodu(yatakKodu, page, limit)
+	cihazlar, total, err := h.service.List(q.With("yatak_kodu", yatakKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve devices", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, cihazlar)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_TABLET_CIHAZLAR_RETRIEVED, "Devices retrieved successfully", cihazlar, meta)
 }
 
 // GetAll handles GET /api/v1/tablet-cihaz
 func (h *TabletCihazHandler) GetAll(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.TabletCihazSorgusu)
+	if !ok {
+		return
 	}
 
-	cihazlar, total, err := h.service.GetAll(page, limit)
+	cihazlar, total, err := h.service.List(q, page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve devices", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, cihazlar)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_TABLET_CIHAZLAR_RETRIEVED, "Devices retrieved successfully", cihazlar, meta)
 }
@@ -1,16 +1,21 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"medscreen/internal/constants"
+	"medscreen/internal/middleware"
+	"medscreen/internal/models"
 	"medscreen/internal/service"
 	"medscreen/internal/utils"
 	"net/http"
-	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// TetkikSonucHandler handles HTTP requests for test results operations (read-only)
+// TetkikSonucHandler handles HTTP requests for test results operations
+// (read-only, except for acknowledging critical results)
 type TetkikSonucHandler struct {
 	service service.TetkikSonucService
 }
@@ -45,22 +50,77 @@ func (h *TetkikSonucHandler) GetByBasvuru(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.TetkikSonucSorgusu)
+	if !ok {
+		return
 	}
 
-	sonuclar, total, err := h.service.GetByBasvuruKodu(basvuruKodu, page, limit)
+	sonuclar, total, err := h.service.List(q.With("hasta_basvuru_kodu", basvuruKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve test results", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, sonuclar)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_TETKIK_SONUCLAR_RETRIEVED, "Test results retrieved successfully", sonuclar, meta)
 }
+
+type kritikOnayRequest struct {
+	Aciklama string `json:"aciklama"`
+}
+
+// GetKritik handles GET /api/v1/tetkik-sonuc/kritik
+// Query parameters: birim_kodu (optional), since (optional, YYYY-MM-DD or RFC 3339; defaults to the last 72 hours)
+func (h *TetkikSonucHandler) GetKritik(c *gin.Context) {
+	var since *time.Time
+	if s := c.Query("since"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t, err = time.Parse("2006-01-02", s)
+		}
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "Invalid since format (use YYYY-MM-DD or RFC 3339)", err)
+			return
+		}
+		since = &t
+	}
+
+	sonuclar, err := h.service.GetKritik(c.Query("birim_kodu"), since)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve critical test results", err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_KRITIK_TETKIK_SONUCLAR_RETRIEVED, "Critical test results retrieved successfully", sonuclar)
+}
+
+// Onayla handles POST /api/v1/tetkik-sonuc/kritik/:kodu/onay
+func (h *TetkikSonucHandler) Onayla(c *gin.Context) {
+	kodu := c.Param("kodu")
+	if kodu == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_TETKIK_SONUC_KODU, "Test result code is required", nil)
+		return
+	}
+
+	// The body is optional; one that is sent must be valid
+	var req kritikOnayRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, constants.ERROR_INVALID_REQUEST, "Invalid request body", err)
+		return
+	}
+
+	onay, err := h.service.Onayla(kodu, c.GetString(middleware.ContextKeyPersonelKodu), req.Aciklama)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTetkikSonucKritikDegil):
+			utils.SendErrorResponse(c, http.StatusConflict, constants.ERROR_TETKIK_SONUC_NOT_CRITICAL, "Test result is not critical", err)
+		case errors.Is(err, service.ErrTetkikSonucBulunamadi):
+			utils.SendErrorResponse(c, http.StatusNotFound, constants.ERROR_TETKIK_SONUC_NOT_FOUND, "Test result not found", err)
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to acknowledge test result", err)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, constants.SUCCESS_KRITIK_SONUC_ACKNOWLEDGED, "Critical test result acknowledged", onay)
+}
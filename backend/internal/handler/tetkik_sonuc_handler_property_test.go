@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"errors"
+	"medscreen/internal/models"
+	"medscreen/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Feature: critical-lab-values, Property 2: Acknowledgements Keep Their Note
+// *For any* acknowledgement of a critical result, a body that is sent SHALL be valid or
+// the acknowledgement SHALL be refused, and only a missing result SHALL be reported as not
+// found.
+
+type onayTetkikService struct {
+	service.TetkikSonucService
+	err      error
+	aciklama string
+	cagrildi bool
+}
+
+func (s *onayTetkikService) Onayla(kodu, personelKodu, aciklama string) (*models.KritikSonucOnay, error) {
+	s.cagrildi = true
+	s.aciklama = aciklama
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.KritikSonucOnay{TetkikSonucKodu: kodu, OnaylayanPersonelKodu: personelKodu}, nil
+}
+
+// onayla acknowledges T1 with the given body and returns the status and the service
+func onayla(body string, err error) (int, *onayTetkikService) {
+	gin.SetMode(gin.TestMode)
+	svc := &onayTetkikService{err: err}
+	h := NewTetkikSonucHandler(svc)
+
+	router := gin.New()
+	router.POST("/api/v1/tetkik-sonuc/kritik/:kodu/onay", h.Onayla)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/tetkik-sonuc/kritik/T1/onay", strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp.Code, svc
+}
+
+// TestTetkikSonuc_OnaylaBody verifies that the note is optional but never dropped silently
+func TestTetkikSonuc_OnaylaBody(t *testing.T) {
+	if code, svc := onayla("", nil); code != http.StatusOK || !svc.cagrildi {
+		t.Errorf("Expected an acknowledgement without a body, got %d", code)
+	}
+	if code, svc := onayla(`{"aciklama": "Nefroloji aranacak"}`, nil); code != http.StatusOK || svc.aciklama != "Nefroloji aranacak" {
+		t.Errorf("Expected the note to be recorded, got %d with %q", code, svc.aciklama)
+	}
+	for _, body := range []string{`{"aciklama": "Nefroloji`, `{"aciklama": 5}`} {
+		if code, svc := onayla(body, nil); code != http.StatusBadRequest || svc.cagrildi {
+			t.Errorf("%s: expected 400 without an acknowledgement, got %d", body, code)
+		}
+	}
+}
+
+// TestTetkikSonuc_OnaylaErrors verifies that only a missing result is reported as not found
+func TestTetkikSonuc_OnaylaErrors(t *testing.T) {
+	cases := []struct {
+		err  error
+		code int
+	}{
+		{service.ErrTetkikSonucBulunamadi, http.StatusNotFound},
+		{service.ErrTetkikSonucKritikDegil, http.StatusConflict},
+		{errors.New("connection reset"), http.StatusInternalServerError},
+	}
+	for _, tt := range cases {
+		if code, _ := onayla("", tt.err); code != tt.code {
+			t.Errorf("%v: expected %d, got %d", tt.err, tt.code, code)
+		}
+	}
+}
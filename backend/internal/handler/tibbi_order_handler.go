@@ -2,10 +2,10 @@ package handler
 
 import (
 	"medscreen/internal/constants"
+	"medscreen/internal/models"
 	"medscreen/internal/service"
 	"medscreen/internal/utils"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -45,23 +45,18 @@ func (h *TibbiOrderHandler) GetByBasvuru(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.TibbiOrderSorgusu)
+	if !ok {
+		return
 	}
 
-	orders, total, err := h.service.GetByBasvuruKodu(basvuruKodu, page, limit)
+	orders, total, err := h.service.List(q.With("hasta_basvuru_kodu", basvuruKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve medical orders", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, orders)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_TIBBI_ORDERLAR_RETRIEVED, "Medical orders retrieved successfully", orders, meta)
 }
 
@@ -73,22 +68,17 @@ func (h *TibbiOrderHandler) GetDetay(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.TibbiOrderDetaySorgusu)
+	if !ok {
+		return
 	}
 
-	detaylar, total, err := h.service.GetDetayByOrderKodu(orderKodu, page, limit)
+	detaylar, total, err := h.service.ListDetay(q.With("tibbi_order_kodu", orderKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve order details", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, detaylar)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_TIBBI_ORDER_DETAY_RETRIEVED, "Order details retrieved successfully", detaylar, meta)
 }
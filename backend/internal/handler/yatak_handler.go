@@ -2,10 +2,10 @@ package handler
 
 import (
 	"medscreen/internal/constants"
+	"medscreen/internal/models"
 	"medscreen/internal/service"
 	"medscreen/internal/utils"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -47,44 +47,34 @@ func (h *YatakHandler) GetByBirimOda(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.YatakSorgusu)
+	if !ok {
+		return
 	}
 
-	yataklar, total, err := h.service.GetByBirimAndOda(birimKodu, odaKodu, page, limit)
+	yataklar, total, err := h.service.List(q.With("birim_kodu", birimKodu).With("oda_kodu", odaKodu), page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve beds", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, yataklar)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_YATAKLAR_RETRIEVED, "Beds retrieved successfully", yataklar, meta)
 }
 
 // GetAll handles GET /api/v1/yatak
 func (h *YatakHandler) GetAll(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	q, page, limit, ok := parseListQuery(c, models.YatakSorgusu)
+	if !ok {
+		return
 	}
 
-	yataklar, total, err := h.service.GetAll(page, limit)
+	yataklar, total, err := h.service.List(q, page, limit)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to retrieve beds", err)
 		return
 	}
 
-	meta := utils.CalculateMeta(page, limit, total)
+	meta := listMeta(q, page, limit, total, yataklar)
 	utils.SendSuccessResponseWithMeta(c, http.StatusOK, constants.SUCCESS_YATAKLAR_RETRIEVED, "Beds retrieved successfully", yataklar, meta)
 }
@@ -0,0 +1,63 @@
+package hl7
+
+import (
+	"errors"
+	"strings"
+)
+
+// Acknowledgement codes of MSA-1, in original (A*) and enhanced (C*) mode
+const (
+	AckAccept = "AA"
+	AckError  = "AE"
+	AckReject = "AR"
+
+	CommitAccept = "CA"
+	CommitError  = "CE"
+	CommitReject = "CR"
+)
+
+// Ack is the acknowledgement an endpoint answered a message with
+type Ack struct {
+	Code string
+	// ControlID is the MSH-10 of the acknowledged message
+	ControlID string
+	// Text is the reason the endpoint gave for an error or a reject (MSA-3 or ERR)
+	Text string
+}
+
+// ParseAck decodes an acknowledgement message
+func ParseAck(data []byte) (*Ack, error) {
+	m, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	msa := m.Segment("MSA")
+	if msa == nil {
+		return nil, errors.New("hl7: acknowledgement without an MSA segment")
+	}
+	ack := &Ack{Code: strings.ToUpper(msa.Get(1, 1)), ControlID: msa.Get(2, 1), Text: msa.Get(3, 1)}
+	if ack.Code == "" {
+		return nil, errors.New("hl7: acknowledgement without a code")
+	}
+	if errSegment := m.Segment("ERR"); ack.Text == "" && errSegment != nil {
+		// ERR-8 user message (v2.5), or ERR-1 error code and location (v2.4 and before)
+		ack.Text = errSegment.Get(8, 1)
+		if ack.Text == "" && len(errSegment.Fields) > 0 {
+			ack.Text = strings.TrimSpace(strings.Join(errSegment.Fields[0], " "))
+		}
+	}
+	return ack, nil
+}
+
+// Accepted reports whether the endpoint took the message
+func (a *Ack) Accepted() bool {
+	return a.Code == AckAccept || a.Code == CommitAccept
+}
+
+// Retryable reports whether the message may be sent again: a reject (AR, CR) is for
+// reasons unrelated to its content, such as the endpoint being busy or down, whereas an
+// error (AE, CE) reports a problem with the message itself that sending it again will
+// not fix. Codes outside the standard are retried.
+func (a *Ack) Retryable() bool {
+	return !a.Accepted() && a.Code != AckError && a.Code != CommitError
+}
@@ -0,0 +1,195 @@
+package hl7
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"medscreen/internal/stream"
+	"net"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Hedef is an MLLP endpoint the feed delivers to. Ad is sent as the receiving application
+// (MSH-5) and names the endpoint's queue in the outbox.
+type Hedef struct {
+	Ad    string
+	Adres string
+}
+
+// ParseHedefler parses endpoints written as ad=host:port
+func ParseHedefler(list []string) ([]Hedef, error) {
+	var hedefler []Hedef
+	adlar := make(map[string]bool)
+	for _, item := range list {
+		ad, adres, ok := strings.Cut(item, "=")
+		ad, adres = strings.TrimSpace(ad), strings.TrimSpace(adres)
+		if !ok || ad == "" {
+			return nil, fmt.Errorf("invalid HL7 endpoint %q: expected name=host:port", item)
+		}
+		if _, _, err := net.SplitHostPort(adres); err != nil {
+			return nil, fmt.Errorf("invalid HL7 endpoint %q: %w", item, err)
+		}
+		if adlar[ad] {
+			return nil, fmt.Errorf("duplicate HL7 endpoint name %q", ad)
+		}
+		adlar[ad] = true
+		hedefler = append(hedefler, Hedef{Ad: ad, Adres: adres})
+	}
+	return hedefler, nil
+}
+
+// Ayarlar configures the delivery of messages
+type Ayarlar struct {
+	// PollInterval is how often an idle or failing queue is looked at again
+	PollInterval time.Duration
+	// AckTimeout bounds connecting and waiting for an acknowledgement
+	AckTimeout time.Duration
+	// RetryBase is the first retry delay; it doubles with every further attempt up to RetryMax
+	RetryBase time.Duration
+	RetryMax  time.Duration
+	// MaxDeneme is the number of attempts after which a message becomes a dead letter
+	MaxDeneme int
+}
+
+// Gonderici delivers the queue of one endpoint. Messages are sent one at a time, oldest
+// first, so the endpoint sees the changes of a patient in order: a message that is
+// retried holds back the ones after it until it is accepted or becomes a dead letter.
+type Gonderici struct {
+	hedef     Hedef
+	mesajRepo repository.HL7MesajRepository
+	client    *Client
+	ayarlar   Ayarlar
+	clock     stream.Clock
+}
+
+// NewGonderici creates a new Gonderici
+func NewGonderici(hedef Hedef, mesajRepo repository.HL7MesajRepository, ayarlar Ayarlar, clock stream.Clock) *Gonderici {
+	return &Gonderici{
+		hedef:     hedef,
+		mesajRepo: mesajRepo,
+		client:    NewClient(hedef.Adres, ayarlar.AckTimeout),
+		ayarlar:   ayarlar,
+		clock:     clock,
+	}
+}
+
+// Calistir delivers until ctx is done
+func (g *Gonderici) Calistir(ctx context.Context) {
+	defer g.client.Close()
+	for {
+		ilerledi, err := g.Gonder(ctx)
+		if err != nil {
+			log.Printf("HL7: failed to deliver to %s: %v", g.hedef.Ad, err)
+		}
+		if ilerledi && err == nil {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-g.clock.After(g.ayarlar.PollInterval):
+		}
+	}
+}
+
+// Gonder makes an attempt to deliver the oldest waiting message, if it is due. ilerledi
+// reports whether the queue moved on: the message was accepted or became a dead letter.
+func (g *Gonderici) Gonder(ctx context.Context) (ilerledi bool, err error) {
+	mesaj, err := g.mesajRepo.FindSiradaki(g.hedef.Ad)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if mesaj.SonrakiDeneme.After(g.clock.Now()) {
+		return false, nil
+	}
+
+	ack, sendErr := g.gonder(ctx, mesaj)
+	if ctx.Err() != nil {
+		// Shutting down is not a failed attempt
+		return false, nil
+	}
+	now := g.clock.Now()
+	teslimat := repository.HL7Teslimat{
+		Durum:         models.HL7MesajBekliyor,
+		DenemeSayisi:  mesaj.DenemeSayisi + 1,
+		SonrakiDeneme: now,
+	}
+	switch {
+	case sendErr == nil && ack.Accepted():
+		teslimat.Durum = models.HL7MesajGonderildi
+		teslimat.SonucZamani = &now
+	case sendErr == nil && !ack.Retryable():
+		teslimat.Durum = models.HL7MesajOlu
+		teslimat.SonucZamani = &now
+		teslimat.SonHata = hataOf(nil, ack)
+		log.Printf("HL7: %s rejected message %s (%s): %s", g.hedef.Ad, mesaj.MesajKontrolKodu, ack.Code, *teslimat.SonHata)
+	default:
+		teslimat.SonHata = hataOf(sendErr, ack)
+		if teslimat.DenemeSayisi >= g.ayarlar.MaxDeneme {
+			teslimat.Durum = models.HL7MesajOlu
+			teslimat.SonucZamani = &now
+			log.Printf("HL7: giving up on message %s to %s after %d attempts: %s", mesaj.MesajKontrolKodu, g.hedef.Ad, teslimat.DenemeSayisi, *teslimat.SonHata)
+		} else {
+			teslimat.SonrakiDeneme = now.Add(g.bekleme(teslimat.DenemeSayisi))
+		}
+	}
+	if err := g.mesajRepo.UpdateTeslimat(mesaj.HL7MesajID, teslimat); err != nil {
+		return false, err
+	}
+	return teslimat.Durum != models.HL7MesajBekliyor, nil
+}
+
+// gonder sends a message and checks that the acknowledgement is for it
+func (g *Gonderici) gonder(ctx context.Context, mesaj *models.HL7Mesaj) (*Ack, error) {
+	data, err := g.client.Send(ctx, []byte(mesaj.Icerik))
+	if err != nil {
+		return nil, err
+	}
+	ack, err := ParseAck(data)
+	if err != nil {
+		return nil, err
+	}
+	if ack.ControlID != mesaj.MesajKontrolKodu {
+		// The connection is out of step with the endpoint; start over on a new one
+		g.client.Close()
+		return nil, fmt.Errorf("acknowledgement is for message %q", ack.ControlID)
+	}
+	return ack, nil
+}
+
+// bekleme is the delay before the next attempt after the given number of attempts
+func (g *Gonderici) bekleme(deneme int) time.Duration {
+	d := g.ayarlar.RetryBase
+	for i := 1; i < deneme && d < g.ayarlar.RetryMax; i++ {
+		d *= 2
+	}
+	if d > g.ayarlar.RetryMax {
+		d = g.ayarlar.RetryMax
+	}
+	return d
+}
+
+func hataOf(err error, ack *Ack) *string {
+	var hata string
+	switch {
+	case err != nil:
+		hata = err.Error()
+	case ack.Text != "":
+		hata = ack.Code + ": " + ack.Text
+	default:
+		hata = ack.Code
+	}
+	return &hata
+}
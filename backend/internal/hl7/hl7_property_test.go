@@ -0,0 +1,903 @@
+package hl7
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"pgregory.net/rapid"
+)
+
+// Feature: hl7-outbound-feed, Property 1: Escaped Values Round-Trip
+// *For any* value, the escaped value SHALL contain no delimiter, line break or MLLP block
+// character and SHALL unescape to the value.
+
+// Feature: hl7-outbound-feed, Property 2: Encoded Messages Round-Trip
+// *For any* message, parsing its encoding, read back from an MLLP frame, SHALL give back
+// its segments, fields and components, trailing empty ones left out.
+
+// Feature: hl7-outbound-feed, Property 3: Messages Are Delivered In Order Until Acknowledged
+// *For any* sequence of answers of an MLLP endpoint, the queue SHALL be delivered oldest
+// first; a message SHALL be retried on a reject, a broken connection or an acknowledgement
+// of another message until it is accepted or runs out of attempts, and SHALL become a dead
+// letter at once on an application error.
+
+// Feature: hl7-outbound-feed, Property 4: Every Change Is Queued Once Per Endpoint
+// *For any* sequence of admissions, transfers, discharges, test results and vital signs,
+// polled across restarts, the feed SHALL queue exactly one message of the matching type
+// per change and endpoint.
+
+// --- Test doubles ---
+
+// fakeClock only moves when Advance is called
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	return make(chan time.Time)
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// memMesajRepo is an in-memory HL7MesajRepository
+type memMesajRepo struct {
+	mu       sync.Mutex
+	mesajlar []models.HL7Mesaj
+}
+
+func (r *memMesajRepo) Create(mesajlar []models.HL7Mesaj) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var eklenen int64
+	for _, m := range mesajlar {
+		var var_ bool
+		for _, mevcut := range r.mesajlar {
+			if mevcut.Hedef == m.Hedef && mevcut.OlayAnahtari == m.OlayAnahtari {
+				var_ = true
+			}
+			if mevcut.MesajKontrolKodu == m.MesajKontrolKodu {
+				return 0, errors.New("duplicate mesaj_kontrol_kodu")
+			}
+		}
+		if var_ {
+			continue
+		}
+		m.HL7MesajID = uint(len(r.mesajlar) + 1)
+		r.mesajlar = append(r.mesajlar, m)
+		eklenen++
+	}
+	return eklenen, nil
+}
+
+func (r *memMesajRepo) SonOlayZamani() (*time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var son *time.Time
+	for i := range r.mesajlar {
+		if son == nil || r.mesajlar[i].OlayZamani.After(*son) {
+			z := r.mesajlar[i].OlayZamani
+			son = &z
+		}
+	}
+	return son, nil
+}
+
+func (r *memMesajRepo) FindSiradaki(hedef string) (*models.HL7Mesaj, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range r.mesajlar {
+		if m.Hedef == hedef && m.Durum == models.HL7MesajBekliyor {
+			return &m, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memMesajRepo) UpdateTeslimat(id uint, teslimat repository.HL7Teslimat) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := &r.mesajlar[id-1]
+	m.Durum, m.DenemeSayisi, m.SonrakiDeneme = teslimat.Durum, teslimat.DenemeSayisi, teslimat.SonrakiDeneme
+	m.SonHata, m.SonucZamani = teslimat.SonHata, teslimat.SonucZamani
+	return nil
+}
+
+func (r *memMesajRepo) FindByDurum(hedef string, durum models.HL7MesajDurumu, page, limit int) ([]models.HL7Mesaj, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sonuc []models.HL7Mesaj
+	for _, m := range r.mesajlar {
+		if m.Durum == durum && (hedef == "" || m.Hedef == hedef) {
+			sonuc = append(sonuc, m)
+		}
+	}
+	return sonuc, int64(len(sonuc)), nil
+}
+
+func (r *memMesajRepo) YenidenKuyrukla(id uint, zaman time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id < 1 || int(id) > len(r.mesajlar) || r.mesajlar[id-1].Durum != models.HL7MesajOlu {
+		return gorm.ErrRecordNotFound
+	}
+	m := &r.mesajlar[id-1]
+	m.Durum, m.DenemeSayisi, m.SonrakiDeneme, m.SonucZamani = models.HL7MesajBekliyor, 0, zaman, nil
+	return nil
+}
+
+func (r *memMesajRepo) hepsi() []models.HL7Mesaj {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.HL7Mesaj(nil), r.mesajlar...)
+}
+
+// memKaynakRepo holds the watched VEM tables in memory
+type memKaynakRepo struct {
+	yatanlar   []models.AnlikYatanHasta
+	basvurular map[string]*models.HastaBasvuru
+	sonuclar   []models.TetkikSonuc
+	bulgular   []models.HastaVitalFizikiBulgu
+}
+
+func (r *memKaynakRepo) FindAnlikYatanHastalar(page, limit int) ([]models.AnlikYatanHasta, int64, error) {
+	var sonuc []models.AnlikYatanHasta
+	for i := (page - 1) * limit; i < len(r.yatanlar) && i < page*limit; i++ {
+		sonuc = append(sonuc, r.yatanlar[i])
+	}
+	return sonuc, int64(len(r.yatanlar)), nil
+}
+
+func (r *memKaynakRepo) FindCikislarSince(since time.Time) ([]models.HastaBasvuru, error) {
+	var sonuc []models.HastaBasvuru
+	for _, b := range r.basvurular {
+		if b.CikisZamani != nil && (!b.CikisZamani.Before(since) || b.GuncellemeZamani != nil && !b.GuncellemeZamani.Before(since)) {
+			sonuc = append(sonuc, *b)
+		}
+	}
+	return sonuc, nil
+}
+
+func (r *memKaynakRepo) FindTetkikSonuclariSince(since time.Time) ([]models.TetkikSonuc, error) {
+	var sonuc []models.TetkikSonuc
+	for _, t := range r.sonuclar {
+		if !t.KayitZamani.Before(since) || t.OnayZamani != nil && !t.OnayZamani.Before(since) {
+			t.ParseSayisalDegerler()
+			sonuc = append(sonuc, t)
+		}
+	}
+	return sonuc, nil
+}
+
+func (r *memKaynakRepo) FindVitalBulgularSince(since time.Time) ([]models.HastaVitalFizikiBulgu, error) {
+	var sonuc []models.HastaVitalFizikiBulgu
+	for _, b := range r.bulgular {
+		if !b.KayitZamani.Before(since) || b.GuncellemeZamani != nil && !b.GuncellemeZamani.Before(since) {
+			b.ParseSayisalDegerler()
+			sonuc = append(sonuc, b)
+		}
+	}
+	return sonuc, nil
+}
+
+// dinleyici is a local MLLP endpoint. cevap decides how each message is answered: with an
+// acknowledgement code, "drop" (close the connection), "wrong" (acknowledge another
+// message) or "garbage" (answer with something that is not HL7).
+type dinleyici struct {
+	ln    net.Listener
+	cevap func(*Message) string
+
+	mu       sync.Mutex
+	alinan   []*Message
+	baglanti int
+}
+
+func yeniDinleyici(t interface{ Fatalf(string, ...any) }, cevap func(*Message) string) *dinleyici {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	d := &dinleyici{ln: ln, cevap: cevap}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			d.mu.Lock()
+			d.baglanti++
+			d.mu.Unlock()
+			go d.sun(conn)
+		}
+	}()
+	return d
+}
+
+func (d *dinleyici) sun(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		data, err := ReadFrame(reader)
+		if err != nil {
+			return
+		}
+		m, err := Parse(data)
+		if err != nil {
+			return
+		}
+		d.mu.Lock()
+		d.alinan = append(d.alinan, m)
+		d.mu.Unlock()
+
+		kontrol := m.Segment("MSH").Get(10, 1)
+		var cevap []byte
+		switch kod := d.cevap(m); kod {
+		case "drop":
+			return
+		case "wrong":
+			cevap = ackMesaji(AckAccept, "BASKA"+kontrol)
+		case "garbage":
+			cevap = []byte("not an acknowledgement")
+		default:
+			cevap = ackMesaji(kod, kontrol)
+		}
+		if err := WriteFrame(conn, cevap); err != nil {
+			return
+		}
+	}
+}
+
+func (d *dinleyici) kapat() {
+	d.ln.Close()
+}
+
+func (d *dinleyici) kontrolKodlari() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var kodlar []string
+	for _, m := range d.alinan {
+		kodlar = append(kodlar, m.Segment("MSH").Get(10, 1))
+	}
+	return kodlar
+}
+
+func ackMesaji(kod, kontrolKodu string) []byte {
+	msh := NewSegment("MSH").Set(3, "MONITOR").Set(5, "MEDSCREEN").Set(9, "ACK").Set(10, "A"+kontrolKodu).Set(11, "P").Set(12, Version)
+	msa := NewSegment("MSA").Set(1, kod).Set(2, kontrolKodu)
+	if kod != AckAccept && kod != CommitAccept {
+		msa.Set(3, "reddedildi | "+kod)
+	}
+	return (&Message{}).Add(msh, msa).Encode()
+}
+
+// normalize drops the trailing empty fields and components the encoding leaves out
+func normalize(m *Message) []Segment {
+	var segmentler []Segment
+	for _, s := range m.Segments {
+		n := Segment{Name: s.Name}
+		for _, f := range s.Fields {
+			for len(f) > 0 && f[len(f)-1] == "" {
+				f = f[:len(f)-1]
+			}
+			if len(f) == 0 {
+				f = Field{""}
+			}
+			n.Fields = append(n.Fields, append(Field(nil), f...))
+		}
+		for len(n.Fields) > 0 && reflect.DeepEqual(n.Fields[len(n.Fields)-1], Field{""}) {
+			n.Fields = n.Fields[:len(n.Fields)-1]
+		}
+		if len(n.Fields) == 0 {
+			n.Fields = nil
+		}
+		segmentler = append(segmentler, n)
+	}
+	return segmentler
+}
+
+// degerGen draws values with delimiters, MLLP block characters, escape sequences and
+// Turkish characters
+func degerGen() *rapid.Generator[string] {
+	return rapid.OneOf(
+		rapid.String(),
+		rapid.StringOfN(rapid.SampledFrom([]rune("|^~\\&\r\n\x0b\x1cFSTREX0aAğüşİıöç ")), 0, 12, -1),
+	)
+}
+
+// --- Properties ---
+
+// TestProperty_EscapedValuesRoundTrip tests Property 1
+func TestProperty_EscapedValuesRoundTrip(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		deger := degerGen().Draw(rt, "deger")
+		escaped := Escape(deger)
+		if strings.ContainsAny(escaped, "|^~&\r\n\x0b\x1c") {
+			rt.Fatalf("Escaped value %q contains a delimiter", escaped)
+		}
+		if got := Unescape(escaped); got != deger {
+			rt.Fatalf("Unescape(Escape(%q)) = %q", deger, got)
+		}
+	})
+}
+
+// TestProperty_EncodedMessagesRoundTrip tests Property 2
+func TestProperty_EncodedMessagesRoundTrip(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		m := &Message{}
+		msh := NewSegment("MSH")
+		for n := 3; n <= rapid.IntRange(3, 12).Draw(rt, "mshAlanlari"); n++ {
+			msh.Set(n, rapid.SliceOfN(degerGen(), 1, 3).Draw(rt, "msh")...)
+		}
+		m.Add(msh)
+		for i := rapid.IntRange(0, 5).Draw(rt, "segmentler"); i > 0; i-- {
+			s := NewSegment(rapid.SampledFrom([]string{"PID", "PV1", "OBX", "NTE", "ZMS"}).Draw(rt, "ad"))
+			for n := 1; n <= rapid.IntRange(0, 8).Draw(rt, "alanlar"); n++ {
+				s.Set(n, rapid.SliceOfN(degerGen(), 0, 4).Draw(rt, "bilesenler")...)
+			}
+			m.Add(s)
+		}
+
+		var frame bytes.Buffer
+		if err := WriteFrame(&frame, m.Encode()); err != nil {
+			rt.Fatalf("WriteFrame failed: %v", err)
+		}
+		data, err := ReadFrame(bufio.NewReader(&frame))
+		if err != nil {
+			rt.Fatalf("ReadFrame failed: %v", err)
+		}
+		if frame.Len() > 0 {
+			rt.Fatalf("A value ended the frame early, %d bytes left", frame.Len())
+		}
+		parsed, err := Parse(data)
+		if err != nil {
+			rt.Fatalf("Parse failed: %v", err)
+		}
+		if got, want := normalize(parsed), normalize(m); !reflect.DeepEqual(got, want) {
+			rt.Fatalf("Round trip changed the message:\n got %q\nwant %q", got, want)
+		}
+	})
+}
+
+// TestProperty_DeliveredInOrderUntilAcknowledged tests Property 3
+func TestProperty_DeliveredInOrderUntilAcknowledged(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		cevaplar := rapid.SliceOfN(rapid.SampledFrom([]string{
+			AckAccept, CommitAccept, AckError, CommitError, AckReject, CommitReject, "drop", "wrong", "garbage",
+		}), 0, 30).Draw(rt, "cevaplar")
+		var mu sync.Mutex
+		sira := append([]string(nil), cevaplar...)
+		d := yeniDinleyici(rt, func(*Message) string {
+			mu.Lock()
+			defer mu.Unlock()
+			if len(sira) == 0 {
+				return AckAccept
+			}
+			kod := sira[0]
+			sira = sira[1:]
+			return kod
+		})
+		defer d.kapat()
+
+		clock := &fakeClock{now: time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)}
+		repo := &memMesajRepo{}
+		hedef := Hedef{Ad: "MONITOR", Adres: d.ln.Addr().String()}
+		mesajSayisi := rapid.IntRange(1, 6).Draw(rt, "mesajSayisi")
+		var mesajlar []models.HL7Mesaj
+		for i := 0; i < mesajSayisi; i++ {
+			kontrol := fmt.Sprintf("K%d", i)
+			icerik := NewMessage(Olay{Tur: turR01}, Baslik{AliciUygulama: hedef.Ad, KontrolKodu: kontrol, IslemeKodu: "P"}).Encode()
+			mesajlar = append(mesajlar, models.HL7Mesaj{
+				Hedef: hedef.Ad, OlayAnahtari: kontrol, MesajKontrolKodu: kontrol, Icerik: string(icerik),
+				Durum: models.HL7MesajBekliyor, SonrakiDeneme: clock.Now(),
+			})
+		}
+		// A message to another endpoint is not touched
+		mesajlar = append(mesajlar, models.HL7Mesaj{Hedef: "NURSECALL", OlayAnahtari: "X", MesajKontrolKodu: "X", Durum: models.HL7MesajBekliyor})
+		if _, err := repo.Create(mesajlar); err != nil {
+			rt.Fatalf("Create failed: %v", err)
+		}
+
+		ayarlar := Ayarlar{PollInterval: time.Second, AckTimeout: 5 * time.Second, RetryBase: time.Second, RetryMax: 4 * time.Second, MaxDeneme: 3}
+		g := NewGonderici(hedef, repo, ayarlar, clock)
+		defer g.client.Close()
+
+		// Expected outcome, simulated from the answers
+		type sonuc struct {
+			durum  models.HL7MesajDurumu
+			deneme int
+		}
+		var beklenen []sonuc
+		var beklenenKodlar []string
+		kalan := append([]string(nil), cevaplar...)
+		for i := 0; i < mesajSayisi; i++ {
+			s := sonuc{durum: models.HL7MesajBekliyor}
+			for s.durum == models.HL7MesajBekliyor {
+				kod := AckAccept
+				if len(kalan) > 0 {
+					kod, kalan = kalan[0], kalan[1:]
+				}
+				s.deneme++
+				beklenenKodlar = append(beklenenKodlar, fmt.Sprintf("K%d", i))
+				switch {
+				case kod == AckAccept || kod == CommitAccept:
+					s.durum = models.HL7MesajGonderildi
+				case kod == AckError || kod == CommitError:
+					s.durum = models.HL7MesajOlu
+				case s.deneme >= ayarlar.MaxDeneme:
+					s.durum = models.HL7MesajOlu
+				}
+			}
+			beklenen = append(beklenen, s)
+		}
+
+		ctx := context.Background()
+		for adim := 0; adim < 1000; adim++ {
+			if _, err := repo.FindSiradaki(hedef.Ad); errors.Is(err, gorm.ErrRecordNotFound) {
+				break
+			}
+			ilerledi, err := g.Gonder(ctx)
+			if err != nil {
+				rt.Fatalf("Gonder failed: %v", err)
+			}
+			if !ilerledi {
+				// A retry is not due before its backoff has passed
+				if tekrar, _ := g.Gonder(ctx); tekrar {
+					rt.Fatalf("Message was retried before its backoff")
+				}
+				clock.Advance(ayarlar.RetryMax)
+			}
+		}
+
+		hepsi := repo.hepsi()
+		for i, s := range beklenen {
+			m := hepsi[i]
+			if m.Durum != s.durum || m.DenemeSayisi != s.deneme {
+				rt.Fatalf("Message %d: got %s after %d attempts, want %s after %d", i, m.Durum, m.DenemeSayisi, s.durum, s.deneme)
+			}
+			if m.Durum == models.HL7MesajOlu && (m.SonHata == nil || *m.SonHata == "") {
+				rt.Fatalf("Dead letter %d has no error", i)
+			}
+			if m.SonucZamani == nil {
+				rt.Fatalf("Message %d has no outcome time", i)
+			}
+		}
+		if hepsi[mesajSayisi].Durum != models.HL7MesajBekliyor {
+			rt.Fatalf("Message of another endpoint was delivered")
+		}
+		if got := d.kontrolKodlari(); !reflect.DeepEqual(got, beklenenKodlar) {
+			rt.Fatalf("Endpoint received %v, want %v", got, beklenenKodlar)
+		}
+	})
+}
+
+// TestProperty_EveryChangeQueuedOncePerEndpoint tests Property 4
+func TestProperty_EveryChangeQueuedOncePerEndpoint(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		clock := &fakeClock{now: time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)}
+		kaynak := &memKaynakRepo{basvurular: make(map[string]*models.HastaBasvuru)}
+		mesajRepo := &memMesajRepo{}
+		hedefler := []Hedef{{Ad: "MONITOR", Adres: "127.0.0.1:1"}, {Ad: "NURSECALL", Adres: "127.0.0.1:2"}}
+		basliklar := Basliklar{GonderenUygulama: "MEDSCREEN", GonderenKurum: "HASTANE", IslemeKodu: "P"}
+		yeni := func() *Uretici {
+			return NewUretici(kaynak, mesajRepo, hedefler, basliklar, nil, clock, time.Second)
+		}
+		u := yeni()
+
+		// The feed starts with patients already admitted; they are not reported
+		type hasta struct {
+			hasta    models.Hasta
+			yatan    bool
+			cikti    bool
+			sonYatak string
+		}
+		hastalar := map[string]*hasta{}
+		for i := 1; i <= 3; i++ {
+			basvuruKodu := fmt.Sprintf("B%d", i)
+			h := &hasta{hasta: models.Hasta{
+				HastaKodu: fmt.Sprintf("H%d", i),
+				Ad:        degerGen().Draw(rt, "ad"),
+				Soyadi:    degerGen().Draw(rt, "soyadi"),
+			}}
+			hastalar[basvuruKodu] = h
+			kaynak.basvurular[basvuruKodu] = &models.HastaBasvuru{HastaBasvuruKodu: basvuruKodu, HastaKodu: h.hasta.HastaKodu, Hasta: &h.hasta}
+		}
+		yatakSayaci := 0
+		yatir := func(basvuruKodu string, yatakKodu string) {
+			h := hastalar[basvuruKodu]
+			yatakSayaci++
+			kaynak.yatanlar = append(kaynak.yatanlar, models.AnlikYatanHasta{
+				AnlikYatanHastaKodu: fmt.Sprintf("AYH%d", yatakSayaci),
+				HastaBasvuruKodu:    basvuruKodu,
+				HastaKodu:           h.hasta.HastaKodu,
+				Hasta:               &h.hasta,
+				YatakKodu:           yatakKodu,
+				Yatak:               &models.Yatak{YatakKodu: yatakKodu, BirimKodu: "DAHILIYE", OdaKodu: "ODA-" + yatakKodu},
+				YatisZamani:         clock.Now(),
+				KayitZamani:         clock.Now(),
+			})
+			h.yatan = true
+			h.sonYatak = yatakKodu
+		}
+		yatir("B1", "Y1")
+		clock.Advance(time.Second)
+		if _, err := u.Tara(); err != nil {
+			rt.Fatalf("Tara failed: %v", err)
+		}
+
+		beklenen := map[string]int{}
+		var sonucSayaci, bulguSayaci int
+		adimlar := rapid.IntRange(1, 25).Draw(rt, "adimlar")
+		for adim := 0; adim < adimlar; adim++ {
+			clock.Advance(time.Second)
+			now := clock.Now()
+			basvuruKodu := rapid.SampledFrom([]string{"B1", "B2", "B3"}).Draw(rt, "basvuru")
+			h := hastalar[basvuruKodu]
+			switch rapid.SampledFrom([]string{"yatis", "nakil", "cikis", "tetkik", "onay", "vital", "vitalGuncelleme", "yenidenBaslat"}).Draw(rt, "islem") {
+			case "yatis":
+				if h.yatan || h.cikti {
+					continue
+				}
+				yatir(basvuruKodu, fmt.Sprintf("Y%d", adim+10))
+				beklenen["ADT^A01"]++
+			case "nakil":
+				if !h.yatan {
+					continue
+				}
+				yeniYatak := fmt.Sprintf("Y%d", adim+10)
+				for i := range kaynak.yatanlar {
+					if kaynak.yatanlar[i].HastaBasvuruKodu != basvuruKodu {
+						continue
+					}
+					if rapid.Bool().Draw(rt, "yeniSatir") {
+						// VEM replaces the row of the stay
+						kaynak.yatanlar = append(kaynak.yatanlar[:i], kaynak.yatanlar[i+1:]...)
+						yatir(basvuruKodu, yeniYatak)
+					} else {
+						kaynak.yatanlar[i].YatakKodu = yeniYatak
+						kaynak.yatanlar[i].Yatak = &models.Yatak{YatakKodu: yeniYatak, BirimKodu: "DAHILIYE", OdaKodu: "ODA-" + yeniYatak}
+						h.sonYatak = yeniYatak
+					}
+					break
+				}
+				beklenen["ADT^A02"]++
+			case "cikis":
+				if !h.yatan {
+					continue
+				}
+				for i := range kaynak.yatanlar {
+					if kaynak.yatanlar[i].HastaBasvuruKodu == basvuruKodu {
+						kaynak.yatanlar = append(kaynak.yatanlar[:i], kaynak.yatanlar[i+1:]...)
+						break
+					}
+				}
+				b := kaynak.basvurular[basvuruKodu]
+				b.CikisZamani, b.GuncellemeZamani = &now, &now
+				h.yatan, h.cikti = false, true
+				beklenen["ADT^A03"]++
+			case "tetkik":
+				sonucSayaci++
+				deger := rapid.SampledFrom([]string{"12,5", "<0,01", "pozitif", "3.2 mmol/L"}).Draw(rt, "deger")
+				t := models.TetkikSonuc{
+					TetkikSonucKodu:  fmt.Sprintf("T%d", sonucSayaci),
+					HastaBasvuruKodu: basvuruKodu,
+					HastaBasvuru:     kaynak.basvurular[basvuruKodu],
+					TetkikAdi:        "Hemoglobin",
+					SonucDegeri:      &deger,
+					KayitZamani:      now,
+				}
+				if rapid.Bool().Draw(rt, "onayli") {
+					t.OnayZamani = &now
+				}
+				kaynak.sonuclar = append(kaynak.sonuclar, t)
+				beklenen["ORU^R01"]++
+			case "onay":
+				var onaysiz []int
+				for i := range kaynak.sonuclar {
+					if kaynak.sonuclar[i].OnayZamani == nil {
+						onaysiz = append(onaysiz, i)
+					}
+				}
+				if len(onaysiz) == 0 {
+					continue
+				}
+				kaynak.sonuclar[rapid.SampledFrom(onaysiz).Draw(rt, "sonuc")].OnayZamani = &now
+				beklenen["ORU^R01"]++
+			case "vital":
+				bulguSayaci++
+				nabiz, ates := "88", "38,2"
+				kaynak.bulgular = append(kaynak.bulgular, models.HastaVitalFizikiBulgu{
+					HastaVitalFizikiBulguKodu: fmt.Sprintf("V%d", bulguSayaci),
+					HastaBasvuruKodu:          basvuruKodu,
+					HastaBasvuru:              kaynak.basvurular[basvuruKodu],
+					IslemZamani:               now,
+					Nabiz:                     &nabiz,
+					Ates:                      &ates,
+					KayitZamani:               now,
+				})
+				beklenen["ORU^R01"]++
+			case "vitalGuncelleme":
+				if len(kaynak.bulgular) == 0 {
+					continue
+				}
+				kaynak.bulgular[rapid.IntRange(0, len(kaynak.bulgular)-1).Draw(rt, "bulgu")].GuncellemeZamani = &now
+				beklenen["ORU^R01"]++
+			case "yenidenBaslat":
+				u = yeni()
+			}
+			if _, err := u.Tara(); err != nil {
+				rt.Fatalf("Tara failed: %v", err)
+			}
+			// Polling again without changes queues nothing
+			if eklenen, err := u.Tara(); err != nil || eklenen != 0 {
+				rt.Fatalf("Repeated poll queued %d messages (%v)", eklenen, err)
+			}
+		}
+
+		// Restarting queues nothing that was queued before
+		if eklenen, err := yeni().Tara(); err != nil || eklenen != 0 {
+			rt.Fatalf("Restart queued %d messages again (%v)", eklenen, err)
+		}
+
+		for _, hedef := range hedefler {
+			turler := map[string]int{}
+			for _, m := range mesajRepo.hepsi() {
+				if m.Hedef != hedef.Ad {
+					continue
+				}
+				turler[m.MesajTuru]++
+				parsed, err := Parse([]byte(m.Icerik))
+				if err != nil {
+					rt.Fatalf("Queued message does not parse: %v", err)
+				}
+				msh := parsed.Segment("MSH")
+				if msh.Get(5, 1) != hedef.Ad || msh.Get(10, 1) != m.MesajKontrolKodu || msh.Get(9, 1)+"^"+msh.Get(9, 2) != m.MesajTuru {
+					rt.Fatalf("MSH %v does not match the queued message %+v", msh.Fields, m)
+				}
+				pid := parsed.Segment("PID")
+				h := hastalar[m.HastaBasvuruKodu]
+				if pid.Get(3, 1) != h.hasta.HastaKodu || pid.Get(5, 1) != h.hasta.Soyadi || pid.Get(5, 2) != h.hasta.Ad {
+					rt.Fatalf("PID %q does not identify patient %+v", pid.Fields, h.hasta)
+				}
+				if m.MesajTuru == "ADT^A03" && parsed.Segment("PV1").Get(3, 3) != h.sonYatak {
+					rt.Fatalf("Discharge from bed %q, want %q", parsed.Segment("PV1").Get(3, 3), h.sonYatak)
+				}
+			}
+			if !reflect.DeepEqual(turler, beklenen) && !(len(turler) == 0 && len(beklenen) == 0) {
+				rt.Fatalf("%s: queued %v, want %v", hedef.Ad, turler, beklenen)
+			}
+		}
+	})
+}
+
+// --- Examples ---
+
+func TestMesajlar_AdmissionAndVitalSigns(t *testing.T) {
+	birim, hekim, cinsiyet := "KARDIYOLOJI", "D1", "K"
+	kabul := time.Date(2026, 3, 1, 9, 30, 0, 0, time.FixedZone("TRT", 3*60*60))
+	hasta := &models.Hasta{HastaKodu: "H1", Ad: "Ayşe", Soyadi: "Yılmaz|Kaya", DogumTarihi: time.Date(1950, 5, 17, 0, 0, 0, 0, time.UTC), Cinsiyet: &cinsiyet}
+	yatan := &models.AnlikYatanHasta{
+		AnlikYatanHastaKodu: "AYH1", HastaBasvuruKodu: "B1", HastaKodu: "H1", Hasta: hasta,
+		YatakKodu: "Y7", Yatak: &models.Yatak{YatakKodu: "Y7", BirimKodu: "KARDIYOLOJI", OdaKodu: "304"},
+		BirimKodu: &birim, HekimKodu: &hekim, YatisZamani: kabul, KayitZamani: kabul,
+	}
+
+	m := NewMessage(YatisOlayi(yatan), Baslik{GonderenUygulama: "MEDSCREEN", AliciUygulama: "NURSECALL", Zaman: kabul, KontrolKodu: "C1", IslemeKodu: "P"})
+	encoded := string(m.Encode())
+	for _, segment := range []string{
+		"MSH|^~\\&|MEDSCREEN||NURSECALL||20260301093000+0300||ADT^A01^ADT_A01|C1|P|2.5||||||UNICODE UTF-8\r",
+		"EVN|A01|20260301093000+0300||||20260301093000+0300\r",
+		"PID|1||H1^^^VEM^PI||Yılmaz\\F\\Kaya^Ayşe||19500517|F\r",
+		"PV1|1|I|KARDIYOLOJI^304^Y7||||D1||||||||||||B1|||||||||||||||||||||||||20260301093000+0300\r",
+	} {
+		if !strings.Contains(encoded, segment) {
+			t.Errorf("Encoded admission misses %q:\n%q", segment, encoded)
+		}
+	}
+
+	nabiz, sistolik, diastolik, ates := "112", "90", "60", "hatalı"
+	bulgu := &models.HastaVitalFizikiBulgu{
+		HastaVitalFizikiBulguKodu: "V1", HastaBasvuruKodu: "B1", HastaBasvuru: &models.HastaBasvuru{HastaKodu: "H1", Hasta: hasta},
+		IslemZamani: kabul, KayitZamani: kabul, Nabiz: &nabiz, SistolikKanBasinciDegeri: &sistolik, DiastolikKanBasinciDegeri: &diastolik, Ates: &ates,
+	}
+	bulgu.ParseSayisalDegerler()
+	olay, ok := VitalOlayi(bulgu, yatan)
+	if !ok {
+		t.Fatalf("Vital signs produced no message")
+	}
+	parsed, err := Parse(NewMessage(olay, Baslik{KontrolKodu: "C2"}).Encode())
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	var kodlar []string
+	for _, s := range parsed.Segments {
+		if s.Name == "OBX" {
+			kodlar = append(kodlar, s.Get(3, 1)+"="+s.Get(5, 1)+" "+s.Get(6, 1))
+		}
+	}
+	// The temperature could not be parsed and is left out
+	want := []string{"8867-4=112 /min", "8480-6=90 mm[Hg]", "8462-4=60 mm[Hg]"}
+	sort.Strings(kodlar)
+	sort.Strings(want)
+	if !reflect.DeepEqual(kodlar, want) {
+		t.Errorf("OBX segments %v, want %v", kodlar, want)
+	}
+	if got := parsed.Segment("PV1").Get(3, 3); got != "Y7" {
+		t.Errorf("PV1-3 bed = %q, want Y7", got)
+	}
+}
+
+func TestMesajlar_TestResultFlags(t *testing.T) {
+	deger, aralik := "2,1", "3,5-5,1"
+	onay := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	sonuc := &models.TetkikSonuc{
+		TetkikSonucKodu: "T1", HastaBasvuruKodu: "B1", TetkikAdi: "Potasyum",
+		SonucDegeri: &deger, KritikDegerAraligi: &aralik, KayitZamani: onay.Add(-time.Hour), OnayZamani: &onay,
+		Degerlendirme: &models.TetkikDegerlendirmesi{Durum: models.TetkikDurumuDusuk, Kritik: true},
+	}
+	sonuc.ParseSayisalDegerler()
+	parsed, err := Parse(NewMessage(TetkikOlayi(sonuc, nil), Baslik{KontrolKodu: "C1"}).Encode())
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	obx := parsed.Segment("OBX")
+	if obx.Get(2, 1) != "NM" || obx.Get(5, 1) != "2.1" || obx.Get(7, 1) != aralik || obx.Get(8, 1) != "LL" || obx.Get(11, 1) != "F" {
+		t.Errorf("Unexpected OBX %q", obx.Fields)
+	}
+	if got := parsed.Segment("PV1").Get(2, 1); got != "O" {
+		t.Errorf("PV1-2 = %q for a visit without a stay, want O", got)
+	}
+}
+
+func TestParseAck(t *testing.T) {
+	ack, err := ParseAck([]byte("MSH|^~\\&|MON||MEDSCREEN||20260301||ACK|9|P|2.5\rMSA|AE|C1\rERR|||207|E||||Hasta \\T\\ yatak bulunamadı\r"))
+	if err != nil {
+		t.Fatalf("ParseAck failed: %v", err)
+	}
+	if ack.Code != AckError || ack.ControlID != "C1" || ack.Text != "Hasta & yatak bulunamadı" || ack.Retryable() || ack.Accepted() {
+		t.Errorf("Unexpected acknowledgement %+v", ack)
+	}
+	if _, err := ParseAck([]byte("MSH|^~\\&|MON\r")); err == nil {
+		t.Errorf("Acknowledgement without MSA was accepted")
+	}
+}
+
+func TestEscape_MLLPBlockCharacters(t *testing.T) {
+	for deger, want := range map[string]string{
+		"\x0b":              `\X0B\`,
+		"\x1c":              `\X1C\`,
+		"\x1c|":             `\X1C\\F\`,
+		"Ali\x0bVeli\x1c\r": `Ali\X0B\Veli\X1C\\X0D\`,
+	} {
+		if got := Escape(deger); got != want {
+			t.Errorf("Escape(%q) = %q, want %q", deger, got, want)
+		}
+		if got := Unescape(want); got != deger {
+			t.Errorf("Unescape(%q) = %q, want %q", want, got, deger)
+		}
+
+		m := (&Message{}).Add(NewSegment("MSH").Set(3, "MEDSCREEN"), NewSegment("NTE").Set(3, deger))
+		var frame bytes.Buffer
+		if err := WriteFrame(&frame, m.Encode()); err != nil {
+			t.Fatalf("WriteFrame failed: %v", err)
+		}
+		data, err := ReadFrame(bufio.NewReader(&frame))
+		if err != nil || frame.Len() > 0 {
+			t.Fatalf("Frame with %q did not read back whole: %v, %d bytes left", deger, err, frame.Len())
+		}
+		parsed, err := Parse(data)
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		if got := parsed.Segment("NTE").Get(3, 1); got != deger {
+			t.Errorf("NTE-3 = %q, want %q", got, deger)
+		}
+	}
+}
+
+func TestReadFrame_Invalid(t *testing.T) {
+	for _, frame := range []string{"MSH|no start block\x1c\r", "\x0bMSH|no carriage return\x1cX", "\x0bMSH|truncated"} {
+		if _, err := ReadFrame(bufio.NewReader(strings.NewReader(frame))); err == nil {
+			t.Errorf("ReadFrame(%q) succeeded", frame)
+		}
+	}
+}
+
+func TestGonderici_Backoff(t *testing.T) {
+	g := &Gonderici{ayarlar: Ayarlar{RetryBase: 10 * time.Second, RetryMax: time.Minute}}
+	for deneme, want := range map[int]time.Duration{1: 10 * time.Second, 2: 20 * time.Second, 3: 40 * time.Second, 4: time.Minute, 30: time.Minute} {
+		if got := g.bekleme(deneme); got != want {
+			t.Errorf("bekleme(%d) = %s, want %s", deneme, got, want)
+		}
+	}
+}
+
+func TestParseHedefler(t *testing.T) {
+	hedefler, err := ParseHedefler([]string{"MONITOR=10.0.0.5:2575", " NURSECALL = nurse.local:6661 "})
+	if err != nil {
+		t.Fatalf("ParseHedefler failed: %v", err)
+	}
+	want := []Hedef{{Ad: "MONITOR", Adres: "10.0.0.5:2575"}, {Ad: "NURSECALL", Adres: "nurse.local:6661"}}
+	if !reflect.DeepEqual(hedefler, want) {
+		t.Errorf("ParseHedefler = %+v, want %+v", hedefler, want)
+	}
+	for _, list := range [][]string{{"10.0.0.5:2575"}, {"MONITOR=10.0.0.5"}, {"A=h:1", "A=h:2"}} {
+		if _, err := ParseHedefler(list); err == nil {
+			t.Errorf("ParseHedefler(%q) succeeded", list)
+		}
+	}
+}
+
+// TestFeed_DeliversOverMLLP runs the feed end to end against a local MLLP endpoint
+func TestFeed_DeliversOverMLLP(t *testing.T) {
+	d := yeniDinleyici(t, func(*Message) string { return AckAccept })
+	defer d.kapat()
+
+	clock := &fakeClock{now: time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)}
+	hasta := models.Hasta{HastaKodu: "H1", Ad: "Ali", Soyadi: "Demir"}
+	kaynak := &memKaynakRepo{basvurular: map[string]*models.HastaBasvuru{"B1": {HastaBasvuruKodu: "B1", HastaKodu: "H1", Hasta: &hasta}}}
+	mesajRepo := &memMesajRepo{}
+	hedef := Hedef{Ad: "MONITOR", Adres: d.ln.Addr().String()}
+	u := NewUretici(kaynak, mesajRepo, []Hedef{hedef}, Basliklar{GonderenUygulama: "MEDSCREEN", IslemeKodu: "T"}, nil, clock, time.Second)
+	if _, err := u.Tara(); err != nil {
+		t.Fatalf("Tara failed: %v", err)
+	}
+
+	clock.Advance(time.Second)
+	kaynak.yatanlar = []models.AnlikYatanHasta{{AnlikYatanHastaKodu: "AYH1", HastaBasvuruKodu: "B1", HastaKodu: "H1", Hasta: &hasta, YatakKodu: "Y1", KayitZamani: clock.Now(), YatisZamani: clock.Now()}}
+	nabiz := "72"
+	kaynak.bulgular = []models.HastaVitalFizikiBulgu{{HastaVitalFizikiBulguKodu: "V1", HastaBasvuruKodu: "B1", HastaBasvuru: kaynak.basvurular["B1"], IslemZamani: clock.Now(), KayitZamani: clock.Now().Add(time.Millisecond), Nabiz: &nabiz}}
+	if eklenen, err := u.Tara(); err != nil || eklenen != 2 {
+		t.Fatalf("Tara queued %d messages (%v), want 2", eklenen, err)
+	}
+
+	g := NewGonderici(hedef, mesajRepo, Ayarlar{PollInterval: time.Second, AckTimeout: 5 * time.Second, RetryBase: time.Second, RetryMax: time.Second, MaxDeneme: 3}, clock)
+	defer g.client.Close()
+	for i := 0; i < 2; i++ {
+		if ilerledi, err := g.Gonder(context.Background()); err != nil || !ilerledi {
+			t.Fatalf("Gonder = %v, %v", ilerledi, err)
+		}
+	}
+	if ilerledi, err := g.Gonder(context.Background()); err != nil || ilerledi {
+		t.Fatalf("Gonder on an empty queue = %v, %v", ilerledi, err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.alinan) != 2 || d.baglanti != 1 {
+		t.Fatalf("Endpoint received %d messages over %d connections, want 2 over 1", len(d.alinan), d.baglanti)
+	}
+	for i, want := range []string{"ADT^A01", "ORU^R01"} {
+		msh := d.alinan[i].Segment("MSH")
+		if got := msh.Get(9, 1) + "^" + msh.Get(9, 2); got != want || msh.Get(11, 1) != "T" {
+			t.Errorf("Message %d is %s (processing %s), want %s", i, got, msh.Get(11, 1), want)
+		}
+	}
+	for _, m := range mesajRepo.hepsi() {
+		if m.Durum != models.HL7MesajGonderildi || m.DenemeSayisi != 1 {
+			t.Errorf("Message %s is %s after %d attempts", m.MesajKontrolKodu, m.Durum, m.DenemeSayisi)
+		}
+	}
+}
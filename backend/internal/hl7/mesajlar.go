@@ -0,0 +1,353 @@
+package hl7
+
+import (
+	"medscreen/internal/measurement"
+	"medscreen/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Olay is a VEM change the feed sends a message for: its segments after MSH, which is
+// added per endpoint
+type Olay struct {
+	// Anahtar identifies the change, so it is queued once per endpoint
+	Anahtar string
+	// Tur is MSH-9: message code, trigger event and message structure
+	Tur              [3]string
+	HastaBasvuruKodu string
+	// Zaman is the change's watermark
+	Zaman      time.Time
+	Segmentler []*Segment
+}
+
+// MesajTuru is the message code and trigger event, e.g. "ADT^A01"
+func (o Olay) MesajTuru() string {
+	return o.Tur[0] + "^" + o.Tur[1]
+}
+
+// Message types of the feed
+var (
+	turA01 = [3]string{"ADT", "A01", "ADT_A01"}
+	turA02 = [3]string{"ADT", "A02", "ADT_A02"}
+	turA03 = [3]string{"ADT", "A03", "ADT_A03"}
+	turR01 = [3]string{"ORU", "R01", "ORU_R01"}
+)
+
+// Baslik holds the MSH values that are not part of the change
+type Baslik struct {
+	GonderenUygulama string
+	GonderenKurum    string
+	AliciUygulama    string
+	Zaman            time.Time
+	KontrolKodu      string
+	// IslemeKodu is MSH-11: P (production), T (training) or D (debugging)
+	IslemeKodu string
+}
+
+// NewMessage adds the MSH segment for an endpoint to the segments of a change
+func NewMessage(olay Olay, baslik Baslik) *Message {
+	msh := NewSegment("MSH").
+		Set(3, baslik.GonderenUygulama).
+		Set(4, baslik.GonderenKurum).
+		Set(5, baslik.AliciUygulama).
+		Set(7, dtm(baslik.Zaman)).
+		Set(9, olay.Tur[:]...).
+		Set(10, baslik.KontrolKodu).
+		Set(11, baslik.IslemeKodu).
+		Set(12, Version).
+		Set(18, "UNICODE UTF-8")
+	return (&Message{}).Add(msh).Add(olay.Segmentler...)
+}
+
+// Konum is where an inpatient lies: ward, room and bed (PV1-3, PV1-6)
+type Konum struct {
+	BirimKodu string
+	OdaKodu   string
+	YatakKodu string
+}
+
+// KonumOf returns the location of an inpatient
+func KonumOf(y *models.AnlikYatanHasta) Konum {
+	k := Konum{YatakKodu: y.YatakKodu}
+	if y.BirimKodu != nil {
+		k.BirimKodu = *y.BirimKodu
+	}
+	if y.Yatak != nil {
+		k.OdaKodu = y.Yatak.OdaKodu
+		if k.BirimKodu == "" {
+			k.BirimKodu = y.Yatak.BirimKodu
+		}
+	}
+	return k
+}
+
+func (k Konum) field() []string {
+	return []string{k.BirimKodu, k.OdaKodu, k.YatakKodu}
+}
+
+// YatisOlayi is the admission of an inpatient (ADT^A01)
+func YatisOlayi(y *models.AnlikYatanHasta) Olay {
+	return Olay{
+		Anahtar:          "A01/" + y.AnlikYatanHastaKodu,
+		Tur:              turA01,
+		HastaBasvuruKodu: y.HastaBasvuruKodu,
+		Zaman:            y.KayitZamani,
+		Segmentler: []*Segment{
+			evn("A01", y.KayitZamani, y.YatisZamani),
+			pid(y.HastaKodu, y.Hasta),
+			yatanPV1(y).Set(44, dtm(y.YatisZamani)),
+		},
+	}
+}
+
+// NakilOlayi is the transfer of an inpatient from another bed (ADT^A02). It is detected
+// when it happens, so tespit identifies it among the transfers of the stay.
+func NakilOlayi(y *models.AnlikYatanHasta, onceki Konum, tespit time.Time) Olay {
+	return Olay{
+		Anahtar:          "A02/" + y.HastaBasvuruKodu + "/" + onceki.YatakKodu + "/" + y.YatakKodu + "/" + strconv.FormatInt(tespit.UnixNano(), 10),
+		Tur:              turA02,
+		HastaBasvuruKodu: y.HastaBasvuruKodu,
+		Zaman:            tespit,
+		Segmentler: []*Segment{
+			evn("A02", tespit, time.Time{}),
+			pid(y.HastaKodu, y.Hasta),
+			yatanPV1(y).Set(6, onceki.field()...).Set(44, dtm(y.YatisZamani)),
+		},
+	}
+}
+
+// CikisOlayi is the discharge of a visit (ADT^A03). sonYatis is where the patient lay
+// before, or nil if the visit is not known as an inpatient stay.
+func CikisOlayi(b *models.HastaBasvuru, sonYatis *models.AnlikYatanHasta) Olay {
+	cikis := *b.CikisZamani
+	var pv1 *Segment
+	if sonYatis != nil {
+		pv1 = yatanPV1(sonYatis).Set(44, dtm(sonYatis.YatisZamani))
+	} else {
+		pv1 = basvuruPV1(b.HastaBasvuruKodu, b, nil).Set(44, dtm(b.HastaKabulZamani))
+	}
+	pv1.Set(45, dtm(cikis))
+	return Olay{
+		Anahtar:          "A03/" + b.HastaBasvuruKodu + "/" + strconv.FormatInt(cikis.UnixNano(), 10),
+		Tur:              turA03,
+		HastaBasvuruKodu: b.HastaBasvuruKodu,
+		Zaman:            watermark(cikis, b.GuncellemeZamani),
+		Segmentler: []*Segment{
+			evn("A03", watermark(cikis, b.GuncellemeZamani), cikis),
+			pid(b.HastaKodu, b.Hasta),
+			pv1,
+		},
+	}
+}
+
+// TetkikOlayi is a test result (ORU^R01), preliminary until it is approved. Its
+// Degerlendirme, if set, gives the abnormal flag. yatan is the inpatient stay of the
+// visit, or nil.
+func TetkikOlayi(t *models.TetkikSonuc, yatan *models.AnlikYatanHasta) Olay {
+	durum := "P"
+	zaman := t.KayitZamani
+	if t.OnayZamani != nil {
+		durum = "F"
+		zaman = watermark(t.KayitZamani, t.OnayZamani)
+	}
+	tetkik := []string{t.TetkikAdi, t.TetkikAdi, "L"}
+
+	obr := NewSegment("OBR").Set(1, "1").Set(3, t.TetkikSonucKodu).Set(4, tetkik...).
+		Set(7, dtm(t.KayitZamani)).Set(22, dtm(zaman)).Set(25, durum)
+
+	obx := NewSegment("OBX").Set(1, "1").Set(3, tetkik...).Set(11, durum).Set(14, dtm(t.KayitZamani))
+	if s := t.SonucDegeriSayisal; s.Gecerli() && s.Isaret == "" {
+		obx.Set(2, "NM").Set(5, sayi(*s.Deger)).Set(6, s.Birim)
+	} else {
+		obx.Set(2, "ST").Set(5, deref(t.SonucDegeri))
+		if s != nil {
+			obx.Set(6, s.Birim)
+		}
+	}
+	obx.Set(7, deref(t.KritikDegerAraligi))
+	if t.Degerlendirme != nil {
+		obx.Set(8, anormallik(t.Degerlendirme))
+	}
+
+	hastaKodu, hasta := "", (*models.Hasta)(nil)
+	if t.HastaBasvuru != nil {
+		hastaKodu, hasta = t.HastaBasvuru.HastaKodu, t.HastaBasvuru.Hasta
+	}
+	return Olay{
+		Anahtar:          "R01/tetkik/" + t.TetkikSonucKodu + "/" + strconv.FormatInt(zaman.UnixNano(), 10),
+		Tur:              turR01,
+		HastaBasvuruKodu: t.HastaBasvuruKodu,
+		Zaman:            zaman,
+		Segmentler:       []*Segment{pid(hastaKodu, hasta), basvuruPV1(t.HastaBasvuruKodu, t.HastaBasvuru, yatan), obr, obx},
+	}
+}
+
+// vitalBulgu is a measurement of a vital signs record, its LOINC code and UCUM unit
+type vitalBulgu struct {
+	loinc, ad   string
+	birim, ucum string // unit MedScreen stores the measurement in, and its UCUM code
+	deger       func(*models.HastaVitalFizikiBulgu) *measurement.Sayisal
+}
+
+var vitalBulgular = []vitalBulgu{
+	{"8310-5", "Body temperature", "°C", "Cel", func(b *models.HastaVitalFizikiBulgu) *measurement.Sayisal { return b.AtesSayisal }},
+	{"8867-4", "Heart rate", "/dk", "/min", func(b *models.HastaVitalFizikiBulgu) *measurement.Sayisal { return b.NabizSayisal }},
+	{"8480-6", "Systolic blood pressure", "mmHg", "mm[Hg]", func(b *models.HastaVitalFizikiBulgu) *measurement.Sayisal { return b.SistolikKanBasinciDegeriSayisal }},
+	{"8462-4", "Diastolic blood pressure", "mmHg", "mm[Hg]", func(b *models.HastaVitalFizikiBulgu) *measurement.Sayisal { return b.DiastolikKanBasinciDegeriSayisal }},
+	{"9279-1", "Respiratory rate", "/dk", "/min", func(b *models.HastaVitalFizikiBulgu) *measurement.Sayisal { return b.SolunumSayisal }},
+	{"59408-5", "Oxygen saturation in Arterial blood by Pulse oximetry", "%", "%", func(b *models.HastaVitalFizikiBulgu) *measurement.Sayisal { return b.SaturasyonSayisal }},
+	{"8302-2", "Body height", "cm", "cm", func(b *models.HastaVitalFizikiBulgu) *measurement.Sayisal { return b.BoySayisal }},
+	{"29463-7", "Body weight", "kg", "kg", func(b *models.HastaVitalFizikiBulgu) *measurement.Sayisal { return b.AgirlikSayisal }},
+}
+
+// VitalOlayi is a vital signs record (ORU^R01) with an OBX per measurement; an updated
+// record is sent again as a correction. Measurements that could not be parsed are left out;
+// ok is false when none is left. The measurements must have been parsed
+// (HastaVitalFizikiBulgu.ParseSayisalDegerler).
+func VitalOlayi(b *models.HastaVitalFizikiBulgu, yatan *models.AnlikYatanHasta) (olay Olay, ok bool) {
+	durum := "F"
+	zaman := b.KayitZamani
+	if b.GuncellemeZamani != nil && b.GuncellemeZamani.After(b.KayitZamani) {
+		durum = "C"
+		zaman = *b.GuncellemeZamani
+	}
+
+	var obxler []*Segment
+	for _, bulgu := range vitalBulgular {
+		deger := bulgu.deger(b)
+		if !deger.Gecerli() {
+			continue
+		}
+		birim := []string{"", deger.Birim}
+		if deger.Birim == bulgu.birim {
+			birim = []string{bulgu.ucum, bulgu.birim, "UCUM"}
+		}
+		obx := NewSegment("OBX").Set(1, strconv.Itoa(len(obxler)+1)).Set(2, "NM").
+			Set(3, bulgu.loinc, bulgu.ad, "LN").Set(5, sayi(*deger.Deger)).Set(6, birim...).
+			Set(11, durum).Set(14, dtm(b.IslemZamani)).Set(16, deref(b.HemsireKodu))
+		obxler = append(obxler, obx)
+	}
+	if len(obxler) == 0 {
+		return Olay{}, false
+	}
+
+	obr := NewSegment("OBR").Set(1, "1").Set(3, b.HastaVitalFizikiBulguKodu).
+		Set(4, "85353-1", "Vital signs, weight, height, head circumference, oxygen saturation and BMI panel", "LN").
+		Set(7, dtm(b.IslemZamani)).Set(22, dtm(zaman)).Set(25, durum)
+
+	hastaKodu, hasta := "", (*models.Hasta)(nil)
+	if b.HastaBasvuru != nil {
+		hastaKodu, hasta = b.HastaBasvuru.HastaKodu, b.HastaBasvuru.Hasta
+	}
+	return Olay{
+		Anahtar:          "R01/vital/" + b.HastaVitalFizikiBulguKodu + "/" + strconv.FormatInt(zaman.UnixNano(), 10),
+		Tur:              turR01,
+		HastaBasvuruKodu: b.HastaBasvuruKodu,
+		Zaman:            zaman,
+		Segmentler:       append([]*Segment{pid(hastaKodu, hasta), basvuruPV1(b.HastaBasvuruKodu, b.HastaBasvuru, yatan), obr}, obxler...),
+	}, true
+}
+
+func evn(tetik string, kayit, gerceklesme time.Time) *Segment {
+	return NewSegment("EVN").Set(1, tetik).Set(2, dtm(kayit)).Set(6, dtm(gerceklesme))
+}
+
+// pid identifies the patient by the VEM patient code. The national id is left out: the
+// receiving systems do not need it to match their own records.
+func pid(hastaKodu string, h *models.Hasta) *Segment {
+	s := NewSegment("PID").Set(1, "1").Set(3, hastaKodu, "", "", "VEM", "PI")
+	if h != nil {
+		s.Set(5, h.Soyadi, h.Ad).Set(7, dt(h.DogumTarihi)).Set(8, cinsiyet(h.Cinsiyet))
+	}
+	return s
+}
+
+// yatanPV1 describes an inpatient stay
+func yatanPV1(y *models.AnlikYatanHasta) *Segment {
+	hekimKodu := deref(y.HekimKodu)
+	if hekimKodu == "" && y.HastaBasvuru != nil {
+		hekimKodu = deref(y.HastaBasvuru.HekimKodu)
+	}
+	return NewSegment("PV1").Set(1, "1").Set(2, "I").Set(3, KonumOf(y).field()...).
+		Set(7, hekimKodu).Set(19, y.HastaBasvuruKodu)
+}
+
+// basvuruPV1 describes the visit of a result: the inpatient stay if there is one
+func basvuruPV1(basvuruKodu string, b *models.HastaBasvuru, yatan *models.AnlikYatanHasta) *Segment {
+	if yatan != nil {
+		return yatanPV1(yatan)
+	}
+	s := NewSegment("PV1").Set(1, "1").Set(2, "O").Set(19, basvuruKodu)
+	if b != nil {
+		s.Set(7, deref(b.HekimKodu))
+	}
+	return s
+}
+
+// anormallik maps the evaluation of a test result to an abnormal flag (OBX-8)
+func anormallik(d *models.TetkikDegerlendirmesi) string {
+	switch d.Durum {
+	case models.TetkikDurumuNormal:
+		return "N"
+	case models.TetkikDurumuDusuk:
+		if d.Kritik {
+			return "LL"
+		}
+		return "L"
+	case models.TetkikDurumuYuksek:
+		if d.Kritik {
+			return "HH"
+		}
+		return "H"
+	}
+	return ""
+}
+
+// cinsiyet maps the VEM cinsiyet codes (E, K) to the HL7 administrative sex
+func cinsiyet(c *string) string {
+	switch strings.ToUpper(deref(c)) {
+	case "":
+		return ""
+	case "E":
+		return "M"
+	case "K":
+		return "F"
+	}
+	return "U"
+}
+
+// dtm formats a timestamp (DTM) with its UTC offset; the zero time is left empty
+func dtm(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("20060102150405-0700")
+}
+
+// dt formats a date (DT)
+func dt(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("20060102")
+}
+
+func sayi(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// watermark is the latest of a row's creation and update times
+func watermark(kayit time.Time, guncelleme *time.Time) time.Time {
+	if guncelleme != nil && guncelleme.After(kayit) {
+		return *guncelleme
+	}
+	return kayit
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,273 @@
+// Package hl7 feeds VEM 2.0 changes to the legacy monitoring and nurse-call systems as
+// HL7 v2.5 messages over MLLP: admissions, transfers and discharges (ADT^A01, A02, A03)
+// and test results and vital signs (ORU^R01).
+//
+// Like the event streams (package stream), changes are detected by polling watermarks, as
+// VEM 2.0 is read-only for MedScreen. Uretici renders the messages into an outbox
+// (medscreen.hl7_mesaj) and one Gonderici per endpoint delivers them in order, waiting for
+// the acknowledgement of each. Messages that are rejected or run out of attempts stay in
+// the outbox as dead letters.
+package hl7
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is the HL7 version the messages declare in MSH-12
+const Version = "2.5"
+
+// Delimiters of the encoded messages (MSH-1 and MSH-2)
+const (
+	FieldSeparator        = '|'
+	ComponentSeparator    = '^'
+	RepetitionSeparator   = '~'
+	EscapeCharacter       = '\\'
+	SubcomponentSeparator = '&'
+
+	EncodingCharacters = "^~\\&"
+	segmentTerminator  = '\r'
+)
+
+// Field is a field of a segment as its components. The feed does not send repetitions or
+// subcomponents; delimiters in component values are escaped.
+type Field []string
+
+// Segment is a segment of a message. Fields[0] is field 1; for MSH that is the field
+// separator and Fields[1] the encoding characters, so field numbers match the standard.
+type Segment struct {
+	Name   string
+	Fields []Field
+}
+
+// NewSegment creates an empty segment
+func NewSegment(name string) *Segment {
+	s := &Segment{Name: name}
+	if name == "MSH" {
+		s.Fields = []Field{{string(FieldSeparator)}, {EncodingCharacters}}
+	}
+	return s
+}
+
+// Set sets field n (1-based) to the given components
+func (s *Segment) Set(n int, components ...string) *Segment {
+	for len(s.Fields) < n {
+		s.Fields = append(s.Fields, nil)
+	}
+	s.Fields[n-1] = Field(components)
+	return s
+}
+
+// Get returns component c of field n (both 1-based), or "" if it is absent
+func (s *Segment) Get(n, c int) string {
+	if n < 1 || n > len(s.Fields) || c < 1 || c > len(s.Fields[n-1]) {
+		return ""
+	}
+	return s.Fields[n-1][c-1]
+}
+
+// Message is an HL7 v2 message
+type Message struct {
+	Segments []*Segment
+}
+
+// Add appends segments to the message
+func (m *Message) Add(segments ...*Segment) *Message {
+	m.Segments = append(m.Segments, segments...)
+	return m
+}
+
+// Segment returns the first segment with the given name, or nil
+func (m *Message) Segment(name string) *Segment {
+	for _, s := range m.Segments {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Encode renders the message in the standard encoding, segments terminated by a carriage
+// return. Trailing empty fields and components are left out.
+func (m *Message) Encode() []byte {
+	var b strings.Builder
+	for _, s := range m.Segments {
+		b.WriteString(s.Name)
+		first := 0
+		if s.Name == "MSH" {
+			b.WriteByte(FieldSeparator)
+			b.WriteString(EncodingCharacters)
+			first = 2
+		}
+		fields := s.Fields
+		for len(fields) > first && isEmpty(fields[len(fields)-1]) {
+			fields = fields[:len(fields)-1]
+		}
+		for i := first; i < len(fields); i++ {
+			b.WriteByte(FieldSeparator)
+			components := fields[i]
+			for len(components) > 0 && components[len(components)-1] == "" {
+				components = components[:len(components)-1]
+			}
+			for j, component := range components {
+				if j > 0 {
+					b.WriteByte(ComponentSeparator)
+				}
+				b.WriteString(Escape(component))
+			}
+		}
+		b.WriteByte(segmentTerminator)
+	}
+	return []byte(b.String())
+}
+
+func isEmpty(f Field) bool {
+	for _, component := range f {
+		if component != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// delimiters are the separators a message declares in MSH-1 and MSH-2
+type delimiters struct {
+	field, component, repetition, escape, subcomponent byte
+}
+
+// Parse decodes a message in the delimiters its MSH segment declares. Only the first
+// repetition of a field is kept, and subcomponents are not split.
+func Parse(data []byte) (*Message, error) {
+	text := strings.NewReplacer("\r\n", "\r", "\n", "\r").Replace(string(data))
+	if !strings.HasPrefix(text, "MSH") || len(text) < 8 {
+		return nil, errors.New("hl7: message does not start with an MSH segment")
+	}
+	d := delimiters{field: text[3], component: text[4], repetition: text[5], escape: text[6], subcomponent: text[7]}
+
+	m := &Message{}
+	for _, line := range strings.Split(text, "\r") {
+		if line == "" {
+			continue
+		}
+		parts := strings.Split(line, string(d.field))
+		s := &Segment{Name: parts[0]}
+		if len(s.Name) != 3 {
+			return nil, fmt.Errorf("hl7: invalid segment name %q", s.Name)
+		}
+		values := parts[1:]
+		if s.Name == "MSH" {
+			if len(values) == 0 {
+				return nil, errors.New("hl7: MSH segment without encoding characters")
+			}
+			s.Fields = []Field{{string(d.field)}, {values[0]}}
+			values = values[1:]
+		}
+		for _, value := range values {
+			if i := strings.IndexByte(value, d.repetition); i >= 0 {
+				value = value[:i]
+			}
+			var f Field
+			for _, component := range strings.Split(value, string(d.component)) {
+				f = append(f, d.unescape(component))
+			}
+			s.Fields = append(s.Fields, f)
+		}
+		m.Segments = append(m.Segments, s)
+	}
+	return m, nil
+}
+
+// Escape escapes the delimiters, line breaks and MLLP block characters in a value, so a
+// value can neither end its segment nor its frame
+func Escape(value string) string {
+	if !strings.ContainsAny(value, "|^~\\&\r\n\x0b\x1c") {
+		return value
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case FieldSeparator:
+			b.WriteString(`\F\`)
+		case ComponentSeparator:
+			b.WriteString(`\S\`)
+		case RepetitionSeparator:
+			b.WriteString(`\R\`)
+		case EscapeCharacter:
+			b.WriteString(`\E\`)
+		case SubcomponentSeparator:
+			b.WriteString(`\T\`)
+		case '\r':
+			b.WriteString(`\X0D\`)
+		case '\n':
+			b.WriteString(`\X0A\`)
+		case startBlock:
+			b.WriteString(`\X0B\`)
+		case endBlock:
+			b.WriteString(`\X1C\`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// Unescape reverses Escape for a value in the standard encoding. Escape sequences it does
+// not know (formatting, character sets) are kept as they are.
+func Unescape(value string) string {
+	return delimiters{FieldSeparator, ComponentSeparator, RepetitionSeparator, EscapeCharacter, SubcomponentSeparator}.unescape(value)
+}
+
+func (d delimiters) unescape(value string) string {
+	if strings.IndexByte(value, d.escape) < 0 {
+		return value
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != d.escape {
+			b.WriteByte(value[i])
+			continue
+		}
+		end := strings.IndexByte(value[i+1:], d.escape)
+		if end < 0 {
+			b.WriteString(value[i:])
+			break
+		}
+		sequence := value[i+1 : i+1+end]
+		if decoded, ok := d.decode(sequence); ok {
+			b.WriteString(decoded)
+		} else {
+			b.WriteString(value[i : i+end+2])
+		}
+		i += end + 1
+	}
+	return b.String()
+}
+
+func (d delimiters) decode(sequence string) (string, bool) {
+	switch sequence {
+	case "F":
+		return string(d.field), true
+	case "S":
+		return string(d.component), true
+	case "R":
+		return string(d.repetition), true
+	case "E":
+		return string(d.escape), true
+	case "T":
+		return string(d.subcomponent), true
+	}
+	if len(sequence) > 1 && sequence[0] == 'X' && len(sequence)%2 == 1 {
+		var b []byte
+		for i := 1; i < len(sequence); i += 2 {
+			v, err := strconv.ParseUint(sequence[i:i+2], 16, 8)
+			if err != nil {
+				return "", false
+			}
+			b = append(b, byte(v))
+		}
+		return string(b), true
+	}
+	return "", false
+}
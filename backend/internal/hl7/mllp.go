@@ -0,0 +1,141 @@
+package hl7
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// MLLP block characters: a frame is <VT> message <FS><CR>
+const (
+	startBlock     = 0x0b
+	endBlock       = 0x1c
+	carriageReturn = 0x0d
+)
+
+// maxFrameSize bounds a frame read from a peer
+const maxFrameSize = 1 << 20
+
+// ErrFrame is returned for data that is not a valid MLLP frame
+var ErrFrame = errors.New("mllp: invalid frame")
+
+// WriteFrame writes a message in an MLLP frame
+func WriteFrame(w io.Writer, message []byte) error {
+	frame := make([]byte, 0, len(message)+3)
+	frame = append(frame, startBlock)
+	frame = append(frame, message...)
+	frame = append(frame, endBlock, carriageReturn)
+	_, err := w.Write(frame)
+	return err
+}
+
+// ReadFrame reads the message of the next MLLP frame. Whitespace between frames is skipped.
+func ReadFrame(r *bufio.Reader) ([]byte, error) {
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if c == startBlock {
+			break
+		}
+		if c != '\r' && c != '\n' && c != ' ' && c != '\t' {
+			return nil, fmt.Errorf("%w: unexpected byte 0x%02x before start block", ErrFrame, c)
+		}
+	}
+
+	var message bytes.Buffer
+	for {
+		chunk, err := r.ReadSlice(endBlock)
+		if len(chunk) > 0 {
+			message.Write(chunk)
+		}
+		if message.Len() > maxFrameSize {
+			return nil, fmt.Errorf("%w: larger than %d bytes", ErrFrame, maxFrameSize)
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		break
+	}
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if c != carriageReturn {
+		return nil, fmt.Errorf("%w: end block not followed by a carriage return", ErrFrame)
+	}
+	return message.Bytes()[:message.Len()-1], nil
+}
+
+// Client sends messages to an MLLP endpoint over a single connection and reads their
+// acknowledgements. It is not safe for concurrent use.
+type Client struct {
+	addr    string
+	timeout time.Duration
+	dialer  net.Dialer
+	conn    net.Conn
+	reader  *bufio.Reader
+}
+
+// NewClient creates a Client; timeout bounds connecting and each exchange
+func NewClient(addr string, timeout time.Duration) *Client {
+	return &Client{addr: addr, timeout: timeout, dialer: net.Dialer{Timeout: timeout}}
+}
+
+// Send delivers a message and returns the acknowledgement the endpoint answered with.
+// The connection is opened on first use and kept open; it is closed after any error, so
+// the next Send connects again.
+func (c *Client) Send(ctx context.Context, message []byte) ([]byte, error) {
+	if c.conn == nil {
+		conn, err := c.dialer.DialContext(ctx, "tcp", c.addr)
+		if err != nil {
+			return nil, err
+		}
+		c.conn = conn
+		c.reader = bufio.NewReader(conn)
+	}
+
+	// Cancelling ctx interrupts the wait for the acknowledgement
+	stop := context.AfterFunc(ctx, func() { _ = c.conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		c.Close()
+		return nil, err
+	}
+	if err := WriteFrame(c.conn, message); err != nil {
+		c.Close()
+		return nil, err
+	}
+	ack, err := ReadFrame(c.reader)
+	if err != nil {
+		c.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	return ack, nil
+}
+
+// Close closes the connection, if one is open
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn, c.reader = nil, nil
+	return err
+}
@@ -0,0 +1,290 @@
+package hl7
+
+import (
+	"context"
+	"log"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"medscreen/internal/stream"
+	"medscreen/internal/utils"
+	"sort"
+	"time"
+)
+
+// sayfaBoyutu is the page size used to list the current inpatients
+const sayfaBoyutu = 500
+
+// ayrilanSaklama is how long the last bed of an inpatient who left anlik_yatan_hasta is
+// kept for the discharge message of the visit
+const ayrilanSaklama = 24 * time.Hour
+
+// Basliklar configures the MSH values of the messages
+type Basliklar struct {
+	GonderenUygulama string
+	GonderenKurum    string
+	IslemeKodu       string
+}
+
+// Uretici detects the changes of the watched VEM tables and queues a message per change
+// and endpoint in the outbox.
+//
+// Discharges, test results and vital signs are polled on their watermarks. Admissions and
+// transfers are found by comparing the current inpatients with the previous poll, as
+// anlik_yatan_hasta has no update time; the first poll after a start only reports the
+// admissions created since the last queued change. Transfers made while MedScreen was
+// down are therefore not reported.
+type Uretici struct {
+	kaynakRepo   repository.HL7KaynakRepository
+	mesajRepo    repository.HL7MesajRepository
+	hedefler     []Hedef
+	basliklar    Basliklar
+	degerlendir  func(*models.TetkikSonuc) *models.TetkikDegerlendirmesi
+	clock        stream.Clock
+	pollInterval time.Duration
+
+	hazir     bool
+	watermark time.Time
+	gorulen   map[string]bool
+	// yatanlar are the inpatients of the previous poll by visit
+	yatanlar map[string]models.AnlikYatanHasta
+	// ayrilanlar are the inpatients who left anlik_yatan_hasta, by visit
+	ayrilanlar map[string]ayrilan
+}
+
+type ayrilan struct {
+	yatan models.AnlikYatanHasta
+	zaman time.Time
+}
+
+// NewUretici creates a new Uretici. degerlendir evaluates test results for their abnormal
+// flags; it may be nil.
+func NewUretici(kaynakRepo repository.HL7KaynakRepository, mesajRepo repository.HL7MesajRepository, hedefler []Hedef, basliklar Basliklar, degerlendir func(*models.TetkikSonuc) *models.TetkikDegerlendirmesi, clock stream.Clock, pollInterval time.Duration) *Uretici {
+	return &Uretici{
+		kaynakRepo:   kaynakRepo,
+		mesajRepo:    mesajRepo,
+		hedefler:     hedefler,
+		basliklar:    basliklar,
+		degerlendir:  degerlendir,
+		clock:        clock,
+		pollInterval: pollInterval,
+		gorulen:      make(map[string]bool),
+		ayrilanlar:   make(map[string]ayrilan),
+	}
+}
+
+// Calistir polls until ctx is done. Errors are logged and the poll is repeated.
+func (u *Uretici) Calistir(ctx context.Context) {
+	for {
+		if _, err := u.Tara(); err != nil {
+			log.Printf("HL7: failed to poll VEM changes: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-u.clock.After(u.pollInterval):
+		}
+	}
+}
+
+// Tara polls once and returns how many messages were queued. Nothing is remembered of a
+// poll that fails, so its changes are found again by the next one.
+func (u *Uretici) Tara() (int64, error) {
+	now := u.clock.Now()
+	watermark := u.watermark
+	if !u.hazir {
+		son, err := u.mesajRepo.SonOlayZamani()
+		if err != nil {
+			return 0, err
+		}
+		// Without a queued change the feed starts now rather than replaying the history
+		watermark = now
+		if son != nil && son.Before(now) {
+			watermark = *son
+		}
+	}
+
+	yatanlar, err := u.anlikYatanHastalar()
+	if err != nil {
+		return 0, err
+	}
+	var olaylar []Olay
+	for _, y := range yatanlar {
+		onceki, vardi := u.yatanlar[y.HastaBasvuruKodu]
+		switch {
+		case !u.hazir:
+			// A row created at the watermark is the last queued change itself, or the new
+			// row of a transfer that was queued then
+			if y.KayitZamani.After(watermark) {
+				olaylar = append(olaylar, YatisOlayi(&y))
+			}
+		case !vardi:
+			olaylar = append(olaylar, YatisOlayi(&y))
+		case onceki.YatakKodu != y.YatakKodu:
+			olaylar = append(olaylar, NakilOlayi(&y, KonumOf(&onceki), now))
+		}
+	}
+	ayrilanlar := make(map[string]ayrilan, len(u.ayrilanlar))
+	for kodu, a := range u.ayrilanlar {
+		if _, dondu := yatanlar[kodu]; !dondu && now.Sub(a.zaman) < ayrilanSaklama {
+			ayrilanlar[kodu] = a
+		}
+	}
+	for kodu, y := range u.yatanlar {
+		if _, kaldi := yatanlar[kodu]; !kaldi {
+			ayrilanlar[kodu] = ayrilan{yatan: y, zaman: now}
+		}
+	}
+
+	var degisiklikler []Olay
+	cikislar, err := u.kaynakRepo.FindCikislarSince(watermark)
+	if err != nil {
+		return 0, err
+	}
+	for i := range cikislar {
+		b := &cikislar[i]
+		var sonYatis *models.AnlikYatanHasta
+		if y, ok := yatanlar[b.HastaBasvuruKodu]; ok {
+			sonYatis = &y
+		} else if a, ok := ayrilanlar[b.HastaBasvuruKodu]; ok {
+			sonYatis = &a.yatan
+		}
+		degisiklikler = append(degisiklikler, CikisOlayi(b, sonYatis))
+	}
+
+	sonuclar, err := u.kaynakRepo.FindTetkikSonuclariSince(watermark)
+	if err != nil {
+		return 0, err
+	}
+	for i := range sonuclar {
+		t := &sonuclar[i]
+		if u.degerlendir != nil {
+			t.Degerlendirme = u.degerlendir(t)
+		}
+		degisiklikler = append(degisiklikler, TetkikOlayi(t, yatisOf(yatanlar, t.HastaBasvuruKodu)))
+	}
+
+	bulgular, err := u.kaynakRepo.FindVitalBulgularSince(watermark)
+	if err != nil {
+		return 0, err
+	}
+	for i := range bulgular {
+		b := &bulgular[i]
+		if olay, ok := VitalOlayi(b, yatisOf(yatanlar, b.HastaBasvuruKodu)); ok {
+			degisiklikler = append(degisiklikler, olay)
+		}
+	}
+
+	// Changes sharing the watermark are queued once per run; the outbox skips the rest
+	gorulen := u.gorulen
+	for _, olay := range degisiklikler {
+		if olay.Zaman.Before(watermark) || (olay.Zaman.Equal(watermark) && gorulen[olay.Anahtar]) {
+			continue
+		}
+		olaylar = append(olaylar, olay)
+	}
+	sortOlaylar(olaylar)
+
+	mesajlar, err := u.mesajlar(olaylar, now)
+	if err != nil {
+		return 0, err
+	}
+	eklenen, err := u.mesajRepo.Create(mesajlar)
+	if err != nil {
+		return 0, err
+	}
+
+	// The watermark does not move past now, so a time in the future is polled again
+	// until it has passed
+	yeniWatermark := watermark
+	for _, olay := range degisiklikler {
+		if olay.Zaman.After(yeniWatermark) && !olay.Zaman.After(now) {
+			yeniWatermark = olay.Zaman
+		}
+	}
+	if yeniWatermark.After(watermark) {
+		gorulen = make(map[string]bool)
+	}
+	for _, olay := range degisiklikler {
+		if olay.Zaman.Equal(yeniWatermark) {
+			gorulen[olay.Anahtar] = true
+		}
+	}
+
+	u.hazir = true
+	u.watermark = yeniWatermark
+	u.gorulen = gorulen
+	u.yatanlar = yatanlar
+	u.ayrilanlar = ayrilanlar
+	return eklenen, nil
+}
+
+// anlikYatanHastalar lists the current inpatients by visit
+func (u *Uretici) anlikYatanHastalar() (map[string]models.AnlikYatanHasta, error) {
+	yatanlar := make(map[string]models.AnlikYatanHasta)
+	for page := 1; ; page++ {
+		sayfa, total, err := u.kaynakRepo.FindAnlikYatanHastalar(page, sayfaBoyutu)
+		if err != nil {
+			return nil, err
+		}
+		for _, y := range sayfa {
+			yatanlar[y.HastaBasvuruKodu] = y
+		}
+		if len(sayfa) == 0 || int64(page*sayfaBoyutu) >= total {
+			return yatanlar, nil
+		}
+	}
+}
+
+// mesajlar renders the changes for every endpoint
+func (u *Uretici) mesajlar(olaylar []Olay, now time.Time) ([]models.HL7Mesaj, error) {
+	var mesajlar []models.HL7Mesaj
+	for _, olay := range olaylar {
+		for _, hedef := range u.hedefler {
+			id, err := utils.NewRandomID()
+			if err != nil {
+				return nil, err
+			}
+			// MSH-10 is limited to 20 characters
+			kontrolKodu := id[:20]
+			mesaj := NewMessage(olay, Baslik{
+				GonderenUygulama: u.basliklar.GonderenUygulama,
+				GonderenKurum:    u.basliklar.GonderenKurum,
+				AliciUygulama:    hedef.Ad,
+				Zaman:            now,
+				KontrolKodu:      kontrolKodu,
+				IslemeKodu:       u.basliklar.IslemeKodu,
+			})
+			mesajlar = append(mesajlar, models.HL7Mesaj{
+				Hedef:            hedef.Ad,
+				OlayAnahtari:     olay.Anahtar,
+				MesajKontrolKodu: kontrolKodu,
+				MesajTuru:        olay.MesajTuru(),
+				HastaBasvuruKodu: olay.HastaBasvuruKodu,
+				OlayZamani:       olay.Zaman,
+				Icerik:           string(mesaj.Encode()),
+				Durum:            models.HL7MesajBekliyor,
+				SonrakiDeneme:    now,
+				OlusturmaZamani:  now,
+			})
+		}
+	}
+	return mesajlar, nil
+}
+
+func yatisOf(yatanlar map[string]models.AnlikYatanHasta, basvuruKodu string) *models.AnlikYatanHasta {
+	if y, ok := yatanlar[basvuruKodu]; ok {
+		return &y
+	}
+	return nil
+}
+
+// sortOlaylar orders changes by watermark, then by key for a stable order
+func sortOlaylar(olaylar []Olay) {
+	sort.SliceStable(olaylar, func(i, j int) bool {
+		a, b := olaylar[i], olaylar[j]
+		if !a.Zaman.Equal(b.Zaman) {
+			return a.Zaman.Before(b.Zaman)
+		}
+		return a.Anahtar < b.Anahtar
+	})
+}
@@ -0,0 +1,224 @@
+// Package masking masks personal data (TC kimlik numarası, names, birth dates) in API
+// responses according to the caller's role and the purpose of the endpoint.
+//
+// Rules are declared once per entity and field in a JSON policy and applied to the
+// encoded response, so an entity is masked the same way wherever it appears: at the top
+// level, in a list or preloaded into another record (HastaBasvuru.Hasta, Hasta.Anne, ...).
+package masking
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Rule is how a field is masked
+type Rule string
+
+const (
+	// RuleFull leaves the field as it is
+	RuleFull Rule = "full"
+	// RulePartial keeps the first three and the last two characters, e.g. 123******01
+	RulePartial Rule = "partial"
+	// RuleInitials keeps the initial of every word, e.g. "Ayşe Nur" -> "A. N."
+	RuleInitials Rule = "initials"
+	// RuleYear keeps only the year of a date
+	RuleYear Rule = "year"
+	// RuleHidden removes the field
+	RuleHidden Rule = "hidden"
+)
+
+// AnyRole is the role key that applies to roles not listed for a purpose
+const AnyRole = "*"
+
+// Purposes of an endpoint
+const (
+	// PurposeDefault applies to clinical and administrative views
+	PurposeDefault = "default"
+	// PurposeBedside applies to patient-facing views on a bedside tablet
+	PurposeBedside = "bedside"
+)
+
+//go:embed default_masking.json
+var defaultPolicyJSON []byte
+
+// Policy declares which JSON objects are which entity and how their fields are masked.
+// Entities maps an entity to the keys that together identify its objects; Purposes maps
+// a purpose and a role (models.PersonelGorevKodu) to rules keyed "entity.field".
+// A role without an entry uses the AnyRole entry of the purpose; entries are not merged.
+type Policy struct {
+	Entities map[string][]string                   `json:"entities"`
+	Purposes map[string]map[string]map[string]Rule `json:"purposes"`
+}
+
+// Default returns the built-in masking policy shipped with MedScreen
+func Default() *Policy {
+	p, err := Parse(defaultPolicyJSON)
+	if err != nil {
+		panic("invalid built-in masking policy: " + err.Error())
+	}
+	return p
+}
+
+// Load reads a masking policy from a JSON file; an empty path returns the built-in policy
+func Load(path string) (*Policy, error) {
+	if path == "" {
+		return Default(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read masking policy file: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates a JSON masking policy
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse masking policy: %w", err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// RulesFor returns the rules of a role for a purpose; an unknown purpose uses PurposeDefault
+func (p *Policy) RulesFor(purpose, role string) map[string]Rule {
+	roles, ok := p.Purposes[purpose]
+	if !ok {
+		roles = p.Purposes[PurposeDefault]
+	}
+	if rules, ok := roles[role]; ok {
+		return rules
+	}
+	return roles[AnyRole]
+}
+
+// Mask masks a decoded JSON value (maps, slices and scalars as produced by encoding/json)
+// in place for a role and purpose. It reports whether anything was changed.
+func (p *Policy) Mask(purpose, role string, v interface{}) bool {
+	rules := p.RulesFor(purpose, role)
+	if len(rules) == 0 {
+		return false
+	}
+	return p.mask(rules, v)
+}
+
+func (p *Policy) mask(rules map[string]Rule, v interface{}) bool {
+	changed := false
+	switch v := v.(type) {
+	case []interface{}:
+		for _, item := range v {
+			changed = p.mask(rules, item) || changed
+		}
+	case map[string]interface{}:
+		if entity := p.entityOf(v); entity != "" {
+			for field, value := range v {
+				rule, ok := rules[entity+"."+field]
+				if !ok || rule == RuleFull || value == nil {
+					continue
+				}
+				if masked, keep := apply(rule, value); keep {
+					v[field] = masked
+				} else {
+					delete(v, field)
+				}
+				changed = true
+			}
+		}
+		for _, value := range v {
+			changed = p.mask(rules, value) || changed
+		}
+	}
+	return changed
+}
+
+// entityOf returns the entity an object is, or "" if it holds every key of none
+func (p *Policy) entityOf(obj map[string]interface{}) string {
+	for entity, keys := range p.Entities {
+		match := len(keys) > 0
+		for _, key := range keys {
+			if _, ok := obj[key]; !ok {
+				match = false
+				break
+			}
+		}
+		if match {
+			return entity
+		}
+	}
+	return ""
+}
+
+// apply masks a single value; keep is false when the field must be removed.
+// Values a rule cannot be applied to are removed rather than leaked.
+func apply(rule Rule, value interface{}) (masked interface{}, keep bool) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, false
+	}
+	switch rule {
+	case RulePartial:
+		return Partial(s), true
+	case RuleInitials:
+		return Initials(s), true
+	case RuleYear:
+		if len(s) >= 4 && strings.IndexFunc(s[:4], func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+			return s[:4], true
+		}
+	}
+	return nil, false
+}
+
+// Partial keeps the first three and the last two characters of s, e.g. 12345678901 ->
+// 123******01. Values too short for that keep only their first character.
+func Partial(s string) string {
+	r := []rune(s)
+	if len(r) < 7 {
+		if len(r) == 0 {
+			return s
+		}
+		return string(r[0]) + strings.Repeat("*", len(r)-1)
+	}
+	return string(r[:3]) + strings.Repeat("*", len(r)-5) + string(r[len(r)-2:])
+}
+
+// Initials keeps the initial of every word of s, e.g. "Ayşe Nur" -> "A. N."
+func Initials(s string) string {
+	words := strings.Fields(s)
+	for i, word := range words {
+		r, _ := utf8.DecodeRuneInString(word)
+		words[i] = string(unicode.ToUpper(r)) + "."
+	}
+	return strings.Join(words, " ")
+}
+
+func (p *Policy) validate() error {
+	if _, ok := p.Purposes[PurposeDefault]; !ok {
+		return fmt.Errorf("masking policy has no %q purpose", PurposeDefault)
+	}
+	for purpose, roles := range p.Purposes {
+		for role, rules := range roles {
+			for field, rule := range rules {
+				entity, _, ok := strings.Cut(field, ".")
+				if _, known := p.Entities[entity]; !ok || !known {
+					return fmt.Errorf("unknown entity in field %q for role %s on purpose %s", field, role, purpose)
+				}
+				if !rule.valid() {
+					return fmt.Errorf("invalid rule %q for %s, role %s on purpose %s", rule, field, role, purpose)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (r Rule) valid() bool {
+	return r == RuleFull || r == RulePartial || r == RuleInitials || r == RuleYear || r == RuleHidden
+}
@@ -0,0 +1,165 @@
+package masking
+
+import (
+	"encoding/json"
+	"fmt"
+	"medscreen/internal/middleware"
+	"medscreen/internal/models"
+	"medscreen/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pgregory.net/rapid"
+)
+
+// Feature: pii-masking, Property 1: Entities Are Masked Wherever They Appear
+// *For any* patient, a DIGER caller SHALL see the partial TC kimlik numarası and only the
+// birth year of that patient at every nesting depth, while a HEKIM SHALL see it in full.
+
+func testHasta(tc string) *models.Hasta {
+	return &models.Hasta{
+		HastaKodu:        "H1",
+		TCKimlikNumarasi: &tc,
+		Ad:               "Ayşe Nur",
+		Soyadi:           "Yılmaz",
+		DogumTarihi:      time.Date(1985, 4, 12, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func maskedBody(t interface{ Fatalf(string, ...any) }, purpose, role string, v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	masked, err := Default().maskJSON(purpose, role, data)
+	if err != nil {
+		t.Fatalf("Failed to mask: %v", err)
+	}
+	return string(masked)
+}
+
+// TestProperty_EntitiesMaskedWhereverTheyAppear tests Property 1
+func TestProperty_EntitiesMaskedWhereverTheyAppear(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		tc := rapid.StringMatching(`[1-9][0-9]{10}`).Draw(rt, "tc")
+		hasta := testHasta(tc)
+		var v interface{}
+		switch rapid.IntRange(0, 3).Draw(rt, "derinlik") {
+		case 0:
+			v = hasta
+		case 1:
+			v = []models.HastaBasvuru{{HastaBasvuruKodu: "B1", HastaKodu: "H1", Hasta: hasta}}
+		case 2:
+			v = utils.SuccessResponse{Data: []models.AnlikYatanHasta{{AnlikYatanHastaKodu: "A1", Hasta: hasta}}}
+		default:
+			v = models.Hasta{HastaKodu: "H2", Ad: "Bebek", Soyadi: "Yılmaz", Anne: hasta}
+		}
+
+		diger := maskedBody(rt, PurposeDefault, string(models.GorevDiger), v)
+		beklenen := tc[:3] + "******" + tc[9:]
+		if strings.Contains(diger, tc) || !strings.Contains(diger, `"tc_kimlik_numarasi":"`+beklenen+`"`) {
+			rt.Fatalf("Expected %s for DIGER, got %s", beklenen, diger)
+		}
+		if strings.Contains(diger, "1985-04-12") || !strings.Contains(diger, `"dogum_tarihi":"1985"`) {
+			rt.Fatalf("Expected only the birth year for DIGER, got %s", diger)
+		}
+
+		hekim := maskedBody(rt, PurposeDefault, string(models.GorevHekim), v)
+		if !strings.Contains(hekim, tc) || !strings.Contains(hekim, "Ayşe Nur") {
+			rt.Fatalf("Expected HEKIM to see the patient in full, got %s", hekim)
+		}
+	})
+}
+
+// TestMasking_BedsideShowsInitials verifies the patient-facing purpose and that records
+// that are not people keep fields with the same names
+func TestMasking_BedsideShowsInitials(t *testing.T) {
+	tc := "12345678901"
+	sicil := "98765432109"
+	v := models.HastaBasvuru{
+		HastaBasvuruKodu: "B1",
+		HastaKodu:        "H1",
+		Hasta:            testHasta(tc),
+		Hekim:            &models.Personel{PersonelKodu: "P1", Ad: "Mehmet", Soyadi: "Demir", TCKimlikNumarasi: &sicil},
+	}
+
+	body := maskedBody(t, PurposeBedside, string(models.GorevHekim), v)
+	var masked struct {
+		Hasta map[string]interface{} `json:"hasta"`
+		Hekim map[string]interface{} `json:"hekim"`
+	}
+	if err := json.Unmarshal([]byte(body), &masked); err != nil {
+		t.Fatal(err)
+	}
+	if masked.Hasta["ad"] != "A. N." || masked.Hasta["soyadi"] != "Y." {
+		t.Errorf("Expected initials on the bedside, got %v", masked.Hasta)
+	}
+	if _, ok := masked.Hasta["tc_kimlik_numarasi"]; ok {
+		t.Errorf("Expected the TC kimlik numarası to be hidden on the bedside, got %v", masked.Hasta)
+	}
+	if _, ok := masked.Hasta["dogum_tarihi"]; ok {
+		t.Errorf("Expected the birth date to be hidden on the bedside, got %v", masked.Hasta)
+	}
+	if masked.Hekim["ad"] != "Mehmet" || masked.Hekim["soyadi"] != "D." {
+		t.Errorf("Expected the physician's first name and initial, got %v", masked.Hekim)
+	}
+
+	ayni := fmt.Sprintf(`{"ad":"Kardiyoloji","soyadi":"-","tc_kimlik_numarasi":%q}`, tc)
+	if got, _ := Default().maskJSON(PurposeDefault, string(models.GorevDiger), []byte(ayni)); string(got) != ayni {
+		t.Errorf("Expected an object that is no entity to be left alone, got %s", got)
+	}
+}
+
+// TestMasking_Middleware verifies masking by the caller's token on a protected route
+func TestMasking_Middleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		claims := &utils.Claims{PersonelKodu: "P1", Role: c.GetHeader("X-Rol"), YatakKodu: c.GetHeader("X-Yatak")}
+		c.Set(middleware.ContextKeyClaims, claims)
+		c.Set(middleware.ContextKeyUserRole, claims.Role)
+	})
+	router.Use(Default().Middleware("/stream/"))
+	router.GET("/hasta", func(c *gin.Context) {
+		utils.SendSuccessResponse(c, http.StatusOK, "OK", "", testHasta("12345678901"))
+	})
+	router.GET("/ozet", Purpose(PurposeBedside), func(c *gin.Context) {
+		utils.SendSuccessResponse(c, http.StatusOK, "OK", "", testHasta("12345678901"))
+	})
+
+	tests := []struct {
+		path, rol, yatak, beklenen string
+	}{
+		{"/hasta", "HEKIM", "", `"tc_kimlik_numarasi":"12345678901"`},
+		{"/hasta", "DIGER", "", `"tc_kimlik_numarasi":"123******01"`},
+		{"/hasta", "HEKIM", "Y1", `"ad":"A. N."`},
+		{"/ozet", "HEKIM", "", `"ad":"A. N."`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Header.Set("X-Rol", tt.rol)
+		req.Header.Set("X-Yatak", tt.yatak)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), tt.beklenen) {
+			t.Errorf("GET %s as %s (bed %q): expected %s, got %d %s", tt.path, tt.rol, tt.yatak, tt.beklenen, w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestMasking_PolicyValidation verifies that unknown entities and rules are rejected
+func TestMasking_PolicyValidation(t *testing.T) {
+	for _, policy := range []string{
+		`{"entities":{"hasta":["hasta_kodu"]},"purposes":{"default":{"*":{"hasta.ad":"blur"}}}}`,
+		`{"entities":{"hasta":["hasta_kodu"]},"purposes":{"default":{"*":{"personel.ad":"partial"}}}}`,
+		`{"entities":{"hasta":["hasta_kodu"]},"purposes":{"bedside":{}}}`,
+	} {
+		if _, err := Parse([]byte(policy)); err == nil {
+			t.Errorf("Expected %s to be rejected", policy)
+		}
+	}
+}
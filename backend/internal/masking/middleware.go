@@ -0,0 +1,180 @@
+package masking
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"medscreen/internal/constants"
+	"medscreen/internal/middleware"
+	"medscreen/internal/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKeyPurpose holds the purpose an endpoint declared with Purpose
+const ContextKeyPurpose = "maskingPurpose"
+
+// ContextKeyPolicy holds the *Policy Middleware masks the response with
+const ContextKeyPolicy = "maskingPolicy"
+
+// Purpose declares the purpose of the endpoints of a route group
+func Purpose(purpose string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(ContextKeyPurpose, purpose)
+		c.Next()
+	}
+}
+
+// PurposeOf returns the purpose a request is answered for: the one its endpoint declared,
+// PurposeBedside for a token bound to a bedside tablet, PurposeDefault otherwise.
+// A token a HEKIM escalated out of its bed binding is not patient-facing.
+func PurposeOf(c *gin.Context) string {
+	if purpose := c.GetString(ContextKeyPurpose); purpose != "" {
+		return purpose
+	}
+	if claims, ok := middleware.GetClaims(c); ok && claims.YatakKodu != "" && !claims.YatakKisitiKaldirildi {
+		return PurposeBedside
+	}
+	return PurposeDefault
+}
+
+// Marshal encodes v as JSON masked for the caller of c, for handlers that write
+// their responses themselves (e.g. event streams)
+func (p *Policy) Marshal(c *gin.Context, v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return p.maskJSON(PurposeOf(c), c.GetString(middleware.ContextKeyUserRole), data)
+}
+
+// Field masks a single field of an entity for the caller of c, for responses that are not
+// shaped like VEM records (FHIR resources). keep is false when the field must be left out.
+func (p *Policy) Field(c *gin.Context, entity, field, value string) (masked string, keep bool) {
+	rule, ok := p.RulesFor(PurposeOf(c), c.GetString(middleware.ContextKeyUserRole))[entity+"."+field]
+	if !ok || rule == RuleFull {
+		return value, true
+	}
+	m, keep := apply(rule, value)
+	masked, _ = m.(string)
+	return masked, keep
+}
+
+// Masked reports whether a field of an entity is masked or hidden for the caller of c.
+// Lists must not be filtered or sorted by such a field, or its value could be recovered
+// by narrowing a filter until a record matches.
+func (p *Policy) Masked(c *gin.Context, entity, field string) bool {
+	rule, ok := p.RulesFor(PurposeOf(c), c.GetString(middleware.ContextKeyUserRole))[entity+"."+field]
+	return ok && rule != RuleFull
+}
+
+// PolicyOf returns the policy the response of c is masked with
+func PolicyOf(c *gin.Context) (*Policy, bool) {
+	v, ok := c.Get(ContextKeyPolicy)
+	if !ok {
+		return nil, false
+	}
+	p, ok := v.(*Policy)
+	return p, ok
+}
+
+// maskJSON masks an encoded JSON document; it is returned unchanged if nothing was masked
+func (p *Policy) maskJSON(purpose, role string, data []byte) ([]byte, error) {
+	if len(p.RulesFor(purpose, role)) == 0 {
+		return data, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	if !p.Mask(purpose, role, v) {
+		return data, nil
+	}
+	return json.Marshal(v)
+}
+
+// bufferedWriter holds the response back until it has been masked
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body    bytes.Buffer
+	status  int
+	written bool
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+	w.written = true
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
+
+// Middleware masks the JSON responses of protected endpoints for the caller's role and
+// purpose. It must run after middleware.AuthMiddleware. A response that cannot be masked
+// is replaced by an error rather than sent unmasked.
+//
+// Responses under streamPrefixes are long-lived and cannot be buffered; their handlers
+// mask each event with Marshal.
+func (p *Policy) Middleware(streamPrefixes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, prefix := range streamPrefixes {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		c.Set(ContextKeyPolicy, p)
+		original := c.Writer
+		buffered := &bufferedWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = buffered
+
+		c.Next()
+
+		c.Writer = original
+		body := buffered.body.Bytes()
+		if len(body) > 0 && strings.HasPrefix(original.Header().Get("Content-Type"), "application/json") {
+			masked, err := p.maskJSON(PurposeOf(c), c.GetString(middleware.ContextKeyUserRole), body)
+			if err != nil {
+				log.Printf("Masking: failed to mask response of %s: %v", c.Request.URL.Path, err)
+				original.Header().Del("Content-Length")
+				utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Response could not be prepared", nil)
+				return
+			}
+			body = masked
+		}
+
+		original.Header().Del("Content-Length")
+		original.WriteHeader(buffered.status)
+		if len(body) > 0 {
+			_, _ = original.Write(body)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+// Package measurement parses the string-encoded measurements of VEM 2.0 (vital signs,
+// test results, reference ranges and risk scores) into typed values.
+//
+// Values are entered by hand on the ward and in the laboratory, so the parser accepts
+// Turkish decimal commas ("36,8"), thousands separators ("1.250,5"), units before or
+// after the number ("%95", "37,2 °C", "5,2 10^3/uL"), comparators for censored results
+// ("<0,5") and ranges ("3,5-5,1").
+package measurement
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrBos is returned for a nil or empty input
+var ErrBos = errors.New("value is empty")
+
+// ParseError describes an input that could not be parsed
+type ParseError struct {
+	Girdi string
+	Sebep string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("cannot parse %q: %s", e.Girdi, e.Sebep)
+}
+
+// Comparators of censored results ("<0,5", ">= 200")
+const (
+	IsaretKucuk     = "<"
+	IsaretKucukEsit = "<="
+	IsaretBuyuk     = ">"
+	IsaretBuyukEsit = ">="
+)
+
+// Deger is a parsed measurement
+type Deger struct {
+	Sayi   float64
+	Birim  string
+	Isaret string // set for censored results, e.g. "<" for "<0,5"
+}
+
+// Aralik is a parsed range; a nil bound is open
+type Aralik struct {
+	Alt   *float64
+	Ust   *float64
+	Birim string
+}
+
+// Icerir reports whether v lies within the range, bounds included
+func (a Aralik) Icerir(v float64) bool {
+	if a.Alt != nil && v < *a.Alt {
+		return false
+	}
+	if a.Ust != nil && v > *a.Ust {
+		return false
+	}
+	return true
+}
+
+const sayiDeseni = `[-+]?\d[\d.,]*`
+
+var (
+	degerPattern  = regexp.MustCompile(`^(<=|>=|≤|≥|<|>)?\s*(%)?\s*(` + sayiDeseni + `)\s*(.*)$`)
+	aralikPattern = regexp.MustCompile(`^(` + sayiDeseni + `)\s*[-–—]\s*(` + sayiDeseni + `)\s*(.*)$`)
+	// ikinciSayiPattern matches a remainder that is another number rather than a unit ("/80", "-5,1")
+	ikinciSayiPattern = regexp.MustCompile(`^(?:[/\-–—]\s*)?` + sayiDeseni + `$`)
+)
+
+// ParseDeger parses a single measurement with an optional unit and comparator.
+// "120/80" and "3,5-5,1" are rejected; use ParseKanBasinci and ParseAralik for those.
+func ParseDeger(s string) (Deger, error) {
+	girdi := strings.TrimSpace(s)
+	if girdi == "" {
+		return Deger{}, ErrBos
+	}
+
+	m := degerPattern.FindStringSubmatch(girdi)
+	if m == nil {
+		return Deger{}, &ParseError{Girdi: s, Sebep: "no number found"}
+	}
+	birim := strings.TrimSpace(m[4])
+	if ikinciSayiPattern.MatchString(birim) {
+		return Deger{}, &ParseError{Girdi: s, Sebep: "more than one value"}
+	}
+	if m[2] != "" {
+		if birim != "" {
+			return Deger{}, &ParseError{Girdi: s, Sebep: "conflicting units"}
+		}
+		birim = "%"
+	}
+	birim = normalizeBirim(birim)
+
+	sayi, err := parseSayi(m[3], birim)
+	if err != nil {
+		return Deger{}, &ParseError{Girdi: s, Sebep: err.Error()}
+	}
+	return Deger{Sayi: sayi, Birim: birim, Isaret: normalizeIsaret(m[1])}, nil
+}
+
+// ParseAralik parses a range such as "3,5-5,1", "3.5 - 5.1 mmol/L", "<0,5" or "> 200".
+// A single number without a comparator is not a range.
+func ParseAralik(s string) (Aralik, error) {
+	girdi := strings.TrimSpace(s)
+	if girdi == "" {
+		return Aralik{}, ErrBos
+	}
+
+	if m := aralikPattern.FindStringSubmatch(girdi); m != nil {
+		birim := strings.TrimSpace(m[3])
+		if ikinciSayiPattern.MatchString(birim) {
+			return Aralik{}, &ParseError{Girdi: s, Sebep: "more than two values"}
+		}
+		birim = normalizeBirim(birim)
+		alt, err := parseSayi(m[1], birim)
+		if err != nil {
+			return Aralik{}, &ParseError{Girdi: s, Sebep: err.Error()}
+		}
+		ust, err := parseSayi(m[2], birim)
+		if err != nil {
+			return Aralik{}, &ParseError{Girdi: s, Sebep: err.Error()}
+		}
+		if alt > ust {
+			return Aralik{}, &ParseError{Girdi: s, Sebep: "lower bound is greater than upper bound"}
+		}
+		return Aralik{Alt: &alt, Ust: &ust, Birim: birim}, nil
+	}
+
+	deger, err := ParseDeger(girdi)
+	if err != nil {
+		return Aralik{}, err
+	}
+	sinir := deger.Sayi
+	switch deger.Isaret {
+	case IsaretKucuk, IsaretKucukEsit:
+		return Aralik{Ust: &sinir, Birim: deger.Birim}, nil
+	case IsaretBuyuk, IsaretBuyukEsit:
+		return Aralik{Alt: &sinir, Birim: deger.Birim}, nil
+	}
+	return Aralik{}, &ParseError{Girdi: s, Sebep: "not a range"}
+}
+
+// ParseKanBasinci parses blood pressure written either as "120/80" or as a single value.
+// dia is nil when the input holds the systolic value only.
+func ParseKanBasinci(s string) (sis Deger, dia *Deger, err error) {
+	girdi := strings.TrimSpace(s)
+	parcalar := strings.SplitN(girdi, "/", 2)
+	if len(parcalar) == 2 && !strings.HasPrefix(strings.TrimSpace(parcalar[1]), "dk") {
+		if sis, err = ParseDeger(parcalar[0]); err != nil {
+			return Deger{}, nil, &ParseError{Girdi: s, Sebep: "invalid systolic value"}
+		}
+		d, err := ParseDeger(parcalar[1])
+		if err != nil {
+			return Deger{}, nil, &ParseError{Girdi: s, Sebep: "invalid diastolic value"}
+		}
+		if sis.Birim == "" {
+			sis.Birim = d.Birim
+		}
+		return sis, &d, nil
+	}
+
+	sis, err = ParseDeger(girdi)
+	return sis, nil, err
+}
+
+// parseSayi converts a number with Turkish or English separators. When both a comma and
+// a point occur, the last one is the decimal separator; a separator that occurs more than
+// once is a thousands separator. A single point is a decimal point ("1.100" INR, "1.015"
+// specific gravity), except in the unit of a count that is always whole, where Turkish
+// writes 250.000 for two hundred and fifty thousand.
+func parseSayi(s, birim string) (float64, error) {
+	s = strings.TrimRight(s, ".,")
+	virgul, nokta := strings.LastIndex(s, ","), strings.LastIndex(s, ".")
+
+	switch {
+	case virgul >= 0 && nokta >= 0:
+		if virgul > nokta {
+			s = strings.ReplaceAll(s, ".", "")
+			s = strings.Replace(s, ",", ".", 1)
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case virgul >= 0:
+		if strings.Count(s, ",") > 1 {
+			s = strings.ReplaceAll(s, ",", "")
+		} else {
+			s = strings.Replace(s, ",", ".", 1)
+		}
+	case nokta >= 0:
+		if strings.Count(s, ".") > 1 || tamSayiBirimleri[strings.ToLower(birim)] && binlikNokta(s, nokta) {
+			s = strings.ReplaceAll(s, ".", "")
+		}
+	}
+
+	sayi, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, errors.New("malformed number")
+	}
+	return sayi, nil
+}
+
+// tamSayiBirimleri are the units of cell counts, whose values have no fraction
+var tamSayiBirimleri = map[string]bool{
+	"/ul":  true,
+	"/µl":  true,
+	"/μl":  true,
+	"/mm3": true,
+	"/mm³": true,
+}
+
+// binlikNokta reports whether the only point of s, at index nokta, can separate thousands:
+// it is followed by exactly three digits and preceded by one to three digits that do not
+// start with zero
+func binlikNokta(s string, nokta int) bool {
+	tam, kesir := strings.TrimPrefix(s[:nokta], "-"), s[nokta+1:]
+	if len(kesir) != 3 || len(tam) < 1 || len(tam) > 3 || tam[0] == '0' {
+		return false
+	}
+	for _, r := range tam + kesir {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// birimler maps the common spellings of ward units to a single form
+var birimler = map[string]string{
+	"c":      "°C",
+	"°c":     "°C",
+	"ºc":     "°C",
+	"derece": "°C",
+	"/dk":    "/dk",
+	"/dak":   "/dk",
+	"dk":     "/dk",
+	"bpm":    "/dk",
+	"mmhg":   "mmHg",
+	"mm hg":  "mmHg",
+	"kg":     "kg",
+	"cm":     "cm",
+	"%":      "%",
+}
+
+func normalizeBirim(birim string) string {
+	if normal, ok := birimler[strings.ToLower(birim)]; ok {
+		return normal
+	}
+	return birim
+}
+
+func normalizeIsaret(isaret string) string {
+	switch isaret {
+	case "≤":
+		return IsaretKucukEsit
+	case "≥":
+		return IsaretBuyukEsit
+	}
+	return isaret
+}
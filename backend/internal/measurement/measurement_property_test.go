@@ -0,0 +1,134 @@
+package measurement
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// Feature: typed-measurements, Property 1: Ward Notation Round Trip
+// *For any* number written with a Turkish or English decimal separator, an optional
+// thousands separator and an optional unit, ParseDeger SHALL return the same number and
+// the normalized unit, and ParseAralik SHALL return both bounds of a range.
+
+// yaz writes hundredths as a number with the given separators, e.g. 125050 -> "1.250,50"
+func yaz(yuzde int, ondalik, binlik string) string {
+	isaret := ""
+	if yuzde < 0 {
+		isaret, yuzde = "-", -yuzde
+	}
+	tam := fmt.Sprint(yuzde / 100)
+	if binlik != "" && len(tam) > 3 {
+		tam = tam[:len(tam)-3] + binlik + tam[len(tam)-3:]
+	}
+	return fmt.Sprintf("%s%s%s%02d", isaret, tam, ondalik, yuzde%100)
+}
+
+// TestProperty_WardNotationRoundTrip tests Property 1
+func TestProperty_WardNotationRoundTrip(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		yuzde := rapid.IntRange(-50000, 999999).Draw(rt, "yuzde")
+		ayirici := rapid.SampledFrom([][2]string{{",", ""}, {".", ""}, {",", "."}, {".", ","}}).Draw(rt, "ayirici")
+		birim := rapid.SampledFrom([][2]string{{"", ""}, {" °C", "°C"}, {"C", "°C"}, {" mmHg", "mmHg"}, {" /dk", "/dk"}, {" mg/dL", "mg/dL"}, {" 10^3/uL", "10^3/uL"}}).Draw(rt, "birim")
+		beklenen := float64(yuzde) / 100
+
+		girdi := yaz(yuzde, ayirici[0], ayirici[1]) + birim[0]
+		deger, err := ParseDeger(girdi)
+		if err != nil || deger.Sayi != beklenen || deger.Birim != birim[1] {
+			rt.Fatalf("ParseDeger(%q) = %+v, %v; expected %v %q", girdi, deger, err, beklenen, birim[1])
+		}
+
+		ust := yuzde + rapid.IntRange(0, 10000).Draw(rt, "genislik")
+		girdi = yaz(yuzde, ayirici[0], ayirici[1]) + rapid.SampledFrom([]string{"-", " - ", "–"}).Draw(rt, "tire") + yaz(ust, ayirici[0], ayirici[1]) + birim[0]
+		aralik, err := ParseAralik(girdi)
+		if err != nil || *aralik.Alt != beklenen || *aralik.Ust != float64(ust)/100 || aralik.Birim != birim[1] {
+			rt.Fatalf("ParseAralik(%q) = %+v, %v", girdi, aralik, err)
+		}
+		if !aralik.Icerir(beklenen) || aralik.Icerir(float64(ust)/100+0.01) {
+			rt.Fatalf("Range %q does not contain its bounds only", girdi)
+		}
+	})
+}
+
+// TestMeasurement_Examples verifies inputs seen in VEM 2.0 data
+func TestMeasurement_Examples(t *testing.T) {
+	tests := []struct {
+		girdi  string
+		sayi   float64
+		birim  string
+		isaret string
+	}{
+		{"36,8", 36.8, "", ""},
+		{" 37.2 °C ", 37.2, "°C", ""},
+		{"%95", 95, "%", ""},
+		{"95 %", 95, "%", ""},
+		{"88 bpm", 88, "/dk", ""},
+		{"<0,5", 0.5, "", "<"},
+		{"≥ 200 mg/dL", 200, "mg/dL", ">="},
+		{"1.250,5", 1250.5, "", ""},
+		{"250.000,0", 250000, "", ""},
+		{"1.250.000", 1250000, "", ""},
+		{"250.000 /uL", 250000, "/uL", ""},
+		{"250.000 10^3/uL", 250, "10^3/uL", ""},
+		{"1.100", 1.1, "", ""},
+		{"5.200", 5.2, "", ""},
+		{"1.015", 1.015, "", ""},
+		{"-1.500", -1.5, "", ""},
+		{"0.125", 0.125, "", ""},
+		{"37.25", 37.25, "", ""},
+		{"1250.000", 1250, "", ""},
+		{"36,", 36, "", ""},
+	}
+	for _, tt := range tests {
+		deger, err := ParseDeger(tt.girdi)
+		if err != nil || deger.Sayi != tt.sayi || deger.Birim != tt.birim || deger.Isaret != tt.isaret {
+			t.Errorf("ParseDeger(%q) = %+v, %v", tt.girdi, deger, err)
+		}
+	}
+
+	for _, girdi := range []string{"120/80", "3,5-5,1", "yok", "%95 %", "12 14"} {
+		var parseErr *ParseError
+		if _, err := ParseDeger(girdi); !errors.As(err, &parseErr) {
+			t.Errorf("ParseDeger(%q): expected a parse error, got %v", girdi, err)
+		}
+	}
+	if _, err := ParseDeger("  "); !errors.Is(err, ErrBos) {
+		t.Errorf("Expected ErrBos for a blank value, got %v", err)
+	}
+
+	if a, err := ParseAralik("> 200"); err != nil || a.Ust != nil || *a.Alt != 200 {
+		t.Errorf("ParseAralik(\"> 200\") = %+v, %v", a, err)
+	}
+	for _, girdi := range []string{"5,1-3,5", "4,2", "1-2-3"} {
+		if _, err := ParseAralik(girdi); err == nil {
+			t.Errorf("ParseAralik(%q): expected an error", girdi)
+		}
+	}
+}
+
+// TestMeasurement_KanBasinci verifies the combined notation and the systolic > diastolic check
+func TestMeasurement_KanBasinci(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	sis, dia := KanBasinci(str("120/80 mmHg"), nil)
+	if !sis.Gecerli() || !dia.Gecerli() || *sis.Deger != 120 || *dia.Deger != 80 || sis.Birim != "mmHg" {
+		t.Errorf("Unexpected values for 120/80: %+v %+v", sis, dia)
+	}
+
+	sis, dia = KanBasinci(str("70"), str("90"))
+	if sis.Gecerli() || dia.Gecerli() || sis.Deger == nil || !strings.Contains(sis.Hata, "systolic") {
+		t.Errorf("Expected systolic <= diastolic to be flagged: %+v %+v", sis, dia)
+	}
+
+	sis, dia = KanBasinci(str(""), nil)
+	if sis != nil || dia != nil {
+		t.Errorf("Expected no values for empty fields: %+v %+v", sis, dia)
+	}
+
+	if s := SayisalOf(str("yüksek"), "°C"); s == nil || s.Deger != nil || s.Hata == "" {
+		t.Errorf("Expected a parse error sibling, got %+v", s)
+	}
+}
@@ -0,0 +1,107 @@
+package measurement
+
+import (
+	"errors"
+	"medscreen/internal/utils"
+)
+
+// Sayisal is the parsed JSON sibling of a string-encoded value, e.g. ates_sayisal next to ates.
+// Hata is set when the value could not be parsed (Deger is then nil) or failed validation
+// (Deger is kept so the client can show what was entered).
+type Sayisal struct {
+	Deger  *float64 `json:"deger,omitempty"`
+	Birim  string   `json:"birim,omitempty"`
+	Isaret string   `json:"isaret,omitempty"`
+	Hata   string   `json:"hata,omitempty"`
+}
+
+// Gecerli reports whether the value was parsed and passed validation
+func (s *Sayisal) Gecerli() bool {
+	return s != nil && s.Deger != nil && s.Hata == ""
+}
+
+// SayisalAralik is the parsed JSON sibling of a string-encoded range
+type SayisalAralik struct {
+	Alt   *float64 `json:"alt,omitempty"`
+	Ust   *float64 `json:"ust,omitempty"`
+	Birim string   `json:"birim,omitempty"`
+	Hata  string   `json:"hata,omitempty"`
+}
+
+// Aralik returns the parsed range, or false if it could not be parsed
+func (s *SayisalAralik) Aralik() (Aralik, bool) {
+	if s == nil || s.Hata != "" {
+		return Aralik{}, false
+	}
+	return Aralik{Alt: s.Alt, Ust: s.Ust, Birim: s.Birim}, true
+}
+
+// SayisalOf parses a raw value; varsayilanBirim is used when no unit was written.
+// It returns nil for a nil or empty value, so the sibling is omitted like the raw field.
+func SayisalOf(raw *string, varsayilanBirim string) *Sayisal {
+	if raw == nil {
+		return nil
+	}
+	deger, err := ParseDeger(*raw)
+	if errors.Is(err, ErrBos) {
+		return nil
+	}
+	if err != nil {
+		return &Sayisal{Hata: err.Error()}
+	}
+	return sayisalFrom(deger, varsayilanBirim)
+}
+
+// SayisalAralikOf parses a raw range. It returns nil for a nil or empty value.
+func SayisalAralikOf(raw *string) *SayisalAralik {
+	if raw == nil {
+		return nil
+	}
+	aralik, err := ParseAralik(*raw)
+	if errors.Is(err, ErrBos) {
+		return nil
+	}
+	if err != nil {
+		return &SayisalAralik{Hata: err.Error()}
+	}
+	return &SayisalAralik{Alt: aralik.Alt, Ust: aralik.Ust, Birim: aralik.Birim}
+}
+
+// KanBasinci parses the systolic and diastolic fields of a vital signs record. A systolic
+// field written as "120/80" also provides the diastolic value when that field is empty.
+// Both values are flagged if the systolic pressure is not above the diastolic one.
+func KanBasinci(sistolik, diastolik *string) (sis, dia *Sayisal) {
+	if sistolik != nil {
+		s, d, err := ParseKanBasinci(*sistolik)
+		switch {
+		case errors.Is(err, ErrBos):
+		case err != nil:
+			sis = &Sayisal{Hata: err.Error()}
+		default:
+			sis = sayisalFrom(s, "mmHg")
+			if d != nil && (diastolik == nil || *diastolik == "") {
+				dia = sayisalFrom(*d, "mmHg")
+			}
+		}
+	}
+	if dia == nil {
+		dia = SayisalOf(diastolik, "mmHg")
+	}
+
+	if sis.Gecerli() && dia.Gecerli() {
+		if err := utils.ValidateBloodPressure(sis.Deger, dia.Deger); err != nil {
+			sis.Hata = err.Error()
+			dia.Hata = err.Error()
+		}
+	}
+	return sis, dia
+}
+
+func sayisalFrom(deger Deger, varsayilanBirim string) *Sayisal {
+	sayi := deger.Sayi
+	birim := deger.Birim
+	if birim == "" {
+		birim = varsayilanBirim
+	}
+	return &Sayisal{Deger: &sayi, Birim: birim, Isaret: deger.Isaret}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"medscreen/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader carries the key of an integration (see service.APIAnahtariOneki)
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyRole is the role of requests authenticated with an API key. Their personnel code
+// is the key code prefixed with APIKeyPersonelOneki, so audit records show the integration.
+const (
+	APIKeyRole          = "API"
+	APIKeyPersonelOneki = "api:"
+)
+
+// ContextKeyAPIAnahtari holds the *models.APIAnahtari of a request authenticated with an API key
+const ContextKeyAPIAnahtari = "apiAnahtari"
+
+// APIKeyVerifier checks an API key presented from an address. It returns nil for a key
+// that must be rejected and an error only when the keys cannot be checked.
+type APIKeyVerifier interface {
+	Dogrula(anahtar, ipAdresi string) (*models.APIAnahtari, error)
+}
+
+// APIKeyMiddleware authenticates integrations that send an X-API-Key header. It must run
+// before AuthMiddleware, which then lets the request through; requests without the header
+// are left to AuthMiddleware. No claims are set, so endpoints that need a personnel session
+// reject API keys, and policy.Engine limits them to the resources in their scopes.
+func APIKeyMiddleware(verifier APIKeyVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		anahtar := c.GetHeader(APIKeyHeader)
+		if anahtar == "" {
+			c.Next()
+			return
+		}
+
+		// The allowlist is checked against the connection, never against X-Forwarded-For,
+		// so a key cannot be used from elsewhere by naming an allowed address in a header
+		kayit, err := verifier.Dogrula(anahtar, c.RemoteIP())
+		if err != nil {
+			// Fail closed: a key we cannot check must not be trusted
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "API keys unavailable"})
+			return
+		}
+		if kayit == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid, expired or revoked API key"})
+			return
+		}
+
+		c.Set(ContextKeyAPIAnahtari, kayit)
+		c.Set(ContextKeyPersonelKodu, APIKeyPersonelOneki+kayit.AnahtarKodu)
+		c.Set(ContextKeyUserRole, APIKeyRole)
+		c.Next()
+	}
+}
+
+// GetAPIAnahtari returns the API key of a request authenticated by APIKeyMiddleware
+func GetAPIAnahtari(c *gin.Context) (*models.APIAnahtari, bool) {
+	value, exists := c.Get(ContextKeyAPIAnahtari)
+	if !exists {
+		return nil, false
+	}
+	anahtar, ok := value.(*models.APIAnahtari)
+	return anahtar, ok
+}
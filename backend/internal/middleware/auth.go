@@ -9,10 +9,31 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware is a Gin middleware for JWT authentication
-// For VEM 2.0 read-only system, this validates JWT tokens without audit logging
-func AuthMiddleware() gin.HandlerFunc {
+// Context keys set by AuthMiddleware
+const (
+	ContextKeyClaims          = "claims"
+	ContextKeyPersonelKodu    = "personelKodu"
+	ContextKeyUserRole        = "userRole"
+	ContextKeyTabletCihazKodu = "tabletCihazKodu"
+	ContextKeyAcilErisimKodu  = "acilErisimKodu"
+)
+
+// TokenRevocationChecker reports whether a token is on the revocation list or can no
+// longer be used because its session ended or its NFC card was deactivated
+type TokenRevocationChecker interface {
+	IsRevoked(claims *utils.Claims) (bool, error)
+}
+
+// AuthMiddleware is a Gin middleware for JWT authentication.
+// Only access tokens are accepted, and every token is checked against the revocation list.
+// Requests already authenticated by APIKeyMiddleware are passed through.
+func AuthMiddleware(revocations TokenRevocationChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if _, ok := GetAPIAnahtari(c); ok {
+			c.Next()
+			return
+		}
+
 		// Get the Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -21,26 +42,48 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 		// Parse the token
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		userID, role, err := utils.ParseJWT(tokenString)
-		if err != nil {
+		claims, err := utils.ParseJWT(tokenString)
+		if err != nil || claims.TokenType != utils.TokenTypeAccess {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			return
 		}
-		c.Set("userID", userID)
-		c.Set("userRole", role)
 
-		// Note: Audit context removed for read-only VEM 2.0 system
-		// No write operations are performed, so audit logging is not needed
+		revoked, err := revocations.IsRevoked(claims)
+		if err != nil {
+			// Fail closed: a token we cannot check must not be trusted
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Token revocation list unavailable"})
+			return
+		}
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked or its session has ended"})
+			return
+		}
+
+		c.Set(ContextKeyClaims, claims)
+		c.Set(ContextKeyPersonelKodu, claims.PersonelKodu)
+		c.Set(ContextKeyUserRole, claims.Role)
+		c.Set(ContextKeyTabletCihazKodu, claims.TabletCihazKodu)
+		c.Set(ContextKeyAcilErisimKodu, claims.AcilErisimKodu)
 
 		c.Next()
 	}
 }
 
+// GetClaims returns the claims of the authenticated request
+func GetClaims(c *gin.Context) (*utils.Claims, bool) {
+	value, exists := c.Get(ContextKeyClaims)
+	if !exists {
+		return nil, false
+	}
+	claims, ok := value.(*utils.Claims)
+	return claims, ok
+}
+
 // RoleMiddleware checks if the user has one of the allowed roles
 // Uses PersonelGorevKodu from VEM 2.0 schema
 func RoleMiddleware(allowedRoles ...models.PersonelGorevKodu) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		roleString, exists := c.Get("userRole")
+		roleString, exists := c.Get(ContextKeyUserRole)
 		if !exists {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "User role not found"})
 			return
@@ -57,3 +100,18 @@ func RoleMiddleware(allowedRoles ...models.PersonelGorevKodu) gin.HandlerFunc {
 		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
 	}
 }
+
+// AdminMiddleware allows only the listed personnel (administrators, auditors)
+func AdminMiddleware(personelKodlari []string) gin.HandlerFunc {
+	admins := make(map[string]bool, len(personelKodlari))
+	for _, kodu := range personelKodlari {
+		admins[kodu] = true
+	}
+	return func(c *gin.Context) {
+		if !admins[c.GetString(ContextKeyPersonelKodu)] {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// AcilErisimSebebi is the reason code of a break-the-glass access
+type AcilErisimSebebi string
+
+const (
+	AcilErisimHayatiTehlike AcilErisimSebebi = "HAYATI_TEHLIKE" // life-threatening condition
+	AcilErisimAcilMudahale  AcilErisimSebebi = "ACIL_MUDAHALE"  // urgent intervention outside the clinician's unit
+	AcilErisimKonsultasyon  AcilErisimSebebi = "KONSULTASYON"   // urgent consultation
+	AcilErisimSistemArizasi AcilErisimSebebi = "SISTEM_ARIZASI" // normal access path unavailable
+	AcilErisimDiger         AcilErisimSebebi = "DIGER"
+)
+
+// Valid reports whether the reason code is known
+func (s AcilErisimSebebi) Valid() bool {
+	switch s {
+	case AcilErisimHayatiTehlike, AcilErisimAcilMudahale, AcilErisimKonsultasyon, AcilErisimSistemArizasi, AcilErisimDiger:
+		return true
+	}
+	return false
+}
+
+// AcilErisim is a break-the-glass grant: a time-boxed token that overrides the
+// authorization policy. It is not part of VEM 2.0 and lives in the medscreen schema.
+// Every access made with the token carries AcilErisimKodu in the access audit trail,
+// and the grant stays in the review queue until a supervisor signs it off.
+type AcilErisim struct {
+	AcilErisimID          uint             `gorm:"column:acil_erisim_id;primaryKey;autoIncrement" json:"acil_erisim_id"`
+	AcilErisimKodu        string           `gorm:"column:acil_erisim_kodu;not null;uniqueIndex" json:"acil_erisim_kodu"`
+	JTI                   string           `gorm:"column:jti;not null;uniqueIndex" json:"jti"`
+	PersonelKodu          string           `gorm:"column:personel_kodu;not null;index" json:"personel_kodu"`
+	PersonelRolu          string           `gorm:"column:personel_rolu;not null" json:"personel_rolu"`
+	NFCKartKodu           *string          `gorm:"column:nfc_kart_kodu" json:"nfc_kart_kodu,omitempty"`
+	TabletCihazKodu       *string          `gorm:"column:tablet_cihaz_kodu" json:"tablet_cihaz_kodu,omitempty"`
+	SebepKodu             AcilErisimSebebi `gorm:"column:sebep_kodu;not null" json:"sebep_kodu"`
+	Gerekce               string           `gorm:"column:gerekce;not null" json:"gerekce"`
+	BaslangicZamani       time.Time        `gorm:"column:baslangic_zamani;not null;index" json:"baslangic_zamani"`
+	BitisZamani           time.Time        `gorm:"column:bitis_zamani;not null" json:"bitis_zamani"`
+	IncelemeZamani        *time.Time       `gorm:"column:inceleme_zamani;index" json:"inceleme_zamani,omitempty"`
+	InceleyenPersonelKodu *string          `gorm:"column:inceleyen_personel_kodu" json:"inceleyen_personel_kodu,omitempty"`
+	IncelemeNotu          *string          `gorm:"column:inceleme_notu" json:"inceleme_notu,omitempty"`
+}
+
+// TableName returns the MedScreen-owned table name
+func (AcilErisim) TableName() string {
+	return "medscreen.acil_erisim"
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"medscreen/internal/query"
+	"time"
+)
 
 // AnlikYatanHasta represents a current inpatient in the VEM 2.0 schema (new entity)
 type AnlikYatanHasta struct {
@@ -23,3 +26,20 @@ type AnlikYatanHasta struct {
 func (AnlikYatanHasta) TableName() string {
 	return "anlik_yatan_hasta"
 }
+
+// AnlikYatanHastaSorgusu declares the filter, sort and include parameters of lists of current inpatients
+var AnlikYatanHastaSorgusu = query.Schema{
+	Model: AnlikYatanHasta{},
+	Key:   "anlik_yatan_hasta_kodu",
+	Fields: []query.Field{
+		{Name: "anlik_yatan_hasta_kodu", Filter: true, Sort: true},
+		{Name: "hasta_basvuru_kodu", Filter: true, Sort: true},
+		{Name: "hasta_kodu", Filter: true, Sort: true},
+		{Name: "yatak_kodu", Filter: true, Sort: true},
+		{Name: "birim_kodu", Filter: true, Sort: true},
+		{Name: "yatis_zamani", Type: query.Time, Filter: true, Sort: true},
+		{Name: "hekim_kodu", Filter: true, Sort: true},
+	},
+	Includes:        map[string]string{"hasta": "Hasta", "yatak": "Yatak", "hekim": "Hekim", "hasta_basvuru": "HastaBasvuru", "hasta_basvuru.hasta": "HastaBasvuru.Hasta"},
+	DefaultIncludes: []string{"hasta", "yatak", "hekim", "hasta_basvuru"},
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// APIAnahtari is a key that lets an integration (a wall display, a reporting job, the
+// kitchen system) call the API without a personnel login. It is not part of VEM 2.0 and
+// lives in the medscreen schema. Only a SHA-256 digest of the secret is stored.
+// Kapsamlar lists the resources the key may read ("yatak:read"); IzinliIPler optionally
+// limits the addresses (IPs or CIDR prefixes) it may be used from.
+type APIAnahtari struct {
+	APIAnahtariID         uint       `gorm:"column:api_anahtari_id;primaryKey;autoIncrement" json:"api_anahtari_id"`
+	AnahtarKodu           string     `gorm:"column:anahtar_kodu;uniqueIndex;not null" json:"anahtar_kodu"`
+	Ad                    string     `gorm:"column:ad;not null" json:"ad"`
+	AnahtarOzeti          string     `gorm:"column:anahtar_ozeti;not null" json:"-"`
+	Kapsamlar             []string   `gorm:"column:kapsamlar;serializer:json;not null" json:"kapsamlar"`
+	IzinliIPler           []string   `gorm:"column:izinli_ipler;serializer:json" json:"izinli_ipler,omitempty"`
+	SonGecerlilikZamani   *time.Time `gorm:"column:son_gecerlilik_zamani" json:"son_gecerlilik_zamani,omitempty"`
+	SonKullanilmaZamani   *time.Time `gorm:"column:son_kullanilma_zamani" json:"son_kullanilma_zamani,omitempty"`
+	SonKullananIPAdresi   *string    `gorm:"column:son_kullanan_ip_adresi" json:"son_kullanan_ip_adresi,omitempty"`
+	OlusturanPersonelKodu string     `gorm:"column:olusturan_personel_kodu;not null" json:"olusturan_personel_kodu"`
+	OlusturmaZamani       time.Time  `gorm:"column:olusturma_zamani;not null" json:"olusturma_zamani"`
+	IptalZamani           *time.Time `gorm:"column:iptal_zamani" json:"iptal_zamani,omitempty"`
+	IptalEdenPersonelKodu *string    `gorm:"column:iptal_eden_personel_kodu" json:"iptal_eden_personel_kodu,omitempty"`
+}
+
+// TableName returns the MedScreen-owned table name
+func (APIAnahtari) TableName() string {
+	return "medscreen.api_anahtari"
+}
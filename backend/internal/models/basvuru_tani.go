@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"medscreen/internal/query"
+	"time"
+)
 
 // BasvuruTani represents a diagnosis in the VEM 2.0 schema (replaces Diagnosis)
 type BasvuruTani struct {
@@ -25,3 +28,22 @@ type BasvuruTani struct {
 func (BasvuruTani) TableName() string {
 	return "basvuru_tani"
 }
+
+// BasvuruTaniSorgusu declares the filter, sort and include parameters of lists of diagnoses
+var BasvuruTaniSorgusu = query.Schema{
+	Model: BasvuruTani{},
+	Key:   "basvuru_tani_kodu",
+	Fields: []query.Field{
+		{Name: "basvuru_tani_kodu", Filter: true, Sort: true},
+		{Name: "hasta_kodu", Filter: true, Sort: true},
+		{Name: "hasta_basvuru_kodu", Filter: true, Sort: true},
+		{Name: "tani_kodu", Filter: true, Sort: true},
+		{Name: "tani_turu", Filter: true, Sort: true},
+		{Name: "birincil_tani", Type: query.Number, Filter: true, Sort: true},
+		{Name: "tani_zamani", Type: query.Time, Filter: true, Sort: true},
+		{Name: "hekim_kodu", Filter: true, Sort: true},
+	},
+	Includes:        map[string]string{"hasta": "Hasta", "hasta_basvuru": "HastaBasvuru", "hasta_basvuru.hasta": "HastaBasvuru.Hasta", "hekim": "Hekim"},
+	DefaultIncludes: []string{"hasta", "hasta_basvuru", "hekim"},
+	DefaultSort:     "-tani_zamani",
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"medscreen/internal/query"
+	"time"
+)
 
 // BasvuruYemek represents patient diet/meal information in the VEM 2.0 schema (new entity)
 type BasvuruYemek struct {
@@ -19,3 +22,19 @@ type BasvuruYemek struct {
 func (BasvuruYemek) TableName() string {
 	return "basvuru_yemek"
 }
+
+// BasvuruYemekSorgusu declares the filter, sort and include parameters of lists of meals
+var BasvuruYemekSorgusu = query.Schema{
+	Model: BasvuruYemek{},
+	Key:   "basvuru_yemek_kodu",
+	Fields: []query.Field{
+		{Name: "basvuru_yemek_kodu", Filter: true, Sort: true},
+		{Name: "hasta_basvuru_kodu", Filter: true, Sort: true},
+		{Name: "yemek_zamani_turu", Filter: true, Sort: true},
+		{Name: "yemek_turu", Filter: true, Sort: true},
+		{Name: "kayit_zamani", Type: query.Time, Filter: true, Sort: true},
+	},
+	Includes:        map[string]string{"hasta_basvuru": "HastaBasvuru", "hasta_basvuru.hasta": "HastaBasvuru.Hasta"},
+	DefaultIncludes: []string{"hasta_basvuru"},
+	DefaultSort:     "-kayit_zamani",
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// CihazKaydi is the enrollment and heartbeat state of a tablet (TabletCihaz).
+// It is not part of VEM 2.0 and lives in the medscreen schema, since the VEM tablet
+// record is read-only for MedScreen. Only a SHA-256 digest of the device credential is stored.
+type CihazKaydi struct {
+	CihazKaydiID     uint       `gorm:"column:cihaz_kaydi_id;primaryKey;autoIncrement" json:"cihaz_kaydi_id"`
+	TabletCihazKodu  string     `gorm:"column:tablet_cihaz_kodu;uniqueIndex;not null" json:"tablet_cihaz_kodu"`
+	SeriNumarasi     string     `gorm:"column:seri_numarasi;not null" json:"seri_numarasi"`
+	AnahtarOzeti     string     `gorm:"column:anahtar_ozeti;not null" json:"-"`
+	KayitZamani      time.Time  `gorm:"column:kayit_zamani;not null" json:"kayit_zamani"`
+	SonGorulmeZamani *time.Time `gorm:"column:son_gorulme_zamani;index" json:"son_gorulme_zamani,omitempty"`
+	IPAdresi         *string    `gorm:"column:ip_adresi" json:"ip_adresi,omitempty"`
+	UygulamaSurumu   *string    `gorm:"column:uygulama_surumu" json:"uygulama_surumu,omitempty"`
+	PilSeviyesi      *int       `gorm:"column:pil_seviyesi" json:"pil_seviyesi,omitempty"`
+}
+
+// TableName returns the MedScreen-owned table name
+func (CihazKaydi) TableName() string {
+	return "medscreen.cihaz_kaydi"
+}
+
+// CihazKayitKodu is the one-time code an admin issues for a tablet to enroll with. Only a
+// SHA-256 digest of the code is stored; it is deleted when the tablet enrolls.
+type CihazKayitKodu struct {
+	TabletCihazKodu       string    `gorm:"column:tablet_cihaz_kodu;primaryKey" json:"tablet_cihaz_kodu"`
+	KodOzeti              string    `gorm:"column:kod_ozeti;not null" json:"-"`
+	OlusturanPersonelKodu string    `gorm:"column:olusturan_personel_kodu;not null" json:"olusturan_personel_kodu"`
+	OlusturmaZamani       time.Time `gorm:"column:olusturma_zamani;not null" json:"olusturma_zamani"`
+	SonKullanmaZamani     time.Time `gorm:"column:son_kullanma_zamani;not null" json:"son_kullanma_zamani"`
+}
+
+// TableName returns the MedScreen-owned table name
+func (CihazKayitKodu) TableName() string {
+	return "medscreen.cihaz_kayit_kodu"
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// ErisimKaydi is a patient data access record kept for KVKK compliance.
+// It is not part of VEM 2.0 and lives in the medscreen schema.
+// A request that returns several patients produces one record per patient,
+// all sharing the same IstekKodu. Accesses made with a break-the-glass token carry
+// the code of the emergency access grant.
+type ErisimKaydi struct {
+	ErisimKaydiID    uint      `gorm:"column:erisim_kaydi_id;primaryKey;autoIncrement" json:"erisim_kaydi_id"`
+	IstekKodu        string    `gorm:"column:istek_kodu;not null;index" json:"istek_kodu"`
+	ErisimZamani     time.Time `gorm:"column:erisim_zamani;not null;index" json:"erisim_zamani"`
+	PersonelKodu     string    `gorm:"column:personel_kodu;not null;index" json:"personel_kodu"`
+	PersonelRolu     string    `gorm:"column:personel_rolu" json:"personel_rolu"`
+	TabletCihazKodu  *string   `gorm:"column:tablet_cihaz_kodu" json:"tablet_cihaz_kodu,omitempty"`
+	IPAdresi         string    `gorm:"column:ip_adresi;not null" json:"ip_adresi"`
+	Metot            string    `gorm:"column:metot;not null" json:"metot"`
+	Rota             string    `gorm:"column:rota;not null" json:"rota"`
+	Yol              string    `gorm:"column:yol;not null" json:"yol"`
+	HastaKodu        *string   `gorm:"column:hasta_kodu;index" json:"hasta_kodu,omitempty"`
+	HastaBasvuruKodu *string   `gorm:"column:hasta_basvuru_kodu;index" json:"hasta_basvuru_kodu,omitempty"`
+	DurumKodu        int       `gorm:"column:durum_kodu;not null" json:"durum_kodu"`
+	SonucSayisi      int       `gorm:"column:sonuc_sayisi;not null" json:"sonuc_sayisi"`
+	AcilErisimKodu   *string   `gorm:"column:acil_erisim_kodu;index" json:"acil_erisim_kodu,omitempty"`
+}
+
+// TableName returns the MedScreen-owned table name
+func (ErisimKaydi) TableName() string {
+	return "medscreen.erisim_kaydi"
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// GirisEngeli blocks a personnel or an NFC card from logging in, e.g. after a lost badge
+// has been revoked, until an administrator lifts it. It is not part of VEM 2.0 and lives
+// in the medscreen schema. Exactly one of PersonelKodu and NFCKartKodu is set.
+type GirisEngeli struct {
+	GirisEngeliID          uint       `gorm:"column:giris_engeli_id;primaryKey;autoIncrement" json:"giris_engeli_id"`
+	PersonelKodu           *string    `gorm:"column:personel_kodu;index" json:"personel_kodu,omitempty"`
+	NFCKartKodu            *string    `gorm:"column:nfc_kart_kodu;index" json:"nfc_kart_kodu,omitempty"`
+	EngelZamani            time.Time  `gorm:"column:engel_zamani;not null" json:"engel_zamani"`
+	EngelleyenPersonelKodu string     `gorm:"column:engelleyen_personel_kodu;not null" json:"engelleyen_personel_kodu"`
+	Sebep                  *string    `gorm:"column:sebep" json:"sebep,omitempty"`
+	KaldirmaZamani         *time.Time `gorm:"column:kaldirma_zamani" json:"kaldirma_zamani,omitempty"`
+	KaldiranPersonelKodu   *string    `gorm:"column:kaldiran_personel_kodu" json:"kaldiran_personel_kodu,omitempty"`
+}
+
+// TableName returns the MedScreen-owned table name
+func (GirisEngeli) TableName() string {
+	return "medscreen.giris_engeli"
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"medscreen/internal/query"
+	"time"
+)
 
 // Hasta represents a patient in the VEM 2.0 schema (replaces Patient)
 type Hasta struct {
@@ -27,3 +30,22 @@ type Hasta struct {
 func (Hasta) TableName() string {
 	return "hasta"
 }
+
+// HastaSorgusu declares the filter, sort and include parameters of lists of patients
+var HastaSorgusu = query.Schema{
+	Model: Hasta{},
+	Key:   "hasta_kodu",
+	Fields: []query.Field{
+		{Name: "hasta_kodu", Filter: true, Sort: true},
+		{Name: "ad", Filter: true, Sort: true, Turkish: true},
+		{Name: "soyadi", Filter: true, Sort: true, Turkish: true},
+		{Name: "dogum_tarihi", Type: query.Time, Filter: true, Sort: true},
+		{Name: "cinsiyet", Filter: true},
+		{Name: "kan_grubu", Filter: true},
+		{Name: "uyruk", Filter: true},
+		{Name: "hasta_tipi", Filter: true},
+		{Name: "kayit_zamani", Type: query.Time, Filter: true, Sort: true},
+	},
+	Includes: map[string]string{"anne": "Anne", "baba": "Baba"},
+	Entity:   "hasta",
+}
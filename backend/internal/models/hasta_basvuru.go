@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"medscreen/internal/query"
+	"time"
+)
 
 // HastaBasvuru represents a patient visit/admission in the VEM 2.0 schema (replaces Appointment)
 type HastaBasvuru struct {
@@ -24,3 +27,23 @@ type HastaBasvuru struct {
 func (HastaBasvuru) TableName() string {
 	return "hasta_basvuru"
 }
+
+// HastaBasvuruSorgusu declares the filter, sort and include parameters of lists of patient visits
+var HastaBasvuruSorgusu = query.Schema{
+	Model: HastaBasvuru{},
+	Key:   "hasta_basvuru_kodu",
+	Fields: []query.Field{
+		{Name: "hasta_basvuru_kodu", Filter: true, Sort: true},
+		{Name: "hasta_kodu", Filter: true, Sort: true},
+		{Name: "basvuru_protokol_numarasi", Filter: true, Sort: true},
+		{Name: "hasta_kabul_zamani", Type: query.Time, Filter: true, Sort: true},
+		{Name: "cikis_zamani", Type: query.Time, Filter: true, Sort: true},
+		{Name: "hekim_kodu", Filter: true, Sort: true},
+		{Name: "basvuru_durumu", Filter: true, Sort: true},
+		{Name: "hayati_tehlike_durumu", Filter: true, Sort: true},
+		{Name: "kayit_zamani", Type: query.Time, Filter: true, Sort: true},
+	},
+	Includes:        map[string]string{"hasta": "Hasta", "hekim": "Hekim"},
+	DefaultIncludes: []string{"hasta", "hekim"},
+	DefaultSort:     "-hasta_kabul_zamani",
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"medscreen/internal/query"
+	"time"
+)
 
 // HastaTibbiBilgi represents patient medical information in the VEM 2.0 schema
 // (replaces Allergy, MedicalHistory, SurgeryHistory)
@@ -21,3 +24,19 @@ type HastaTibbiBilgi struct {
 func (HastaTibbiBilgi) TableName() string {
 	return "hasta_tibbi_bilgi"
 }
+
+// HastaTibbiBilgiSorgusu declares the filter, sort and include parameters of lists of medical information
+var HastaTibbiBilgiSorgusu = query.Schema{
+	Model: HastaTibbiBilgi{},
+	Key:   "hasta_tibbi_bilgi_kodu",
+	Fields: []query.Field{
+		{Name: "hasta_tibbi_bilgi_kodu", Filter: true, Sort: true},
+		{Name: "hasta_kodu", Filter: true, Sort: true},
+		{Name: "tibbi_bilgi_turu_kodu", Filter: true, Sort: true},
+		{Name: "tibbi_bilgi_alt_turu_kodu", Filter: true, Sort: true},
+		{Name: "kayit_zamani", Type: query.Time, Filter: true, Sort: true},
+	},
+	Includes:        map[string]string{"hasta": "Hasta"},
+	DefaultIncludes: []string{"hasta"},
+	DefaultSort:     "-kayit_zamani",
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"medscreen/internal/query"
+	"time"
+)
 
 // HastaUyari represents patient warnings/alerts in the VEM 2.0 schema (new entity)
 type HastaUyari struct {
@@ -20,3 +23,19 @@ type HastaUyari struct {
 func (HastaUyari) TableName() string {
 	return "hasta_uyari"
 }
+
+// HastaUyariSorgusu declares the filter, sort and include parameters of lists of patient warnings
+var HastaUyariSorgusu = query.Schema{
+	Model: HastaUyari{},
+	Key:   "hasta_uyari_kodu",
+	Fields: []query.Field{
+		{Name: "hasta_uyari_kodu", Filter: true, Sort: true},
+		{Name: "hasta_basvuru_kodu", Filter: true, Sort: true},
+		{Name: "uyari_turu", Filter: true, Sort: true},
+		{Name: "aktiflik_bilgisi", Type: query.Number, Filter: true, Sort: true},
+		{Name: "kayit_zamani", Type: query.Time, Filter: true, Sort: true},
+	},
+	Includes:        map[string]string{"hasta_basvuru": "HastaBasvuru", "hasta_basvuru.hasta": "HastaBasvuru.Hasta"},
+	DefaultIncludes: []string{"hasta_basvuru"},
+	DefaultSort:     "-kayit_zamani",
+}
@@ -1,6 +1,12 @@
 package models
 
-import "time"
+import (
+	"medscreen/internal/measurement"
+	"medscreen/internal/query"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // HastaVitalFizikiBulgu represents patient vital signs in the VEM 2.0 schema (replaces VitalSign)
 type HastaVitalFizikiBulgu struct {
@@ -22,9 +28,53 @@ type HastaVitalFizikiBulgu struct {
 	EkleyenKullaniciKodu      string        `gorm:"column:ekleyen_kullanici_kodu;not null" json:"ekleyen_kullanici_kodu"`
 	GuncellemeZamani          *time.Time    `gorm:"column:guncelleme_zamani" json:"guncelleme_zamani,omitempty"`
 	GuncelleyenKullaniciKodu  *string       `gorm:"column:guncelleyen_kullanici_kodu" json:"guncelleyen_kullanici_kodu,omitempty"`
+
+	// Parsed values of the string-encoded measurements (not stored)
+	AtesSayisal                      *measurement.Sayisal `gorm:"-" json:"ates_sayisal,omitempty"`
+	NabizSayisal                     *measurement.Sayisal `gorm:"-" json:"nabiz_sayisal,omitempty"`
+	SistolikKanBasinciDegeriSayisal  *measurement.Sayisal `gorm:"-" json:"sistolik_kan_basinci_degeri_sayisal,omitempty"`
+	DiastolikKanBasinciDegeriSayisal *measurement.Sayisal `gorm:"-" json:"diastolik_kan_basinci_degeri_sayisal,omitempty"`
+	SolunumSayisal                   *measurement.Sayisal `gorm:"-" json:"solunum_sayisal,omitempty"`
+	SaturasyonSayisal                *measurement.Sayisal `gorm:"-" json:"saturasyon_sayisal,omitempty"`
+	BoySayisal                       *measurement.Sayisal `gorm:"-" json:"boy_sayisal,omitempty"`
+	AgirlikSayisal                   *measurement.Sayisal `gorm:"-" json:"agirlik_sayisal,omitempty"`
 }
 
 // TableName returns the VEM 2.0 table name
 func (HastaVitalFizikiBulgu) TableName() string {
 	return "hasta_vital_fiziki_bulgu"
 }
+
+// ParseSayisalDegerler fills the parsed siblings of the string-encoded measurements
+func (b *HastaVitalFizikiBulgu) ParseSayisalDegerler() {
+	b.AtesSayisal = measurement.SayisalOf(b.Ates, "°C")
+	b.NabizSayisal = measurement.SayisalOf(b.Nabiz, "/dk")
+	b.SistolikKanBasinciDegeriSayisal, b.DiastolikKanBasinciDegeriSayisal = measurement.KanBasinci(b.SistolikKanBasinciDegeri, b.DiastolikKanBasinciDegeri)
+	b.SolunumSayisal = measurement.SayisalOf(b.Solunum, "/dk")
+	b.SaturasyonSayisal = measurement.SayisalOf(b.Saturasyon, "%")
+	b.BoySayisal = measurement.SayisalOf(b.Boy, "cm")
+	b.AgirlikSayisal = measurement.SayisalOf(b.Agirlik, "kg")
+}
+
+// AfterFind parses the measurements of every loaded record, including preloaded ones
+func (b *HastaVitalFizikiBulgu) AfterFind(tx *gorm.DB) error {
+	b.ParseSayisalDegerler()
+	return nil
+}
+
+// HastaVitalFizikiBulguSorgusu declares the filter, sort and include parameters of lists of vital signs
+var HastaVitalFizikiBulguSorgusu = query.Schema{
+	Model: HastaVitalFizikiBulgu{},
+	Key:   "hasta_vital_fiziki_bulgu_kodu",
+	Fields: []query.Field{
+		{Name: "hasta_vital_fiziki_bulgu_kodu", Filter: true, Sort: true},
+		{Name: "hasta_basvuru_kodu", Filter: true, Sort: true},
+		{Name: "islem_zamani", Type: query.Time, Filter: true, Sort: true},
+		{Name: "hemsire_kodu", Filter: true, Sort: true},
+		{Name: "kayit_zamani", Type: query.Time, Filter: true, Sort: true},
+	},
+	Includes:        map[string]string{"hasta_basvuru": "HastaBasvuru", "hasta_basvuru.hasta": "HastaBasvuru.Hasta", "hemsire": "Hemsire"},
+	DefaultIncludes: []string{"hasta_basvuru", "hemsire"},
+	DefaultSort:     "-islem_zamani",
+	Cursor:          "islem_zamani",
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// HL7MesajDurumu is the delivery state of an outbound HL7 v2 message
+type HL7MesajDurumu string
+
+const (
+	HL7MesajBekliyor   HL7MesajDurumu = "BEKLIYOR"   // waiting to be delivered or retried
+	HL7MesajGonderildi HL7MesajDurumu = "GONDERILDI" // accepted by the receiver
+	HL7MesajOlu        HL7MesajDurumu = "OLU"        // dead letter: rejected or out of attempts
+)
+
+// HL7Mesaj is an HL7 v2 message of the outbound feed to one MLLP endpoint (Hedef). It is
+// not part of VEM 2.0 and lives in the medscreen schema. The table is both the outbox the
+// messages are delivered from, in order, and the dead-letter store of the ones that were
+// given up on. OlayAnahtari identifies the VEM change a message was generated from, so a
+// change that is detected again (after a restart) is not sent twice.
+type HL7Mesaj struct {
+	HL7MesajID       uint           `gorm:"column:hl7_mesaj_id;primaryKey;autoIncrement" json:"hl7_mesaj_id"`
+	Hedef            string         `gorm:"column:hedef;not null;uniqueIndex:ux_hl7_mesaj_hedef_olay;index:ix_hl7_mesaj_kuyruk" json:"hedef"`
+	OlayAnahtari     string         `gorm:"column:olay_anahtari;not null;uniqueIndex:ux_hl7_mesaj_hedef_olay" json:"olay_anahtari"`
+	MesajKontrolKodu string         `gorm:"column:mesaj_kontrol_kodu;uniqueIndex;not null" json:"mesaj_kontrol_kodu"`
+	MesajTuru        string         `gorm:"column:mesaj_turu;not null" json:"mesaj_turu"`
+	HastaBasvuruKodu string         `gorm:"column:hasta_basvuru_kodu;index;not null" json:"hasta_basvuru_kodu"`
+	OlayZamani       time.Time      `gorm:"column:olay_zamani;index;not null" json:"olay_zamani"`
+	Icerik           string         `gorm:"column:icerik;type:text;not null" json:"icerik"`
+	Durum            HL7MesajDurumu `gorm:"column:durum;not null;index:ix_hl7_mesaj_kuyruk" json:"durum"`
+	DenemeSayisi     int            `gorm:"column:deneme_sayisi;not null" json:"deneme_sayisi"`
+	SonrakiDeneme    time.Time      `gorm:"column:sonraki_deneme;not null" json:"sonraki_deneme"`
+	SonHata          *string        `gorm:"column:son_hata" json:"son_hata,omitempty"`
+	OlusturmaZamani  time.Time      `gorm:"column:olusturma_zamani;not null" json:"olusturma_zamani"`
+	SonucZamani      *time.Time     `gorm:"column:sonuc_zamani" json:"sonuc_zamani,omitempty"`
+}
+
+// TableName returns the MedScreen-owned table name
+func (HL7Mesaj) TableName() string {
+	return "medscreen.hl7_mesaj"
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// IkinciFaktor is the second authentication factor of a personnel: a PIN, a TOTP
+// authenticator or both. It is not part of VEM 2.0 and lives in the medscreen schema.
+// Only a salted PBKDF2 hash of the PIN is stored. SonTOTPAdimi is the last accepted TOTP
+// time step, so a code cannot be used twice.
+type IkinciFaktor struct {
+	IkinciFaktorID          uint       `gorm:"column:ikinci_faktor_id;primaryKey;autoIncrement" json:"ikinci_faktor_id"`
+	PersonelKodu            string     `gorm:"column:personel_kodu;uniqueIndex;not null" json:"personel_kodu"`
+	PinOzeti                *string    `gorm:"column:pin_ozeti" json:"-"`
+	TOTPAnahtari            *string    `gorm:"column:totp_anahtari" json:"-"`
+	SonTOTPAdimi            int64      `gorm:"column:son_totp_adimi;not null;default:0" json:"-"`
+	BasarisizDeneme         int        `gorm:"column:basarisiz_deneme;not null;default:0" json:"basarisiz_deneme"`
+	KilitBitisZamani        *time.Time `gorm:"column:kilit_bitis_zamani" json:"kilit_bitis_zamani,omitempty"`
+	GuncelleyenPersonelKodu string     `gorm:"column:guncelleyen_personel_kodu;not null" json:"guncelleyen_personel_kodu"`
+	GuncellemeZamani        time.Time  `gorm:"column:guncelleme_zamani;not null" json:"guncelleme_zamani"`
+}
+
+// TableName returns the MedScreen-owned table name
+func (IkinciFaktor) TableName() string {
+	return "medscreen.ikinci_faktor"
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"medscreen/internal/query"
+	"time"
+)
 
 // KlinikSeyir represents clinical progress notes in the VEM 2.0 schema (new entity)
 type KlinikSeyir struct {
@@ -24,3 +27,23 @@ type KlinikSeyir struct {
 func (KlinikSeyir) TableName() string {
 	return "klinik_seyir"
 }
+
+// KlinikSeyirSorgusu declares the filter, sort and include parameters of lists of clinical notes
+var KlinikSeyirSorgusu = query.Schema{
+	Model: KlinikSeyir{},
+	Key:   "klinik_seyir_kodu",
+	Fields: []query.Field{
+		{Name: "klinik_seyir_kodu", Filter: true, Sort: true},
+		{Name: "hasta_basvuru_kodu", Filter: true, Sort: true},
+		{Name: "seyir_tipi", Filter: true, Sort: true},
+		{Name: "seyir_zamani", Type: query.Time, Filter: true, Sort: true},
+		{Name: "septik_sok", Type: query.Number, Filter: true, Sort: true},
+		{Name: "sepsis_durumu", Type: query.Number, Filter: true, Sort: true},
+		{Name: "hekim_kodu", Filter: true, Sort: true},
+		{Name: "kayit_zamani", Type: query.Time, Filter: true, Sort: true},
+	},
+	Includes:        map[string]string{"hasta_basvuru": "HastaBasvuru", "hasta_basvuru.hasta": "HastaBasvuru.Hasta", "hekim": "Hekim"},
+	DefaultIncludes: []string{"hasta_basvuru", "hekim"},
+	DefaultSort:     "-seyir_zamani",
+	Cursor:          "seyir_zamani",
+}
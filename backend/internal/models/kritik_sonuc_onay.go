@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// KritikSonucOnay records that a clinician has acknowledged a critical test result.
+// It is not part of VEM 2.0 and lives in the medscreen schema.
+type KritikSonucOnay struct {
+	KritikSonucOnayID     uint      `gorm:"column:kritik_sonuc_onay_id;primaryKey;autoIncrement" json:"kritik_sonuc_onay_id"`
+	TetkikSonucKodu       string    `gorm:"column:tetkik_sonuc_kodu;not null;uniqueIndex" json:"tetkik_sonuc_kodu"`
+	HastaBasvuruKodu      string    `gorm:"column:hasta_basvuru_kodu;not null;index" json:"hasta_basvuru_kodu"`
+	OnaylayanPersonelKodu string    `gorm:"column:onaylayan_personel_kodu;not null" json:"onaylayan_personel_kodu"`
+	OnayZamani            time.Time `gorm:"column:onay_zamani;not null" json:"onay_zamani"`
+	Aciklama              *string   `gorm:"column:aciklama" json:"aciklama,omitempty"`
+}
+
+// TableName returns the MedScreen-owned table name
+func (KritikSonucOnay) TableName() string {
+	return "medscreen.kritik_sonuc_onay"
+}
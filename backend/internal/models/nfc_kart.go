@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"medscreen/internal/query"
+	"time"
+)
 
 // NFCKart represents an NFC card in the VEM 2.0 schema (replaces NFCCard)
 type NFCKart struct {
@@ -20,3 +23,18 @@ type NFCKart struct {
 func (NFCKart) TableName() string {
 	return "nfc_kart"
 }
+
+// NFCKartSorgusu declares the filter, sort and include parameters of lists of NFC cards
+var NFCKartSorgusu = query.Schema{
+	Model: NFCKart{},
+	Key:   "nfc_kart_kodu",
+	Fields: []query.Field{
+		{Name: "nfc_kart_kodu", Filter: true, Sort: true},
+		{Name: "personel_kodu", Filter: true, Sort: true},
+		{Name: "son_kullanim_tarihi", Type: query.Time, Filter: true, Sort: true},
+		{Name: "aktiflik_bilgisi", Type: query.Number, Filter: true},
+		{Name: "kayit_zamani", Type: query.Time, Filter: true, Sort: true},
+	},
+	Includes:        map[string]string{"personel": "Personel"},
+	DefaultIncludes: []string{"personel"},
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Oturum is a login session: every token issued from one NFC or SSO login and its refreshes
+// carry the same OturumKodu. It is not part of VEM 2.0 and lives in the medscreen schema.
+// A session ends when it is logged out or revoked (SonlanmaZamani), when it was idle for
+// longer than the idle timeout, or when the NFC card it was opened with is deactivated.
+type Oturum struct {
+	OturumID                uint       `gorm:"column:oturum_id;primaryKey;autoIncrement" json:"oturum_id"`
+	OturumKodu              string     `gorm:"column:oturum_kodu;uniqueIndex;not null" json:"oturum_kodu"`
+	PersonelKodu            string     `gorm:"column:personel_kodu;index;not null" json:"personel_kodu"`
+	GirisYontemi            string     `gorm:"column:giris_yontemi;not null" json:"giris_yontemi"`
+	NFCKartKodu             *string    `gorm:"column:nfc_kart_kodu;index" json:"nfc_kart_kodu,omitempty"`
+	TabletCihazKodu         *string    `gorm:"column:tablet_cihaz_kodu;index" json:"tablet_cihaz_kodu,omitempty"`
+	BirimKodu               *string    `gorm:"column:birim_kodu;index" json:"birim_kodu,omitempty"`
+	YatakKodu               *string    `gorm:"column:yatak_kodu" json:"yatak_kodu,omitempty"`
+	BaslangicZamani         time.Time  `gorm:"column:baslangic_zamani;not null" json:"baslangic_zamani"`
+	SonEtkinlikZamani       time.Time  `gorm:"column:son_etkinlik_zamani;not null" json:"son_etkinlik_zamani"`
+	SonGecerlilikZamani     time.Time  `gorm:"column:son_gecerlilik_zamani;index;not null" json:"son_gecerlilik_zamani"`
+	SonlanmaZamani          *time.Time `gorm:"column:sonlanma_zamani;index" json:"sonlanma_zamani,omitempty"`
+	SonlandiranPersonelKodu *string    `gorm:"column:sonlandiran_personel_kodu" json:"sonlandiran_personel_kodu,omitempty"`
+	SonlanmaSebebi          *string    `gorm:"column:sonlanma_sebebi" json:"sonlanma_sebebi,omitempty"`
+}
+
+// TableName returns the MedScreen-owned table name
+func (Oturum) TableName() string {
+	return "medscreen.oturum"
+}
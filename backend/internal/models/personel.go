@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"medscreen/internal/query"
+	"time"
+)
 
 // Personel represents a staff member in the VEM 2.0 schema (replaces User)
 type Personel struct {
@@ -22,3 +25,19 @@ type Personel struct {
 func (Personel) TableName() string {
 	return "personel"
 }
+
+// PersonelSorgusu declares the filter, sort and include parameters of lists of personnel
+var PersonelSorgusu = query.Schema{
+	Model: Personel{},
+	Key:   "personel_kodu",
+	Fields: []query.Field{
+		{Name: "personel_kodu", Filter: true, Sort: true},
+		{Name: "ad", Filter: true, Sort: true, Turkish: true},
+		{Name: "soyadi", Filter: true, Sort: true, Turkish: true},
+		{Name: "personel_gorev_kodu", Filter: true, Sort: true},
+		{Name: "medula_brans_kodu", Filter: true},
+		{Name: "aktiflik_bilgisi", Type: query.Number, Filter: true},
+		{Name: "kayit_zamani", Type: query.Time, Filter: true, Sort: true},
+	},
+	Entity: "personel",
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"medscreen/internal/query"
+	"time"
+)
 
 // Randevu represents appointment information in the VEM 2.0 schema
 type Randevu struct {
@@ -27,3 +30,23 @@ type Randevu struct {
 func (Randevu) TableName() string {
 	return "randevu"
 }
+
+// RandevuSorgusu declares the filter, sort and include parameters of lists of appointments
+var RandevuSorgusu = query.Schema{
+	Model: Randevu{},
+	Key:   "randevu_kodu",
+	Fields: []query.Field{
+		{Name: "randevu_kodu", Filter: true, Sort: true},
+		{Name: "hasta_kodu", Filter: true, Sort: true},
+		{Name: "hasta_basvuru_kodu", Filter: true, Sort: true},
+		{Name: "hekim_kodu", Filter: true, Sort: true},
+		{Name: "birim_kodu", Filter: true, Sort: true},
+		{Name: "randevu_turu", Filter: true, Sort: true},
+		{Name: "randevu_zamani", Type: query.Time, Filter: true, Sort: true},
+		{Name: "randevu_gelme_durumu", Filter: true, Sort: true},
+		{Name: "iptal_durumu", Type: query.Number, Filter: true, Sort: true},
+	},
+	Includes:        map[string]string{"hasta": "Hasta", "hasta_basvuru": "HastaBasvuru", "hasta_basvuru.hasta": "HastaBasvuru.Hasta", "hekim": "Hekim"},
+	DefaultIncludes: []string{"hasta", "hasta_basvuru", "hekim"},
+	DefaultSort:     "-randevu_zamani",
+}
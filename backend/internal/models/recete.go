@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"medscreen/internal/query"
+	"time"
+)
 
 // Recete represents a prescription in the VEM 2.0 schema (replaces Prescription)
 type Recete struct {
@@ -48,3 +51,34 @@ type ReceteIlac struct {
 func (ReceteIlac) TableName() string {
 	return "recete_ilac"
 }
+
+// ReceteSorgusu declares the filter, sort and include parameters of lists of prescriptions
+var ReceteSorgusu = query.Schema{
+	Model: Recete{},
+	Key:   "recete_kodu",
+	Fields: []query.Field{
+		{Name: "recete_kodu", Filter: true, Sort: true},
+		{Name: "hasta_basvuru_kodu", Filter: true, Sort: true},
+		{Name: "medula_e_recete_numarasi", Filter: true, Sort: true},
+		{Name: "recete_turu_kodu", Filter: true, Sort: true},
+		{Name: "hekim_kodu", Filter: true, Sort: true},
+		{Name: "recete_zamani", Type: query.Time, Filter: true, Sort: true},
+		{Name: "aktiflik_bilgisi", Type: query.Number, Filter: true, Sort: true},
+	},
+	Includes:        map[string]string{"hasta_basvuru": "HastaBasvuru", "hasta_basvuru.hasta": "HastaBasvuru.Hasta", "hekim": "Hekim", "ilaclar": "Ilaclar"},
+	DefaultIncludes: []string{"hasta_basvuru", "hekim", "ilaclar"},
+	DefaultSort:     "-recete_zamani",
+}
+
+// ReceteIlacSorgusu declares the filter, sort and include parameters of lists of the drugs of a prescription
+var ReceteIlacSorgusu = query.Schema{
+	Model: ReceteIlac{},
+	Key:   "recete_ilac_kodu",
+	Fields: []query.Field{
+		{Name: "recete_ilac_kodu", Filter: true, Sort: true},
+		{Name: "barkod", Filter: true, Sort: true},
+		{Name: "ilac_adi", Filter: true, Sort: true, Turkish: true},
+		{Name: "ilac_kullanim_sekli", Filter: true, Sort: true},
+	},
+	Includes: map[string]string{"recete": "Recete"},
+}
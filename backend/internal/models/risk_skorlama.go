@@ -1,6 +1,12 @@
 package models
 
-import "time"
+import (
+	"medscreen/internal/measurement"
+	"medscreen/internal/query"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // RiskSkorlama represents risk scoring in the VEM 2.0 schema (new entity)
 type RiskSkorlama struct {
@@ -14,9 +20,39 @@ type RiskSkorlama struct {
 	EkleyenKullaniciKodu     string        `gorm:"column:ekleyen_kullanici_kodu;not null" json:"ekleyen_kullanici_kodu"`
 	GuncellemeZamani         *time.Time    `gorm:"column:guncelleme_zamani" json:"guncelleme_zamani,omitempty"`
 	GuncelleyenKullaniciKodu *string       `gorm:"column:guncelleyen_kullanici_kodu" json:"guncelleyen_kullanici_kodu,omitempty"`
+
+	// Parsed value of the string-encoded total score (not stored)
+	RiskSkorlamaToplamPuaniSayisal *measurement.Sayisal `gorm:"-" json:"risk_skorlama_toplam_puani_sayisal,omitempty"`
 }
 
 // TableName returns the VEM 2.0 table name
 func (RiskSkorlama) TableName() string {
 	return "risk_skorlama"
 }
+
+// ParseSayisalDegerler fills the parsed sibling of the total score
+func (r *RiskSkorlama) ParseSayisalDegerler() {
+	r.RiskSkorlamaToplamPuaniSayisal = measurement.SayisalOf(&r.RiskSkorlamaToplamPuani, "")
+}
+
+// AfterFind parses the total score of every loaded record, including preloaded ones
+func (r *RiskSkorlama) AfterFind(tx *gorm.DB) error {
+	r.ParseSayisalDegerler()
+	return nil
+}
+
+// RiskSkorlamaSorgusu declares the filter, sort and include parameters of lists of risk scores
+var RiskSkorlamaSorgusu = query.Schema{
+	Model: RiskSkorlama{},
+	Key:   "risk_skorlama_kodu",
+	Fields: []query.Field{
+		{Name: "risk_skorlama_kodu", Filter: true, Sort: true},
+		{Name: "hasta_basvuru_kodu", Filter: true, Sort: true},
+		{Name: "risk_skorlama_turu", Filter: true, Sort: true},
+		{Name: "islem_zamani", Type: query.Time, Filter: true, Sort: true},
+		{Name: "kayit_zamani", Type: query.Time, Filter: true, Sort: true},
+	},
+	Includes:        map[string]string{"hasta_basvuru": "HastaBasvuru", "hasta_basvuru.hasta": "HastaBasvuru.Hasta"},
+	DefaultIncludes: []string{"hasta_basvuru"},
+	DefaultSort:     "-islem_zamani",
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"medscreen/internal/query"
+	"time"
+)
 
 // TabletCihaz represents a tablet device in the VEM 2.0 schema (replaces Device)
 type TabletCihaz struct {
@@ -21,3 +24,18 @@ type TabletCihaz struct {
 func (TabletCihaz) TableName() string {
 	return "tablet_cihaz"
 }
+
+// TabletCihazSorgusu declares the filter, sort and include parameters of lists of tablet devices
+var TabletCihazSorgusu = query.Schema{
+	Model: TabletCihaz{},
+	Key:   "tablet_cihaz_kodu",
+	Fields: []query.Field{
+		{Name: "tablet_cihaz_kodu", Filter: true, Sort: true},
+		{Name: "yatak_kodu", Filter: true, Sort: true},
+		{Name: "seri_numarasi", Filter: true, Sort: true},
+		{Name: "son_gorulme_zamani", Type: query.Time, Filter: true, Sort: true},
+		{Name: "kayit_zamani", Type: query.Time, Filter: true, Sort: true},
+	},
+	Includes:        map[string]string{"yatak": "Yatak"},
+	DefaultIncludes: []string{"yatak"},
+}
@@ -1,6 +1,12 @@
 package models
 
-import "time"
+import (
+	"medscreen/internal/measurement"
+	"medscreen/internal/query"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // TetkikSonuc represents test results in the VEM 2.0 schema (replaces MedicalTest)
 type TetkikSonuc struct {
@@ -13,9 +19,64 @@ type TetkikSonuc struct {
 	OnayZamani           *time.Time    `gorm:"column:onay_zamani" json:"onay_zamani,omitempty"`
 	KayitZamani          time.Time     `gorm:"column:kayit_zamani;not null" json:"kayit_zamani"`
 	EkleyenKullaniciKodu string        `gorm:"column:ekleyen_kullanici_kodu;not null" json:"ekleyen_kullanici_kodu"`
+
+	// Parsed values of the string-encoded result and range (not stored)
+	SonucDegeriSayisal        *measurement.Sayisal       `gorm:"-" json:"sonuc_degeri_sayisal,omitempty"`
+	KritikDegerAraligiSayisal *measurement.SayisalAralik `gorm:"-" json:"kritik_deger_araligi_sayisal,omitempty"`
+	// Degerlendirme is the evaluation of the result against KritikDegerAraligi (not stored)
+	Degerlendirme *TetkikDegerlendirmesi `gorm:"-" json:"degerlendirme,omitempty"`
+}
+
+// TetkikDurumu places a test result relative to its critical value range
+type TetkikDurumu string
+
+const (
+	TetkikDurumuNormal   TetkikDurumu = "NORMAL"
+	TetkikDurumuDusuk    TetkikDurumu = "DUSUK"
+	TetkikDurumuYuksek   TetkikDurumu = "YUKSEK"
+	TetkikDurumuBelirsiz TetkikDurumu = "BELIRSIZ" // the result or the range could not be evaluated
+)
+
+// TetkikDegerlendirmesi is the evaluation of a test result (computed by the service layer)
+type TetkikDegerlendirmesi struct {
+	Durum  TetkikDurumu `json:"durum"`
+	Kritik bool         `json:"kritik"`
 }
 
 // TableName returns the VEM 2.0 table name
 func (TetkikSonuc) TableName() string {
 	return "tetkik_sonuc"
 }
+
+// ParseSayisalDegerler fills the parsed siblings of the result and the critical value range
+func (t *TetkikSonuc) ParseSayisalDegerler() {
+	t.SonucDegeriSayisal = measurement.SayisalOf(t.SonucDegeri, "")
+	t.KritikDegerAraligiSayisal = measurement.SayisalAralikOf(t.KritikDegerAraligi)
+	// Laboratories often write the unit only once, on the range
+	if t.SonucDegeriSayisal.Gecerli() && t.SonucDegeriSayisal.Birim == "" && t.KritikDegerAraligiSayisal != nil {
+		t.SonucDegeriSayisal.Birim = t.KritikDegerAraligiSayisal.Birim
+	}
+}
+
+// AfterFind parses the result of every loaded record, including preloaded ones
+func (t *TetkikSonuc) AfterFind(tx *gorm.DB) error {
+	t.ParseSayisalDegerler()
+	return nil
+}
+
+// TetkikSonucSorgusu declares the filter, sort and include parameters of lists of test results
+var TetkikSonucSorgusu = query.Schema{
+	Model: TetkikSonuc{},
+	Key:   "tetkik_sonuc_kodu",
+	Fields: []query.Field{
+		{Name: "tetkik_sonuc_kodu", Filter: true, Sort: true},
+		{Name: "hasta_basvuru_kodu", Filter: true, Sort: true},
+		{Name: "tetkik_adi", Filter: true, Sort: true, Turkish: true},
+		{Name: "onay_zamani", Type: query.Time, Filter: true, Sort: true},
+		{Name: "kayit_zamani", Type: query.Time, Filter: true, Sort: true},
+	},
+	Includes:        map[string]string{"hasta_basvuru": "HastaBasvuru", "hasta_basvuru.hasta": "HastaBasvuru.Hasta"},
+	DefaultIncludes: []string{"hasta_basvuru"},
+	DefaultSort:     "-kayit_zamani",
+	Cursor:          "kayit_zamani",
+}
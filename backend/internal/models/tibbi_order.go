@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"medscreen/internal/query"
+	"time"
+)
 
 // TibbiOrder represents a medical order in the VEM 2.0 schema (new entity)
 type TibbiOrder struct {
@@ -43,3 +46,37 @@ type TibbiOrderDetay struct {
 func (TibbiOrderDetay) TableName() string {
 	return "tibbi_order_detay"
 }
+
+// TibbiOrderSorgusu declares the filter, sort and include parameters of lists of medical orders
+var TibbiOrderSorgusu = query.Schema{
+	Model: TibbiOrder{},
+	Key:   "tibbi_order_kodu",
+	Fields: []query.Field{
+		{Name: "tibbi_order_kodu", Filter: true, Sort: true},
+		{Name: "hasta_basvuru_kodu", Filter: true, Sort: true},
+		{Name: "order_turu_kodu", Filter: true, Sort: true},
+		{Name: "order_zamani", Type: query.Time, Filter: true, Sort: true},
+		{Name: "hekim_kodu", Filter: true, Sort: true},
+		{Name: "iptal_durumu", Type: query.Number, Filter: true, Sort: true},
+		{Name: "kayit_zamani", Type: query.Time, Filter: true, Sort: true},
+	},
+	Includes:        map[string]string{"hasta_basvuru": "HastaBasvuru", "hasta_basvuru.hasta": "HastaBasvuru.Hasta", "hekim": "Hekim", "detaylar": "Detaylar"},
+	DefaultIncludes: []string{"hasta_basvuru", "hekim", "detaylar"},
+	DefaultSort:     "-order_zamani",
+}
+
+// TibbiOrderDetaySorgusu declares the filter, sort and include parameters of lists of the administrations of an order
+var TibbiOrderDetaySorgusu = query.Schema{
+	Model: TibbiOrderDetay{},
+	Key:   "tibbi_order_detay_kodu",
+	Fields: []query.Field{
+		{Name: "tibbi_order_detay_kodu", Filter: true, Sort: true},
+		{Name: "planlanan_uygulama_zamani", Type: query.Time, Filter: true, Sort: true},
+		{Name: "uygulama_zamani", Type: query.Time, Filter: true, Sort: true},
+		{Name: "uygulanma_durumu", Type: query.Number, Filter: true, Sort: true},
+		{Name: "uygulayan_personel_kodu", Filter: true, Sort: true},
+	},
+	Includes:        map[string]string{"tibbi_order": "TibbiOrder", "uygulayan_personel": "UygulayanPersonel"},
+	DefaultIncludes: []string{"uygulayan_personel"},
+	DefaultSort:     "planlanan_uygulama_zamani",
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// TokenIptal is an entry of the MedScreen-owned token revocation list.
+// It is not part of VEM 2.0 and lives in the medscreen schema.
+// An entry either revokes a single token (JTI) or every token issued to a
+// personnel or NFC card before IptalZamani.
+type TokenIptal struct {
+	TokenIptalID          uint      `gorm:"column:token_iptal_id;primaryKey;autoIncrement" json:"token_iptal_id"`
+	JTI                   *string   `gorm:"column:jti;uniqueIndex" json:"jti,omitempty"`
+	PersonelKodu          *string   `gorm:"column:personel_kodu;index" json:"personel_kodu,omitempty"`
+	NFCKartKodu           *string   `gorm:"column:nfc_kart_kodu;index" json:"nfc_kart_kodu,omitempty"`
+	IptalZamani           time.Time `gorm:"column:iptal_zamani;not null" json:"iptal_zamani"`
+	SonGecerlilikZamani   time.Time `gorm:"column:son_gecerlilik_zamani;not null;index" json:"son_gecerlilik_zamani"`
+	IptalEdenPersonelKodu string    `gorm:"column:iptal_eden_personel_kodu;not null" json:"iptal_eden_personel_kodu"`
+	Sebep                 *string   `gorm:"column:sebep" json:"sebep,omitempty"`
+}
+
+// TableName returns the MedScreen-owned table name
+func (TokenIptal) TableName() string {
+	return "medscreen.token_iptal"
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"medscreen/internal/query"
+	"time"
+)
 
 // Yatak represents a bed in the VEM 2.0 schema (new entity)
 type Yatak struct {
@@ -21,3 +24,17 @@ type Yatak struct {
 func (Yatak) TableName() string {
 	return "yatak"
 }
+
+// YatakSorgusu declares the filter, sort and include parameters of lists of beds
+var YatakSorgusu = query.Schema{
+	Model: Yatak{},
+	Key:   "yatak_kodu",
+	Fields: []query.Field{
+		{Name: "yatak_kodu", Filter: true, Sort: true},
+		{Name: "birim_kodu", Filter: true, Sort: true},
+		{Name: "oda_kodu", Filter: true, Sort: true},
+		{Name: "yatak_adi", Filter: true, Sort: true, Turkish: true},
+		{Name: "yatak_turu_kodu", Filter: true, Sort: true},
+		{Name: "yogun_bakim_yatak_seviyesi", Filter: true, Sort: true},
+	},
+}
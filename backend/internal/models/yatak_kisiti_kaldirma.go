@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// YatakKisitiKaldirma records a HEKIM escalating a bedside tablet session out of its bed
+// binding. It is not part of VEM 2.0 and lives in the medscreen schema.
+// JTI identifies the escalated access token, which stops working at SonGecerlilikZamani.
+type YatakKisitiKaldirma struct {
+	YatakKisitiKaldirmaID uint      `gorm:"column:yatak_kisiti_kaldirma_id;primaryKey;autoIncrement" json:"yatak_kisiti_kaldirma_id"`
+	JTI                   string    `gorm:"column:jti;not null;uniqueIndex" json:"jti"`
+	PersonelKodu          string    `gorm:"column:personel_kodu;not null;index" json:"personel_kodu"`
+	NFCKartKodu           string    `gorm:"column:nfc_kart_kodu;not null" json:"nfc_kart_kodu"`
+	TabletCihazKodu       string    `gorm:"column:tablet_cihaz_kodu;not null" json:"tablet_cihaz_kodu"`
+	YatakKodu             string    `gorm:"column:yatak_kodu;not null;index" json:"yatak_kodu"`
+	Sebep                 string    `gorm:"column:sebep;not null" json:"sebep"`
+	KaldirmaZamani        time.Time `gorm:"column:kaldirma_zamani;not null;index" json:"kaldirma_zamani"`
+	SonGecerlilikZamani   time.Time `gorm:"column:son_gecerlilik_zamani;not null" json:"son_gecerlilik_zamani"`
+}
+
+// TableName returns the MedScreen-owned table name
+func (YatakKisitiKaldirma) TableName() string {
+	return "medscreen.yatak_kisiti_kaldirma"
+}
@@ -0,0 +1,178 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"medscreen/internal/oidc/oidctest"
+
+	"github.com/golang-jwt/jwt/v5"
+	"pgregory.net/rapid"
+)
+
+// Feature: hospital-sso, Property 1: Only Genuine ID Tokens For This Login Are Accepted
+// *For any* ID token, Verify SHALL return its claims only if it is signed by the provider,
+// issued by the configured issuer for this client, unexpired and carries the login's nonce.
+
+const testRedirect = "http://medscreen.test/api/v1/auth/sso/callback"
+
+func newTestClient(idp *oidctest.Server) *Client {
+	return NewClient(Config{
+		Issuer:       idp.Issuer,
+		ClientID:     "medscreen",
+		ClientSecret: "s3cret",
+		RedirectURL:  testRedirect,
+		Scopes:       []string{"profile"},
+	}, idp.Client())
+}
+
+// login runs the browser side of a login against the mock provider and returns the code and state
+func login(t interface{ Fatalf(string, ...any) }, idp *oidctest.Server, authURL string) (string, string) {
+	resp, err := idp.Client().Get(authURL)
+	if err != nil {
+		t.Fatalf("Authorize request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("Expected a redirect from the provider, got %d", resp.StatusCode)
+	}
+	back, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("Invalid redirect: %v", err)
+	}
+	return back.Query().Get("code"), back.Query().Get("state")
+}
+
+// TestOIDC_CodeFlowWithPKCE tests a complete login and that a code is bound to its verifier
+func TestOIDC_CodeFlowWithPKCE(t *testing.T) {
+	idp := oidctest.NewServer("medscreen", "s3cret")
+	defer idp.Close()
+	idp.Login(map[string]interface{}{"sub": "abc-123", "employee_number": 4711})
+	client := newTestClient(idp)
+	ctx := context.Background()
+
+	verifier, err := NewVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := client.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL failed: %v", err)
+	}
+	q, _ := url.Parse(authURL)
+	if got := q.Query().Get("scope"); got != "openid profile" {
+		t.Errorf("Expected scope %q, got %q", "openid profile", got)
+	}
+
+	code, state := login(t, idp, authURL)
+	if state != "state-1" {
+		t.Fatalf("Expected the state to come back, got %q", state)
+	}
+	claims, err := client.Exchange(ctx, code, verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+	if claims.String("sub") != "abc-123" || claims.String("employee_number") != "4711" {
+		t.Fatalf("Unexpected claims %v", claims)
+	}
+
+	// A code is redeemed once, and only with the verifier of its challenge
+	if _, err := client.Exchange(ctx, code, verifier, "nonce-1"); !errors.Is(err, ErrTokenExchange) {
+		t.Errorf("Expected a reused code to be refused, got %v", err)
+	}
+	code, _ = login(t, idp, authURL)
+	other, _ := NewVerifier()
+	if _, err := client.Exchange(ctx, code, other, "nonce-1"); !errors.Is(err, ErrTokenExchange) {
+		t.Errorf("Expected a wrong PKCE verifier to be refused, got %v", err)
+	}
+
+	// A wrong client secret is refused by the provider
+	bad := NewClient(Config{Issuer: idp.Issuer, ClientID: "medscreen", ClientSecret: "wrong", RedirectURL: testRedirect}, idp.Client())
+	code, _ = login(t, idp, authURL)
+	if _, err := bad.Exchange(ctx, code, verifier, "nonce-1"); !errors.Is(err, ErrTokenExchange) {
+		t.Errorf("Expected a wrong client secret to be refused, got %v", err)
+	}
+}
+
+// TestProperty_OnlyGenuineIDTokensAccepted tests Property 1
+func TestProperty_OnlyGenuineIDTokensAccepted(t *testing.T) {
+	idp := oidctest.NewServer("medscreen", "")
+	defer idp.Close()
+	foreign := oidctest.NewProvider(idp.Issuer, "medscreen", "")
+	client := newTestClient(idp)
+
+	rapid.Check(t, func(rt *rapid.T) {
+		now := time.Now()
+		claims := jwt.MapClaims{
+			"iss":   idp.Issuer,
+			"aud":   "medscreen",
+			"sub":   "P000001",
+			"iat":   now.Unix(),
+			"exp":   now.Add(5 * time.Minute).Unix(),
+			"nonce": "n-1",
+		}
+		signer := idp.Provider
+		fault := rapid.SampledFrom([]string{"", "nonce", "audience", "issuer", "expired", "no-exp", "key", "azp"}).Draw(rt, "fault")
+		switch fault {
+		case "nonce":
+			claims["nonce"] = "n-2"
+		case "audience":
+			claims["aud"] = "another-app"
+		case "issuer":
+			claims["iss"] = "https://evil.example"
+		case "expired":
+			claims["exp"] = now.Add(-10 * time.Minute).Unix()
+		case "no-exp":
+			delete(claims, "exp")
+		case "key":
+			signer = foreign
+		case "azp":
+			claims["aud"] = []string{"medscreen", "another-app"}
+			claims["azp"] = "another-app"
+		}
+
+		got, err := client.Verify(context.Background(), signer.SignIDToken(claims), "n-1")
+		if fault == "" {
+			if err != nil || got.String("sub") != "P000001" {
+				rt.Fatalf("Expected a genuine token to verify, got %v", err)
+			}
+			return
+		}
+		if !errors.Is(err, ErrInvalidToken) {
+			rt.Fatalf("Expected a token with fault %q to be refused, got %v", fault, err)
+		}
+	})
+}
+
+// TestOIDC_ProviderKeyRotation tests that a new provider key is picked up without a restart
+func TestOIDC_ProviderKeyRotation(t *testing.T) {
+	idp := oidctest.NewServer("medscreen", "")
+	defer idp.Close()
+	client := newTestClient(idp)
+	now := time.Now()
+	claims := jwt.MapClaims{"iss": idp.Issuer, "aud": "medscreen", "sub": "P1", "iat": now.Unix(), "exp": now.Add(time.Minute).Unix(), "nonce": "n"}
+
+	if _, err := client.Verify(context.Background(), idp.SignIDToken(claims), "n"); err != nil {
+		t.Fatalf("Expected the first token to verify: %v", err)
+	}
+	idp.RotateKey()
+	client.now = func() time.Time { return now.Add(2 * keyRefreshInterval) }
+	claims["iat"], claims["exp"] = now.Add(2*keyRefreshInterval).Unix(), now.Add(3*keyRefreshInterval).Unix()
+	if _, err := client.Verify(context.Background(), idp.SignIDToken(claims), "n"); err != nil {
+		t.Fatalf("Expected a token signed with the rotated key to verify: %v", err)
+	}
+}
+
+// TestOIDC_DiscoveryIssuerMismatch tests that a provider claiming another issuer is refused
+func TestOIDC_DiscoveryIssuerMismatch(t *testing.T) {
+	idp := oidctest.NewServer("medscreen", "")
+	defer idp.Close()
+	client := NewClient(Config{Issuer: idp.Issuer + "/", ClientID: "medscreen", RedirectURL: testRedirect}, idp.Client())
+	if _, err := client.AuthCodeURL(context.Background(), "s", "n", "v"); !errors.Is(err, ErrDiscovery) {
+		t.Fatalf("Expected an issuer mismatch to fail discovery, got %v", err)
+	}
+}
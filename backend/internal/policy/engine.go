@@ -0,0 +1,227 @@
+package policy
+
+import (
+	"medscreen/internal/constants"
+	"medscreen/internal/middleware"
+	"medscreen/internal/models"
+	"medscreen/internal/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BirimResolver returns the units (birim_kodu) of the records a route parameter
+// value refers to. A nil result means that nothing was found; the handler reports that.
+type BirimResolver func(value string) ([]string, error)
+
+// HastaBirimResolver returns the units a patient, visit or bed is currently in. A patient
+// or visit that is not an inpatient has no unit and is denied to unit-scoped roles.
+type HastaBirimResolver func(kimlik HastaKimligi) ([]string, error)
+
+// Engine enforces a Policy on the protected route groups
+type Engine struct {
+	policy         *Policy
+	resolvers      map[string]map[string]BirimResolver
+	hastaResolvers map[string]map[string]HastaResolver
+	yatakHastalari HastaResolver
+	hastaBirimleri HastaBirimResolver
+	birimSorgulari map[string]string
+	ikinciFaktor   time.Duration
+	now            func() time.Time
+}
+
+// NewEngine creates a new Engine for the given policy
+func NewEngine(policy *Policy) *Engine {
+	return &Engine{
+		policy:         policy,
+		resolvers:      make(map[string]map[string]BirimResolver),
+		hastaResolvers: make(map[string]map[string]HastaResolver),
+		birimSorgulari: make(map[string]string),
+		ikinciFaktor:   DefaultIkinciFaktorSuresi,
+		now:            time.Now,
+	}
+}
+
+// RegisterBirimResolver registers how the unit of a resource is found from a route parameter.
+// Unit-scoped requests are only allowed when one of their parameters has a resolver.
+func (e *Engine) RegisterBirimResolver(resource, param string, resolver BirimResolver) {
+	if e.resolvers[resource] == nil {
+		e.resolvers[resource] = make(map[string]BirimResolver)
+	}
+	e.resolvers[resource][param] = resolver
+}
+
+// SetHastaBirimleri sets how the units of a patient are found. Parameters without a unit
+// resolver are then resolved through the patient resolvers, so every patient data route
+// that is available on a bedside tablet can also be limited to a unit.
+func (e *Engine) SetHastaBirimleri(resolver HastaBirimResolver) {
+	e.hastaBirimleri = resolver
+}
+
+// RegisterBirimSorgusu declares that a list route is filtered to a unit by a query
+// parameter, e.g. the critical results of /tetkik-sonuc/kritik?birim_kodu=. fullPath is
+// the route as registered with gin.
+func (e *Engine) RegisterBirimSorgusu(fullPath, param string) {
+	e.birimSorgulari[fullPath] = param
+}
+
+// Resource returns a middleware that authorizes requests to a resource.
+// It must run after middleware.AuthMiddleware.
+func (e *Engine) Resource(resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if e.authorize(c, resource) {
+			c.Next()
+		}
+	}
+}
+
+// Resources returns a middleware that authorizes a request to every resource resourcesOf
+// returns for it, for endpoints whose resource depends on the request (the FHIR
+// Observation serves both vital signs and test results). It must run after
+// middleware.AuthMiddleware.
+func (e *Engine) Resources(resourcesOf func(c *gin.Context) []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, resource := range resourcesOf(c) {
+			if !e.authorize(c, resource) {
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// authorize checks a request against the policy of a resource. It aborts the request and
+// returns false if access is denied.
+func (e *Engine) authorize(c *gin.Context, resource string) bool {
+	if anahtar, ok := middleware.GetAPIAnahtari(c); ok {
+		return e.authorizeAPIAnahtari(c, resource, anahtar)
+	}
+
+	if !e.authorizeIkinciFaktor(c, resource) {
+		return false
+	}
+
+	// A token bound to a bed stays bound in an emergency; only an escalation lifts the bed,
+	// so a card tapped on a bedside tablet cannot break the glass to every patient
+	if !e.authorizeYatak(c, resource) {
+		return false
+	}
+
+	// A break-the-glass token overrides the rest of the policy; its accesses are reviewed afterwards
+	if c.GetString(middleware.ContextKeyAcilErisimKodu) != "" {
+		return true
+	}
+
+	role := c.GetString(middleware.ContextKeyUserRole)
+
+	access := e.policy.AccessFor(resource, role)
+	if access != AccessAll && access != AccessBirim {
+		deny(c, "Your role is not allowed to access this resource")
+		return false
+	}
+	if access == AccessBirim {
+		return e.authorizeBirim(c, resource)
+	}
+	return true
+}
+
+// authorizeBirim allows the request only if every record it targets is in the caller's unit
+func (e *Engine) authorizeBirim(c *gin.Context, resource string) bool {
+	claims, ok := middleware.GetClaims(c)
+	if !ok || claims.BirimKodu == "" {
+		deny(c, "Your unit could not be determined; log in from a bedside tablet")
+		return false
+	}
+
+	checked := false
+	for _, param := range c.Params {
+		resolver, ok := e.birimResolver(resource, param.Key)
+		if !ok {
+			continue
+		}
+		checked = true
+
+		birimler, err := resolver(param.Value)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to check access", err)
+			c.Abort()
+			return false
+		}
+		for _, birim := range birimler {
+			if birim != claims.BirimKodu {
+				deny(c, "This record belongs to another unit")
+				return false
+			}
+		}
+	}
+
+	if param, ok := e.birimSorgulari[c.FullPath()]; ok && c.Query(param) != "" {
+		checked = true
+		if c.Query(param) != claims.BirimKodu {
+			deny(c, "This record belongs to another unit")
+			return false
+		}
+	}
+
+	// Without a resolver the unit of the result cannot be checked, so fail closed
+	if !checked {
+		deny(c, "This endpoint is not available for unit-scoped access")
+		return false
+	}
+
+	return true
+}
+
+// birimResolver returns the unit resolver of a route parameter: the one registered for the
+// resource, or else its patient resolver followed by the units of the patient
+func (e *Engine) birimResolver(resource, param string) (BirimResolver, bool) {
+	if resolver, ok := e.resolvers[resource][param]; ok {
+		return resolver, true
+	}
+	if e.hastaBirimleri == nil {
+		return nil, false
+	}
+	hastaResolver, ok := e.hastaResolver(resource, param)
+	if !ok {
+		return nil, false
+	}
+	return func(value string) ([]string, error) {
+		kimlikler, err := hastaResolver(value)
+		if err != nil {
+			return nil, err
+		}
+		var birimler []string
+		for _, kimlik := range kimlikler {
+			kimlikBirimleri, err := e.hastaBirimleri(kimlik)
+			if err != nil {
+				return nil, err
+			}
+			birimler = append(birimler, kimlikBirimleri...)
+		}
+		return birimler, nil
+	}, true
+}
+
+// authorizeAPIAnahtari allows an API key to read a resource in its scopes. Keys cannot step
+// up, so resources that need a second factor are never available to them.
+func (e *Engine) authorizeAPIAnahtari(c *gin.Context, resource string, anahtar *models.APIAnahtari) bool {
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		deny(c, "API keys are read-only")
+		return false
+	}
+	if !contains(anahtar.Kapsamlar, ReadScope(resource)) {
+		deny(c, "This API key does not have the "+ReadScope(resource)+" scope")
+		return false
+	}
+	if e.policy.RequiresSecondFactor(resource, middleware.APIKeyRole) {
+		deny(c, "This resource requires a second factor and is not available to API keys")
+		return false
+	}
+	return true
+}
+
+func deny(c *gin.Context, message string) {
+	utils.SendErrorResponse(c, http.StatusForbidden, constants.ERROR_FORBIDDEN, message, nil)
+	c.Abort()
+}
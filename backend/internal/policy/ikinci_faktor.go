@@ -0,0 +1,56 @@
+package policy
+
+import (
+	"medscreen/internal/constants"
+	"medscreen/internal/middleware"
+	"medscreen/internal/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultIkinciFaktorSuresi is how long a step-up with a PIN or TOTP code is honoured
+const DefaultIkinciFaktorSuresi = 5 * time.Minute
+
+// SetIkinciFaktorSuresi sets how long a step-up is honoured for sensitive roles and endpoints
+func (e *Engine) SetIkinciFaktorSuresi(d time.Duration) {
+	if d > 0 {
+		e.ikinciFaktor = d
+	}
+}
+
+// IkinciFaktor returns a middleware that requires a recent second factor for an action the
+// policy marks as sensitive. It must run after middleware.AuthMiddleware.
+func (e *Engine) IkinciFaktor(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !e.authorizeIkinciFaktor(c, action) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// authorizeIkinciFaktor requires a step-up within the window when the caller's role or the
+// resource or action is sensitive. It aborts the request and returns false if access is denied.
+// Claims copied into longer-lived tokens keep the step-up time, so the window is measured
+// from the step-up itself and cannot be stretched.
+func (e *Engine) authorizeIkinciFaktor(c *gin.Context, name string) bool {
+	role := c.GetString(middleware.ContextKeyUserRole)
+	if !e.policy.RequiresSecondFactor(name, role) {
+		return true
+	}
+
+	claims, ok := middleware.GetClaims(c)
+	if ok && claims.IkinciFaktorZamani > 0 {
+		dogrulama := time.Unix(claims.IkinciFaktorZamani, 0)
+		if now := e.now(); !dogrulama.After(now) && now.Sub(dogrulama) <= e.ikinciFaktor {
+			return true
+		}
+	}
+
+	utils.SendErrorResponse(c, http.StatusForbidden, constants.ERROR_SECOND_FACTOR_REQUIRED,
+		"A recent PIN or TOTP verification is required; use POST /api/v1/auth/step-up", nil)
+	c.Abort()
+	return false
+}
@@ -0,0 +1,159 @@
+package policy
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Access is the level of access a role has to a resource
+type Access string
+
+const (
+	// AccessAll allows every record of the resource
+	AccessAll Access = "all"
+	// AccessBirim allows only records in the caller's unit (birim_kodu)
+	AccessBirim Access = "birim"
+	// AccessNone denies the resource
+	AccessNone Access = "none"
+)
+
+// AnyRole is the role key that applies to roles not listed for a resource
+const AnyRole = "*"
+
+//go:embed default_policy.json
+var defaultPolicyJSON []byte
+
+// Policy maps resources (protected route groups such as "klinik-seyir") to the
+// access each role (models.PersonelGorevKodu) has to them.
+// Resources that are not listed fall back to DefaultAccess.
+// BedScopeExempt lists the resources that hold no patient data, so tokens bound to a
+// bed (utils.Claims.YatakKodu) may use them without restriction.
+// SecondFactor lists what is sensitive enough to need a recent PIN or TOTP step-up.
+type Policy struct {
+	DefaultAccess  Access                       `json:"default_access"`
+	Resources      map[string]map[string]Access `json:"resources"`
+	BedScopeExempt []string                     `json:"bed_scope_exempt"`
+	SecondFactor   SecondFactor                 `json:"second_factor"`
+}
+
+// SecondFactor marks roles, resources and actions (such as "escalate" or "break-glass")
+// as sensitive. A sensitive role needs the second factor on every resource and action.
+// All lists are empty by default, so the second factor is optional.
+type SecondFactor struct {
+	Roles     []string `json:"roles"`
+	Resources []string `json:"resources"`
+	Actions   []string `json:"actions"`
+}
+
+// Default returns the built-in policy shipped with MedScreen
+func Default() *Policy {
+	p, err := Parse(defaultPolicyJSON)
+	if err != nil {
+		panic("invalid built-in policy: " + err.Error())
+	}
+	return p
+}
+
+// Load reads a policy from a JSON file; an empty path returns the built-in policy
+func Load(path string) (*Policy, error) {
+	if path == "" {
+		return Default(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates a JSON policy
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	if p.DefaultAccess == "" {
+		p.DefaultAccess = AccessNone
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// AccessFor returns the access a role has to a resource
+func (p *Policy) AccessFor(resource, role string) Access {
+	roles, ok := p.Resources[resource]
+	if !ok {
+		return p.DefaultAccess
+	}
+	if access, ok := roles[role]; ok {
+		return access
+	}
+	if access, ok := roles[AnyRole]; ok {
+		return access
+	}
+	return p.DefaultAccess
+}
+
+// BedScoped reports whether bed-bound tokens are limited to their bed's patient on a resource
+func (p *Policy) BedScoped(resource string) bool {
+	for _, exempt := range p.BedScopeExempt {
+		if exempt == resource {
+			return false
+		}
+	}
+	return true
+}
+
+// RequiresSecondFactor reports whether a role needs a second factor for a resource or action
+func (p *Policy) RequiresSecondFactor(name, role string) bool {
+	return contains(p.SecondFactor.Roles, role) ||
+		contains(p.SecondFactor.Resources, name) ||
+		contains(p.SecondFactor.Actions, name)
+}
+
+// ReadScope is the API key scope that allows reading a resource, e.g. "yatak:read"
+func ReadScope(resource string) string {
+	return resource + ":read"
+}
+
+// ReadScopes lists the scopes API keys may be given: reading any resource of the policy
+func (p *Policy) ReadScopes() []string {
+	scopes := make([]string, 0, len(p.Resources))
+	for resource := range p.Resources {
+		scopes = append(scopes, ReadScope(resource))
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) validate() error {
+	if !p.DefaultAccess.valid() {
+		return fmt.Errorf("invalid default_access %q", p.DefaultAccess)
+	}
+	for resource, roles := range p.Resources {
+		for role, access := range roles {
+			if !access.valid() {
+				return fmt.Errorf("invalid access %q for role %s on resource %s", access, role, resource)
+			}
+		}
+	}
+	return nil
+}
+
+func (a Access) valid() bool {
+	return a == AccessAll || a == AccessBirim || a == AccessNone
+}
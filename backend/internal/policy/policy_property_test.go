@@ -0,0 +1,600 @@
+package policy
+
+import (
+	"medscreen/internal/middleware"
+	"medscreen/internal/models"
+	"medscreen/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pgregory.net/rapid"
+)
+
+// Feature: authorization-policy, Property 1: Role and Unit Based Access
+// *For any* authenticated request to a protected resource, the system SHALL allow it only
+// if the caller's role has access to the resource, and for unit-scoped access only if every
+// record the request targets belongs to the caller's unit (birim_kodu).
+
+// noRevocations treats every token as valid
+type noRevocations struct{}
+
+func (noRevocations) IsRevoked(*utils.Claims) (bool, error) { return false, nil }
+
+// testBirimleri maps the test records to their units
+var testBirimleri = map[string]string{
+	"AYH-DAHILIYE": "DAHILIYE",
+	"AYH-CERRAHI":  "CERRAHI",
+	"Y-DAHILIYE":   "DAHILIYE",
+	"Y-CERRAHI":    "CERRAHI",
+}
+
+// testBasvurulari maps the test clinical records to their visits, and testBasvuruBirimleri
+// maps the visits of current inpatients to their units
+var (
+	testBasvurulari = map[string]string{
+		"KS1": "B-DAHILIYE",
+		"KS2": "B-CERRAHI",
+		"KS3": "B-TABURCU",
+		"TS1": "B-DAHILIYE",
+		"VB1": "B-DAHILIYE",
+	}
+	testBasvuruBirimleri = map[string]string{
+		"B-DAHILIYE": "DAHILIYE",
+		"B-CERRAHI":  "CERRAHI",
+	}
+)
+
+// setupPolicyRouter builds a router with the real auth middleware and the given policy
+func setupPolicyRouter(p *Policy) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	engine := NewEngine(p)
+	lookup := func(value string) ([]string, error) {
+		if birim, ok := testBirimleri[value]; ok {
+			return []string{birim}, nil
+		}
+		return nil, nil
+	}
+	engine.RegisterBirimResolver(ResourceAnlikYatanHasta, "birim_kodu", func(v string) ([]string, error) { return []string{v}, nil })
+	engine.RegisterBirimResolver(ResourceAnlikYatanHasta, "kodu", lookup)
+	engine.RegisterBirimResolver(ResourceAnlikYatanHasta, "yatak_kodu", lookup)
+
+	basvuru := func(kodu string) ([]HastaKimligi, error) {
+		if basvuruKodu, ok := testBasvurulari[kodu]; ok {
+			return []HastaKimligi{{HastaBasvuruKodu: basvuruKodu}}, nil
+		}
+		return nil, nil
+	}
+	for _, resource := range []string{"klinik-seyir", "tetkik-sonuc", "vital-bulgu"} {
+		engine.RegisterHastaResolver(resource, "kodu", basvuru)
+	}
+	engine.RegisterHastaResolver(AnyResource, "basvuru_kodu", func(v string) ([]HastaKimligi, error) {
+		return []HastaKimligi{{HastaBasvuruKodu: v}}, nil
+	})
+	// A visit without a current stay has no unit, as in RegisterHastaBirimResolvers
+	engine.SetHastaBirimleri(func(kimlik HastaKimligi) ([]string, error) {
+		return []string{testBasvuruBirimleri[kimlik.HastaBasvuruKodu]}, nil
+	})
+
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"success": true}) }
+
+	protected := router.Group("/api/v1")
+	protected.Use(middleware.AuthMiddleware(noRevocations{}))
+
+	anlikYatanHasta := protected.Group("/anlik-yatan-hasta", engine.Resource("anlik-yatan-hasta"))
+	anlikYatanHasta.GET("/:kodu", ok)
+	anlikYatanHasta.GET("/yatak/:yatak_kodu", ok)
+	anlikYatanHasta.GET("/birim/:birim_kodu", ok)
+
+	klinikSeyir := protected.Group("/klinik-seyir", engine.Resource("klinik-seyir"))
+	klinikSeyir.GET("/:kodu", ok)
+	klinikSeyir.GET("/basvuru/:basvuru_kodu", ok)
+	klinikSeyir.GET("/filter", ok)
+
+	tetkikSonuc := protected.Group("/tetkik-sonuc", engine.Resource("tetkik-sonuc"))
+	tetkikSonuc.GET("/kritik", ok)
+	tetkikSonuc.GET("/:kodu", ok)
+	engine.RegisterBirimSorgusu(tetkikSonuc.BasePath()+"/kritik", "birim_kodu")
+
+	vitalBulgu := protected.Group("/vital-bulgu", engine.Resource("vital-bulgu"))
+	vitalBulgu.GET("/:kodu", ok)
+
+	unknown := protected.Group("/unlisted", engine.Resource("unlisted"))
+	unknown.GET("", ok)
+
+	return router
+}
+
+// tokenFor issues an access token for a role in a unit
+func tokenFor(t interface{ Fatalf(string, ...any) }, role models.PersonelGorevKodu, birimKodu string) string {
+	token, _, err := utils.GenerateJWT(utils.Claims{
+		PersonelKodu: "P000001",
+		Role:         string(role),
+		BirimKodu:    birimKodu,
+		TokenType:    utils.TokenTypeAccess,
+	}, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	return token
+}
+
+func doGet(router *gin.Engine, path, token string) int {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp.Code
+}
+
+// TestPolicy_DefaultPolicyOnRouter checks the built-in policy against the gin router
+func TestPolicy_DefaultPolicyOnRouter(t *testing.T) {
+	router := setupPolicyRouter(Default())
+
+	tests := []struct {
+		name   string
+		role   models.PersonelGorevKodu
+		birim  string
+		path   string
+		status int
+	}{
+		{"hekim sees any inpatient", models.GorevHekim, "", "/api/v1/anlik-yatan-hasta/AYH-CERRAHI", http.StatusOK},
+		{"hekim sees any unit", models.GorevHekim, "DAHILIYE", "/api/v1/anlik-yatan-hasta/birim/CERRAHI", http.StatusOK},
+		{"nurse sees own unit", models.GorevHemsire, "DAHILIYE", "/api/v1/anlik-yatan-hasta/birim/DAHILIYE", http.StatusOK},
+		{"nurse denied other unit", models.GorevHemsire, "DAHILIYE", "/api/v1/anlik-yatan-hasta/birim/CERRAHI", http.StatusForbidden},
+		{"nurse sees own inpatient", models.GorevHemsire, "DAHILIYE", "/api/v1/anlik-yatan-hasta/AYH-DAHILIYE", http.StatusOK},
+		{"nurse denied other inpatient", models.GorevHemsire, "DAHILIYE", "/api/v1/anlik-yatan-hasta/AYH-CERRAHI", http.StatusForbidden},
+		{"nurse sees own bed", models.GorevHemsire, "CERRAHI", "/api/v1/anlik-yatan-hasta/yatak/Y-CERRAHI", http.StatusOK},
+		{"nurse denied other bed", models.GorevHemsire, "CERRAHI", "/api/v1/anlik-yatan-hasta/yatak/Y-DAHILIYE", http.StatusForbidden},
+		{"nurse missing record reaches handler", models.GorevHemsire, "CERRAHI", "/api/v1/anlik-yatan-hasta/UNKNOWN", http.StatusOK},
+		{"nurse without unit denied", models.GorevHemsire, "", "/api/v1/anlik-yatan-hasta/birim/DAHILIYE", http.StatusForbidden},
+		{"nurse sees clinical notes of own unit", models.GorevHemsire, "DAHILIYE", "/api/v1/klinik-seyir/KS1", http.StatusOK},
+		{"nurse denied clinical notes of other unit", models.GorevHemsire, "DAHILIYE", "/api/v1/klinik-seyir/KS2", http.StatusForbidden},
+		{"nurse denied clinical notes of discharged patient", models.GorevHemsire, "DAHILIYE", "/api/v1/klinik-seyir/KS3", http.StatusForbidden},
+		{"nurse sees visit of own unit", models.GorevHemsire, "CERRAHI", "/api/v1/klinik-seyir/basvuru/B-CERRAHI", http.StatusOK},
+		{"nurse denied visit of other unit", models.GorevHemsire, "CERRAHI", "/api/v1/klinik-seyir/basvuru/B-DAHILIYE", http.StatusForbidden},
+		{"nurse denied unscoped list", models.GorevHemsire, "DAHILIYE", "/api/v1/klinik-seyir/filter", http.StatusForbidden},
+		{"hekim sees unscoped list", models.GorevHekim, "", "/api/v1/klinik-seyir/filter", http.StatusOK},
+		{"nurse sees critical results of own unit", models.GorevHemsire, "DAHILIYE", "/api/v1/tetkik-sonuc/kritik?birim_kodu=DAHILIYE", http.StatusOK},
+		{"nurse denied critical results of other unit", models.GorevHemsire, "DAHILIYE", "/api/v1/tetkik-sonuc/kritik?birim_kodu=CERRAHI", http.StatusForbidden},
+		{"nurse denied critical results of all units", models.GorevHemsire, "DAHILIYE", "/api/v1/tetkik-sonuc/kritik", http.StatusForbidden},
+		{"nurse denied lab results of other unit", models.GorevHemsire, "CERRAHI", "/api/v1/tetkik-sonuc/TS1", http.StatusForbidden},
+		{"diger denied clinical notes", models.GorevDiger, "", "/api/v1/klinik-seyir/KS1", http.StatusForbidden},
+		{"diger denied lab results", models.GorevDiger, "", "/api/v1/tetkik-sonuc/TS1", http.StatusForbidden},
+		{"diger sees vitals", models.GorevDiger, "", "/api/v1/vital-bulgu/VB1", http.StatusOK},
+		{"unknown role uses wildcard", "STAJYER", "", "/api/v1/vital-bulgu/VB1", http.StatusOK},
+		{"unlisted resource denied", models.GorevHekim, "", "/api/v1/unlisted", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := doGet(router, tt.path, tokenFor(t, tt.role, tt.birim)); got != tt.status {
+				t.Errorf("GET %s as %s/%q: expected %d, got %d", tt.path, tt.role, tt.birim, tt.status, got)
+			}
+		})
+	}
+}
+
+// TestPolicy_LoadFromFile checks that a policy file overrides the built-in policy
+func TestPolicy_LoadFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	content := `{
+		"default_access": "all",
+		"resources": {
+			"vital-bulgu": {"DIGER": "none"}
+		}
+	}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write policy file: %v", err)
+	}
+
+	p, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load policy: %v", err)
+	}
+	router := setupPolicyRouter(p)
+
+	tests := []struct {
+		name   string
+		role   models.PersonelGorevKodu
+		path   string
+		status int
+	}{
+		{"diger denied vitals", models.GorevDiger, "/api/v1/vital-bulgu/VB1", http.StatusForbidden},
+		{"hekim falls back to default", models.GorevHekim, "/api/v1/vital-bulgu/VB1", http.StatusOK},
+		{"diger sees lab results", models.GorevDiger, "/api/v1/tetkik-sonuc/TS1", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := doGet(router, tt.path, tokenFor(t, tt.role, "")); got != tt.status {
+				t.Errorf("GET %s as %s: expected %d, got %d", tt.path, tt.role, tt.status, got)
+			}
+		})
+	}
+
+	if _, err := Parse([]byte(`{"resources": {"hasta": {"HEKIM": "sometimes"}}}`)); err == nil {
+		t.Error("Expected an invalid access level to be rejected")
+	}
+}
+
+// TestProperty_UnitScopedAccess tests Property 1 for unit-scoped nurses
+func TestProperty_UnitScopedAccess(t *testing.T) {
+	router := setupPolicyRouter(Default())
+
+	rapid.Check(t, func(t *rapid.T) {
+		callerBirim := rapid.SampledFrom([]string{"DAHILIYE", "CERRAHI", "KARDIYOLOJI"}).Draw(t, "caller_birim")
+		targetBirim := rapid.SampledFrom([]string{"DAHILIYE", "CERRAHI", "KARDIYOLOJI"}).Draw(t, "target_birim")
+
+		got := doGet(router, "/api/v1/anlik-yatan-hasta/birim/"+targetBirim, tokenFor(t, models.GorevHemsire, callerBirim))
+		want := http.StatusForbidden
+		if callerBirim == targetBirim {
+			want = http.StatusOK
+		}
+		if got != want {
+			t.Fatalf("Nurse in %s reading unit %s: expected %d, got %d", callerBirim, targetBirim, want, got)
+		}
+	})
+}
+
+// Feature: authorization-policy, Property 2: Bed-Bound Tokens See Only Their Bed's Patient
+// *For any* token bound to a bed, a request to a patient data resource SHALL be allowed only
+// if every record it targets belongs to the visit or patient currently in that bed, list
+// endpoints SHALL be denied, and a token escalated by a HEKIM SHALL not be limited.
+
+// testYatakHastalari maps the test beds to their current visit and patient
+var testYatakHastalari = map[string]HastaKimligi{
+	"Y1": {HastaKodu: "H1", HastaBasvuruKodu: "B1"},
+	"Y2": {HastaKodu: "H2", HastaBasvuruKodu: "B2"},
+}
+
+// setupYatakRouter builds a router with patient resolvers over the test beds
+func setupYatakRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	engine := NewEngine(Default())
+	engine.SetYatakHastalari(func(yatakKodu string) ([]HastaKimligi, error) {
+		if kimlik, ok := testYatakHastalari[yatakKodu]; ok {
+			return []HastaKimligi{kimlik}, nil
+		}
+		return nil, nil
+	})
+	engine.RegisterHastaResolver(AnyResource, "basvuru_kodu", func(v string) ([]HastaKimligi, error) {
+		return []HastaKimligi{{HastaBasvuruKodu: v}}, nil
+	})
+	engine.RegisterHastaResolver(AnyResource, "hasta_kodu", func(v string) ([]HastaKimligi, error) {
+		return []HastaKimligi{{HastaKodu: v}}, nil
+	})
+	engine.RegisterHastaResolver(AnyResource, "yatak_kodu", func(v string) ([]HastaKimligi, error) {
+		return []HastaKimligi{{YatakKodu: v}}, nil
+	})
+	engine.RegisterHastaResolver("hasta", "kodu", func(v string) ([]HastaKimligi, error) {
+		return []HastaKimligi{{HastaKodu: v}}, nil
+	})
+	// Clinical notes KS-<visit> belong to that visit
+	engine.RegisterHastaResolver("klinik-seyir", "kodu", func(v string) ([]HastaKimligi, error) {
+		if len(v) > 3 && v[:3] == "KS-" {
+			return []HastaKimligi{{HastaBasvuruKodu: v[3:]}}, nil
+		}
+		return nil, nil
+	})
+
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"success": true}) }
+
+	protected := router.Group("/api/v1")
+	protected.Use(middleware.AuthMiddleware(noRevocations{}))
+
+	klinikSeyir := protected.Group("/klinik-seyir", engine.Resource("klinik-seyir"))
+	klinikSeyir.GET("/filter", ok)
+	klinikSeyir.GET("/:kodu", ok)
+	klinikSeyir.GET("/basvuru/:basvuru_kodu", ok)
+
+	hasta := protected.Group("/hasta", engine.Resource("hasta"))
+	hasta.GET("", ok)
+	hasta.GET("/:kodu", ok)
+
+	randevu := protected.Group("/randevu", engine.Resource("randevu"))
+	randevu.GET("/hasta/:hasta_kodu", ok)
+	randevu.GET("/hekim/:hekim_kodu", ok)
+
+	eventStream := protected.Group("/stream", engine.Resource("stream"))
+	eventStream.GET("/yatak/:yatak_kodu", ok)
+
+	personel := protected.Group("/personel", engine.Resource("personel"))
+	personel.GET("", ok)
+
+	return router
+}
+
+// yatakTokenFor issues an access token for a HEKIM on the tablet of a bed
+func yatakTokenFor(t interface{ Fatalf(string, ...any) }, yatakKodu string, kaldirildi bool) string {
+	token, _, err := utils.GenerateJWT(utils.Claims{
+		PersonelKodu:          "P000001",
+		Role:                  string(models.GorevHekim),
+		YatakKodu:             yatakKodu,
+		YatakKisitiKaldirildi: kaldirildi,
+		TokenType:             utils.TokenTypeAccess,
+	}, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	return token
+}
+
+// TestProperty_BedBoundAccess tests Property 2
+func TestProperty_BedBoundAccess(t *testing.T) {
+	router := setupYatakRouter()
+
+	rapid.Check(t, func(t *rapid.T) {
+		yatak := rapid.SampledFrom([]string{"Y1", "Y2", "Y3"}).Draw(t, "yatak")
+		hedef := rapid.SampledFrom([]string{"Y1", "Y2", "Y3"}).Draw(t, "hedef")
+		kaldirildi := rapid.Bool().Draw(t, "kaldirildi")
+		token := yatakTokenFor(t, yatak, kaldirildi)
+
+		kimlik := testYatakHastalari[hedef]
+		if kimlik.HastaKodu == "" {
+			// The empty bed's visit and patient exist elsewhere in the hospital
+			kimlik = HastaKimligi{HastaKodu: "H9", HastaBasvuruKodu: "B9"}
+		}
+		paths := []string{
+			"/api/v1/klinik-seyir/KS-" + kimlik.HastaBasvuruKodu,
+			"/api/v1/klinik-seyir/basvuru/" + kimlik.HastaBasvuruKodu,
+			"/api/v1/hasta/" + kimlik.HastaKodu,
+			"/api/v1/randevu/hasta/" + kimlik.HastaKodu,
+			"/api/v1/stream/yatak/" + hedef,
+		}
+
+		want := http.StatusForbidden
+		if kaldirildi || (yatak == hedef && testYatakHastalari[yatak].HastaKodu != "") {
+			want = http.StatusOK
+		}
+		for i, path := range paths {
+			w := want
+			// The bed's own stream is allowed even while it is empty
+			if i == len(paths)-1 && yatak == hedef {
+				w = http.StatusOK
+			}
+			if got := doGet(router, path, token); got != w {
+				t.Fatalf("Token for %s (escalated %v) reading %s: expected %d, got %d", yatak, kaldirildi, path, w, got)
+			}
+		}
+
+		listeler := []string{"/api/v1/hasta", "/api/v1/klinik-seyir/filter", "/api/v1/randevu/hekim/P000002"}
+		for _, path := range listeler {
+			w := http.StatusForbidden
+			if kaldirildi {
+				w = http.StatusOK
+			}
+			if got := doGet(router, path, token); got != w {
+				t.Fatalf("Token for %s (escalated %v) listing %s: expected %d, got %d", yatak, kaldirildi, path, w, got)
+			}
+		}
+
+		if got := doGet(router, "/api/v1/personel", token); got != http.StatusOK {
+			t.Fatalf("Expected exempt resource to be allowed, got %d", got)
+		}
+	})
+}
+
+// TestPolicy_UnboundTokensUnaffected checks that tokens without a bed keep their access
+func TestPolicy_UnboundTokensUnaffected(t *testing.T) {
+	router := setupYatakRouter()
+	token := tokenFor(t, models.GorevHekim, "")
+	for _, path := range []string{"/api/v1/hasta", "/api/v1/klinik-seyir/KS-B2", "/api/v1/randevu/hekim/P000002"} {
+		if got := doGet(router, path, token); got != http.StatusOK {
+			t.Errorf("GET %s without a bed: expected 200, got %d", path, got)
+		}
+	}
+}
+
+// acilErisimTokeni issues a break-the-glass token for a NURSE of CERRAHI on the tablet of a bed
+func acilErisimTokeni(t *testing.T, yatakKodu string, kaldirildi bool) string {
+	token, _, err := utils.GenerateJWT(utils.Claims{
+		PersonelKodu:          "P000001",
+		Role:                  string(models.GorevHemsire),
+		BirimKodu:             "CERRAHI",
+		YatakKodu:             yatakKodu,
+		YatakKisitiKaldirildi: kaldirildi,
+		AcilErisimKodu:        "AE-1",
+		TokenType:             utils.TokenTypeAccess,
+	}, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	return token
+}
+
+// TestPolicy_BreakGlassOverridesScope verifies that a break-the-glass token reaches patients
+// outside the clinician's unit and, once the bed is lifted, outside the bed
+func TestPolicy_BreakGlassOverridesScope(t *testing.T) {
+	router := setupYatakRouter()
+	for _, token := range []string{acilErisimTokeni(t, "", false), acilErisimTokeni(t, "Y1", true)} {
+		for _, path := range []string{"/api/v1/klinik-seyir/KS-B2", "/api/v1/hasta", "/api/v1/randevu/hasta/H9"} {
+			if got := doGet(router, path, token); got != http.StatusOK {
+				t.Errorf("GET %s with emergency access: expected 200, got %d", path, got)
+			}
+		}
+		if got := doGet(setupPolicyRouter(Default()), "/api/v1/anlik-yatan-hasta/yatak/Y-DAHILIYE", token); got != http.StatusOK {
+			t.Errorf("Expected emergency access outside the unit, got %d", got)
+		}
+	}
+}
+
+// TestPolicy_BreakGlassKeepsBed verifies that break-the-glass on a bed-bound token does not
+// lift the bed: only the patient in the bed is reachable until the token is escalated
+func TestPolicy_BreakGlassKeepsBed(t *testing.T) {
+	router := setupYatakRouter()
+	token := acilErisimTokeni(t, "Y1", false)
+
+	for path, want := range map[string]int{
+		"/api/v1/klinik-seyir/KS-B1": http.StatusOK,
+		"/api/v1/randevu/hasta/H1":   http.StatusOK,
+		"/api/v1/klinik-seyir/KS-B2": http.StatusForbidden,
+		"/api/v1/randevu/hasta/H9":   http.StatusForbidden,
+		"/api/v1/hasta":              http.StatusForbidden,
+	} {
+		if got := doGet(router, path, token); got != want {
+			t.Errorf("GET %s with emergency access on bed Y1: expected %d, got %d", path, want, got)
+		}
+	}
+}
+
+// TestPolicy_SecondFactorForSensitiveRolesAndActions checks that sensitive roles, resources
+// and actions need a step-up within the window, and that others are unaffected
+func TestPolicy_SecondFactorForSensitiveRolesAndActions(t *testing.T) {
+	p := Default()
+	p.SecondFactor = SecondFactor{Roles: []string{string(models.GorevHekim)}, Resources: []string{"tetkik-sonuc"}, Actions: []string{"escalate"}}
+	router := setupPolicyRouter(p)
+
+	engine := NewEngine(p)
+	engine.SetIkinciFaktorSuresi(2 * time.Minute)
+	router.GET("/api/v1/auth/escalate", middleware.AuthMiddleware(noRevocations{}), engine.IkinciFaktor("escalate"), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+
+	token := func(role models.PersonelGorevKodu, stepUp time.Duration) string {
+		claims := utils.Claims{PersonelKodu: "P000001", Role: string(role), BirimKodu: "DAHILIYE", TokenType: utils.TokenTypeAccess}
+		if stepUp >= 0 {
+			claims.IkinciFaktorZamani = time.Now().Add(-stepUp).Unix()
+		}
+		signed, _, err := utils.GenerateJWT(claims, time.Hour)
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+		return signed
+	}
+
+	cases := []struct {
+		path   string
+		role   models.PersonelGorevKodu
+		stepUp time.Duration
+		want   int
+	}{
+		{"/api/v1/klinik-seyir/KS1", models.GorevHemsire, -1, http.StatusOK},
+		{"/api/v1/klinik-seyir/KS1", models.GorevHekim, -1, http.StatusForbidden},
+		{"/api/v1/klinik-seyir/KS1", models.GorevHekim, 0, http.StatusOK},
+		{"/api/v1/tetkik-sonuc/TS1", models.GorevHemsire, -1, http.StatusForbidden},
+		{"/api/v1/tetkik-sonuc/TS1", models.GorevHemsire, 4 * time.Minute, http.StatusOK},
+		{"/api/v1/tetkik-sonuc/TS1", models.GorevHemsire, 6 * time.Minute, http.StatusForbidden},
+		{"/api/v1/auth/escalate", models.GorevHemsire, -1, http.StatusForbidden},
+		{"/api/v1/auth/escalate", models.GorevHemsire, time.Minute, http.StatusOK},
+		{"/api/v1/auth/escalate", models.GorevHemsire, 3 * time.Minute, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		if got := doGet(router, tc.path, token(tc.role, tc.stepUp)); got != tc.want {
+			t.Errorf("GET %s as %s with step-up %v ago: expected %d, got %d", tc.path, tc.role, tc.stepUp, tc.want, got)
+		}
+	}
+
+	if Default().RequiresSecondFactor("escalate", string(models.GorevHekim)) {
+		t.Error("Expected the built-in policy to leave the second factor optional")
+	}
+}
+
+// stubAPIKeys accepts a single key
+type stubAPIKeys struct {
+	anahtar models.APIAnahtari
+}
+
+func (s stubAPIKeys) Dogrula(anahtar, ipAdresi string) (*models.APIAnahtari, error) {
+	if anahtar != "msk_kodu_secret" {
+		return nil, nil
+	}
+	kayit := s.anahtar
+	return &kayit, nil
+}
+
+// ipKaydeden records the address an API key was presented from
+type ipKaydeden struct {
+	ipAdresi string
+}
+
+func (k *ipKaydeden) Dogrula(_, ipAdresi string) (*models.APIAnahtari, error) {
+	k.ipAdresi = ipAdresi
+	return nil, nil
+}
+
+// TestPolicy_APIKeyAllowlistIgnoresForwardedFor checks that the address an API key is
+// checked against is the connection's, even when the router trusts X-Forwarded-For
+func TestPolicy_APIKeyAllowlistIgnoresForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	verifier := &ipKaydeden{}
+	router := gin.New()
+	router.GET("/api/v1/vital-bulgu/:kodu", middleware.APIKeyMiddleware(verifier))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/vital-bulgu/V1", nil)
+	req.RemoteAddr = "203.0.113.7:4321"
+	req.Header.Set(middleware.APIKeyHeader, "msk_kodu_secret")
+	req.Header.Set("X-Forwarded-For", "10.20.0.5")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if verifier.ipAdresi != "203.0.113.7" {
+		t.Errorf("Expected the key to be checked against the connection address, got %q", verifier.ipAdresi)
+	}
+}
+
+// TestPolicy_APIKeyScopes checks that API keys may only read the resources in their scopes
+func TestPolicy_APIKeyScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	p := Default()
+	p.SecondFactor = SecondFactor{Resources: []string{"tetkik-sonuc"}}
+	engine := NewEngine(p)
+
+	router := gin.New()
+	protected := router.Group("/api/v1")
+	protected.Use(middleware.APIKeyMiddleware(stubAPIKeys{anahtar: models.APIAnahtari{
+		AnahtarKodu: "kodu",
+		Kapsamlar:   []string{ReadScope("vital-bulgu"), ReadScope("tetkik-sonuc")},
+	}}))
+	protected.Use(middleware.AuthMiddleware(noRevocations{}))
+	ok := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"personel_kodu": c.GetString(middleware.ContextKeyPersonelKodu)})
+	}
+	for _, resource := range []string{"vital-bulgu", "klinik-seyir", "tetkik-sonuc"} {
+		group := protected.Group("/"+resource, engine.Resource(resource))
+		group.GET("/:kodu", ok)
+		group.POST("/:kodu", ok)
+	}
+
+	cases := []struct {
+		method string
+		path   string
+		key    string
+		want   int
+	}{
+		{http.MethodGet, "/api/v1/vital-bulgu/VB1", "msk_kodu_secret", http.StatusOK},
+		{http.MethodPost, "/api/v1/vital-bulgu/VB1", "msk_kodu_secret", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/klinik-seyir/KS1", "msk_kodu_secret", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/tetkik-sonuc/TS1", "msk_kodu_secret", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/vital-bulgu/VB1", "msk_kodu_wrong", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set(middleware.APIKeyHeader, tc.key)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		if resp.Code != tc.want {
+			t.Errorf("%s %s with key %s: expected %d, got %d", tc.method, tc.path, tc.key, tc.want, resp.Code)
+		}
+		if resp.Code == http.StatusOK && !strings.Contains(resp.Body.String(), `"api:kodu"`) {
+			t.Errorf("Expected the request to be attributed to the key, got %s", resp.Body.String())
+		}
+	}
+
+	// Requests without a key still need a token
+	if got := doGet(router, "/api/v1/vital-bulgu/VB1", "invalid"); got != http.StatusUnauthorized {
+		t.Errorf("Expected an invalid token to be rejected, got %d", got)
+	}
+
+	scopes := Default().ReadScopes()
+	if !contains(scopes, "yatak:read") || !contains(scopes, "basvuru-yemek:read") || contains(scopes, "unlisted:read") {
+		t.Errorf("Expected a read scope for every resource of the policy, got %v", scopes)
+	}
+}
@@ -0,0 +1,233 @@
+package policy
+
+import (
+	"errors"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// ResourceAnlikYatanHasta is the policy resource of the current inpatient routes
+const ResourceAnlikYatanHasta = "anlik-yatan-hasta"
+
+// ResourceStream is the policy resource of the clinical event streams
+const ResourceStream = "stream"
+
+// RegisterAnlikYatanHastaResolvers registers unit resolvers for every parameter of the
+// /anlik-yatan-hasta routes, so nurses can be limited to the inpatients of their unit
+func (e *Engine) RegisterAnlikYatanHastaResolvers(anlikYatanHastaRepo repository.AnlikYatanHastaRepository, yatakRepo repository.YatakRepository) {
+	e.RegisterBirimResolver(ResourceAnlikYatanHasta, "birim_kodu", func(birimKodu string) ([]string, error) {
+		return []string{birimKodu}, nil
+	})
+
+	e.RegisterBirimResolver(ResourceAnlikYatanHasta, "kodu", func(kodu string) ([]string, error) {
+		yatanHasta, err := anlikYatanHastaRepo.FindByKodu(kodu)
+		if err != nil || yatanHasta == nil {
+			return nil, ignoreNotFound(err)
+		}
+		return []string{yatanHastaBirimi(yatanHasta)}, nil
+	})
+
+	e.RegisterBirimResolver(ResourceAnlikYatanHasta, "yatak_kodu", func(yatakKodu string) ([]string, error) {
+		yatak, err := yatakRepo.FindByKodu(yatakKodu)
+		if err != nil || yatak == nil {
+			return nil, ignoreNotFound(err)
+		}
+		return []string{yatak.BirimKodu}, nil
+	})
+
+	// A patient normally has a single current stay; 100 is the service's page limit
+	e.RegisterBirimResolver(ResourceAnlikYatanHasta, "hasta_kodu", func(hastaKodu string) ([]string, error) {
+		yatanHastalar, _, err := anlikYatanHastaRepo.FindByHastaKodu(hastaKodu, 1, 100)
+		if err != nil {
+			return nil, ignoreNotFound(err)
+		}
+		birimler := make([]string, 0, len(yatanHastalar))
+		for i := range yatanHastalar {
+			birimler = append(birimler, yatanHastaBirimi(&yatanHastalar[i]))
+		}
+		return birimler, nil
+	})
+}
+
+// RegisterStreamResolvers registers unit resolvers for the bed and ward streams, so nurses
+// can only follow the beds of their unit
+func (e *Engine) RegisterStreamResolvers(yatakRepo repository.YatakRepository) {
+	e.RegisterBirimResolver(ResourceStream, "birim_kodu", func(birimKodu string) ([]string, error) {
+		return []string{birimKodu}, nil
+	})
+
+	e.RegisterBirimResolver(ResourceStream, "yatak_kodu", func(yatakKodu string) ([]string, error) {
+		yatak, err := yatakRepo.FindByKodu(yatakKodu)
+		if err != nil || yatak == nil {
+			return nil, ignoreNotFound(err)
+		}
+		return []string{yatak.BirimKodu}, nil
+	})
+}
+
+// RegisterHastaBirimResolvers sets how the unit of a patient is found: the units of the
+// patient's or the visit's current inpatient stays, or the unit of a bed. Together with the
+// patient resolvers of RegisterYatakResolvers this limits nurses to the patients of their
+// unit on every patient data route.
+func (e *Engine) RegisterHastaBirimResolvers(anlikYatanHastaRepo repository.AnlikYatanHastaRepository, yatakRepo repository.YatakRepository) {
+	e.SetHastaBirimleri(func(kimlik HastaKimligi) ([]string, error) {
+		switch {
+		case kimlik.HastaBasvuruKodu != "":
+			yatanHasta, err := anlikYatanHastaRepo.FindByBasvuruKodu(kimlik.HastaBasvuruKodu)
+			if err := ignoreNotFound(err); err != nil {
+				return nil, err
+			}
+			if yatanHasta == nil {
+				return []string{""}, nil
+			}
+			return []string{yatanHastaBirimi(yatanHasta)}, nil
+		case kimlik.HastaKodu != "":
+			// A patient normally has a single current stay; 100 is the service's page limit
+			yatanHastalar, _, err := anlikYatanHastaRepo.FindByHastaKodu(kimlik.HastaKodu, 1, 100)
+			if err := ignoreNotFound(err); err != nil {
+				return nil, err
+			}
+			if len(yatanHastalar) == 0 {
+				return []string{""}, nil
+			}
+			birimler := make([]string, 0, len(yatanHastalar))
+			for i := range yatanHastalar {
+				birimler = append(birimler, yatanHastaBirimi(&yatanHastalar[i]))
+			}
+			return birimler, nil
+		case kimlik.YatakKodu != "":
+			yatak, err := yatakRepo.FindByKodu(kimlik.YatakKodu)
+			if err != nil || yatak == nil {
+				return nil, ignoreNotFound(err)
+			}
+			return []string{yatak.BirimKodu}, nil
+		}
+		return nil, nil
+	})
+}
+
+// yatanHastaBirimi returns the unit of a stay, falling back to the unit of its bed
+func yatanHastaBirimi(yatanHasta *models.AnlikYatanHasta) string {
+	if yatanHasta.BirimKodu != nil {
+		return *yatanHasta.BirimKodu
+	}
+	if yatanHasta.Yatak != nil {
+		return yatanHasta.Yatak.BirimKodu
+	}
+	return ""
+}
+
+func ignoreNotFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	return err
+}
+
+// YatakRepositories holds the repositories used to find the patient of a request made
+// with a token bound to a bed
+type YatakRepositories struct {
+	Hasta                 repository.HastaRepository
+	AnlikYatanHasta       repository.AnlikYatanHastaRepository
+	HastaVitalFizikiBulgu repository.HastaVitalFizikiBulguRepository
+	KlinikSeyir           repository.KlinikSeyirRepository
+	TibbiOrder            repository.TibbiOrderRepository
+	TetkikSonuc           repository.TetkikSonucRepository
+	Recete                repository.ReceteRepository
+	BasvuruTani           repository.BasvuruTaniRepository
+	HastaTibbiBilgi       repository.HastaTibbiBilgiRepository
+	HastaUyari            repository.HastaUyariRepository
+	RiskSkorlama          repository.RiskSkorlamaRepository
+	BasvuruYemek          repository.BasvuruYemekRepository
+	Randevu               repository.RandevuRepository
+}
+
+// RegisterYatakResolvers registers patient resolvers for the parameters of every patient
+// data route, so a bedside tablet can only read the patient currently in its bed
+func (e *Engine) RegisterYatakResolvers(repos YatakRepositories) {
+	// A bed normally holds a single current stay; 100 is the service's page limit
+	e.SetYatakHastalari(func(yatakKodu string) ([]HastaKimligi, error) {
+		yatanHastalar, _, err := repos.AnlikYatanHasta.FindByYatakKodu(yatakKodu, 1, 100)
+		if err != nil {
+			return nil, ignoreNotFound(err)
+		}
+		kimlikler := make([]HastaKimligi, 0, len(yatanHastalar))
+		for _, y := range yatanHastalar {
+			kimlikler = append(kimlikler, HastaKimligi{HastaKodu: y.HastaKodu, HastaBasvuruKodu: y.HastaBasvuruKodu, YatakKodu: y.YatakKodu})
+		}
+		return kimlikler, nil
+	})
+
+	e.RegisterHastaResolver(AnyResource, "hasta_kodu", func(hastaKodu string) ([]HastaKimligi, error) {
+		return []HastaKimligi{{HastaKodu: hastaKodu}}, nil
+	})
+	e.RegisterHastaResolver(AnyResource, "basvuru_kodu", func(basvuruKodu string) ([]HastaKimligi, error) {
+		return []HastaKimligi{{HastaBasvuruKodu: basvuruKodu}}, nil
+	})
+	e.RegisterHastaResolver(AnyResource, "yatak_kodu", func(yatakKodu string) ([]HastaKimligi, error) {
+		return []HastaKimligi{{YatakKodu: yatakKodu}}, nil
+	})
+
+	e.RegisterHastaResolver("hasta", "kodu", func(kodu string) ([]HastaKimligi, error) {
+		return []HastaKimligi{{HastaKodu: kodu}}, nil
+	})
+	e.RegisterHastaResolver("hasta", "tc_kimlik", kayitResolver(repos.Hasta.FindByTCKimlik, func(h *models.Hasta) HastaKimligi {
+		return HastaKimligi{HastaKodu: h.HastaKodu}
+	}))
+	e.RegisterHastaResolver("hasta-basvuru", "kodu", func(kodu string) ([]HastaKimligi, error) {
+		return []HastaKimligi{{HastaBasvuruKodu: kodu}}, nil
+	})
+	e.RegisterHastaResolver(ResourceAnlikYatanHasta, "kodu", kayitResolver(repos.AnlikYatanHasta.FindByKodu, func(y *models.AnlikYatanHasta) HastaKimligi {
+		return HastaKimligi{HastaKodu: y.HastaKodu, HastaBasvuruKodu: y.HastaBasvuruKodu}
+	}))
+	e.RegisterHastaResolver("vital-bulgu", "kodu", kayitResolver(repos.HastaVitalFizikiBulgu.FindByKodu, func(v *models.HastaVitalFizikiBulgu) HastaKimligi {
+		return HastaKimligi{HastaBasvuruKodu: v.HastaBasvuruKodu}
+	}))
+	e.RegisterHastaResolver("klinik-seyir", "kodu", kayitResolver(repos.KlinikSeyir.FindByKodu, func(k *models.KlinikSeyir) HastaKimligi {
+		return HastaKimligi{HastaBasvuruKodu: k.HastaBasvuruKodu}
+	}))
+	e.RegisterHastaResolver("tibbi-order", "kodu", kayitResolver(repos.TibbiOrder.FindByKodu, func(o *models.TibbiOrder) HastaKimligi {
+		return HastaKimligi{HastaBasvuruKodu: o.HastaBasvuruKodu}
+	}))
+	e.RegisterHastaResolver("tetkik-sonuc", "kodu", kayitResolver(repos.TetkikSonuc.FindByKodu, func(t *models.TetkikSonuc) HastaKimligi {
+		return HastaKimligi{HastaBasvuruKodu: t.HastaBasvuruKodu}
+	}))
+	e.RegisterHastaResolver("recete", "kodu", kayitResolver(repos.Recete.FindByKodu, func(r *models.Recete) HastaKimligi {
+		return HastaKimligi{HastaBasvuruKodu: r.HastaBasvuruKodu}
+	}))
+	e.RegisterHastaResolver("basvuru-tani", "kodu", kayitResolver(repos.BasvuruTani.FindByKodu, func(b *models.BasvuruTani) HastaKimligi {
+		return HastaKimligi{HastaKodu: b.HastaKodu, HastaBasvuruKodu: b.HastaBasvuruKodu}
+	}))
+	e.RegisterHastaResolver("hasta-tibbi-bilgi", "kodu", kayitResolver(repos.HastaTibbiBilgi.FindByKodu, func(h *models.HastaTibbiBilgi) HastaKimligi {
+		return HastaKimligi{HastaKodu: h.HastaKodu}
+	}))
+	e.RegisterHastaResolver("hasta-uyari", "kodu", kayitResolver(repos.HastaUyari.FindByKodu, func(h *models.HastaUyari) HastaKimligi {
+		return HastaKimligi{HastaBasvuruKodu: h.HastaBasvuruKodu}
+	}))
+	e.RegisterHastaResolver("risk-skorlama", "kodu", kayitResolver(repos.RiskSkorlama.FindByKodu, func(r *models.RiskSkorlama) HastaKimligi {
+		return HastaKimligi{HastaBasvuruKodu: r.HastaBasvuruKodu}
+	}))
+	e.RegisterHastaResolver("basvuru-yemek", "kodu", kayitResolver(repos.BasvuruYemek.FindByKodu, func(b *models.BasvuruYemek) HastaKimligi {
+		return HastaKimligi{HastaBasvuruKodu: b.HastaBasvuruKodu}
+	}))
+	e.RegisterHastaResolver("randevu", "kodu", kayitResolver(repos.Randevu.FindByKodu, func(r *models.Randevu) HastaKimligi {
+		kimlik := HastaKimligi{HastaKodu: r.HastaKodu}
+		if r.HastaBasvuruKodu != nil {
+			kimlik.HastaBasvuruKodu = *r.HastaBasvuruKodu
+		}
+		return kimlik
+	}))
+}
+
+// kayitResolver builds a HastaResolver from a repository lookup
+func kayitResolver[T any](find func(string) (*T, error), kimlik func(*T) HastaKimligi) HastaResolver {
+	return func(value string) ([]HastaKimligi, error) {
+		kayit, err := find(value)
+		if err != nil || kayit == nil {
+			return nil, ignoreNotFound(err)
+		}
+		return []HastaKimligi{kimlik(kayit)}, nil
+	}
+}
@@ -0,0 +1,115 @@
+package policy
+
+import (
+	"medscreen/internal/constants"
+	"medscreen/internal/middleware"
+	"medscreen/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AnyResource is the resource key of patient resolvers that apply to every resource,
+// e.g. for the hasta_kodu and basvuru_kodu parameters
+const AnyResource = "*"
+
+// HastaKimligi identifies whose data a request targets. Only the most specific field
+// that is set is compared: the visit, then the patient, then the bed.
+type HastaKimligi struct {
+	HastaKodu        string
+	HastaBasvuruKodu string
+	YatakKodu        string
+}
+
+// HastaResolver returns the patients of the records a route parameter value refers to.
+// A nil result means that nothing was found; the handler reports that.
+type HastaResolver func(value string) ([]HastaKimligi, error)
+
+// RegisterHastaResolver registers how the patient of a resource is found from a route parameter.
+// Bed-bound requests are only allowed when every one of their parameters has a resolver.
+func (e *Engine) RegisterHastaResolver(resource, param string, resolver HastaResolver) {
+	if e.hastaResolvers[resource] == nil {
+		e.hastaResolvers[resource] = make(map[string]HastaResolver)
+	}
+	e.hastaResolvers[resource][param] = resolver
+}
+
+// SetYatakHastalari sets how the current patients of a bed are found
+func (e *Engine) SetYatakHastalari(resolver HastaResolver) {
+	e.yatakHastalari = resolver
+}
+
+// authorizeYatak limits a token bound to a bed to the records of the patient currently in
+// that bed. It aborts the request and returns false if access is denied.
+func (e *Engine) authorizeYatak(c *gin.Context, resource string) bool {
+	claims, ok := middleware.GetClaims(c)
+	if !ok || claims.YatakKodu == "" || claims.YatakKisitiKaldirildi || !e.policy.BedScoped(resource) {
+		return true
+	}
+
+	// List endpoints have no parameter that names the patient, so fail closed
+	if len(c.Params) == 0 || e.yatakHastalari == nil {
+		deny(c, "This endpoint is not available on a bedside tablet")
+		return false
+	}
+
+	var hedefler []HastaKimligi
+	for _, param := range c.Params {
+		resolver, ok := e.hastaResolver(resource, param.Key)
+		if !ok {
+			deny(c, "This endpoint is not available on a bedside tablet")
+			return false
+		}
+
+		kimlikler, err := resolver(param.Value)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to check access", err)
+			c.Abort()
+			return false
+		}
+		hedefler = append(hedefler, kimlikler...)
+	}
+	if len(hedefler) == 0 {
+		return true
+	}
+
+	izinliler, err := e.yatakHastalari(claims.YatakKodu)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Failed to check access", err)
+		c.Abort()
+		return false
+	}
+	for _, hedef := range hedefler {
+		if !hedef.izinli(claims.YatakKodu, izinliler) {
+			deny(c, "This tablet may only show the patient in its bed")
+			return false
+		}
+	}
+	return true
+}
+
+// hastaResolver returns the patient resolver of a route parameter of a resource
+func (e *Engine) hastaResolver(resource, param string) (HastaResolver, bool) {
+	if resolver, ok := e.hastaResolvers[resource][param]; ok {
+		return resolver, true
+	}
+	resolver, ok := e.hastaResolvers[AnyResource][param]
+	return resolver, ok
+}
+
+// izinli reports whether the target is the bed or one of the patients in it
+func (k HastaKimligi) izinli(yatakKodu string, izinliler []HastaKimligi) bool {
+	for _, izinli := range izinliler {
+		switch {
+		case k.HastaBasvuruKodu != "":
+			if k.HastaBasvuruKodu == izinli.HastaBasvuruKodu {
+				return true
+			}
+		case k.HastaKodu != "":
+			if k.HastaKodu == izinli.HastaKodu {
+				return true
+			}
+		}
+	}
+	return k.HastaBasvuruKodu == "" && k.HastaKodu == "" && k.YatakKodu == yatakKodu
+}
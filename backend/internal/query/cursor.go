@@ -0,0 +1,152 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// keyset pages a list by its time column and primary key instead of an offset, so a page
+// costs the same at any depth and rows inserted while a client scrolls are neither skipped
+// nor repeated
+type keyset struct {
+	column string
+	key    string
+	desc   bool
+	total  bool
+	after  *cursor // nil on the first page
+}
+
+// cursor is the position after the last record of a page; clients see it as an opaque token
+type cursor struct {
+	Column string    `json:"c"`
+	Desc   bool      `json:"d"`
+	Time   time.Time `json:"z"`
+	Key    string    `json:"k"`
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalid)
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Key == "" {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalid)
+	}
+	return &c, nil
+}
+
+// parseKeyset reads cursor=, total= and sort= for a list in cursor mode. Only the time
+// column of the schema may be sorted by, in either direction; the default is newest first.
+func parseKeyset(values url.Values, schema Schema) (*keyset, error) {
+	if schema.Cursor == "" || schema.Key == "" {
+		return nil, fmt.Errorf("%w: this list does not support cursor pagination", ErrInvalid)
+	}
+	ks := &keyset{column: schema.Cursor, key: schema.Key, desc: true}
+
+	if sort := strings.TrimSpace(values.Get("sort")); sort != "" {
+		name, desc := strings.CutPrefix(sort, "-")
+		if name != schema.Cursor {
+			return nil, fmt.Errorf("%w: with a cursor, lists can only be sorted by %s", ErrInvalid, schema.Cursor)
+		}
+		ks.desc = desc
+	}
+
+	if total := values.Get("total"); total != "" {
+		b, err := strconv.ParseBool(total)
+		if err != nil {
+			return nil, fmt.Errorf("%w: total takes true or false, got %q", ErrInvalid, total)
+		}
+		ks.total = b
+	}
+
+	if token := values.Get("cursor"); token != "" {
+		after, err := decodeCursor(token)
+		if err != nil {
+			return nil, err
+		}
+		if after.Column != ks.column || after.Desc != ks.desc {
+			return nil, fmt.Errorf("%w: the cursor belongs to a different list or sort order", ErrInvalid)
+		}
+		ks.after = after
+	}
+	return ks, nil
+}
+
+func (ks *keyset) orders() []order {
+	return []order{{column: ks.column, desc: ks.desc}, {column: ks.key, desc: ks.desc}}
+}
+
+// apply restricts db to the records after the cursor
+func (ks *keyset) apply(db *gorm.DB) *gorm.DB {
+	if ks.after == nil {
+		return db
+	}
+	op := ">"
+	if ks.desc {
+		op = "<"
+	}
+	return db.Where(clause.Expr{
+		SQL:  "(?, ?) " + op + " (?, ?)",
+		Vars: []interface{}{clause.Column{Name: ks.column}, clause.Column{Name: ks.key}, ks.after.Time, ks.after.Key},
+	})
+}
+
+// Cursor reports whether the query pages by cursor rather than by page number
+func (q Query) Cursor() bool {
+	return q.keyset != nil
+}
+
+// Counted reports whether the total number of matching records should be counted. Cursor
+// pages are only counted when total=true is given.
+func (q Query) Counted() bool {
+	return q.keyset == nil || q.keyset.total
+}
+
+// Next returns the cursor of the page after records, or "" when records is the last page.
+// A full page always gets a cursor, so the page after it may turn out to be empty.
+func Next[T any](q Query, records []T, limit int) string {
+	if q.keyset == nil || len(records) == 0 || len(records) < limit {
+		return ""
+	}
+	last := reflect.ValueOf(records[len(records)-1])
+	for last.Kind() == reflect.Pointer {
+		last = last.Elem()
+	}
+
+	c := cursor{Column: q.keyset.column, Desc: q.keyset.desc}
+	var timeOK, keyOK bool
+	c.Time, timeOK = fieldByJSONName(last, q.keyset.column).Interface().(time.Time)
+	c.Key, keyOK = fieldByJSONName(last, q.keyset.key).Interface().(string)
+	if !timeOK || !keyOK {
+		return ""
+	}
+	return encodeCursor(c)
+}
+
+// fieldByJSONName returns the field of a struct value with the given JSON name, or an empty
+// struct value when there is none
+func fieldByJSONName(v reflect.Value, name string) reflect.Value {
+	if v.Kind() == reflect.Struct {
+		for _, f := range reflect.VisibleFields(v.Type()) {
+			if tag, _, _ := strings.Cut(f.Tag.Get("json"), ","); tag == name && f.IsExported() {
+				return v.FieldByIndex(f.Index)
+			}
+		}
+	}
+	return reflect.ValueOf(struct{}{})
+}
@@ -0,0 +1,167 @@
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"medscreen/internal/constants"
+	"medscreen/internal/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKeyQuery holds the list query a handler accepted
+const ContextKeyQuery = "listQuery"
+
+// Set records the parsed query of a list handler for Middleware
+func Set(c *gin.Context, q Query) {
+	if len(q.fields) > 0 || q.sideload {
+		c.Set(ContextKeyQuery, q)
+	}
+}
+
+// Project keeps only the given top-level fields of the items of a JSON array, or of a
+// JSON object
+func Project(data json.RawMessage, fields []string) (json.RawMessage, error) {
+	keep := func(obj map[string]json.RawMessage) map[string]json.RawMessage {
+		kept := make(map[string]json.RawMessage, len(fields))
+		for _, name := range fields {
+			if value, ok := obj[name]; ok {
+				kept[name] = value
+			}
+		}
+		return kept
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var items []map[string]json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+		for i := range items {
+			items[i] = keep(items[i])
+		}
+		return json.Marshal(items)
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	return json.Marshal(keep(obj))
+}
+
+// bufferedWriter holds the response back until it has been reshaped
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body    bytes.Buffer
+	status  int
+	written bool
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+	w.written = true
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
+
+// Middleware applies sideload= and fields= to the data of list responses whose handler
+// accepted them (see Set). It must run before the audit and masking middlewares so that
+// they see the whole records: dropping the keys an entity is recognised by would otherwise
+// leave its personal data unmasked.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Query("fields") == "" && c.Query("sideload") == "" {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		buffered := &bufferedWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = buffered
+
+		c.Next()
+
+		c.Writer = original
+		body := buffered.body.Bytes()
+		value, ok := c.Get(ContextKeyQuery)
+		q, _ := value.(Query)
+		if ok && len(body) > 0 && buffered.status < http.StatusBadRequest &&
+			strings.HasPrefix(original.Header().Get("Content-Type"), "application/json") {
+			projected, err := reshape(body, q)
+			if err != nil {
+				log.Printf("Query: failed to reshape the response of %s: %v", c.Request.URL.Path, err)
+				original.Header().Del("Content-Length")
+				utils.SendErrorResponse(c, http.StatusInternalServerError, constants.ERROR_INTERNAL_SERVER, "Response could not be prepared", nil)
+				return
+			}
+			body = projected
+		}
+
+		original.Header().Del("Content-Length")
+		original.WriteHeader(buffered.status)
+		if len(body) > 0 {
+			_, _ = original.Write(body)
+		}
+	}
+}
+
+// reshape sideloads the associations of the data of a response envelope into included
+// and then applies Project to it
+func reshape(body []byte, q Query) ([]byte, error) {
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, err
+	}
+	data, ok := envelope["data"]
+	if !ok || string(data) == "null" {
+		return body, nil
+	}
+
+	if q.sideload {
+		reduced, sections, err := sideload(data, q.relations)
+		if err != nil {
+			return nil, err
+		}
+		if envelope["included"], err = json.Marshal(sections); err != nil {
+			return nil, err
+		}
+		data = reduced
+	}
+	if len(q.fields) > 0 {
+		projected, err := Project(data, q.fields)
+		if err != nil {
+			return nil, err
+		}
+		data = projected
+	}
+
+	envelope["data"] = data
+	return json.Marshal(envelope)
+}
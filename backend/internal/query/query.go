@@ -0,0 +1,455 @@
+// Package query implements the filter, sort, fields and include parameters shared by the
+// list endpoints.
+//
+// Every model declares a Schema that whitelists the columns clients may filter and sort
+// by and the relations they may preload; Parse turns the query string of a request into
+// a Query that repositories apply to their gorm statements:
+//
+//	?filter=islem_zamani:gte:2026-01-01,hemsire_kodu:in:P1|P2
+//	?sort=-islem_zamani,ad
+//	?fields=hasta_vital_fiziki_bulgu_kodu,islem_zamani,ates
+//	?include=hasta_basvuru,hasta_basvuru.hasta,hemsire
+//	?include=hasta_basvuru.hasta,hemsire&sideload=true
+//
+// Lists of large, time ordered tables may also be paged by cursor instead of page number:
+//
+//	?cursor=&limit=50                (first page, newest first)
+//	?cursor=<meta.next_cursor>       (following pages)
+//	?cursor=&sort=islem_zamani&total=true
+package query
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalid is returned for filter, sort, fields or include parameters a list does not accept
+var ErrInvalid = errors.New("invalid list query")
+
+// TurkishCollation sorts names in Turkish alphabetical order (ç after c, ı before i, ...).
+// It is an ICU collation PostgreSQL provides when built with ICU support.
+const TurkishCollation = "tr-TR-x-icu"
+
+// Type is the type of a column; filter values are parsed into it
+type Type int
+
+const (
+	// Text columns are compared as strings
+	Text Type = iota
+	// Number columns accept integer values
+	Number
+	// Time columns accept RFC 3339 timestamps or dates (2006-01-02, UTC midnight)
+	Time
+)
+
+// Field is a column of a list; Name is both its column and its JSON name
+type Field struct {
+	Name    string
+	Type    Type
+	Filter  bool // may be used in filter=
+	Sort    bool // may be used in sort=
+	Turkish bool // sorted with TurkishCollation (names)
+}
+
+// Schema declares what the list endpoints of a model accept
+type Schema struct {
+	// Model is a value of the listed model; fields= accepts its JSON names
+	Model interface{}
+	// Key is the primary key column; it is the last sort key so pages are stable
+	Key    string
+	Fields []Field
+	// Includes maps the names include= accepts to the associations they preload
+	Includes map[string]string
+	// DefaultIncludes are preloaded when include= is not given
+	DefaultIncludes []string
+	// DefaultSort is used when sort= is not given, e.g. "-islem_zamani"
+	DefaultSort string
+	// Cursor is the time column cursor pages are ordered by, together with Key; lists
+	// without it only page by page number
+	Cursor string
+	// Entity is the masking entity of the listed records (see package masking), whose
+	// masked fields the caller may not filter or sort by
+	Entity string
+}
+
+// Operators accepted in filter=
+const (
+	OpEq   = "eq"
+	OpNe   = "ne"
+	OpGt   = "gt"
+	OpGte  = "gte"
+	OpLt   = "lt"
+	OpLte  = "lte"
+	OpIn   = "in"   // values separated by |
+	OpLike = "like" // case-insensitive substring, text columns only
+	OpNull = "null" // true or false
+)
+
+type condition struct {
+	column string
+	op     string
+	values []interface{}
+}
+
+type order struct {
+	column  string
+	desc    bool
+	turkish bool
+}
+
+// Query is a parsed list query. The zero value lists every row in no particular order.
+type Query struct {
+	conditions []condition
+	orders     []order
+	preloads   []string
+	fields     []string
+	keyset     *keyset
+	sideload   bool
+	relations  []relation
+}
+
+// Parse reads the filter, sort, fields, include, sideload and cursor parameters of a
+// request for a schema. filter may be repeated; each value holds comma separated column:operator:value
+// terms that must all match.
+func Parse(values url.Values, schema Schema) (Query, error) {
+	var q Query
+
+	for _, filter := range values["filter"] {
+		for _, term := range strings.Split(filter, ",") {
+			if term = strings.TrimSpace(term); term == "" {
+				continue
+			}
+			cond, err := parseCondition(term, schema)
+			if err != nil {
+				return Query{}, err
+			}
+			q.conditions = append(q.conditions, cond)
+		}
+	}
+
+	if _, ok := values["cursor"]; ok {
+		ks, err := parseKeyset(values, schema)
+		if err != nil {
+			return Query{}, err
+		}
+		q.keyset = ks
+		q.orders = ks.orders()
+	} else if err := q.parseSort(values.Get("sort"), schema); err != nil {
+		return Query{}, err
+	}
+
+	includes := schema.DefaultIncludes
+	if _, ok := values["include"]; ok {
+		includes = splitList(values.Get("include"))
+	}
+	for _, name := range includes {
+		association, ok := schema.Includes[name]
+		if !ok {
+			return Query{}, fmt.Errorf("%w: cannot include %q", ErrInvalid, name)
+		}
+		if !slices.Contains(q.preloads, association) {
+			q.preloads = append(q.preloads, association)
+		}
+	}
+
+	if fields := splitList(values.Get("fields")); len(fields) > 0 {
+		known := jsonNames(schema.Model)
+		for _, name := range fields {
+			if !known[name] {
+				return Query{}, fmt.Errorf("%w: unknown field %q", ErrInvalid, name)
+			}
+		}
+		q.fields = fields
+	}
+
+	if s := values.Get("sideload"); s != "" {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return Query{}, fmt.Errorf("%w: sideload takes true or false, got %q", ErrInvalid, s)
+		}
+		q.sideload = b
+		if b {
+			q.relations = relationsOf(reflect.TypeOf(schema.Model), make(map[reflect.Type]bool))
+		}
+	}
+
+	return q, nil
+}
+
+// parseSort reads sort=, falling back to the default sort of the schema
+func (q *Query) parseSort(sort string, schema Schema) error {
+	if sort == "" {
+		sort = schema.DefaultSort
+	}
+	for _, key := range strings.Split(sort, ",") {
+		if key = strings.TrimSpace(key); key == "" {
+			continue
+		}
+		name, desc := strings.CutPrefix(key, "-")
+		field, ok := schema.field(name)
+		if !ok || !field.Sort {
+			return fmt.Errorf("%w: cannot sort by %q", ErrInvalid, name)
+		}
+		q.orders = append(q.orders, order{column: field.Name, desc: desc, turkish: field.Turkish})
+	}
+	if schema.Key != "" && !slices.ContainsFunc(q.orders, func(o order) bool { return o.column == schema.Key }) {
+		desc := len(q.orders) > 0 && q.orders[0].desc
+		q.orders = append(q.orders, order{column: schema.Key, desc: desc})
+	}
+	return nil
+}
+
+func parseCondition(term string, schema Schema) (condition, error) {
+	parts := strings.SplitN(term, ":", 3)
+	if len(parts) != 3 {
+		return condition{}, fmt.Errorf("%w: filter %q is not column:operator:value", ErrInvalid, term)
+	}
+	name, op, raw := parts[0], parts[1], parts[2]
+	field, ok := schema.field(name)
+	if !ok || !field.Filter {
+		return condition{}, fmt.Errorf("%w: cannot filter by %q", ErrInvalid, name)
+	}
+
+	cond := condition{column: field.Name, op: op}
+	switch op {
+	case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte:
+		value, err := parseValue(field, raw)
+		if err != nil {
+			return condition{}, err
+		}
+		cond.values = []interface{}{value}
+	case OpIn:
+		for _, item := range strings.Split(raw, "|") {
+			value, err := parseValue(field, item)
+			if err != nil {
+				return condition{}, err
+			}
+			cond.values = append(cond.values, value)
+		}
+	case OpLike:
+		if field.Type != Text {
+			return condition{}, fmt.Errorf("%w: like only applies to text columns, not %q", ErrInvalid, name)
+		}
+		cond.values = []interface{}{raw}
+	case OpNull:
+		isNull, err := strconv.ParseBool(raw)
+		if err != nil {
+			return condition{}, fmt.Errorf("%w: null takes true or false, got %q", ErrInvalid, raw)
+		}
+		cond.values = []interface{}{isNull}
+	default:
+		return condition{}, fmt.Errorf("%w: unknown operator %q", ErrInvalid, op)
+	}
+	return cond, nil
+}
+
+func parseValue(field Field, raw string) (interface{}, error) {
+	switch field.Type {
+	case Number:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s takes a number, got %q", ErrInvalid, field.Name, raw)
+		}
+		return n, nil
+	case Time:
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t, nil
+		}
+		t, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s takes a date (YYYY-MM-DD) or an RFC 3339 time, got %q", ErrInvalid, field.Name, raw)
+		}
+		return t, nil
+	}
+	return raw, nil
+}
+
+// With adds a condition that column equals value, for the path parameters of an endpoint.
+// column is not checked against the schema and must not come from the request.
+func (q Query) With(column string, value interface{}) Query {
+	q.conditions = append(slices.Clip(q.conditions), condition{column: column, op: OpEq, values: []interface{}{value}})
+	return q
+}
+
+// Between adds a condition that column lies within [start, end], for date range endpoints
+func (q Query) Between(column string, start, end time.Time) Query {
+	q.conditions = append(slices.Clip(q.conditions),
+		condition{column: column, op: OpGte, values: []interface{}{start}},
+		condition{column: column, op: OpLte, values: []interface{}{end}})
+	return q
+}
+
+// Like adds a condition that column contains value, ignoring case
+func (q Query) Like(column, value string) Query {
+	q.conditions = append(slices.Clip(q.conditions), condition{column: column, op: OpLike, values: []interface{}{value}})
+	return q
+}
+
+// Compare adds a condition that column compares to value with op, one of OpEq, OpNe, OpGt,
+// OpGte, OpLt and OpLte. column is not checked against the schema and must not come from the request.
+func (q Query) Compare(column, op string, value interface{}) Query {
+	q.conditions = append(slices.Clip(q.conditions), condition{column: column, op: op, values: []interface{}{value}})
+	return q
+}
+
+// In adds a condition that column is one of values; without values nothing matches
+func (q Query) In(column string, values ...interface{}) Query {
+	q.conditions = append(slices.Clip(q.conditions), condition{column: column, op: OpIn, values: values})
+	return q
+}
+
+// Filtered reports whether the query has any condition
+func (q Query) Filtered() bool {
+	return len(q.conditions) > 0
+}
+
+// Columns returns the columns the query filters or sorts by, each once
+func (q Query) Columns() []string {
+	var columns []string
+	for _, cond := range q.conditions {
+		if !slices.Contains(columns, cond.column) {
+			columns = append(columns, cond.column)
+		}
+	}
+	for _, o := range q.orders {
+		if !slices.Contains(columns, o.column) {
+			columns = append(columns, o.column)
+		}
+	}
+	return columns
+}
+
+// Fields returns the JSON names fields= asked for, or nil for every field
+func (q Query) Fields() []string {
+	return q.fields
+}
+
+// Preloads returns the associations the query preloads
+func (q Query) Preloads() []string {
+	return q.preloads
+}
+
+// Scope applies the conditions of the query to db, e.g. before counting. It leaves out the
+// cursor position so that totals cover the whole list.
+func (q Query) Scope(db *gorm.DB) *gorm.DB {
+	for _, cond := range q.conditions {
+		db = db.Where(cond.expression())
+	}
+	return db
+}
+
+// Apply applies the conditions, the cursor position, the sort order and the preloads of
+// the query to db
+func (q Query) Apply(db *gorm.DB) *gorm.DB {
+	db = q.Scope(db)
+	if q.keyset != nil {
+		db = q.keyset.apply(db)
+	}
+	for _, o := range q.orders {
+		db = db.Order(o.sql())
+	}
+	for _, association := range q.preloads {
+		db = db.Preload(association)
+	}
+	return db
+}
+
+func (c condition) expression() clause.Expression {
+	column := clause.Column{Name: c.column}
+	switch c.op {
+	case OpNe:
+		return clause.Neq{Column: column, Value: c.values[0]}
+	case OpGt:
+		return clause.Gt{Column: column, Value: c.values[0]}
+	case OpGte:
+		return clause.Gte{Column: column, Value: c.values[0]}
+	case OpLt:
+		return clause.Lt{Column: column, Value: c.values[0]}
+	case OpLte:
+		return clause.Lte{Column: column, Value: c.values[0]}
+	case OpIn:
+		return clause.IN{Column: column, Values: c.values}
+	case OpLike:
+		pattern := "%" + escapeLike(c.values[0].(string)) + "%"
+		return clause.Expr{SQL: "? ILIKE ?", Vars: []interface{}{column, pattern}}
+	case OpNull:
+		if c.values[0].(bool) {
+			return clause.Eq{Column: column, Value: nil}
+		}
+		return clause.Neq{Column: column, Value: nil}
+	}
+	return clause.Eq{Column: column, Value: c.values[0]}
+}
+
+// sql renders the order; columns come from a schema or the code, never from the request
+func (o order) sql() string {
+	s := o.column
+	if o.turkish {
+		s += ` COLLATE "` + TurkishCollation + `"`
+	}
+	if o.desc {
+		s += " DESC"
+	}
+	return s
+}
+
+func (s Schema) field(name string) (Field, bool) {
+	for _, field := range s.Fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return Field{}, false
+}
+
+// jsonNames returns the JSON names of the fields of a struct
+func jsonNames(model interface{}) map[string]bool {
+	names := make(map[string]bool)
+	t := reflect.TypeOf(model)
+	if t == nil {
+		return names
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return names
+	}
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names[name] = true
+	}
+	return names
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
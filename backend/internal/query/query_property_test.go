@@ -0,0 +1,419 @@
+package query
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"pgregory.net/rapid"
+)
+
+// Feature: list-query, Property 1: Only Whitelisted Columns Reach SQL
+// *For any* column name, filter= and sort= SHALL be accepted only for the columns the schema
+// declares filterable or sortable; any other name SHALL be rejected with ErrInvalid.
+
+type testKayit struct {
+	KayitKodu   string    `json:"kayit_kodu"`
+	Ad          string    `json:"ad"`
+	Puan        int       `json:"puan"`
+	IslemZamani time.Time `json:"islem_zamani"`
+	Gizli       string    `json:"-"`
+	KisiKodu    string    `json:"kisi_kodu,omitempty"`
+	Kisi        *testKisi `gorm:"foreignKey:KisiKodu;references:KisiKodu" json:"kisi,omitempty"`
+}
+
+type testKisi struct {
+	KisiKodu string    `gorm:"primaryKey" json:"kisi_kodu"`
+	Ad       string    `json:"ad"`
+	Anne     *testKisi `gorm:"-" json:"anne,omitempty"`
+}
+
+var testSchema = Schema{
+	Model: testKayit{},
+	Key:   "kayit_kodu",
+	Fields: []Field{
+		{Name: "kayit_kodu", Filter: true, Sort: true},
+		{Name: "ad", Filter: true, Sort: true, Turkish: true},
+		{Name: "puan", Type: Number, Filter: true},
+		{Name: "islem_zamani", Type: Time, Filter: true, Sort: true},
+	},
+	Includes:        map[string]string{"hasta": "Hasta", "hekim": "Hekim"},
+	DefaultIncludes: []string{"hasta"},
+	DefaultSort:     "-islem_zamani",
+	Cursor:          "islem_zamani",
+}
+
+func TestProperty_OnlyWhitelistedColumnsReachSQL(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		name := rapid.StringMatching(`[a-z_;' ]{1,20}`).Draw(rt, "column")
+		field, known := testSchema.field(name)
+
+		_, err := Parse(url.Values{"filter": {name + ":eq:1"}}, testSchema)
+		if known && field.Filter {
+			if err != nil {
+				rt.Fatalf("Filter by %q should be accepted: %v", name, err)
+			}
+		} else if !errors.Is(err, ErrInvalid) {
+			rt.Fatalf("Filter by %q should be rejected, got %v", name, err)
+		}
+
+		_, err = Parse(url.Values{"sort": {"-" + name}}, testSchema)
+		if known && field.Sort {
+			if err != nil {
+				rt.Fatalf("Sort by %q should be accepted: %v", name, err)
+			}
+		} else if !errors.Is(err, ErrInvalid) {
+			rt.Fatalf("Sort by %q should be rejected, got %v", name, err)
+		}
+	})
+}
+
+func TestParse_RejectsInvalidParameters(t *testing.T) {
+	tests := []url.Values{
+		{"filter": {"ad"}},
+		{"filter": {"ad:regex:x"}},
+		{"filter": {"puan:gt:bir"}},
+		{"filter": {"puan:like:1"}},
+		{"filter": {"islem_zamani:gte:dün"}},
+		{"filter": {"ad:null:belki"}},
+		{"sort": {"puan"}},
+		{"include": {"hemsire"}},
+		{"fields": {"gizli"}},
+	}
+	for _, values := range tests {
+		if _, err := Parse(values, testSchema); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Expected %v to be rejected, got %v", values, err)
+		}
+	}
+}
+
+func TestParse_Defaults(t *testing.T) {
+	q, err := Parse(url.Values{}, testSchema)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if q.Filtered() {
+		t.Error("Expected no conditions")
+	}
+	if !slices.Equal(q.Preloads(), []string{"Hasta"}) {
+		t.Errorf("Expected default includes, got %v", q.Preloads())
+	}
+
+	q, err = Parse(url.Values{"include": {""}}, testSchema)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if len(q.Preloads()) != 0 {
+		t.Errorf("Expected an empty include= to preload nothing, got %v", q.Preloads())
+	}
+}
+
+func dryRun(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to open dry run database: %v", err)
+	}
+	return db
+}
+
+func TestApply_SQL(t *testing.T) {
+	q, err := Parse(url.Values{
+		"filter": {"puan:in:1|2,ad:like:a_b", "islem_zamani:gte:2026-01-01"},
+		"sort":   {"ad,-islem_zamani"},
+	}, testSchema)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	q = q.With("kayit_kodu", "K1")
+
+	var records []testKayit
+	stmt := q.Apply(dryRun(t).Table("kayit")).Find(&records).Statement
+	sql := stmt.SQL.String()
+
+	for _, want := range []string{
+		`"puan" IN ($1,$2)`,
+		`"ad" ILIKE $3`,
+		`"islem_zamani" >= $4`,
+		`"kayit_kodu" = $5`,
+		`ORDER BY ad COLLATE "tr-TR-x-icu",islem_zamani DESC,kayit_kodu`,
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("Expected %q in %s", want, sql)
+		}
+	}
+	if stmt.Vars[2] != `%a\_b%` {
+		t.Errorf("Expected the like pattern to be escaped, got %v", stmt.Vars[2])
+	}
+	if want := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC); stmt.Vars[3] != want {
+		t.Errorf("Expected %v, got %v", want, stmt.Vars[3])
+	}
+}
+
+// Feature: list-query, Property 2: Field Selection Keeps Exactly The Requested Fields
+// *For any* subset of the JSON fields of a record, Project SHALL return every item with
+// exactly the requested fields and their original values.
+
+func TestProperty_FieldSelectionKeepsRequestedFields(t *testing.T) {
+	all := []string{"kayit_kodu", "ad", "puan", "islem_zamani"}
+	rapid.Check(t, func(rt *rapid.T) {
+		fields := rapid.SliceOfNDistinct(rapid.SampledFrom(all), 1, len(all), rapid.ID[string]).Draw(rt, "fields")
+		items := make([]testKayit, rapid.IntRange(0, 5).Draw(rt, "n"))
+		for i := range items {
+			items[i] = testKayit{
+				KayitKodu: rapid.StringMatching(`K[0-9]{3}`).Draw(rt, "kodu"),
+				Ad:        rapid.String().Draw(rt, "ad"),
+				Puan:      rapid.Int().Draw(rt, "puan"),
+			}
+		}
+		data, _ := json.Marshal(items)
+
+		projected, err := Project(data, fields)
+		if err != nil {
+			rt.Fatalf("Failed to project: %v", err)
+		}
+		var got, want []map[string]interface{}
+		if err := json.Unmarshal(projected, &got); err != nil {
+			rt.Fatalf("Failed to decode: %v", err)
+		}
+		_ = json.Unmarshal(data, &want)
+		if len(got) != len(want) {
+			rt.Fatalf("Expected %d items, got %d", len(want), len(got))
+		}
+		for i := range got {
+			if len(got[i]) != len(fields) {
+				rt.Fatalf("Expected fields %v, got %v", fields, got[i])
+			}
+			for _, name := range fields {
+				if got[i][name] != want[i][name] {
+					rt.Fatalf("Field %s changed from %v to %v", name, want[i][name], got[i][name])
+				}
+			}
+		}
+	})
+}
+
+func TestFieldsMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/kayit", func(c *gin.Context) {
+		q, err := Parse(c.Request.URL.Query(), testSchema)
+		if err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		Set(c, q)
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": []testKayit{{KayitKodu: "K1", Ad: "Ayşe", Puan: 3}}})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/kayit?fields=kayit_kodu,puan", nil))
+
+	var body struct {
+		Success bool                     `json:"success"`
+		Data    []map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if !body.Success || len(body.Data) != 1 {
+		t.Fatalf("Unexpected body %s", w.Body.String())
+	}
+	if len(body.Data[0]) != 2 || body.Data[0]["kayit_kodu"] != "K1" || body.Data[0]["puan"] != float64(3) {
+		t.Errorf("Expected only kayit_kodu and puan, got %v", body.Data[0])
+	}
+}
+
+// Feature: list-query, Property 3: Cursors Resume After The Last Record
+// *For any* full page, the next cursor SHALL resume the list strictly after the last record
+// of the page in the same order, and a cursor SHALL NOT be accepted for another order.
+
+func TestProperty_CursorsResumeAfterLastRecord(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		desc := rapid.Bool().Draw(rt, "desc")
+		sort := "islem_zamani"
+		if desc {
+			sort = "-" + sort
+		}
+		limit := rapid.IntRange(1, 5).Draw(rt, "limit")
+		records := make([]testKayit, rapid.IntRange(0, 6).Draw(rt, "n"))
+		for i := range records {
+			records[i] = testKayit{
+				KayitKodu:   rapid.StringMatching(`K[0-9]{1,6}`).Draw(rt, "kodu"),
+				IslemZamani: time.Unix(rapid.Int64Range(0, 1<<32).Draw(rt, "zaman"), rapid.Int64Range(0, 999999).Draw(rt, "us")*1000).UTC(),
+			}
+		}
+
+		q, err := Parse(url.Values{"cursor": {""}, "sort": {sort}}, testSchema)
+		if err != nil {
+			rt.Fatalf("Failed to parse: %v", err)
+		}
+		next := Next(q, records, limit)
+		if len(records) < limit || len(records) == 0 {
+			if next != "" {
+				rt.Fatalf("Expected no cursor after a partial page, got %q", next)
+			}
+			return
+		}
+
+		resumed, err := Parse(url.Values{"cursor": {next}, "sort": {sort}}, testSchema)
+		if err != nil {
+			rt.Fatalf("Failed to parse cursor: %v", err)
+		}
+		last := records[len(records)-1]
+		after := resumed.keyset.after
+		if !after.Time.Equal(last.IslemZamani) || after.Key != last.KayitKodu {
+			rt.Fatalf("Expected the cursor to point at %v/%s, got %v/%s", last.IslemZamani, last.KayitKodu, after.Time, after.Key)
+		}
+
+		flipped := "-islem_zamani"
+		if desc {
+			flipped = "islem_zamani"
+		}
+		if _, err := Parse(url.Values{"cursor": {next}, "sort": {flipped}}, testSchema); !errors.Is(err, ErrInvalid) {
+			rt.Fatalf("Expected the cursor to be rejected for the opposite order, got %v", err)
+		}
+	})
+}
+
+func TestParse_RejectsInvalidCursors(t *testing.T) {
+	tests := []url.Values{
+		{"cursor": {"not a cursor"}},
+		{"cursor": {"e30"}},
+		{"cursor": {""}, "sort": {"ad"}},
+		{"cursor": {""}, "total": {"belki"}},
+	}
+	for _, values := range tests {
+		if _, err := Parse(values, testSchema); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Expected %v to be rejected, got %v", values, err)
+		}
+	}
+
+	withoutCursor := testSchema
+	withoutCursor.Cursor = ""
+	if _, err := Parse(url.Values{"cursor": {""}}, withoutCursor); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Expected cursor pagination to be rejected, got %v", err)
+	}
+}
+
+func TestApply_CursorSQL(t *testing.T) {
+	first, err := Parse(url.Values{"cursor": {""}, "filter": {"puan:gt:1"}}, testSchema)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if first.Counted() {
+		t.Error("Expected cursor pages not to be counted unless total=true")
+	}
+	next := Next(first, []testKayit{{KayitKodu: "K9", IslemZamani: time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)}}, 1)
+
+	q, err := Parse(url.Values{"cursor": {next}, "filter": {"puan:gt:1"}, "total": {"true"}}, testSchema)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if !q.Counted() {
+		t.Error("Expected total=true to count")
+	}
+
+	var records []testKayit
+	sql := q.Apply(dryRun(t).Table("kayit")).Find(&records).Statement.SQL.String()
+	for _, want := range []string{
+		`"puan" > $1`,
+		`("islem_zamani", "kayit_kodu") < ($2, $3)`,
+		`ORDER BY islem_zamani DESC,kayit_kodu DESC`,
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("Expected %q in %s", want, sql)
+		}
+	}
+
+	var total int64
+	count := q.Scope(dryRun(t).Table("kayit")).Count(&total).Statement.SQL.String()
+	if strings.Contains(count, "kayit_kodu") {
+		t.Errorf("Expected the total to ignore the cursor position: %s", count)
+	}
+}
+
+// Feature: list-query, Property 4: Sideloading Sends Each Related Record Once
+// *For any* list whose records share related records, sideload SHALL remove the related
+// records from the list and return each of them exactly once in included, so that joining
+// them back by key restores the original list.
+
+func TestProperty_SideloadingSendsRelatedRecordsOnce(t *testing.T) {
+	relations := relationsOf(reflect.TypeOf(testKayit{}), make(map[reflect.Type]bool))
+	rapid.Check(t, func(rt *rapid.T) {
+		pool := make([]testKisi, rapid.IntRange(1, 3).Draw(rt, "kisiler"))
+		for i := range pool {
+			pool[i] = testKisi{KisiKodu: fmt.Sprintf("P%d", i), Ad: rapid.String().Draw(rt, "ad")}
+			if rapid.Bool().Draw(rt, "anne") {
+				pool[i].Anne = &testKisi{KisiKodu: "A", Ad: "Anne"}
+			}
+		}
+		records := make([]testKayit, rapid.IntRange(0, 8).Draw(rt, "n"))
+		for i := range records {
+			records[i].KayitKodu = fmt.Sprintf("K%d", i)
+			if j := rapid.IntRange(-1, len(pool)-1).Draw(rt, "kisi"); j >= 0 {
+				records[i].KisiKodu = pool[j].KisiKodu
+				records[i].Kisi = &pool[j]
+			}
+		}
+		data, _ := json.Marshal(records)
+
+		reduced, sections, err := sideload(data, relations)
+		if err != nil {
+			rt.Fatalf("Failed to sideload: %v", err)
+		}
+		var got []testKayit
+		if err := json.Unmarshal(reduced, &got); err != nil {
+			rt.Fatalf("Failed to decode: %v", err)
+		}
+		kisiler := make(map[string]*testKisi)
+		for _, raw := range sections["test_kisi"] {
+			var k testKisi
+			if err := json.Unmarshal(raw, &k); err != nil {
+				rt.Fatalf("Failed to decode included: %v", err)
+			}
+			if kisiler[k.KisiKodu] != nil {
+				rt.Fatalf("%s is included twice", k.KisiKodu)
+			}
+			kisiler[k.KisiKodu] = &k
+		}
+
+		for i := range got {
+			if got[i].Kisi != nil {
+				rt.Fatalf("Expected %s to be sideloaded", got[i].KayitKodu)
+			}
+			if got[i].KisiKodu != "" {
+				got[i].Kisi = kisiler[got[i].KisiKodu]
+			}
+		}
+		restored, _ := json.Marshal(got)
+		if string(restored) != string(data) {
+			rt.Fatalf("Joining included back gave %s, want %s", restored, data)
+		}
+	})
+}
+
+func TestSnakeCase(t *testing.T) {
+	for in, want := range map[string]string{"Personel": "personel", "HastaBasvuru": "hasta_basvuru", "NFCKart": "nfc_kart"} {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
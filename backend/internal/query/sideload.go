@@ -0,0 +1,178 @@
+package query
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// relation is a to-one association of a model that sideload=true moves out of the records
+// into the included section of the response
+type relation struct {
+	name     string // JSON name of the association in the record
+	typ      string // section of included, e.g. "personel"
+	key      string // JSON name of the primary key of the related model
+	children []relation
+}
+
+// relationsOf returns the belongs-to associations of a model, and theirs in turn. Only
+// associations whose foreign key the record carries are returned, so clients can find them
+// in included. A model does not descend into associations of its own type (Hasta.Anne) or
+// of one already on the path.
+func relationsOf(t reflect.Type, path map[reflect.Type]bool) []relation {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	path[t] = true
+	defer delete(path, t)
+
+	var relations []relation
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Type.Kind() != reflect.Pointer || f.Type.Elem().Kind() != reflect.Struct {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		key := primaryKey(f.Type.Elem())
+		if name == "" || name == "-" || key == "" || !hasForeignKey(t, f) {
+			continue
+		}
+		r := relation{name: name, typ: snakeCase(f.Type.Elem().Name()), key: key}
+		if !path[f.Type.Elem()] {
+			r.children = relationsOf(f.Type.Elem(), path)
+		}
+		relations = append(relations, r)
+	}
+	return relations
+}
+
+// primaryKey returns the JSON name of the gorm primary key of a model
+func primaryKey(t reflect.Type) string {
+	for _, f := range reflect.VisibleFields(t) {
+		if strings.Contains(f.Tag.Get("gorm"), "primaryKey") {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			return name
+		}
+	}
+	return ""
+}
+
+// hasForeignKey reports whether model t holds the foreign key of its association f
+func hasForeignKey(t reflect.Type, f reflect.StructField) bool {
+	for _, setting := range strings.Split(f.Tag.Get("gorm"), ";") {
+		if name, ok := strings.CutPrefix(setting, "foreignKey:"); ok {
+			fk, found := t.FieldByName(name)
+			return found && fk.Tag.Get("json") != "-"
+		}
+	}
+	return false
+}
+
+// snakeCase turns a Go type name into its table style name (NFCKart -> nfc_kart)
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 &&
+			(unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// included collects related records once per type and primary key, in order of first
+// appearance
+type included struct {
+	sections map[string][]json.RawMessage
+	seen     map[string]bool
+}
+
+func (inc *included) add(typ, key string, record json.RawMessage) {
+	if inc.seen[typ+"\x00"+key] {
+		return
+	}
+	inc.seen[typ+"\x00"+key] = true
+	inc.sections[typ] = append(inc.sections[typ], record)
+}
+
+// sideload moves the to-one associations out of the records of data (a JSON array or
+// object) and returns them deduplicated by type, so that an admission shared by a hundred
+// vital sign records is sent once. Records keep the foreign keys that refer to them.
+func sideload(data json.RawMessage, relations []relation) (json.RawMessage, map[string][]json.RawMessage, error) {
+	inc := &included{sections: make(map[string][]json.RawMessage), seen: make(map[string]bool)}
+
+	var out json.RawMessage
+	var err error
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, nil, err
+		}
+		for i := range items {
+			if items[i], err = inc.extract(items[i], relations); err != nil {
+				return nil, nil, err
+			}
+		}
+		out, err = json.Marshal(items)
+	} else {
+		out, err = inc.extract(data, relations)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, inc.sections, nil
+}
+
+// extract removes the relations from a record, adding them to included
+func (inc *included) extract(record json.RawMessage, relations []relation) (json.RawMessage, error) {
+	if len(relations) == 0 {
+		return record, nil
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(record, &obj); err != nil || obj == nil {
+		return record, err
+	}
+	if err := inc.extractObject(obj, relations); err != nil {
+		return nil, err
+	}
+	return json.Marshal(obj)
+}
+
+func (inc *included) extractObject(obj map[string]json.RawMessage, relations []relation) error {
+	for _, r := range relations {
+		nested, ok := obj[r.name]
+		if !ok || string(nested) == "null" {
+			continue
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(nested, &fields); err != nil {
+			return err
+		}
+		key := string(fields[r.key])
+		if key == "" {
+			// Without its key the record cannot be referred to; leave it in place
+			continue
+		}
+		delete(obj, r.name)
+		if inc.seen[r.typ+"\x00"+key] {
+			continue
+		}
+
+		if len(r.children) > 0 {
+			if err := inc.extractObject(fields, r.children); err != nil {
+				return err
+			}
+			var err error
+			if nested, err = json.Marshal(fields); err != nil {
+				return err
+			}
+		}
+		inc.add(r.typ, key, nested)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the failure records in process memory. Records untouched for longer
+// than the retention time are dropped, so the store does not grow with every scanned IP.
+type MemoryStore struct {
+	mu          sync.Mutex
+	kayitlar    map[string]Kayit
+	saklama     time.Duration
+	sonTemizlik time.Time
+	now         func() time.Time
+}
+
+// NewMemoryStore creates a MemoryStore that keeps records for saklama after their last
+// failure or block
+func NewMemoryStore(saklama time.Duration) *MemoryStore {
+	return &MemoryStore{kayitlar: make(map[string]Kayit), saklama: saklama, now: time.Now}
+}
+
+// Get returns the record of a source, or nil if none is kept
+func (s *MemoryStore) Get(anahtar string) (*Kayit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kayit, ok := s.kayitlar[anahtar]
+	if !ok {
+		return nil, nil
+	}
+	return &kayit, nil
+}
+
+// Update applies fn to the record of a source under the store lock
+func (s *MemoryStore) Update(anahtar string, fn func(*Kayit)) (Kayit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.temizle()
+	kayit := s.kayitlar[anahtar]
+	fn(&kayit)
+	s.kayitlar[anahtar] = kayit
+	return kayit, nil
+}
+
+// Delete forgets a source
+func (s *MemoryStore) Delete(anahtar string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.kayitlar, anahtar)
+	return nil
+}
+
+// List returns every kept record
+func (s *MemoryStore) List() ([]Kayit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.temizle()
+	kayitlar := make([]Kayit, 0, len(s.kayitlar))
+	for _, kayit := range s.kayitlar {
+		kayitlar = append(kayitlar, kayit)
+	}
+	return kayitlar, nil
+}
+
+// temizle drops expired records, at most once per retention period
+func (s *MemoryStore) temizle() {
+	now := s.now()
+	if now.Sub(s.sonTemizlik) < s.saklama {
+		return
+	}
+	s.sonTemizlik = now
+	for anahtar, kayit := range s.kayitlar {
+		if now.Sub(kayit.sonHareket()) > s.saklama {
+			delete(s.kayitlar, anahtar)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"log"
+	"math"
+	"medscreen/internal/constants"
+	"medscreen/internal/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware guards a public authentication endpoint named olay. A request from a blocked
+// source is refused with 429 and a Retry-After header. After the handler has run, a 401
+// counts as a failure of every source and a 2xx clears the sources sifirlanir accepts.
+// Shared sources such as the client IP should not be cleared by a success, or one valid
+// credential would reset the count of every guess made alongside it.
+//
+// Failures and blocks are logged as security events together with the reason the handler
+// attached with c.Error, which is never sent to the client. If the store is unavailable
+// the endpoint stays open, so an outage of a shared store cannot lock out every ward.
+func Middleware(l *Limiter, olay string, kaynaklar func(c *gin.Context) []string, sifirlanir func(anahtar string) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		anahtarlar := kaynaklar(c)
+
+		bekleme, err := l.Bekleme(anahtarlar...)
+		if err != nil {
+			log.Printf("[SECURITY] %s: rate limit store unavailable: %v", olay, err)
+		}
+		if bekleme > 0 {
+			log.Printf("[SECURITY] %s refused for blocked source %v (%s left)", olay, anahtarlar, bekleme.Round(time.Second))
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(bekleme.Seconds()))))
+			utils.SendErrorResponse(c, http.StatusTooManyRequests, constants.ERROR_TOO_MANY_ATTEMPTS, "Too many failed attempts, try again later", nil)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		switch status := c.Writer.Status(); {
+		case status == http.StatusUnauthorized:
+			sebep := "unknown"
+			if last := c.Errors.Last(); last != nil {
+				sebep = last.Error()
+			}
+			kayitlar, err := l.Basarisiz(anahtarlar...)
+			if err != nil {
+				log.Printf("[SECURITY] %s: rate limit store unavailable: %v", olay, err)
+			}
+			log.Printf("[SECURITY] %s failed from %v: %s", olay, anahtarlar, sebep)
+			for _, kayit := range kayitlar {
+				if kayit.Engelli(kayit.SonBasarisiz) {
+					log.Printf("[SECURITY] %s: %s blocked until %s after %d failures (locked out: %t)",
+						olay, kayit.Anahtar, kayit.EngelBitis.Format(time.RFC3339), kayit.Basarisiz, kayit.Kilitli)
+				}
+			}
+		case status >= 200 && status < 300:
+			var sifirlanan []string
+			for _, anahtar := range anahtarlar {
+				if sifirlanir(anahtar) {
+					sifirlanan = append(sifirlanan, anahtar)
+				}
+			}
+			if err := l.Basarili(sifirlanan...); err != nil {
+				log.Printf("[SECURITY] %s: rate limit store unavailable: %v", olay, err)
+			}
+		}
+	}
+}
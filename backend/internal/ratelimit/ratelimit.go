@@ -0,0 +1,166 @@
+// Package ratelimit slows down and locks out sources that keep failing to authenticate.
+//
+// Failures are counted per source, e.g. a client IP or a tablet. After a number of free
+// attempts every further failure blocks the source for an exponentially growing backoff,
+// and a source that keeps failing is locked out. The counts live in a Store, so several
+// MedScreen instances can share them; MemoryStore serves a single instance.
+package ratelimit
+
+import (
+	"sort"
+	"time"
+)
+
+// Ayarlar configures a Limiter
+type Ayarlar struct {
+	// Serbest is the number of failures allowed before backoff starts
+	Serbest int
+	// Taban is the backoff after the first failure past Serbest; it doubles with every further failure
+	Taban time.Duration
+	// KilitEsigi is the number of failures after which the source is locked out
+	KilitEsigi int
+	// KilitSuresi is how long a lockout lasts; backoffs never exceed it
+	KilitSuresi time.Duration
+	// Sifirlama is the quiet time after which the failures of a source are forgotten
+	Sifirlama time.Duration
+}
+
+// Kayit is the failure record of a single source
+type Kayit struct {
+	Anahtar      string    `json:"anahtar"`
+	Basarisiz    int       `json:"basarisiz_deneme"`
+	SonBasarisiz time.Time `json:"son_basarisiz"`
+	// EngelBitis is when the source may try again; zero when it was never blocked
+	EngelBitis time.Time `json:"engel_bitis"`
+	Kilitli    bool      `json:"kilitli"`
+}
+
+// Engelli reports whether the source is blocked at now
+func (k Kayit) Engelli(now time.Time) bool {
+	return now.Before(k.EngelBitis)
+}
+
+// sonHareket is the end of the source's latest failure or block
+func (k Kayit) sonHareket() time.Time {
+	if k.EngelBitis.After(k.SonBasarisiz) {
+		return k.EngelBitis
+	}
+	return k.SonBasarisiz
+}
+
+// Store keeps the failure records. Update must apply fn atomically per key, so failures
+// reported concurrently by several instances are all counted.
+type Store interface {
+	// Get returns the record of a source, or nil if none is kept
+	Get(anahtar string) (*Kayit, error)
+	// Update applies fn to the record of a source, creating it if needed, and stores the result
+	Update(anahtar string, fn func(*Kayit)) (Kayit, error)
+	Delete(anahtar string) error
+	List() ([]Kayit, error)
+}
+
+// Limiter applies backoff and lockout to the sources of failed attempts
+type Limiter struct {
+	store Store
+	ayar  Ayarlar
+	now   func() time.Time
+}
+
+// New creates a new Limiter
+func New(store Store, ayar Ayarlar) *Limiter {
+	return &Limiter{store: store, ayar: ayar, now: time.Now}
+}
+
+// Bekleme returns how long the caller must wait before the sources may try again;
+// zero means the attempt is allowed. The longest wait of all sources applies.
+func (l *Limiter) Bekleme(anahtarlar ...string) (time.Duration, error) {
+	now := l.now()
+	var bekleme time.Duration
+	for _, anahtar := range anahtarlar {
+		kayit, err := l.store.Get(anahtar)
+		if err != nil {
+			return 0, err
+		}
+		if kayit != nil && kayit.Engelli(now) && kayit.EngelBitis.Sub(now) > bekleme {
+			bekleme = kayit.EngelBitis.Sub(now)
+		}
+	}
+	return bekleme, nil
+}
+
+// Basarisiz records a failed attempt for every source and returns their updated records
+func (l *Limiter) Basarisiz(anahtarlar ...string) ([]Kayit, error) {
+	now := l.now()
+	kayitlar := make([]Kayit, 0, len(anahtarlar))
+	for _, anahtar := range anahtarlar {
+		kayit, err := l.store.Update(anahtar, func(k *Kayit) {
+			if k.Basarisiz > 0 && now.Sub(k.sonHareket()) > l.ayar.Sifirlama {
+				*k = Kayit{}
+			}
+			k.Anahtar = anahtar
+			k.Basarisiz++
+			k.SonBasarisiz = now
+			switch {
+			case l.ayar.KilitEsigi > 0 && k.Basarisiz >= l.ayar.KilitEsigi:
+				k.EngelBitis = now.Add(l.ayar.KilitSuresi)
+				k.Kilitli = true
+			case k.Basarisiz > l.ayar.Serbest:
+				k.EngelBitis = now.Add(l.geriCekilme(k.Basarisiz - l.ayar.Serbest))
+			}
+		})
+		if err != nil {
+			return kayitlar, err
+		}
+		kayitlar = append(kayitlar, kayit)
+	}
+	return kayitlar, nil
+}
+
+// Basarili clears the failures of the sources after a successful attempt
+func (l *Limiter) Basarili(anahtarlar ...string) error {
+	for _, anahtar := range anahtarlar {
+		if err := l.store.Delete(anahtar); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Engelliler lists the sources blocked now, those blocked longest first
+func (l *Limiter) Engelliler() ([]Kayit, error) {
+	kayitlar, err := l.store.List()
+	if err != nil {
+		return nil, err
+	}
+	now := l.now()
+	engelliler := []Kayit{}
+	for _, kayit := range kayitlar {
+		if kayit.Engelli(now) {
+			engelliler = append(engelliler, kayit)
+		}
+	}
+	sort.Slice(engelliler, func(i, j int) bool {
+		if !engelliler[i].EngelBitis.Equal(engelliler[j].EngelBitis) {
+			return engelliler[i].EngelBitis.After(engelliler[j].EngelBitis)
+		}
+		return engelliler[i].Anahtar < engelliler[j].Anahtar
+	})
+	return engelliler, nil
+}
+
+// Kaldir lifts the block of a source and forgets its failures
+func (l *Limiter) Kaldir(anahtar string) error {
+	return l.store.Delete(anahtar)
+}
+
+// geriCekilme is the backoff after the n-th failure past the free attempts
+func (l *Limiter) geriCekilme(n int) time.Duration {
+	sure := l.ayar.Taban
+	for i := 1; i < n && sure < l.ayar.KilitSuresi; i++ {
+		sure *= 2
+	}
+	if l.ayar.KilitSuresi > 0 && sure > l.ayar.KilitSuresi {
+		sure = l.ayar.KilitSuresi
+	}
+	return sure
+}
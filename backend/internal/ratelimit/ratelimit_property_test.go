@@ -0,0 +1,167 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pgregory.net/rapid"
+)
+
+// Feature: nfc-brute-force-protection, Property 1: Backoff Grows Until Lockout
+// *For any* sequence of failures, a source SHALL be free for the first Serbest failures,
+// then blocked for a backoff that doubles with each failure and never exceeds the lockout,
+// and locked out from the KilitEsigi-th failure on.
+
+var testAyarlar = Ayarlar{Serbest: 3, Taban: time.Second, KilitEsigi: 10, KilitSuresi: time.Minute, Sifirlama: 10 * time.Minute}
+
+func newTestLimiter(now *time.Time) *Limiter {
+	store := NewMemoryStore(time.Hour)
+	store.now = func() time.Time { return *now }
+	l := New(store, testAyarlar)
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+// TestProperty_BackoffGrowsUntilLockout tests Property 1
+func TestProperty_BackoffGrowsUntilLockout(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+		l := newTestLimiter(&now)
+		deneme := rapid.IntRange(1, 15).Draw(rt, "deneme")
+
+		var onceki time.Duration
+		for n := 1; n <= deneme; n++ {
+			kayitlar, err := l.Basarisiz("ip:10.0.0.1")
+			if err != nil {
+				rt.Fatalf("Unexpected error: %v", err)
+			}
+			k := kayitlar[0]
+			bekleme, _ := l.Bekleme("ip:10.0.0.1", "cihaz:TBL1")
+
+			switch {
+			case n <= testAyarlar.Serbest:
+				if bekleme != 0 {
+					rt.Fatalf("Failure %d: expected no backoff, got %s", n, bekleme)
+				}
+			case n >= testAyarlar.KilitEsigi:
+				if !k.Kilitli || bekleme != testAyarlar.KilitSuresi {
+					rt.Fatalf("Failure %d: expected lockout, got %+v (%s)", n, k, bekleme)
+				}
+			default:
+				if bekleme <= 0 || bekleme > testAyarlar.KilitSuresi || (onceki > 0 && bekleme < onceki) || k.Kilitli {
+					rt.Fatalf("Failure %d: unexpected backoff %s after %s", n, bekleme, onceki)
+				}
+				if onceki > 0 && bekleme < testAyarlar.KilitSuresi && bekleme != 2*onceki {
+					rt.Fatalf("Failure %d: expected the backoff to double from %s, got %s", n, onceki, bekleme)
+				}
+			}
+			onceki = bekleme
+			// The next attempt comes once the block is over
+			now = now.Add(bekleme)
+		}
+
+		if err := l.Basarili("ip:10.0.0.1"); err != nil {
+			rt.Fatal(err)
+		}
+		if kayit, _ := l.store.Get("ip:10.0.0.1"); kayit != nil {
+			rt.Fatalf("Expected a success to clear the source, got %+v", kayit)
+		}
+	})
+}
+
+// TestLimiter_ResetAndBlockedList verifies that quiet sources are forgotten, that a lockout
+// is not forgotten while it lasts and that the admin list shows only blocked sources
+func TestLimiter_ResetAndBlockedList(t *testing.T) {
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	l := newTestLimiter(&now)
+
+	for i := 0; i < testAyarlar.Serbest; i++ {
+		_, _ = l.Basarisiz("ip:10.0.0.1")
+	}
+	now = now.Add(testAyarlar.Sifirlama + time.Second)
+	kayitlar, _ := l.Basarisiz("ip:10.0.0.1")
+	if kayitlar[0].Basarisiz != 1 {
+		t.Errorf("Expected failures to be forgotten after a quiet period, got %d", kayitlar[0].Basarisiz)
+	}
+
+	for i := 0; i < testAyarlar.KilitEsigi; i++ {
+		_, _ = l.Basarisiz("cihaz:TBL1")
+	}
+	now = now.Add(testAyarlar.KilitSuresi + time.Second)
+	kayitlar, _ = l.Basarisiz("cihaz:TBL1")
+	if !kayitlar[0].Kilitli {
+		t.Errorf("Expected a failure right after a lockout to lock the source again, got %+v", kayitlar[0])
+	}
+
+	engelliler, err := l.Engelliler()
+	if err != nil || len(engelliler) != 1 || engelliler[0].Anahtar != "cihaz:TBL1" {
+		t.Fatalf("Expected only the locked tablet to be listed, got %+v, %v", engelliler, err)
+	}
+	if err := l.Kaldir("cihaz:TBL1"); err != nil {
+		t.Fatal(err)
+	}
+	if bekleme, _ := l.Bekleme("cihaz:TBL1"); bekleme != 0 {
+		t.Errorf("Expected the unblocked source to be allowed, got %s", bekleme)
+	}
+}
+
+type failingStore struct{ *MemoryStore }
+
+func (*failingStore) Get(string) (*Kayit, error) { return nil, errors.New("store unavailable") }
+
+// TestMiddleware_BlocksAfterFailures verifies 429 with Retry-After for a blocked source, that
+// a success does not clear a shared source and that a store outage leaves the endpoint open
+func TestMiddleware_BlocksAfterFailures(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	l := newTestLimiter(&now)
+
+	router := gin.New()
+	kaynaklar := func(c *gin.Context) []string { return []string{"ip:" + c.ClientIP(), "kart:" + c.Param("uid")} }
+	sifirlanir := func(anahtar string) bool { return strings.HasPrefix(anahtar, "kart:") }
+	router.GET("/login/:uid", Middleware(l, "test_login", kaynaklar, sifirlanir), func(c *gin.Context) {
+		if c.Param("uid") == "GECERLI" {
+			c.JSON(http.StatusOK, gin.H{"success": true})
+			return
+		}
+		_ = c.Error(errors.New("NFC card not found"))
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false})
+	})
+	get := func(uid string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/login/"+uid, nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < testAyarlar.Serbest; i++ {
+		if w := get("YOK"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("Attempt %d: expected 401, got %d", i+1, w.Code)
+		}
+	}
+	if w := get("GECERLI"); w.Code != http.StatusOK {
+		t.Fatalf("Expected a valid card within the free attempts to pass, got %d", w.Code)
+	}
+	// The success does not clear the IP, so the next failure is already over the free attempts
+	get("YOK")
+	w := get("GECERLI")
+	var body map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" || body["code"] != "TOO_MANY_ATTEMPTS" {
+		t.Fatalf("Expected 429 with Retry-After while blocked, got %d %q %v", w.Code, w.Header().Get("Retry-After"), body)
+	}
+
+	acik := New(&failingStore{MemoryStore: NewMemoryStore(time.Hour)}, testAyarlar)
+	router = gin.New()
+	router.GET("/login/:uid", Middleware(acik, "test_login", kaynaklar, sifirlanir), func(c *gin.Context) { c.Status(http.StatusOK) })
+	if w := get("GECERLI"); w.Code != http.StatusOK {
+		t.Errorf("Expected the endpoint to stay open when the store fails, got %d", w.Code)
+	}
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"medscreen/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// acilErisimRepository implements AcilErisimRepository interface
+type acilErisimRepository struct {
+	db *gorm.DB
+}
+
+// NewAcilErisimRepository creates a new AcilErisimRepository instance
+func NewAcilErisimRepository(db *gorm.DB) AcilErisimRepository {
+	return &acilErisimRepository{db: db}
+}
+
+// Create records a grant
+func (r *acilErisimRepository) Create(erisim *models.AcilErisim) error {
+	return r.db.Create(erisim).Error
+}
+
+// FindByKodu retrieves a grant by its code
+func (r *acilErisimRepository) FindByKodu(acilErisimKodu string) (*models.AcilErisim, error) {
+	var erisim models.AcilErisim
+	if err := r.db.Where("acil_erisim_kodu = ?", acilErisimKodu).First(&erisim).Error; err != nil {
+		return nil, err
+	}
+	return &erisim, nil
+}
+
+// FindByInceleme retrieves grants by review state, newest first, with pagination
+func (r *acilErisimRepository) FindByInceleme(incelendi *bool, page, limit int) ([]models.AcilErisim, int64, error) {
+	var erisimler []models.AcilErisim
+	var total int64
+
+	query := r.db.Model(&models.AcilErisim{})
+	if incelendi != nil {
+		if *incelendi {
+			query = query.Where("inceleme_zamani IS NOT NULL")
+		} else {
+			query = query.Where("inceleme_zamani IS NULL")
+		}
+	}
+
+	// Count total records
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	if err := query.Order("baslangic_zamani DESC, acil_erisim_id DESC").
+		Offset(offset).Limit(limit).Find(&erisimler).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return erisimler, total, nil
+}
+
+// Incele signs off a pending grant
+func (r *acilErisimRepository) Incele(acilErisimKodu string, inceleme AcilErisimIncelemesi) error {
+	result := r.db.Model(&models.AcilErisim{}).
+		Where("acil_erisim_kodu = ? AND inceleme_zamani IS NULL", acilErisimKodu).
+		Updates(map[string]interface{}{
+			"inceleme_zamani":         inceleme.Zaman,
+			"inceleyen_personel_kodu": inceleme.InceleyenPersonelKodu,
+			"inceleme_notu":           inceleme.Not,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 
 	"gorm.io/gorm"
 )
@@ -94,3 +95,34 @@ func (r *anlikYatanHastaRepository) FindByBirimKodu(birimKodu string, page, limi
 
 	return yatanHastalar, total, nil
 }
+
+// FindByBasvuruKodu retrieves the current inpatient record of a visit, if the patient is still admitted
+func (r *anlikYatanHastaRepository) FindByBasvuruKodu(basvuruKodu string) (*models.AnlikYatanHasta, error) {
+	var yatanHasta models.AnlikYatanHasta
+	if err := r.db.Preload("Yatak").Preload("Hekim").
+		Where("hasta_basvuru_kodu = ?", basvuruKodu).
+		Order("yatis_zamani DESC").First(&yatanHasta).Error; err != nil {
+		return nil, err
+	}
+	return &yatanHasta, nil
+}
+
+// FindByBasvuruKodlari retrieves the current stays of several visits with one query, the
+// latest stay of each visit first
+func (r *anlikYatanHastaRepository) FindByBasvuruKodlari(basvuruKodlari []string) ([]models.AnlikYatanHasta, error) {
+	if len(basvuruKodlari) == 0 {
+		return nil, nil
+	}
+	var yatanHastalar []models.AnlikYatanHasta
+	if err := r.db.Preload("Yatak").Preload("Hekim").
+		Where("hasta_basvuru_kodu IN ?", basvuruKodlari).
+		Order("hasta_basvuru_kodu, yatis_zamani DESC").Find(&yatanHastalar).Error; err != nil {
+		return nil, err
+	}
+	return yatanHastalar, nil
+}
+
+// List retrieves current inpatients matching a list query with pagination
+func (r *anlikYatanHastaRepository) List(q query.Query, page, limit int) ([]models.AnlikYatanHasta, int64, error) {
+	return list[models.AnlikYatanHasta](r.db, q, page, limit)
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"medscreen/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// apiAnahtariRepository implements APIAnahtariRepository interface
+type apiAnahtariRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIAnahtariRepository creates a new APIAnahtariRepository instance
+func NewAPIAnahtariRepository(db *gorm.DB) APIAnahtariRepository {
+	return &apiAnahtariRepository{db: db}
+}
+
+// Create records a new API key
+func (r *apiAnahtariRepository) Create(anahtar *models.APIAnahtari) error {
+	return r.db.Create(anahtar).Error
+}
+
+// FindByKodu retrieves an API key by its public code
+func (r *apiAnahtariRepository) FindByKodu(anahtarKodu string) (*models.APIAnahtari, error) {
+	var anahtar models.APIAnahtari
+	if err := r.db.Where("anahtar_kodu = ?", anahtarKodu).First(&anahtar).Error; err != nil {
+		return nil, err
+	}
+	return &anahtar, nil
+}
+
+// FindAll retrieves every API key, newest first
+func (r *apiAnahtariRepository) FindAll() ([]models.APIAnahtari, error) {
+	var anahtarlar []models.APIAnahtari
+	if err := r.db.Order("olusturma_zamani DESC").Find(&anahtarlar).Error; err != nil {
+		return nil, err
+	}
+	return anahtarlar, nil
+}
+
+// UpdateKullanim records when and from where a key was last used
+func (r *apiAnahtariRepository) UpdateKullanim(anahtarKodu string, zaman time.Time, ipAdresi string) error {
+	return r.db.Model(&models.APIAnahtari{}).
+		Where("anahtar_kodu = ?", anahtarKodu).
+		Updates(map[string]interface{}{
+			"son_kullanilma_zamani":  zaman,
+			"son_kullanan_ip_adresi": ipAdresi,
+		}).Error
+}
+
+// Iptal revokes an active API key
+func (r *apiAnahtariRepository) Iptal(anahtarKodu, iptalEden string, zaman time.Time) error {
+	result := r.db.Model(&models.APIAnahtari{}).
+		Where("anahtar_kodu = ? AND iptal_zamani IS NULL", anahtarKodu).
+		Updates(map[string]interface{}{
+			"iptal_zamani":             zaman,
+			"iptal_eden_personel_kodu": iptalEden,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
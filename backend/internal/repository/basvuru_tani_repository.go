@@ -2,6 +2,7 @@ package repository
 
 import (
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 
 	"gorm.io/gorm"
 )
@@ -85,3 +86,19 @@ func (r *basvuruTaniRepository) FindByTaniKodu(taniKodu string, page, limit int)
 
 	return tanilar, total, nil
 }
+
+// FindBirincilByBasvuruKodu retrieves the latest primary diagnosis of a visit
+func (r *basvuruTaniRepository) FindBirincilByBasvuruKodu(basvuruKodu string) (*models.BasvuruTani, error) {
+	var tani models.BasvuruTani
+	if err := r.db.Preload("Hekim").
+		Where("hasta_basvuru_kodu = ? AND birincil_tani = ?", basvuruKodu, 1).
+		Order("tani_zamani DESC").First(&tani).Error; err != nil {
+		return nil, err
+	}
+	return &tani, nil
+}
+
+// List retrieves diagnoses matching a list query with pagination
+func (r *basvuruTaniRepository) List(q query.Query, page, limit int) ([]models.BasvuruTani, int64, error) {
+	return list[models.BasvuruTani](r.db, q, page, limit)
+}
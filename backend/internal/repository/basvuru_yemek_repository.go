@@ -2,6 +2,8 @@ package repository
 
 import (
 	"medscreen/internal/models"
+	"medscreen/internal/query"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -65,3 +67,19 @@ func (r *basvuruYemekRepository) FindByTuru(yemekTuru string, page, limit int) (
 
 	return yemekler, total, nil
 }
+
+// FindByBasvuruKoduAndDateRange retrieves meal information of a visit recorded within a date range
+func (r *basvuruYemekRepository) FindByBasvuruKoduAndDateRange(basvuruKodu string, startDate, endDate time.Time) ([]models.BasvuruYemek, error) {
+	var yemekler []models.BasvuruYemek
+	if err := r.db.Where("hasta_basvuru_kodu = ? AND kayit_zamani >= ? AND kayit_zamani < ?", basvuruKodu, startDate, endDate).
+		Order("kayit_zamani ASC").
+		Find(&yemekler).Error; err != nil {
+		return nil, err
+	}
+	return yemekler, nil
+}
+
+// List retrieves meals matching a list query with pagination
+func (r *basvuruYemekRepository) List(q query.Query, page, limit int) ([]models.BasvuruYemek, int64, error) {
+	return list[models.BasvuruYemek](r.db, q, page, limit)
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"medscreen/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// cihazKaydiRepository implements CihazKaydiRepository interface
+type cihazKaydiRepository struct {
+	db *gorm.DB
+}
+
+// NewCihazKaydiRepository creates a new CihazKaydiRepository instance
+func NewCihazKaydiRepository(db *gorm.DB) CihazKaydiRepository {
+	return &cihazKaydiRepository{db: db}
+}
+
+// Save records the enrollment of a tablet, replacing its previous enrollment
+func (r *cihazKaydiRepository) Save(kayit *models.CihazKaydi) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tablet_cihaz_kodu"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"seri_numarasi", "anahtar_ozeti", "kayit_zamani", "son_gorulme_zamani",
+			"ip_adresi", "uygulama_surumu", "pil_seviyesi",
+		}),
+	}).Create(kayit).Error
+}
+
+// FindByTabletCihazKodu retrieves the enrollment of a tablet
+func (r *cihazKaydiRepository) FindByTabletCihazKodu(tabletCihazKodu string) (*models.CihazKaydi, error) {
+	var kayit models.CihazKaydi
+	if err := r.db.Where("tablet_cihaz_kodu = ?", tabletCihazKodu).First(&kayit).Error; err != nil {
+		return nil, err
+	}
+	return &kayit, nil
+}
+
+// FindAll retrieves every enrollment
+func (r *cihazKaydiRepository) FindAll() ([]models.CihazKaydi, error) {
+	var kayitlar []models.CihazKaydi
+	if err := r.db.Order("tablet_cihaz_kodu ASC").Find(&kayitlar).Error; err != nil {
+		return nil, err
+	}
+	return kayitlar, nil
+}
+
+// UpdateNabiz records a heartbeat
+func (r *cihazKaydiRepository) UpdateNabiz(tabletCihazKodu string, nabiz CihazNabzi) error {
+	result := r.db.Model(&models.CihazKaydi{}).
+		Where("tablet_cihaz_kodu = ?", tabletCihazKodu).
+		Updates(map[string]interface{}{
+			"son_gorulme_zamani": nabiz.Zaman,
+			"ip_adresi":          nabiz.IPAdresi,
+			"uygulama_surumu":    nabiz.UygulamaSurumu,
+			"pil_seviyesi":       nabiz.PilSeviyesi,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Delete removes the enrollment of a tablet
+func (r *cihazKaydiRepository) Delete(tabletCihazKodu string) error {
+	result := r.db.Where("tablet_cihaz_kodu = ?", tabletCihazKodu).Delete(&models.CihazKaydi{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// cihazKayitKoduRepository implements CihazKayitKoduRepository interface
+type cihazKayitKoduRepository struct {
+	db *gorm.DB
+}
+
+// NewCihazKayitKoduRepository creates a new CihazKayitKoduRepository instance
+func NewCihazKayitKoduRepository(db *gorm.DB) CihazKayitKoduRepository {
+	return &cihazKayitKoduRepository{db: db}
+}
+
+// Save stores the enrollment code of a tablet, replacing a code issued before
+func (r *cihazKayitKoduRepository) Save(kod *models.CihazKayitKodu) error {
+	return r.db.Save(kod).Error
+}
+
+// Kullan deletes the code of a tablet in one statement if it has the given digest and has
+// not expired, so a code enrolls at most one device
+func (r *cihazKayitKoduRepository) Kullan(tabletCihazKodu, kodOzeti string, now time.Time) (bool, error) {
+	result := r.db.Where("tablet_cihaz_kodu = ? AND kod_ozeti = ? AND son_kullanma_zamani > ?", tabletCihazKodu, kodOzeti, now).
+		Delete(&models.CihazKayitKodu{})
+	return result.RowsAffected > 0, result.Error
+}
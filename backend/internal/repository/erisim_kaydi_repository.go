@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"medscreen/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// erisimKaydiRepository implements ErisimKaydiRepository on the medscreen schema
+type erisimKaydiRepository struct {
+	db *gorm.DB
+}
+
+// NewErisimKaydiRepository creates a new ErisimKaydiRepository instance
+func NewErisimKaydiRepository(db *gorm.DB) ErisimKaydiRepository {
+	return &erisimKaydiRepository{db: db}
+}
+
+// Create appends the records of a single request in one transaction
+func (r *erisimKaydiRepository) Create(kayitlar []models.ErisimKaydi) error {
+	if len(kayitlar) == 0 {
+		return nil
+	}
+	return r.db.Create(&kayitlar).Error
+}
+
+// FindByFilter retrieves access records, newest first, with pagination
+func (r *erisimKaydiRepository) FindByFilter(filtre ErisimKaydiFiltresi, page, limit int) ([]models.ErisimKaydi, int64, error) {
+	var kayitlar []models.ErisimKaydi
+	var total int64
+
+	query := r.db.Model(&models.ErisimKaydi{})
+	if filtre.HastaKodu != "" {
+		query = query.Where("hasta_kodu = ?", filtre.HastaKodu)
+	}
+	if filtre.HastaBasvuruKodu != "" {
+		query = query.Where("hasta_basvuru_kodu = ?", filtre.HastaBasvuruKodu)
+	}
+	if filtre.PersonelKodu != "" {
+		query = query.Where("personel_kodu = ?", filtre.PersonelKodu)
+	}
+	if filtre.AcilErisimKodu != "" {
+		query = query.Where("acil_erisim_kodu = ?", filtre.AcilErisimKodu)
+	}
+	if filtre.StartDate != nil {
+		query = query.Where("erisim_zamani >= ?", *filtre.StartDate)
+	}
+	if filtre.EndDate != nil {
+		query = query.Where("erisim_zamani < ?", *filtre.EndDate)
+	}
+
+	// Count total records
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	if err := query.Order("erisim_zamani DESC, erisim_kaydi_id DESC").
+		Offset(offset).Limit(limit).Find(&kayitlar).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return kayitlar, total, nil
+}
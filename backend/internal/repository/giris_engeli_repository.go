@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"medscreen/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// girisEngeliRepository implements GirisEngeliRepository interface
+type girisEngeliRepository struct {
+	db *gorm.DB
+}
+
+// NewGirisEngeliRepository creates a new GirisEngeliRepository instance
+func NewGirisEngeliRepository(db *gorm.DB) GirisEngeliRepository {
+	return &girisEngeliRepository{db: db}
+}
+
+// Create adds a login block
+func (r *girisEngeliRepository) Create(engel *models.GirisEngeli) error {
+	return r.db.Create(engel).Error
+}
+
+// IsEngelli checks for a block of the personnel or the NFC card that has not been lifted
+func (r *girisEngeliRepository) IsEngelli(personelKodu, nfcKartKodu string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.GirisEngeli{}).
+		Where("kaldirma_zamani IS NULL AND (personel_kodu = ? OR nfc_kart_kodu = ?)", personelKodu, nfcKartKodu).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Kaldir lifts the blocks of a personnel or an NFC card and returns how many were lifted
+func (r *girisEngeliRepository) Kaldir(personelKodu, nfcKartKodu, kaldiran string, zaman time.Time) (int64, error) {
+	result := r.db.Model(&models.GirisEngeli{}).
+		Where("kaldirma_zamani IS NULL AND (personel_kodu = ? OR nfc_kart_kodu = ?)", personelKodu, nfcKartKodu).
+		Updates(map[string]interface{}{
+			"kaldirma_zamani":        zaman,
+			"kaldiran_personel_kodu": kaldiran,
+		})
+	return result.RowsAffected, result.Error
+}
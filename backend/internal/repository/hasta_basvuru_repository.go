@@ -2,6 +2,7 @@ package repository
 
 import (
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"time"
 
 	"gorm.io/gorm"
@@ -120,3 +121,8 @@ func (r *hastaBasvuruRepository) FindByDateRange(startDate, endDate time.Time, p
 
 	return basvurular, total, nil
 }
+
+// List retrieves patient visits matching a list query with pagination
+func (r *hastaBasvuruRepository) List(q query.Query, page, limit int) ([]models.HastaBasvuru, int64, error) {
+	return list[models.HastaBasvuru](r.db, q, page, limit)
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 
 	"gorm.io/gorm"
 )
@@ -83,3 +84,8 @@ func (r *hastaRepository) SearchByAdSoyadi(ad, soyadi string, page, limit int) (
 
 	return hastalar, total, nil
 }
+
+// List retrieves patients matching a list query with pagination
+func (r *hastaRepository) List(q query.Query, page, limit int) ([]models.Hasta, int64, error) {
+	return list[models.Hasta](r.db, q, page, limit)
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 
 	"gorm.io/gorm"
 )
@@ -65,3 +66,19 @@ func (r *hastaTibbiBilgiRepository) FindByTuru(turuKodu string, page, limit int)
 
 	return bilgiler, total, nil
 }
+
+// FindByHastaKoduAndTuru retrieves all medical information of a patient with the given type
+func (r *hastaTibbiBilgiRepository) FindByHastaKoduAndTuru(hastaKodu, turuKodu string) ([]models.HastaTibbiBilgi, error) {
+	var bilgiler []models.HastaTibbiBilgi
+	if err := r.db.Where("hasta_kodu = ? AND tibbi_bilgi_turu_kodu = ?", hastaKodu, turuKodu).
+		Order("kayit_zamani DESC").
+		Find(&bilgiler).Error; err != nil {
+		return nil, err
+	}
+	return bilgiler, nil
+}
+
+// List retrieves medical information matching a list query with pagination
+func (r *hastaTibbiBilgiRepository) List(q query.Query, page, limit int) ([]models.HastaTibbiBilgi, int64, error) {
+	return list[models.HastaTibbiBilgi](r.db, q, page, limit)
+}
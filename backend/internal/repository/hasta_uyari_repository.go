@@ -2,6 +2,7 @@ package repository
 
 import (
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"medscreen/internal/utils"
 
 	"gorm.io/gorm"
@@ -100,3 +101,30 @@ func (r *hastaUyariRepository) FindByAktiflik(aktiflik int, page, limit int) ([]
 func sanitizeHastaUyari(uyari *models.HastaUyari) {
 	uyari.UyariAciklama = utils.NormalizeUTF8Ptr(uyari.UyariAciklama)
 }
+
+// FindAktifByBasvuruKodu retrieves all active warnings of a visit
+func (r *hastaUyariRepository) FindAktifByBasvuruKodu(basvuruKodu string) ([]models.HastaUyari, error) {
+	var uyarilar []models.HastaUyari
+	if err := r.db.Where("hasta_basvuru_kodu = ? AND aktiflik_bilgisi = ?", basvuruKodu, 1).
+		Order("kayit_zamani DESC").
+		Find(&uyarilar).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range uyarilar {
+		sanitizeHastaUyari(&uyarilar[i])
+	}
+	return uyarilar, nil
+}
+
+// List retrieves patient warnings matching a list query with pagination
+func (r *hastaUyariRepository) List(q query.Query, page, limit int) ([]models.HastaUyari, int64, error) {
+	kayitlar, total, err := list[models.HastaUyari](r.db, q, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range kayitlar {
+		sanitizeHastaUyari(&kayitlar[i])
+	}
+	return kayitlar, total, nil
+}
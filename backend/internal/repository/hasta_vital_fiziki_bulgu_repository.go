@@ -2,6 +2,7 @@ package repository
 
 import (
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"time"
 
 	"gorm.io/gorm"
@@ -76,3 +77,40 @@ func (r *hastaVitalFizikiBulguRepository) FindByDateRange(startDate, endDate tim
 
 	return bulgular, total, nil
 }
+
+// FindSonByBasvuruKodu retrieves the most recent vital signs of a visit
+func (r *hastaVitalFizikiBulguRepository) FindSonByBasvuruKodu(basvuruKodu string) (*models.HastaVitalFizikiBulgu, error) {
+	var bulgu models.HastaVitalFizikiBulgu
+	if err := r.db.Preload("Hemsire").
+		Where("hasta_basvuru_kodu = ?", basvuruKodu).
+		Order("islem_zamani DESC").First(&bulgu).Error; err != nil {
+		return nil, err
+	}
+	return &bulgu, nil
+}
+
+// FindSonByBasvuruKodlari retrieves the most recent limit vital signs of each visit in a
+// single query, grouped by visit and newest first within a visit
+func (r *hastaVitalFizikiBulguRepository) FindSonByBasvuruKodlari(basvuruKodlari []string, limit int) ([]models.HastaVitalFizikiBulgu, error) {
+	if len(basvuruKodlari) == 0 {
+		return nil, nil
+	}
+
+	sirali := r.db.Model(&models.HastaVitalFizikiBulgu{}).
+		Select("*, ROW_NUMBER() OVER (PARTITION BY hasta_basvuru_kodu ORDER BY islem_zamani DESC) AS sira").
+		Where("hasta_basvuru_kodu IN ?", basvuruKodlari)
+
+	var bulgular []models.HastaVitalFizikiBulgu
+	if err := r.db.Table("(?) AS son", sirali).
+		Where("sira <= ?", limit).
+		Order("hasta_basvuru_kodu, islem_zamani DESC").
+		Find(&bulgular).Error; err != nil {
+		return nil, err
+	}
+	return bulgular, nil
+}
+
+// List retrieves vital signs matching a list query with pagination
+func (r *hastaVitalFizikiBulguRepository) List(q query.Query, page, limit int) ([]models.HastaVitalFizikiBulgu, int64, error) {
+	return list[models.HastaVitalFizikiBulgu](r.db, q, page, limit)
+}
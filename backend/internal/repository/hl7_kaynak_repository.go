@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"medscreen/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// hl7KaynakRepository implements HL7KaynakRepository interface
+type hl7KaynakRepository struct {
+	db *gorm.DB
+}
+
+// NewHL7KaynakRepository creates a new HL7KaynakRepository instance
+func NewHL7KaynakRepository(db *gorm.DB) HL7KaynakRepository {
+	return &hl7KaynakRepository{db: db}
+}
+
+// FindAnlikYatanHastalar retrieves the current inpatients with pagination
+func (r *hl7KaynakRepository) FindAnlikYatanHastalar(page, limit int) ([]models.AnlikYatanHasta, int64, error) {
+	var yatanHastalar []models.AnlikYatanHasta
+	var total int64
+
+	// Count total records
+	if err := r.db.Model(&models.AnlikYatanHasta{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	if err := r.db.Preload("Hasta").Preload("Yatak").Preload("HastaBasvuru").
+		Order("anlik_yatan_hasta_kodu").
+		Offset(offset).Limit(limit).Find(&yatanHastalar).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return yatanHastalar, total, nil
+}
+
+// FindCikislarSince polls hasta_basvuru on the cikis_zamani and guncelleme_zamani
+// watermarks; a discharge entered late with an earlier time is caught by its update time
+func (r *hl7KaynakRepository) FindCikislarSince(since time.Time) ([]models.HastaBasvuru, error) {
+	var basvurular []models.HastaBasvuru
+	if err := r.db.Preload("Hasta").
+		Where("cikis_zamani IS NOT NULL").
+		Where("cikis_zamani >= ? OR guncelleme_zamani >= ?", since, since).
+		Find(&basvurular).Error; err != nil {
+		return nil, err
+	}
+	return basvurular, nil
+}
+
+// FindTetkikSonuclariSince polls tetkik_sonuc on the kayit_zamani and onay_zamani watermarks
+func (r *hl7KaynakRepository) FindTetkikSonuclariSince(since time.Time) ([]models.TetkikSonuc, error) {
+	var sonuclar []models.TetkikSonuc
+	if err := r.db.Preload("HastaBasvuru.Hasta").
+		Where("kayit_zamani >= ? OR onay_zamani >= ?", since, since).
+		Find(&sonuclar).Error; err != nil {
+		return nil, err
+	}
+	return sonuclar, nil
+}
+
+// FindVitalBulgularSince polls hasta_vital_fiziki_bulgu on the kayit_zamani and
+// guncelleme_zamani watermarks
+func (r *hl7KaynakRepository) FindVitalBulgularSince(since time.Time) ([]models.HastaVitalFizikiBulgu, error) {
+	var bulgular []models.HastaVitalFizikiBulgu
+	if err := r.db.Preload("HastaBasvuru.Hasta").
+		Where("kayit_zamani >= ? OR guncelleme_zamani >= ?", since, since).
+		Find(&bulgular).Error; err != nil {
+		return nil, err
+	}
+	return bulgular, nil
+}
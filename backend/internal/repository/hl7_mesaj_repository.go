@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"errors"
+	"medscreen/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// hl7MesajRepository implements HL7MesajRepository interface
+type hl7MesajRepository struct {
+	db *gorm.DB
+}
+
+// NewHL7MesajRepository creates a new HL7MesajRepository instance
+func NewHL7MesajRepository(db *gorm.DB) HL7MesajRepository {
+	return &hl7MesajRepository{db: db}
+}
+
+// hl7MesajBatchSize keeps the inserts of a large poll below the parameter limit of Postgres
+const hl7MesajBatchSize = 500
+
+// Create stores new messages in a single transaction, skipping those already stored for
+// their endpoint
+func (r *hl7MesajRepository) Create(mesajlar []models.HL7Mesaj) (int64, error) {
+	if len(mesajlar) == 0 {
+		return 0, nil
+	}
+	var eklenen int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "hedef"}, {Name: "olay_anahtari"}},
+			DoNothing: true,
+		}).CreateInBatches(&mesajlar, hl7MesajBatchSize)
+		eklenen = result.RowsAffected
+		return result.Error
+	})
+	return eklenen, err
+}
+
+// SonOlayZamani retrieves the latest event time stored
+func (r *hl7MesajRepository) SonOlayZamani() (*time.Time, error) {
+	var mesaj models.HL7Mesaj
+	err := r.db.Order("olay_zamani DESC").First(&mesaj).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &mesaj.OlayZamani, nil
+}
+
+// FindSiradaki retrieves the oldest waiting message of an endpoint
+func (r *hl7MesajRepository) FindSiradaki(hedef string) (*models.HL7Mesaj, error) {
+	var mesaj models.HL7Mesaj
+	if err := r.db.Where("hedef = ? AND durum = ?", hedef, models.HL7MesajBekliyor).
+		Order("hl7_mesaj_id").First(&mesaj).Error; err != nil {
+		return nil, err
+	}
+	return &mesaj, nil
+}
+
+// UpdateTeslimat records the outcome of a delivery attempt
+func (r *hl7MesajRepository) UpdateTeslimat(hl7MesajID uint, teslimat HL7Teslimat) error {
+	return r.db.Model(&models.HL7Mesaj{}).Where("hl7_mesaj_id = ?", hl7MesajID).
+		Updates(map[string]interface{}{
+			"durum":          teslimat.Durum,
+			"deneme_sayisi":  teslimat.DenemeSayisi,
+			"sonraki_deneme": teslimat.SonrakiDeneme,
+			"son_hata":       teslimat.SonHata,
+			"sonuc_zamani":   teslimat.SonucZamani,
+		}).Error
+}
+
+// FindByDurum retrieves the messages in a state, oldest first, with pagination
+func (r *hl7MesajRepository) FindByDurum(hedef string, durum models.HL7MesajDurumu, page, limit int) ([]models.HL7Mesaj, int64, error) {
+	var mesajlar []models.HL7Mesaj
+	var total int64
+
+	query := r.db.Model(&models.HL7Mesaj{}).Where("durum = ?", durum)
+	if hedef != "" {
+		query = query.Where("hedef = ?", hedef)
+	}
+
+	// Count total records
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	if err := query.Order("hl7_mesaj_id").Offset(offset).Limit(limit).Find(&mesajlar).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return mesajlar, total, nil
+}
+
+// YenidenKuyrukla puts a dead letter back in the queue with a fresh attempt count
+func (r *hl7MesajRepository) YenidenKuyrukla(hl7MesajID uint, zaman time.Time) error {
+	result := r.db.Model(&models.HL7Mesaj{}).
+		Where("hl7_mesaj_id = ? AND durum = ?", hl7MesajID, models.HL7MesajOlu).
+		Updates(map[string]interface{}{
+			"durum":          models.HL7MesajBekliyor,
+			"deneme_sayisi":  0,
+			"sonraki_deneme": zaman,
+			"sonuc_zamani":   nil,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"medscreen/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ikinciFaktorRepository implements IkinciFaktorRepository interface
+type ikinciFaktorRepository struct {
+	db *gorm.DB
+}
+
+// NewIkinciFaktorRepository creates a new IkinciFaktorRepository instance
+func NewIkinciFaktorRepository(db *gorm.DB) IkinciFaktorRepository {
+	return &ikinciFaktorRepository{db: db}
+}
+
+// FindByPersonelKodu retrieves the second factor of a personnel
+func (r *ikinciFaktorRepository) FindByPersonelKodu(personelKodu string) (*models.IkinciFaktor, error) {
+	var faktor models.IkinciFaktor
+	if err := r.db.Where("personel_kodu = ?", personelKodu).First(&faktor).Error; err != nil {
+		return nil, err
+	}
+	return &faktor, nil
+}
+
+// Save creates the record of a personnel or replaces it
+func (r *ikinciFaktorRepository) Save(faktor *models.IkinciFaktor) error {
+	return r.db.Save(faktor).Error
+}
+
+// Basarisiz counts a failed step-up in one conditional UPDATE, so concurrent attempts are all
+// counted and an admin changing the PIN at the same time is not overwritten
+func (r *ikinciFaktorRepository) Basarisiz(personelKodu string, now time.Time, esik int, kilitBitis time.Time) (*time.Time, error) {
+	var faktor models.IkinciFaktor
+	result := r.db.Model(&faktor).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "kilit_bitis_zamani"}}}).
+		Where("personel_kodu = ? AND (kilit_bitis_zamani IS NULL OR kilit_bitis_zamani <= ?)", personelKodu, now).
+		Updates(map[string]interface{}{
+			"basarisiz_deneme":   gorm.Expr("CASE WHEN basarisiz_deneme + 1 >= ? THEN 0 ELSE basarisiz_deneme + 1 END", esik),
+			"kilit_bitis_zamani": gorm.Expr("CASE WHEN basarisiz_deneme + 1 >= ? THEN ? ELSE kilit_bitis_zamani END", esik, kilitBitis),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return faktor.KilitBitisZamani, nil
+}
+
+// Basarili clears the failed step-ups and, for a TOTP code, claims its step in one
+// conditional UPDATE, so the same code cannot be accepted by two concurrent requests
+func (r *ikinciFaktorRepository) Basarili(personelKodu string, now time.Time, adim int64) (bool, error) {
+	query := r.db.Model(&models.IkinciFaktor{}).
+		Where("personel_kodu = ? AND (kilit_bitis_zamani IS NULL OR kilit_bitis_zamani <= ?)", personelKodu, now)
+	alanlar := map[string]interface{}{"basarisiz_deneme": 0, "kilit_bitis_zamani": nil}
+	if adim > 0 {
+		query = query.Where("son_totp_adimi < ?", adim)
+		alanlar["son_totp_adimi"] = adim
+	}
+	result := query.Updates(alanlar)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Delete removes the second factor of a personnel
+func (r *ikinciFaktorRepository) Delete(personelKodu string) error {
+	result := r.db.Where("personel_kodu = ?", personelKodu).Delete(&models.IkinciFaktor{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
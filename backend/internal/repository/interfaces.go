@@ -2,6 +2,7 @@ package repository
 
 import (
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"time"
 )
 
@@ -10,6 +11,7 @@ type PersonelRepository interface {
 	FindByKodu(kodu string) (*models.Personel, error)
 	FindAll(page, limit int) ([]models.Personel, int64, error)
 	FindByGorevKodu(gorevKodu string, page, limit int) ([]models.Personel, int64, error)
+	List(q query.Query, page, limit int) ([]models.Personel, int64, error)
 }
 
 // NFCKartRepository defines the read-only interface for NFC card data access
@@ -18,6 +20,7 @@ type NFCKartRepository interface {
 	FindByKartUID(kartUID string) (*models.NFCKart, error)
 	FindByPersonelKodu(personelKodu string, page, limit int) ([]models.NFCKart, int64, error)
 	FindAll(page, limit int) ([]models.NFCKart, int64, error)
+	List(q query.Query, page, limit int) ([]models.NFCKart, int64, error)
 }
 
 // HastaRepository defines the read-only interface for patient data access
@@ -26,6 +29,7 @@ type HastaRepository interface {
 	FindByTCKimlik(tcKimlik string) (*models.Hasta, error)
 	FindAll(page, limit int) ([]models.Hasta, int64, error)
 	SearchByAdSoyadi(ad, soyadi string, page, limit int) ([]models.Hasta, int64, error)
+	List(q query.Query, page, limit int) ([]models.Hasta, int64, error)
 }
 
 // HastaBasvuruRepository defines the read-only interface for patient visit/admission data access
@@ -35,6 +39,7 @@ type HastaBasvuruRepository interface {
 	FindByHekimKodu(hekimKodu string, page, limit int) ([]models.HastaBasvuru, int64, error)
 	FindByDurum(durum string, page, limit int) ([]models.HastaBasvuru, int64, error)
 	FindByDateRange(startDate, endDate time.Time, page, limit int) ([]models.HastaBasvuru, int64, error)
+	List(q query.Query, page, limit int) ([]models.HastaBasvuru, int64, error)
 }
 
 // YatakRepository defines the read-only interface for bed data access
@@ -42,6 +47,7 @@ type YatakRepository interface {
 	FindByKodu(kodu string) (*models.Yatak, error)
 	FindByBirimAndOda(birimKodu, odaKodu string, page, limit int) ([]models.Yatak, int64, error)
 	FindAll(page, limit int) ([]models.Yatak, int64, error)
+	List(q query.Query, page, limit int) ([]models.Yatak, int64, error)
 }
 
 // TabletCihazRepository defines the read-only interface for tablet device data access
@@ -49,6 +55,8 @@ type TabletCihazRepository interface {
 	FindByKodu(kodu string) (*models.TabletCihaz, error)
 	FindByYatakKodu(yatakKodu string, page, limit int) ([]models.TabletCihaz, int64, error)
 	FindAll(page, limit int) ([]models.TabletCihaz, int64, error)
+	FindBySeriNumarasi(seriNumarasi string) (*models.TabletCihaz, error)
+	List(q query.Query, page, limit int) ([]models.TabletCihaz, int64, error)
 }
 
 // AnlikYatanHastaRepository defines the read-only interface for current inpatient data access
@@ -57,6 +65,9 @@ type AnlikYatanHastaRepository interface {
 	FindByYatakKodu(yatakKodu string, page, limit int) ([]models.AnlikYatanHasta, int64, error)
 	FindByHastaKodu(hastaKodu string, page, limit int) ([]models.AnlikYatanHasta, int64, error)
 	FindByBirimKodu(birimKodu string, page, limit int) ([]models.AnlikYatanHasta, int64, error)
+	FindByBasvuruKodu(basvuruKodu string) (*models.AnlikYatanHasta, error)
+	FindByBasvuruKodlari(basvuruKodlari []string) ([]models.AnlikYatanHasta, error)
+	List(q query.Query, page, limit int) ([]models.AnlikYatanHasta, int64, error)
 }
 
 // HastaVitalFizikiBulguRepository defines the read-only interface for vital signs data access
@@ -64,6 +75,9 @@ type HastaVitalFizikiBulguRepository interface {
 	FindByKodu(kodu string) (*models.HastaVitalFizikiBulgu, error)
 	FindByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.HastaVitalFizikiBulgu, int64, error)
 	FindByDateRange(startDate, endDate time.Time, page, limit int) ([]models.HastaVitalFizikiBulgu, int64, error)
+	FindSonByBasvuruKodu(basvuruKodu string) (*models.HastaVitalFizikiBulgu, error)
+	FindSonByBasvuruKodlari(basvuruKodlari []string, limit int) ([]models.HastaVitalFizikiBulgu, error)
+	List(q query.Query, page, limit int) ([]models.HastaVitalFizikiBulgu, int64, error)
 }
 
 // KlinikSeyirRepository defines the read-only interface for clinical progress notes data access
@@ -74,6 +88,7 @@ type KlinikSeyirRepository interface {
 	FindBySepsisDurumu(sepsisDurumu int, page, limit int) ([]models.KlinikSeyir, int64, error)
 	FindBySeyirTipiAndSepsisDurumu(seyirTipi string, sepsisDurumu int, page, limit int) ([]models.KlinikSeyir, int64, error)
 	FindByDateRange(startDate, endDate time.Time, page, limit int) ([]models.KlinikSeyir, int64, error)
+	List(q query.Query, page, limit int) ([]models.KlinikSeyir, int64, error)
 }
 
 // TibbiOrderRepository defines the read-only interface for medical orders data access
@@ -81,12 +96,21 @@ type TibbiOrderRepository interface {
 	FindByKodu(kodu string) (*models.TibbiOrder, error)
 	FindByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.TibbiOrder, int64, error)
 	FindDetayByOrderKodu(orderKodu string, page, limit int) ([]models.TibbiOrderDetay, int64, error)
+	FindAcikByBasvuruKodu(basvuruKodu string) ([]models.TibbiOrder, error)
+	FindDetayByBasvuruKodlari(basvuruKodlari []string, startDate, endDate time.Time) ([]models.TibbiOrderDetay, error)
+	List(q query.Query, page, limit int) ([]models.TibbiOrder, int64, error)
+	ListDetay(q query.Query, page, limit int) ([]models.TibbiOrderDetay, int64, error)
 }
 
 // TetkikSonucRepository defines the read-only interface for test results data access
 type TetkikSonucRepository interface {
 	FindByKodu(kodu string) (*models.TetkikSonuc, error)
 	FindByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.TetkikSonuc, int64, error)
+	// FindByYatanHastalar retrieves a page of the results of currently admitted patients that
+	// have both a value and a critical value range and were recorded at or after since, newest
+	// first. An empty birimKodu matches every unit.
+	FindByYatanHastalar(birimKodu string, since time.Time, page, limit int) ([]models.TetkikSonuc, error)
+	List(q query.Query, page, limit int) ([]models.TetkikSonuc, int64, error)
 }
 
 // ReceteRepository defines the read-only interface for prescription data access
@@ -95,6 +119,9 @@ type ReceteRepository interface {
 	FindByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.Recete, int64, error)
 	FindByHekimKodu(hekimKodu string, page, limit int) ([]models.Recete, int64, error)
 	FindIlacByReceteKodu(receteKodu string, page, limit int) ([]models.ReceteIlac, int64, error)
+	FindAktifByBasvuruKodu(basvuruKodu string) ([]models.Recete, error)
+	List(q query.Query, page, limit int) ([]models.Recete, int64, error)
+	ListIlac(q query.Query, page, limit int) ([]models.ReceteIlac, int64, error)
 }
 
 // BasvuruTaniRepository defines the read-only interface for diagnosis data access
@@ -103,6 +130,8 @@ type BasvuruTaniRepository interface {
 	FindByHastaKodu(hastaKodu string, page, limit int) ([]models.BasvuruTani, int64, error)
 	FindByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.BasvuruTani, int64, error)
 	FindByTaniKodu(taniKodu string, page, limit int) ([]models.BasvuruTani, int64, error)
+	FindBirincilByBasvuruKodu(basvuruKodu string) (*models.BasvuruTani, error)
+	List(q query.Query, page, limit int) ([]models.BasvuruTani, int64, error)
 }
 
 // HastaTibbiBilgiRepository defines the read-only interface for patient medical information data access
@@ -110,6 +139,8 @@ type HastaTibbiBilgiRepository interface {
 	FindByKodu(kodu string) (*models.HastaTibbiBilgi, error)
 	FindByHastaKodu(hastaKodu string, page, limit int) ([]models.HastaTibbiBilgi, int64, error)
 	FindByTuru(turuKodu string, page, limit int) ([]models.HastaTibbiBilgi, int64, error)
+	FindByHastaKoduAndTuru(hastaKodu, turuKodu string) ([]models.HastaTibbiBilgi, error)
+	List(q query.Query, page, limit int) ([]models.HastaTibbiBilgi, int64, error)
 }
 
 // HastaUyariRepository defines the read-only interface for patient warnings data access
@@ -118,6 +149,8 @@ type HastaUyariRepository interface {
 	FindByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.HastaUyari, int64, error)
 	FindByTuru(uyariTuru string, page, limit int) ([]models.HastaUyari, int64, error)
 	FindByAktiflik(aktiflik int, page, limit int) ([]models.HastaUyari, int64, error)
+	FindAktifByBasvuruKodu(basvuruKodu string) ([]models.HastaUyari, error)
+	List(q query.Query, page, limit int) ([]models.HastaUyari, int64, error)
 }
 
 // RiskSkorlamaRepository defines the read-only interface for risk scoring data access
@@ -125,6 +158,8 @@ type RiskSkorlamaRepository interface {
 	FindByKodu(kodu string) (*models.RiskSkorlama, error)
 	FindByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.RiskSkorlama, int64, error)
 	FindByTuru(turu string, page, limit int) ([]models.RiskSkorlama, int64, error)
+	FindSonByBasvuruKodu(basvuruKodu string) ([]models.RiskSkorlama, error)
+	List(q query.Query, page, limit int) ([]models.RiskSkorlama, int64, error)
 }
 
 // BasvuruYemekRepository defines the read-only interface for diet/meal data access
@@ -132,6 +167,8 @@ type BasvuruYemekRepository interface {
 	FindByKodu(kodu string) (*models.BasvuruYemek, error)
 	FindByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.BasvuruYemek, int64, error)
 	FindByTuru(yemekTuru string, page, limit int) ([]models.BasvuruYemek, int64, error)
+	FindByBasvuruKoduAndDateRange(basvuruKodu string, startDate, endDate time.Time) ([]models.BasvuruYemek, error)
+	List(q query.Query, page, limit int) ([]models.BasvuruYemek, int64, error)
 }
 
 // RandevuRepository defines the read-only interface for appointment data access
@@ -142,4 +179,26 @@ type RandevuRepository interface {
 	FindByHekimKodu(hekimKodu string, page, limit int) ([]models.Randevu, int64, error)
 	FindByTuru(randevuTuru string, page, limit int) ([]models.Randevu, int64, error)
 	FindByDateRange(startDate, endDate time.Time, page, limit int) ([]models.Randevu, int64, error)
+	List(q query.Query, page, limit int) ([]models.Randevu, int64, error)
+}
+
+// KlinikOlayRepository defines the read-only interface for change detection on the clinical
+// tables written by VEM (vital signs, order executions, warnings, test results, progress notes)
+type KlinikOlayRepository interface {
+	// FindSince returns the rows of the given visits created or updated at or after since, oldest first
+	FindSince(basvuruKodlari []string, since time.Time) ([]KlinikOlay, error)
+}
+
+// HL7KaynakRepository defines the read-only interface for change detection on the tables
+// the HL7 v2 outbound feed is generated from, hospital-wide
+type HL7KaynakRepository interface {
+	// FindAnlikYatanHastalar lists the current inpatients with their patient and bed
+	FindAnlikYatanHastalar(page, limit int) ([]models.AnlikYatanHasta, int64, error)
+	// FindCikislarSince returns the discharged visits whose discharge time is at or after
+	// since, or that were updated at or after since
+	FindCikislarSince(since time.Time) ([]models.HastaBasvuru, error)
+	// FindTetkikSonuclariSince returns the test results created or approved at or after since
+	FindTetkikSonuclariSince(since time.Time) ([]models.TetkikSonuc, error)
+	// FindVitalBulgularSince returns the vital signs created or updated at or after since
+	FindVitalBulgularSince(since time.Time) ([]models.HastaVitalFizikiBulgu, error)
 }
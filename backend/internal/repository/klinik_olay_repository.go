@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"medscreen/internal/models"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// KlinikOlayTuru identifies the table a clinical event comes from
+type KlinikOlayTuru string
+
+// Clinical event types, one per watched table
+const (
+	OlayVitalBulgu      KlinikOlayTuru = "vital_bulgu"
+	OlayTibbiOrderDetay KlinikOlayTuru = "tibbi_order_detay"
+	OlayHastaUyari      KlinikOlayTuru = "hasta_uyari"
+	OlayTetkikSonuc     KlinikOlayTuru = "tetkik_sonuc"
+	OlayKlinikSeyir     KlinikOlayTuru = "klinik_seyir"
+)
+
+// KlinikOlay is a row of a clinical table that was created or updated
+type KlinikOlay struct {
+	Tur              KlinikOlayTuru `json:"tur"`
+	Kodu             string         `json:"kodu"`
+	HastaBasvuruKodu string         `json:"hasta_basvuru_kodu"`
+	// Zaman is the row's watermark: the latest of its creation and update times
+	Zaman time.Time `json:"zaman"`
+	// Guncelleme is true when the row existed before and was updated
+	Guncelleme bool        `json:"guncelleme"`
+	Kayit      interface{} `json:"kayit"`
+}
+
+// klinikOlayRepository implements KlinikOlayRepository interface
+type klinikOlayRepository struct {
+	db *gorm.DB
+}
+
+// NewKlinikOlayRepository creates a new KlinikOlayRepository instance
+func NewKlinikOlayRepository(db *gorm.DB) KlinikOlayRepository {
+	return &klinikOlayRepository{db: db}
+}
+
+// FindSince polls the watched tables on their kayit_zamani/guncelleme_zamani watermarks.
+// Order executions have no update time; setting uygulama_zamani counts as their update.
+func (r *klinikOlayRepository) FindSince(basvuruKodlari []string, since time.Time) ([]KlinikOlay, error) {
+	var olaylar []KlinikOlay
+	if len(basvuruKodlari) == 0 {
+		return olaylar, nil
+	}
+
+	var bulgular []models.HastaVitalFizikiBulgu
+	if err := r.db.Where("hasta_basvuru_kodu IN ?", basvuruKodlari).
+		Where("kayit_zamani >= ? OR guncelleme_zamani >= ?", since, since).
+		Find(&bulgular).Error; err != nil {
+		return nil, err
+	}
+	for i := range bulgular {
+		b := &bulgular[i]
+		olaylar = append(olaylar, yeniOlay(OlayVitalBulgu, b.HastaVitalFizikiBulguKodu, b.HastaBasvuruKodu, b.KayitZamani, b.GuncellemeZamani, b))
+	}
+
+	var detaylar []models.TibbiOrderDetay
+	if err := r.db.Preload("TibbiOrder").
+		Joins("JOIN tibbi_order ON tibbi_order.tibbi_order_kodu = tibbi_order_detay.tibbi_order_kodu").
+		Where("tibbi_order.hasta_basvuru_kodu IN ?", basvuruKodlari).
+		Where("tibbi_order_detay.kayit_zamani >= ? OR tibbi_order_detay.uygulama_zamani >= ?", since, since).
+		Find(&detaylar).Error; err != nil {
+		return nil, err
+	}
+	for i := range detaylar {
+		d := &detaylar[i]
+		basvuruKodu := ""
+		if d.TibbiOrder != nil {
+			basvuruKodu = d.TibbiOrder.HastaBasvuruKodu
+		}
+		olaylar = append(olaylar, yeniOlay(OlayTibbiOrderDetay, d.TibbiOrderDetayKodu, basvuruKodu, d.KayitZamani, d.UygulamaZamani, d))
+	}
+
+	var uyarilar []models.HastaUyari
+	if err := r.db.Where("hasta_basvuru_kodu IN ?", basvuruKodlari).
+		Where("kayit_zamani >= ? OR guncelleme_zamani >= ?", since, since).
+		Find(&uyarilar).Error; err != nil {
+		return nil, err
+	}
+	for i := range uyarilar {
+		u := &uyarilar[i]
+		olaylar = append(olaylar, yeniOlay(OlayHastaUyari, u.HastaUyariKodu, u.HastaBasvuruKodu, u.KayitZamani, u.GuncellemeZamani, u))
+	}
+
+	var sonuclar []models.TetkikSonuc
+	if err := r.db.Where("hasta_basvuru_kodu IN ?", basvuruKodlari).
+		Where("kayit_zamani >= ? OR onay_zamani >= ?", since, since).
+		Find(&sonuclar).Error; err != nil {
+		return nil, err
+	}
+	for i := range sonuclar {
+		t := &sonuclar[i]
+		olaylar = append(olaylar, yeniOlay(OlayTetkikSonuc, t.TetkikSonucKodu, t.HastaBasvuruKodu, t.KayitZamani, t.OnayZamani, t))
+	}
+
+	var seyirler []models.KlinikSeyir
+	if err := r.db.Where("hasta_basvuru_kodu IN ?", basvuruKodlari).
+		Where("kayit_zamani >= ? OR guncelleme_zamani >= ?", since, since).
+		Find(&seyirler).Error; err != nil {
+		return nil, err
+	}
+	for i := range seyirler {
+		k := &seyirler[i]
+		olaylar = append(olaylar, yeniOlay(OlayKlinikSeyir, k.KlinikSeyirKodu, k.HastaBasvuruKodu, k.KayitZamani, k.GuncellemeZamani, k))
+	}
+
+	SortKlinikOlaylar(olaylar)
+	return olaylar, nil
+}
+
+// SortKlinikOlaylar orders events by watermark, then by type and code for a stable order
+func SortKlinikOlaylar(olaylar []KlinikOlay) {
+	sort.SliceStable(olaylar, func(i, j int) bool {
+		a, b := olaylar[i], olaylar[j]
+		if !a.Zaman.Equal(b.Zaman) {
+			return a.Zaman.Before(b.Zaman)
+		}
+		if a.Tur != b.Tur {
+			return a.Tur < b.Tur
+		}
+		return a.Kodu < b.Kodu
+	})
+}
+
+func yeniOlay(tur KlinikOlayTuru, kodu, basvuruKodu string, kayitZamani time.Time, guncellemeZamani *time.Time, kayit interface{}) KlinikOlay {
+	olay := KlinikOlay{Tur: tur, Kodu: kodu, HastaBasvuruKodu: basvuruKodu, Zaman: kayitZamani, Kayit: kayit}
+	if guncellemeZamani != nil && guncellemeZamani.After(kayitZamani) {
+		olay.Zaman = *guncellemeZamani
+		olay.Guncelleme = true
+	}
+	return olay
+}
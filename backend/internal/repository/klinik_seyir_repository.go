@@ -2,6 +2,7 @@ package repository
 
 import (
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"medscreen/internal/utils"
 	"time"
 
@@ -151,3 +152,15 @@ func (r *klinikSeyirRepository) FindByDateRange(startDate, endDate time.Time, pa
 func sanitizeKlinikSeyir(seyir *models.KlinikSeyir) {
 	seyir.SeyirBilgisi = utils.NormalizeUTF8(seyir.SeyirBilgisi)
 }
+
+// List retrieves clinical notes matching a list query with pagination
+func (r *klinikSeyirRepository) List(q query.Query, page, limit int) ([]models.KlinikSeyir, int64, error) {
+	kayitlar, total, err := list[models.KlinikSeyir](r.db, q, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range kayitlar {
+		sanitizeKlinikSeyir(&kayitlar[i])
+	}
+	return kayitlar, total, nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"medscreen/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// kritikSonucOnayRepository implements KritikSonucOnayRepository interface
+type kritikSonucOnayRepository struct {
+	db *gorm.DB
+}
+
+// NewKritikSonucOnayRepository creates a new KritikSonucOnayRepository instance
+func NewKritikSonucOnayRepository(db *gorm.DB) KritikSonucOnayRepository {
+	return &kritikSonucOnayRepository{db: db}
+}
+
+// Create records an acknowledgement unless the test result already has one
+func (r *kritikSonucOnayRepository) Create(onay *models.KritikSonucOnay) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tetkik_sonuc_kodu"}},
+		DoNothing: true,
+	}).Create(onay)
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindByTetkikSonucKodu retrieves the acknowledgement of a test result
+func (r *kritikSonucOnayRepository) FindByTetkikSonucKodu(tetkikSonucKodu string) (*models.KritikSonucOnay, error) {
+	var onay models.KritikSonucOnay
+	if err := r.db.Where("tetkik_sonuc_kodu = ?", tetkikSonucKodu).First(&onay).Error; err != nil {
+		return nil, err
+	}
+	return &onay, nil
+}
+
+// FindByTetkikSonucKodlari retrieves the acknowledgements of the given test results
+func (r *kritikSonucOnayRepository) FindByTetkikSonucKodlari(tetkikSonucKodlari []string) ([]models.KritikSonucOnay, error) {
+	var onaylar []models.KritikSonucOnay
+	if len(tetkikSonucKodlari) == 0 {
+		return onaylar, nil
+	}
+	if err := r.db.Where("tetkik_sonuc_kodu IN ?", tetkikSonucKodlari).Find(&onaylar).Error; err != nil {
+		return nil, err
+	}
+	return onaylar, nil
+}
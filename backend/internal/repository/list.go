@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"medscreen/internal/query"
+
+	"gorm.io/gorm"
+)
+
+// list runs a list query on the table of T with pagination, returning the page and the
+// total number of matching records. Cursor queries ignore page and are only counted when
+// the client asked for a total (see query.Query.Counted); otherwise total is 0.
+func list[T any](db *gorm.DB, q query.Query, page, limit int) ([]T, int64, error) {
+	var records []T
+	var total int64
+
+	// Count total records
+	if q.Counted() {
+		if err := q.Scope(db.Model(new(T))).Count(&total).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+
+	// Fetch paginated results
+	stmt := q.Apply(db).Limit(limit)
+	if !q.Cursor() {
+		stmt = stmt.Offset((page - 1) * limit)
+	}
+	if err := stmt.Find(&records).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return records, total, nil
+}
@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"medscreen/internal/models"
+	"time"
+)
+
+// The interfaces in this file cover tables owned by MedScreen itself (medscreen schema).
+// Unlike the VEM 2.0 repositories they may write, since the data does not belong to VEM.
+
+// TokenIptalRepository defines the interface for the token revocation list
+type TokenIptalRepository interface {
+	Create(iptal *models.TokenIptal) error
+	// IsRevoked reports whether the token with the given jti, or every token of the
+	// personnel or NFC card issued at or before issuedAt, has been revoked
+	IsRevoked(jti, personelKodu, nfcKartKodu string, issuedAt time.Time) (bool, error)
+	// DeleteExpired removes entries whose tokens have all expired before the given time
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+// ErisimKaydiFiltresi selects access records; empty fields are not filtered on
+type ErisimKaydiFiltresi struct {
+	HastaKodu        string
+	HastaBasvuruKodu string
+	PersonelKodu     string
+	AcilErisimKodu   string
+	StartDate        *time.Time
+	EndDate          *time.Time
+}
+
+// Matches reports whether a record satisfies the filter.
+// It mirrors the database query for sinks that filter in memory.
+func (f ErisimKaydiFiltresi) Matches(kayit *models.ErisimKaydi) bool {
+	if f.HastaKodu != "" && (kayit.HastaKodu == nil || *kayit.HastaKodu != f.HastaKodu) {
+		return false
+	}
+	if f.HastaBasvuruKodu != "" && (kayit.HastaBasvuruKodu == nil || *kayit.HastaBasvuruKodu != f.HastaBasvuruKodu) {
+		return false
+	}
+	if f.PersonelKodu != "" && kayit.PersonelKodu != f.PersonelKodu {
+		return false
+	}
+	if f.AcilErisimKodu != "" && (kayit.AcilErisimKodu == nil || *kayit.AcilErisimKodu != f.AcilErisimKodu) {
+		return false
+	}
+	if f.StartDate != nil && kayit.ErisimZamani.Before(*f.StartDate) {
+		return false
+	}
+	if f.EndDate != nil && !kayit.ErisimZamani.Before(*f.EndDate) {
+		return false
+	}
+	return true
+}
+
+// ErisimKaydiRepository defines the interface for the patient data access audit trail.
+// Records are append-only: there is no update or delete.
+type ErisimKaydiRepository interface {
+	Create(kayitlar []models.ErisimKaydi) error
+	FindByFilter(filtre ErisimKaydiFiltresi, page, limit int) ([]models.ErisimKaydi, int64, error)
+}
+
+// KritikSonucOnayRepository defines the interface for acknowledgements of critical test results
+type KritikSonucOnayRepository interface {
+	// Create stores an acknowledgement and reports false, storing nothing, when the test
+	// result was acknowledged already
+	Create(onay *models.KritikSonucOnay) (bool, error)
+	FindByTetkikSonucKodu(tetkikSonucKodu string) (*models.KritikSonucOnay, error)
+	FindByTetkikSonucKodlari(tetkikSonucKodlari []string) ([]models.KritikSonucOnay, error)
+}
+
+// CihazNabzi is the state a tablet reports with a heartbeat
+type CihazNabzi struct {
+	Zaman          time.Time
+	IPAdresi       string
+	UygulamaSurumu *string
+	PilSeviyesi    *int
+}
+
+// CihazKaydiRepository defines the interface for tablet enrollments and heartbeats
+type CihazKaydiRepository interface {
+	// Save creates the enrollment of a tablet or replaces it; the previous credential stops working
+	Save(kayit *models.CihazKaydi) error
+	FindByTabletCihazKodu(tabletCihazKodu string) (*models.CihazKaydi, error)
+	FindAll() ([]models.CihazKaydi, error)
+	UpdateNabiz(tabletCihazKodu string, nabiz CihazNabzi) error
+	// Delete removes an enrollment; its credential stops working
+	Delete(tabletCihazKodu string) error
+}
+
+// CihazKayitKoduRepository defines the interface for the one-time enrollment codes of tablets
+type CihazKayitKoduRepository interface {
+	// Save stores the code of a tablet, replacing a code issued before
+	Save(kod *models.CihazKayitKodu) error
+	// Kullan deletes the code of a tablet if it has the given digest and expires after now.
+	// ok is false when there was no such code.
+	Kullan(tabletCihazKodu, kodOzeti string, now time.Time) (ok bool, err error)
+}
+
+// IkinciFaktorRepository defines the interface for the second authentication factors of personnel
+type IkinciFaktorRepository interface {
+	FindByPersonelKodu(personelKodu string) (*models.IkinciFaktor, error)
+	// Save creates the record of a personnel or replaces it
+	Save(faktor *models.IkinciFaktor) error
+	// Basarisiz counts a failed step-up unless the record is locked at now; the esik-th
+	// failure in a row locks it until kilitBitis and restarts the count. It returns the end
+	// of the lock, nil if unlocked, and gorm.ErrRecordNotFound if the record is locked or gone.
+	Basarisiz(personelKodu string, now time.Time, esik int, kilitBitis time.Time) (*time.Time, error)
+	// Basarili clears the failed step-ups unless the record is locked at now. A TOTP step
+	// (adim > 0) is stored only if it is later than the last accepted one. ok is false when
+	// nothing was updated.
+	Basarili(personelKodu string, now time.Time, adim int64) (ok bool, err error)
+	Delete(personelKodu string) error
+}
+
+// APIAnahtariRepository defines the interface for the API keys of integrations
+type APIAnahtariRepository interface {
+	Create(anahtar *models.APIAnahtari) error
+	FindByKodu(anahtarKodu string) (*models.APIAnahtari, error)
+	FindAll() ([]models.APIAnahtari, error)
+	UpdateKullanim(anahtarKodu string, zaman time.Time, ipAdresi string) error
+	// Iptal revokes a key; it returns gorm.ErrRecordNotFound if the key does not exist
+	// or has already been revoked
+	Iptal(anahtarKodu, iptalEden string, zaman time.Time) error
+}
+
+// OturumFiltresi selects sessions; empty fields are not filtered on
+type OturumFiltresi struct {
+	OturumKodu      string
+	PersonelKodu    string
+	NFCKartKodu     string
+	TabletCihazKodu string
+	BirimKodu       string
+}
+
+// OturumYenileme is the state of a session after its tokens were refreshed
+type OturumYenileme struct {
+	Zaman               time.Time
+	SonGecerlilikZamani time.Time
+	BirimKodu           *string
+	YatakKodu           *string
+}
+
+// OturumSonu records when, by whom and why sessions were ended
+type OturumSonu struct {
+	Zaman                   time.Time
+	SonlandiranPersonelKodu string
+	Sebep                   string
+}
+
+// OturumRepository defines the interface for login sessions
+type OturumRepository interface {
+	Create(oturum *models.Oturum) error
+	FindByKodu(oturumKodu string) (*models.Oturum, error)
+	// FindAktif lists the sessions that have not ended, have not expired at now and were
+	// active since enErkenEtkinlik, most recently active first
+	FindAktif(filtre OturumFiltresi, now, enErkenEtkinlik time.Time, page, limit int) ([]models.Oturum, int64, error)
+	UpdateEtkinlik(oturumKodu string, zaman time.Time) error
+	Yenile(oturumKodu string, yenileme OturumYenileme) error
+	// Sonlandir ends the sessions matching a non-empty filter that have not ended yet and
+	// returns how many were ended
+	Sonlandir(filtre OturumFiltresi, son OturumSonu) (int64, error)
+}
+
+// GirisEngeliRepository defines the interface for the blocks that keep a revoked personnel
+// or NFC card from logging in again
+type GirisEngeliRepository interface {
+	Create(engel *models.GirisEngeli) error
+	// IsEngelli reports whether the personnel or the NFC card has a block that was not lifted
+	IsEngelli(personelKodu, nfcKartKodu string) (bool, error)
+	// Kaldir lifts the blocks of the personnel or the NFC card; an empty code matches nothing
+	Kaldir(personelKodu, nfcKartKodu, kaldiran string, zaman time.Time) (int64, error)
+}
+
+// YatakKisitiKaldirmaRepository defines the interface for the log of bed binding escalations
+type YatakKisitiKaldirmaRepository interface {
+	Create(kaldirma *models.YatakKisitiKaldirma) error
+}
+
+// AcilErisimIncelemesi is a supervisor's sign-off of a break-the-glass grant
+type AcilErisimIncelemesi struct {
+	InceleyenPersonelKodu string
+	Not                   *string
+	Zaman                 time.Time
+}
+
+// AcilErisimRepository defines the interface for break-the-glass grants and their review queue
+type AcilErisimRepository interface {
+	Create(erisim *models.AcilErisim) error
+	FindByKodu(acilErisimKodu string) (*models.AcilErisim, error)
+	// FindByInceleme lists grants, newest first; incelendi nil lists both reviewed and pending grants
+	FindByInceleme(incelendi *bool, page, limit int) ([]models.AcilErisim, int64, error)
+	// Incele signs off a pending grant; it returns gorm.ErrRecordNotFound if the grant
+	// does not exist or has already been reviewed
+	Incele(acilErisimKodu string, inceleme AcilErisimIncelemesi) error
+}
+
+// HL7Teslimat is the outcome of a delivery attempt of an HL7 message
+type HL7Teslimat struct {
+	Durum         models.HL7MesajDurumu
+	DenemeSayisi  int
+	SonrakiDeneme time.Time
+	SonHata       *string
+	SonucZamani   *time.Time
+}
+
+// HL7MesajRepository defines the interface for the outbox and dead-letter store of the
+// HL7 v2 outbound feed
+type HL7MesajRepository interface {
+	// Create stores new messages; a message whose Hedef and OlayAnahtari are already
+	// stored is skipped. It returns how many were stored.
+	Create(mesajlar []models.HL7Mesaj) (int64, error)
+	// SonOlayZamani returns the latest OlayZamani stored, or nil if there is no message
+	SonOlayZamani() (*time.Time, error)
+	// FindSiradaki returns the oldest waiting message of an endpoint; it returns
+	// gorm.ErrRecordNotFound if none is waiting
+	FindSiradaki(hedef string) (*models.HL7Mesaj, error)
+	UpdateTeslimat(hl7MesajID uint, teslimat HL7Teslimat) error
+	// FindByDurum lists the messages of an endpoint in a state, oldest first; an empty
+	// hedef lists every endpoint
+	FindByDurum(hedef string, durum models.HL7MesajDurumu, page, limit int) ([]models.HL7Mesaj, int64, error)
+	// YenidenKuyrukla puts a dead letter back in the queue; it returns
+	// gorm.ErrRecordNotFound if the message does not exist or is not a dead letter
+	YenidenKuyrukla(hl7MesajID uint, zaman time.Time) error
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 
 	"gorm.io/gorm"
 )
@@ -75,3 +76,8 @@ func (r *nfcKartRepository) FindAll(page, limit int) ([]models.NFCKart, int64, e
 
 	return nfcKartlar, total, nil
 }
+
+// List retrieves NFC cards matching a list query with pagination
+func (r *nfcKartRepository) List(q query.Query, page, limit int) ([]models.NFCKart, int64, error) {
+	return list[models.NFCKart](r.db, q, page, limit)
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"errors"
+	"medscreen/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// oturumRepository implements OturumRepository interface
+type oturumRepository struct {
+	db *gorm.DB
+}
+
+// NewOturumRepository creates a new OturumRepository instance
+func NewOturumRepository(db *gorm.DB) OturumRepository {
+	return &oturumRepository{db: db}
+}
+
+// Create records a new session
+func (r *oturumRepository) Create(oturum *models.Oturum) error {
+	return r.db.Create(oturum).Error
+}
+
+// FindByKodu retrieves a session by its code
+func (r *oturumRepository) FindByKodu(oturumKodu string) (*models.Oturum, error) {
+	var oturum models.Oturum
+	if err := r.db.Where("oturum_kodu = ?", oturumKodu).First(&oturum).Error; err != nil {
+		return nil, err
+	}
+	return &oturum, nil
+}
+
+// FindAktif retrieves the active sessions matching the filter with pagination
+func (r *oturumRepository) FindAktif(filtre OturumFiltresi, now, enErkenEtkinlik time.Time, page, limit int) ([]models.Oturum, int64, error) {
+	var oturumlar []models.Oturum
+	var total int64
+
+	query := applyOturumFiltresi(r.db.Model(&models.Oturum{}), filtre).
+		Where("sonlanma_zamani IS NULL AND son_gecerlilik_zamani > ? AND son_etkinlik_zamani >= ?", now, enErkenEtkinlik)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("son_etkinlik_zamani DESC").Offset(offset).Limit(limit).Find(&oturumlar).Error; err != nil {
+		return nil, 0, err
+	}
+	return oturumlar, total, nil
+}
+
+// UpdateEtkinlik records the last activity of a session
+func (r *oturumRepository) UpdateEtkinlik(oturumKodu string, zaman time.Time) error {
+	return r.db.Model(&models.Oturum{}).
+		Where("oturum_kodu = ?", oturumKodu).
+		Update("son_etkinlik_zamani", zaman).Error
+}
+
+// Yenile records a refresh of an active session
+func (r *oturumRepository) Yenile(oturumKodu string, yenileme OturumYenileme) error {
+	result := r.db.Model(&models.Oturum{}).
+		Where("oturum_kodu = ? AND sonlanma_zamani IS NULL", oturumKodu).
+		Updates(map[string]interface{}{
+			"son_etkinlik_zamani":   yenileme.Zaman,
+			"son_gecerlilik_zamani": yenileme.SonGecerlilikZamani,
+			"birim_kodu":            yenileme.BirimKodu,
+			"yatak_kodu":            yenileme.YatakKodu,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Sonlandir ends the sessions matching the filter that have not ended yet
+func (r *oturumRepository) Sonlandir(filtre OturumFiltresi, son OturumSonu) (int64, error) {
+	if filtre == (OturumFiltresi{}) {
+		return 0, errors.New("ending sessions requires a filter")
+	}
+	updates := map[string]interface{}{
+		"sonlanma_zamani":           son.Zaman,
+		"sonlandiran_personel_kodu": son.SonlandiranPersonelKodu,
+		"sonlanma_sebebi":           son.Sebep,
+	}
+	result := applyOturumFiltresi(r.db.Model(&models.Oturum{}), filtre).
+		Where("sonlanma_zamani IS NULL").
+		Updates(updates)
+	return result.RowsAffected, result.Error
+}
+
+func applyOturumFiltresi(query *gorm.DB, filtre OturumFiltresi) *gorm.DB {
+	if filtre.OturumKodu != "" {
+		query = query.Where("oturum_kodu = ?", filtre.OturumKodu)
+	}
+	if filtre.PersonelKodu != "" {
+		query = query.Where("personel_kodu = ?", filtre.PersonelKodu)
+	}
+	if filtre.NFCKartKodu != "" {
+		query = query.Where("nfc_kart_kodu = ?", filtre.NFCKartKodu)
+	}
+	if filtre.TabletCihazKodu != "" {
+		query = query.Where("tablet_cihaz_kodu = ?", filtre.TabletCihazKodu)
+	}
+	if filtre.BirimKodu != "" {
+		query = query.Where("birim_kodu = ?", filtre.BirimKodu)
+	}
+	return query
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 
 	"gorm.io/gorm"
 )
@@ -66,3 +67,8 @@ func (r *personelRepository) FindByGorevKodu(gorevKodu string, page, limit int)
 
 	return personeller, total, nil
 }
+
+// List retrieves personnel matching a list query with pagination
+func (r *personelRepository) List(q query.Query, page, limit int) ([]models.Personel, int64, error) {
+	return list[models.Personel](r.db, q, page, limit)
+}
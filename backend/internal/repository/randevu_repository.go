@@ -2,6 +2,7 @@ package repository
 
 import (
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"time"
 
 	"gorm.io/gorm"
@@ -128,3 +129,8 @@ func (r *randevuRepository) FindByDateRange(startDate, endDate time.Time, page,
 
 	return randevular, total, nil
 }
+
+// List retrieves appointments matching a list query with pagination
+func (r *randevuRepository) List(q query.Query, page, limit int) ([]models.Randevu, int64, error) {
+	return list[models.Randevu](r.db, q, page, limit)
+}
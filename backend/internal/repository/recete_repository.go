@@ -2,6 +2,7 @@ package repository
 
 import (
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 
 	"gorm.io/gorm"
 )
@@ -83,3 +84,25 @@ func (r *receteRepository) FindIlacByReceteKodu(receteKodu string, page, limit i
 
 	return ilaclar, total, nil
 }
+
+// FindAktifByBasvuruKodu retrieves active prescriptions of a visit together with their medications
+func (r *receteRepository) FindAktifByBasvuruKodu(basvuruKodu string) ([]models.Recete, error) {
+	var receteler []models.Recete
+	if err := r.db.Preload("Hekim").Preload("Ilaclar").
+		Where("hasta_basvuru_kodu = ? AND aktiflik_bilgisi = ?", basvuruKodu, 1).
+		Order("recete_zamani DESC").
+		Find(&receteler).Error; err != nil {
+		return nil, err
+	}
+	return receteler, nil
+}
+
+// List retrieves prescriptions matching a list query with pagination
+func (r *receteRepository) List(q query.Query, page, limit int) ([]models.Recete, int64, error) {
+	return list[models.Recete](r.db, q, page, limit)
+}
+
+// ListIlac retrieves prescription drugs matching a list query with pagination
+func (r *receteRepository) ListIlac(q query.Query, page, limit int) ([]models.ReceteIlac, int64, error) {
+	return list[models.ReceteIlac](r.db, q, page, limit)
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 
 	"gorm.io/gorm"
 )
@@ -65,3 +66,20 @@ func (r *riskSkorlamaRepository) FindByTuru(turu string, page, limit int) ([]mod
 
 	return skorlar, total, nil
 }
+
+// FindSonByBasvuruKodu retrieves the most recent risk score of each scoring type for a visit
+func (r *riskSkorlamaRepository) FindSonByBasvuruKodu(basvuruKodu string) ([]models.RiskSkorlama, error) {
+	var skorlar []models.RiskSkorlama
+	if err := r.db.Select("DISTINCT ON (risk_skorlama_turu) *").
+		Where("hasta_basvuru_kodu = ?", basvuruKodu).
+		Order("risk_skorlama_turu, islem_zamani DESC").
+		Find(&skorlar).Error; err != nil {
+		return nil, err
+	}
+	return skorlar, nil
+}
+
+// List retrieves risk scores matching a list query with pagination
+func (r *riskSkorlamaRepository) List(q query.Query, page, limit int) ([]models.RiskSkorlama, int64, error) {
+	return list[models.RiskSkorlama](r.db, q, page, limit)
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 
 	"gorm.io/gorm"
 )
@@ -67,3 +68,17 @@ func (r *tabletCihazRepository) FindAll(page, limit int) ([]models.TabletCihaz,
 
 	return cihazlar, total, nil
 }
+
+// FindBySeriNumarasi retrieves a tablet device by its serial number
+func (r *tabletCihazRepository) FindBySeriNumarasi(seriNumarasi string) (*models.TabletCihaz, error) {
+	var cihaz models.TabletCihaz
+	if err := r.db.Preload("Yatak").Where("seri_numarasi = ?", seriNumarasi).First(&cihaz).Error; err != nil {
+		return nil, err
+	}
+	return &cihaz, nil
+}
+
+// List retrieves tablet devices matching a list query with pagination
+func (r *tabletCihazRepository) List(q query.Query, page, limit int) ([]models.TabletCihaz, int64, error) {
+	return list[models.TabletCihaz](r.db, q, page, limit)
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"medscreen/internal/models"
+	"medscreen/internal/query"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -45,3 +47,31 @@ func (r *tetkikSonucRepository) FindByBasvuruKodu(basvuruKodu string, page, limi
 
 	return sonuclar, total, nil
 }
+
+// FindByYatanHastalar retrieves the evaluable results of current inpatients, joined with
+// anlik_yatan_hasta; the unit falls back to the unit of the bed like the policy resolvers do
+func (r *tetkikSonucRepository) FindByYatanHastalar(birimKodu string, since time.Time, page, limit int) ([]models.TetkikSonuc, error) {
+	var sonuclar []models.TetkikSonuc
+
+	query := r.db.Preload("HastaBasvuru").
+		Joins("JOIN anlik_yatan_hasta ON anlik_yatan_hasta.hasta_basvuru_kodu = tetkik_sonuc.hasta_basvuru_kodu").
+		Joins("JOIN yatak ON yatak.yatak_kodu = anlik_yatan_hasta.yatak_kodu").
+		Where("tetkik_sonuc.sonuc_degeri IS NOT NULL AND tetkik_sonuc.kritik_deger_araligi IS NOT NULL").
+		Where("tetkik_sonuc.kayit_zamani >= ?", since)
+	if birimKodu != "" {
+		query = query.Where("COALESCE(anlik_yatan_hasta.birim_kodu, yatak.birim_kodu) = ?", birimKodu)
+	}
+
+	if err := query.Distinct("tetkik_sonuc.*").
+		Order("tetkik_sonuc.kayit_zamani DESC, tetkik_sonuc.tetkik_sonuc_kodu").
+		Offset((page - 1) * limit).Limit(limit).
+		Find(&sonuclar).Error; err != nil {
+		return nil, err
+	}
+	return sonuclar, nil
+}
+
+// List retrieves test results matching a list query with pagination
+func (r *tetkikSonucRepository) List(q query.Query, page, limit int) ([]models.TetkikSonuc, int64, error) {
+	return list[models.TetkikSonuc](r.db, q, page, limit)
+}
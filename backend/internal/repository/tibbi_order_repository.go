@@ -2,6 +2,8 @@ package repository
 
 import (
 	"medscreen/internal/models"
+	"medscreen/internal/query"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -65,3 +67,48 @@ func (r *tibbiOrderRepository) FindDetayByOrderKodu(orderKodu string, page, limi
 
 	return detaylar, total, nil
 }
+
+// FindAcikByBasvuruKodu retrieves non-cancelled medical orders of a visit that still have
+// pending details, preloading only the pending details
+func (r *tibbiOrderRepository) FindAcikByBasvuruKodu(basvuruKodu string) ([]models.TibbiOrder, error) {
+	var orders []models.TibbiOrder
+	if err := r.db.Preload("Hekim").
+		Preload("Detaylar", func(db *gorm.DB) *gorm.DB {
+			return db.Where("uygulanma_durumu = ?", 0).Order("planlanan_uygulama_zamani ASC")
+		}).
+		Where("hasta_basvuru_kodu = ? AND iptal_durumu = ?", basvuruKodu, 0).
+		Where("EXISTS (SELECT 1 FROM tibbi_order_detay d WHERE d.tibbi_order_kodu = tibbi_order.tibbi_order_kodu AND d.uygulanma_durumu = ?)", 0).
+		Order("order_zamani DESC").
+		Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// FindDetayByBasvuruKodlari retrieves the details of the non-cancelled orders of the given visits
+// planned between startDate and endDate, with their order and administering personnel
+func (r *tibbiOrderRepository) FindDetayByBasvuruKodlari(basvuruKodlari []string, startDate, endDate time.Time) ([]models.TibbiOrderDetay, error) {
+	var detaylar []models.TibbiOrderDetay
+	if len(basvuruKodlari) == 0 {
+		return detaylar, nil
+	}
+	if err := r.db.Preload("TibbiOrder").Preload("UygulayanPersonel").
+		Joins("JOIN tibbi_order ON tibbi_order.tibbi_order_kodu = tibbi_order_detay.tibbi_order_kodu").
+		Where("tibbi_order.hasta_basvuru_kodu IN ? AND tibbi_order.iptal_durumu = ?", basvuruKodlari, 0).
+		Where("tibbi_order_detay.planlanan_uygulama_zamani BETWEEN ? AND ?", startDate, endDate).
+		Order("tibbi_order_detay.planlanan_uygulama_zamani ASC").
+		Find(&detaylar).Error; err != nil {
+		return nil, err
+	}
+	return detaylar, nil
+}
+
+// List retrieves medical orders matching a list query with pagination
+func (r *tibbiOrderRepository) List(q query.Query, page, limit int) ([]models.TibbiOrder, int64, error) {
+	return list[models.TibbiOrder](r.db, q, page, limit)
+}
+
+// ListDetay retrieves order administrations matching a list query with pagination
+func (r *tibbiOrderRepository) ListDetay(q query.Query, page, limit int) ([]models.TibbiOrderDetay, int64, error) {
+	return list[models.TibbiOrderDetay](r.db, q, page, limit)
+}
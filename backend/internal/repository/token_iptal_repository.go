@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"medscreen/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// tokenIptalRepository implements TokenIptalRepository interface
+type tokenIptalRepository struct {
+	db *gorm.DB
+}
+
+// NewTokenIptalRepository creates a new TokenIptalRepository instance
+func NewTokenIptalRepository(db *gorm.DB) TokenIptalRepository {
+	return &tokenIptalRepository{db: db}
+}
+
+// Create adds a revocation entry
+func (r *tokenIptalRepository) Create(iptal *models.TokenIptal) error {
+	return r.db.Create(iptal).Error
+}
+
+// IsRevoked checks the revocation list for a single token or a personnel/card-wide entry
+func (r *tokenIptalRepository) IsRevoked(jti, personelKodu, nfcKartKodu string, issuedAt time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.TokenIptal{}).
+		Where("jti = ?", jti).
+		Or("jti IS NULL AND iptal_zamani >= ? AND (personel_kodu = ? OR nfc_kart_kodu = ?)", issuedAt, personelKodu, nfcKartKodu).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// DeleteExpired removes entries that can no longer match a valid token
+func (r *tokenIptalRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db.Where("son_gecerlilik_zamani < ?", before).Delete(&models.TokenIptal{})
+	return result.RowsAffected, result.Error
+}
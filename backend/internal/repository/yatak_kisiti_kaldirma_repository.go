@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"medscreen/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// yatakKisitiKaldirmaRepository implements YatakKisitiKaldirmaRepository interface
+type yatakKisitiKaldirmaRepository struct {
+	db *gorm.DB
+}
+
+// NewYatakKisitiKaldirmaRepository creates a new YatakKisitiKaldirmaRepository instance
+func NewYatakKisitiKaldirmaRepository(db *gorm.DB) YatakKisitiKaldirmaRepository {
+	return &yatakKisitiKaldirmaRepository{db: db}
+}
+
+// Create records an escalation
+func (r *yatakKisitiKaldirmaRepository) Create(kaldirma *models.YatakKisitiKaldirma) error {
+	return r.db.Create(kaldirma).Error
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 
 	"gorm.io/gorm"
 )
@@ -69,3 +70,8 @@ func (r *yatakRepository) FindAll(page, limit int) ([]models.Yatak, int64, error
 
 	return yataklar, total, nil
 }
+
+// List retrieves beds matching a list query with pagination
+func (r *yatakRepository) List(q query.Query, page, limit int) ([]models.Yatak, int64, error) {
+	return list[models.Yatak](r.db, q, page, limit)
+}
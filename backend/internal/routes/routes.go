@@ -1,42 +1,110 @@
 package routes
 
 import (
+	"medscreen/internal/audit"
+	"medscreen/internal/fhir"
 	"medscreen/internal/handler"
+	"medscreen/internal/masking"
 	"medscreen/internal/middleware"
+	"medscreen/internal/policy"
+	"medscreen/internal/query"
+	"medscreen/internal/ratelimit"
+	"medscreen/internal/repository"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 // Handlers holds all VEM 2.0 HTTP handlers (read-only)
 type Handlers struct {
+	Auth                  *handler.AuthHandler
+	Oturum                *handler.OturumHandler
+	ErisimKaydi           *handler.ErisimKaydiHandler
 	Personel              *handler.PersonelHandler
 	NFCKart               *handler.NFCKartHandler
 	Hasta                 *handler.HastaHandler
 	HastaBasvuru          *handler.HastaBasvuruHandler
+	HastaBasvuruOzet      *handler.HastaBasvuruOzetHandler
 	Yatak                 *handler.YatakHandler
 	TabletCihaz           *handler.TabletCihazHandler
+	Cihaz                 *handler.CihazHandler
+	AcilErisim            *handler.AcilErisimHandler
+	IkinciFaktor          *handler.IkinciFaktorHandler
+	APIAnahtari           *handler.APIAnahtariHandler
+	JWKS                  *handler.JWKSHandler
+	Engel                 *handler.EngelHandler
 	AnlikYatanHasta       *handler.AnlikYatanHastaHandler
 	HastaVitalFizikiBulgu *handler.HastaVitalFizikiBulguHandler
+	News2                 *handler.News2Handler
+	Mar                   *handler.MarHandler
 	KlinikSeyir           *handler.KlinikSeyirHandler
 	TibbiOrder            *handler.TibbiOrderHandler
 	TetkikSonuc           *handler.TetkikSonucHandler
 	Recete                *handler.ReceteHandler
+	IlacAlerji            *handler.IlacAlerjiHandler
 	BasvuruTani           *handler.BasvuruTaniHandler
 	HastaTibbiBilgi       *handler.HastaTibbiBilgiHandler
 	HastaUyari            *handler.HastaUyariHandler
 	RiskSkorlama          *handler.RiskSkorlamaHandler
 	BasvuruYemek          *handler.BasvuruYemekHandler
 	Randevu               *handler.RandevuHandler
+	Stream                *handler.StreamHandler
+	FHIR                  *handler.FHIRHandler
+	HL7Mesaj              *handler.HL7MesajHandler
+	// SSO is nil when no OpenID Connect provider is configured
+	SSO *handler.SSOHandler
+}
+
+// Options holds the non-handler dependencies of the routes
+type Options struct {
+	CORSOrigins          []string
+	CORSMethods          []string
+	CORSHeaders          []string
+	Revocations          middleware.TokenRevocationChecker
+	APIKeys              middleware.APIKeyVerifier
+	Policy               *policy.Engine
+	AuditSink            repository.ErisimKaydiRepository
+	AdminPersonelKodlari []string
+	// AuditorPersonelKodlari may query the access audit trail in addition to the admins
+	AuditorPersonelKodlari []string
+	// SupervisorPersonelKodlari may review break-the-glass accesses in addition to the admins
+	SupervisorPersonelKodlari []string
+	// NFCLimiter applies backoff and lockout to failed NFC logins
+	NFCLimiter *ratelimit.Limiter
+	// Masking masks personal data in protected responses by role and purpose
+	Masking *masking.Policy
+}
+
+// writablePrefixes lists the endpoints that manage MedScreen-owned state.
+// They never write to VEM 2.0 tables, so they are exempt from the read-only rule.
+var writablePrefixes = []string{
+	"/api/v1/auth/",
+	"/api/v1/devices/",
+	"/api/v1/acil-erisim/",
+	"/api/v1/tetkik-sonuc/kritik/",
+	"/api/v1/hl7/",
+}
+
+// streamPrefixes lists the long-lived Server-Sent Events endpoints. Their responses are
+// not buffered by the audit middleware; the handlers record each batch themselves.
+var streamPrefixes = []string{
+	"/api/v1/stream/",
 }
 
 // MethodNotAllowedMiddleware rejects write operations (POST, PUT, PATCH, DELETE)
-// This middleware ensures the API is read-only as per VEM 2.0 requirements
-func MethodNotAllowedMiddleware() gin.HandlerFunc {
+// This middleware ensures the VEM 2.0 API is read-only; paths under the given prefixes are exempt
+func MethodNotAllowedMiddleware(writablePrefixes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		method := c.Request.Method
 		if method == http.MethodPost || method == http.MethodPut ||
 			method == http.MethodPatch || method == http.MethodDelete {
+			for _, prefix := range writablePrefixes {
+				if strings.HasPrefix(c.Request.URL.Path, prefix) {
+					c.Next()
+					return
+				}
+			}
 			c.JSON(http.StatusMethodNotAllowed, gin.H{
 				"success": false,
 				"code":    "METHOD_NOT_ALLOWED",
@@ -49,35 +117,123 @@ func MethodNotAllowedMiddleware() gin.HandlerFunc {
 	}
 }
 
-// SetupRoutes registers all VEM 2.0 API endpoints (GET only)
-func SetupRoutes(router *gin.Engine, handlers *Handlers, corsOrigins, corsMethods, corsHeaders []string) {
+// SetupRoutes registers all VEM 2.0 API endpoints (GET only) and the MedScreen auth endpoints
+func SetupRoutes(router *gin.Engine, handlers *Handlers, opts Options) {
 	// Apply global middleware
-	router.Use(middleware.CORSMiddleware(corsOrigins, corsMethods, corsHeaders))
+	router.Use(middleware.CORSMiddleware(opts.CORSOrigins, opts.CORSMethods, opts.CORSHeaders))
 	router.Use(middleware.LoggerMiddleware())
 	router.Use(middleware.RecoveryMiddleware())
-	router.Use(MethodNotAllowedMiddleware())
+	router.Use(MethodNotAllowedMiddleware(writablePrefixes...))
+
+	// Public keys for services that verify MedScreen tokens
+	router.GET("/.well-known/jwks.json", handlers.JWKS.GetJWKS)
 
 	// API v1 group
 	api := router.Group("/api/v1")
 
-	// NFC Authentication endpoint (public, GET only for read-only system)
-	api.GET("/nfc-kart/authenticate/:kart_uid", handlers.NFCKart.GetByKartUID)
+	// NFC Authentication endpoints (public)
+	nfcLimit := ratelimit.Middleware(opts.NFCLimiter, "nfc_authenticate", handler.NFCKaynaklari, handler.NFCSifirlanir)
+	api.GET("/nfc-kart/authenticate/:kart_uid", handlers.Auth.TabletDogrula, nfcLimit, handlers.Auth.LoginWithNFC)
+	api.POST("/auth/refresh", handlers.Auth.Refresh)
+
+	// Hospital SSO (public; the provider authenticates the user)
+	if handlers.SSO != nil {
+		api.GET("/auth/sso/login", handlers.SSO.Login)
+		api.GET("/auth/sso/callback", handlers.SSO.Callback)
+	}
+
+	// Tablet enrollment and heartbeat endpoints (public; heartbeats carry the device credential)
+	api.POST("/devices/register", handlers.Cihaz.Register)
+	api.POST("/devices/heartbeat", handlers.Cihaz.Heartbeat)
 
 	// Protected routes (require authentication)
 	protected := api.Group("/")
-	protected.Use(middleware.AuthMiddleware())
+	protected.Use(middleware.APIKeyMiddleware(opts.APIKeys))
+	protected.Use(middleware.AuthMiddleware(opts.Revocations))
+	// fields= and sideload= are applied outside audit and masking so both see whole records
+	protected.Use(query.Middleware())
+	protected.Use(audit.Middleware(opts.AuditSink, streamPrefixes...))
+	protected.Use(opts.Masking.Middleware(streamPrefixes...))
+
+	// Auth routes
+	auth := protected.Group("/auth")
+	{
+		auth.POST("/logout", handlers.Auth.Logout)
+		auth.POST("/logout-everywhere", handlers.Auth.LogoutEverywhere)
+		auth.POST("/escalate", opts.Policy.IkinciFaktor("escalate"), handlers.Auth.Escalate)
+		auth.POST("/break-glass", opts.Policy.IkinciFaktor("break-glass"), handlers.AcilErisim.BreakGlass)
+		auth.POST("/step-up", handlers.IkinciFaktor.StepUp)
+
+		admin := auth.Group("/revoke", middleware.AdminMiddleware(opts.AdminPersonelKodlari))
+		admin.POST("/personel/:personel_kodu", handlers.Auth.RevokePersonel)
+		admin.POST("/personel/:personel_kodu/unblock", handlers.Auth.UnblockPersonel)
+		admin.POST("/nfc-kart/:nfc_kart_kodu", handlers.Auth.RevokeNFCKart)
+		admin.POST("/nfc-kart/:nfc_kart_kodu/unblock", handlers.Auth.UnblockNFCKart)
+		admin.POST("/oturum/:oturum_kodu", handlers.Auth.RevokeOturum)
+		admin.POST("/tablet-cihaz/:tablet_cihaz_kodu", handlers.Auth.RevokeTabletCihaz)
+
+		oturumlar := auth.Group("/oturumlar", middleware.AdminMiddleware(opts.AdminPersonelKodlari))
+		oturumlar.GET("", handlers.Oturum.GetAktif)
+		oturumlar.GET("/birim/:birim_kodu", handlers.Oturum.GetByBirim)
+
+		engeller := auth.Group("/blocked-sources", middleware.AdminMiddleware(opts.AdminPersonelKodlari))
+		engeller.GET("", handlers.Engel.GetAll)
+		engeller.POST("/unblock", handlers.Engel.Unblock)
+
+		ikinciFaktor := auth.Group("/ikinci-faktor/:personel_kodu", middleware.AdminMiddleware(opts.AdminPersonelKodlari), opts.Policy.IkinciFaktor("ikinci-faktor"))
+		ikinciFaktor.GET("", handlers.IkinciFaktor.GetDurum)
+		ikinciFaktor.POST("/pin", handlers.IkinciFaktor.PinBelirle)
+		ikinciFaktor.POST("/totp", handlers.IkinciFaktor.TOTPOlustur)
+		ikinciFaktor.POST("/reset", handlers.IkinciFaktor.Sifirla)
+
+		apiAnahtari := auth.Group("/api-anahtari", middleware.AdminMiddleware(opts.AdminPersonelKodlari), opts.Policy.IkinciFaktor("api-anahtari"))
+		apiAnahtari.GET("", handlers.APIAnahtari.GetAll)
+		apiAnahtari.POST("", handlers.APIAnahtari.Create)
+		apiAnahtari.POST("/:anahtar_kodu/revoke", handlers.APIAnahtari.Revoke)
+	}
+
+	// Device enrollment management (admins only)
+	devices := protected.Group("/devices", middleware.AdminMiddleware(opts.AdminPersonelKodlari))
+	{
+		devices.POST("/:tablet_cihaz_kodu/kayit-kodu", handlers.Cihaz.KayitKoduOlustur)
+		devices.POST("/:tablet_cihaz_kodu/revoke", handlers.Cihaz.Revoke)
+	}
+
+	// Outbox and dead letters of the HL7 v2 feed (admins only)
+	hl7Mesaj := protected.Group("/hl7/mesajlar", middleware.AdminMiddleware(opts.AdminPersonelKodlari))
+	{
+		hl7Mesaj.GET("", handlers.HL7Mesaj.GetAll)
+		hl7Mesaj.POST("/:hl7_mesaj_id/requeue", handlers.HL7Mesaj.Requeue)
+	}
+
+	// Access audit trail routes (auditors only)
+	auditors := append(append([]string{}, opts.AdminPersonelKodlari...), opts.AuditorPersonelKodlari...)
+	erisimKaydi := protected.Group("/erisim-kaydi", middleware.AdminMiddleware(auditors))
+	{
+		erisimKaydi.GET("", handlers.ErisimKaydi.GetByFilters)
+		erisimKaydi.GET("/hasta/:hasta_kodu", handlers.ErisimKaydi.GetByHasta)
+	}
+
+	// Break-the-glass review queue (supervisors only)
+	supervisors := append(append([]string{}, opts.AdminPersonelKodlari...), opts.SupervisorPersonelKodlari...)
+	acilErisim := protected.Group("/acil-erisim", middleware.AdminMiddleware(supervisors))
+	{
+		acilErisim.GET("", handlers.AcilErisim.GetAll)
+		acilErisim.GET("/:kodu", handlers.AcilErisim.GetByKodu)
+		acilErisim.GET("/:kodu/erisimler", handlers.AcilErisim.GetErisimler)
+		acilErisim.POST("/:kodu/incele", opts.Policy.IkinciFaktor("acil-erisim-incele"), handlers.AcilErisim.Incele)
+	}
 
 	// Personel routes (GET only)
-	personel := protected.Group("/personel")
+	personel := protected.Group("/personel", opts.Policy.Resource("personel"))
 	{
 		personel.GET("", handlers.Personel.GetAll)
 		personel.GET("/:kodu", handlers.Personel.GetByKodu)
 		personel.GET("/gorev/:gorev_kodu", handlers.Personel.GetByGorev)
-		personel.GET("/authenticate/:kart_uid", handlers.Personel.Authenticate)
 	}
 
 	// NFC Kart routes (GET only)
-	nfcKart := protected.Group("/nfc-kart")
+	nfcKart := protected.Group("/nfc-kart", opts.Policy.Resource("nfc-kart"))
 	{
 		nfcKart.GET("/:kodu", handlers.NFCKart.GetByKodu)
 		nfcKart.GET("/uid/:kart_uid", handlers.NFCKart.GetByKartUID)
@@ -85,7 +241,7 @@ func SetupRoutes(router *gin.Engine, handlers *Handlers, corsOrigins, corsMethod
 	}
 
 	// Hasta routes (GET only)
-	hasta := protected.Group("/hasta")
+	hasta := protected.Group("/hasta", opts.Policy.Resource("hasta"))
 	{
 		hasta.GET("", handlers.Hasta.GetAll)
 		hasta.GET("/search", handlers.Hasta.Search)
@@ -94,16 +250,17 @@ func SetupRoutes(router *gin.Engine, handlers *Handlers, corsOrigins, corsMethod
 	}
 
 	// Hasta Basvuru routes (GET only)
-	hastaBasvuru := protected.Group("/hasta-basvuru")
+	hastaBasvuru := protected.Group("/hasta-basvuru", opts.Policy.Resource("hasta-basvuru"))
 	{
 		hastaBasvuru.GET("/filter", handlers.HastaBasvuru.GetByFilters)
 		hastaBasvuru.GET("/:kodu", handlers.HastaBasvuru.GetByKodu)
+		hastaBasvuru.GET("/:kodu/ozet", handlers.HastaBasvuruOzet.GetOzet)
 		hastaBasvuru.GET("/hasta/:hasta_kodu", handlers.HastaBasvuru.GetByHasta)
 		hastaBasvuru.GET("/hekim/:hekim_kodu", handlers.HastaBasvuru.GetByHekim)
 	}
 
 	// Yatak routes (GET only)
-	yatak := protected.Group("/yatak")
+	yatak := protected.Group("/yatak", opts.Policy.Resource("yatak"))
 	{
 		yatak.GET("", handlers.Yatak.GetAll)
 		yatak.GET("/:kodu", handlers.Yatak.GetByKodu)
@@ -111,32 +268,36 @@ func SetupRoutes(router *gin.Engine, handlers *Handlers, corsOrigins, corsMethod
 	}
 
 	// Tablet Cihaz routes (GET only)
-	tabletCihaz := protected.Group("/tablet-cihaz")
+	tabletCihaz := protected.Group("/tablet-cihaz", opts.Policy.Resource("tablet-cihaz"))
 	{
 		tabletCihaz.GET("", handlers.TabletCihaz.GetAll)
+		tabletCihaz.GET("/filo", handlers.Cihaz.GetFilo)
 		tabletCihaz.GET("/:kodu", handlers.TabletCihaz.GetByKodu)
 		tabletCihaz.GET("/yatak/:yatak_kodu", handlers.TabletCihaz.GetByYatak)
 	}
 
 	// Anlik Yatan Hasta routes (GET only)
-	anlikYatanHasta := protected.Group("/anlik-yatan-hasta")
+	anlikYatanHasta := protected.Group("/anlik-yatan-hasta", opts.Policy.Resource("anlik-yatan-hasta"))
 	{
 		anlikYatanHasta.GET("/:kodu", handlers.AnlikYatanHasta.GetByKodu)
 		anlikYatanHasta.GET("/yatak/:yatak_kodu", handlers.AnlikYatanHasta.GetByYatak)
 		anlikYatanHasta.GET("/hasta/:hasta_kodu", handlers.AnlikYatanHasta.GetByHasta)
 		anlikYatanHasta.GET("/birim/:birim_kodu", handlers.AnlikYatanHasta.GetByBirim)
+		anlikYatanHasta.GET("/birim/:birim_kodu/news2", handlers.News2.GetBirimEskalasyonlari)
+		anlikYatanHasta.GET("/birim/:birim_kodu/mar", handlers.Mar.GetByBirim)
 	}
 
 	// Vital Bulgu routes (GET only)
-	vitalBulgu := protected.Group("/vital-bulgu")
+	vitalBulgu := protected.Group("/vital-bulgu", opts.Policy.Resource("vital-bulgu"))
 	{
 		vitalBulgu.GET("/date-range", handlers.HastaVitalFizikiBulgu.GetByDateRange)
 		vitalBulgu.GET("/:kodu", handlers.HastaVitalFizikiBulgu.GetByKodu)
 		vitalBulgu.GET("/basvuru/:basvuru_kodu", handlers.HastaVitalFizikiBulgu.GetByBasvuru)
+		vitalBulgu.GET("/basvuru/:basvuru_kodu/news2", handlers.News2.GetByBasvuru)
 	}
 
 	// Klinik Seyir routes (GET only)
-	klinikSeyir := protected.Group("/klinik-seyir")
+	klinikSeyir := protected.Group("/klinik-seyir", opts.Policy.Resource("klinik-seyir"))
 	{
 		klinikSeyir.GET("/filter", handlers.KlinikSeyir.GetByFilters)
 		klinikSeyir.GET("/:kodu", handlers.KlinikSeyir.GetByKodu)
@@ -144,31 +305,37 @@ func SetupRoutes(router *gin.Engine, handlers *Handlers, corsOrigins, corsMethod
 	}
 
 	// Tibbi Order routes (GET only)
-	tibbiOrder := protected.Group("/tibbi-order")
+	tibbiOrder := protected.Group("/tibbi-order", opts.Policy.Resource("tibbi-order"))
 	{
 		tibbiOrder.GET("/:kodu", handlers.TibbiOrder.GetByKodu)
 		tibbiOrder.GET("/:kodu/detay", handlers.TibbiOrder.GetDetay)
 		tibbiOrder.GET("/basvuru/:basvuru_kodu", handlers.TibbiOrder.GetByBasvuru)
+		tibbiOrder.GET("/basvuru/:basvuru_kodu/mar", handlers.Mar.GetByBasvuru)
 	}
 
-	// Tetkik Sonuc routes (GET only)
-	tetkikSonuc := protected.Group("/tetkik-sonuc")
+	// Tetkik Sonuc routes (GET only, plus acknowledging critical results)
+	tetkikSonuc := protected.Group("/tetkik-sonuc", opts.Policy.Resource("tetkik-sonuc"))
 	{
+		tetkikSonuc.GET("/kritik", handlers.TetkikSonuc.GetKritik)
+		tetkikSonuc.POST("/kritik/:kodu/onay", opts.Policy.IkinciFaktor("kritik-onay"), handlers.TetkikSonuc.Onayla)
 		tetkikSonuc.GET("/:kodu", handlers.TetkikSonuc.GetByKodu)
 		tetkikSonuc.GET("/basvuru/:basvuru_kodu", handlers.TetkikSonuc.GetByBasvuru)
 	}
+	opts.Policy.RegisterBirimSorgusu(tetkikSonuc.BasePath()+"/kritik", "birim_kodu")
 
 	// Recete routes (GET only)
-	recete := protected.Group("/recete")
+	recete := protected.Group("/recete", opts.Policy.Resource("recete"))
 	{
 		recete.GET("/:kodu", handlers.Recete.GetByKodu)
 		recete.GET("/:kodu/ilaclar", handlers.Recete.GetIlaclar)
+		recete.GET("/:kodu/uyumsuzluk", handlers.IlacAlerji.GetByRecete)
 		recete.GET("/basvuru/:basvuru_kodu", handlers.Recete.GetByBasvuru)
+		recete.GET("/basvuru/:basvuru_kodu/uyumsuzluk", handlers.IlacAlerji.GetByBasvuru)
 		recete.GET("/hekim/:hekim_kodu", handlers.Recete.GetByHekim)
 	}
 
 	// Basvuru Tani routes (GET only)
-	basvuruTani := protected.Group("/basvuru-tani")
+	basvuruTani := protected.Group("/basvuru-tani", opts.Policy.Resource("basvuru-tani"))
 	{
 		basvuruTani.GET("/:kodu", handlers.BasvuruTani.GetByKodu)
 		basvuruTani.GET("/hasta/:hasta_kodu", handlers.BasvuruTani.GetByHasta)
@@ -176,7 +343,7 @@ func SetupRoutes(router *gin.Engine, handlers *Handlers, corsOrigins, corsMethod
 	}
 
 	// Hasta Tibbi Bilgi routes (GET only)
-	hastaTibbiBilgi := protected.Group("/hasta-tibbi-bilgi")
+	hastaTibbiBilgi := protected.Group("/hasta-tibbi-bilgi", opts.Policy.Resource("hasta-tibbi-bilgi"))
 	{
 		hastaTibbiBilgi.GET("/:kodu", handlers.HastaTibbiBilgi.GetByKodu)
 		hastaTibbiBilgi.GET("/hasta/:hasta_kodu", handlers.HastaTibbiBilgi.GetByHasta)
@@ -184,7 +351,7 @@ func SetupRoutes(router *gin.Engine, handlers *Handlers, corsOrigins, corsMethod
 	}
 
 	// Hasta Uyari routes (GET only)
-	hastaUyari := protected.Group("/hasta-uyari")
+	hastaUyari := protected.Group("/hasta-uyari", opts.Policy.Resource("hasta-uyari"))
 	{
 		hastaUyari.GET("/filter", handlers.HastaUyari.GetByFilters)
 		hastaUyari.GET("/:kodu", handlers.HastaUyari.GetByKodu)
@@ -192,7 +359,7 @@ func SetupRoutes(router *gin.Engine, handlers *Handlers, corsOrigins, corsMethod
 	}
 
 	// Risk Skorlama routes (GET only)
-	riskSkorlama := protected.Group("/risk-skorlama")
+	riskSkorlama := protected.Group("/risk-skorlama", opts.Policy.Resource("risk-skorlama"))
 	{
 		riskSkorlama.GET("/:kodu", handlers.RiskSkorlama.GetByKodu)
 		riskSkorlama.GET("/basvuru/:basvuru_kodu", handlers.RiskSkorlama.GetByBasvuru)
@@ -200,7 +367,7 @@ func SetupRoutes(router *gin.Engine, handlers *Handlers, corsOrigins, corsMethod
 	}
 
 	// Basvuru Yemek routes (GET only)
-	basvuruYemek := protected.Group("/basvuru-yemek")
+	basvuruYemek := protected.Group("/basvuru-yemek", opts.Policy.Resource("basvuru-yemek"))
 	{
 		basvuruYemek.GET("/:kodu", handlers.BasvuruYemek.GetByKodu)
 		basvuruYemek.GET("/basvuru/:basvuru_kodu", handlers.BasvuruYemek.GetByBasvuru)
@@ -208,7 +375,7 @@ func SetupRoutes(router *gin.Engine, handlers *Handlers, corsOrigins, corsMethod
 	}
 
 	// Randevu routes (GET only)
-	randevu := protected.Group("/randevu")
+	randevu := protected.Group("/randevu", opts.Policy.Resource("randevu"))
 	{
 		randevu.GET("/:kodu", handlers.Randevu.GetByKodu)
 		randevu.GET("/hasta/:hasta_kodu", handlers.Randevu.GetByHasta)
@@ -217,4 +384,78 @@ func SetupRoutes(router *gin.Engine, handlers *Handlers, corsOrigins, corsMethod
 		randevu.GET("/turu/:randevu_turu", handlers.Randevu.GetByTuru)
 		randevu.GET("/date-range", handlers.Randevu.GetByDateRange)
 	}
+
+	// Clinical event streams (Server-Sent Events)
+	eventStream := protected.Group("/stream", opts.Policy.Resource("stream"))
+	{
+		eventStream.GET("/yatak/:yatak_kodu", handlers.Stream.GetByYatak)
+		eventStream.GET("/birim/:birim_kodu", handlers.Stream.GetByBirim)
+	}
+
+	setupFHIRRoutes(router, handlers, opts)
+}
+
+// setupFHIRRoutes registers the read-only FHIR R4 facade. Each resource type is authorized
+// as the VEM records it is mapped from. Responses are masked while they are built, so the
+// masking middleware (which only handles application/json) is not used.
+func setupFHIRRoutes(router *gin.Engine, handlers *Handlers, opts Options) {
+	// Errors of every middleware below are reported as OperationOutcomes
+	fhirAPI := router.Group(fhir.BasePath, fhir.Middleware())
+	fhirAPI.GET("/metadata", handlers.FHIR.Metadata)
+
+	protected := fhirAPI.Group("/")
+	protected.Use(middleware.APIKeyMiddleware(opts.APIKeys))
+	protected.Use(middleware.AuthMiddleware(opts.Revocations))
+	protected.Use(audit.Middleware(opts.AuditSink))
+
+	patient := protected.Group("/Patient", opts.Policy.Resource("hasta"))
+	patient.GET("", handlers.FHIR.SearchPatient)
+	patient.GET("/:id", handlers.FHIR.ReadPatient)
+
+	encounter := protected.Group("/Encounter", opts.Policy.Resource("hasta-basvuru"))
+	encounter.GET("", handlers.FHIR.SearchEncounter)
+	encounter.GET("/:id", handlers.FHIR.ReadEncounter)
+
+	observation := protected.Group("/Observation", opts.Policy.Resources(observationResources))
+	observation.GET("", handlers.FHIR.SearchObservation)
+	observation.GET("/:id", handlers.FHIR.ReadObservation)
+
+	condition := protected.Group("/Condition", opts.Policy.Resource("basvuru-tani"))
+	condition.GET("", handlers.FHIR.SearchCondition)
+	condition.GET("/:id", handlers.FHIR.ReadCondition)
+
+	allergy := protected.Group("/AllergyIntolerance", opts.Policy.Resource("hasta-tibbi-bilgi"))
+	allergy.GET("", handlers.FHIR.SearchAllergyIntolerance)
+	allergy.GET("/:id", handlers.FHIR.ReadAllergyIntolerance)
+
+	medicationRequest := protected.Group("/MedicationRequest", opts.Policy.Resource("recete"))
+	medicationRequest.GET("", handlers.FHIR.SearchMedicationRequest)
+	medicationRequest.GET("/:id", handlers.FHIR.ReadMedicationRequest)
+
+	practitioner := protected.Group("/Practitioner", opts.Policy.Resource("personel"))
+	practitioner.GET("", handlers.FHIR.SearchPractitioner)
+	practitioner.GET("/:id", handlers.FHIR.ReadPractitioner)
+}
+
+// observationResources returns the policy resources an Observation request reads: vital
+// signs, laboratory results or, for a search without a category, both
+func observationResources(c *gin.Context) []string {
+	category := c.Query("category")
+	if _, code, found := strings.Cut(category, "|"); found {
+		category = code
+	}
+	if id := c.Param("id"); id != "" {
+		category = fhir.CategoryVitalSigns
+		if _, _, lab, _ := fhir.ParseObservationID(id); lab {
+			category = fhir.CategoryLaboratory
+		}
+	}
+
+	switch category {
+	case fhir.CategoryVitalSigns:
+		return []string{"vital-bulgu"}
+	case fhir.CategoryLaboratory:
+		return []string{"tetkik-sonuc"}
+	}
+	return []string{"vital-bulgu", "tetkik-sonuc"}
 }
@@ -0,0 +1,244 @@
+// Package scoring computes clinical early warning scores from VEM 2.0 vital signs.
+package scoring
+
+import (
+	"math"
+	"medscreen/internal/measurement"
+	"medscreen/internal/models"
+)
+
+// Parametre is a physiological parameter of the NEWS2 score
+type Parametre string
+
+// NEWS2 parameters recorded in HastaVitalFizikiBulgu.
+// Level of consciousness (ACVPU) and supplemental oxygen are not recorded in VEM 2.0;
+// they are scored as alert and room air, so a computed score is a lower bound.
+const (
+	ParametreSolunum            Parametre = "solunum"
+	ParametreSaturasyon         Parametre = "saturasyon"
+	ParametreSistolikKanBasinci Parametre = "sistolik_kan_basinci"
+	ParametreNabiz              Parametre = "nabiz"
+	ParametreAtes               Parametre = "ates"
+)
+
+// Parametreler lists the scored parameters in NEWS2 chart order
+var Parametreler = []Parametre{
+	ParametreSolunum,
+	ParametreSaturasyon,
+	ParametreSistolikKanBasinci,
+	ParametreNabiz,
+	ParametreAtes,
+}
+
+// RiskDuzeyi is the NEWS2 clinical risk band that determines the response
+type RiskDuzeyi string
+
+// NEWS2 clinical risk bands
+const (
+	RiskDusuk     RiskDuzeyi = "DUSUK"      // 0-4: ward-based response
+	RiskDusukOrta RiskDuzeyi = "DUSUK_ORTA" // a single parameter scoring 3: urgent ward-based response
+	RiskOrta      RiskDuzeyi = "ORTA"       // 5-6: key threshold for urgent response
+	RiskYuksek    RiskDuzeyi = "YUKSEK"     // 7 or more: emergency response
+)
+
+// riskSirasi orders the risk bands from lowest to highest
+var riskSirasi = map[RiskDuzeyi]int{
+	RiskDusuk:     0,
+	RiskDusukOrta: 1,
+	RiskOrta:      2,
+	RiskYuksek:    3,
+}
+
+// AtLeast reports whether the band is the same as or higher than other
+func (r RiskDuzeyi) AtLeast(other RiskDuzeyi) bool {
+	return riskSirasi[r] >= riskSirasi[other]
+}
+
+// Olcum holds the parsed parameters of one measurement; nil means not recorded
+type Olcum struct {
+	Solunum            *float64
+	Saturasyon         *float64
+	SistolikKanBasinci *float64
+	Nabiz              *float64
+	Ates               *float64
+}
+
+// OlcumFromVital parses the free-text values of a vital signs record.
+// Values that cannot be parsed or fail validation are treated as not recorded.
+func OlcumFromVital(bulgu *models.HastaVitalFizikiBulgu) Olcum {
+	sistolik, _ := measurement.KanBasinci(bulgu.SistolikKanBasinciDegeri, bulgu.DiastolikKanBasinciDegeri)
+	return Olcum{
+		Solunum:            gecerli(measurement.SayisalOf(bulgu.Solunum, "")),
+		Saturasyon:         gecerli(measurement.SayisalOf(bulgu.Saturasyon, "")),
+		SistolikKanBasinci: gecerli(sistolik),
+		Nabiz:              gecerli(measurement.SayisalOf(bulgu.Nabiz, "")),
+		Ates:               gecerli(measurement.SayisalOf(bulgu.Ates, "")),
+	}
+}
+
+func gecerli(s *measurement.Sayisal) *float64 {
+	if !s.Gecerli() {
+		return nil
+	}
+	return s.Deger
+}
+
+// News2 is the score of a single measurement
+type News2 struct {
+	ToplamPuan        int               `json:"toplam_puan"`
+	Puanlar           map[Parametre]int `json:"puanlar"`
+	EksikParametreler []Parametre       `json:"eksik_parametreler,omitempty"`
+	RiskDuzeyi        RiskDuzeyi        `json:"risk_duzeyi"`
+}
+
+// Eskalasyon reports whether the score requires more than routine ward-based monitoring
+func (n News2) Eskalasyon() bool {
+	return n.RiskDuzeyi != RiskDusuk
+}
+
+// Hesapla computes the NEWS2 score of a measurement (SpO2 scale 1).
+// Parameters that were not recorded score 0 and are listed in EksikParametreler.
+func Hesapla(olcum Olcum) News2 {
+	degerler := map[Parametre]*float64{
+		ParametreSolunum:            olcum.Solunum,
+		ParametreSaturasyon:         olcum.Saturasyon,
+		ParametreSistolikKanBasinci: olcum.SistolikKanBasinci,
+		ParametreNabiz:              olcum.Nabiz,
+		ParametreAtes:               olcum.Ates,
+	}
+
+	sonuc := News2{Puanlar: make(map[Parametre]int, len(Parametreler))}
+	kirmizi := false
+	for _, p := range Parametreler {
+		deger := degerler[p]
+		if deger == nil {
+			sonuc.EksikParametreler = append(sonuc.EksikParametreler, p)
+			continue
+		}
+		puan := ParametrePuani(p, *deger)
+		sonuc.Puanlar[p] = puan
+		sonuc.ToplamPuan += puan
+		if puan == 3 {
+			kirmizi = true
+		}
+	}
+
+	switch {
+	case sonuc.ToplamPuan >= 7:
+		sonuc.RiskDuzeyi = RiskYuksek
+	case sonuc.ToplamPuan >= 5:
+		sonuc.RiskDuzeyi = RiskOrta
+	case kirmizi:
+		sonuc.RiskDuzeyi = RiskDusukOrta
+	default:
+		sonuc.RiskDuzeyi = RiskDusuk
+	}
+	return sonuc
+}
+
+// ParametrePuani scores a single parameter according to the NEWS2 chart.
+// Values are rounded to the chart's precision first: whole numbers, temperature to 0.1 °C.
+func ParametrePuani(p Parametre, deger float64) int {
+	switch p {
+	case ParametreSolunum:
+		v := math.Round(deger)
+		switch {
+		case v <= 8:
+			return 3
+		case v <= 11:
+			return 1
+		case v <= 20:
+			return 0
+		case v <= 24:
+			return 2
+		default:
+			return 3
+		}
+	case ParametreSaturasyon:
+		v := math.Round(deger)
+		switch {
+		case v <= 91:
+			return 3
+		case v <= 93:
+			return 2
+		case v <= 95:
+			return 1
+		default:
+			return 0
+		}
+	case ParametreSistolikKanBasinci:
+		v := math.Round(deger)
+		switch {
+		case v <= 90:
+			return 3
+		case v <= 100:
+			return 2
+		case v <= 110:
+			return 1
+		case v <= 219:
+			return 0
+		default:
+			return 3
+		}
+	case ParametreNabiz:
+		v := math.Round(deger)
+		switch {
+		case v <= 40:
+			return 3
+		case v <= 50:
+			return 1
+		case v <= 90:
+			return 0
+		case v <= 110:
+			return 1
+		case v <= 130:
+			return 2
+		default:
+			return 3
+		}
+	case ParametreAtes:
+		// Compare in tenths of a degree to avoid floating point edge cases
+		v := math.Round(deger * 10)
+		switch {
+		case v <= 350:
+			return 3
+		case v <= 360:
+			return 1
+		case v <= 380:
+			return 0
+		case v <= 390:
+			return 1
+		default:
+			return 2
+		}
+	}
+	return 0
+}
+
+// Egilim is the direction of the score between the last two measurements
+type Egilim string
+
+// Score trends
+const (
+	EgilimArtiyor  Egilim = "ARTIYOR"
+	EgilimAzaliyor Egilim = "AZALIYOR"
+	EgilimSabit    Egilim = "SABIT"
+	EgilimBelirsiz Egilim = "BELIRSIZ" // fewer than two measurements
+)
+
+// EgilimHesapla returns the trend and the change of the last score against the previous one.
+// puanlar must be ordered from oldest to newest.
+func EgilimHesapla(puanlar []int) (Egilim, int) {
+	if len(puanlar) < 2 {
+		return EgilimBelirsiz, 0
+	}
+	degisim := puanlar[len(puanlar)-1] - puanlar[len(puanlar)-2]
+	switch {
+	case degisim > 0:
+		return EgilimArtiyor, degisim
+	case degisim < 0:
+		return EgilimAzaliyor, degisim
+	default:
+		return EgilimSabit, 0
+	}
+}
@@ -0,0 +1,116 @@
+package scoring
+
+import (
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// Feature: news2-early-warning, Property 1: Score Is the Sum of the Chart
+// *For any* measurement, the NEWS2 total SHALL equal the sum of the parameter scores,
+// every missing parameter SHALL be reported, and the risk band SHALL follow the
+// total and single-parameter thresholds.
+
+func optionalValue(t *rapid.T, label string, min, max float64) *float64 {
+	if !rapid.Bool().Draw(t, label+"_var") {
+		return nil
+	}
+	v := rapid.Float64Range(min, max).Draw(t, label)
+	return &v
+}
+
+// TestProperty_ScoreIsSumOfChart tests Property 1
+func TestProperty_ScoreIsSumOfChart(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		olcum := Olcum{
+			Solunum:            optionalValue(rt, "solunum", 0, 60),
+			Saturasyon:         optionalValue(rt, "saturasyon", 60, 100),
+			SistolikKanBasinci: optionalValue(rt, "sistolik", 50, 260),
+			Nabiz:              optionalValue(rt, "nabiz", 20, 200),
+			Ates:               optionalValue(rt, "ates", 30, 43),
+		}
+		sonuc := Hesapla(olcum)
+
+		toplam, kirmizi := 0, false
+		for _, puan := range sonuc.Puanlar {
+			if puan < 0 || puan > 3 {
+				rt.Fatalf("Parameter score out of range: %d", puan)
+			}
+			toplam += puan
+			kirmizi = kirmizi || puan == 3
+		}
+		if toplam != sonuc.ToplamPuan {
+			rt.Fatalf("Total %d does not match parameter scores %v", sonuc.ToplamPuan, sonuc.Puanlar)
+		}
+		if len(sonuc.Puanlar)+len(sonuc.EksikParametreler) != len(Parametreler) {
+			rt.Fatalf("Scored %v and missing %v do not cover all parameters", sonuc.Puanlar, sonuc.EksikParametreler)
+		}
+
+		beklenen := RiskDusuk
+		switch {
+		case toplam >= 7:
+			beklenen = RiskYuksek
+		case toplam >= 5:
+			beklenen = RiskOrta
+		case kirmizi:
+			beklenen = RiskDusukOrta
+		}
+		if sonuc.RiskDuzeyi != beklenen {
+			rt.Fatalf("Expected risk %s for %v, got %s", beklenen, sonuc.Puanlar, sonuc.RiskDuzeyi)
+		}
+	})
+}
+
+// TestNews2_ChartBoundaries verifies the band edges of every parameter
+func TestNews2_ChartBoundaries(t *testing.T) {
+	tests := []struct {
+		p     Parametre
+		deger float64
+		puan  int
+	}{
+		{ParametreSolunum, 8, 3}, {ParametreSolunum, 9, 1}, {ParametreSolunum, 11, 1},
+		{ParametreSolunum, 12, 0}, {ParametreSolunum, 20, 0}, {ParametreSolunum, 21, 2},
+		{ParametreSolunum, 24, 2}, {ParametreSolunum, 25, 3},
+		{ParametreSaturasyon, 91, 3}, {ParametreSaturasyon, 92, 2}, {ParametreSaturasyon, 93, 2},
+		{ParametreSaturasyon, 94, 1}, {ParametreSaturasyon, 95, 1}, {ParametreSaturasyon, 96, 0},
+		{ParametreSistolikKanBasinci, 90, 3}, {ParametreSistolikKanBasinci, 91, 2},
+		{ParametreSistolikKanBasinci, 100, 2}, {ParametreSistolikKanBasinci, 101, 1},
+		{ParametreSistolikKanBasinci, 110, 1}, {ParametreSistolikKanBasinci, 111, 0},
+		{ParametreSistolikKanBasinci, 219, 0}, {ParametreSistolikKanBasinci, 220, 3},
+		{ParametreNabiz, 40, 3}, {ParametreNabiz, 41, 1}, {ParametreNabiz, 50, 1},
+		{ParametreNabiz, 51, 0}, {ParametreNabiz, 90, 0}, {ParametreNabiz, 91, 1},
+		{ParametreNabiz, 110, 1}, {ParametreNabiz, 111, 2}, {ParametreNabiz, 130, 2},
+		{ParametreNabiz, 131, 3},
+		{ParametreAtes, 35.0, 3}, {ParametreAtes, 35.1, 1}, {ParametreAtes, 36.0, 1},
+		{ParametreAtes, 36.1, 0}, {ParametreAtes, 38.0, 0}, {ParametreAtes, 38.1, 1},
+		{ParametreAtes, 39.0, 1}, {ParametreAtes, 39.1, 2},
+	}
+
+	for _, tt := range tests {
+		if got := ParametrePuani(tt.p, tt.deger); got != tt.puan {
+			t.Errorf("%s=%v: expected %d, got %d", tt.p, tt.deger, tt.puan, got)
+		}
+	}
+}
+
+// TestNews2_Trend verifies the trend between the last two measurements
+func TestNews2_Trend(t *testing.T) {
+	tests := []struct {
+		puanlar []int
+		egilim  Egilim
+		degisim int
+	}{
+		{nil, EgilimBelirsiz, 0},
+		{[]int{4}, EgilimBelirsiz, 0},
+		{[]int{1, 2, 5}, EgilimArtiyor, 3},
+		{[]int{7, 3}, EgilimAzaliyor, -4},
+		{[]int{9, 2, 2}, EgilimSabit, 0},
+	}
+
+	for _, tt := range tests {
+		egilim, degisim := EgilimHesapla(tt.puanlar)
+		if egilim != tt.egilim || degisim != tt.degisim {
+			t.Errorf("%v: expected %s %d, got %s %d", tt.puanlar, tt.egilim, tt.degisim, egilim, degisim)
+		}
+	}
+}
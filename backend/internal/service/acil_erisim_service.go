@@ -0,0 +1,170 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"medscreen/internal/utils"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// AcilErisimGerekceEnAz is the minimum length of a break-the-glass justification
+const AcilErisimGerekceEnAz = 20
+
+// Errors returned by AcilErisimService
+var (
+	ErrAcilErisimYetkisi   = errors.New("only a HEKIM or HEMSIRE may use emergency access")
+	ErrAcilErisimAktif     = errors.New("token is already an emergency access token")
+	ErrAcilErisimSebebi    = errors.New("invalid sebep_kodu")
+	ErrAcilErisimGerekcesi = fmt.Errorf("gerekce must be at least %d characters", AcilErisimGerekceEnAz)
+	ErrAcilErisimSuruyor   = errors.New("emergency access window has not ended yet")
+	ErrAcilErisimIncelendi = errors.New("emergency access has already been reviewed")
+	ErrKendiAcilErisimi    = errors.New("an emergency access cannot be reviewed by the personnel who used it")
+)
+
+// AcilErisimSonucu is the elevated token issued for a break-the-glass access.
+// No refresh token is issued; the access ends when the token expires.
+type AcilErisimSonucu struct {
+	AccessToken    string    `json:"token"`
+	TokenType      string    `json:"token_type"`
+	ExpiresIn      int64     `json:"expires_in"`
+	AcilErisimKodu string    `json:"acil_erisim_kodu"`
+	BitisZamani    time.Time `json:"bitis_zamani"`
+}
+
+type acilErisimService struct {
+	repo      repository.AcilErisimRepository
+	auditRepo repository.ErisimKaydiRepository
+	sure      time.Duration
+	now       func() time.Time
+}
+
+// NewAcilErisimService creates a new instance of AcilErisimService.
+// Grants last for sure; the reads made with them are taken from the access audit trail.
+func NewAcilErisimService(repo repository.AcilErisimRepository, auditRepo repository.ErisimKaydiRepository, sure time.Duration) AcilErisimService {
+	return &acilErisimService{
+		repo:      repo,
+		auditRepo: auditRepo,
+		sure:      sure,
+		now:       time.Now,
+	}
+}
+
+// Baslat issues a time-boxed token that overrides the authorization policy.
+// The grant is recorded for review before the token is handed out.
+func (s *acilErisimService) Baslat(accessClaims *utils.Claims, sebepKodu models.AcilErisimSebebi, gerekce string) (*AcilErisimSonucu, error) {
+	if accessClaims == nil {
+		return nil, ErrInvalidToken
+	}
+	if accessClaims.AcilErisimKodu != "" {
+		return nil, ErrAcilErisimAktif
+	}
+	if accessClaims.Role != string(models.GorevHekim) && accessClaims.Role != string(models.GorevHemsire) {
+		return nil, ErrAcilErisimYetkisi
+	}
+	if !sebepKodu.Valid() {
+		return nil, ErrAcilErisimSebebi
+	}
+	gerekce = strings.TrimSpace(gerekce)
+	if utf8.RuneCountInString(gerekce) < AcilErisimGerekceEnAz {
+		return nil, ErrAcilErisimGerekcesi
+	}
+
+	acilErisimKodu, err := utils.NewRandomID()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := *accessClaims
+	claims.AcilErisimKodu = acilErisimKodu
+	claims.TokenType = utils.TokenTypeAccess
+	accessToken, issued, err := utils.GenerateJWT(claims, s.sure)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenGeneration, err)
+	}
+
+	erisim := &models.AcilErisim{
+		AcilErisimKodu:  acilErisimKodu,
+		JTI:             issued.ID,
+		PersonelKodu:    accessClaims.PersonelKodu,
+		PersonelRolu:    accessClaims.Role,
+		SebepKodu:       sebepKodu,
+		Gerekce:         gerekce,
+		BaslangicZamani: issued.IssuedAt.Time,
+		BitisZamani:     issued.ExpiresAt.Time,
+	}
+	if accessClaims.NFCKartKodu != "" {
+		erisim.NFCKartKodu = &accessClaims.NFCKartKodu
+	}
+	if accessClaims.TabletCihazKodu != "" {
+		erisim.TabletCihazKodu = &accessClaims.TabletCihazKodu
+	}
+	// A grant that cannot be recorded must not be handed out
+	if err := s.repo.Create(erisim); err != nil {
+		return nil, err
+	}
+
+	return &AcilErisimSonucu{
+		AccessToken:    accessToken,
+		TokenType:      "Bearer",
+		ExpiresIn:      int64(s.sure.Seconds()),
+		AcilErisimKodu: acilErisimKodu,
+		BitisZamani:    erisim.BitisZamani,
+	}, nil
+}
+
+// GetAll retrieves grants, newest first; incelendi nil returns both reviewed and pending grants
+func (s *acilErisimService) GetAll(incelendi *bool, page, limit int) ([]models.AcilErisim, int64, error) {
+	return s.repo.FindByInceleme(incelendi, page, limit)
+}
+
+// GetByKodu retrieves a grant by its code
+func (s *acilErisimService) GetByKodu(acilErisimKodu string) (*models.AcilErisim, error) {
+	if acilErisimKodu == "" {
+		return nil, errors.New("acil_erisim_kodu is required")
+	}
+	return s.repo.FindByKodu(acilErisimKodu)
+}
+
+// GetErisimler retrieves the patient data accessed with a grant, newest first
+func (s *acilErisimService) GetErisimler(acilErisimKodu string, page, limit int) ([]models.ErisimKaydi, int64, error) {
+	if _, err := s.GetByKodu(acilErisimKodu); err != nil {
+		return nil, 0, err
+	}
+	return s.auditRepo.FindByFilter(repository.ErisimKaydiFiltresi{AcilErisimKodu: acilErisimKodu}, page, limit)
+}
+
+// Incele signs off a grant once its window has ended. Supervisors cannot sign off their own grants.
+func (s *acilErisimService) Incele(acilErisimKodu, inceleyenPersonelKodu, not string) (*models.AcilErisim, error) {
+	erisim, err := s.GetByKodu(acilErisimKodu)
+	if err != nil {
+		return nil, err
+	}
+	if erisim.IncelemeZamani != nil {
+		return nil, ErrAcilErisimIncelendi
+	}
+	if erisim.PersonelKodu == inceleyenPersonelKodu {
+		return nil, ErrKendiAcilErisimi
+	}
+	now := s.now()
+	if now.Before(erisim.BitisZamani) {
+		return nil, ErrAcilErisimSuruyor
+	}
+
+	inceleme := repository.AcilErisimIncelemesi{InceleyenPersonelKodu: inceleyenPersonelKodu, Zaman: now}
+	if not = strings.TrimSpace(not); not != "" {
+		inceleme.Not = &not
+	}
+	if err := s.repo.Incele(acilErisimKodu, inceleme); err != nil {
+		// Another supervisor signed it off in the meantime
+		return nil, ErrAcilErisimIncelendi
+	}
+
+	erisim.IncelemeZamani = &inceleme.Zaman
+	erisim.InceleyenPersonelKodu = &inceleme.InceleyenPersonelKodu
+	erisim.IncelemeNotu = inceleme.Not
+	return erisim, nil
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"errors"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"medscreen/internal/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"pgregory.net/rapid"
+)
+
+// Feature: break-the-glass, Property 1: Only Justified Clinical Grants Are Issued
+// *For any* role, reason and justification, Baslat SHALL issue a token only to a HEKIM or
+// HEMSIRE with a valid reason and a justification of at least AcilErisimGerekceEnAz
+// characters, and every issued token SHALL carry the code of a recorded grant.
+
+type mockAcilErisimRepository struct {
+	erisimler []models.AcilErisim
+}
+
+func (r *mockAcilErisimRepository) Create(erisim *models.AcilErisim) error {
+	erisim.AcilErisimID = uint(len(r.erisimler) + 1)
+	r.erisimler = append(r.erisimler, *erisim)
+	return nil
+}
+
+func (r *mockAcilErisimRepository) FindByKodu(kodu string) (*models.AcilErisim, error) {
+	for i := range r.erisimler {
+		if r.erisimler[i].AcilErisimKodu == kodu {
+			erisim := r.erisimler[i]
+			return &erisim, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *mockAcilErisimRepository) FindByInceleme(incelendi *bool, page, limit int) ([]models.AcilErisim, int64, error) {
+	var sonuc []models.AcilErisim
+	for _, erisim := range r.erisimler {
+		if incelendi == nil || *incelendi == (erisim.IncelemeZamani != nil) {
+			sonuc = append(sonuc, erisim)
+		}
+	}
+	return sonuc, int64(len(sonuc)), nil
+}
+
+func (r *mockAcilErisimRepository) Incele(kodu string, inceleme repository.AcilErisimIncelemesi) error {
+	for i := range r.erisimler {
+		if r.erisimler[i].AcilErisimKodu == kodu && r.erisimler[i].IncelemeZamani == nil {
+			r.erisimler[i].IncelemeZamani = &inceleme.Zaman
+			r.erisimler[i].InceleyenPersonelKodu = &inceleme.InceleyenPersonelKodu
+			r.erisimler[i].IncelemeNotu = inceleme.Not
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+type mockAcilErisimAuditRepository struct {
+	kayitlar []models.ErisimKaydi
+}
+
+func (r *mockAcilErisimAuditRepository) Create(kayitlar []models.ErisimKaydi) error {
+	r.kayitlar = append(r.kayitlar, kayitlar...)
+	return nil
+}
+
+func (r *mockAcilErisimAuditRepository) FindByFilter(filtre repository.ErisimKaydiFiltresi, page, limit int) ([]models.ErisimKaydi, int64, error) {
+	var sonuc []models.ErisimKaydi
+	for _, kayit := range r.kayitlar {
+		if filtre.Matches(&kayit) {
+			sonuc = append(sonuc, kayit)
+		}
+	}
+	return sonuc, int64(len(sonuc)), nil
+}
+
+const testGerekce = "Hasta acil serviste bilinçsiz getirildi"
+
+// TestProperty_OnlyJustifiedGrantsAreIssued tests Property 1
+func TestProperty_OnlyJustifiedGrantsAreIssued(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		rol := rapid.SampledFrom([]string{string(models.GorevHekim), string(models.GorevHemsire), "SEKRETER", "TEKNISYEN"}).Draw(rt, "rol")
+		sebep := models.AcilErisimSebebi(rapid.SampledFrom([]string{
+			string(models.AcilErisimHayatiTehlike), string(models.AcilErisimDiger), "", "MERAK",
+		}).Draw(rt, "sebep"))
+		gerekce := strings.Repeat("ş", rapid.IntRange(0, 40).Draw(rt, "uzunluk"))
+
+		repo := &mockAcilErisimRepository{}
+		svc := NewAcilErisimService(repo, &mockAcilErisimAuditRepository{}, 30*time.Minute)
+		sonuc, err := svc.Baslat(&utils.Claims{PersonelKodu: "P000001", Role: rol, TokenType: utils.TokenTypeAccess}, sebep, gerekce)
+
+		izinli := rol == string(models.GorevHekim) || rol == string(models.GorevHemsire)
+		gecerli := izinli && sebep.Valid() && len([]rune(gerekce)) >= AcilErisimGerekceEnAz
+		if !gecerli {
+			if err == nil || len(repo.erisimler) != 0 {
+				rt.Fatalf("Expected %s/%q/%d to be rejected without a grant, got %v", rol, sebep, len([]rune(gerekce)), err)
+			}
+			if !izinli && !errors.Is(err, ErrAcilErisimYetkisi) {
+				rt.Fatalf("Expected ErrAcilErisimYetkisi for %s, got %v", rol, err)
+			}
+			return
+		}
+		if err != nil {
+			rt.Fatalf("Unexpected error: %v", err)
+		}
+
+		claims, err := utils.ParseJWT(sonuc.AccessToken)
+		if err != nil || claims.AcilErisimKodu != sonuc.AcilErisimKodu || claims.TokenType != utils.TokenTypeAccess {
+			rt.Fatalf("Unexpected token claims: %+v, %v", claims, err)
+		}
+		if len(repo.erisimler) != 1 || repo.erisimler[0].AcilErisimKodu != sonuc.AcilErisimKodu || repo.erisimler[0].JTI != claims.ID {
+			rt.Fatalf("Expected the grant to be recorded, got %+v", repo.erisimler)
+		}
+	})
+}
+
+// TestAcilErisim_ReviewQueue verifies chaining, the accesses made under a grant and the sign-off rules
+func TestAcilErisim_ReviewQueue(t *testing.T) {
+	repo := &mockAcilErisimRepository{}
+	auditRepo := &mockAcilErisimAuditRepository{}
+	svc := NewAcilErisimService(repo, auditRepo, 30*time.Minute).(*acilErisimService)
+
+	sonuc, err := svc.Baslat(&utils.Claims{PersonelKodu: "P000001", Role: string(models.GorevHekim)}, models.AcilErisimHayatiTehlike, testGerekce)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	acilClaims, _ := utils.ParseJWT(sonuc.AccessToken)
+	if _, err := svc.Baslat(acilClaims, models.AcilErisimHayatiTehlike, testGerekce); !errors.Is(err, ErrAcilErisimAktif) {
+		t.Errorf("Expected ErrAcilErisimAktif for a chained grant, got %v", err)
+	}
+
+	kodu, baska := sonuc.AcilErisimKodu, "BASKA"
+	h1, h2, h3 := "H1", "H2", "H3"
+	auditRepo.kayitlar = []models.ErisimKaydi{
+		{PersonelKodu: "P000001", HastaKodu: &h1, AcilErisimKodu: &kodu},
+		{PersonelKodu: "P000001", HastaKodu: &h2},
+		{PersonelKodu: "P000001", HastaKodu: &h3, AcilErisimKodu: &baska},
+	}
+	kayitlar, total, err := svc.GetErisimler(kodu, 1, 10)
+	if err != nil || total != 1 || *kayitlar[0].HastaKodu != "H1" {
+		t.Errorf("Expected only the access made under the grant, got %+v, %v", kayitlar, err)
+	}
+	if _, _, err := svc.GetErisimler("YOK", 1, 10); err == nil {
+		t.Error("Expected an error for an unknown grant")
+	}
+
+	if _, err := svc.Incele(kodu, "P000009", ""); !errors.Is(err, ErrAcilErisimSuruyor) {
+		t.Errorf("Expected ErrAcilErisimSuruyor while the window is open, got %v", err)
+	}
+	svc.now = func() time.Time { return time.Now().Add(time.Hour) }
+	if _, err := svc.Incele(kodu, "P000001", ""); !errors.Is(err, ErrKendiAcilErisimi) {
+		t.Errorf("Expected ErrKendiAcilErisimi for a self-review, got %v", err)
+	}
+
+	erisim, err := svc.Incele(kodu, "P000009", " Uygun ")
+	if err != nil || erisim.IncelemeZamani == nil || *erisim.InceleyenPersonelKodu != "P000009" || *erisim.IncelemeNotu != "Uygun" {
+		t.Fatalf("Unexpected review: %+v, %v", erisim, err)
+	}
+	if _, err := svc.Incele(kodu, "P000008", ""); !errors.Is(err, ErrAcilErisimIncelendi) {
+		t.Errorf("Expected ErrAcilErisimIncelendi for a second review, got %v", err)
+	}
+
+	bekleyen := false
+	if erisimler, _, _ := svc.GetAll(&bekleyen, 1, 10); len(erisimler) != 0 {
+		t.Errorf("Expected an empty pending queue, got %d", len(erisimler))
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"medscreen/internal/repository"
 )
 
@@ -32,44 +33,8 @@ func (s *anlikYatanHastaService) GetByKodu(kodu string) (*models.AnlikYatanHasta
 	return inpatient, nil
 }
 
-// GetByYatakKodu retrieves current inpatients by bed code
-func (s *anlikYatanHastaService) GetByYatakKodu(yatakKodu string, page, limit int) ([]models.AnlikYatanHasta, int64, error) {
-	if yatakKodu == "" {
-		return nil, 0, errors.New("yatak_kodu is required")
-	}
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
-	}
-
-	return s.repo.FindByYatakKodu(yatakKodu, page, limit)
-}
-
-// GetByHastaKodu retrieves current inpatients by patient code
-func (s *anlikYatanHastaService) GetByHastaKodu(hastaKodu string, page, limit int) ([]models.AnlikYatanHasta, int64, error) {
-	if hastaKodu == "" {
-		return nil, 0, errors.New("hasta_kodu is required")
-	}
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
-	}
-
-	return s.repo.FindByHastaKodu(hastaKodu, page, limit)
-}
-
-// GetByBirimKodu retrieves current inpatients by unit code
-func (s *anlikYatanHastaService) GetByBirimKodu(birimKodu string, page, limit int) ([]models.AnlikYatanHasta, int64, error) {
-	if birimKodu == "" {
-		return nil, 0, errors.New("birim_kodu is required")
-	}
-
+// List retrieves current inpatients matching a list query
+func (s *anlikYatanHastaService) List(q query.Query, page, limit int) ([]models.AnlikYatanHasta, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -77,5 +42,5 @@ func (s *anlikYatanHastaService) GetByBirimKodu(birimKodu string, page, limit in
 		limit = 10
 	}
 
-	return s.repo.FindByBirimKodu(birimKodu, page, limit)
+	return s.repo.List(q, page, limit)
 }
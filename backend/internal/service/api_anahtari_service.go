@@ -0,0 +1,222 @@
+package service
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"medscreen/internal/utils"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIAnahtariOneki starts every API key, so leaked keys are easy to recognise in logs
+// and secret scanners. A key is "msk_<anahtar_kodu>_<secret>".
+const APIAnahtariOneki = "msk_"
+
+// APIAnahtariKullanimAraligi is how often the last use of a key is written; a busy
+// integration would otherwise update its row on every request
+const APIAnahtariKullanimAraligi = time.Minute
+
+// Errors returned by APIAnahtariService
+var (
+	ErrGecersizAPIAnahtari   = errors.New("invalid API key request")
+	ErrAPIAnahtariBulunamadi = errors.New("API key not found or already revoked")
+)
+
+// APIAnahtariIstegi describes a new API key
+type APIAnahtariIstegi struct {
+	Ad                  string     `json:"ad"`
+	Kapsamlar           []string   `json:"kapsamlar"`
+	IzinliIPler         []string   `json:"izinli_ipler"`
+	SonGecerlilikZamani *time.Time `json:"son_gecerlilik_zamani"`
+}
+
+// APIAnahtariSonucu is returned once when a key is created. The secret is not stored
+// and cannot be retrieved again.
+type APIAnahtariSonucu struct {
+	Anahtar     string             `json:"anahtar"`
+	APIAnahtari models.APIAnahtari `json:"api_anahtari"`
+}
+
+type apiAnahtariService struct {
+	repo      repository.APIAnahtariRepository
+	kapsamlar map[string]bool
+	now       func() time.Time
+}
+
+// NewAPIAnahtariService creates a new instance of APIAnahtariService.
+// kapsamlar lists the scopes a key may be given, e.g. "yatak:read".
+func NewAPIAnahtariService(repo repository.APIAnahtariRepository, kapsamlar []string) APIAnahtariService {
+	gecerli := make(map[string]bool, len(kapsamlar))
+	for _, kapsam := range kapsamlar {
+		gecerli[kapsam] = true
+	}
+	return &apiAnahtariService{
+		repo:      repo,
+		kapsamlar: gecerli,
+		now:       time.Now,
+	}
+}
+
+// Olustur creates an API key and returns its secret
+func (s *apiAnahtariService) Olustur(istek APIAnahtariIstegi, olusturan string) (*APIAnahtariSonucu, error) {
+	ad := strings.TrimSpace(istek.Ad)
+	if ad == "" {
+		return nil, fmt.Errorf("%w: ad is required", ErrGecersizAPIAnahtari)
+	}
+	if len(istek.Kapsamlar) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrGecersizAPIAnahtari)
+	}
+	kapsamlar := make([]string, 0, len(istek.Kapsamlar))
+	for _, kapsam := range istek.Kapsamlar {
+		if !s.kapsamlar[kapsam] {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrGecersizAPIAnahtari, kapsam)
+		}
+		if !slices.Contains(kapsamlar, kapsam) {
+			kapsamlar = append(kapsamlar, kapsam)
+		}
+	}
+	izinliIPler := make([]string, 0, len(istek.IzinliIPler))
+	for _, ip := range istek.IzinliIPler {
+		prefix, err := parseIzinliIP(ip)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid allowed IP %q", ErrGecersizAPIAnahtari, ip)
+		}
+		izinliIPler = append(izinliIPler, prefix.String())
+	}
+	now := s.now()
+	if istek.SonGecerlilikZamani != nil && !istek.SonGecerlilikZamani.After(now) {
+		return nil, fmt.Errorf("%w: son_gecerlilik_zamani must be in the future", ErrGecersizAPIAnahtari)
+	}
+
+	kodu, err := utils.NewRandomID()
+	if err != nil {
+		return nil, err
+	}
+	secret, err := yeniCihazAnahtari()
+	if err != nil {
+		return nil, err
+	}
+	anahtar := &models.APIAnahtari{
+		AnahtarKodu:           kodu,
+		Ad:                    ad,
+		AnahtarOzeti:          cihazAnahtariOzeti(secret),
+		Kapsamlar:             kapsamlar,
+		IzinliIPler:           izinliIPler,
+		SonGecerlilikZamani:   istek.SonGecerlilikZamani,
+		OlusturanPersonelKodu: olusturan,
+		OlusturmaZamani:       now,
+	}
+	if err := s.repo.Create(anahtar); err != nil {
+		return nil, err
+	}
+
+	return &APIAnahtariSonucu{
+		Anahtar:     APIAnahtariOneki + kodu + "_" + secret,
+		APIAnahtari: *anahtar,
+	}, nil
+}
+
+// GetAll lists every API key, including revoked and expired ones
+func (s *apiAnahtariService) GetAll() ([]models.APIAnahtari, error) {
+	return s.repo.FindAll()
+}
+
+// Iptal revokes an API key; it stops working immediately
+func (s *apiAnahtariService) Iptal(anahtarKodu, iptalEden string) error {
+	if err := s.repo.Iptal(anahtarKodu, iptalEden, s.now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAPIAnahtariBulunamadi
+		}
+		return err
+	}
+	return nil
+}
+
+// Dogrula returns the key for a presented secret, or nil if the key is unknown, revoked,
+// expired or used from an address it is not allowed from. An error means the keys could
+// not be checked. The last use is recorded at most once per APIAnahtariKullanimAraligi.
+func (s *apiAnahtariService) Dogrula(anahtar, ipAdresi string) (*models.APIAnahtari, error) {
+	rest, ok := strings.CutPrefix(anahtar, APIAnahtariOneki)
+	if !ok {
+		return nil, nil
+	}
+	kodu, secret, ok := strings.Cut(rest, "_")
+	if !ok || kodu == "" || secret == "" {
+		return nil, nil
+	}
+
+	kayit, err := s.repo.FindByKodu(kodu)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(kayit.AnahtarOzeti), []byte(cihazAnahtariOzeti(secret))) != 1 {
+		return nil, nil
+	}
+
+	now := s.now()
+	if kayit.IptalZamani != nil {
+		return nil, nil
+	}
+	if kayit.SonGecerlilikZamani != nil && !now.Before(*kayit.SonGecerlilikZamani) {
+		return nil, nil
+	}
+	if !izinliIP(kayit.IzinliIPler, ipAdresi) {
+		return nil, nil
+	}
+
+	if kayit.SonKullanilmaZamani == nil || now.Sub(*kayit.SonKullanilmaZamani) >= APIAnahtariKullanimAraligi ||
+		kayit.SonKullananIPAdresi == nil || *kayit.SonKullananIPAdresi != ipAdresi {
+		// Tracking is best effort; a failed update must not reject a valid key
+		if err := s.repo.UpdateKullanim(kodu, now, ipAdresi); err == nil {
+			kayit.SonKullanilmaZamani = &now
+			kayit.SonKullananIPAdresi = &ipAdresi
+		}
+	}
+	return kayit, nil
+}
+
+// izinliIP reports whether ipAdresi is covered by the allowlist; an empty allowlist allows any address
+func izinliIP(izinliIPler []string, ipAdresi string) bool {
+	if len(izinliIPler) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(ipAdresi)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, ip := range izinliIPler {
+		if prefix, err := parseIzinliIP(ip); err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIzinliIP parses an allowlist entry; a single address is treated as a full-length prefix
+func parseIzinliIP(ip string) (netip.Prefix, error) {
+	ip = strings.TrimSpace(ip)
+	if strings.Contains(ip, "/") {
+		prefix, err := netip.ParsePrefix(ip)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"medscreen/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"pgregory.net/rapid"
+)
+
+// Feature: api-keys, Property 1: API Keys Work Only As Issued
+// *For any* API key, Dogrula SHALL return the key exactly when the presented secret is the
+// one issued, the key is neither revoked nor expired and the address is in its allowlist
+// (if any); a rejected key SHALL never be returned, and the last use SHALL be written at
+// most once per APIAnahtariKullanimAraligi for the same address.
+
+type mockAPIAnahtariRepository struct {
+	anahtarlar       map[string]*models.APIAnahtari
+	guncellemeSayisi int
+}
+
+func newMockAPIAnahtariRepository() *mockAPIAnahtariRepository {
+	return &mockAPIAnahtariRepository{anahtarlar: make(map[string]*models.APIAnahtari)}
+}
+
+func (r *mockAPIAnahtariRepository) Create(anahtar *models.APIAnahtari) error {
+	anahtar.APIAnahtariID = uint(len(r.anahtarlar) + 1)
+	kopya := *anahtar
+	r.anahtarlar[anahtar.AnahtarKodu] = &kopya
+	return nil
+}
+
+func (r *mockAPIAnahtariRepository) FindByKodu(kodu string) (*models.APIAnahtari, error) {
+	if anahtar, ok := r.anahtarlar[kodu]; ok {
+		kopya := *anahtar
+		return &kopya, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *mockAPIAnahtariRepository) FindAll() ([]models.APIAnahtari, error) {
+	var anahtarlar []models.APIAnahtari
+	for _, anahtar := range r.anahtarlar {
+		anahtarlar = append(anahtarlar, *anahtar)
+	}
+	return anahtarlar, nil
+}
+
+func (r *mockAPIAnahtariRepository) UpdateKullanim(kodu string, zaman time.Time, ipAdresi string) error {
+	anahtar, ok := r.anahtarlar[kodu]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	r.guncellemeSayisi++
+	anahtar.SonKullanilmaZamani = &zaman
+	anahtar.SonKullananIPAdresi = &ipAdresi
+	return nil
+}
+
+func (r *mockAPIAnahtariRepository) Iptal(kodu, iptalEden string, zaman time.Time) error {
+	anahtar, ok := r.anahtarlar[kodu]
+	if !ok || anahtar.IptalZamani != nil {
+		return gorm.ErrRecordNotFound
+	}
+	anahtar.IptalZamani = &zaman
+	anahtar.IptalEdenPersonelKodu = &iptalEden
+	return nil
+}
+
+var testAPIKapsamlari = []string{"yatak:read", "basvuru-yemek:read", "hasta:read"}
+
+func TestProperty_APIAnahtariDogrulama(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		repo := newMockAPIAnahtariRepository()
+		svc := NewAPIAnahtariService(repo, testAPIKapsamlari).(*apiAnahtariService)
+		now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+		svc.now = func() time.Time { return now }
+
+		var izinliIPler []string
+		if rapid.Bool().Draw(t, "allowlist") {
+			izinliIPler = []string{"10.20.0.0/24", "192.168.1.7"}
+		}
+		var sonGecerlilik *time.Time
+		if rapid.Bool().Draw(t, "expires") {
+			bitis := now.Add(time.Duration(rapid.IntRange(1, 48).Draw(t, "lifetimeHours")) * time.Hour)
+			sonGecerlilik = &bitis
+		}
+		sonuc, err := svc.Olustur(APIAnahtariIstegi{
+			Ad:                  "Mutfak",
+			Kapsamlar:           rapid.SliceOfNDistinct(rapid.SampledFrom(testAPIKapsamlari), 1, 3, rapid.ID[string]).Draw(t, "scopes"),
+			IzinliIPler:         izinliIPler,
+			SonGecerlilikZamani: sonGecerlilik,
+		}, "ADMIN")
+		if err != nil {
+			t.Fatalf("Failed to create key: %v", err)
+		}
+		if !strings.HasPrefix(sonuc.Anahtar, APIAnahtariOneki+sonuc.APIAnahtari.AnahtarKodu+"_") {
+			t.Fatalf("Expected the key to carry its code, got %q", sonuc.Anahtar)
+		}
+		if repo.anahtarlar[sonuc.APIAnahtari.AnahtarKodu].AnahtarOzeti == sonuc.Anahtar {
+			t.Fatal("Expected only a digest of the key to be stored")
+		}
+
+		ip := rapid.SampledFrom([]string{"10.20.0.9", "10.20.1.9", "192.168.1.7", "::ffff:10.20.0.200", "2001:db8::1"}).Draw(t, "ip")
+		izinli := izinliIPler == nil || ip == "10.20.0.9" || ip == "192.168.1.7" || ip == "::ffff:10.20.0.200"
+
+		now = now.Add(time.Duration(rapid.IntRange(0, 72).Draw(t, "elapsedHours")) * time.Hour)
+		gecerli := sonGecerlilik == nil || now.Before(*sonGecerlilik)
+
+		iptal := rapid.Bool().Draw(t, "revoked")
+		if iptal {
+			if err := svc.Iptal(sonuc.APIAnahtari.AnahtarKodu, "ADMIN"); err != nil {
+				t.Fatalf("Failed to revoke key: %v", err)
+			}
+			if err := svc.Iptal(sonuc.APIAnahtari.AnahtarKodu, "ADMIN"); !errors.Is(err, ErrAPIAnahtariBulunamadi) {
+				t.Fatalf("Expected a second revocation to fail, got %v", err)
+			}
+		}
+
+		anahtar := sonuc.Anahtar
+		dogru := true
+		switch rapid.IntRange(0, 3).Draw(t, "tamper") {
+		case 1:
+			son := "A"
+			if strings.HasSuffix(anahtar, son) {
+				son = "B"
+			}
+			anahtar = anahtar[:len(anahtar)-1] + son
+			dogru = false
+		case 2:
+			anahtar = strings.TrimPrefix(anahtar, APIAnahtariOneki)
+			dogru = false
+		}
+
+		kayit, err := svc.Dogrula(anahtar, ip)
+		if err != nil {
+			t.Fatalf("Dogrula failed: %v", err)
+		}
+		beklenen := dogru && izinli && gecerli && !iptal
+		if (kayit != nil) != beklenen {
+			t.Fatalf("Key (correct %v, allowed ip %v, valid %v, revoked %v): expected accepted=%v, got %v",
+				dogru, izinli, gecerli, iptal, beklenen, kayit != nil)
+		}
+		if !beklenen {
+			if repo.guncellemeSayisi != 0 {
+				t.Fatal("Expected a rejected key not to record a use")
+			}
+			return
+		}
+
+		if kayit.SonKullanilmaZamani == nil || !kayit.SonKullanilmaZamani.Equal(now) || *kayit.SonKullananIPAdresi != ip {
+			t.Fatalf("Expected the use to be recorded, got %v from %v", kayit.SonKullanilmaZamani, kayit.SonKullananIPAdresi)
+		}
+		now = now.Add(time.Duration(rapid.IntRange(0, 59).Draw(t, "withinSeconds")) * time.Second)
+		if _, err := svc.Dogrula(anahtar, ip); err != nil {
+			t.Fatalf("Dogrula failed: %v", err)
+		}
+		if repo.guncellemeSayisi != 1 {
+			t.Fatalf("Expected a use within %v not to be written again, got %d writes", APIAnahtariKullanimAraligi, repo.guncellemeSayisi)
+		}
+	})
+}
+
+// TestAPIAnahtari_InvalidRequests checks that keys are only created with known scopes,
+// valid allowlists and a future expiry
+func TestAPIAnahtari_InvalidRequests(t *testing.T) {
+	svc := NewAPIAnahtariService(newMockAPIAnahtariRepository(), testAPIKapsamlari)
+	gecmis := time.Now().Add(-time.Hour)
+
+	cases := []APIAnahtariIstegi{
+		{Ad: "", Kapsamlar: []string{"yatak:read"}},
+		{Ad: "Pano"},
+		{Ad: "Pano", Kapsamlar: []string{"yatak:write"}},
+		{Ad: "Pano", Kapsamlar: []string{"klinik-seyir:read"}},
+		{Ad: "Pano", Kapsamlar: []string{"yatak:read"}, IzinliIPler: []string{"10.0.0.300"}},
+		{Ad: "Pano", Kapsamlar: []string{"yatak:read"}, IzinliIPler: []string{"10.0.0.0/40"}},
+		{Ad: "Pano", Kapsamlar: []string{"yatak:read"}, SonGecerlilikZamani: &gecmis},
+	}
+	for i, istek := range cases {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			if _, err := svc.Olustur(istek, "ADMIN"); !errors.Is(err, ErrGecersizAPIAnahtari) {
+				t.Errorf("Expected ErrGecersizAPIAnahtari for %+v, got %v", istek, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,551 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"medscreen/internal/utils"
+	"time"
+)
+
+// Errors returned by AuthService for tokens that must not be accepted
+var (
+	ErrInvalidToken    = errors.New("invalid or expired token")
+	ErrTokenRevoked    = errors.New("token has been revoked")
+	ErrTokenGeneration = errors.New("failed to generate token")
+)
+
+// Errors returned by AuthService for revoked personnel and NFC cards
+var (
+	ErrGirisEngelli   = errors.New("personnel or NFC card is blocked from logging in")
+	ErrGirisEngeliYok = errors.New("no login block to lift")
+)
+
+// Errors returned by AuthService.Escalate
+var (
+	ErrYatakKisitiYok    = errors.New("token is not bound to a bed")
+	ErrEskalasyonYetkisi = errors.New("only a HEKIM may lift the bed binding")
+)
+
+// AuthTokens is an access/refresh token pair issued to a personnel
+type AuthTokens struct {
+	AccessToken      string `json:"token"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"`
+}
+
+// NFCGirisSonucu is the result of a successful NFC login
+type NFCGirisSonucu struct {
+	*AuthTokens
+	Personel *models.Personel `json:"personel"`
+	NFCKart  *models.NFCKart  `json:"nfc_kart"`
+}
+
+// SSOGirisSonucu is the result of a successful login through the hospital identity provider
+type SSOGirisSonucu struct {
+	*AuthTokens
+	Personel *models.Personel `json:"personel"`
+}
+
+// EskalasyonSonucu is the access token issued when a HEKIM lifts the bed binding.
+// No refresh token is issued; refreshing returns to a bed-bound session.
+type EskalasyonSonucu struct {
+	AccessToken string `json:"token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	YatakKodu   string `json:"yatak_kodu"`
+}
+
+type authService struct {
+	personelService PersonelService
+	nfcKartRepo     repository.NFCKartRepository
+	tabletCihazRepo repository.TabletCihazRepository
+	tokenIptalRepo  repository.TokenIptalRepository
+	engelRepo       repository.GirisEngeliRepository
+	kaldirmaRepo    repository.YatakKisitiKaldirmaRepository
+	oturumService   OturumService
+	accessTTL       time.Duration
+	refreshTTL      time.Duration
+	now             func() time.Time
+}
+
+// NewAuthService creates a new instance of AuthService.
+// Card and personnel checks are delegated to PersonelService.AuthenticateByNFC;
+// every login opens a session in OturumService.
+func NewAuthService(
+	personelService PersonelService,
+	nfcKartRepo repository.NFCKartRepository,
+	tabletCihazRepo repository.TabletCihazRepository,
+	tokenIptalRepo repository.TokenIptalRepository,
+	engelRepo repository.GirisEngeliRepository,
+	kaldirmaRepo repository.YatakKisitiKaldirmaRepository,
+	oturumService OturumService,
+	accessTTL, refreshTTL time.Duration,
+) AuthService {
+	return &authService{
+		personelService: personelService,
+		nfcKartRepo:     nfcKartRepo,
+		tabletCihazRepo: tabletCihazRepo,
+		tokenIptalRepo:  tokenIptalRepo,
+		engelRepo:       engelRepo,
+		kaldirmaRepo:    kaldirmaRepo,
+		oturumService:   oturumService,
+		accessTTL:       accessTTL,
+		refreshTTL:      refreshTTL,
+		now:             time.Now,
+	}
+}
+
+// LoginWithNFC authenticates a card and issues tokens bound to the personnel and, if given, the tablet.
+// A tablet mounted at a bed binds the tokens to that bed.
+func (s *authService) LoginWithNFC(kartUID, tabletCihazKodu string) (*NFCGirisSonucu, error) {
+	personel, err := s.personelService.AuthenticateByNFC(kartUID)
+	if err != nil {
+		return nil, err
+	}
+
+	nfcKart, err := s.nfcKartRepo.FindByKartUID(kartUID)
+	if err != nil {
+		return nil, err
+	}
+	if nfcKart == nil {
+		return nil, errors.New("NFC card not found")
+	}
+	if err := s.engelKontrol(personel.PersonelKodu, nfcKart.NFCKartKodu); err != nil {
+		return nil, err
+	}
+
+	birimKodu, yatakKodu, err := s.tabletKonumu(tabletCihazKodu)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.openSession(utils.Claims{
+		PersonelKodu:    personel.PersonelKodu,
+		Role:            personel.PersonelGorevKodu,
+		TabletCihazKodu: tabletCihazKodu,
+		BirimKodu:       birimKodu,
+		YatakKodu:       yatakKodu,
+		NFCKartKodu:     nfcKart.NFCKartKodu,
+		GirisYontemi:    utils.GirisYontemiNFC,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &NFCGirisSonucu{AuthTokens: tokens, Personel: personel, NFCKart: nfcKart}, nil
+}
+
+// LoginWithSSO issues tokens to a personnel the hospital identity provider has identified.
+// The tokens carry no card, tablet, unit or bed, so unit-scoped roles see nothing with them.
+func (s *authService) LoginWithSSO(personelKodu string) (*SSOGirisSonucu, error) {
+	personel, err := s.personelService.AuthenticateByKodu(personelKodu)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.engelKontrol(personel.PersonelKodu, ""); err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.openSession(utils.Claims{
+		PersonelKodu: personel.PersonelKodu,
+		Role:         personel.PersonelGorevKodu,
+		GirisYontemi: utils.GirisYontemiSSO,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SSOGirisSonucu{AuthTokens: tokens, Personel: personel}, nil
+}
+
+// Refresh exchanges a refresh token for a new token pair.
+// The card (or, for SSO sessions, the personnel) is checked again and the used refresh token is revoked.
+func (s *authService) Refresh(refreshToken string) (*AuthTokens, error) {
+	if refreshToken == "" {
+		return nil, errors.New("refresh_token is required")
+	}
+
+	claims, err := utils.ParseJWT(refreshToken)
+	if err != nil || claims.TokenType != utils.TokenTypeRefresh {
+		return nil, ErrInvalidToken
+	}
+
+	revoked, err := s.IsRevoked(claims)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	personel, err := oturumSahibi(s.personelService, s.nfcKartRepo, claims)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.engelKontrol(personel.PersonelKodu, claims.NFCKartKodu); err != nil {
+		if errors.Is(err, ErrGirisEngelli) {
+			return nil, ErrTokenRevoked
+		}
+		return nil, err
+	}
+
+	// The tablet may have been moved to another ward or bed since the last login
+	birimKodu, yatakKodu, err := s.tabletKonumu(claims.TabletCihazKodu)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.revokeToken(claims, claims.PersonelKodu, "refresh token rotated"); err != nil {
+		return nil, err
+	}
+
+	yeni := utils.Claims{
+		PersonelKodu:    personel.PersonelKodu,
+		Role:            personel.PersonelGorevKodu,
+		TabletCihazKodu: claims.TabletCihazKodu,
+		BirimKodu:       birimKodu,
+		YatakKodu:       yatakKodu,
+		NFCKartKodu:     claims.NFCKartKodu,
+		GirisYontemi:    claims.GirisYontemi,
+		OturumKodu:      claims.OturumKodu,
+	}
+	if err := s.oturumService.Yenile(yeni, s.refreshTTL); err != nil {
+		if errors.Is(err, ErrOturumBulunamadi) {
+			return nil, ErrTokenRevoked
+		}
+		return nil, err
+	}
+	return s.issueTokens(yeni)
+}
+
+// Escalate issues an access token that is no longer limited to the patient of the tablet's
+// bed. The card and personnel are checked again, only a HEKIM may escalate, and every
+// escalation is recorded with its reason.
+func (s *authService) Escalate(accessClaims *utils.Claims, sebep string) (*EskalasyonSonucu, error) {
+	if accessClaims == nil {
+		return nil, ErrInvalidToken
+	}
+	if sebep == "" {
+		return nil, errors.New("sebep is required")
+	}
+	if accessClaims.YatakKodu == "" || accessClaims.YatakKisitiKaldirildi {
+		return nil, ErrYatakKisitiYok
+	}
+
+	nfcKart, err := s.nfcKartRepo.FindByKodu(accessClaims.NFCKartKodu)
+	if err != nil || nfcKart == nil {
+		return nil, ErrInvalidToken
+	}
+	personel, err := s.personelService.AuthenticateByNFC(nfcKart.KartUID)
+	if err != nil {
+		return nil, err
+	}
+	if personel.PersonelKodu != accessClaims.PersonelKodu || personel.PersonelGorevKodu != string(models.GorevHekim) {
+		return nil, ErrEskalasyonYetkisi
+	}
+
+	claims := *accessClaims
+	claims.Role = personel.PersonelGorevKodu
+	claims.YatakKisitiKaldirildi = true
+	claims.TokenType = utils.TokenTypeAccess
+	accessToken, issued, err := utils.GenerateJWT(claims, s.accessTTL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenGeneration, err)
+	}
+
+	if err := s.kaldirmaRepo.Create(&models.YatakKisitiKaldirma{
+		JTI:                 issued.ID,
+		PersonelKodu:        personel.PersonelKodu,
+		NFCKartKodu:         nfcKart.NFCKartKodu,
+		TabletCihazKodu:     accessClaims.TabletCihazKodu,
+		YatakKodu:           accessClaims.YatakKodu,
+		Sebep:               sebep,
+		KaldirmaZamani:      s.now(),
+		SonGecerlilikZamani: issued.ExpiresAt.Time,
+	}); err != nil {
+		// An escalation that cannot be recorded must not be granted
+		return nil, err
+	}
+
+	return &EskalasyonSonucu{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.accessTTL.Seconds()),
+		YatakKodu:   accessClaims.YatakKodu,
+	}, nil
+}
+
+// Logout ends the session of the current access token and revokes the token and, if given,
+// the refresh token issued with it
+func (s *authService) Logout(accessClaims *utils.Claims, refreshToken string) error {
+	if accessClaims == nil {
+		return ErrInvalidToken
+	}
+
+	if accessClaims.OturumKodu != "" {
+		if _, err := s.oturumService.Sonlandir(repository.OturumFiltresi{OturumKodu: accessClaims.OturumKodu}, accessClaims.PersonelKodu, OturumSonuCikis); err != nil {
+			return err
+		}
+	}
+	if err := s.revokeToken(accessClaims, accessClaims.PersonelKodu, "logout"); err != nil {
+		return err
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+	refreshClaims, err := utils.ParseJWT(refreshToken)
+	if err != nil {
+		// An expired or malformed refresh token cannot be used anyway
+		return nil
+	}
+	if refreshClaims.PersonelKodu != accessClaims.PersonelKodu {
+		return errors.New("refresh token belongs to another personnel")
+	}
+	return s.revokeToken(refreshClaims, accessClaims.PersonelKodu, "logout")
+}
+
+// LogoutEverywhere ends every session of the caller, on every tablet and browser, and
+// revokes all of their tokens issued so far
+func (s *authService) LogoutEverywhere(accessClaims *utils.Claims) error {
+	if accessClaims == nil {
+		return ErrInvalidToken
+	}
+	personelKodu := accessClaims.PersonelKodu
+	if _, err := s.oturumService.Sonlandir(repository.OturumFiltresi{PersonelKodu: personelKodu}, personelKodu, OturumSonuHerYerden); err != nil {
+		return err
+	}
+	return s.revokeAll(&models.TokenIptal{PersonelKodu: &personelKodu}, personelKodu, "logout everywhere")
+}
+
+// RevokePersonel ends every session of a personnel, revokes every token issued to them so
+// far and blocks them from logging in again until UnblockPersonel
+func (s *authService) RevokePersonel(personelKodu, iptalEden, sebep string) error {
+	if _, err := s.personelService.GetByKodu(personelKodu); err != nil {
+		return err
+	}
+	if err := s.engelle(&models.GirisEngeli{PersonelKodu: &personelKodu}, iptalEden, sebep); err != nil {
+		return err
+	}
+	if _, err := s.oturumService.Sonlandir(repository.OturumFiltresi{PersonelKodu: personelKodu}, iptalEden, sebep); err != nil {
+		return err
+	}
+	return s.revokeAll(&models.TokenIptal{PersonelKodu: &personelKodu}, iptalEden, sebep)
+}
+
+// UnblockPersonel lets a revoked personnel log in again
+func (s *authService) UnblockPersonel(personelKodu, kaldiran string) error {
+	return s.engelKaldir(personelKodu, "", kaldiran)
+}
+
+// RevokeOturum ends a single session; its tokens stop working immediately
+func (s *authService) RevokeOturum(oturumKodu, iptalEden, sebep string) error {
+	if oturumKodu == "" {
+		return errors.New("oturum_kodu is required")
+	}
+	return s.sonlandir(repository.OturumFiltresi{OturumKodu: oturumKodu}, iptalEden, sebep)
+}
+
+// RevokeTabletCihaz ends every session opened on a tablet, e.g. at the end of a shift
+func (s *authService) RevokeTabletCihaz(tabletCihazKodu, iptalEden, sebep string) error {
+	if tabletCihazKodu == "" {
+		return errors.New("tablet_cihaz_kodu is required")
+	}
+	return s.sonlandir(repository.OturumFiltresi{TabletCihazKodu: tabletCihazKodu}, iptalEden, sebep)
+}
+
+// RevokeNFCKart revokes every token issued through an NFC card so far, e.g. for a lost
+// badge, and blocks the card from logging in again until UnblockNFCKart
+func (s *authService) RevokeNFCKart(nfcKartKodu, iptalEden, sebep string) error {
+	if nfcKartKodu == "" {
+		return errors.New("nfc_kart_kodu is required")
+	}
+	nfcKart, err := s.nfcKartRepo.FindByKodu(nfcKartKodu)
+	if err != nil {
+		return err
+	}
+	if nfcKart == nil {
+		return errors.New("NFC card not found")
+	}
+	if err := s.engelle(&models.GirisEngeli{NFCKartKodu: &nfcKartKodu}, iptalEden, sebep); err != nil {
+		return err
+	}
+	return s.revokeAll(&models.TokenIptal{NFCKartKodu: &nfcKartKodu}, iptalEden, sebep)
+}
+
+// UnblockNFCKart lets a revoked NFC card log in again, e.g. after a lost badge was found
+func (s *authService) UnblockNFCKart(nfcKartKodu, kaldiran string) error {
+	return s.engelKaldir("", nfcKartKodu, kaldiran)
+}
+
+// IsRevoked checks the token against the revocation list, its session and its NFC card
+func (s *authService) IsRevoked(claims *utils.Claims) (bool, error) {
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	revoked, err := s.tokenIptalRepo.IsRevoked(claims.ID, claims.PersonelKodu, claims.NFCKartKodu, issuedAt)
+	if err != nil || revoked {
+		return revoked, err
+	}
+	aktif, err := s.oturumService.Aktif(claims)
+	if err != nil {
+		return false, err
+	}
+	return !aktif, nil
+}
+
+// engelKontrol refuses a login of a blocked personnel or NFC card
+func (s *authService) engelKontrol(personelKodu, nfcKartKodu string) error {
+	engelli, err := s.engelRepo.IsEngelli(personelKodu, nfcKartKodu)
+	if err != nil {
+		return err
+	}
+	if engelli {
+		return ErrGirisEngelli
+	}
+	return nil
+}
+
+// engelle blocks a personnel or an NFC card from logging in. The block is stored before the
+// tokens are revoked, so a login racing the revocation cannot get a token the revocation misses.
+func (s *authService) engelle(engel *models.GirisEngeli, engelleyen, sebep string) error {
+	engel.EngelZamani = s.now()
+	engel.EngelleyenPersonelKodu = engelleyen
+	if sebep != "" {
+		engel.Sebep = &sebep
+	}
+	return s.engelRepo.Create(engel)
+}
+
+func (s *authService) engelKaldir(personelKodu, nfcKartKodu, kaldiran string) error {
+	if personelKodu == "" && nfcKartKodu == "" {
+		return ErrGirisEngeliYok
+	}
+	sayi, err := s.engelRepo.Kaldir(personelKodu, nfcKartKodu, kaldiran, s.now())
+	if err != nil {
+		return err
+	}
+	if sayi == 0 {
+		return ErrGirisEngeliYok
+	}
+	return nil
+}
+
+// oturumSahibi checks again that the holder of a session may still use it: the card of an
+// NFC session must still be valid, the personnel of an SSO session must still be active
+func oturumSahibi(personelService PersonelService, nfcKartRepo repository.NFCKartRepository, claims *utils.Claims) (*models.Personel, error) {
+	if claims.GirisYontemi == utils.GirisYontemiSSO {
+		return personelService.AuthenticateByKodu(claims.PersonelKodu)
+	}
+	nfcKart, err := nfcKartRepo.FindByKodu(claims.NFCKartKodu)
+	if err != nil || nfcKart == nil {
+		return nil, ErrInvalidToken
+	}
+	return personelService.AuthenticateByNFC(nfcKart.KartUID)
+}
+
+// tabletKonumu verifies that the tablet the login comes from exists and is active,
+// and returns the bed it is mounted on and that bed's unit (birim_kodu).
+// Logins without a tablet carry neither.
+func (s *authService) tabletKonumu(tabletCihazKodu string) (birimKodu, yatakKodu string, err error) {
+	if tabletCihazKodu == "" {
+		return "", "", nil
+	}
+	tablet, err := s.tabletCihazRepo.FindByKodu(tabletCihazKodu)
+	if err != nil || tablet == nil {
+		return "", "", errors.New("tablet device not found")
+	}
+	if !tablet.AktiflikBilgisi {
+		return "", "", errors.New("tablet device is inactive")
+	}
+	if tablet.YatakKodu != nil {
+		yatakKodu = *tablet.YatakKodu
+	}
+	if tablet.Yatak != nil {
+		birimKodu = tablet.Yatak.BirimKodu
+	}
+	return birimKodu, yatakKodu, nil
+}
+
+// openSession starts a session for a login and issues its first token pair
+func (s *authService) openSession(claims utils.Claims) (*AuthTokens, error) {
+	oturumKodu, err := s.oturumService.Baslat(claims, s.refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+	claims.OturumKodu = oturumKodu
+	return s.issueTokens(claims)
+}
+
+// sonlandir ends the sessions matching the filter; it fails if none was active
+func (s *authService) sonlandir(filtre repository.OturumFiltresi, iptalEden, sebep string) error {
+	sayi, err := s.oturumService.Sonlandir(filtre, iptalEden, sebep)
+	if err != nil {
+		return err
+	}
+	if sayi == 0 {
+		return ErrOturumBulunamadi
+	}
+	return nil
+}
+
+// issueTokens signs an access and a refresh token with the same identity claims
+func (s *authService) issueTokens(claims utils.Claims) (*AuthTokens, error) {
+	claims.TokenType = utils.TokenTypeAccess
+	accessToken, _, err := utils.GenerateJWT(claims, s.accessTTL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenGeneration, err)
+	}
+
+	claims.TokenType = utils.TokenTypeRefresh
+	refreshToken, _, err := utils.GenerateJWT(claims, s.refreshTTL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenGeneration, err)
+	}
+
+	return &AuthTokens{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(s.accessTTL.Seconds()),
+		RefreshExpiresIn: int64(s.refreshTTL.Seconds()),
+	}, nil
+}
+
+// revokeToken adds a single token to the revocation list until it expires
+func (s *authService) revokeToken(claims *utils.Claims, iptalEden, sebep string) error {
+	jti := claims.ID
+	gecerlilik := s.now().Add(s.refreshTTL)
+	if claims.ExpiresAt != nil {
+		gecerlilik = claims.ExpiresAt.Time
+	}
+	return s.create(&models.TokenIptal{JTI: &jti, SonGecerlilikZamani: gecerlilik}, iptalEden, sebep)
+}
+
+// revokeAll adds a personnel- or card-wide entry covering every token issued before now.
+// No token outlives the refresh TTL, so the entry can be dropped after that.
+func (s *authService) revokeAll(iptal *models.TokenIptal, iptalEden, sebep string) error {
+	iptal.SonGecerlilikZamani = s.now().Add(s.refreshTTL)
+	return s.create(iptal, iptalEden, sebep)
+}
+
+func (s *authService) create(iptal *models.TokenIptal, iptalEden, sebep string) error {
+	now := s.now()
+	iptal.IptalZamani = now
+	iptal.IptalEdenPersonelKodu = iptalEden
+	if sebep != "" {
+		iptal.Sebep = &sebep
+	}
+	if err := s.tokenIptalRepo.Create(iptal); err != nil {
+		return err
+	}
+
+	// Best effort cleanup; stale entries only cost a little space
+	_, _ = s.tokenIptalRepo.DeleteExpired(now)
+	return nil
+}
@@ -0,0 +1,335 @@
+package service
+
+import (
+	"errors"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"medscreen/internal/utils"
+	"sync"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+)
+
+// Feature: nfc-auth, Property 1: Per-person Tokens and Revocation
+// *For any* active personnel logging in with an active, unexpired NFC card, the issued
+// tokens SHALL carry that personnel's code, role and tablet, every token SHALL have a
+// distinct jti, and revoking the card SHALL invalidate all tokens issued before it and
+// refuse new logins with the card until the block is lifted.
+
+// mockTokenIptalRepository is an in-memory TokenIptalRepository for testing
+type mockTokenIptalRepository struct {
+	mu      sync.Mutex
+	entries []models.TokenIptal
+}
+
+func (m *mockTokenIptalRepository) Create(iptal *models.TokenIptal) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, *iptal)
+	return nil
+}
+
+func (m *mockTokenIptalRepository) IsRevoked(jti, personelKodu, nfcKartKodu string, issuedAt time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.entries {
+		if e.JTI != nil {
+			if *e.JTI == jti {
+				return true, nil
+			}
+			continue
+		}
+		if e.IptalZamani.Before(issuedAt) {
+			continue
+		}
+		if (e.PersonelKodu != nil && *e.PersonelKodu == personelKodu) ||
+			(e.NFCKartKodu != nil && *e.NFCKartKodu == nfcKartKodu) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockTokenIptalRepository) DeleteExpired(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// mockGirisEngeliRepository is an in-memory GirisEngeliRepository for testing
+type mockGirisEngeliRepository struct {
+	mu       sync.Mutex
+	engeller []models.GirisEngeli
+}
+
+func (m *mockGirisEngeliRepository) Create(engel *models.GirisEngeli) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.engeller = append(m.engeller, *engel)
+	return nil
+}
+
+func (m *mockGirisEngeliRepository) IsEngelli(personelKodu, nfcKartKodu string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.engeller {
+		if e.KaldirmaZamani == nil && m.eslesir(e, personelKodu, nfcKartKodu) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockGirisEngeliRepository) Kaldir(personelKodu, nfcKartKodu, kaldiran string, zaman time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var sayi int64
+	for i := range m.engeller {
+		if m.engeller[i].KaldirmaZamani == nil && m.eslesir(m.engeller[i], personelKodu, nfcKartKodu) {
+			m.engeller[i].KaldirmaZamani = &zaman
+			m.engeller[i].KaldiranPersonelKodu = &kaldiran
+			sayi++
+		}
+	}
+	return sayi, nil
+}
+
+func (m *mockGirisEngeliRepository) eslesir(e models.GirisEngeli, personelKodu, nfcKartKodu string) bool {
+	return (e.PersonelKodu != nil && *e.PersonelKodu == personelKodu) ||
+		(e.NFCKartKodu != nil && *e.NFCKartKodu == nfcKartKodu)
+}
+
+// mockTabletCihazRepository is a mock implementation of TabletCihazRepository for testing
+type mockTabletCihazRepository struct {
+	repository.TabletCihazRepository
+	tablets map[string]*models.TabletCihaz
+}
+
+func (m *mockTabletCihazRepository) FindByKodu(kodu string) (*models.TabletCihaz, error) {
+	if tablet, ok := m.tablets[kodu]; ok {
+		return tablet, nil
+	}
+	return nil, nil
+}
+
+// mockYatakKisitiKaldirmaRepository is an in-memory YatakKisitiKaldirmaRepository for testing
+type mockYatakKisitiKaldirmaRepository struct {
+	kayitlar []models.YatakKisitiKaldirma
+}
+
+func (m *mockYatakKisitiKaldirmaRepository) Create(kaldirma *models.YatakKisitiKaldirma) error {
+	m.kayitlar = append(m.kayitlar, *kaldirma)
+	return nil
+}
+
+// newTestAuthService builds an AuthService over one active personnel with one active card
+func newTestAuthService(personelKodu, role, kartUID, nfcKartKodu string, sonKullanim *time.Time) AuthService {
+	return newTestAuthServiceWithLog(personelKodu, role, kartUID, nfcKartKodu, sonKullanim, &mockYatakKisitiKaldirmaRepository{})
+}
+
+func newTestAuthServiceWithLog(personelKodu, role, kartUID, nfcKartKodu string, sonKullanim *time.Time, kaldirmaRepo repository.YatakKisitiKaldirmaRepository) AuthService {
+	personelRepo := newMockPersonelRepository()
+	personelRepo.addPersonel(&models.Personel{
+		PersonelKodu:      personelKodu,
+		PersonelGorevKodu: role,
+		AktiflikBilgisi:   1,
+	})
+	nfcKartRepo := newMockNFCKartRepository()
+	nfcKartRepo.addKart(&models.NFCKart{
+		NFCKartKodu:       nfcKartKodu,
+		PersonelKodu:      personelKodu,
+		KartUID:           kartUID,
+		SonKullanimTarihi: sonKullanim,
+		AktiflikBilgisi:   1,
+	})
+	tabletRepo := &mockTabletCihazRepository{tablets: map[string]*models.TabletCihaz{
+		"TBL1": {TabletCihazKodu: "TBL1", AktiflikBilgisi: true},
+		"TBL2": {TabletCihazKodu: "TBL2", AktiflikBilgisi: false},
+		"TBL3": {TabletCihazKodu: "TBL3", AktiflikBilgisi: true, YatakKodu: &testYatakKodu, Yatak: &models.Yatak{YatakKodu: testYatakKodu, BirimKodu: "DAHILIYE"}},
+	}}
+	return NewAuthService(NewPersonelService(personelRepo, nfcKartRepo), nfcKartRepo, tabletRepo, &mockTokenIptalRepository{}, &mockGirisEngeliRepository{}, kaldirmaRepo, NewOturumService(newMockOturumRepository(), nfcKartRepo, time.Hour), 15*time.Minute, 12*time.Hour)
+}
+
+// testYatakKodu is the bed the TBL3 test tablet is mounted on
+var testYatakKodu = "Y-101"
+
+// TestProperty_PerPersonTokensAndRevocation tests Property 1
+func TestProperty_PerPersonTokensAndRevocation(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		personelKodu := generatePersonelKodu(t)
+		role := rapid.SampledFrom([]string{
+			string(models.GorevHekim),
+			string(models.GorevHemsire),
+			string(models.GorevDiger),
+		}).Draw(t, "role")
+		kartUID := generateKartUID(t)
+		nfcKartKodu := generateNFCKartKodu(t)
+		tablet := rapid.SampledFrom([]string{"", "TBL1"}).Draw(t, "tablet")
+
+		svc := newTestAuthService(personelKodu, role, kartUID, nfcKartKodu, nil)
+
+		sonuc, err := svc.LoginWithNFC(kartUID, tablet)
+		if err != nil {
+			t.Fatalf("Expected successful login but got error: %v", err)
+		}
+
+		access, err := utils.ParseJWT(sonuc.AccessToken)
+		if err != nil {
+			t.Fatalf("Access token does not parse: %v", err)
+		}
+		refresh, err := utils.ParseJWT(sonuc.RefreshToken)
+		if err != nil {
+			t.Fatalf("Refresh token does not parse: %v", err)
+		}
+
+		if access.PersonelKodu != personelKodu || access.Role != role || access.TabletCihazKodu != tablet {
+			t.Fatalf("Unexpected access claims: %+v", access)
+		}
+		if access.NFCKartKodu != nfcKartKodu {
+			t.Fatalf("Expected nfc_kart_kodu %s, got %s", nfcKartKodu, access.NFCKartKodu)
+		}
+		if access.TokenType != utils.TokenTypeAccess || refresh.TokenType != utils.TokenTypeRefresh {
+			t.Fatalf("Unexpected token types: %s / %s", access.TokenType, refresh.TokenType)
+		}
+		if access.ID == "" || access.ID == refresh.ID {
+			t.Fatalf("Expected distinct non-empty jti values, got %q and %q", access.ID, refresh.ID)
+		}
+		if !access.ExpiresAt.Before(refresh.ExpiresAt.Time) {
+			t.Fatal("Access token should expire before the refresh token")
+		}
+
+		if revoked, _ := svc.IsRevoked(access); revoked {
+			t.Fatal("Fresh access token should not be revoked")
+		}
+
+		// Lost badge: revoke the card
+		if err := svc.RevokeNFCKart(nfcKartKodu, "ADMIN", "lost badge"); err != nil {
+			t.Fatalf("Failed to revoke card: %v", err)
+		}
+		if revoked, _ := svc.IsRevoked(access); !revoked {
+			t.Fatal("Access token should be revoked after revoking the card")
+		}
+		if _, err := svc.Refresh(sonuc.RefreshToken); !errors.Is(err, ErrTokenRevoked) {
+			t.Fatalf("Expected ErrTokenRevoked on refresh, got %v", err)
+		}
+
+		// The revoked card cannot simply log in again
+		if _, err := svc.LoginWithNFC(kartUID, tablet); !errors.Is(err, ErrGirisEngelli) {
+			t.Fatalf("Expected ErrGirisEngelli on login with a revoked card, got %v", err)
+		}
+		if err := svc.UnblockNFCKart(nfcKartKodu, "ADMIN"); err != nil {
+			t.Fatalf("Failed to unblock card: %v", err)
+		}
+		if err := svc.UnblockNFCKart(nfcKartKodu, "ADMIN"); !errors.Is(err, ErrGirisEngeliYok) {
+			t.Fatalf("Expected ErrGirisEngeliYok on a second unblock, got %v", err)
+		}
+		if _, err := svc.LoginWithNFC(kartUID, tablet); err != nil {
+			t.Fatalf("Expected login after unblocking to succeed, got %v", err)
+		}
+	})
+}
+
+// TestAuth_RefreshRotation verifies that a refresh token can only be used once
+func TestAuth_RefreshRotation(t *testing.T) {
+	svc := newTestAuthService("P000001", string(models.GorevHemsire), "AABBCCDD", "NFC000001", nil)
+
+	sonuc, err := svc.LoginWithNFC("AABBCCDD", "TBL1")
+	if err != nil {
+		t.Fatalf("Expected successful login but got error: %v", err)
+	}
+
+	tokens, err := svc.Refresh(sonuc.RefreshToken)
+	if err != nil {
+		t.Fatalf("Expected successful refresh but got error: %v", err)
+	}
+	claims, err := utils.ParseJWT(tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("Refreshed access token does not parse: %v", err)
+	}
+	if claims.PersonelKodu != "P000001" || claims.TabletCihazKodu != "TBL1" {
+		t.Errorf("Refreshed token lost its identity: %+v", claims)
+	}
+
+	if _, err := svc.Refresh(sonuc.RefreshToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("Expected reused refresh token to be revoked, got %v", err)
+	}
+	if _, err := svc.Refresh(sonuc.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected access token to be rejected as refresh token, got %v", err)
+	}
+}
+
+// TestAuth_LoginRejections verifies that expired cards and inactive tablets cannot log in
+func TestAuth_LoginRejections(t *testing.T) {
+	gecmis := time.Now().Add(-time.Hour)
+	svc := newTestAuthService("P000002", string(models.GorevHekim), "11223344", "NFC000002", &gecmis)
+	if _, err := svc.LoginWithNFC("11223344", ""); err == nil {
+		t.Error("Expected login with an expired card to fail")
+	}
+
+	svc = newTestAuthService("P000003", string(models.GorevHekim), "55667788", "NFC000003", nil)
+	if _, err := svc.LoginWithNFC("55667788", "TBL2"); err == nil {
+		t.Error("Expected login from an inactive tablet to fail")
+	}
+	if _, err := svc.LoginWithNFC("55667788", "UNKNOWN"); err == nil {
+		t.Error("Expected login from an unknown tablet to fail")
+	}
+}
+
+// TestAuth_BedBindingAndEscalation verifies that bedside logins are bound to the bed and
+// that only a HEKIM can lift the binding, with every escalation recorded
+func TestAuth_BedBindingAndEscalation(t *testing.T) {
+	kaldirmaRepo := &mockYatakKisitiKaldirmaRepository{}
+	svc := newTestAuthServiceWithLog("P000004", string(models.GorevHekim), "99AABBCC", "NFC000004", nil, kaldirmaRepo)
+
+	sonuc, err := svc.LoginWithNFC("99AABBCC", "TBL3")
+	if err != nil {
+		t.Fatalf("Expected successful login but got error: %v", err)
+	}
+	claims, _ := utils.ParseJWT(sonuc.AccessToken)
+	if claims.YatakKodu != testYatakKodu || claims.BirimKodu != "DAHILIYE" || claims.YatakKisitiKaldirildi {
+		t.Fatalf("Expected a token bound to %s, got %+v", testYatakKodu, claims)
+	}
+
+	if _, err := svc.Escalate(claims, ""); err == nil {
+		t.Error("Expected an escalation without a reason to fail")
+	}
+	eskalasyon, err := svc.Escalate(claims, "Konsültasyon: önceki yatışlar incelenecek")
+	if err != nil {
+		t.Fatalf("Expected escalation to succeed, got %v", err)
+	}
+	yukseltilmis, err := utils.ParseJWT(eskalasyon.AccessToken)
+	if err != nil || !yukseltilmis.YatakKisitiKaldirildi || yukseltilmis.YatakKodu != testYatakKodu || yukseltilmis.ID == claims.ID {
+		t.Fatalf("Unexpected escalated token: %+v, %v", yukseltilmis, err)
+	}
+	if len(kaldirmaRepo.kayitlar) != 1 || kaldirmaRepo.kayitlar[0].JTI != yukseltilmis.ID || kaldirmaRepo.kayitlar[0].PersonelKodu != "P000004" {
+		t.Errorf("Expected the escalation to be recorded, got %+v", kaldirmaRepo.kayitlar)
+	}
+	if _, err := svc.Escalate(yukseltilmis, "tekrar"); !errors.Is(err, ErrYatakKisitiYok) {
+		t.Errorf("Expected ErrYatakKisitiYok for an escalated token, got %v", err)
+	}
+
+	// Refreshing returns to a bed-bound session
+	tokens, err := svc.Refresh(sonuc.RefreshToken)
+	if err != nil {
+		t.Fatalf("Expected successful refresh but got error: %v", err)
+	}
+	if refreshed, _ := utils.ParseJWT(tokens.AccessToken); refreshed.YatakKodu != testYatakKodu || refreshed.YatakKisitiKaldirildi {
+		t.Errorf("Expected the refreshed token to be bound to the bed, got %+v", refreshed)
+	}
+
+	hemsire := newTestAuthServiceWithLog("P000005", string(models.GorevHemsire), "DDEEFF00", "NFC000005", nil, kaldirmaRepo)
+	sonuc, _ = hemsire.LoginWithNFC("DDEEFF00", "TBL3")
+	claims, _ = utils.ParseJWT(sonuc.AccessToken)
+	if _, err := hemsire.Escalate(claims, "acil"); !errors.Is(err, ErrEskalasyonYetkisi) {
+		t.Errorf("Expected ErrEskalasyonYetkisi for a nurse, got %v", err)
+	}
+
+	sonuc, _ = hemsire.LoginWithNFC("DDEEFF00", "TBL1")
+	claims, _ = utils.ParseJWT(sonuc.AccessToken)
+	if _, err := hemsire.Escalate(claims, "acil"); !errors.Is(err, ErrYatakKisitiYok) {
+		t.Errorf("Expected ErrYatakKisitiYok for a tablet without a bed, got %v", err)
+	}
+	if len(kaldirmaRepo.kayitlar) != 1 {
+		t.Errorf("Expected rejected escalations not to be recorded, got %d records", len(kaldirmaRepo.kayitlar))
+	}
+}
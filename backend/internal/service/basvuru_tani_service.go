@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"medscreen/internal/repository"
 )
 
@@ -32,28 +33,8 @@ func (s *basvuruTaniService) GetByKodu(kodu string) (*models.BasvuruTani, error)
 	return tani, nil
 }
 
-// GetByHastaKodu retrieves diagnoses by patient code
-func (s *basvuruTaniService) GetByHastaKodu(hastaKodu string, page, limit int) ([]models.BasvuruTani, int64, error) {
-	if hastaKodu == "" {
-		return nil, 0, errors.New("hasta_kodu is required")
-	}
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
-	}
-
-	return s.repo.FindByHastaKodu(hastaKodu, page, limit)
-}
-
-// GetByBasvuruKodu retrieves diagnoses by patient visit code
-func (s *basvuruTaniService) GetByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.BasvuruTani, int64, error) {
-	if basvuruKodu == "" {
-		return nil, 0, errors.New("hasta_basvuru_kodu is required")
-	}
-
+// List retrieves diagnoses matching a list query
+func (s *basvuruTaniService) List(q query.Query, page, limit int) ([]models.BasvuruTani, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -61,5 +42,5 @@ func (s *basvuruTaniService) GetByBasvuruKodu(basvuruKodu string, page, limit in
 		limit = 10
 	}
 
-	return s.repo.FindByBasvuruKodu(basvuruKodu, page, limit)
+	return s.repo.List(q, page, limit)
 }
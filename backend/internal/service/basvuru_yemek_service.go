@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"medscreen/internal/repository"
 )
 
@@ -32,28 +33,8 @@ func (s *basvuruYemekService) GetByKodu(kodu string) (*models.BasvuruYemek, erro
 	return yemek, nil
 }
 
-// GetByBasvuruKodu retrieves meal information by patient visit code
-func (s *basvuruYemekService) GetByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.BasvuruYemek, int64, error) {
-	if basvuruKodu == "" {
-		return nil, 0, errors.New("hasta_basvuru_kodu is required")
-	}
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
-	}
-
-	return s.repo.FindByBasvuruKodu(basvuruKodu, page, limit)
-}
-
-// GetByTuru retrieves meal information by meal type
-func (s *basvuruYemekService) GetByTuru(yemekTuru string, page, limit int) ([]models.BasvuruYemek, int64, error) {
-	if yemekTuru == "" {
-		return nil, 0, errors.New("yemek_turu is required")
-	}
-
+// List retrieves meals matching a list query
+func (s *basvuruYemekService) List(q query.Query, page, limit int) ([]models.BasvuruYemek, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -61,5 +42,5 @@ func (s *basvuruYemekService) GetByTuru(yemekTuru string, page, limit int) ([]mo
 		limit = 10
 	}
 
-	return s.repo.FindByTuru(yemekTuru, page, limit)
+	return s.repo.List(q, page, limit)
 }
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCihazBulunamadi       = errors.New("tablet not found or inactive")
+	ErrCihazKaydiGecersiz    = errors.New("invalid serial number or enrollment code")
+	ErrCihazAnahtariGecersiz = errors.New("invalid device credential")
+)
+
+// CihazKayitKoduSonucu is returned once when an admin issues an enrollment code. The code
+// is not stored and cannot be retrieved again.
+type CihazKayitKoduSonucu struct {
+	TabletCihazKodu   string    `json:"tablet_cihaz_kodu"`
+	KayitKodu         string    `json:"kayit_kodu"`
+	SonKullanmaZamani time.Time `json:"son_kullanma_zamani"`
+}
+
+// CihazKayitSonucu is returned once when a tablet enrolls. The credential is not stored
+// and cannot be retrieved again.
+type CihazKayitSonucu struct {
+	TabletCihazKodu        string  `json:"tablet_cihaz_kodu"`
+	YatakKodu              *string `json:"yatak_kodu,omitempty"`
+	CihazAnahtari          string  `json:"cihaz_anahtari"`
+	NabizAraligiSaniye     int     `json:"nabiz_araligi_saniye"`
+	CevrimdisiSuresiSaniye int     `json:"cevrimdisi_suresi_saniye"`
+}
+
+// FiloSorunu is a problem of a tablet shown in the fleet status
+type FiloSorunu string
+
+const (
+	FiloSorunuKayitsiz   FiloSorunu = "KAYITSIZ"   // never enrolled
+	FiloSorunuCevrimdisi FiloSorunu = "CEVRIMDISI" // no heartbeat within the offline threshold
+	FiloSorunuAtanmamis  FiloSorunu = "ATANMAMIS"  // not assigned to a bed
+	FiloSorunuBosYatak   FiloSorunu = "BOS_YATAK"  // assigned to a bed without a current inpatient
+)
+
+// FiloDurumu is the status of a tablet in the fleet
+type FiloDurumu struct {
+	TabletCihazKodu  string       `json:"tablet_cihaz_kodu"`
+	SeriNumarasi     *string      `json:"seri_numarasi,omitempty"`
+	YatakKodu        *string      `json:"yatak_kodu,omitempty"`
+	BirimKodu        *string      `json:"birim_kodu,omitempty"`
+	Kayitli          bool         `json:"kayitli"`
+	Cevrimici        bool         `json:"cevrimici"`
+	SonGorulmeZamani *time.Time   `json:"son_gorulme_zamani,omitempty"`
+	IPAdresi         *string      `json:"ip_adresi,omitempty"`
+	UygulamaSurumu   *string      `json:"uygulama_surumu,omitempty"`
+	PilSeviyesi      *int         `json:"pil_seviyesi,omitempty"`
+	Sorunlar         []FiloSorunu `json:"sorunlar"`
+}
+
+type cihazService struct {
+	tabletRepo       repository.TabletCihazRepository
+	kayitRepo        repository.CihazKaydiRepository
+	kodRepo          repository.CihazKayitKoduRepository
+	yatanRepo        repository.AnlikYatanHastaRepository
+	nabizAraligi     time.Duration
+	cevrimdisiSuresi time.Duration
+	kodSuresi        time.Duration
+	now              func() time.Time
+}
+
+// NewCihazService creates a new instance of CihazService. Tablets are expected to send a
+// heartbeat every nabizAraligi and are shown offline after cevrimdisiSuresi without one.
+// Enrollment codes expire kodSuresi after they are issued.
+func NewCihazService(tabletRepo repository.TabletCihazRepository, kayitRepo repository.CihazKaydiRepository, kodRepo repository.CihazKayitKoduRepository, yatanRepo repository.AnlikYatanHastaRepository, nabizAraligi, cevrimdisiSuresi, kodSuresi time.Duration) CihazService {
+	return &cihazService{
+		tabletRepo:       tabletRepo,
+		kayitRepo:        kayitRepo,
+		kodRepo:          kodRepo,
+		yatanRepo:        yatanRepo,
+		nabizAraligi:     nabizAraligi,
+		cevrimdisiSuresi: cevrimdisiSuresi,
+		kodSuresi:        kodSuresi,
+		now:              time.Now,
+	}
+}
+
+// KayitKoduOlustur issues a one-time enrollment code for an active tablet. A code issued
+// before for the tablet stops working.
+func (s *cihazService) KayitKoduOlustur(tabletCihazKodu, olusturan string) (*CihazKayitKoduSonucu, error) {
+	if tabletCihazKodu == "" {
+		return nil, errors.New("tablet_cihaz_kodu is required")
+	}
+	tablet, err := s.tabletRepo.FindByKodu(tabletCihazKodu)
+	if err != nil || tablet == nil || !tablet.AktiflikBilgisi {
+		if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCihazBulunamadi
+		}
+		return nil, err
+	}
+
+	kod, err := yeniKayitKodu()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	kayitKodu := &models.CihazKayitKodu{
+		TabletCihazKodu:       tablet.TabletCihazKodu,
+		KodOzeti:              cihazAnahtariOzeti(normalizeKayitKodu(kod)),
+		OlusturanPersonelKodu: olusturan,
+		OlusturmaZamani:       now,
+		SonKullanmaZamani:     now.Add(s.kodSuresi),
+	}
+	if err := s.kodRepo.Save(kayitKodu); err != nil {
+		return nil, err
+	}
+	return &CihazKayitKoduSonucu{TabletCihazKodu: tablet.TabletCihazKodu, KayitKodu: kod, SonKullanmaZamani: kayitKodu.SonKullanmaZamani}, nil
+}
+
+// Register enrolls the active VEM tablet with the given serial number and issues its
+// credential. The tablet must present the one-time code an admin issued for it; the code
+// is used up and a previous enrollment of the tablet is replaced. Unknown or inactive
+// tablets and missing, wrong or expired codes all return ErrCihazKaydiGecersiz, so serial
+// numbers cannot be probed.
+func (s *cihazService) Register(seriNumarasi, kayitKodu, ipAdresi string) (*CihazKayitSonucu, error) {
+	if seriNumarasi == "" || kayitKodu == "" {
+		return nil, ErrCihazKaydiGecersiz
+	}
+
+	tablet, err := s.tabletRepo.FindBySeriNumarasi(seriNumarasi)
+	if err != nil || tablet == nil || !tablet.AktiflikBilgisi {
+		if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCihazKaydiGecersiz
+		}
+		return nil, err
+	}
+
+	now := s.now()
+	ok, err := s.kodRepo.Kullan(tablet.TabletCihazKodu, cihazAnahtariOzeti(normalizeKayitKodu(kayitKodu)), now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrCihazKaydiGecersiz
+	}
+
+	anahtar, err := yeniCihazAnahtari()
+	if err != nil {
+		return nil, err
+	}
+	kayit := &models.CihazKaydi{
+		TabletCihazKodu:  tablet.TabletCihazKodu,
+		SeriNumarasi:     seriNumarasi,
+		AnahtarOzeti:     cihazAnahtariOzeti(anahtar),
+		KayitZamani:      now,
+		SonGorulmeZamani: &now,
+	}
+	if ipAdresi != "" {
+		kayit.IPAdresi = &ipAdresi
+	}
+	if err := s.kayitRepo.Save(kayit); err != nil {
+		return nil, err
+	}
+
+	return &CihazKayitSonucu{
+		TabletCihazKodu:        tablet.TabletCihazKodu,
+		YatakKodu:              tablet.YatakKodu,
+		CihazAnahtari:          anahtar,
+		NabizAraligiSaniye:     int(s.nabizAraligi / time.Second),
+		CevrimdisiSuresiSaniye: int(s.cevrimdisiSuresi / time.Second),
+	}, nil
+}
+
+// Nabiz records a heartbeat of an enrolled tablet
+func (s *cihazService) Nabiz(tabletCihazKodu, anahtar string, nabiz repository.CihazNabzi) error {
+	if err := s.Dogrula(tabletCihazKodu, anahtar); err != nil {
+		return err
+	}
+	nabiz.Zaman = s.now()
+	return s.kayitRepo.UpdateNabiz(tabletCihazKodu, nabiz)
+}
+
+// Dogrula checks a device credential
+func (s *cihazService) Dogrula(tabletCihazKodu, anahtar string) error {
+	if tabletCihazKodu == "" || anahtar == "" {
+		return ErrCihazAnahtariGecersiz
+	}
+	kayit, err := s.kayitRepo.FindByTabletCihazKodu(tabletCihazKodu)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCihazAnahtariGecersiz
+		}
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(kayit.AnahtarOzeti), []byte(cihazAnahtariOzeti(anahtar))) != 1 {
+		return ErrCihazAnahtariGecersiz
+	}
+	return nil
+}
+
+// Iptal removes the enrollment of a tablet; its credential stops working immediately
+func (s *cihazService) Iptal(tabletCihazKodu string) error {
+	if tabletCihazKodu == "" {
+		return errors.New("tablet_cihaz_kodu is required")
+	}
+	return s.kayitRepo.Delete(tabletCihazKodu)
+}
+
+// GetFilo returns the status of every active tablet. With sorun empty only tablets with at
+// least one problem are listed; otherwise only tablets with that problem. tumu lists all.
+func (s *cihazService) GetFilo(sorun FiloSorunu, tumu bool) ([]FiloDurumu, error) {
+	var tabletler []models.TabletCihaz
+	for page := 1; ; page++ {
+		sayfa, total, err := s.tabletRepo.FindAll(page, 100)
+		if err != nil {
+			return nil, err
+		}
+		tabletler = append(tabletler, sayfa...)
+		if len(sayfa) == 0 || int64(len(tabletler)) >= total {
+			break
+		}
+	}
+
+	kayitlar, err := s.kayitRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	kayitByKodu := make(map[string]*models.CihazKaydi, len(kayitlar))
+	for i := range kayitlar {
+		kayitByKodu[kayitlar[i].TabletCihazKodu] = &kayitlar[i]
+	}
+
+	now := s.now()
+	dolu := make(map[string]bool)
+	filo := make([]FiloDurumu, 0)
+	for i := range tabletler {
+		tablet := &tabletler[i]
+		if !tablet.AktiflikBilgisi {
+			continue
+		}
+
+		durum := FiloDurumu{
+			TabletCihazKodu:  tablet.TabletCihazKodu,
+			SeriNumarasi:     tablet.SeriNumarasi,
+			YatakKodu:        tablet.YatakKodu,
+			SonGorulmeZamani: tablet.SonGorulmeZamani,
+			IPAdresi:         tablet.IPAdresi,
+			Sorunlar:         []FiloSorunu{},
+		}
+		if tablet.Yatak != nil {
+			durum.BirimKodu = &tablet.Yatak.BirimKodu
+		}
+
+		if kayit, ok := kayitByKodu[tablet.TabletCihazKodu]; ok {
+			durum.Kayitli = true
+			if kayit.SonGorulmeZamani != nil && (durum.SonGorulmeZamani == nil || kayit.SonGorulmeZamani.After(*durum.SonGorulmeZamani)) {
+				durum.SonGorulmeZamani = kayit.SonGorulmeZamani
+			}
+			if kayit.IPAdresi != nil {
+				durum.IPAdresi = kayit.IPAdresi
+			}
+			durum.UygulamaSurumu = kayit.UygulamaSurumu
+			durum.PilSeviyesi = kayit.PilSeviyesi
+		} else {
+			durum.Sorunlar = append(durum.Sorunlar, FiloSorunuKayitsiz)
+		}
+
+		durum.Cevrimici = durum.SonGorulmeZamani != nil && now.Sub(*durum.SonGorulmeZamani) <= s.cevrimdisiSuresi
+		if !durum.Cevrimici {
+			durum.Sorunlar = append(durum.Sorunlar, FiloSorunuCevrimdisi)
+		}
+
+		if tablet.YatakKodu == nil || *tablet.YatakKodu == "" {
+			durum.Sorunlar = append(durum.Sorunlar, FiloSorunuAtanmamis)
+		} else {
+			yatakKodu := *tablet.YatakKodu
+			hastaVar, ok := dolu[yatakKodu]
+			if !ok {
+				_, total, err := s.yatanRepo.FindByYatakKodu(yatakKodu, 1, 1)
+				if err != nil {
+					return nil, err
+				}
+				hastaVar = total > 0
+				dolu[yatakKodu] = hastaVar
+			}
+			if !hastaVar {
+				durum.Sorunlar = append(durum.Sorunlar, FiloSorunuBosYatak)
+			}
+		}
+
+		if !tumu && !filoSorunuVar(durum.Sorunlar, sorun) {
+			continue
+		}
+		filo = append(filo, durum)
+	}
+	return filo, nil
+}
+
+// filoSorunuVar reports whether sorunlar contains sorun, or any problem if sorun is empty
+func filoSorunuVar(sorunlar []FiloSorunu, sorun FiloSorunu) bool {
+	if sorun == "" {
+		return len(sorunlar) > 0
+	}
+	for _, s := range sorunlar {
+		if s == sorun {
+			return true
+		}
+	}
+	return false
+}
+
+// yeniCihazAnahtari returns a random 256-bit credential
+func yeniCihazAnahtari() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// yeniKayitKodu returns a random 80-bit enrollment code in groups of four, e.g. ABCD-EFGH-JKLM-NPQR
+func yeniKayitKodu() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	kod := base32.StdEncoding.EncodeToString(b)
+	return kod[0:4] + "-" + kod[4:8] + "-" + kod[8:12] + "-" + kod[12:16], nil
+}
+
+// normalizeKayitKodu ignores the case, spaces and dashes of a code typed on the tablet
+func normalizeKayitKodu(kod string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(kod))
+}
+
+func cihazAnahtariOzeti(anahtar string) string {
+	ozet := sha256.Sum256([]byte(anahtar))
+	return hex.EncodeToString(ozet[:])
+}
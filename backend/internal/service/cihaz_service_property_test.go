@@ -0,0 +1,351 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"pgregory.net/rapid"
+)
+
+// Feature: tablet-fleet, Property 1: Fleet Problems Follow Heartbeats and Bed Assignment
+// *For any* set of tablets, enrollments and inpatients, GetFilo SHALL report a tablet as
+// KAYITSIZ exactly when it never enrolled, CEVRIMDISI exactly when it was not seen within
+// the offline threshold, ATANMAMIS exactly when it has no bed and BOS_YATAK exactly when
+// its bed has no current inpatient; inactive tablets SHALL never be listed.
+
+type stubFiloTabletRepo struct {
+	repository.TabletCihazRepository
+	tabletler []models.TabletCihaz
+}
+
+func (r *stubFiloTabletRepo) FindAll(page, limit int) ([]models.TabletCihaz, int64, error) {
+	total := int64(len(r.tabletler))
+	start := (page - 1) * limit
+	if start >= len(r.tabletler) {
+		return nil, total, nil
+	}
+	end := start + limit
+	if end > len(r.tabletler) {
+		end = len(r.tabletler)
+	}
+	return r.tabletler[start:end], total, nil
+}
+
+func (r *stubFiloTabletRepo) FindByKodu(kodu string) (*models.TabletCihaz, error) {
+	for i := range r.tabletler {
+		if r.tabletler[i].TabletCihazKodu == kodu {
+			return &r.tabletler[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *stubFiloTabletRepo) FindBySeriNumarasi(seriNumarasi string) (*models.TabletCihaz, error) {
+	for i := range r.tabletler {
+		if r.tabletler[i].SeriNumarasi != nil && *r.tabletler[i].SeriNumarasi == seriNumarasi {
+			return &r.tabletler[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type stubFiloYatanRepo struct {
+	repository.AnlikYatanHastaRepository
+	doluYataklar map[string]bool
+}
+
+func (r *stubFiloYatanRepo) FindByYatakKodu(yatakKodu string, page, limit int) ([]models.AnlikYatanHasta, int64, error) {
+	if r.doluYataklar[yatakKodu] {
+		return []models.AnlikYatanHasta{{YatakKodu: yatakKodu}}, 1, nil
+	}
+	return nil, 0, nil
+}
+
+type mockCihazKaydiRepository struct {
+	kayitlar map[string]*models.CihazKaydi
+}
+
+func (r *mockCihazKaydiRepository) Save(kayit *models.CihazKaydi) error {
+	kayit.CihazKaydiID = uint(len(r.kayitlar) + 1)
+	r.kayitlar[kayit.TabletCihazKodu] = kayit
+	return nil
+}
+
+func (r *mockCihazKaydiRepository) FindByTabletCihazKodu(kodu string) (*models.CihazKaydi, error) {
+	if kayit, ok := r.kayitlar[kodu]; ok {
+		return kayit, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *mockCihazKaydiRepository) FindAll() ([]models.CihazKaydi, error) {
+	var kayitlar []models.CihazKaydi
+	for _, kayit := range r.kayitlar {
+		kayitlar = append(kayitlar, *kayit)
+	}
+	return kayitlar, nil
+}
+
+func (r *mockCihazKaydiRepository) UpdateNabiz(kodu string, nabiz repository.CihazNabzi) error {
+	kayit, ok := r.kayitlar[kodu]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	zaman := nabiz.Zaman
+	kayit.SonGorulmeZamani = &zaman
+	kayit.IPAdresi = &nabiz.IPAdresi
+	kayit.UygulamaSurumu = nabiz.UygulamaSurumu
+	kayit.PilSeviyesi = nabiz.PilSeviyesi
+	return nil
+}
+
+func (r *mockCihazKaydiRepository) Delete(kodu string) error {
+	if _, ok := r.kayitlar[kodu]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(r.kayitlar, kodu)
+	return nil
+}
+
+func newMockCihazKaydiRepository() *mockCihazKaydiRepository {
+	return &mockCihazKaydiRepository{kayitlar: make(map[string]*models.CihazKaydi)}
+}
+
+type mockCihazKayitKoduRepository struct {
+	kodlar map[string]models.CihazKayitKodu
+}
+
+func (r *mockCihazKayitKoduRepository) Save(kod *models.CihazKayitKodu) error {
+	r.kodlar[kod.TabletCihazKodu] = *kod
+	return nil
+}
+
+func (r *mockCihazKayitKoduRepository) Kullan(kodu, kodOzeti string, now time.Time) (bool, error) {
+	kod, ok := r.kodlar[kodu]
+	if !ok || kod.KodOzeti != kodOzeti || !kod.SonKullanmaZamani.After(now) {
+		return false, nil
+	}
+	delete(r.kodlar, kodu)
+	return true, nil
+}
+
+func newMockCihazKayitKoduRepository() *mockCihazKayitKoduRepository {
+	return &mockCihazKayitKoduRepository{kodlar: make(map[string]models.CihazKayitKodu)}
+}
+
+// TestProperty_FleetProblems tests Property 1
+func TestProperty_FleetProblems(t *testing.T) {
+	simdi := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	cevrimdisiSuresi := 5 * time.Minute
+
+	rapid.Check(t, func(rt *rapid.T) {
+		tabletRepo := &stubFiloTabletRepo{}
+		kayitRepo := newMockCihazKaydiRepository()
+		yatanRepo := &stubFiloYatanRepo{doluYataklar: make(map[string]bool)}
+		beklenen := make(map[string][]FiloSorunu)
+
+		tabletSayisi := rapid.IntRange(0, 150).Draw(rt, "tabletSayisi")
+		for i := 0; i < tabletSayisi; i++ {
+			tablet := models.TabletCihaz{
+				TabletCihazKodu: fmt.Sprintf("T%03d", i),
+				AktiflikBilgisi: rapid.IntRange(0, 9).Draw(rt, "aktif") > 0,
+			}
+			sorunlar := []FiloSorunu{}
+
+			kayitli := rapid.Bool().Draw(rt, "kayitli")
+			if !kayitli {
+				sorunlar = append(sorunlar, FiloSorunuKayitsiz)
+			}
+			// Minutes since the tablet was last seen; -1 means never
+			dakika := rapid.IntRange(-1, 10).Draw(rt, "dakika")
+			if dakika >= 0 {
+				gorulme := simdi.Add(-time.Duration(dakika) * time.Minute)
+				if kayitli && rapid.Bool().Draw(rt, "nabizMedScreen") {
+					kayitRepo.kayitlar[tablet.TabletCihazKodu] = &models.CihazKaydi{TabletCihazKodu: tablet.TabletCihazKodu, SonGorulmeZamani: &gorulme}
+				} else {
+					tablet.SonGorulmeZamani = &gorulme
+				}
+			}
+			if kayitli && kayitRepo.kayitlar[tablet.TabletCihazKodu] == nil {
+				kayitRepo.kayitlar[tablet.TabletCihazKodu] = &models.CihazKaydi{TabletCihazKodu: tablet.TabletCihazKodu}
+			}
+			if dakika < 0 || time.Duration(dakika)*time.Minute > cevrimdisiSuresi {
+				sorunlar = append(sorunlar, FiloSorunuCevrimdisi)
+			}
+
+			switch rapid.IntRange(0, 2).Draw(rt, "yatak") {
+			case 0:
+				sorunlar = append(sorunlar, FiloSorunuAtanmamis)
+			case 1:
+				yatak := fmt.Sprintf("Y%02d", rapid.IntRange(0, 20).Draw(rt, "bosYatak"))
+				tablet.YatakKodu = &yatak
+			case 2:
+				yatak := fmt.Sprintf("D%02d", rapid.IntRange(0, 20).Draw(rt, "doluYatak"))
+				tablet.YatakKodu = &yatak
+				yatanRepo.doluYataklar[yatak] = true
+			}
+			if tablet.YatakKodu != nil && (*tablet.YatakKodu)[0] == 'Y' {
+				sorunlar = append(sorunlar, FiloSorunuBosYatak)
+			}
+
+			tabletRepo.tabletler = append(tabletRepo.tabletler, tablet)
+			if tablet.AktiflikBilgisi {
+				beklenen[tablet.TabletCihazKodu] = sorunlar
+			}
+		}
+
+		svc := NewCihazService(tabletRepo, kayitRepo, newMockCihazKayitKoduRepository(), yatanRepo, time.Minute, cevrimdisiSuresi, time.Hour).(*cihazService)
+		svc.now = func() time.Time { return simdi }
+
+		tumu, err := svc.GetFilo("", true)
+		if err != nil {
+			rt.Fatalf("Unexpected error: %v", err)
+		}
+		if len(tumu) != len(beklenen) {
+			rt.Fatalf("Expected %d active tablets, got %d", len(beklenen), len(tumu))
+		}
+		sorunlu := 0
+		for _, durum := range tumu {
+			sorunlar, ok := beklenen[durum.TabletCihazKodu]
+			if !ok {
+				rt.Fatalf("Inactive tablet listed: %s", durum.TabletCihazKodu)
+			}
+			if fmt.Sprint(durum.Sorunlar) != fmt.Sprint(sorunlar) {
+				rt.Fatalf("%s: expected %v, got %v", durum.TabletCihazKodu, sorunlar, durum.Sorunlar)
+			}
+			if durum.Cevrimici == filoSorunuVar(sorunlar, FiloSorunuCevrimdisi) {
+				rt.Fatalf("%s: online flag %v disagrees with %v", durum.TabletCihazKodu, durum.Cevrimici, sorunlar)
+			}
+			if len(sorunlar) > 0 {
+				sorunlu++
+			}
+		}
+
+		varsayilan, _ := svc.GetFilo("", false)
+		if len(varsayilan) != sorunlu {
+			rt.Fatalf("Expected %d tablets with problems, got %d", sorunlu, len(varsayilan))
+		}
+		bosYatak, _ := svc.GetFilo(FiloSorunuBosYatak, false)
+		for _, durum := range bosYatak {
+			if !filoSorunuVar(durum.Sorunlar, FiloSorunuBosYatak) {
+				rt.Fatalf("%s listed as BOS_YATAK with %v", durum.TabletCihazKodu, durum.Sorunlar)
+			}
+		}
+	})
+}
+
+// TestCihaz_EnrollmentAndHeartbeat verifies enrollment, credential checks and revocation
+func TestCihaz_EnrollmentAndHeartbeat(t *testing.T) {
+	seri, pasifSeri := "SN-001", "SN-002"
+	yatak := "Y01"
+	tabletRepo := &stubFiloTabletRepo{tabletler: []models.TabletCihaz{
+		{TabletCihazKodu: "T1", SeriNumarasi: &seri, YatakKodu: &yatak, AktiflikBilgisi: true},
+		{TabletCihazKodu: "T2", SeriNumarasi: &pasifSeri},
+	}}
+	kayitRepo := newMockCihazKaydiRepository()
+	kodRepo := newMockCihazKayitKoduRepository()
+	svc := NewCihazService(tabletRepo, kayitRepo, kodRepo, &stubFiloYatanRepo{}, time.Minute, 5*time.Minute, time.Hour)
+
+	kod, err := svc.KayitKoduOlustur("T1", "ADMIN1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if kodRepo.kodlar["T1"].KodOzeti == kod.KayitKodu || kodRepo.kodlar["T1"].OlusturanPersonelKodu != "ADMIN1" {
+		t.Errorf("Unexpected stored code: %+v", kodRepo.kodlar["T1"])
+	}
+	if _, err := svc.KayitKoduOlustur("T2", "ADMIN1"); !errors.Is(err, ErrCihazBulunamadi) {
+		t.Errorf("Expected ErrCihazBulunamadi for an inactive tablet, got %v", err)
+	}
+
+	sonuc, err := svc.Register(seri, strings.ToLower(kod.KayitKodu), "10.0.0.5")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if sonuc.TabletCihazKodu != "T1" || sonuc.YatakKodu == nil || *sonuc.YatakKodu != yatak || sonuc.CihazAnahtari == "" || sonuc.NabizAraligiSaniye != 60 {
+		t.Fatalf("Unexpected enrollment: %+v", sonuc)
+	}
+	if kayitRepo.kayitlar["T1"].AnahtarOzeti == sonuc.CihazAnahtari {
+		t.Error("The credential must not be stored in plain text")
+	}
+
+	pil := 42
+	if err := svc.Nabiz("T1", sonuc.CihazAnahtari, repository.CihazNabzi{IPAdresi: "10.0.0.7", PilSeviyesi: &pil}); err != nil {
+		t.Fatalf("Unexpected heartbeat error: %v", err)
+	}
+	if kayit := kayitRepo.kayitlar["T1"]; kayit.PilSeviyesi == nil || *kayit.PilSeviyesi != 42 || *kayit.IPAdresi != "10.0.0.7" {
+		t.Errorf("Heartbeat not recorded: %+v", kayit)
+	}
+	if err := svc.Nabiz("T1", "yanlis", repository.CihazNabzi{}); !errors.Is(err, ErrCihazAnahtariGecersiz) {
+		t.Errorf("Expected ErrCihazAnahtariGecersiz for a wrong credential, got %v", err)
+	}
+	if err := svc.Nabiz("T2", sonuc.CihazAnahtari, repository.CihazNabzi{}); !errors.Is(err, ErrCihazAnahtariGecersiz) {
+		t.Errorf("Expected ErrCihazAnahtariGecersiz for another tablet, got %v", err)
+	}
+
+	if err := svc.Iptal("T1"); err != nil {
+		t.Fatalf("Unexpected revoke error: %v", err)
+	}
+	if err := svc.Nabiz("T1", sonuc.CihazAnahtari, repository.CihazNabzi{}); !errors.Is(err, ErrCihazAnahtariGecersiz) {
+		t.Errorf("Expected the credential to stop working after revocation, got %v", err)
+	}
+}
+
+// TestCihaz_EnrollmentNeedsIssuedCode verifies that knowing a serial number is not enough to
+// enroll, that a code enrolls once, and that every refusal looks the same
+func TestCihaz_EnrollmentNeedsIssuedCode(t *testing.T) {
+	seri, pasifSeri := "SN-001", "SN-002"
+	tabletRepo := &stubFiloTabletRepo{tabletler: []models.TabletCihaz{
+		{TabletCihazKodu: "T1", SeriNumarasi: &seri, AktiflikBilgisi: true},
+		{TabletCihazKodu: "T2", SeriNumarasi: &pasifSeri},
+	}}
+	kayitRepo := newMockCihazKaydiRepository()
+	svc := NewCihazService(tabletRepo, kayitRepo, newMockCihazKayitKoduRepository(), &stubFiloYatanRepo{}, time.Minute, 5*time.Minute, time.Hour).(*cihazService)
+	simdi := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return simdi }
+
+	if _, err := svc.Register(seri, "", ""); !errors.Is(err, ErrCihazKaydiGecersiz) {
+		t.Errorf("Expected ErrCihazKaydiGecersiz without a code, got %v", err)
+	}
+	kod, err := svc.KayitKoduOlustur("T1", "ADMIN1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, girdi := range [][2]string{{seri, "AAAA-BBBB-CCCC-DDDD"}, {"SN-999", kod.KayitKodu}, {pasifSeri, kod.KayitKodu}} {
+		if _, err := svc.Register(girdi[0], girdi[1], ""); !errors.Is(err, ErrCihazKaydiGecersiz) {
+			t.Errorf("%v: expected ErrCihazKaydiGecersiz, got %v", girdi, err)
+		}
+	}
+
+	ilk, err := svc.Register(seri, kod.KayitKodu, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := svc.Register(seri, kod.KayitKodu, ""); !errors.Is(err, ErrCihazKaydiGecersiz) {
+		t.Errorf("Expected a used code to be refused like an unknown one, got %v", err)
+	}
+
+	// A new code replaces the enrollment and the credential issued with it
+	yeni, _ := svc.KayitKoduOlustur("T1", "ADMIN1")
+	ikinci, err := svc.Register(seri, yeni.KayitKodu, "")
+	if err != nil {
+		t.Fatalf("Unexpected re-enrollment error: %v", err)
+	}
+	if err := svc.Dogrula("T1", ilk.CihazAnahtari); !errors.Is(err, ErrCihazAnahtariGecersiz) {
+		t.Errorf("Expected the replaced credential to stop working, got %v", err)
+	}
+	if err := svc.Dogrula("T1", ikinci.CihazAnahtari); err != nil {
+		t.Errorf("Expected the new credential to work, got %v", err)
+	}
+
+	eski, _ := svc.KayitKoduOlustur("T1", "ADMIN1")
+	simdi = simdi.Add(time.Hour)
+	if _, err := svc.Register(seri, eski.KayitKodu, ""); !errors.Is(err, ErrCihazKaydiGecersiz) {
+		t.Errorf("Expected an expired code to be refused, got %v", err)
+	}
+}
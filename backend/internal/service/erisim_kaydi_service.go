@@ -0,0 +1,43 @@
+package service
+
+import (
+	"errors"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+)
+
+type erisimKaydiService struct {
+	repo repository.ErisimKaydiRepository
+}
+
+// NewErisimKaydiService creates a new instance of ErisimKaydiService
+func NewErisimKaydiService(repo repository.ErisimKaydiRepository) ErisimKaydiService {
+	return &erisimKaydiService{repo: repo}
+}
+
+// GetByHastaKodu retrieves who accessed a patient's data, newest first
+func (s *erisimKaydiService) GetByHastaKodu(hastaKodu string, page, limit int) ([]models.ErisimKaydi, int64, error) {
+	if hastaKodu == "" {
+		return nil, 0, errors.New("hasta_kodu is required")
+	}
+	return s.GetByFilter(repository.ErisimKaydiFiltresi{HastaKodu: hastaKodu}, page, limit)
+}
+
+// GetByFilter retrieves access records by patient, visit, personnel or emergency access grant with pagination
+func (s *erisimKaydiService) GetByFilter(filtre repository.ErisimKaydiFiltresi, page, limit int) ([]models.ErisimKaydi, int64, error) {
+	if filtre.HastaKodu == "" && filtre.HastaBasvuruKodu == "" && filtre.PersonelKodu == "" && filtre.AcilErisimKodu == "" {
+		return nil, 0, errors.New("hasta_kodu, hasta_basvuru_kodu, personel_kodu or acil_erisim_kodu is required")
+	}
+	if filtre.StartDate != nil && filtre.EndDate != nil && filtre.EndDate.Before(*filtre.StartDate) {
+		return nil, 0, errors.New("end_date must be after start_date")
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	return s.repo.FindByFilter(filtre, page, limit)
+}
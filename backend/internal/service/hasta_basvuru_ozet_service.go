@@ -0,0 +1,201 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Section names used as keys in HastaBasvuruOzet.Hatalar
+const (
+	OzetBolumAnlikYatanHasta = "anlik_yatan_hasta"
+	OzetBolumSonVitalBulgu   = "son_vital_bulgu"
+	OzetBolumAktifUyarilar   = "aktif_uyarilar"
+	OzetBolumAlerjiler       = "alerjiler"
+	OzetBolumBirincilTani    = "birincil_tani"
+	OzetBolumAcikOrderlar    = "acik_orderlar"
+	OzetBolumAktifReceteler  = "aktif_receteler"
+	OzetBolumSonRiskSkorlari = "son_risk_skorlari"
+	OzetBolumBugunkuYemekler = "bugunku_yemekler"
+)
+
+// HastaBasvuruOzet is the bedside summary of a single patient visit.
+// Sections that could not be loaded are left empty and reported in Hatalar.
+type HastaBasvuruOzet struct {
+	HastaBasvuru    *models.HastaBasvuru          `json:"hasta_basvuru"`
+	AnlikYatanHasta *models.AnlikYatanHasta       `json:"anlik_yatan_hasta,omitempty"`
+	SonVitalBulgu   *models.HastaVitalFizikiBulgu `json:"son_vital_bulgu,omitempty"`
+	AktifUyarilar   []models.HastaUyari           `json:"aktif_uyarilar"`
+	Alerjiler       []models.HastaTibbiBilgi      `json:"alerjiler"`
+	BirincilTani    *models.BasvuruTani           `json:"birincil_tani,omitempty"`
+	AcikOrderlar    []models.TibbiOrder           `json:"acik_orderlar"`
+	AktifReceteler  []models.Recete               `json:"aktif_receteler"`
+	SonRiskSkorlari []models.RiskSkorlama         `json:"son_risk_skorlari"`
+	BugunkuYemekler []models.BasvuruYemek         `json:"bugunku_yemekler"`
+	Hatalar         map[string]string             `json:"hatalar,omitempty"`
+}
+
+// HastaBasvuruOzetRepositories groups the repositories the summary service reads from
+type HastaBasvuruOzetRepositories struct {
+	HastaBasvuru          repository.HastaBasvuruRepository
+	AnlikYatanHasta       repository.AnlikYatanHastaRepository
+	HastaVitalFizikiBulgu repository.HastaVitalFizikiBulguRepository
+	HastaUyari            repository.HastaUyariRepository
+	HastaTibbiBilgi       repository.HastaTibbiBilgiRepository
+	BasvuruTani           repository.BasvuruTaniRepository
+	TibbiOrder            repository.TibbiOrderRepository
+	Recete                repository.ReceteRepository
+	RiskSkorlama          repository.RiskSkorlamaRepository
+	BasvuruYemek          repository.BasvuruYemekRepository
+}
+
+type hastaBasvuruOzetService struct {
+	repos HastaBasvuruOzetRepositories
+	now   func() time.Time
+}
+
+// NewHastaBasvuruOzetService creates a new instance of HastaBasvuruOzetService
+func NewHastaBasvuruOzetService(repos HastaBasvuruOzetRepositories) HastaBasvuruOzetService {
+	return &hastaBasvuruOzetService{repos: repos, now: time.Now}
+}
+
+// GetOzet retrieves the bedside summary of a patient visit.
+// The visit itself is loaded first; all other sections are loaded concurrently and a
+// failing section is recorded in Hatalar instead of failing the whole summary.
+func (s *hastaBasvuruOzetService) GetOzet(basvuruKodu string) (*HastaBasvuruOzet, error) {
+	if basvuruKodu == "" {
+		return nil, errors.New("hasta_basvuru_kodu is required")
+	}
+
+	basvuru, err := s.repos.HastaBasvuru.FindByKodu(basvuruKodu)
+	if isNotFound(err) || (err == nil && basvuru == nil) {
+		return nil, ErrBasvuruBulunamadi
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ozet := &HastaBasvuruOzet{
+		HastaBasvuru:    basvuru,
+		AktifUyarilar:   []models.HastaUyari{},
+		Alerjiler:       []models.HastaTibbiBilgi{},
+		AcikOrderlar:    []models.TibbiOrder{},
+		AktifReceteler:  []models.Recete{},
+		SonRiskSkorlari: []models.RiskSkorlama{},
+		BugunkuYemekler: []models.BasvuruYemek{},
+	}
+
+	now := s.now()
+	gunBasi := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	// Each loader writes only its own section, so only the error map needs locking
+	loaders := map[string]func() error{
+		OzetBolumAnlikYatanHasta: func() error {
+			yatanHasta, err := s.repos.AnlikYatanHasta.FindByBasvuruKodu(basvuruKodu)
+			if isNotFound(err) {
+				return nil
+			}
+			ozet.AnlikYatanHasta = yatanHasta
+			return err
+		},
+		OzetBolumSonVitalBulgu: func() error {
+			bulgu, err := s.repos.HastaVitalFizikiBulgu.FindSonByBasvuruKodu(basvuruKodu)
+			if isNotFound(err) {
+				return nil
+			}
+			ozet.SonVitalBulgu = bulgu
+			return err
+		},
+		OzetBolumAktifUyarilar: func() error {
+			uyarilar, err := s.repos.HastaUyari.FindAktifByBasvuruKodu(basvuruKodu)
+			if err == nil && uyarilar != nil {
+				ozet.AktifUyarilar = uyarilar
+			}
+			return err
+		},
+		OzetBolumAlerjiler: func() error {
+			alerjiler, err := s.repos.HastaTibbiBilgi.FindByHastaKoduAndTuru(basvuru.HastaKodu, string(models.TibbiBilgiAlerji))
+			if err == nil && alerjiler != nil {
+				ozet.Alerjiler = alerjiler
+			}
+			return err
+		},
+		OzetBolumBirincilTani: func() error {
+			tani, err := s.repos.BasvuruTani.FindBirincilByBasvuruKodu(basvuruKodu)
+			if isNotFound(err) {
+				return nil
+			}
+			ozet.BirincilTani = tani
+			return err
+		},
+		OzetBolumAcikOrderlar: func() error {
+			orderlar, err := s.repos.TibbiOrder.FindAcikByBasvuruKodu(basvuruKodu)
+			if err == nil && orderlar != nil {
+				ozet.AcikOrderlar = orderlar
+			}
+			return err
+		},
+		OzetBolumAktifReceteler: func() error {
+			receteler, err := s.repos.Recete.FindAktifByBasvuruKodu(basvuruKodu)
+			if err == nil && receteler != nil {
+				ozet.AktifReceteler = receteler
+			}
+			return err
+		},
+		OzetBolumSonRiskSkorlari: func() error {
+			skorlar, err := s.repos.RiskSkorlama.FindSonByBasvuruKodu(basvuruKodu)
+			if err == nil && skorlar != nil {
+				ozet.SonRiskSkorlari = skorlar
+			}
+			return err
+		},
+		OzetBolumBugunkuYemekler: func() error {
+			yemekler, err := s.repos.BasvuruYemek.FindByBasvuruKoduAndDateRange(basvuruKodu, gunBasi, gunBasi.AddDate(0, 0, 1))
+			if err == nil && yemekler != nil {
+				ozet.BugunkuYemekler = yemekler
+			}
+			return err
+		},
+	}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	fail := func(bolum string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if ozet.Hatalar == nil {
+			ozet.Hatalar = make(map[string]string)
+		}
+		ozet.Hatalar[bolum] = err.Error()
+	}
+	for bolum, load := range loaders {
+		wg.Add(1)
+		go func(bolum string, load func() error) {
+			defer wg.Done()
+			// A panicking section must not take the whole server down with it
+			defer func() {
+				if r := recover(); r != nil {
+					fail(bolum, fmt.Errorf("panic: %v", r))
+				}
+			}()
+			if err := load(); err != nil {
+				fail(bolum, err)
+			}
+		}(bolum, load)
+	}
+	wg.Wait()
+
+	return ozet, nil
+}
+
+// isNotFound reports whether err means that an optional single record does not exist
+func isNotFound(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound)
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"errors"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"pgregory.net/rapid"
+)
+
+// Feature: bedside-summary, Property 1: Partial Summary on Section Failure
+// *For any* subset of failing summary sections, GetOzet SHALL still return the visit,
+// populate every non-failing section, and report exactly the failing sections in Hatalar.
+
+var errOzetTest = errors.New("section unavailable")
+
+type stubOzetBasvuruRepo struct {
+	repository.HastaBasvuruRepository
+	basvuru *models.HastaBasvuru
+	err     error
+}
+
+func (r *stubOzetBasvuruRepo) FindByKodu(kodu string) (*models.HastaBasvuru, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.basvuru == nil || r.basvuru.HastaBasvuruKodu != kodu {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.basvuru, nil
+}
+
+type stubOzetYatanRepo struct {
+	repository.AnlikYatanHastaRepository
+	fail bool
+}
+
+func (r *stubOzetYatanRepo) FindByBasvuruKodu(basvuruKodu string) (*models.AnlikYatanHasta, error) {
+	if r.fail {
+		return nil, errOzetTest
+	}
+	return &models.AnlikYatanHasta{HastaBasvuruKodu: basvuruKodu, YatakKodu: "Y1"}, nil
+}
+
+type stubOzetVitalRepo struct {
+	repository.HastaVitalFizikiBulguRepository
+	fail bool
+}
+
+func (r *stubOzetVitalRepo) FindSonByBasvuruKodu(basvuruKodu string) (*models.HastaVitalFizikiBulgu, error) {
+	if r.fail {
+		return nil, errOzetTest
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type stubOzetUyariRepo struct {
+	repository.HastaUyariRepository
+	fail bool
+}
+
+func (r *stubOzetUyariRepo) FindAktifByBasvuruKodu(basvuruKodu string) ([]models.HastaUyari, error) {
+	if r.fail {
+		return nil, errOzetTest
+	}
+	return []models.HastaUyari{{HastaBasvuruKodu: basvuruKodu, AktiflikBilgisi: 1}}, nil
+}
+
+type stubOzetTibbiBilgiRepo struct {
+	repository.HastaTibbiBilgiRepository
+	fail bool
+}
+
+func (r *stubOzetTibbiBilgiRepo) FindByHastaKoduAndTuru(hastaKodu, turuKodu string) ([]models.HastaTibbiBilgi, error) {
+	if r.fail {
+		return nil, errOzetTest
+	}
+	return []models.HastaTibbiBilgi{{HastaKodu: hastaKodu, TibbiBilgiTuruKodu: turuKodu}}, nil
+}
+
+type stubOzetTaniRepo struct {
+	repository.BasvuruTaniRepository
+	fail bool
+}
+
+func (r *stubOzetTaniRepo) FindBirincilByBasvuruKodu(basvuruKodu string) (*models.BasvuruTani, error) {
+	if r.fail {
+		return nil, errOzetTest
+	}
+	return &models.BasvuruTani{HastaBasvuruKodu: basvuruKodu, BirincilTani: 1}, nil
+}
+
+type stubOzetOrderRepo struct {
+	repository.TibbiOrderRepository
+	fail bool
+}
+
+func (r *stubOzetOrderRepo) FindAcikByBasvuruKodu(basvuruKodu string) ([]models.TibbiOrder, error) {
+	if r.fail {
+		return nil, errOzetTest
+	}
+	return []models.TibbiOrder{{HastaBasvuruKodu: basvuruKodu}}, nil
+}
+
+type stubOzetReceteRepo struct {
+	repository.ReceteRepository
+	fail bool
+}
+
+func (r *stubOzetReceteRepo) FindAktifByBasvuruKodu(basvuruKodu string) ([]models.Recete, error) {
+	if r.fail {
+		return nil, errOzetTest
+	}
+	return []models.Recete{{HastaBasvuruKodu: basvuruKodu, AktiflikBilgisi: 1}}, nil
+}
+
+type stubOzetRiskRepo struct {
+	repository.RiskSkorlamaRepository
+	fail bool
+}
+
+func (r *stubOzetRiskRepo) FindSonByBasvuruKodu(basvuruKodu string) ([]models.RiskSkorlama, error) {
+	if r.fail {
+		return nil, errOzetTest
+	}
+	return []models.RiskSkorlama{{HastaBasvuruKodu: basvuruKodu}}, nil
+}
+
+type stubOzetYemekRepo struct {
+	repository.BasvuruYemekRepository
+	fail bool
+}
+
+func (r *stubOzetYemekRepo) FindByBasvuruKoduAndDateRange(basvuruKodu string, startDate, endDate time.Time) ([]models.BasvuruYemek, error) {
+	if r.fail {
+		return nil, errOzetTest
+	}
+	if !endDate.Equal(startDate.AddDate(0, 0, 1)) {
+		return nil, errors.New("expected a one day range")
+	}
+	return []models.BasvuruYemek{{HastaBasvuruKodu: basvuruKodu}}, nil
+}
+
+func TestProperty_PartialSummaryOnSectionFailure(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		basvuru := &models.HastaBasvuru{HastaBasvuruKodu: "HB1", HastaKodu: "H1"}
+		failing := map[string]bool{}
+		for _, bolum := range []string{
+			OzetBolumAnlikYatanHasta, OzetBolumSonVitalBulgu, OzetBolumAktifUyarilar,
+			OzetBolumAlerjiler, OzetBolumBirincilTani, OzetBolumAcikOrderlar,
+			OzetBolumAktifReceteler, OzetBolumSonRiskSkorlari, OzetBolumBugunkuYemekler,
+		} {
+			failing[bolum] = rapid.Bool().Draw(t, bolum)
+		}
+
+		svc := NewHastaBasvuruOzetService(HastaBasvuruOzetRepositories{
+			HastaBasvuru:          &stubOzetBasvuruRepo{basvuru: basvuru},
+			AnlikYatanHasta:       &stubOzetYatanRepo{fail: failing[OzetBolumAnlikYatanHasta]},
+			HastaVitalFizikiBulgu: &stubOzetVitalRepo{fail: failing[OzetBolumSonVitalBulgu]},
+			HastaUyari:            &stubOzetUyariRepo{fail: failing[OzetBolumAktifUyarilar]},
+			HastaTibbiBilgi:       &stubOzetTibbiBilgiRepo{fail: failing[OzetBolumAlerjiler]},
+			BasvuruTani:           &stubOzetTaniRepo{fail: failing[OzetBolumBirincilTani]},
+			TibbiOrder:            &stubOzetOrderRepo{fail: failing[OzetBolumAcikOrderlar]},
+			Recete:                &stubOzetReceteRepo{fail: failing[OzetBolumAktifReceteler]},
+			RiskSkorlama:          &stubOzetRiskRepo{fail: failing[OzetBolumSonRiskSkorlari]},
+			BasvuruYemek:          &stubOzetYemekRepo{fail: failing[OzetBolumBugunkuYemekler]},
+		})
+
+		ozet, err := svc.GetOzet("HB1")
+		if err != nil {
+			t.Fatalf("GetOzet returned error: %v", err)
+		}
+		if ozet.HastaBasvuru != basvuru {
+			t.Fatalf("summary does not carry the visit")
+		}
+
+		for bolum, fail := range failing {
+			_, reported := ozet.Hatalar[bolum]
+			if fail != reported {
+				t.Fatalf("section %s: failing=%v but reported=%v", bolum, fail, reported)
+			}
+		}
+
+		// A missing latest vital sign is not an error
+		if ozet.SonVitalBulgu != nil {
+			t.Fatalf("expected no latest vital sign")
+		}
+		if !failing[OzetBolumAnlikYatanHasta] && ozet.AnlikYatanHasta == nil {
+			t.Fatalf("current inpatient section should be populated")
+		}
+		if !failing[OzetBolumAlerjiler] && len(ozet.Alerjiler) != 1 {
+			t.Fatalf("allergy section should be populated")
+		}
+		if failing[OzetBolumAlerjiler] && ozet.Alerjiler == nil {
+			t.Fatalf("failed list sections should serialize as empty lists")
+		}
+		if !failing[OzetBolumBugunkuYemekler] && len(ozet.BugunkuYemekler) != 1 {
+			t.Fatalf("meal section should be populated")
+		}
+	})
+}
+
+// TestOzet_UnknownBasvuru verifies that a missing visit fails the whole summary
+func TestOzet_UnknownBasvuru(t *testing.T) {
+	svc := NewHastaBasvuruOzetService(HastaBasvuruOzetRepositories{
+		HastaBasvuru: &stubOzetBasvuruRepo{},
+	})
+	if _, err := svc.GetOzet("missing"); !errors.Is(err, ErrBasvuruBulunamadi) {
+		t.Fatalf("expected ErrBasvuruBulunamadi for unknown visit, got %v", err)
+	}
+	if _, err := svc.GetOzet(""); err == nil {
+		t.Fatal("expected error for empty visit code")
+	}
+}
+
+// TestOzet_VisitLookupFailure verifies that a failing visit lookup is not reported as a missing visit
+func TestOzet_VisitLookupFailure(t *testing.T) {
+	svc := NewHastaBasvuruOzetService(HastaBasvuruOzetRepositories{
+		HastaBasvuru: &stubOzetBasvuruRepo{err: errOzetTest},
+	})
+	if _, err := svc.GetOzet("B1"); !errors.Is(err, errOzetTest) || errors.Is(err, ErrBasvuruBulunamadi) {
+		t.Fatalf("expected the lookup error, got %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"medscreen/internal/repository"
 )
 
@@ -54,8 +55,8 @@ func (s *hastaService) GetByTCKimlik(tcKimlik string) (*models.Hasta, error) {
 	return hasta, nil
 }
 
-// GetAll retrieves all patients with pagination
-func (s *hastaService) GetAll(page, limit int) ([]models.Hasta, int64, error) {
+// List retrieves patients matching a list query
+func (s *hastaService) List(q query.Query, page, limit int) ([]models.Hasta, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -63,23 +64,7 @@ func (s *hastaService) GetAll(page, limit int) ([]models.Hasta, int64, error) {
 		limit = 10
 	}
 
-	return s.repo.FindAll(page, limit)
-}
-
-// SearchByAdSoyadi searches for patients by first name and/or last name
-func (s *hastaService) SearchByAdSoyadi(ad, soyadi string, page, limit int) ([]models.Hasta, int64, error) {
-	if ad == "" && soyadi == "" {
-		return nil, 0, errors.New("ad or soyadi is required for search")
-	}
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
-	}
-
-	return s.repo.SearchByAdSoyadi(ad, soyadi, page, limit)
+	return s.repo.List(q, page, limit)
 }
 
 // validateTCKimlik validates that the TC number is exactly 11 digits
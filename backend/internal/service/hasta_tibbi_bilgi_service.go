@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"medscreen/internal/repository"
 )
 
@@ -32,28 +33,8 @@ func (s *hastaTibbiBilgiService) GetByKodu(kodu string) (*models.HastaTibbiBilgi
 	return bilgi, nil
 }
 
-// GetByHastaKodu retrieves patient medical information by patient code
-func (s *hastaTibbiBilgiService) GetByHastaKodu(hastaKodu string, page, limit int) ([]models.HastaTibbiBilgi, int64, error) {
-	if hastaKodu == "" {
-		return nil, 0, errors.New("hasta_kodu is required")
-	}
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
-	}
-
-	return s.repo.FindByHastaKodu(hastaKodu, page, limit)
-}
-
-// GetByTuru retrieves patient medical information by type code
-func (s *hastaTibbiBilgiService) GetByTuru(turuKodu string, page, limit int) ([]models.HastaTibbiBilgi, int64, error) {
-	if turuKodu == "" {
-		return nil, 0, errors.New("tibbi_bilgi_turu_kodu is required")
-	}
-
+// List retrieves medical information matching a list query
+func (s *hastaTibbiBilgiService) List(q query.Query, page, limit int) ([]models.HastaTibbiBilgi, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -61,5 +42,5 @@ func (s *hastaTibbiBilgiService) GetByTuru(turuKodu string, page, limit int) ([]
 		limit = 10
 	}
 
-	return s.repo.FindByTuru(turuKodu, page, limit)
+	return s.repo.List(q, page, limit)
 }
@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"medscreen/internal/repository"
 )
 
@@ -32,12 +33,8 @@ func (s *hastaUyariService) GetByKodu(kodu string) (*models.HastaUyari, error) {
 	return uyari, nil
 }
 
-// GetByBasvuruKodu retrieves patient warnings by patient visit code
-func (s *hastaUyariService) GetByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.HastaUyari, int64, error) {
-	if basvuruKodu == "" {
-		return nil, 0, errors.New("hasta_basvuru_kodu is required")
-	}
-
+// List retrieves patient warnings matching a list query
+func (s *hastaUyariService) List(q query.Query, page, limit int) ([]models.HastaUyari, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -45,27 +42,5 @@ func (s *hastaUyariService) GetByBasvuruKodu(basvuruKodu string, page, limit int
 		limit = 10
 	}
 
-	return s.repo.FindByBasvuruKodu(basvuruKodu, page, limit)
-}
-
-// GetByFilters retrieves patient warnings by various filters
-func (s *hastaUyariService) GetByFilters(uyariTuru *string, aktiflik *int, page, limit int) ([]models.HastaUyari, int64, error) {
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
-	}
-
-	// If warning type filter is provided
-	if uyariTuru != nil && *uyariTuru != "" {
-		return s.repo.FindByTuru(*uyariTuru, page, limit)
-	}
-
-	// If active status filter is provided
-	if aktiflik != nil {
-		return s.repo.FindByAktiflik(*aktiflik, page, limit)
-	}
-
-	return nil, 0, errors.New("at least one filter (uyari_turu or aktiflik) is required")
+	return s.repo.List(q, page, limit)
 }
@@ -3,8 +3,8 @@ package service
 import (
 	"errors"
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"medscreen/internal/repository"
-	"time"
 )
 
 type hastaVitalFizikiBulguService struct {
@@ -33,28 +33,8 @@ func (s *hastaVitalFizikiBulguService) GetByKodu(kodu string) (*models.HastaVita
 	return bulgu, nil
 }
 
-// GetByBasvuruKodu retrieves vital signs by patient visit code
-func (s *hastaVitalFizikiBulguService) GetByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.HastaVitalFizikiBulgu, int64, error) {
-	if basvuruKodu == "" {
-		return nil, 0, errors.New("hasta_basvuru_kodu is required")
-	}
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
-	}
-
-	return s.repo.FindByBasvuruKodu(basvuruKodu, page, limit)
-}
-
-// GetByDateRange retrieves vital signs within a date range
-func (s *hastaVitalFizikiBulguService) GetByDateRange(startDate, endDate time.Time, page, limit int) ([]models.HastaVitalFizikiBulgu, int64, error) {
-	if startDate.After(endDate) {
-		return nil, 0, errors.New("start_date must be before end_date")
-	}
-
+// List retrieves vital signs matching a list query
+func (s *hastaVitalFizikiBulguService) List(q query.Query, page, limit int) ([]models.HastaVitalFizikiBulgu, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -62,5 +42,5 @@ func (s *hastaVitalFizikiBulguService) GetByDateRange(startDate, endDate time.Ti
 		limit = 10
 	}
 
-	return s.repo.FindByDateRange(startDate, endDate, page, limit)
+	return s.repo.List(q, page, limit)
 }
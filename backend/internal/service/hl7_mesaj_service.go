@@ -0,0 +1,37 @@
+package service
+
+import (
+	"errors"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"time"
+)
+
+// ErrHL7MesajDurumu is returned for a state that is not one of the outbox states
+var ErrHL7MesajDurumu = errors.New("durum must be one of BEKLIYOR, GONDERILDI, OLU")
+
+type hl7MesajService struct {
+	repo repository.HL7MesajRepository
+	now  func() time.Time
+}
+
+// NewHL7MesajService creates a new instance of HL7MesajService
+func NewHL7MesajService(repo repository.HL7MesajRepository) HL7MesajService {
+	return &hl7MesajService{repo: repo, now: time.Now}
+}
+
+// GetAll lists the messages of the HL7 feed in a state, oldest first
+func (s *hl7MesajService) GetAll(hedef string, durum models.HL7MesajDurumu, page, limit int) ([]models.HL7Mesaj, int64, error) {
+	switch durum {
+	case models.HL7MesajBekliyor, models.HL7MesajGonderildi, models.HL7MesajOlu:
+	default:
+		return nil, 0, ErrHL7MesajDurumu
+	}
+	return s.repo.FindByDurum(hedef, durum, page, limit)
+}
+
+// YenidenGonder puts a dead letter back in the queue of its endpoint. As the oldest
+// waiting message it is sent next, ahead of the changes queued after it.
+func (s *hl7MesajService) YenidenGonder(hl7MesajID uint) error {
+	return s.repo.YenidenKuyrukla(hl7MesajID, s.now())
+}
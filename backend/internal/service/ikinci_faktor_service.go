@@ -0,0 +1,317 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"medscreen/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Second factor lockout: after IkinciFaktorKilitEsigi wrong PINs or codes in a row the
+// step-up is refused for IkinciFaktorKilitSuresi, so a PIN cannot be guessed with a found card
+const (
+	IkinciFaktorKilitEsigi  = 5
+	IkinciFaktorKilitSuresi = 15 * time.Minute
+)
+
+// Errors returned by IkinciFaktorService
+var (
+	ErrGecersizPIN           = errors.New("pin must be 4 to 8 digits and not a repeated or sequential run")
+	ErrIkinciFaktorPersoneli = errors.New("personel not found")
+	ErrIkinciFaktorYok       = errors.New("no second factor is enrolled")
+	ErrIkinciFaktorHatali    = errors.New("invalid PIN or TOTP code")
+	ErrIkinciFaktorKilitli   = errors.New("second factor is locked after too many failed attempts")
+)
+
+// IkinciFaktorDurumu shows which second factors a personnel has enrolled, without the secrets
+type IkinciFaktorDurumu struct {
+	PersonelKodu            string     `json:"personel_kodu"`
+	PIN                     bool       `json:"pin"`
+	TOTP                    bool       `json:"totp"`
+	BasarisizDeneme         int        `json:"basarisiz_deneme"`
+	KilitBitisZamani        *time.Time `json:"kilit_bitis_zamani,omitempty"`
+	GuncelleyenPersonelKodu string     `json:"guncelleyen_personel_kodu,omitempty"`
+	GuncellemeZamani        *time.Time `json:"guncelleme_zamani,omitempty"`
+}
+
+// TOTPKaydi is a new TOTP secret. It is shown once, for enrolling an authenticator app.
+type TOTPKaydi struct {
+	PersonelKodu string `json:"personel_kodu"`
+	Anahtar      string `json:"anahtar"`
+	URI          string `json:"uri"`
+}
+
+// StepUpSonucu is the access token issued after a second factor was verified.
+// No refresh token is issued; refreshing returns to a session without the step-up.
+type StepUpSonucu struct {
+	AccessToken string    `json:"token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresIn   int64     `json:"expires_in"`
+	BitisZamani time.Time `json:"bitis_zamani"`
+}
+
+type ikinciFaktorService struct {
+	repo            repository.IkinciFaktorRepository
+	personelService PersonelService
+	nfcKartRepo     repository.NFCKartRepository
+	sure            time.Duration
+	issuer          string
+	now             func() time.Time
+}
+
+// NewIkinciFaktorService creates a new instance of IkinciFaktorService.
+// A step-up token lasts for sure; issuer names MedScreen in authenticator apps.
+func NewIkinciFaktorService(
+	repo repository.IkinciFaktorRepository,
+	personelService PersonelService,
+	nfcKartRepo repository.NFCKartRepository,
+	sure time.Duration,
+	issuer string,
+) IkinciFaktorService {
+	return &ikinciFaktorService{
+		repo:            repo,
+		personelService: personelService,
+		nfcKartRepo:     nfcKartRepo,
+		sure:            sure,
+		issuer:          issuer,
+		now:             time.Now,
+	}
+}
+
+// GetDurum retrieves which second factors a personnel has enrolled
+func (s *ikinciFaktorService) GetDurum(personelKodu string) (*IkinciFaktorDurumu, error) {
+	if _, err := s.personelService.GetByKodu(personelKodu); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIkinciFaktorPersoneli, err)
+	}
+
+	faktor, err := s.find(personelKodu)
+	if err != nil {
+		return nil, err
+	}
+	durum := &IkinciFaktorDurumu{PersonelKodu: personelKodu}
+	if faktor == nil {
+		return durum, nil
+	}
+	durum.PIN = faktor.PinOzeti != nil
+	durum.TOTP = faktor.TOTPAnahtari != nil
+	durum.BasarisizDeneme = faktor.BasarisizDeneme
+	durum.KilitBitisZamani = faktor.KilitBitisZamani
+	durum.GuncelleyenPersonelKodu = faktor.GuncelleyenPersonelKodu
+	durum.GuncellemeZamani = &faktor.GuncellemeZamani
+	return durum, nil
+}
+
+// PinBelirle sets or replaces the PIN of a personnel and lifts any lockout.
+// Only a PBKDF2 hash of the PIN is stored.
+func (s *ikinciFaktorService) PinBelirle(personelKodu, pin, guncelleyen string) error {
+	if !GecerliPIN(pin) {
+		return ErrGecersizPIN
+	}
+	faktor, err := s.kayit(personelKodu)
+	if err != nil {
+		return err
+	}
+
+	ozet, err := utils.HashPIN(pin)
+	if err != nil {
+		return err
+	}
+	faktor.PinOzeti = &ozet
+	return s.save(faktor, guncelleyen)
+}
+
+// TOTPOlustur generates a new TOTP secret for a personnel, replacing any earlier one.
+// The secret is returned once so it can be enrolled in an authenticator app.
+func (s *ikinciFaktorService) TOTPOlustur(personelKodu, guncelleyen string) (*TOTPKaydi, error) {
+	faktor, err := s.kayit(personelKodu)
+	if err != nil {
+		return nil, err
+	}
+
+	anahtar, err := utils.NewTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	faktor.TOTPAnahtari = &anahtar
+	faktor.SonTOTPAdimi = 0
+	if err := s.save(faktor, guncelleyen); err != nil {
+		return nil, err
+	}
+
+	return &TOTPKaydi{
+		PersonelKodu: personelKodu,
+		Anahtar:      anahtar,
+		URI:          utils.TOTPURI(s.issuer, personelKodu, anahtar),
+	}, nil
+}
+
+// Sifirla removes every second factor of a personnel, e.g. for a forgotten PIN or a lost phone
+func (s *ikinciFaktorService) Sifirla(personelKodu string) error {
+	if _, err := s.personelService.GetByKodu(personelKodu); err != nil {
+		return fmt.Errorf("%w: %v", ErrIkinciFaktorPersoneli, err)
+	}
+	if err := s.repo.Delete(personelKodu); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrIkinciFaktorYok
+		}
+		return err
+	}
+	return nil
+}
+
+// StepUp verifies a PIN or a TOTP code and issues a short-lived access token carrying the
+// verification time, which the policy requires for sensitive roles and endpoints.
+// The card (or, for SSO sessions, the personnel) is checked again, so a blocked card cannot step up.
+func (s *ikinciFaktorService) StepUp(accessClaims *utils.Claims, pin, totp string) (*StepUpSonucu, error) {
+	if accessClaims == nil {
+		return nil, ErrInvalidToken
+	}
+	if pin == "" && totp == "" {
+		return nil, ErrIkinciFaktorHatali
+	}
+
+	personel, err := oturumSahibi(s.personelService, s.nfcKartRepo, accessClaims)
+	if err != nil {
+		return nil, err
+	}
+	if personel.PersonelKodu != accessClaims.PersonelKodu {
+		return nil, ErrInvalidToken
+	}
+
+	faktor, err := s.find(personel.PersonelKodu)
+	if err != nil {
+		return nil, err
+	}
+	if faktor == nil {
+		return nil, ErrIkinciFaktorYok
+	}
+	now := s.now()
+	if faktor.KilitBitisZamani != nil && now.Before(*faktor.KilitBitisZamani) {
+		return nil, ErrIkinciFaktorKilitli
+	}
+
+	// The counters are changed with conditional updates rather than by saving the record read
+	// above, so concurrent attempts are all counted and an admin's PIN change is kept
+	adim, dogru := s.dogrula(faktor, pin, totp, now)
+	if dogru {
+		// The accepted TOTP step must be stored before the token is handed out, or the code could be replayed
+		dogru, err = s.repo.Basarili(personel.PersonelKodu, now, adim)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !dogru {
+		kilit, err := s.repo.Basarisiz(personel.PersonelKodu, now, IkinciFaktorKilitEsigi, now.Add(IkinciFaktorKilitSuresi))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrIkinciFaktorKilitli
+		}
+		if err != nil {
+			return nil, err
+		}
+		if kilit != nil && now.Before(*kilit) {
+			return nil, ErrIkinciFaktorKilitli
+		}
+		return nil, ErrIkinciFaktorHatali
+	}
+
+	// A step-up never outlives the token it upgrades, so break-the-glass and escalated
+	// sessions keep their own time limits
+	sure := s.sure
+	if accessClaims.ExpiresAt != nil {
+		if kalan := accessClaims.ExpiresAt.Time.Sub(now); kalan < sure {
+			sure = kalan
+		}
+	}
+	if sure <= 0 {
+		return nil, ErrInvalidToken
+	}
+
+	claims := *accessClaims
+	claims.Role = personel.PersonelGorevKodu
+	claims.IkinciFaktorZamani = now.Unix()
+	claims.TokenType = utils.TokenTypeAccess
+	accessToken, issued, err := utils.GenerateJWT(claims, sure)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenGeneration, err)
+	}
+
+	return &StepUpSonucu{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(sure.Seconds()),
+		BitisZamani: issued.ExpiresAt.Time,
+	}, nil
+}
+
+// GecerliPIN reports whether a PIN is 4 to 8 digits and not trivially guessable:
+// not a single repeated digit and not an ascending or descending run such as 1234
+func GecerliPIN(pin string) bool {
+	if len(pin) < 4 || len(pin) > 8 {
+		return false
+	}
+	for i := 0; i < len(pin); i++ {
+		if pin[i] < '0' || pin[i] > '9' {
+			return false
+		}
+	}
+
+	ayni, artan, azalan := true, true, true
+	for i := 1; i < len(pin); i++ {
+		fark := int(pin[i]) - int(pin[i-1])
+		ayni = ayni && fark == 0
+		artan = artan && fark == 1
+		azalan = azalan && fark == -1
+	}
+	return !ayni && !artan && !azalan
+}
+
+// dogrula checks the PIN or, failing that, the TOTP code. For a TOTP code it returns the
+// accepted time step, which must still be claimed on the record; for a PIN it returns 0.
+func (s *ikinciFaktorService) dogrula(faktor *models.IkinciFaktor, pin, totp string, now time.Time) (int64, bool) {
+	if pin != "" && faktor.PinOzeti != nil && utils.VerifyPIN(pin, *faktor.PinOzeti) {
+		return 0, true
+	}
+	if totp != "" && faktor.TOTPAnahtari != nil {
+		if adim, ok := utils.DogrulaTOTP(*faktor.TOTPAnahtari, totp, now, faktor.SonTOTPAdimi); ok {
+			return adim, true
+		}
+	}
+	return 0, false
+}
+
+// kayit returns the record of an existing personnel, or a new one if nothing is enrolled yet
+func (s *ikinciFaktorService) kayit(personelKodu string) (*models.IkinciFaktor, error) {
+	if _, err := s.personelService.GetByKodu(personelKodu); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIkinciFaktorPersoneli, err)
+	}
+	faktor, err := s.find(personelKodu)
+	if err != nil {
+		return nil, err
+	}
+	if faktor == nil {
+		faktor = &models.IkinciFaktor{PersonelKodu: personelKodu}
+	}
+	return faktor, nil
+}
+
+// find returns nil without an error when the personnel has no second factor
+func (s *ikinciFaktorService) find(personelKodu string) (*models.IkinciFaktor, error) {
+	faktor, err := s.repo.FindByPersonelKodu(personelKodu)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return faktor, err
+}
+
+// save records an admin change; a new factor also lifts any lockout
+func (s *ikinciFaktorService) save(faktor *models.IkinciFaktor, guncelleyen string) error {
+	faktor.BasarisizDeneme = 0
+	faktor.KilitBitisZamani = nil
+	faktor.GuncelleyenPersonelKodu = guncelleyen
+	faktor.GuncellemeZamani = s.now()
+	return s.repo.Save(faktor)
+}
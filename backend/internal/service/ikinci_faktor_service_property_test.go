@@ -0,0 +1,301 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"medscreen/internal/utils"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"pgregory.net/rapid"
+)
+
+// Feature: second-factor, Property 1: Step-Up Requires The Enrolled Factor
+// *For any* enrolled PIN and any attempted PIN or TOTP code, StepUp SHALL issue a token
+// carrying the verification time only for the enrolled PIN or a TOTP code not used before,
+// and SHALL lock the factor after IkinciFaktorKilitEsigi failures in a row.
+
+type mockIkinciFaktorRepository struct {
+	kayitlar map[string]models.IkinciFaktor
+	// okundu runs after a record was read, to change it the way a concurrent request would
+	okundu func()
+}
+
+func newMockIkinciFaktorRepository() *mockIkinciFaktorRepository {
+	return &mockIkinciFaktorRepository{kayitlar: make(map[string]models.IkinciFaktor)}
+}
+
+func (r *mockIkinciFaktorRepository) FindByPersonelKodu(personelKodu string) (*models.IkinciFaktor, error) {
+	faktor, ok := r.kayitlar[personelKodu]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if r.okundu != nil {
+		r.okundu()
+	}
+	return &faktor, nil
+}
+
+func (r *mockIkinciFaktorRepository) Save(faktor *models.IkinciFaktor) error {
+	r.kayitlar[faktor.PersonelKodu] = *faktor
+	return nil
+}
+
+// kilitsiz returns the record of a personnel if it exists and is not locked at now
+func (r *mockIkinciFaktorRepository) kilitsiz(personelKodu string, now time.Time) (models.IkinciFaktor, bool) {
+	faktor, ok := r.kayitlar[personelKodu]
+	return faktor, ok && (faktor.KilitBitisZamani == nil || !now.Before(*faktor.KilitBitisZamani))
+}
+
+func (r *mockIkinciFaktorRepository) Basarisiz(personelKodu string, now time.Time, esik int, kilitBitis time.Time) (*time.Time, error) {
+	faktor, ok := r.kilitsiz(personelKodu, now)
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	faktor.BasarisizDeneme++
+	if faktor.BasarisizDeneme >= esik {
+		faktor.BasarisizDeneme = 0
+		faktor.KilitBitisZamani = &kilitBitis
+	}
+	r.kayitlar[personelKodu] = faktor
+	return faktor.KilitBitisZamani, nil
+}
+
+func (r *mockIkinciFaktorRepository) Basarili(personelKodu string, now time.Time, adim int64) (bool, error) {
+	faktor, ok := r.kilitsiz(personelKodu, now)
+	if !ok || (adim > 0 && faktor.SonTOTPAdimi >= adim) {
+		return false, nil
+	}
+	if adim > 0 {
+		faktor.SonTOTPAdimi = adim
+	}
+	faktor.BasarisizDeneme = 0
+	faktor.KilitBitisZamani = nil
+	r.kayitlar[personelKodu] = faktor
+	return true, nil
+}
+
+func (r *mockIkinciFaktorRepository) Delete(personelKodu string) error {
+	if _, ok := r.kayitlar[personelKodu]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(r.kayitlar, personelKodu)
+	return nil
+}
+
+var _ repository.IkinciFaktorRepository = (*mockIkinciFaktorRepository)(nil)
+
+// newTestIkinciFaktorService builds an IkinciFaktorService over one active HEKIM with one
+// active card, and returns the access claims of that card's session
+func newTestIkinciFaktorService(now time.Time) (*ikinciFaktorService, *mockIkinciFaktorRepository, *utils.Claims) {
+	utils.PINIterasyon = 1000
+
+	personelRepo := newMockPersonelRepository()
+	personelRepo.addPersonel(&models.Personel{PersonelKodu: "P000001", PersonelGorevKodu: string(models.GorevHekim), AktiflikBilgisi: 1})
+	nfcKartRepo := newMockNFCKartRepository()
+	nfcKartRepo.addKart(&models.NFCKart{NFCKartKodu: "NFC1", PersonelKodu: "P000001", KartUID: "UID1", AktiflikBilgisi: 1})
+
+	repo := newMockIkinciFaktorRepository()
+	svc := NewIkinciFaktorService(repo, NewPersonelService(personelRepo, nfcKartRepo), nfcKartRepo, 5*time.Minute, "MedScreen").(*ikinciFaktorService)
+	svc.now = func() time.Time { return now }
+
+	_, claims, err := utils.GenerateJWT(utils.Claims{
+		PersonelKodu: "P000001",
+		Role:         string(models.GorevHekim),
+		NFCKartKodu:  "NFC1",
+		TokenType:    utils.TokenTypeAccess,
+	}, 15*time.Minute)
+	if err != nil {
+		panic(err)
+	}
+	return svc, repo, claims
+}
+
+// TestProperty_StepUpRequiresEnrolledFactor tests Property 1
+func TestProperty_StepUpRequiresEnrolledFactor(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		now := time.Now().Truncate(time.Second)
+		svc, repo, claims := newTestIkinciFaktorService(now)
+
+		pin := rapid.StringMatching(`[0-9]{4,8}`).Filter(GecerliPIN).Draw(rt, "pin")
+		if err := svc.PinBelirle("P000001", pin, "ADMIN"); err != nil {
+			rt.Fatalf("Failed to set PIN: %v", err)
+		}
+		kayit, err := svc.TOTPOlustur("P000001", "ADMIN")
+		if err != nil {
+			rt.Fatalf("Failed to enroll TOTP: %v", err)
+		}
+
+		basarisiz := 0
+		for i := 0; i < rapid.IntRange(1, 12).Draw(rt, "deneme"); i++ {
+			var denemePIN, denemeTOTP string
+			dogru := false
+			switch rapid.IntRange(0, 2).Draw(rt, "tur") {
+			case 0:
+				denemePIN = pin
+				dogru = true
+			case 1:
+				denemePIN = rapid.StringMatching(`[0-9]{4,8}`).Filter(func(p string) bool { return p != pin }).Draw(rt, "yanlis")
+			case 2:
+				denemeTOTP, _ = utils.TOTPKodu(kayit.Anahtar, utils.TOTPAdimiAt(now))
+				// A TOTP code is accepted once per step
+				dogru = repo.kayitlar["P000001"].SonTOTPAdimi < utils.TOTPAdimiAt(now)
+			}
+
+			sonuc, err := svc.StepUp(claims, denemePIN, denemeTOTP)
+			kilitli := basarisiz >= IkinciFaktorKilitEsigi
+			switch {
+			case kilitli:
+				if !errors.Is(err, ErrIkinciFaktorKilitli) {
+					rt.Fatalf("Expected a locked factor to refuse attempt %d, got %v", i, err)
+				}
+				continue
+			case !dogru:
+				basarisiz++
+				if err == nil {
+					rt.Fatalf("Expected attempt %d (pin %q, totp %q) to be rejected", i, denemePIN, denemeTOTP)
+				}
+				if basarisiz == IkinciFaktorKilitEsigi && !errors.Is(err, ErrIkinciFaktorKilitli) {
+					rt.Fatalf("Expected the factor to lock after %d failures, got %v", basarisiz, err)
+				}
+				continue
+			}
+
+			if err != nil {
+				rt.Fatalf("Expected attempt %d to succeed, got %v", i, err)
+			}
+			basarisiz = 0
+			yeni, err := utils.ParseJWT(sonuc.AccessToken)
+			if err != nil || yeni.IkinciFaktorZamani != now.Unix() || yeni.PersonelKodu != claims.PersonelKodu || yeni.NFCKartKodu != claims.NFCKartKodu {
+				rt.Fatalf("Unexpected step-up claims: %+v, %v", yeni, err)
+			}
+			if sonuc.ExpiresIn != int64((5 * time.Minute).Seconds()) {
+				rt.Fatalf("Expected a five minute step-up token, got %ds", sonuc.ExpiresIn)
+			}
+		}
+	})
+}
+
+// TestIkinciFaktor_PinRules tests that trivially guessable PINs are refused and never stored
+func TestIkinciFaktor_PinRules(t *testing.T) {
+	svc, repo, _ := newTestIkinciFaktorService(time.Now())
+	for _, pin := range []string{"", "123", "123456789", "12a4", "0000", "1234", "98765", "345678"} {
+		if err := svc.PinBelirle("P000001", pin, "ADMIN"); !errors.Is(err, ErrGecersizPIN) {
+			t.Errorf("Expected PIN %q to be refused, got %v", pin, err)
+		}
+	}
+	if len(repo.kayitlar) != 0 {
+		t.Fatalf("Expected no second factor to be stored, got %+v", repo.kayitlar)
+	}
+
+	if err := svc.PinBelirle("P000001", "4071", "ADMIN"); err != nil {
+		t.Fatalf("Expected PIN to be accepted, got %v", err)
+	}
+	faktor := repo.kayitlar["P000001"]
+	if faktor.PinOzeti == nil || *faktor.PinOzeti == "4071" || !utils.VerifyPIN("4071", *faktor.PinOzeti) || utils.VerifyPIN("4072", *faktor.PinOzeti) {
+		t.Fatalf("Expected only a hash of the PIN to be stored, got %v", faktor.PinOzeti)
+	}
+
+	if err := svc.PinBelirle("P999999", "4071", "ADMIN"); !errors.Is(err, ErrIkinciFaktorPersoneli) {
+		t.Fatalf("Expected an unknown personnel to be refused, got %v", err)
+	}
+	if err := svc.Sifirla("P000001"); err != nil {
+		t.Fatalf("Expected reset to succeed, got %v", err)
+	}
+	if err := svc.Sifirla("P000001"); !errors.Is(err, ErrIkinciFaktorYok) {
+		t.Fatalf("Expected a second reset to report nothing enrolled, got %v", err)
+	}
+}
+
+// TestIkinciFaktor_StepUpLimits tests that a step-up never outlives the upgraded token and
+// that a blocked card cannot step up
+func TestIkinciFaktor_StepUpLimits(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	svc, _, claims := newTestIkinciFaktorService(now)
+	if _, err := svc.StepUp(claims, "4071", ""); !errors.Is(err, ErrIkinciFaktorYok) {
+		t.Fatalf("Expected step-up without an enrolled factor to fail, got %v", err)
+	}
+	if err := svc.PinBelirle("P000001", "4071", "ADMIN"); err != nil {
+		t.Fatal(err)
+	}
+
+	kisa := *claims
+	kisa.ExpiresAt.Time = now.Add(90 * time.Second)
+	sonuc, err := svc.StepUp(&kisa, "4071", "")
+	if err != nil || sonuc.ExpiresIn != 90 {
+		t.Fatalf("Expected the step-up to end with the upgraded token, got %+v, %v", sonuc, err)
+	}
+
+	baskasi := *claims
+	baskasi.NFCKartKodu = "NFC-KAYIP"
+	if _, err := svc.StepUp(&baskasi, "4071", ""); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Expected an unknown card to be refused, got %v", err)
+	}
+}
+
+// TestIkinciFaktor_StepUpKeepsConcurrentChanges tests that a step-up only changes its own
+// counters: a PIN set while it runs is kept, and a TOTP code read as unused by two
+// requests at once is accepted only once
+func TestIkinciFaktor_StepUpKeepsConcurrentChanges(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	svc, repo, claims := newTestIkinciFaktorService(now)
+	if err := svc.PinBelirle("P000001", "4071", "ADMIN"); err != nil {
+		t.Fatal(err)
+	}
+	kayit, err := svc.TOTPOlustur("P000001", "ADMIN")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo.okundu = func() {
+		repo.okundu = nil
+		if err := svc.PinBelirle("P000001", "5182", "ADMIN"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := svc.StepUp(claims, "9306", ""); !errors.Is(err, ErrIkinciFaktorHatali) {
+		t.Fatalf("Expected a wrong PIN to be refused, got %v", err)
+	}
+	faktor := repo.kayitlar["P000001"]
+	if faktor.PinOzeti == nil || !utils.VerifyPIN("5182", *faktor.PinOzeti) || faktor.BasarisizDeneme != 1 {
+		t.Fatalf("Expected the new PIN and one failure to be kept, got %+v", faktor)
+	}
+
+	kod, _ := utils.TOTPKodu(kayit.Anahtar, utils.TOTPAdimiAt(now))
+	repo.okundu = func() {
+		repo.okundu = nil
+		if _, err := svc.StepUp(claims, "", kod); err != nil {
+			t.Fatalf("Expected the first use of the code to succeed, got %v", err)
+		}
+	}
+	if _, err := svc.StepUp(claims, "", kod); !errors.Is(err, ErrIkinciFaktorHatali) {
+		t.Fatalf("Expected the concurrent replay of the code to be refused, got %v", err)
+	}
+}
+
+// TestIkinciFaktor_TOTPVectors checks the TOTP implementation against RFC 6238 appendix B
+func TestIkinciFaktor_TOTPVectors(t *testing.T) {
+	// Base32 of the ASCII secret "12345678901234567890"
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	for unix, want := range map[int64]string{59: "287082", 1111111109: "081804", 1234567890: "005924", 2000000000: "279037"} {
+		got, err := utils.TOTPKodu(secret, utils.TOTPAdimiAt(time.Unix(unix, 0)))
+		if err != nil || got != want {
+			t.Errorf("TOTP at %d: got %s (%v), want %s", unix, got, err, want)
+		}
+	}
+
+	now := time.Unix(1234567890, 0)
+	adim, ok := utils.DogrulaTOTP(secret, "005924", now, 0)
+	if !ok {
+		t.Fatal("Expected the current code to be accepted")
+	}
+	if _, ok := utils.DogrulaTOTP(secret, "005924", now, adim); ok {
+		t.Fatal("Expected a used code to be refused")
+	}
+	if uri := utils.TOTPURI("MedScreen", "P000001", secret); uri != fmt.Sprintf("otpauth://totp/MedScreen:P000001?digits=6&issuer=MedScreen&period=30&secret=%s", secret) {
+		t.Fatalf("Unexpected otpauth URI %s", uri)
+	}
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"errors"
+	"medscreen/internal/allergy"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// ErrBasvuruBulunamadi is returned when the visit to check does not exist
+var ErrBasvuruBulunamadi = errors.New("visit not found")
+
+// ReceteUyumsuzlukRaporu lists the drug-allergy conflicts of a prescription, most severe first
+type ReceteUyumsuzlukRaporu struct {
+	ReceteKodu       string               `json:"recete_kodu"`
+	HastaBasvuruKodu string               `json:"hasta_basvuru_kodu"`
+	HastaKodu        string               `json:"hasta_kodu"`
+	EnYuksekSiddet   allergy.Siddet       `json:"en_yuksek_siddet,omitempty"`
+	Uyumsuzluklar    []allergy.Uyumsuzluk `json:"uyumsuzluklar"`
+	// EslesmeyenIlaclar are the recete_ilac_kodu of drugs whose ingredients are unknown;
+	// they were only compared by name and need a manual check
+	EslesmeyenIlaclar []string `json:"eslesmeyen_ilaclar"`
+}
+
+type ilacAlerjiService struct {
+	receteRepo     repository.ReceteRepository
+	basvuruRepo    repository.HastaBasvuruRepository
+	tibbiBilgiRepo repository.HastaTibbiBilgiRepository
+	esleme         *allergy.Esleme
+}
+
+// NewIlacAlerjiService creates a new instance of IlacAlerjiService
+func NewIlacAlerjiService(receteRepo repository.ReceteRepository, basvuruRepo repository.HastaBasvuruRepository, tibbiBilgiRepo repository.HastaTibbiBilgiRepository, esleme *allergy.Esleme) IlacAlerjiService {
+	return &ilacAlerjiService{
+		receteRepo:     receteRepo,
+		basvuruRepo:    basvuruRepo,
+		tibbiBilgiRepo: tibbiBilgiRepo,
+		esleme:         esleme,
+	}
+}
+
+// GetByReceteKodu checks the drugs of a prescription against the patient's allergies
+func (s *ilacAlerjiService) GetByReceteKodu(receteKodu string) (*ReceteUyumsuzlukRaporu, error) {
+	if receteKodu == "" {
+		return nil, errors.New("recete_kodu is required")
+	}
+
+	recete, err := s.receteRepo.FindByKodu(receteKodu)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReceteBulunamadi
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	basvuru := recete.HastaBasvuru
+	if basvuru == nil {
+		if basvuru, err = s.basvuruRepo.FindByKodu(recete.HastaBasvuruKodu); err != nil {
+			return nil, err
+		}
+	}
+
+	alerjiler, err := s.tibbiBilgiRepo.FindByHastaKoduAndTuru(basvuru.HastaKodu, string(models.TibbiBilgiAlerji))
+	if err != nil {
+		return nil, err
+	}
+	return s.rapor(recete, basvuru.HastaKodu, alerjiler), nil
+}
+
+// GetByBasvuruKodu checks every active prescription of a visit
+func (s *ilacAlerjiService) GetByBasvuruKodu(basvuruKodu string) ([]ReceteUyumsuzlukRaporu, error) {
+	if basvuruKodu == "" {
+		return nil, errors.New("hasta_basvuru_kodu is required")
+	}
+
+	basvuru, err := s.basvuruRepo.FindByKodu(basvuruKodu)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBasvuruBulunamadi
+	}
+	if err != nil {
+		return nil, err
+	}
+	receteler, err := s.receteRepo.FindAktifByBasvuruKodu(basvuruKodu)
+	if err != nil {
+		return nil, err
+	}
+	alerjiler, err := s.tibbiBilgiRepo.FindByHastaKoduAndTuru(basvuru.HastaKodu, string(models.TibbiBilgiAlerji))
+	if err != nil {
+		return nil, err
+	}
+
+	raporlar := make([]ReceteUyumsuzlukRaporu, 0, len(receteler))
+	for i := range receteler {
+		raporlar = append(raporlar, *s.rapor(&receteler[i], basvuru.HastaKodu, alerjiler))
+	}
+	return raporlar, nil
+}
+
+func (s *ilacAlerjiService) rapor(recete *models.Recete, hastaKodu string, alerjiler []models.HastaTibbiBilgi) *ReceteUyumsuzlukRaporu {
+	rapor := &ReceteUyumsuzlukRaporu{
+		ReceteKodu:        recete.ReceteKodu,
+		HastaBasvuruKodu:  recete.HastaBasvuruKodu,
+		HastaKodu:         hastaKodu,
+		Uyumsuzluklar:     []allergy.Uyumsuzluk{},
+		EslesmeyenIlaclar: []string{},
+	}
+
+	for i := range recete.Ilaclar {
+		sonuc := s.esleme.Kontrol(&recete.Ilaclar[i], alerjiler)
+		if !sonuc.Eslesti {
+			rapor.EslesmeyenIlaclar = append(rapor.EslesmeyenIlaclar, recete.Ilaclar[i].ReceteIlacKodu)
+		}
+		rapor.Uyumsuzluklar = append(rapor.Uyumsuzluklar, sonuc.Uyumsuzluklar...)
+	}
+
+	sort.SliceStable(rapor.Uyumsuzluklar, func(i, j int) bool {
+		return !rapor.Uyumsuzluklar[j].Siddet.AtLeast(rapor.Uyumsuzluklar[i].Siddet)
+	})
+	if len(rapor.Uyumsuzluklar) > 0 {
+		rapor.EnYuksekSiddet = rapor.Uyumsuzluklar[0].Siddet
+	}
+	return rapor
+}
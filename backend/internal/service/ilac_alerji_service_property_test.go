@@ -0,0 +1,102 @@
+package service
+
+import (
+	"errors"
+	"medscreen/internal/allergy"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// Feature: drug-allergy-check, Property 2: Every Active Prescription Is Checked
+// *For any* visit, the conflict report SHALL contain one entry per active prescription,
+// conflicts most severe first, and list every drug whose ingredients are unknown.
+
+type stubAlerjiReceteRepo struct {
+	repository.ReceteRepository
+	receteler []models.Recete
+}
+
+func (r *stubAlerjiReceteRepo) FindAktifByBasvuruKodu(basvuruKodu string) ([]models.Recete, error) {
+	return r.receteler, nil
+}
+
+type stubAlerjiBasvuruRepo struct {
+	repository.HastaBasvuruRepository
+}
+
+func (r *stubAlerjiBasvuruRepo) FindByKodu(kodu string) (*models.HastaBasvuru, error) {
+	switch kodu {
+	case "B404":
+		return nil, gorm.ErrRecordNotFound
+	case "BERR":
+		return nil, errors.New("connection reset")
+	}
+	return &models.HastaBasvuru{HastaBasvuruKodu: kodu, HastaKodu: "H1"}, nil
+}
+
+type stubAlerjiTibbiBilgiRepo struct {
+	repository.HastaTibbiBilgiRepository
+	alerjiler []models.HastaTibbiBilgi
+}
+
+func (r *stubAlerjiTibbiBilgiRepo) FindByHastaKoduAndTuru(hastaKodu, turuKodu string) ([]models.HastaTibbiBilgi, error) {
+	return r.alerjiler, nil
+}
+
+func receteIlaci(kodu, ad string) models.ReceteIlac {
+	return models.ReceteIlac{ReceteIlacKodu: kodu, ReceteKodu: "R1", Barkod: "B-" + kodu, IlacAdi: &ad}
+}
+
+// TestIlacAlerji_VisitReport tests Property 2
+func TestIlacAlerji_VisitReport(t *testing.T) {
+	aciklama := "Penisilin alerjisi"
+	receteRepo := &stubAlerjiReceteRepo{receteler: []models.Recete{
+		{ReceteKodu: "R1", HastaBasvuruKodu: "B1", Ilaclar: []models.ReceteIlac{
+			receteIlaci("I1", "SEFTRIAKSON 1 G FLAKON"),
+			receteIlaci("I2", "AMOKSISILIN 500 MG KAPSUL"),
+			receteIlaci("I3", "BILINMEYEN SURUP"),
+		}},
+		{ReceteKodu: "R2", HastaBasvuruKodu: "B1", Ilaclar: []models.ReceteIlac{receteIlaci("I4", "PARASETAMOL 500 MG")}},
+	}}
+	tibbiBilgiRepo := &stubAlerjiTibbiBilgiRepo{alerjiler: []models.HastaTibbiBilgi{
+		{HastaTibbiBilgiKodu: "A1", HastaKodu: "H1", TibbiBilgiTuruKodu: string(models.TibbiBilgiAlerji), Aciklama: &aciklama},
+	}}
+	svc := NewIlacAlerjiService(receteRepo, &stubAlerjiBasvuruRepo{}, tibbiBilgiRepo, allergy.Default())
+
+	raporlar, err := svc.GetByBasvuruKodu("B1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(raporlar) != 2 {
+		t.Fatalf("Expected one report per active prescription, got %d", len(raporlar))
+	}
+
+	r1 := raporlar[0]
+	if r1.HastaKodu != "H1" || r1.EnYuksekSiddet != allergy.SiddetYuksek || len(r1.Uyumsuzluklar) != 2 {
+		t.Fatalf("Unexpected report: %+v", r1)
+	}
+	if r1.Uyumsuzluklar[0].ReceteIlacKodu != "I2" || r1.Uyumsuzluklar[1].Siddet != allergy.SiddetOrta {
+		t.Errorf("Expected conflicts most severe first, got %+v", r1.Uyumsuzluklar)
+	}
+	if len(r1.EslesmeyenIlaclar) != 1 || r1.EslesmeyenIlaclar[0] != "I3" {
+		t.Errorf("Expected I3 to need a manual check, got %v", r1.EslesmeyenIlaclar)
+	}
+	if len(raporlar[1].Uyumsuzluklar) != 0 || raporlar[1].EnYuksekSiddet != "" {
+		t.Errorf("Expected no conflicts for R2, got %+v", raporlar[1])
+	}
+
+	if _, err := svc.GetByReceteKodu(""); err == nil {
+		t.Error("Expected an error for an empty prescription code")
+	}
+
+	// Only a missing visit is reported as not found; other failures are server errors
+	if _, err := svc.GetByBasvuruKodu("B404"); !errors.Is(err, ErrBasvuruBulunamadi) {
+		t.Errorf("Expected ErrBasvuruBulunamadi for a missing visit, got %v", err)
+	}
+	if _, err := svc.GetByBasvuruKodu("BERR"); err == nil || errors.Is(err, ErrBasvuruBulunamadi) {
+		t.Errorf("Expected a database error to be passed through, got %v", err)
+	}
+}
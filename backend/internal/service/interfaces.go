@@ -1,139 +1,237 @@
 package service
 
 import (
+	"context"
 	"medscreen/internal/models"
+	"medscreen/internal/query"
+	"medscreen/internal/repository"
+	"medscreen/internal/utils"
 	"time"
 )
 
 // PersonelService defines the read-only interface for personnel business logic operations
 type PersonelService interface {
 	GetByKodu(kodu string) (*models.Personel, error)
-	GetAll(page, limit int) ([]models.Personel, int64, error)
-	GetByGorevKodu(gorevKodu string, page, limit int) ([]models.Personel, int64, error)
 	AuthenticateByNFC(kartUID string) (*models.Personel, error)
+	AuthenticateByKodu(personelKodu string) (*models.Personel, error)
+	List(q query.Query, page, limit int) ([]models.Personel, int64, error)
 }
 
 // NFCKartService defines the read-only interface for NFC card business logic operations
 type NFCKartService interface {
 	GetByKodu(kodu string) (*models.NFCKart, error)
 	GetByKartUID(kartUID string) (*models.NFCKart, error)
-	GetByPersonelKodu(personelKodu string, page, limit int) ([]models.NFCKart, int64, error)
+	List(q query.Query, page, limit int) ([]models.NFCKart, int64, error)
 }
 
 // HastaService defines the read-only interface for patient business logic operations
 type HastaService interface {
 	GetByKodu(kodu string) (*models.Hasta, error)
 	GetByTCKimlik(tcKimlik string) (*models.Hasta, error)
-	GetAll(page, limit int) ([]models.Hasta, int64, error)
-	SearchByAdSoyadi(ad, soyadi string, page, limit int) ([]models.Hasta, int64, error)
+	List(q query.Query, page, limit int) ([]models.Hasta, int64, error)
 }
 
 // HastaBasvuruService defines the read-only interface for patient visit business logic operations
 type HastaBasvuruService interface {
 	GetByKodu(kodu string) (*models.HastaBasvuru, error)
-	GetByHastaKodu(hastaKodu string, page, limit int) ([]models.HastaBasvuru, int64, error)
-	GetByHekimKodu(hekimKodu string, page, limit int) ([]models.HastaBasvuru, int64, error)
-	GetByFilters(durum *string, startDate, endDate *time.Time, page, limit int) ([]models.HastaBasvuru, int64, error)
+	List(q query.Query, page, limit int) ([]models.HastaBasvuru, int64, error)
 }
 
 // YatakService defines the read-only interface for bed business logic operations
 type YatakService interface {
 	GetByKodu(kodu string) (*models.Yatak, error)
-	GetByBirimAndOda(birimKodu, odaKodu string, page, limit int) ([]models.Yatak, int64, error)
-	GetAll(page, limit int) ([]models.Yatak, int64, error)
+	List(q query.Query, page, limit int) ([]models.Yatak, int64, error)
 }
 
 // TabletCihazService defines the read-only interface for tablet device business logic operations
 type TabletCihazService interface {
 	GetByKodu(kodu string) (*models.TabletCihaz, error)
-	GetByYatakKodu(yatakKodu string, page, limit int) ([]models.TabletCihaz, int64, error)
-	GetAll(page, limit int) ([]models.TabletCihaz, int64, error)
+	List(q query.Query, page, limit int) ([]models.TabletCihaz, int64, error)
 }
 
 // AnlikYatanHastaService defines the read-only interface for current inpatient business logic operations
 type AnlikYatanHastaService interface {
 	GetByKodu(kodu string) (*models.AnlikYatanHasta, error)
-	GetByYatakKodu(yatakKodu string, page, limit int) ([]models.AnlikYatanHasta, int64, error)
-	GetByHastaKodu(hastaKodu string, page, limit int) ([]models.AnlikYatanHasta, int64, error)
-	GetByBirimKodu(birimKodu string, page, limit int) ([]models.AnlikYatanHasta, int64, error)
+	List(q query.Query, page, limit int) ([]models.AnlikYatanHasta, int64, error)
 }
 
 // HastaVitalFizikiBulguService defines the read-only interface for vital signs business logic operations
 type HastaVitalFizikiBulguService interface {
 	GetByKodu(kodu string) (*models.HastaVitalFizikiBulgu, error)
-	GetByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.HastaVitalFizikiBulgu, int64, error)
-	GetByDateRange(startDate, endDate time.Time, page, limit int) ([]models.HastaVitalFizikiBulgu, int64, error)
+	List(q query.Query, page, limit int) ([]models.HastaVitalFizikiBulgu, int64, error)
 }
 
 // KlinikSeyirService defines the read-only interface for clinical progress notes business logic operations
 type KlinikSeyirService interface {
 	GetByKodu(kodu string) (*models.KlinikSeyir, error)
-	GetByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.KlinikSeyir, int64, error)
-	GetByFilters(seyirTipi *string, sepsisDurumu *int, startDate, endDate *time.Time, page, limit int) ([]models.KlinikSeyir, int64, error)
+	List(q query.Query, page, limit int) ([]models.KlinikSeyir, int64, error)
 }
 
 // TibbiOrderService defines the read-only interface for medical orders business logic operations
 type TibbiOrderService interface {
 	GetByKodu(kodu string) (*models.TibbiOrder, error)
-	GetByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.TibbiOrder, int64, error)
-	GetDetayByOrderKodu(orderKodu string, page, limit int) ([]models.TibbiOrderDetay, int64, error)
+	List(q query.Query, page, limit int) ([]models.TibbiOrder, int64, error)
+	ListDetay(q query.Query, page, limit int) ([]models.TibbiOrderDetay, int64, error)
 }
 
-// TetkikSonucService defines the read-only interface for test results business logic operations
+// TetkikSonucService defines the interface for test results business logic operations.
+// Test results are read-only; only acknowledgements of critical results are written (medscreen schema).
 type TetkikSonucService interface {
 	GetByKodu(kodu string) (*models.TetkikSonuc, error)
-	GetByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.TetkikSonuc, int64, error)
+	GetKritik(birimKodu string, since *time.Time) ([]KritikTetkikSonuc, error)
+	Onayla(kodu, personelKodu, aciklama string) (*models.KritikSonucOnay, error)
+	List(q query.Query, page, limit int) ([]models.TetkikSonuc, int64, error)
 }
 
 // ReceteService defines the read-only interface for prescription business logic operations
 type ReceteService interface {
 	GetByKodu(kodu string) (*models.Recete, error)
-	GetByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.Recete, int64, error)
-	GetByHekimKodu(hekimKodu string, page, limit int) ([]models.Recete, int64, error)
-	GetIlaclar(receteKodu string, page, limit int) ([]models.ReceteIlac, int64, error)
+	List(q query.Query, page, limit int) ([]models.Recete, int64, error)
+	ListIlac(q query.Query, page, limit int) ([]models.ReceteIlac, int64, error)
 }
 
 // BasvuruTaniService defines the read-only interface for diagnosis business logic operations
 type BasvuruTaniService interface {
 	GetByKodu(kodu string) (*models.BasvuruTani, error)
-	GetByHastaKodu(hastaKodu string, page, limit int) ([]models.BasvuruTani, int64, error)
-	GetByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.BasvuruTani, int64, error)
+	List(q query.Query, page, limit int) ([]models.BasvuruTani, int64, error)
 }
 
 // HastaTibbiBilgiService defines the read-only interface for patient medical information business logic operations
 type HastaTibbiBilgiService interface {
 	GetByKodu(kodu string) (*models.HastaTibbiBilgi, error)
-	GetByHastaKodu(hastaKodu string, page, limit int) ([]models.HastaTibbiBilgi, int64, error)
-	GetByTuru(turuKodu string, page, limit int) ([]models.HastaTibbiBilgi, int64, error)
+	List(q query.Query, page, limit int) ([]models.HastaTibbiBilgi, int64, error)
 }
 
 // HastaUyariService defines the read-only interface for patient warnings business logic operations
 type HastaUyariService interface {
 	GetByKodu(kodu string) (*models.HastaUyari, error)
-	GetByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.HastaUyari, int64, error)
-	GetByFilters(uyariTuru *string, aktiflik *int, page, limit int) ([]models.HastaUyari, int64, error)
+	List(q query.Query, page, limit int) ([]models.HastaUyari, int64, error)
 }
 
 // RiskSkorlamaService defines the read-only interface for risk scoring business logic operations
 type RiskSkorlamaService interface {
 	GetByKodu(kodu string) (*models.RiskSkorlama, error)
-	GetByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.RiskSkorlama, int64, error)
-	GetByTuru(turu string, page, limit int) ([]models.RiskSkorlama, int64, error)
+	List(q query.Query, page, limit int) ([]models.RiskSkorlama, int64, error)
 }
 
 // BasvuruYemekService defines the read-only interface for diet/meal business logic operations
 type BasvuruYemekService interface {
 	GetByKodu(kodu string) (*models.BasvuruYemek, error)
-	GetByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.BasvuruYemek, int64, error)
-	GetByTuru(yemekTuru string, page, limit int) ([]models.BasvuruYemek, int64, error)
+	List(q query.Query, page, limit int) ([]models.BasvuruYemek, int64, error)
 }
 
 // RandevuService defines the read-only interface for appointment business logic operations
 type RandevuService interface {
 	GetByKodu(kodu string) (*models.Randevu, error)
-	GetByHastaKodu(hastaKodu string, page, limit int) ([]models.Randevu, int64, error)
-	GetByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.Randevu, int64, error)
-	GetByHekimKodu(hekimKodu string, page, limit int) ([]models.Randevu, int64, error)
-	GetByTuru(randevuTuru string, page, limit int) ([]models.Randevu, int64, error)
-	GetByDateRange(startDate, endDate time.Time, page, limit int) ([]models.Randevu, int64, error)
+	List(q query.Query, page, limit int) ([]models.Randevu, int64, error)
+}
+
+// HastaBasvuruOzetService defines the read-only interface for the bedside visit summary
+type HastaBasvuruOzetService interface {
+	GetOzet(basvuruKodu string) (*HastaBasvuruOzet, error)
+}
+
+// News2Service defines the read-only interface for NEWS2 early warning scores
+type News2Service interface {
+	GetByBasvuruKodu(basvuruKodu string, limit int) (*News2Seyri, error)
+	GetBirimEskalasyonlari(birimKodu string, minPuan int) ([]News2Eskalasyon, error)
+}
+
+// MarService defines the read-only interface for the medication administration record
+type MarService interface {
+	GetByBasvuruKodu(basvuruKodu string, baslangic, bitis time.Time) (*MarCizelgesi, error)
+	GetByBirimKodu(birimKodu string, baslangic, bitis time.Time) ([]MarCizelgesi, error)
+}
+
+// IlacAlerjiService defines the read-only interface for drug-allergy conflict checks
+type IlacAlerjiService interface {
+	GetByReceteKodu(receteKodu string) (*ReceteUyumsuzlukRaporu, error)
+	GetByBasvuruKodu(basvuruKodu string) ([]ReceteUyumsuzlukRaporu, error)
+}
+
+// CihazService defines the interface for tablet enrollment, heartbeats and fleet status.
+// Enrollments are stored by MedScreen; VEM tablet records are only read.
+type CihazService interface {
+	KayitKoduOlustur(tabletCihazKodu, olusturan string) (*CihazKayitKoduSonucu, error)
+	Register(seriNumarasi, kayitKodu, ipAdresi string) (*CihazKayitSonucu, error)
+	Nabiz(tabletCihazKodu, anahtar string, nabiz repository.CihazNabzi) error
+	Dogrula(tabletCihazKodu, anahtar string) error
+	Iptal(tabletCihazKodu string) error
+	GetFilo(sorun FiloSorunu, tumu bool) ([]FiloDurumu, error)
+}
+
+// APIAnahtariService defines the interface for the scoped API keys of integrations
+type APIAnahtariService interface {
+	Olustur(istek APIAnahtariIstegi, olusturan string) (*APIAnahtariSonucu, error)
+	GetAll() ([]models.APIAnahtari, error)
+	Iptal(anahtarKodu, iptalEden string) error
+	// Dogrula returns nil for a key that must be rejected; an error means the keys could not be checked
+	Dogrula(anahtar, ipAdresi string) (*models.APIAnahtari, error)
+}
+
+// OturumService defines the interface for login sessions, their idle timeout and revocation
+type OturumService interface {
+	Baslat(claims utils.Claims, sure time.Duration) (string, error)
+	// Aktif reports whether the session and NFC card of a token may still be used
+	Aktif(claims *utils.Claims) (bool, error)
+	Yenile(claims utils.Claims, sure time.Duration) error
+	Sonlandir(filtre repository.OturumFiltresi, sonlandiran, sebep string) (int64, error)
+	GetAktif(filtre repository.OturumFiltresi, page, limit int) ([]models.Oturum, int64, error)
+}
+
+// AuthService defines the interface for NFC login, token refresh and revocation
+type AuthService interface {
+	LoginWithNFC(kartUID, tabletCihazKodu string) (*NFCGirisSonucu, error)
+	// LoginWithSSO issues tokens to a personnel identified by the hospital identity provider
+	LoginWithSSO(personelKodu string) (*SSOGirisSonucu, error)
+	Refresh(refreshToken string) (*AuthTokens, error)
+	Logout(accessClaims *utils.Claims, refreshToken string) error
+	RevokePersonel(personelKodu, iptalEden, sebep string) error
+	RevokeNFCKart(nfcKartKodu, iptalEden, sebep string) error
+	// UnblockPersonel and UnblockNFCKart lift the login block a revocation leaves behind
+	UnblockPersonel(personelKodu, kaldiran string) error
+	UnblockNFCKart(nfcKartKodu, kaldiran string) error
+	// LogoutEverywhere ends every session of the caller and revokes all of their tokens
+	LogoutEverywhere(accessClaims *utils.Claims) error
+	RevokeOturum(oturumKodu, iptalEden, sebep string) error
+	RevokeTabletCihaz(tabletCihazKodu, iptalEden, sebep string) error
+	IsRevoked(claims *utils.Claims) (bool, error)
+	// Escalate lifts the bed binding of a bedside tablet session for a HEKIM
+	Escalate(accessClaims *utils.Claims, sebep string) (*EskalasyonSonucu, error)
+}
+
+// IkinciFaktorService defines the interface for PIN/TOTP second factors and the step-up flow
+type IkinciFaktorService interface {
+	GetDurum(personelKodu string) (*IkinciFaktorDurumu, error)
+	PinBelirle(personelKodu, pin, guncelleyen string) error
+	TOTPOlustur(personelKodu, guncelleyen string) (*TOTPKaydi, error)
+	Sifirla(personelKodu string) error
+	StepUp(accessClaims *utils.Claims, pin, totp string) (*StepUpSonucu, error)
+}
+
+// SSOService defines the interface for logging in through the hospital OpenID Connect provider
+type SSOService interface {
+	Baslat(ctx context.Context) (*SSOBaslangic, error)
+	Tamamla(ctx context.Context, baslangic SSOBaslangic, code, state string) (*SSOGirisSonucu, error)
+}
+
+// AcilErisimService defines the interface for break-the-glass access and its review queue
+type AcilErisimService interface {
+	Baslat(accessClaims *utils.Claims, sebepKodu models.AcilErisimSebebi, gerekce string) (*AcilErisimSonucu, error)
+	GetAll(incelendi *bool, page, limit int) ([]models.AcilErisim, int64, error)
+	GetByKodu(acilErisimKodu string) (*models.AcilErisim, error)
+	GetErisimler(acilErisimKodu string, page, limit int) ([]models.ErisimKaydi, int64, error)
+	Incele(acilErisimKodu, inceleyenPersonelKodu, not string) (*models.AcilErisim, error)
+}
+
+// ErisimKaydiService defines the interface for querying the patient data access audit trail
+type ErisimKaydiService interface {
+	GetByHastaKodu(hastaKodu string, page, limit int) ([]models.ErisimKaydi, int64, error)
+	GetByFilter(filtre repository.ErisimKaydiFiltresi, page, limit int) ([]models.ErisimKaydi, int64, error)
+}
+
+// HL7MesajService defines the interface for the outbox and dead letters of the HL7 v2 feed
+type HL7MesajService interface {
+	GetAll(hedef string, durum models.HL7MesajDurumu, page, limit int) ([]models.HL7Mesaj, int64, error)
+	YenidenGonder(hl7MesajID uint) error
 }
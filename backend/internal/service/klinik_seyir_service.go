@@ -3,8 +3,8 @@ package service
 import (
 	"errors"
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"medscreen/internal/repository"
-	"time"
 )
 
 type klinikSeyirService struct {
@@ -33,12 +33,8 @@ func (s *klinikSeyirService) GetByKodu(kodu string) (*models.KlinikSeyir, error)
 	return seyir, nil
 }
 
-// GetByBasvuruKodu retrieves clinical progress notes by patient visit code
-func (s *klinikSeyirService) GetByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.KlinikSeyir, int64, error) {
-	if basvuruKodu == "" {
-		return nil, 0, errors.New("hasta_basvuru_kodu is required")
-	}
-
+// List retrieves clinical progress notes matching a list query
+func (s *klinikSeyirService) List(q query.Query, page, limit int) ([]models.KlinikSeyir, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -46,38 +42,5 @@ func (s *klinikSeyirService) GetByBasvuruKodu(basvuruKodu string, page, limit in
 		limit = 10
 	}
 
-	return s.repo.FindByBasvuruKodu(basvuruKodu, page, limit)
-}
-
-// GetByFilters retrieves clinical progress notes by various filters
-func (s *klinikSeyirService) GetByFilters(seyirTipi *string, sepsisDurumu *int, startDate, endDate *time.Time, page, limit int) ([]models.KlinikSeyir, int64, error) {
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
-	}
-
-	// If note type filter is provided
-	if seyirTipi != nil && *seyirTipi != "" {
-		if sepsisDurumu != nil {
-			return s.repo.FindBySeyirTipiAndSepsisDurumu(*seyirTipi, *sepsisDurumu, page, limit)
-		}
-		return s.repo.FindBySeyirTipi(*seyirTipi, page, limit)
-	}
-
-	// If sepsis status filter is provided
-	if sepsisDurumu != nil {
-		return s.repo.FindBySepsisDurumu(*sepsisDurumu, page, limit)
-	}
-
-	// If date range filter is provided
-	if startDate != nil && endDate != nil {
-		if startDate.After(*endDate) {
-			return nil, 0, errors.New("start_date must be before end_date")
-		}
-		return s.repo.FindByDateRange(*startDate, *endDate, page, limit)
-	}
-
-	return nil, 0, errors.New("at least one filter (seyir_tipi or date range) is required")
+	return s.repo.List(q, page, limit)
 }
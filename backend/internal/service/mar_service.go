@@ -0,0 +1,214 @@
+package service
+
+import (
+	"errors"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"time"
+)
+
+// MarDurumu is the administration state of a scheduled order detail
+type MarDurumu string
+
+// Administration states of the medication administration record
+const (
+	MarDurumuPlanlandi   MarDurumu = "PLANLANDI"    // not yet due
+	MarDurumuZamaniGeldi MarDurumu = "ZAMANI_GELDI" // within the tolerance around the planned time
+	MarDurumuGecikti     MarDurumu = "GECIKTI"      // past the tolerance, not yet missed
+	MarDurumuVerildi     MarDurumu = "VERILDI"
+	MarDurumuKacirildi   MarDurumu = "KACIRILDI"
+)
+
+// UygulanmaDurumu values of TibbiOrderDetay. VEM 2.0 only defines 0 (pending) and 1 (given);
+// any other value records an administration that did not take place (refused, held).
+const (
+	uygulanmaDurumuBekliyor = 0
+	uygulanmaDurumuVerildi  = 1
+)
+
+// MarPenceresiEnFazla bounds the time window of a single MAR query
+const MarPenceresiEnFazla = 48 * time.Hour
+
+// MarKalemi is a scheduled administration with its state
+type MarKalemi struct {
+	models.TibbiOrderDetay
+	Durum MarDurumu `json:"mar_durumu"`
+	// GecikmeDakika is how late a pending administration is, or how late it was given
+	GecikmeDakika int `json:"gecikme_dakika,omitempty"`
+}
+
+// MarCizelgesi is the medication administration record of a visit, bucketed by state.
+// Every bucket is ordered by planned time.
+type MarCizelgesi struct {
+	HastaBasvuruKodu string                  `json:"hasta_basvuru_kodu"`
+	AnlikYatanHasta  *models.AnlikYatanHasta `json:"anlik_yatan_hasta,omitempty"`
+	Baslangic        time.Time               `json:"baslangic"`
+	Bitis            time.Time               `json:"bitis"`
+	ZamaniGelen      []MarKalemi             `json:"zamani_gelen"`
+	Geciken          []MarKalemi             `json:"geciken"`
+	Verilen          []MarKalemi             `json:"verilen"`
+	Kacirilan        []MarKalemi             `json:"kacirilan"`
+	Planlanan        []MarKalemi             `json:"planlanan"`
+}
+
+type marService struct {
+	orderRepo     repository.TibbiOrderRepository
+	yatanRepo     repository.AnlikYatanHastaRepository
+	tolerans      time.Duration
+	kacirmaSuresi time.Duration
+	now           func() time.Time
+}
+
+// NewMarService creates a new instance of MarService. An administration is due within
+// tolerans of its planned time, overdue after that and missed once it is kacirmaSuresi late.
+func NewMarService(orderRepo repository.TibbiOrderRepository, yatanRepo repository.AnlikYatanHastaRepository, tolerans, kacirmaSuresi time.Duration) MarService {
+	return &marService{
+		orderRepo:     orderRepo,
+		yatanRepo:     yatanRepo,
+		tolerans:      tolerans,
+		kacirmaSuresi: kacirmaSuresi,
+		now:           time.Now,
+	}
+}
+
+// MarDurumuBelirle classifies a scheduled administration at now
+func MarDurumuBelirle(detay *models.TibbiOrderDetay, now time.Time, tolerans, kacirmaSuresi time.Duration) MarDurumu {
+	switch {
+	case detay.UygulanmaDurumu == uygulanmaDurumuVerildi,
+		detay.UygulanmaDurumu == uygulanmaDurumuBekliyor && detay.UygulamaZamani != nil:
+		return MarDurumuVerildi
+	case detay.UygulanmaDurumu != uygulanmaDurumuBekliyor:
+		return MarDurumuKacirildi
+	}
+
+	gecikme := now.Sub(detay.PlanlananUygulamaZamani)
+	switch {
+	case gecikme < -tolerans:
+		return MarDurumuPlanlandi
+	case gecikme <= tolerans:
+		return MarDurumuZamaniGeldi
+	case gecikme < kacirmaSuresi:
+		return MarDurumuGecikti
+	default:
+		return MarDurumuKacirildi
+	}
+}
+
+// GetByBasvuruKodu returns the MAR of a visit for the administrations planned between
+// baslangic and bitis
+func (s *marService) GetByBasvuruKodu(basvuruKodu string, baslangic, bitis time.Time) (*MarCizelgesi, error) {
+	if basvuruKodu == "" {
+		return nil, errors.New("hasta_basvuru_kodu is required")
+	}
+	if err := marPenceresiDogrula(baslangic, bitis); err != nil {
+		return nil, err
+	}
+
+	detaylar, err := s.orderRepo.FindDetayByBasvuruKodlari([]string{basvuruKodu}, baslangic, bitis)
+	if err != nil {
+		return nil, err
+	}
+
+	cizelge := s.yeniCizelge(basvuruKodu, baslangic, bitis)
+	for i := range detaylar {
+		s.ekle(cizelge, &detaylar[i])
+	}
+	return cizelge, nil
+}
+
+// GetByBirimKodu returns the MAR of every inpatient of a unit, in the order of the unit's
+// inpatient list, for shift handover
+func (s *marService) GetByBirimKodu(birimKodu string, baslangic, bitis time.Time) ([]MarCizelgesi, error) {
+	if birimKodu == "" {
+		return nil, errors.New("birim_kodu is required")
+	}
+	if err := marPenceresiDogrula(baslangic, bitis); err != nil {
+		return nil, err
+	}
+
+	var yatanHastalar []models.AnlikYatanHasta
+	for page := 1; ; page++ {
+		sayfa, total, err := s.yatanRepo.FindByBirimKodu(birimKodu, page, 100)
+		if err != nil {
+			return nil, err
+		}
+		yatanHastalar = append(yatanHastalar, sayfa...)
+		if len(sayfa) == 0 || int64(len(yatanHastalar)) >= total {
+			break
+		}
+	}
+
+	cizelgeler := make([]MarCizelgesi, len(yatanHastalar))
+	sira := make(map[string]int, len(yatanHastalar))
+	basvuruKodlari := make([]string, 0, len(yatanHastalar))
+	for i := range yatanHastalar {
+		cizelgeler[i] = *s.yeniCizelge(yatanHastalar[i].HastaBasvuruKodu, baslangic, bitis)
+		cizelgeler[i].AnlikYatanHasta = &yatanHastalar[i]
+		sira[yatanHastalar[i].HastaBasvuruKodu] = i
+		basvuruKodlari = append(basvuruKodlari, yatanHastalar[i].HastaBasvuruKodu)
+	}
+
+	detaylar, err := s.orderRepo.FindDetayByBasvuruKodlari(basvuruKodlari, baslangic, bitis)
+	if err != nil {
+		return nil, err
+	}
+	for i := range detaylar {
+		if detaylar[i].TibbiOrder == nil {
+			continue
+		}
+		if j, ok := sira[detaylar[i].TibbiOrder.HastaBasvuruKodu]; ok {
+			s.ekle(&cizelgeler[j], &detaylar[i])
+		}
+	}
+	return cizelgeler, nil
+}
+
+func (s *marService) yeniCizelge(basvuruKodu string, baslangic, bitis time.Time) *MarCizelgesi {
+	return &MarCizelgesi{
+		HastaBasvuruKodu: basvuruKodu,
+		Baslangic:        baslangic,
+		Bitis:            bitis,
+		ZamaniGelen:      []MarKalemi{},
+		Geciken:          []MarKalemi{},
+		Verilen:          []MarKalemi{},
+		Kacirilan:        []MarKalemi{},
+		Planlanan:        []MarKalemi{},
+	}
+}
+
+// ekle classifies a detail and appends it to its bucket
+func (s *marService) ekle(cizelge *MarCizelgesi, detay *models.TibbiOrderDetay) {
+	now := s.now()
+	kalem := MarKalemi{TibbiOrderDetay: *detay, Durum: MarDurumuBelirle(detay, now, s.tolerans, s.kacirmaSuresi)}
+
+	gecikme := now.Sub(detay.PlanlananUygulamaZamani)
+	if kalem.Durum == MarDurumuVerildi && detay.UygulamaZamani != nil {
+		gecikme = detay.UygulamaZamani.Sub(detay.PlanlananUygulamaZamani)
+	}
+	if gecikme > 0 && kalem.Durum != MarDurumuPlanlandi {
+		kalem.GecikmeDakika = int(gecikme / time.Minute)
+	}
+
+	switch kalem.Durum {
+	case MarDurumuZamaniGeldi:
+		cizelge.ZamaniGelen = append(cizelge.ZamaniGelen, kalem)
+	case MarDurumuGecikti:
+		cizelge.Geciken = append(cizelge.Geciken, kalem)
+	case MarDurumuVerildi:
+		cizelge.Verilen = append(cizelge.Verilen, kalem)
+	case MarDurumuKacirildi:
+		cizelge.Kacirilan = append(cizelge.Kacirilan, kalem)
+	default:
+		cizelge.Planlanan = append(cizelge.Planlanan, kalem)
+	}
+}
+
+func marPenceresiDogrula(baslangic, bitis time.Time) error {
+	if !bitis.After(baslangic) {
+		return errors.New("bitis must be after baslangic")
+	}
+	if bitis.Sub(baslangic) > MarPenceresiEnFazla {
+		return errors.New("time window must not exceed 48 hours")
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"fmt"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+)
+
+// Feature: medication-administration-record, Property 1: Every Dose Is in One Bucket
+// *For any* scheduled administrations of a ward's inpatients, the ward MAR SHALL list each
+// administration exactly once, in its own visit's record, in the bucket given by its state
+// and its distance from the planned time.
+
+type stubMarOrderRepo struct {
+	repository.TibbiOrderRepository
+	detaylar []models.TibbiOrderDetay
+}
+
+func (r *stubMarOrderRepo) FindDetayByBasvuruKodlari(basvuruKodlari []string, startDate, endDate time.Time) ([]models.TibbiOrderDetay, error) {
+	var sonuc []models.TibbiOrderDetay
+	for _, d := range r.detaylar {
+		for _, kodu := range basvuruKodlari {
+			if d.TibbiOrder.HastaBasvuruKodu == kodu && !d.PlanlananUygulamaZamani.Before(startDate) && !d.PlanlananUygulamaZamani.After(endDate) {
+				sonuc = append(sonuc, d)
+			}
+		}
+	}
+	return sonuc, nil
+}
+
+// marDetayi builds a detail of an order of the given visit, planned offset from now
+func marDetayi(kodu, basvuru string, planlanan time.Time, durum int, uygulama *time.Time) models.TibbiOrderDetay {
+	return models.TibbiOrderDetay{
+		TibbiOrderDetayKodu:     kodu,
+		TibbiOrderKodu:          "O-" + basvuru,
+		TibbiOrder:              &models.TibbiOrder{TibbiOrderKodu: "O-" + basvuru, HastaBasvuruKodu: basvuru},
+		PlanlananUygulamaZamani: planlanan,
+		UygulanmaDurumu:         durum,
+		UygulamaZamani:          uygulama,
+	}
+}
+
+// TestProperty_EveryDoseInOneBucket tests Property 1
+func TestProperty_EveryDoseInOneBucket(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		simdi := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+		tolerans, kacirma := 30*time.Minute, 2*time.Hour
+
+		yatanRepo := &stubNews2YatanRepo{}
+		hastaSayisi := rapid.IntRange(0, 5).Draw(rt, "hastaSayisi")
+		for i := 0; i < hastaSayisi; i++ {
+			yatanRepo.yatanHastalar = append(yatanRepo.yatanHastalar, models.AnlikYatanHasta{HastaBasvuruKodu: fmt.Sprintf("B%d", i)})
+		}
+
+		orderRepo := &stubMarOrderRepo{}
+		beklenen := make(map[string]MarDurumu)
+		sahibi := make(map[string]string)
+		detaySayisi := rapid.IntRange(0, 40).Draw(rt, "detaySayisi")
+		for i := 0; i < detaySayisi; i++ {
+			// Visits beyond hastaSayisi are not in the ward
+			hasta := rapid.IntRange(0, 6).Draw(rt, "hasta")
+			basvuru := fmt.Sprintf("B%d", hasta)
+			dakika := rapid.IntRange(-600, 600).Draw(rt, "dakika")
+			planlanan := simdi.Add(time.Duration(dakika) * time.Minute)
+			durum := rapid.IntRange(0, 2).Draw(rt, "durum")
+			var uygulama *time.Time
+			if durum == 1 {
+				u := planlanan.Add(10 * time.Minute)
+				uygulama = &u
+			}
+			kodu := fmt.Sprintf("D%d", i)
+			orderRepo.detaylar = append(orderRepo.detaylar, marDetayi(kodu, basvuru, planlanan, durum, uygulama))
+
+			var d MarDurumu
+			switch {
+			case durum == 1:
+				d = MarDurumuVerildi
+			case durum == 2 || dakika <= -120:
+				d = MarDurumuKacirildi
+			case dakika < -30:
+				d = MarDurumuGecikti
+			case dakika <= 30:
+				d = MarDurumuZamaniGeldi
+			default:
+				d = MarDurumuPlanlandi
+			}
+			if hasta < hastaSayisi {
+				beklenen[kodu] = d
+				sahibi[kodu] = basvuru
+			}
+		}
+
+		svc := &marService{orderRepo: orderRepo, yatanRepo: yatanRepo, tolerans: tolerans, kacirmaSuresi: kacirma, now: func() time.Time { return simdi }}
+		cizelgeler, err := svc.GetByBirimKodu("DAHILIYE", simdi.Add(-12*time.Hour), simdi.Add(12*time.Hour))
+		if err != nil {
+			rt.Fatalf("Unexpected error: %v", err)
+		}
+		if len(cizelgeler) != hastaSayisi {
+			rt.Fatalf("Expected one record per inpatient, got %d", len(cizelgeler))
+		}
+
+		gorulen := make(map[string]bool)
+		for _, c := range cizelgeler {
+			kovalar := map[MarDurumu][]MarKalemi{
+				MarDurumuZamaniGeldi: c.ZamaniGelen,
+				MarDurumuGecikti:     c.Geciken,
+				MarDurumuVerildi:     c.Verilen,
+				MarDurumuKacirildi:   c.Kacirilan,
+				MarDurumuPlanlandi:   c.Planlanan,
+			}
+			for durum, kalemler := range kovalar {
+				for _, k := range kalemler {
+					kodu := k.TibbiOrderDetayKodu
+					if gorulen[kodu] || beklenen[kodu] != durum || k.Durum != durum || sahibi[kodu] != c.HastaBasvuruKodu {
+						rt.Fatalf("%s listed as %s under %s, expected %s under %s", kodu, durum, c.HastaBasvuruKodu, beklenen[kodu], sahibi[kodu])
+					}
+					gorulen[kodu] = true
+				}
+			}
+		}
+		if len(gorulen) != len(beklenen) {
+			rt.Fatalf("Expected %d administrations, got %d", len(beklenen), len(gorulen))
+		}
+	})
+}
+
+// TestMar_VisitRecord verifies delays, the resolved administering personnel and window validation
+func TestMar_VisitRecord(t *testing.T) {
+	simdi := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	verildi := simdi.Add(-3*time.Hour + 15*time.Minute)
+	personelKodu := "P000002"
+	verilen := marDetayi("D1", "B1", simdi.Add(-3*time.Hour), 1, &verildi)
+	verilen.UygulayanPersonelKodu = &personelKodu
+	verilen.UygulayanPersonel = &models.Personel{PersonelKodu: personelKodu}
+
+	orderRepo := &stubMarOrderRepo{detaylar: []models.TibbiOrderDetay{
+		verilen,
+		marDetayi("D2", "B1", simdi.Add(-45*time.Minute), 0, nil),
+		marDetayi("D3", "B1", simdi.Add(time.Hour), 0, nil),
+	}}
+	svc := NewMarService(orderRepo, &stubNews2YatanRepo{}, 30*time.Minute, 2*time.Hour).(*marService)
+	svc.now = func() time.Time { return simdi }
+
+	cizelge, err := svc.GetByBasvuruKodu("B1", simdi.Add(-12*time.Hour), simdi.Add(12*time.Hour))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(cizelge.Verilen) != 1 || cizelge.Verilen[0].GecikmeDakika != 15 || cizelge.Verilen[0].UygulayanPersonel == nil {
+		t.Errorf("Unexpected given administrations: %+v", cizelge.Verilen)
+	}
+	if len(cizelge.Geciken) != 1 || cizelge.Geciken[0].GecikmeDakika != 45 {
+		t.Errorf("Unexpected overdue administrations: %+v", cizelge.Geciken)
+	}
+	if len(cizelge.Planlanan) != 1 || cizelge.Planlanan[0].GecikmeDakika != 0 || len(cizelge.ZamaniGelen) != 0 || len(cizelge.Kacirilan) != 0 {
+		t.Errorf("Unexpected buckets: %+v", cizelge)
+	}
+
+	if _, err := svc.GetByBasvuruKodu("B1", simdi, simdi.Add(-time.Hour)); err == nil {
+		t.Error("Expected an error for an inverted window")
+	}
+	if _, err := svc.GetByBasvuruKodu("B1", simdi, simdi.Add(72*time.Hour)); err == nil {
+		t.Error("Expected an error for a window over 48 hours")
+	}
+}
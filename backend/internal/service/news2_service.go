@@ -0,0 +1,175 @@
+package service
+
+import (
+	"errors"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"medscreen/internal/scoring"
+	"sort"
+	"time"
+)
+
+// News2Olcum is the NEWS2 score of a single vital signs record
+type News2Olcum struct {
+	HastaVitalFizikiBulguKodu string    `json:"hasta_vital_fiziki_bulgu_kodu"`
+	IslemZamani               time.Time `json:"islem_zamani"`
+	scoring.News2
+}
+
+// News2Seyri is the NEWS2 series of a visit, oldest measurement first
+type News2Seyri struct {
+	HastaBasvuruKodu string         `json:"hasta_basvuru_kodu"`
+	Olcumler         []News2Olcum   `json:"olcumler"`
+	Son              *News2Olcum    `json:"son,omitempty"`
+	Egilim           scoring.Egilim `json:"egilim"`
+	Degisim          int            `json:"degisim"`
+}
+
+// News2Eskalasyon is an inpatient whose latest NEWS2 score crosses an escalation threshold
+type News2Eskalasyon struct {
+	AnlikYatanHastaKodu string         `json:"anlik_yatan_hasta_kodu"`
+	HastaKodu           string         `json:"hasta_kodu"`
+	HastaBasvuruKodu    string         `json:"hasta_basvuru_kodu"`
+	YatakKodu           string         `json:"yatak_kodu"`
+	Hasta               *models.Hasta  `json:"hasta,omitempty"`
+	Son                 News2Olcum     `json:"son"`
+	Egilim              scoring.Egilim `json:"egilim"`
+	Degisim             int            `json:"degisim"`
+}
+
+type news2Service struct {
+	vitalRepo repository.HastaVitalFizikiBulguRepository
+	yatanRepo repository.AnlikYatanHastaRepository
+}
+
+// NewNews2Service creates a new instance of News2Service
+func NewNews2Service(vitalRepo repository.HastaVitalFizikiBulguRepository, yatanRepo repository.AnlikYatanHastaRepository) News2Service {
+	return &news2Service{vitalRepo: vitalRepo, yatanRepo: yatanRepo}
+}
+
+// GetByBasvuruKodu scores the most recent limit measurements of a visit
+func (s *news2Service) GetByBasvuruKodu(basvuruKodu string, limit int) (*News2Seyri, error) {
+	if basvuruKodu == "" {
+		return nil, errors.New("hasta_basvuru_kodu is required")
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	olcumler, err := s.sonOlcumler(basvuruKodu, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	seyri := &News2Seyri{HastaBasvuruKodu: basvuruKodu, Olcumler: olcumler}
+	seyri.Egilim, seyri.Degisim = egilim(olcumler)
+	if len(olcumler) > 0 {
+		seyri.Son = &olcumler[len(olcumler)-1]
+	}
+	return seyri, nil
+}
+
+// GetBirimEskalasyonlari lists the inpatients of a unit whose latest score needs escalation,
+// highest score first. With minPuan > 0 only patients scoring at least minPuan are listed;
+// otherwise every patient above the low risk band is.
+func (s *news2Service) GetBirimEskalasyonlari(birimKodu string, minPuan int) ([]News2Eskalasyon, error) {
+	if birimKodu == "" {
+		return nil, errors.New("birim_kodu is required")
+	}
+
+	var yatanHastalar []models.AnlikYatanHasta
+	for page := 1; ; page++ {
+		sayfa, total, err := s.yatanRepo.FindByBirimKodu(birimKodu, page, 100)
+		if err != nil {
+			return nil, err
+		}
+		yatanHastalar = append(yatanHastalar, sayfa...)
+		if len(sayfa) == 0 || int64(len(yatanHastalar)) >= total {
+			break
+		}
+	}
+
+	// The previous measurement is only needed for the trend; the measurements of the
+	// whole ward are loaded with one query
+	basvuruKodlari := make([]string, len(yatanHastalar))
+	for i, yatanHasta := range yatanHastalar {
+		basvuruKodlari[i] = yatanHasta.HastaBasvuruKodu
+	}
+	bulgular, err := s.vitalRepo.FindSonByBasvuruKodlari(basvuruKodlari, 2)
+	if err != nil {
+		return nil, err
+	}
+	basvuruBulgulari := make(map[string][]models.HastaVitalFizikiBulgu)
+	for _, bulgu := range bulgular {
+		basvuruBulgulari[bulgu.HastaBasvuruKodu] = append(basvuruBulgulari[bulgu.HastaBasvuruKodu], bulgu)
+	}
+
+	eskalasyonlar := make([]News2Eskalasyon, 0)
+	for _, yatanHasta := range yatanHastalar {
+		olcumler := puanla(basvuruBulgulari[yatanHasta.HastaBasvuruKodu])
+		if len(olcumler) == 0 {
+			continue
+		}
+
+		son := olcumler[len(olcumler)-1]
+		esikte := son.Eskalasyon()
+		if minPuan > 0 {
+			esikte = son.ToplamPuan >= minPuan
+		}
+		if !esikte {
+			continue
+		}
+
+		eskalasyon := News2Eskalasyon{
+			AnlikYatanHastaKodu: yatanHasta.AnlikYatanHastaKodu,
+			HastaKodu:           yatanHasta.HastaKodu,
+			HastaBasvuruKodu:    yatanHasta.HastaBasvuruKodu,
+			YatakKodu:           yatanHasta.YatakKodu,
+			Hasta:               yatanHasta.Hasta,
+			Son:                 son,
+		}
+		eskalasyon.Egilim, eskalasyon.Degisim = egilim(olcumler)
+		eskalasyonlar = append(eskalasyonlar, eskalasyon)
+	}
+
+	sort.SliceStable(eskalasyonlar, func(i, j int) bool {
+		a, b := eskalasyonlar[i].Son, eskalasyonlar[j].Son
+		if a.RiskDuzeyi != b.RiskDuzeyi {
+			return a.RiskDuzeyi.AtLeast(b.RiskDuzeyi)
+		}
+		return a.ToplamPuan > b.ToplamPuan
+	})
+	return eskalasyonlar, nil
+}
+
+// sonOlcumler scores the most recent limit measurements of a visit, oldest first
+func (s *news2Service) sonOlcumler(basvuruKodu string, limit int) ([]News2Olcum, error) {
+	bulgular, _, err := s.vitalRepo.FindByBasvuruKodu(basvuruKodu, 1, limit)
+	if err != nil {
+		return nil, err
+	}
+	return puanla(bulgular), nil
+}
+
+// puanla scores measurements given newest first, as the repository returns them, and
+// returns them oldest first
+func puanla(bulgular []models.HastaVitalFizikiBulgu) []News2Olcum {
+	olcumler := make([]News2Olcum, len(bulgular))
+	for i := range bulgular {
+		bulgu := &bulgular[i]
+		olcumler[len(bulgular)-1-i] = News2Olcum{
+			HastaVitalFizikiBulguKodu: bulgu.HastaVitalFizikiBulguKodu,
+			IslemZamani:               bulgu.IslemZamani,
+			News2:                     scoring.Hesapla(scoring.OlcumFromVital(bulgu)),
+		}
+	}
+	return olcumler
+}
+
+func egilim(olcumler []News2Olcum) (scoring.Egilim, int) {
+	puanlar := make([]int, len(olcumler))
+	for i, olcum := range olcumler {
+		puanlar[i] = olcum.ToplamPuan
+	}
+	return scoring.EgilimHesapla(puanlar)
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"fmt"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"medscreen/internal/scoring"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+)
+
+// Feature: news2-early-warning, Property 2: Ward Escalation List
+// *For any* set of inpatients in a unit, GetBirimEskalasyonlari SHALL list exactly the
+// patients whose latest measurement is above the low risk band, highest risk first.
+
+type stubNews2VitalRepo struct {
+	repository.HastaVitalFizikiBulguRepository
+	bulgular map[string][]models.HastaVitalFizikiBulgu // newest first
+
+	topluSorgular int
+}
+
+func (r *stubNews2VitalRepo) FindByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.HastaVitalFizikiBulgu, int64, error) {
+	bulgular := r.bulgular[basvuruKodu]
+	total := int64(len(bulgular))
+	start := (page - 1) * limit
+	if start >= len(bulgular) {
+		return nil, total, nil
+	}
+	end := start + limit
+	if end > len(bulgular) {
+		end = len(bulgular)
+	}
+	return bulgular[start:end], total, nil
+}
+
+// FindSonByBasvuruKodlari counts the calls so tests can check the ward is loaded at once
+func (r *stubNews2VitalRepo) FindSonByBasvuruKodlari(basvuruKodlari []string, limit int) ([]models.HastaVitalFizikiBulgu, error) {
+	r.topluSorgular++
+	var sonuc []models.HastaVitalFizikiBulgu
+	for _, basvuruKodu := range basvuruKodlari {
+		bulgular := r.bulgular[basvuruKodu]
+		if len(bulgular) > limit {
+			bulgular = bulgular[:limit]
+		}
+		for _, bulgu := range bulgular {
+			bulgu.HastaBasvuruKodu = basvuruKodu
+			sonuc = append(sonuc, bulgu)
+		}
+	}
+	return sonuc, nil
+}
+
+type stubNews2YatanRepo struct {
+	repository.AnlikYatanHastaRepository
+	yatanHastalar []models.AnlikYatanHasta
+}
+
+func (r *stubNews2YatanRepo) FindByBirimKodu(birimKodu string, page, limit int) ([]models.AnlikYatanHasta, int64, error) {
+	total := int64(len(r.yatanHastalar))
+	start := (page - 1) * limit
+	if start >= len(r.yatanHastalar) {
+		return nil, total, nil
+	}
+	end := start + limit
+	if end > len(r.yatanHastalar) {
+		end = len(r.yatanHastalar)
+	}
+	return r.yatanHastalar[start:end], total, nil
+}
+
+// vitalOlcumu builds a vital signs record with ward-style free-text values
+func vitalOlcumu(kodu string, zaman time.Time, solunum, saturasyon, sistolik, nabiz int, ates string) models.HastaVitalFizikiBulgu {
+	str := func(v int) *string {
+		s := fmt.Sprint(v)
+		return &s
+	}
+	return models.HastaVitalFizikiBulgu{
+		HastaVitalFizikiBulguKodu: kodu,
+		IslemZamani:               zaman,
+		Solunum:                   str(solunum),
+		Saturasyon:                str(saturasyon),
+		SistolikKanBasinciDegeri:  str(sistolik),
+		Nabiz:                     str(nabiz),
+		Ates:                      &ates,
+	}
+}
+
+// TestProperty_WardEscalationList tests Property 2
+func TestProperty_WardEscalationList(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		hastaSayisi := rapid.IntRange(0, 120).Draw(rt, "hastaSayisi")
+		yatanRepo := &stubNews2YatanRepo{}
+		vitalRepo := &stubNews2VitalRepo{bulgular: make(map[string][]models.HastaVitalFizikiBulgu)}
+		beklenen := make(map[string]bool)
+
+		simdi := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+		for i := 0; i < hastaSayisi; i++ {
+			basvuru := fmt.Sprintf("B%03d", i)
+			yatanRepo.yatanHastalar = append(yatanRepo.yatanHastalar, models.AnlikYatanHasta{
+				AnlikYatanHastaKodu: fmt.Sprintf("AYH%03d", i),
+				HastaBasvuruKodu:    basvuru,
+				HastaKodu:           fmt.Sprintf("H%03d", i),
+				YatakKodu:           fmt.Sprintf("Y%03d", i),
+			})
+			if !rapid.Bool().Draw(rt, "olcumVar") {
+				continue
+			}
+			son := vitalOlcumu(basvuru+"-2", simdi,
+				rapid.IntRange(5, 30).Draw(rt, "solunum"),
+				rapid.IntRange(85, 100).Draw(rt, "saturasyon"),
+				rapid.IntRange(80, 230).Draw(rt, "sistolik"),
+				rapid.IntRange(35, 140).Draw(rt, "nabiz"),
+				rapid.SampledFrom([]string{"34,9", "36,5", "37,8 °C", "38,6", "39.4"}).Draw(rt, "ates"))
+			onceki := vitalOlcumu(basvuru+"-1", simdi.Add(-4*time.Hour), 16, 98, 120, 70, "36,8")
+			vitalRepo.bulgular[basvuru] = []models.HastaVitalFizikiBulgu{son, onceki}
+
+			if scoring.Hesapla(scoring.OlcumFromVital(&son)).Eskalasyon() {
+				beklenen[basvuru] = true
+			}
+		}
+
+		svc := NewNews2Service(vitalRepo, yatanRepo)
+		eskalasyonlar, err := svc.GetBirimEskalasyonlari("DAHILIYE", 0)
+		if err != nil {
+			rt.Fatalf("Unexpected error: %v", err)
+		}
+		if vitalRepo.topluSorgular != 1 {
+			rt.Fatalf("Expected the ward's measurements to be loaded with one query, got %d", vitalRepo.topluSorgular)
+		}
+
+		if len(eskalasyonlar) != len(beklenen) {
+			rt.Fatalf("Expected %d escalations, got %d", len(beklenen), len(eskalasyonlar))
+		}
+		for i, e := range eskalasyonlar {
+			if !beklenen[e.HastaBasvuruKodu] || e.Son.HastaVitalFizikiBulguKodu != e.HastaBasvuruKodu+"-2" {
+				rt.Fatalf("Unexpected escalation: %+v", e)
+			}
+			if e.Egilim == scoring.EgilimBelirsiz {
+				rt.Fatalf("Trend not computed for %s", e.HastaBasvuruKodu)
+			}
+			if i > 0 && !eskalasyonlar[i-1].Son.RiskDuzeyi.AtLeast(e.Son.RiskDuzeyi) {
+				rt.Fatalf("Escalations not ordered by risk: %s before %s", eskalasyonlar[i-1].Son.RiskDuzeyi, e.Son.RiskDuzeyi)
+			}
+		}
+	})
+}
+
+// TestNews2_SeriesOldestFirst verifies the per-visit series order, trend and latest score
+func TestNews2_SeriesOldestFirst(t *testing.T) {
+	simdi := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	vitalRepo := &stubNews2VitalRepo{bulgular: map[string][]models.HastaVitalFizikiBulgu{
+		"B1": {
+			vitalOlcumu("V3", simdi, 26, 90, 88, 135, "39,5"),
+			vitalOlcumu("V2", simdi.Add(-time.Hour), 22, 94, 105, 100, "38,2"),
+			vitalOlcumu("V1", simdi.Add(-2*time.Hour), 16, 98, 120, 70, "36,8"),
+		},
+	}}
+	svc := NewNews2Service(vitalRepo, &stubNews2YatanRepo{})
+
+	seyri, err := svc.GetByBasvuruKodu("B1", 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(seyri.Olcumler) != 3 || seyri.Olcumler[0].HastaVitalFizikiBulguKodu != "V1" || seyri.Son.HastaVitalFizikiBulguKodu != "V3" {
+		t.Fatalf("Unexpected series order: %+v", seyri.Olcumler)
+	}
+	if seyri.Olcumler[0].ToplamPuan != 0 || seyri.Son.ToplamPuan != 14 || seyri.Son.RiskDuzeyi != scoring.RiskYuksek {
+		t.Errorf("Unexpected scores: first=%d last=%d (%s)", seyri.Olcumler[0].ToplamPuan, seyri.Son.ToplamPuan, seyri.Son.RiskDuzeyi)
+	}
+	if seyri.Egilim != scoring.EgilimArtiyor || seyri.Degisim != seyri.Son.ToplamPuan-seyri.Olcumler[1].ToplamPuan {
+		t.Errorf("Unexpected trend: %s %d", seyri.Egilim, seyri.Degisim)
+	}
+
+	if _, err := svc.GetByBasvuruKodu("", 10); err == nil {
+		t.Error("Expected an error for an empty visit code")
+	}
+}
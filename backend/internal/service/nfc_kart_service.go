@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"medscreen/internal/repository"
 )
 
@@ -49,12 +50,8 @@ func (s *nfcKartService) GetByKartUID(kartUID string) (*models.NFCKart, error) {
 	return nfcKart, nil
 }
 
-// GetByPersonelKodu retrieves NFC cards by personnel code
-func (s *nfcKartService) GetByPersonelKodu(personelKodu string, page, limit int) ([]models.NFCKart, int64, error) {
-	if personelKodu == "" {
-		return nil, 0, errors.New("personel_kodu is required")
-	}
-
+// List retrieves NFC cards matching a list query
+func (s *nfcKartService) List(q query.Query, page, limit int) ([]models.NFCKart, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -62,5 +59,5 @@ func (s *nfcKartService) GetByPersonelKodu(personelKodu string, page, limit int)
 		limit = 10
 	}
 
-	return s.repo.FindByPersonelKodu(personelKodu, page, limit)
+	return s.repo.List(q, page, limit)
 }
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"errors"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"medscreen/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OturumEtkinlikAraligi is how often the last activity of a session is written; the idle
+// timeout is therefore only accurate to this interval
+const OturumEtkinlikAraligi = time.Minute
+
+// Why a session ended (Oturum.SonlanmaSebebi) when no reason was given
+const (
+	OturumSonuCikis      = "CIKIS"       // logged out
+	OturumSonuHerYerden  = "HER_YERDEN"  // logged out everywhere
+	OturumSonuIptal      = "IPTAL"       // revoked by an administrator
+	OturumSonuZamanAsimi = "ZAMAN_ASIMI" // idle for longer than the idle timeout
+	OturumSonuKartPasif  = "KART_PASIF"  // the NFC card was deactivated
+)
+
+// OturumSistemKodu is recorded as SonlandiranPersonelKodu of sessions MedScreen ended itself
+const OturumSistemKodu = "SYSTEM"
+
+// ErrOturumBulunamadi is returned when no active session matches
+var ErrOturumBulunamadi = errors.New("session not found or already ended")
+
+type oturumService struct {
+	repo        repository.OturumRepository
+	nfcKartRepo repository.NFCKartRepository
+	zamanAsimi  time.Duration
+	now         func() time.Time
+}
+
+// NewOturumService creates a new instance of OturumService.
+// A session ends after zamanAsimi without a request.
+func NewOturumService(repo repository.OturumRepository, nfcKartRepo repository.NFCKartRepository, zamanAsimi time.Duration) OturumService {
+	return &oturumService{
+		repo:        repo,
+		nfcKartRepo: nfcKartRepo,
+		zamanAsimi:  zamanAsimi,
+		now:         time.Now,
+	}
+}
+
+// Baslat opens a session for the identity in claims; it lasts for sure unless it is refreshed
+func (s *oturumService) Baslat(claims utils.Claims, sure time.Duration) (string, error) {
+	kodu, err := utils.NewRandomID()
+	if err != nil {
+		return "", err
+	}
+	now := s.now()
+	oturum := &models.Oturum{
+		OturumKodu:          kodu,
+		PersonelKodu:        claims.PersonelKodu,
+		GirisYontemi:        claims.GirisYontemi,
+		NFCKartKodu:         bosDegilse(claims.NFCKartKodu),
+		TabletCihazKodu:     bosDegilse(claims.TabletCihazKodu),
+		BirimKodu:           bosDegilse(claims.BirimKodu),
+		YatakKodu:           bosDegilse(claims.YatakKodu),
+		BaslangicZamani:     now,
+		SonEtkinlikZamani:   now,
+		SonGecerlilikZamani: now.Add(sure),
+	}
+	if err := s.repo.Create(oturum); err != nil {
+		return "", err
+	}
+	return kodu, nil
+}
+
+// Aktif reports whether a token may still be used: its NFC card must still be active and
+// its session must not have ended, expired or been idle for longer than the idle timeout.
+// Sessions found idle or opened with a deactivated card are ended on the way. Tokens
+// issued before sessions existed carry no session and are only checked for their card.
+func (s *oturumService) Aktif(claims *utils.Claims) (bool, error) {
+	now := s.now()
+
+	if claims.NFCKartKodu != "" {
+		nfcKart, err := s.nfcKartRepo.FindByKodu(claims.NFCKartKodu)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, err
+		}
+		if nfcKart == nil || nfcKart.AktiflikBilgisi != 1 {
+			s.sonlandir(repository.OturumFiltresi{NFCKartKodu: claims.NFCKartKodu}, OturumSonuKartPasif, now)
+			return false, nil
+		}
+	}
+
+	if claims.OturumKodu == "" {
+		return true, nil
+	}
+	oturum, err := s.repo.FindByKodu(claims.OturumKodu)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if oturum.SonlanmaZamani != nil || !now.Before(oturum.SonGecerlilikZamani) {
+		return false, nil
+	}
+	bosta := now.Sub(oturum.SonEtkinlikZamani)
+	if bosta > s.zamanAsimi {
+		s.sonlandir(repository.OturumFiltresi{OturumKodu: oturum.OturumKodu}, OturumSonuZamanAsimi, now)
+		return false, nil
+	}
+	if bosta >= OturumEtkinlikAraligi {
+		// Best effort; a missed update only brings the idle timeout a little closer
+		_ = s.repo.UpdateEtkinlik(oturum.OturumKodu, now)
+	}
+	return true, nil
+}
+
+// Yenile records that the tokens of a session were refreshed: the session lasts for sure
+// from now and follows the tablet if it was moved to another bed or ward
+func (s *oturumService) Yenile(claims utils.Claims, sure time.Duration) error {
+	if claims.OturumKodu == "" {
+		return nil
+	}
+	now := s.now()
+	err := s.repo.Yenile(claims.OturumKodu, repository.OturumYenileme{
+		Zaman:               now,
+		SonGecerlilikZamani: now.Add(sure),
+		BirimKodu:           bosDegilse(claims.BirimKodu),
+		YatakKodu:           bosDegilse(claims.YatakKodu),
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrOturumBulunamadi
+	}
+	return err
+}
+
+// Sonlandir ends the active sessions matching a non-empty filter and returns how many were ended
+func (s *oturumService) Sonlandir(filtre repository.OturumFiltresi, sonlandiran, sebep string) (int64, error) {
+	if filtre == (repository.OturumFiltresi{}) {
+		return 0, errors.New("a session, personnel, card or tablet is required")
+	}
+	if sebep == "" {
+		sebep = OturumSonuIptal
+	}
+	return s.repo.Sonlandir(filtre, repository.OturumSonu{
+		Zaman:                   s.now(),
+		SonlandiranPersonelKodu: sonlandiran,
+		Sebep:                   sebep,
+	})
+}
+
+// GetAktif lists the active sessions matching the filter, most recently active first
+func (s *oturumService) GetAktif(filtre repository.OturumFiltresi, page, limit int) ([]models.Oturum, int64, error) {
+	now := s.now()
+	return s.repo.FindAktif(filtre, now, now.Add(-s.zamanAsimi), page, limit)
+}
+
+// sonlandir ends sessions the system found unusable; a failure is retried on the next request
+func (s *oturumService) sonlandir(filtre repository.OturumFiltresi, sebep string, now time.Time) {
+	_, _ = s.repo.Sonlandir(filtre, repository.OturumSonu{
+		Zaman:                   now,
+		SonlandiranPersonelKodu: OturumSistemKodu,
+		Sebep:                   sebep,
+	})
+}
+
+func bosDegilse(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
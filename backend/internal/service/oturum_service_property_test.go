@@ -0,0 +1,213 @@
+package service
+
+import (
+	"errors"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"medscreen/internal/utils"
+	"sort"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"pgregory.net/rapid"
+)
+
+// Feature: sessions, Property 1: Tokens Stop Working When Their Session Ends
+// *For any* NFC login, its tokens SHALL be accepted and refreshed within the same session
+// until the session is logged out, logged out everywhere, revoked for its tablet, idle for
+// longer than the timeout, or its NFC card is deactivated; from then on they SHALL be
+// rejected and the session SHALL no longer be listed as active for its ward.
+
+// mockOturumRepository is an in-memory OturumRepository for testing
+type mockOturumRepository struct {
+	oturumlar map[string]*models.Oturum
+}
+
+func newMockOturumRepository() *mockOturumRepository {
+	return &mockOturumRepository{oturumlar: make(map[string]*models.Oturum)}
+}
+
+func (m *mockOturumRepository) Create(oturum *models.Oturum) error {
+	oturum.OturumID = uint(len(m.oturumlar) + 1)
+	kopya := *oturum
+	m.oturumlar[oturum.OturumKodu] = &kopya
+	return nil
+}
+
+func (m *mockOturumRepository) FindByKodu(kodu string) (*models.Oturum, error) {
+	if oturum, ok := m.oturumlar[kodu]; ok {
+		kopya := *oturum
+		return &kopya, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *mockOturumRepository) FindAktif(filtre repository.OturumFiltresi, now, enErkenEtkinlik time.Time, page, limit int) ([]models.Oturum, int64, error) {
+	var sonuc []models.Oturum
+	for _, oturum := range m.oturumlar {
+		if eslesir(oturum, filtre) && oturum.SonlanmaZamani == nil &&
+			oturum.SonGecerlilikZamani.After(now) && !oturum.SonEtkinlikZamani.Before(enErkenEtkinlik) {
+			sonuc = append(sonuc, *oturum)
+		}
+	}
+	sort.Slice(sonuc, func(i, j int) bool { return sonuc[i].SonEtkinlikZamani.After(sonuc[j].SonEtkinlikZamani) })
+	return sonuc, int64(len(sonuc)), nil
+}
+
+func (m *mockOturumRepository) UpdateEtkinlik(kodu string, zaman time.Time) error {
+	if oturum, ok := m.oturumlar[kodu]; ok {
+		oturum.SonEtkinlikZamani = zaman
+	}
+	return nil
+}
+
+func (m *mockOturumRepository) Yenile(kodu string, yenileme repository.OturumYenileme) error {
+	oturum, ok := m.oturumlar[kodu]
+	if !ok || oturum.SonlanmaZamani != nil {
+		return gorm.ErrRecordNotFound
+	}
+	oturum.SonEtkinlikZamani = yenileme.Zaman
+	oturum.SonGecerlilikZamani = yenileme.SonGecerlilikZamani
+	oturum.BirimKodu = yenileme.BirimKodu
+	oturum.YatakKodu = yenileme.YatakKodu
+	return nil
+}
+
+func (m *mockOturumRepository) Sonlandir(filtre repository.OturumFiltresi, son repository.OturumSonu) (int64, error) {
+	if filtre == (repository.OturumFiltresi{}) {
+		return 0, errors.New("ending sessions requires a filter")
+	}
+	var sayi int64
+	for _, oturum := range m.oturumlar {
+		if eslesir(oturum, filtre) && oturum.SonlanmaZamani == nil {
+			zaman, sonlandiran, sebep := son.Zaman, son.SonlandiranPersonelKodu, son.Sebep
+			oturum.SonlanmaZamani = &zaman
+			oturum.SonlandiranPersonelKodu = &sonlandiran
+			oturum.SonlanmaSebebi = &sebep
+			sayi++
+		}
+	}
+	return sayi, nil
+}
+
+func eslesir(oturum *models.Oturum, filtre repository.OturumFiltresi) bool {
+	esit := func(deger *string, beklenen string) bool {
+		return beklenen == "" || (deger != nil && *deger == beklenen)
+	}
+	return (filtre.OturumKodu == "" || oturum.OturumKodu == filtre.OturumKodu) &&
+		(filtre.PersonelKodu == "" || oturum.PersonelKodu == filtre.PersonelKodu) &&
+		esit(oturum.NFCKartKodu, filtre.NFCKartKodu) &&
+		esit(oturum.TabletCihazKodu, filtre.TabletCihazKodu) &&
+		esit(oturum.BirimKodu, filtre.BirimKodu)
+}
+
+func TestProperty_SessionsEndTokens(t *testing.T) {
+	const zamanAsimi = 30 * time.Minute
+
+	rapid.Check(t, func(t *rapid.T) {
+		personelRepo := newMockPersonelRepository()
+		personelRepo.addPersonel(&models.Personel{PersonelKodu: "P000001", PersonelGorevKodu: string(models.GorevHemsire), AktiflikBilgisi: 1})
+		nfcKartRepo := newMockNFCKartRepository()
+		kart := &models.NFCKart{NFCKartKodu: "K1", PersonelKodu: "P000001", KartUID: "UID1", AktiflikBilgisi: 1}
+		nfcKartRepo.addKart(kart)
+		tabletRepo := &mockTabletCihazRepository{tablets: map[string]*models.TabletCihaz{
+			"TBL3": {TabletCihazKodu: "TBL3", AktiflikBilgisi: true, YatakKodu: &testYatakKodu, Yatak: &models.Yatak{YatakKodu: testYatakKodu, BirimKodu: "DAHILIYE"}},
+		}}
+
+		oturumRepo := newMockOturumRepository()
+		oturumService := NewOturumService(oturumRepo, nfcKartRepo, zamanAsimi).(*oturumService)
+		now := time.Now()
+		oturumService.now = func() time.Time { return now }
+		svc := NewAuthService(NewPersonelService(personelRepo, nfcKartRepo), nfcKartRepo, tabletRepo,
+			&mockTokenIptalRepository{}, &mockGirisEngeliRepository{}, &mockYatakKisitiKaldirmaRepository{}, oturumService, 15*time.Minute, 12*time.Hour)
+
+		tablet := rapid.SampledFrom([]string{"", "TBL3"}).Draw(t, "tablet")
+		sonuc, err := svc.LoginWithNFC("UID1", tablet)
+		if err != nil {
+			t.Fatalf("Login failed: %v", err)
+		}
+		access := mustParse(t, sonuc.AccessToken)
+		if access.OturumKodu == "" {
+			t.Fatal("Expected the token to carry its session")
+		}
+		// A second login of the same personnel on another device
+		diger, err := svc.LoginWithNFC("UID1", "")
+		if err != nil {
+			t.Fatalf("Second login failed: %v", err)
+		}
+
+		bosta := time.Duration(rapid.IntRange(0, 29).Draw(t, "idleMinutes")) * time.Minute
+		islem := rapid.SampledFrom([]string{"none", "idle", "logout", "everywhere", "tablet", "card"}).Draw(t, "action")
+		switch islem {
+		case "idle":
+			bosta = zamanAsimi + time.Duration(rapid.IntRange(1, 120).Draw(t, "overMinutes"))*time.Minute
+		case "logout":
+			err = svc.Logout(access, "")
+		case "everywhere":
+			err = svc.LogoutEverywhere(access)
+		case "tablet":
+			err = svc.RevokeTabletCihaz("TBL3", "ADMIN", "")
+			if tablet == "" {
+				if !errors.Is(err, ErrOturumBulunamadi) {
+					t.Fatalf("Expected no session on the tablet, got %v", err)
+				}
+				err = nil
+			}
+		case "card":
+			kart.AktiflikBilgisi = 0
+		}
+		if err != nil {
+			t.Fatalf("%s failed: %v", islem, err)
+		}
+		now = now.Add(bosta)
+
+		bitti := islem != "none" && !(islem == "tablet" && tablet == "")
+		revoked, err := svc.IsRevoked(access)
+		if err != nil {
+			t.Fatalf("IsRevoked failed: %v", err)
+		}
+		if revoked != bitti {
+			t.Fatalf("After %s (tablet %q, idle %v): expected revoked=%v, got %v", islem, tablet, bosta, bitti, revoked)
+		}
+
+		yeni, err := svc.Refresh(sonuc.RefreshToken)
+		if bitti {
+			if err == nil {
+				t.Fatalf("Expected the refresh token of an ended session (%s) to be rejected", islem)
+			}
+		} else {
+			if err != nil {
+				t.Fatalf("Refresh failed: %v", err)
+			}
+			if mustParse(t, yeni.AccessToken).OturumKodu != access.OturumKodu {
+				t.Fatal("Expected refreshed tokens to stay in the same session")
+			}
+		}
+
+		// The other session ends with the personnel, the card or its own idle time, not with this session
+		digerBitti := islem == "everywhere" || islem == "card" || islem == "idle"
+		if revoked, err := svc.IsRevoked(mustParse(t, diger.AccessToken)); err != nil || revoked != digerBitti {
+			t.Fatalf("After %s: expected the other session revoked=%v, got %v (%v)", islem, digerBitti, revoked, err)
+		}
+
+		if tablet != "" {
+			aktif, _, err := oturumService.GetAktif(repository.OturumFiltresi{BirimKodu: "DAHILIYE"}, 1, 10)
+			if err != nil {
+				t.Fatalf("GetAktif failed: %v", err)
+			}
+			listed := len(aktif) == 1 && aktif[0].OturumKodu == access.OturumKodu
+			if listed == bitti || (bitti && len(aktif) != 0) {
+				t.Fatalf("After %s: expected the ward to list the session only while active, got %d sessions", islem, len(aktif))
+			}
+		}
+	})
+}
+
+func mustParse(t *rapid.T, token string) *utils.Claims {
+	claims, err := utils.ParseJWT(token)
+	if err != nil {
+		t.Fatalf("Token does not parse: %v", err)
+	}
+	return claims
+}
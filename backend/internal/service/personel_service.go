@@ -3,12 +3,15 @@ package service
 import (
 	"errors"
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"medscreen/internal/repository"
+	"time"
 )
 
 type personelService struct {
 	personelRepo repository.PersonelRepository
 	nfcKartRepo  repository.NFCKartRepository
+	now          func() time.Time
 }
 
 // NewPersonelService creates a new instance of PersonelService
@@ -16,6 +19,7 @@ func NewPersonelService(personelRepo repository.PersonelRepository, nfcKartRepo
 	return &personelService{
 		personelRepo: personelRepo,
 		nfcKartRepo:  nfcKartRepo,
+		now:          time.Now,
 	}
 }
 
@@ -36,8 +40,8 @@ func (s *personelService) GetByKodu(kodu string) (*models.Personel, error) {
 	return personel, nil
 }
 
-// GetAll retrieves all personnel with pagination
-func (s *personelService) GetAll(page, limit int) ([]models.Personel, int64, error) {
+// List retrieves personnel matching a list query
+func (s *personelService) List(q query.Query, page, limit int) ([]models.Personel, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -45,23 +49,7 @@ func (s *personelService) GetAll(page, limit int) ([]models.Personel, int64, err
 		limit = 10
 	}
 
-	return s.personelRepo.FindAll(page, limit)
-}
-
-// GetByGorevKodu retrieves personnel by their role code
-func (s *personelService) GetByGorevKodu(gorevKodu string, page, limit int) ([]models.Personel, int64, error) {
-	if gorevKodu == "" {
-		return nil, 0, errors.New("personel_gorev_kodu is required")
-	}
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
-	}
-
-	return s.personelRepo.FindByGorevKodu(gorevKodu, page, limit)
+	return s.personelRepo.List(q, page, limit)
 }
 
 // AuthenticateByNFC authenticates a personnel by NFC card UID
@@ -84,8 +72,22 @@ func (s *personelService) AuthenticateByNFC(kartUID string) (*models.Personel, e
 		return nil, errors.New("NFC card is inactive")
 	}
 
+	// Check that the card has not passed its expiry date (son_kullanim_tarihi)
+	if nfcKart.SonKullanimTarihi != nil && s.now().After(*nfcKart.SonKullanimTarihi) {
+		return nil, errors.New("NFC card has expired")
+	}
+
 	// Get the associated personnel
-	personel, err := s.personelRepo.FindByKodu(nfcKart.PersonelKodu)
+	return s.AuthenticateByKodu(nfcKart.PersonelKodu)
+}
+
+// AuthenticateByKodu returns an active personnel, e.g. one identified by the hospital SSO
+func (s *personelService) AuthenticateByKodu(personelKodu string) (*models.Personel, error) {
+	if personelKodu == "" {
+		return nil, errors.New("personel_kodu is required")
+	}
+
+	personel, err := s.personelRepo.FindByKodu(personelKodu)
 	if err != nil {
 		return nil, err
 	}
@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"testing"
 	"time"
 
@@ -50,6 +51,10 @@ func (m *mockPersonelRepository) FindByGorevKodu(gorevKodu string, page, limit i
 	return result, int64(len(result)), nil
 }
 
+func (m *mockPersonelRepository) List(q query.Query, page, limit int) ([]models.Personel, int64, error) {
+	return m.FindAll(page, limit)
+}
+
 func (m *mockPersonelRepository) addPersonel(p *models.Personel) {
 	m.personelMap[p.PersonelKodu] = p
 }
@@ -99,6 +104,10 @@ func (m *mockNFCKartRepository) FindAll(page, limit int) ([]models.NFCKart, int6
 	return result, int64(len(result)), nil
 }
 
+func (m *mockNFCKartRepository) List(q query.Query, page, limit int) ([]models.NFCKart, int64, error) {
+	return m.FindAll(page, limit)
+}
+
 func (m *mockNFCKartRepository) addKart(k *models.NFCKart) {
 	m.kartMap[k.KartUID] = k
 }
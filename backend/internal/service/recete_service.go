@@ -3,9 +3,15 @@ package service
 import (
 	"errors"
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"medscreen/internal/repository"
+
+	"gorm.io/gorm"
 )
 
+// ErrReceteBulunamadi is returned when a prescription does not exist
+var ErrReceteBulunamadi = errors.New("prescription not found")
+
 type receteService struct {
 	repo repository.ReceteRepository
 }
@@ -22,22 +28,18 @@ func (s *receteService) GetByKodu(kodu string) (*models.Recete, error) {
 	}
 
 	recete, err := s.repo.FindByKodu(kodu)
+	if errors.Is(err, gorm.ErrRecordNotFound) || err == nil && recete == nil {
+		return nil, ErrReceteBulunamadi
+	}
 	if err != nil {
 		return nil, err
 	}
-	if recete == nil {
-		return nil, errors.New("prescription not found")
-	}
 
 	return recete, nil
 }
 
-// GetByBasvuruKodu retrieves prescriptions by patient visit code
-func (s *receteService) GetByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.Recete, int64, error) {
-	if basvuruKodu == "" {
-		return nil, 0, errors.New("hasta_basvuru_kodu is required")
-	}
-
+// List retrieves prescriptions matching a list query
+func (s *receteService) List(q query.Query, page, limit int) ([]models.Recete, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -45,31 +47,11 @@ func (s *receteService) GetByBasvuruKodu(basvuruKodu string, page, limit int) ([
 		limit = 10
 	}
 
-	return s.repo.FindByBasvuruKodu(basvuruKodu, page, limit)
+	return s.repo.List(q, page, limit)
 }
 
-// GetByHekimKodu retrieves prescriptions by physician code
-func (s *receteService) GetByHekimKodu(hekimKodu string, page, limit int) ([]models.Recete, int64, error) {
-	if hekimKodu == "" {
-		return nil, 0, errors.New("hekim_kodu is required")
-	}
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
-	}
-
-	return s.repo.FindByHekimKodu(hekimKodu, page, limit)
-}
-
-// GetIlaclar retrieves prescription medications by prescription code
-func (s *receteService) GetIlaclar(receteKodu string, page, limit int) ([]models.ReceteIlac, int64, error) {
-	if receteKodu == "" {
-		return nil, 0, errors.New("recete_kodu is required")
-	}
-
+// ListIlac retrieves prescription drugs matching a list query
+func (s *receteService) ListIlac(q query.Query, page, limit int) ([]models.ReceteIlac, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -77,5 +59,5 @@ func (s *receteService) GetIlaclar(receteKodu string, page, limit int) ([]models
 		limit = 10
 	}
 
-	return s.repo.FindIlacByReceteKodu(receteKodu, page, limit)
+	return s.repo.ListIlac(q, page, limit)
 }
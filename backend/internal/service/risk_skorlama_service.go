@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"medscreen/internal/repository"
 )
 
@@ -32,28 +33,8 @@ func (s *riskSkorlamaService) GetByKodu(kodu string) (*models.RiskSkorlama, erro
 	return skor, nil
 }
 
-// GetByBasvuruKodu retrieves risk scores by patient visit code
-func (s *riskSkorlamaService) GetByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.RiskSkorlama, int64, error) {
-	if basvuruKodu == "" {
-		return nil, 0, errors.New("hasta_basvuru_kodu is required")
-	}
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
-	}
-
-	return s.repo.FindByBasvuruKodu(basvuruKodu, page, limit)
-}
-
-// GetByTuru retrieves risk scores by score type
-func (s *riskSkorlamaService) GetByTuru(turu string, page, limit int) ([]models.RiskSkorlama, int64, error) {
-	if turu == "" {
-		return nil, 0, errors.New("risk_skorlama_turu is required")
-	}
-
+// List retrieves risk scores matching a list query
+func (s *riskSkorlamaService) List(q query.Query, page, limit int) ([]models.RiskSkorlama, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -61,5 +42,5 @@ func (s *riskSkorlamaService) GetByTuru(turu string, page, limit int) ([]models.
 		limit = 10
 	}
 
-	return s.repo.FindByTuru(turu, page, limit)
+	return s.repo.List(q, page, limit)
 }
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"medscreen/internal/oidc"
+	"medscreen/internal/utils"
+)
+
+// Errors returned by SSOService
+var (
+	ErrSSOState    = errors.New("login state does not match; start the login again")
+	ErrSSOPersonel = errors.New("the identity provider did not return a personnel code")
+)
+
+// SSOBaslangic is a login started at the identity provider. State, Nonce and Verifier must be
+// kept by the browser (in a cookie) until the provider redirects back.
+type SSOBaslangic struct {
+	URL      string
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+type ssoService struct {
+	client        *oidc.Client
+	authService   AuthService
+	personelClaim string
+}
+
+// NewSSOService creates a new instance of SSOService.
+// personelClaim names the ID token claim holding Personel.PersonelKodu, e.g. sub or employee_number.
+func NewSSOService(client *oidc.Client, authService AuthService, personelClaim string) SSOService {
+	return &ssoService{client: client, authService: authService, personelClaim: personelClaim}
+}
+
+// Baslat starts an authorization code login with PKCE
+func (s *ssoService) Baslat(ctx context.Context) (*SSOBaslangic, error) {
+	state, err := utils.NewRandomID()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := utils.NewRandomID()
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := s.client.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return nil, err
+	}
+	return &SSOBaslangic{URL: url, State: state, Nonce: nonce, Verifier: verifier}, nil
+}
+
+// Tamamla finishes a login: the state must match the one the login started with, the code
+// is redeemed with the PKCE verifier, and the personnel named by the ID token is logged in
+func (s *ssoService) Tamamla(ctx context.Context, baslangic SSOBaslangic, code, state string) (*SSOGirisSonucu, error) {
+	if baslangic.State == "" || subtle.ConstantTimeCompare([]byte(baslangic.State), []byte(state)) != 1 {
+		return nil, ErrSSOState
+	}
+	if code == "" {
+		return nil, errors.New("code is required")
+	}
+
+	claims, err := s.client.Exchange(ctx, code, baslangic.Verifier, baslangic.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	personelKodu := claims.String(s.personelClaim)
+	if personelKodu == "" {
+		return nil, fmt.Errorf("%w: claim %q", ErrSSOPersonel, s.personelClaim)
+	}
+	return s.authService.LoginWithSSO(personelKodu)
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"medscreen/internal/models"
+	"medscreen/internal/oidc"
+	"medscreen/internal/oidc/oidctest"
+	"medscreen/internal/utils"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// Feature: hospital-sso, Property 2: SSO Logins Issue The Same Internal Tokens
+// *For any* login through the identity provider, the system SHALL issue access and refresh
+// tokens for the personnel named by the configured claim, only when the callback carries the
+// state of the login, and SHALL stop refreshing them once the personnel is deactivated.
+
+// newTestSSOService builds an SSOService against a mock provider over one active HEKIM
+func newTestSSOService(t *testing.T, personelClaim string) (SSOService, AuthService, *oidctest.Server, *models.Personel) {
+	idp := oidctest.NewServer("medscreen", "")
+	t.Cleanup(idp.Close)
+
+	personel := &models.Personel{PersonelKodu: "P004711", PersonelGorevKodu: string(models.GorevHekim), AktiflikBilgisi: 1}
+	personelRepo := newMockPersonelRepository()
+	personelRepo.addPersonel(personel)
+	nfcKartRepo := newMockNFCKartRepository()
+	authService := NewAuthService(NewPersonelService(personelRepo, nfcKartRepo), nfcKartRepo, &mockTabletCihazRepository{},
+		&mockTokenIptalRepository{}, &mockGirisEngeliRepository{}, &mockYatakKisitiKaldirmaRepository{}, NewOturumService(newMockOturumRepository(), nfcKartRepo, time.Hour), 15*time.Minute, 12*time.Hour)
+
+	client := oidc.NewClient(oidc.Config{
+		Issuer:      idp.Issuer,
+		ClientID:    "medscreen",
+		RedirectURL: "http://medscreen.test/api/v1/auth/sso/callback",
+	}, idp.Client())
+	return NewSSOService(client, authService, personelClaim), authService, idp, personel
+}
+
+// ssoLogin follows the redirect to the mock provider and returns the callback's code and state
+func ssoLogin(t *testing.T, idp *oidctest.Server, baslangic *SSOBaslangic) (string, string) {
+	resp, err := idp.Client().Get(baslangic.URL)
+	if err != nil {
+		t.Fatalf("Authorize request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("Expected a redirect from the provider, got %d", resp.StatusCode)
+	}
+	back, _ := url.Parse(resp.Header.Get("Location"))
+	return back.Query().Get("code"), back.Query().Get("state")
+}
+
+// TestSSO_LoginIssuesInternalTokens tests Property 2
+func TestSSO_LoginIssuesInternalTokens(t *testing.T) {
+	svc, authService, idp, personel := newTestSSOService(t, "employee_number")
+	idp.Login(map[string]interface{}{"sub": "9f1c-user", "employee_number": "P004711"})
+	ctx := context.Background()
+
+	baslangic, err := svc.Baslat(ctx)
+	if err != nil {
+		t.Fatalf("Baslat failed: %v", err)
+	}
+	code, state := ssoLogin(t, idp, baslangic)
+	sonuc, err := svc.Tamamla(ctx, *baslangic, code, state)
+	if err != nil {
+		t.Fatalf("Tamamla failed: %v", err)
+	}
+	if sonuc.Personel.PersonelKodu != personel.PersonelKodu {
+		t.Fatalf("Expected %s to be logged in, got %s", personel.PersonelKodu, sonuc.Personel.PersonelKodu)
+	}
+
+	claims, err := utils.ParseJWT(sonuc.AccessToken)
+	if err != nil || claims.TokenType != utils.TokenTypeAccess || claims.PersonelKodu != personel.PersonelKodu ||
+		claims.Role != string(models.GorevHekim) || claims.GirisYontemi != utils.GirisYontemiSSO || claims.NFCKartKodu != "" {
+		t.Fatalf("Unexpected access token claims %+v, %v", claims, err)
+	}
+
+	// SSO sessions refresh without a card while the personnel stays active
+	yenilenen, err := authService.Refresh(sonuc.RefreshToken)
+	if err != nil {
+		t.Fatalf("Expected an SSO session to refresh, got %v", err)
+	}
+	personel.AktiflikBilgisi = 0
+	if _, err := authService.Refresh(yenilenen.RefreshToken); err == nil {
+		t.Fatal("Expected a deactivated personnel to lose the SSO session")
+	}
+
+	// A revoked personnel cannot log in through the provider until the block is lifted
+	personel.AktiflikBilgisi = 1
+	if err := authService.RevokePersonel(personel.PersonelKodu, "ADMIN", "account compromised"); err != nil {
+		t.Fatalf("RevokePersonel failed: %v", err)
+	}
+	if _, err := authService.LoginWithSSO(personel.PersonelKodu); !errors.Is(err, ErrGirisEngelli) {
+		t.Fatalf("Expected a revoked personnel to be refused, got %v", err)
+	}
+	if err := authService.UnblockPersonel(personel.PersonelKodu, "ADMIN"); err != nil {
+		t.Fatalf("UnblockPersonel failed: %v", err)
+	}
+	if _, err := authService.LoginWithSSO(personel.PersonelKodu); err != nil {
+		t.Fatalf("Expected login after unblocking to succeed, got %v", err)
+	}
+}
+
+// TestSSO_CallbackRejections tests that forged or incomplete callbacks do not log anyone in
+func TestSSO_CallbackRejections(t *testing.T) {
+	svc, _, idp, _ := newTestSSOService(t, "sub")
+	ctx := context.Background()
+
+	// State from another browser (login CSRF)
+	idp.Login(map[string]interface{}{"sub": "P004711"})
+	baslangic, _ := svc.Baslat(ctx)
+	code, _ := ssoLogin(t, idp, baslangic)
+	if _, err := svc.Tamamla(ctx, *baslangic, code, "forged-state"); !errors.Is(err, ErrSSOState) {
+		t.Errorf("Expected a forged state to be refused, got %v", err)
+	}
+	if _, err := svc.Tamamla(ctx, SSOBaslangic{}, code, ""); !errors.Is(err, ErrSSOState) {
+		t.Errorf("Expected a callback without a login cookie to be refused, got %v", err)
+	}
+
+	// The provider does not send the configured claim
+	idp.Login(map[string]interface{}{"email": "hekim@hastane.test"})
+	baslangic, _ = svc.Baslat(ctx)
+	code, state := ssoLogin(t, idp, baslangic)
+	if _, err := svc.Tamamla(ctx, *baslangic, code, state); !errors.Is(err, ErrSSOPersonel) {
+		t.Errorf("Expected a missing personnel claim to be refused, got %v", err)
+	}
+
+	// The claim names no personnel in VEM 2.0
+	idp.Login(map[string]interface{}{"sub": "P999999"})
+	baslangic, _ = svc.Baslat(ctx)
+	code, state = ssoLogin(t, idp, baslangic)
+	if sonuc, err := svc.Tamamla(ctx, *baslangic, code, state); err == nil {
+		t.Errorf("Expected an unknown personnel to be refused, got %+v", sonuc)
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"medscreen/internal/repository"
 )
 
@@ -32,24 +33,8 @@ func (s *tabletCihazService) GetByKodu(kodu string) (*models.TabletCihaz, error)
 	return cihaz, nil
 }
 
-// GetByYatakKodu retrieves tablet devices by bed code
-func (s *tabletCihazService) GetByYatakKodu(yatakKodu string, page, limit int) ([]models.TabletCihaz, int64, error) {
-	if yatakKodu == "" {
-		return nil, 0, errors.New("yatak_kodu is required")
-	}
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
-	}
-
-	return s.repo.FindByYatakKodu(yatakKodu, page, limit)
-}
-
-// GetAll retrieves all tablet devices with pagination
-func (s *tabletCihazService) GetAll(page, limit int) ([]models.TabletCihaz, int64, error) {
+// List retrieves tablet devices matching a list query
+func (s *tabletCihazService) List(q query.Query, page, limit int) ([]models.TabletCihaz, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -57,5 +42,5 @@ func (s *tabletCihazService) GetAll(page, limit int) ([]models.TabletCihaz, int6
 		limit = 10
 	}
 
-	return s.repo.FindAll(page, limit)
+	return s.repo.List(q, page, limit)
 }
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"medscreen/internal/critical"
+	"medscreen/internal/measurement"
+	"medscreen/internal/models"
+	"strings"
+)
+
+// DegerlendirTetkikSonuc evaluates a test result against its KritikDegerAraligi and the
+// critical limits of its test.
+// A two-sided range ("3,5-5,1") lists the acceptable values, so results outside it are low
+// or high; they are critical only beyond the critical limits of the test in esikler (nil
+// has none). A one-sided range ("> 200", "<2,5") is the critical region itself, bound
+// included. Censored results ("<0,01") are assumed to be non-negative.
+// It returns nil when there is nothing to evaluate and BELIRSIZ when the value, the range
+// or their units do not allow a decision.
+func DegerlendirTetkikSonuc(sonuc *models.TetkikSonuc, esikler *critical.Esikler) *models.TetkikDegerlendirmesi {
+	if sonuc.SonucDegeri == nil || sonuc.KritikDegerAraligi == nil {
+		return nil
+	}
+	deger, degerErr := measurement.ParseDeger(*sonuc.SonucDegeri)
+	aralik, aralikErr := measurement.ParseAralik(*sonuc.KritikDegerAraligi)
+	if errors.Is(degerErr, measurement.ErrBos) || errors.Is(aralikErr, measurement.ErrBos) {
+		return nil
+	}
+	if degerErr != nil || aralikErr != nil ||
+		deger.Birim != "" && aralik.Birim != "" && deger.Birim != aralik.Birim {
+		return &models.TetkikDegerlendirmesi{Durum: models.TetkikDurumuBelirsiz}
+	}
+
+	// The interval the true value lies in
+	alt, ust := deger.Sayi, deger.Sayi
+	switch deger.Isaret {
+	case measurement.IsaretKucuk, measurement.IsaretKucukEsit:
+		alt = math.Min(0, deger.Sayi)
+	case measurement.IsaretBuyuk, measurement.IsaretBuyukEsit:
+		ust = math.Inf(1)
+	}
+
+	durum := models.TetkikDurumuBelirsiz
+	kritik := false
+	switch {
+	case aralik.Alt != nil && aralik.Ust != nil:
+		switch {
+		case ust < *aralik.Alt:
+			durum = models.TetkikDurumuDusuk
+		case alt > *aralik.Ust:
+			durum = models.TetkikDurumuYuksek
+		case alt >= *aralik.Alt && ust <= *aralik.Ust:
+			durum = models.TetkikDurumuNormal
+		}
+		if durum == models.TetkikDurumuDusuk || durum == models.TetkikDurumuYuksek {
+			birim := deger.Birim
+			if birim == "" {
+				birim = aralik.Birim
+			}
+			kritik = esikDisinda(esikler, sonuc.TetkikAdi, birim, alt, ust)
+		}
+	case aralik.Ust != nil:
+		switch {
+		case ust <= *aralik.Ust:
+			durum, kritik = models.TetkikDurumuDusuk, true
+		case alt > *aralik.Ust:
+			durum = models.TetkikDurumuNormal
+		}
+	case aralik.Alt != nil:
+		switch {
+		case alt >= *aralik.Alt:
+			durum, kritik = models.TetkikDurumuYuksek, true
+		case ust < *aralik.Alt:
+			durum = models.TetkikDurumuNormal
+		}
+	}
+
+	return &models.TetkikDegerlendirmesi{Durum: durum, Kritik: kritik}
+}
+
+// esikDisinda reports whether a result, known to lie in [alt, ust], is beyond the critical
+// limits of its test. A test without limits, or a result in another unit, is not critical.
+func esikDisinda(esikler *critical.Esikler, tetkikAdi, birim string, alt, ust float64) bool {
+	esik, ok := esikler.Bul(tetkikAdi)
+	if !ok || birim != "" && esik.Birim != "" && !strings.EqualFold(birim, esik.Birim) {
+		return false
+	}
+	return esik.Alt != nil && ust < *esik.Alt || esik.Ust != nil && alt > *esik.Ust
+}
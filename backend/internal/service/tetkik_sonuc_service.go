@@ -2,17 +2,46 @@ package service
 
 import (
 	"errors"
+	"medscreen/internal/critical"
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"medscreen/internal/repository"
+	"time"
+
+	"gorm.io/gorm"
 )
 
+// ErrTetkikSonucKritikDegil is returned when acknowledging a result that is not critical
+var ErrTetkikSonucKritikDegil = errors.New("test result is not critical")
+
+// ErrTetkikSonucBulunamadi is returned when acknowledging a result that does not exist
+var ErrTetkikSonucBulunamadi = errors.New("test result not found")
+
+const (
+	// kritikPencere is how far back the critical results feed looks without a since
+	kritikPencere = 72 * time.Hour
+	// kritikSayfa is the number of results evaluated per query of the feed
+	kritikSayfa = 500
+)
+
+// KritikTetkikSonuc is an unacknowledged critical result of a current inpatient
+type KritikTetkikSonuc struct {
+	models.TetkikSonuc
+	AnlikYatanHasta *models.AnlikYatanHasta `json:"anlik_yatan_hasta,omitempty"`
+}
+
 type tetkikSonucService struct {
-	repo repository.TetkikSonucRepository
+	repo      repository.TetkikSonucRepository
+	yatanRepo repository.AnlikYatanHastaRepository
+	onayRepo  repository.KritikSonucOnayRepository
+	esikler   *critical.Esikler
+	now       func() time.Time
 }
 
-// NewTetkikSonucService creates a new instance of TetkikSonucService
-func NewTetkikSonucService(repo repository.TetkikSonucRepository) TetkikSonucService {
-	return &tetkikSonucService{repo: repo}
+// NewTetkikSonucService creates a new instance of TetkikSonucService. Results are critical
+// beyond the limits in esikler or inside a one-sided KritikDegerAraligi.
+func NewTetkikSonucService(repo repository.TetkikSonucRepository, yatanRepo repository.AnlikYatanHastaRepository, onayRepo repository.KritikSonucOnayRepository, esikler *critical.Esikler) TetkikSonucService {
+	return &tetkikSonucService{repo: repo, yatanRepo: yatanRepo, onayRepo: onayRepo, esikler: esikler, now: time.Now}
 }
 
 // GetByKodu retrieves test results by their code
@@ -29,15 +58,12 @@ func (s *tetkikSonucService) GetByKodu(kodu string) (*models.TetkikSonuc, error)
 		return nil, errors.New("test result not found")
 	}
 
+	sonuc.Degerlendirme = DegerlendirTetkikSonuc(sonuc, s.esikler)
 	return sonuc, nil
 }
 
-// GetByBasvuruKodu retrieves test results by patient visit code
-func (s *tetkikSonucService) GetByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.TetkikSonuc, int64, error) {
-	if basvuruKodu == "" {
-		return nil, 0, errors.New("hasta_basvuru_kodu is required")
-	}
-
+// List retrieves test results matching a list query
+func (s *tetkikSonucService) List(q query.Query, page, limit int) ([]models.TetkikSonuc, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -45,5 +71,133 @@ func (s *tetkikSonucService) GetByBasvuruKodu(basvuruKodu string, page, limit in
 		limit = 10
 	}
 
-	return s.repo.FindByBasvuruKodu(basvuruKodu, page, limit)
+	sonuclar, total, err := s.repo.List(q, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range sonuclar {
+		sonuclar[i].Degerlendirme = DegerlendirTetkikSonuc(&sonuclar[i], s.esikler)
+	}
+	return sonuclar, total, nil
+}
+
+// GetKritik lists the unacknowledged critical results of current inpatients, newest first.
+// An empty birimKodu covers every unit; since limits the results to those recorded after it
+// and defaults to the last kritikPencere. Results are read kritikSayfa at a time.
+func (s *tetkikSonucService) GetKritik(birimKodu string, since *time.Time) ([]KritikTetkikSonuc, error) {
+	baslangic := s.now().Add(-kritikPencere)
+	if since != nil {
+		baslangic = *since
+	}
+
+	var kritikler []models.TetkikSonuc
+	for page := 1; ; page++ {
+		sonuclar, err := s.repo.FindByYatanHastalar(birimKodu, baslangic, page, kritikSayfa)
+		if err != nil {
+			return nil, err
+		}
+		for i := range sonuclar {
+			sonuc := sonuclar[i]
+			sonuc.Degerlendirme = DegerlendirTetkikSonuc(&sonuc, s.esikler)
+			if sonuc.Degerlendirme != nil && sonuc.Degerlendirme.Kritik {
+				kritikler = append(kritikler, sonuc)
+			}
+		}
+		if len(sonuclar) < kritikSayfa {
+			break
+		}
+	}
+
+	kodlar := make([]string, 0, len(kritikler))
+	for _, sonuc := range kritikler {
+		kodlar = append(kodlar, sonuc.TetkikSonucKodu)
+	}
+	onaylar, err := s.onayRepo.FindByTetkikSonucKodlari(kodlar)
+	if err != nil {
+		return nil, err
+	}
+	onaylanan := make(map[string]bool, len(onaylar))
+	for _, onay := range onaylar {
+		onaylanan[onay.TetkikSonucKodu] = true
+	}
+
+	// The inpatients of the listed results are loaded with one query
+	var acik []models.TetkikSonuc
+	basvurular := make(map[string]bool)
+	var basvuruKodlari []string
+	for _, kritik := range kritikler {
+		if onaylanan[kritik.TetkikSonucKodu] {
+			continue
+		}
+		acik = append(acik, kritik)
+		if !basvurular[kritik.HastaBasvuruKodu] {
+			basvurular[kritik.HastaBasvuruKodu] = true
+			basvuruKodlari = append(basvuruKodlari, kritik.HastaBasvuruKodu)
+		}
+	}
+	yatanlar, err := s.yatanRepo.FindByBasvuruKodlari(basvuruKodlari)
+	if err != nil {
+		return nil, err
+	}
+	yatanHastalar := make(map[string]*models.AnlikYatanHasta, len(yatanlar))
+	for i := range yatanlar {
+		if _, ok := yatanHastalar[yatanlar[i].HastaBasvuruKodu]; !ok {
+			yatanHastalar[yatanlar[i].HastaBasvuruKodu] = &yatanlar[i]
+		}
+	}
+
+	sonuc := make([]KritikTetkikSonuc, 0, len(acik))
+	for _, kritik := range acik {
+		sonuc = append(sonuc, KritikTetkikSonuc{TetkikSonuc: kritik, AnlikYatanHasta: yatanHastalar[kritik.HastaBasvuruKodu]})
+	}
+	return sonuc, nil
+}
+
+// Onayla records that a clinician has seen a critical result. Acknowledging a result
+// twice, even at the same time, returns the first acknowledgement.
+func (s *tetkikSonucService) Onayla(kodu, personelKodu, aciklama string) (*models.KritikSonucOnay, error) {
+	if kodu == "" {
+		return nil, errors.New("tetkik_sonuc_kodu is required")
+	}
+	if personelKodu == "" {
+		return nil, errors.New("personel_kodu is required")
+	}
+
+	sonuc, err := s.repo.FindByKodu(kodu)
+	if errors.Is(err, gorm.ErrRecordNotFound) || err == nil && sonuc == nil {
+		return nil, ErrTetkikSonucBulunamadi
+	}
+	if err != nil {
+		return nil, err
+	}
+	if degerlendirme := DegerlendirTetkikSonuc(sonuc, s.esikler); degerlendirme == nil || !degerlendirme.Kritik {
+		return nil, ErrTetkikSonucKritikDegil
+	}
+
+	mevcut, err := s.onayRepo.FindByTetkikSonucKodu(kodu)
+	if err == nil {
+		return mevcut, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	onay := &models.KritikSonucOnay{
+		TetkikSonucKodu:       kodu,
+		HastaBasvuruKodu:      sonuc.HastaBasvuruKodu,
+		OnaylayanPersonelKodu: personelKodu,
+		OnayZamani:            s.now(),
+	}
+	if aciklama != "" {
+		onay.Aciklama = &aciklama
+	}
+	olusturuldu, err := s.onayRepo.Create(onay)
+	if err != nil {
+		return nil, err
+	}
+	if !olusturuldu {
+		// Another clinician acknowledged the result since it was looked up
+		return s.onayRepo.FindByTetkikSonucKodu(kodu)
+	}
+	return onay, nil
 }
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"medscreen/internal/critical"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"pgregory.net/rapid"
+)
+
+// Feature: critical-lab-values, Property 1: Results Beyond the Critical Limits Are Critical
+// *For any* result and two-sided KritikDegerAraligi written in ward notation, the result
+// SHALL be DUSUK below the range and YUKSEK above it, and SHALL be flagged critical exactly
+// when it also lies beyond the critical limits of its test.
+
+// turkce writes hundredths with a Turkish decimal comma, e.g. 351 -> "3,51"
+func turkce(yuzde int) string {
+	isaret := ""
+	if yuzde < 0 {
+		isaret, yuzde = "-", -yuzde
+	}
+	return fmt.Sprintf("%s%d,%02d", isaret, yuzde/100, yuzde%100)
+}
+
+// TestProperty_ResultsBeyondLimitsAreCritical tests Property 1
+func TestProperty_ResultsBeyondLimitsAreCritical(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		alt := rapid.IntRange(0, 50000).Draw(rt, "alt")
+		ust := alt + rapid.IntRange(0, 50000).Draw(rt, "genislik")
+		kritikAlt := alt - rapid.IntRange(0, alt).Draw(rt, "kritikAltPayi")
+		kritikUst := ust + rapid.IntRange(0, 50000).Draw(rt, "kritikUstPayi")
+		deger := rapid.IntRange(0, 160000).Draw(rt, "deger")
+
+		esikler, err := critical.Parse([]byte(fmt.Sprintf(`{"tetkikler": {"potasyum": {"terimler": ["K"], "alt": %v, "ust": %v, "birim": "mmol/L"}}}`,
+			float64(kritikAlt)/100, float64(kritikUst)/100)))
+		if err != nil {
+			rt.Fatalf("Failed to parse limits: %v", err)
+		}
+
+		sonucDegeri := turkce(deger) + rapid.SampledFrom([]string{"", " mmol/L"}).Draw(rt, "birim")
+		aralikDegeri := turkce(alt) + " - " + turkce(ust)
+		tetkikAdi := rapid.SampledFrom([]string{"Potasyum", "K", "Sodyum"}).Draw(rt, "tetkikAdi")
+		sonuc := &models.TetkikSonuc{TetkikAdi: tetkikAdi, SonucDegeri: &sonucDegeri, KritikDegerAraligi: &aralikDegeri}
+
+		d := DegerlendirTetkikSonuc(sonuc, esikler)
+		if d == nil {
+			rt.Fatalf("Expected an evaluation for %q against %q", sonucDegeri, aralikDegeri)
+		}
+		beklenen := models.TetkikDurumuNormal
+		switch {
+		case deger < alt:
+			beklenen = models.TetkikDurumuDusuk
+		case deger > ust:
+			beklenen = models.TetkikDurumuYuksek
+		}
+		kritik := tetkikAdi != "Sodyum" && (deger < kritikAlt || deger > kritikUst)
+		if d.Durum != beklenen || d.Kritik != kritik {
+			rt.Fatalf("%s %q against %q: expected %s (critical %v), got %+v", tetkikAdi, sonucDegeri, aralikDegeri, beklenen, kritik, d)
+		}
+	})
+}
+
+// TestTetkikDegerlendirme_Examples verifies one-sided ranges, censored results and parse failures
+func TestTetkikDegerlendirme_Examples(t *testing.T) {
+	tests := []struct {
+		deger, aralik string
+		durum         models.TetkikDurumu
+	}{
+		{"250", "> 200", models.TetkikDurumuYuksek},
+		{"200", ">200", models.TetkikDurumuYuksek},
+		{"150", "> 200", models.TetkikDurumuNormal},
+		{"2,1", "<2,5", models.TetkikDurumuDusuk},
+		{"<0,01", "0-0,04", models.TetkikDurumuNormal},
+		{"<0,5", "1-5", models.TetkikDurumuDusuk},
+		{">1000", "70-110", models.TetkikDurumuYuksek},
+		{"<50", "30-100", models.TetkikDurumuBelirsiz},
+		{"hemolizli", "3,5-5,1", models.TetkikDurumuBelirsiz},
+		{"4,2 mg/dL", "3,5-5,1 mmol/L", models.TetkikDurumuBelirsiz},
+		{"4,2", "normal", models.TetkikDurumuBelirsiz},
+		{"1.100", "0.8-1.2", models.TetkikDurumuNormal},
+		{"1.015", "1.005-1.030", models.TetkikDurumuNormal},
+	}
+	for _, tt := range tests {
+		deger, aralik := tt.deger, tt.aralik
+		d := DegerlendirTetkikSonuc(&models.TetkikSonuc{SonucDegeri: &deger, KritikDegerAraligi: &aralik}, nil)
+		if d == nil || d.Durum != tt.durum {
+			t.Errorf("%q against %q: expected %s, got %+v", tt.deger, tt.aralik, tt.durum, d)
+		}
+	}
+
+	bos := " "
+	if d := DegerlendirTetkikSonuc(&models.TetkikSonuc{SonucDegeri: &bos, KritikDegerAraligi: &bos}, nil); d != nil {
+		t.Errorf("Expected no evaluation for empty values, got %+v", d)
+	}
+
+	// One-sided ranges are the critical region; two-sided ones need the limits of the test
+	kritikler := []struct {
+		tetkik, deger, aralik string
+		kritik                bool
+	}{
+		{"Troponin I", "250", "> 200", true},
+		{"Troponin I", "150", "> 200", false},
+		{"Potasyum", "5,8", "3,5-5,1", false},
+		{"Potasyum", "6,8 mmol/L", "3,5-5,1", true},
+		{"POTASYUM", "2,1", "3,5-5,1 mmol/L", true},
+		{"Potasyum", "6,8 mg/dL", "3,5-5,1", false},
+		{"Glukoz", "1.250,0", "70-110", true},
+		{"INR", "1.100", "0.8-1.2", false},
+		{"INR", "5.200", "0.8-1.2", true},
+		{"Ferritin", "900", "20-300", false},
+	}
+	for _, tt := range kritikler {
+		deger, aralik := tt.deger, tt.aralik
+		d := DegerlendirTetkikSonuc(&models.TetkikSonuc{TetkikAdi: tt.tetkik, SonucDegeri: &deger, KritikDegerAraligi: &aralik}, critical.Default())
+		if d == nil || d.Kritik != tt.kritik {
+			t.Errorf("%s %q against %q: expected critical %v, got %+v", tt.tetkik, tt.deger, tt.aralik, tt.kritik, d)
+		}
+	}
+}
+
+type stubKritikTetkikRepo struct {
+	repository.TetkikSonucRepository
+	sonuclar []models.TetkikSonuc
+	since    time.Time
+}
+
+func (r *stubKritikTetkikRepo) FindByYatanHastalar(birimKodu string, since time.Time, page, limit int) ([]models.TetkikSonuc, error) {
+	r.since = since
+	start := (page - 1) * limit
+	if start >= len(r.sonuclar) {
+		return nil, nil
+	}
+	return r.sonuclar[start:min(start+limit, len(r.sonuclar))], nil
+}
+
+func (r *stubKritikTetkikRepo) FindByKodu(kodu string) (*models.TetkikSonuc, error) {
+	for i := range r.sonuclar {
+		if r.sonuclar[i].TetkikSonucKodu == kodu {
+			return &r.sonuclar[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type stubKritikYatanRepo struct {
+	repository.AnlikYatanHastaRepository
+	calls int
+}
+
+func (r *stubKritikYatanRepo) FindByBasvuruKodlari(basvuruKodlari []string) ([]models.AnlikYatanHasta, error) {
+	r.calls++
+	yatanHastalar := make([]models.AnlikYatanHasta, 0, len(basvuruKodlari))
+	for _, basvuruKodu := range basvuruKodlari {
+		yatanHastalar = append(yatanHastalar, models.AnlikYatanHasta{HastaBasvuruKodu: basvuruKodu, YatakKodu: "Y-" + basvuruKodu})
+	}
+	return yatanHastalar, nil
+}
+
+type mockKritikSonucOnayRepository struct {
+	onaylar []models.KritikSonucOnay
+	// araya is stored by another clinician just before the next Create
+	araya *models.KritikSonucOnay
+}
+
+func (r *mockKritikSonucOnayRepository) Create(onay *models.KritikSonucOnay) (bool, error) {
+	if r.araya != nil {
+		r.araya.KritikSonucOnayID = uint(len(r.onaylar) + 1)
+		r.onaylar = append(r.onaylar, *r.araya)
+		r.araya = nil
+	}
+	for _, mevcut := range r.onaylar {
+		if mevcut.TetkikSonucKodu == onay.TetkikSonucKodu {
+			return false, nil
+		}
+	}
+	onay.KritikSonucOnayID = uint(len(r.onaylar) + 1)
+	r.onaylar = append(r.onaylar, *onay)
+	return true, nil
+}
+
+func (r *mockKritikSonucOnayRepository) FindByTetkikSonucKodu(kodu string) (*models.KritikSonucOnay, error) {
+	for i := range r.onaylar {
+		if r.onaylar[i].TetkikSonucKodu == kodu {
+			return &r.onaylar[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *mockKritikSonucOnayRepository) FindByTetkikSonucKodlari(kodlar []string) ([]models.KritikSonucOnay, error) {
+	var sonuc []models.KritikSonucOnay
+	for _, onay := range r.onaylar {
+		for _, kodu := range kodlar {
+			if onay.TetkikSonucKodu == kodu {
+				sonuc = append(sonuc, onay)
+			}
+		}
+	}
+	return sonuc, nil
+}
+
+func tetkikSonucu(kodu, basvuru, tetkik, deger, aralik string) models.TetkikSonuc {
+	return models.TetkikSonuc{TetkikSonucKodu: kodu, HastaBasvuruKodu: basvuru, TetkikAdi: tetkik, SonucDegeri: &deger, KritikDegerAraligi: &aralik}
+}
+
+// TestTetkikSonuc_KritikFeed verifies that only unacknowledged critical results are listed
+// and that acknowledging removes a result from the feed
+func TestTetkikSonuc_KritikFeed(t *testing.T) {
+	tetkikRepo := &stubKritikTetkikRepo{sonuclar: []models.TetkikSonuc{
+		tetkikSonucu("T1", "B1", "Potasyum", "6,8", "3,5-5,1"),
+		tetkikSonucu("T2", "B1", "Potasyum", "4,2", "3,5-5,1"),
+		tetkikSonucu("T3", "B2", "Potasyum", "2,1", "3,5-5,1"),
+		tetkikSonucu("T4", "B1", "Trombosit", "350", "> 300"),
+		tetkikSonucu("T5", "B2", "Potasyum", "5,8", "3,5-5,1"),
+	}}
+	for i := 0; i < kritikSayfa; i++ {
+		tetkikRepo.sonuclar = append(tetkikRepo.sonuclar, tetkikSonucu(fmt.Sprintf("N%d", i), "B3", "Sodyum", "140", "136-145"))
+	}
+	tetkikRepo.sonuclar = append(tetkikRepo.sonuclar, tetkikSonucu("T6", "B3", "Sodyum", "112", "136-145"))
+	yatanRepo := &stubKritikYatanRepo{}
+	onayRepo := &mockKritikSonucOnayRepository{}
+	svc := NewTetkikSonucService(tetkikRepo, yatanRepo, onayRepo, critical.Default()).(*tetkikSonucService)
+	simdi := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return simdi }
+
+	kritikler, err := svc.GetKritik("DAHILIYE", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var kodlar []string
+	for _, k := range kritikler {
+		kodlar = append(kodlar, k.TetkikSonucKodu)
+		if k.AnlikYatanHasta == nil || k.AnlikYatanHasta.HastaBasvuruKodu != k.HastaBasvuruKodu || !k.Degerlendirme.Kritik {
+			t.Errorf("Unexpected critical result: %+v", k)
+		}
+	}
+	if strings.Join(kodlar, ",") != "T1,T3,T4,T6" {
+		t.Fatalf("Expected T1,T3,T4,T6, got %v", kodlar)
+	}
+	if yatanRepo.calls != 1 {
+		t.Errorf("Expected the inpatients to be loaded with one query, got %d", yatanRepo.calls)
+	}
+	if !tetkikRepo.since.Equal(simdi.Add(-kritikPencere)) {
+		t.Errorf("Expected the feed to default to the last %v, got since %v", kritikPencere, tetkikRepo.since)
+	}
+
+	onay, err := svc.Onayla("T1", "P000001", "Nefroloji aranacak")
+	if err != nil || onay.OnaylayanPersonelKodu != "P000001" || onay.HastaBasvuruKodu != "B1" {
+		t.Fatalf("Unexpected acknowledgement: %+v, %v", onay, err)
+	}
+	tekrar, err := svc.Onayla("T1", "P000002", "")
+	if err != nil || tekrar.KritikSonucOnayID != onay.KritikSonucOnayID || len(onayRepo.onaylar) != 1 {
+		t.Errorf("Expected the first acknowledgement to be returned, got %+v, %v", tekrar, err)
+	}
+	onayRepo.araya = &models.KritikSonucOnay{TetkikSonucKodu: "T3", HastaBasvuruKodu: "B2", OnaylayanPersonelKodu: "P000003"}
+	if yaris, err := svc.Onayla("T3", "P000001", ""); err != nil || yaris.OnaylayanPersonelKodu != "P000003" || len(onayRepo.onaylar) != 2 {
+		t.Errorf("Expected a concurrent acknowledgement to be returned, got %+v, %v", yaris, err)
+	}
+	onayRepo.onaylar = onayRepo.onaylar[:1]
+	if _, err := svc.Onayla("T9", "P000001", ""); !errors.Is(err, ErrTetkikSonucBulunamadi) {
+		t.Errorf("Expected ErrTetkikSonucBulunamadi for an unknown result, got %v", err)
+	}
+	for _, kodu := range []string{"T2", "T5"} {
+		if _, err := svc.Onayla(kodu, "P000001", ""); !errors.Is(err, ErrTetkikSonucKritikDegil) {
+			t.Errorf("Expected ErrTetkikSonucKritikDegil for %s, got %v", kodu, err)
+		}
+	}
+
+	kritikler, _ = svc.GetKritik("DAHILIYE", nil)
+	if len(kritikler) != 3 || kritikler[0].TetkikSonucKodu != "T3" {
+		t.Errorf("Expected the acknowledged result to leave the feed, got %d results", len(kritikler))
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"medscreen/internal/repository"
 )
 
@@ -32,12 +33,8 @@ func (s *tibbiOrderService) GetByKodu(kodu string) (*models.TibbiOrder, error) {
 	return order, nil
 }
 
-// GetByBasvuruKodu retrieves medical orders by patient visit code
-func (s *tibbiOrderService) GetByBasvuruKodu(basvuruKodu string, page, limit int) ([]models.TibbiOrder, int64, error) {
-	if basvuruKodu == "" {
-		return nil, 0, errors.New("hasta_basvuru_kodu is required")
-	}
-
+// List retrieves medical orders matching a list query
+func (s *tibbiOrderService) List(q query.Query, page, limit int) ([]models.TibbiOrder, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -45,15 +42,11 @@ func (s *tibbiOrderService) GetByBasvuruKodu(basvuruKodu string, page, limit int
 		limit = 10
 	}
 
-	return s.repo.FindByBasvuruKodu(basvuruKodu, page, limit)
+	return s.repo.List(q, page, limit)
 }
 
-// GetDetayByOrderKodu retrieves medical order details by order code
-func (s *tibbiOrderService) GetDetayByOrderKodu(orderKodu string, page, limit int) ([]models.TibbiOrderDetay, int64, error) {
-	if orderKodu == "" {
-		return nil, 0, errors.New("tibbi_order_kodu is required")
-	}
-
+// ListDetay retrieves order administrations matching a list query
+func (s *tibbiOrderService) ListDetay(q query.Query, page, limit int) ([]models.TibbiOrderDetay, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -61,5 +54,5 @@ func (s *tibbiOrderService) GetDetayByOrderKodu(orderKodu string, page, limit in
 		limit = 10
 	}
 
-	return s.repo.FindDetayByOrderKodu(orderKodu, page, limit)
+	return s.repo.ListDetay(q, page, limit)
 }
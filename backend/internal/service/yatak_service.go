@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"medscreen/internal/models"
+	"medscreen/internal/query"
 	"medscreen/internal/repository"
 )
 
@@ -32,27 +33,8 @@ func (s *yatakService) GetByKodu(kodu string) (*models.Yatak, error) {
 	return yatak, nil
 }
 
-// GetByBirimAndOda retrieves beds by unit and room codes
-func (s *yatakService) GetByBirimAndOda(birimKodu, odaKodu string, page, limit int) ([]models.Yatak, int64, error) {
-	if birimKodu == "" {
-		return nil, 0, errors.New("birim_kodu is required")
-	}
-	if odaKodu == "" {
-		return nil, 0, errors.New("oda_kodu is required")
-	}
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
-	}
-
-	return s.repo.FindByBirimAndOda(birimKodu, odaKodu, page, limit)
-}
-
-// GetAll retrieves all beds with pagination
-func (s *yatakService) GetAll(page, limit int) ([]models.Yatak, int64, error) {
+// List retrieves beds matching a list query
+func (s *yatakService) List(q query.Query, page, limit int) ([]models.Yatak, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -60,5 +42,5 @@ func (s *yatakService) GetAll(page, limit int) ([]models.Yatak, int64, error) {
 		limit = 10
 	}
 
-	return s.repo.FindAll(page, limit)
+	return s.repo.List(q, page, limit)
 }
@@ -0,0 +1,138 @@
+// Package stream detects new clinical events for a bed or a ward and pushes them to
+// long-lived clients (Server-Sent Events).
+//
+// VEM 2.0 is read-only for MedScreen, so triggers for Postgres LISTEN/NOTIFY cannot be
+// installed on its tables. Changes are detected by polling the kayit_zamani and
+// guncelleme_zamani watermarks through repository.KlinikOlayRepository instead.
+package stream
+
+import (
+	"context"
+	"errors"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"time"
+)
+
+// Konu is what a stream watches: a single bed or a whole ward
+type Konu struct {
+	YatakKodu string
+	BirimKodu string
+}
+
+// sayfaBoyutu is the page size used to list the inpatients of a bed or ward
+const sayfaBoyutu = 100
+
+// Izleyici polls the clinical tables for the visits of a bed or ward
+type Izleyici struct {
+	yatanRepo    repository.AnlikYatanHastaRepository
+	olayRepo     repository.KlinikOlayRepository
+	clock        Clock
+	pollInterval time.Duration
+	keepalive    time.Duration
+}
+
+// NewIzleyici creates a new Izleyici. A keepalive is emitted when no event was emitted
+// for the keepalive duration, so proxies do not close idle streams.
+func NewIzleyici(yatanRepo repository.AnlikYatanHastaRepository, olayRepo repository.KlinikOlayRepository, clock Clock, pollInterval, keepalive time.Duration) *Izleyici {
+	return &Izleyici{
+		yatanRepo:    yatanRepo,
+		olayRepo:     olayRepo,
+		clock:        clock,
+		pollInterval: pollInterval,
+		keepalive:    keepalive,
+	}
+}
+
+// Izle emits the events at or after since, then polls for new ones until ctx is done or
+// emit returns an error. emit is called with a nil batch as a keepalive.
+//
+// The inpatients of the bed or ward are resolved again on every poll, so admissions and
+// transfers are picked up. Events sharing the watermark are emitted once per stream;
+// a client resuming from a watermark may see the events at that instant again.
+func (iz *Izleyici) Izle(ctx context.Context, konu Konu, since time.Time, emit func([]repository.KlinikOlay) error) error {
+	if konu.YatakKodu == "" && konu.BirimKodu == "" {
+		return errors.New("yatak_kodu or birim_kodu is required")
+	}
+
+	watermark := since
+	gorulen := make(map[string]bool)
+	sonGonderim := iz.clock.Now()
+
+	for {
+		basvuruKodlari, err := iz.basvuruKodlari(konu)
+		if err != nil {
+			return err
+		}
+		olaylar, err := iz.olayRepo.FindSince(basvuruKodlari, watermark)
+		if err != nil {
+			return err
+		}
+
+		var yeni []repository.KlinikOlay
+		for _, olay := range olaylar {
+			if olay.Zaman.Before(watermark) || (olay.Zaman.Equal(watermark) && gorulen[olayAnahtari(olay)]) {
+				continue
+			}
+			yeni = append(yeni, olay)
+		}
+
+		if len(yeni) > 0 {
+			if err := emit(yeni); err != nil {
+				return err
+			}
+			son := yeni[len(yeni)-1].Zaman
+			if son.After(watermark) {
+				watermark = son
+				gorulen = make(map[string]bool)
+			}
+			for _, olay := range yeni {
+				if olay.Zaman.Equal(watermark) {
+					gorulen[olayAnahtari(olay)] = true
+				}
+			}
+			sonGonderim = iz.clock.Now()
+		} else if iz.clock.Now().Sub(sonGonderim) >= iz.keepalive {
+			if err := emit(nil); err != nil {
+				return err
+			}
+			sonGonderim = iz.clock.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-iz.clock.After(iz.pollInterval):
+		}
+	}
+}
+
+// basvuruKodlari lists the visits currently admitted to the bed or ward
+func (iz *Izleyici) basvuruKodlari(konu Konu) ([]string, error) {
+	var kodlar []string
+	for page := 1; ; page++ {
+		var (
+			yatanHastalar []models.AnlikYatanHasta
+			total         int64
+			err           error
+		)
+		if konu.YatakKodu != "" {
+			yatanHastalar, total, err = iz.yatanRepo.FindByYatakKodu(konu.YatakKodu, page, sayfaBoyutu)
+		} else {
+			yatanHastalar, total, err = iz.yatanRepo.FindByBirimKodu(konu.BirimKodu, page, sayfaBoyutu)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, yatanHasta := range yatanHastalar {
+			kodlar = append(kodlar, yatanHasta.HastaBasvuruKodu)
+		}
+		if len(yatanHastalar) == 0 || int64(page*sayfaBoyutu) >= total {
+			return kodlar, nil
+		}
+	}
+}
+
+func olayAnahtari(olay repository.KlinikOlay) string {
+	return string(olay.Tur) + "/" + olay.Kodu
+}
@@ -0,0 +1,244 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"medscreen/internal/models"
+	"medscreen/internal/repository"
+	"sync"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+)
+
+// Feature: clinical-event-stream, Property 1: Every Event Is Emitted Once
+// *For any* sequence of rows written to the visits of a ward between polls, the stream
+// SHALL emit every row exactly once, oldest first, and no row of another ward.
+
+// fakeClock only moves when Advance is called; waiting signals every call to After
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []fakeTimer
+	waiting chan struct{}
+}
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, waiting: make(chan struct{}, 1)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	ch := make(chan time.Time, 1)
+	c.timers = append(c.timers, fakeTimer{at: c.now.Add(d), ch: ch})
+	c.mu.Unlock()
+	c.waiting <- struct{}{}
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	kalan := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			kalan = append(kalan, t)
+			continue
+		}
+		t.ch <- c.now
+	}
+	c.timers = kalan
+}
+
+// memYatanRepo holds the inpatients of a single ward
+type memYatanRepo struct {
+	repository.AnlikYatanHastaRepository
+	basvuruKodlari []string
+}
+
+func (r *memYatanRepo) FindByBirimKodu(birimKodu string, page, limit int) ([]models.AnlikYatanHasta, int64, error) {
+	var sonuc []models.AnlikYatanHasta
+	for i := (page - 1) * limit; i < len(r.basvuruKodlari) && i < page*limit; i++ {
+		sonuc = append(sonuc, models.AnlikYatanHasta{HastaBasvuruKodu: r.basvuruKodlari[i]})
+	}
+	return sonuc, int64(len(r.basvuruKodlari)), nil
+}
+
+// memOlayRepo is an in-memory KlinikOlayRepository
+type memOlayRepo struct {
+	mu      sync.Mutex
+	olaylar []repository.KlinikOlay
+}
+
+func (r *memOlayRepo) ekle(olay repository.KlinikOlay) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.olaylar = append(r.olaylar, olay)
+}
+
+func (r *memOlayRepo) FindSince(basvuruKodlari []string, since time.Time) ([]repository.KlinikOlay, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sonuc []repository.KlinikOlay
+	for _, olay := range r.olaylar {
+		for _, kodu := range basvuruKodlari {
+			if olay.HastaBasvuruKodu == kodu && !olay.Zaman.Before(since) {
+				sonuc = append(sonuc, olay)
+			}
+		}
+	}
+	repository.SortKlinikOlaylar(sonuc)
+	return sonuc, nil
+}
+
+// akis runs Izle in the background and collects what it emits
+type akis struct {
+	clock   *fakeClock
+	batches chan []repository.KlinikOlay
+	cancel  context.CancelFunc
+	done    chan error
+}
+
+func baslat(iz *Izleyici, clock *fakeClock, konu Konu, since time.Time) *akis {
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &akis{clock: clock, batches: make(chan []repository.KlinikOlay, 100), cancel: cancel, done: make(chan error, 1)}
+	go func() {
+		a.done <- iz.Izle(ctx, konu, since, func(olaylar []repository.KlinikOlay) error {
+			a.batches <- olaylar
+			return nil
+		})
+	}()
+	return a
+}
+
+// poll waits for the stream to go idle, advances the clock by d and waits again,
+// returning everything emitted in between
+func (a *akis) poll(t interface{ Fatalf(string, ...any) }, d time.Duration) [][]repository.KlinikOlay {
+	a.clock.Advance(d)
+	select {
+	case <-a.clock.waiting:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Stream did not poll again")
+	}
+	var sonuc [][]repository.KlinikOlay
+	for {
+		select {
+		case batch := <-a.batches:
+			sonuc = append(sonuc, batch)
+		default:
+			return sonuc
+		}
+	}
+}
+
+func (a *akis) durdur(t interface{ Fatalf(string, ...any) }) {
+	a.cancel()
+	a.clock.Advance(time.Hour)
+	if err := <-a.done; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+// TestProperty_EveryEventEmittedOnce tests Property 1
+func TestProperty_EveryEventEmittedOnce(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		baslangic := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+		clock := newFakeClock(baslangic)
+		yatanRepo := &memYatanRepo{basvuruKodlari: []string{"B1", "B2"}}
+		olayRepo := &memOlayRepo{}
+		iz := NewIzleyici(yatanRepo, olayRepo, clock, 5*time.Second, time.Hour)
+
+		a := baslat(iz, clock, Konu{BirimKodu: "DAHILIYE"}, baslangic)
+		<-clock.waiting
+
+		beklenen := make(map[string]bool)
+		gorulen := make(map[string]bool)
+		var sonZaman time.Time
+		zaman := baslangic
+		adimlar := rapid.IntRange(1, 10).Draw(rt, "adimlar")
+		for adim := 0; adim < adimlar; adim++ {
+			yeniSayisi := rapid.IntRange(0, 4).Draw(rt, "yeniSayisi")
+			for i := 0; i < yeniSayisi; i++ {
+				// Rows may share a timestamp with rows already emitted
+				zaman = zaman.Add(time.Duration(rapid.IntRange(0, 2).Draw(rt, "sn")) * time.Second)
+				basvuru := rapid.SampledFrom([]string{"B1", "B2", "B9"}).Draw(rt, "basvuru")
+				olay := repository.KlinikOlay{
+					Tur:              rapid.SampledFrom([]repository.KlinikOlayTuru{repository.OlayVitalBulgu, repository.OlayHastaUyari, repository.OlayKlinikSeyir}).Draw(rt, "tur"),
+					Kodu:             fmt.Sprintf("K%d-%d", adim, i),
+					HastaBasvuruKodu: basvuru,
+					Zaman:            zaman,
+				}
+				olayRepo.ekle(olay)
+				if basvuru != "B9" {
+					beklenen[olay.Kodu] = true
+				}
+			}
+
+			for _, batch := range a.poll(rt, 5*time.Second) {
+				for _, olay := range batch {
+					if !beklenen[olay.Kodu] || gorulen[olay.Kodu] {
+						rt.Fatalf("Unexpected or repeated event %+v", olay)
+					}
+					if olay.Zaman.Before(sonZaman) {
+						rt.Fatalf("Event %s emitted out of order", olay.Kodu)
+					}
+					gorulen[olay.Kodu] = true
+					sonZaman = olay.Zaman
+				}
+			}
+		}
+		a.durdur(rt)
+
+		if len(gorulen) != len(beklenen) {
+			rt.Fatalf("Expected %d events, got %d", len(beklenen), len(gorulen))
+		}
+	})
+}
+
+// TestIzleyici_KeepaliveAndResume verifies keepalives on an idle stream and that a
+// stream started from a watermark only emits later events
+func TestIzleyici_KeepaliveAndResume(t *testing.T) {
+	baslangic := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	clock := newFakeClock(baslangic)
+	olayRepo := &memOlayRepo{}
+	olayRepo.ekle(repository.KlinikOlay{Tur: repository.OlayVitalBulgu, Kodu: "V1", HastaBasvuruKodu: "B1", Zaman: baslangic.Add(-time.Hour)})
+	olayRepo.ekle(repository.KlinikOlay{Tur: repository.OlayTetkikSonuc, Kodu: "T1", HastaBasvuruKodu: "B1", Zaman: baslangic.Add(-time.Minute)})
+	iz := NewIzleyici(&memYatanRepo{basvuruKodlari: []string{"B1"}}, olayRepo, clock, 5*time.Second, 20*time.Second)
+
+	a := baslat(iz, clock, Konu{BirimKodu: "DAHILIYE"}, baslangic.Add(-10*time.Minute))
+	<-clock.waiting
+	if batch := <-a.batches; len(batch) != 1 || batch[0].Kodu != "T1" {
+		t.Fatalf("Expected only the event after the watermark, got %+v", batch)
+	}
+
+	var keepalive int
+	for i := 0; i < 4; i++ {
+		for _, batch := range a.poll(t, 5*time.Second) {
+			if batch != nil {
+				t.Fatalf("Unexpected events on an idle stream: %+v", batch)
+			}
+			keepalive++
+		}
+	}
+	if keepalive != 1 {
+		t.Errorf("Expected one keepalive after 20s, got %d", keepalive)
+	}
+	a.durdur(t)
+
+	if err := iz.Izle(context.Background(), Konu{}, baslangic, func([]repository.KlinikOlay) error { return nil }); err == nil {
+		t.Error("Expected an error without a bed or ward")
+	}
+}
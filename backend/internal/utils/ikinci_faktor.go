@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PINIterasyon is the PBKDF2-SHA256 iteration count for new PIN hashes
+var PINIterasyon = 600000
+
+// TOTP parameters (RFC 6238 defaults understood by every authenticator app)
+const (
+	TOTPAdimi    = 30 * time.Second
+	TOTPBasamak  = 6
+	totpAnahtarB = 20
+)
+
+// HashPIN returns a salted PBKDF2-SHA256 hash of a PIN in the form
+// pbkdf2-sha256$<iterations>$<salt>$<hash>
+func HashPIN(pin string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, pin, salt, PINIterasyon, 32)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", PINIterasyon,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// VerifyPIN reports whether pin matches a hash made by HashPIN
+func VerifyPIN(pin, ozet string) bool {
+	parts := strings.Split(ozet, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iter, err := strconv.Atoi(parts[1])
+	if err != nil || iter <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, pin, salt, iter, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// NewTOTPSecret returns a random base32 TOTP secret
+func NewTOTPSecret() (string, error) {
+	b := make([]byte, totpAnahtarB)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b), nil
+}
+
+// TOTPURI returns the otpauth:// URI an authenticator app enrolls from (usually as a QR code)
+func TOTPURI(issuer, hesap, secret string) string {
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("digits", strconv.Itoa(TOTPBasamak))
+	q.Set("period", strconv.Itoa(int(TOTPAdimi.Seconds())))
+	return "otpauth://totp/" + url.PathEscape(issuer+":"+hesap) + "?" + q.Encode()
+}
+
+// TOTPKodu returns the code of a secret for a time step (RFC 4226 with HMAC-SHA1)
+func TOTPKodu(secret string, adim int64) (string, error) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", errors.New("invalid TOTP secret")
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(adim))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	kod := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < TOTPBasamak; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", TOTPBasamak, kod%mod), nil
+}
+
+// TOTPAdimiAt returns the time step of t
+func TOTPAdimiAt(t time.Time) int64 {
+	return t.Unix() / int64(TOTPAdimi.Seconds())
+}
+
+// DogrulaTOTP checks a code against the time steps around now, allowing one step of
+// clock drift either way, and returns the matching step. Steps at or before sonAdim are
+// refused so a code cannot be replayed.
+func DogrulaTOTP(secret, kod string, now time.Time, sonAdim int64) (int64, bool) {
+	simdi := TOTPAdimiAt(now)
+	for adim := simdi - 1; adim <= simdi+1; adim++ {
+		if adim <= sonAdim {
+			continue
+		}
+		beklenen, err := TOTPKodu(secret, adim)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(beklenen), []byte(kod)) == 1 {
+			return adim, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,250 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync/atomic"
+
+	"medscreen/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSABits is the smallest RSA modulus accepted for signing or verification
+const minRSABits = 2048
+
+// ErrUnknownKey is returned for a token signed with a key that is not in the key set
+var ErrUnknownKey = errors.New("token is signed with an unknown key")
+
+// signingKey is one key of a KeySet. sign is nil for a key that only verifies tokens.
+type signingKey struct {
+	kid    string
+	method jwt.SigningMethod
+	sign   interface{}
+	verify interface{}
+}
+
+// KeySet holds the key new tokens are signed with and every key whose tokens are accepted.
+// Keeping the previous key in the set while a new one signs allows rotation without downtime.
+type KeySet struct {
+	signer *signingKey
+	keys   map[string]*signingKey
+}
+
+// NewHMACKeySet returns a key set that signs and verifies HS256 tokens with a shared secret.
+// HS256 tokens carry no kid and the key set publishes no JWKS keys.
+func NewHMACKeySet(secret []byte) *KeySet {
+	key := &signingKey{method: jwt.SigningMethodHS256, sign: secret, verify: secret}
+	return &KeySet{signer: key, keys: map[string]*signingKey{"": key}}
+}
+
+// LoadKeySet loads an RSA or Ed25519 private key in PEM form that signs new tokens, and
+// further PEM keys (public or private) whose tokens are still accepted.
+// Each key's kid is its RFC 7638 thumbprint, so the same file always yields the same kid.
+func LoadKeySet(signingKeyFile string, verificationKeyFiles []string) (*KeySet, error) {
+	signer, err := loadKeyFile(signingKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if signer.sign == nil {
+		return nil, fmt.Errorf("%s: signing key must be a private key", signingKeyFile)
+	}
+
+	ks := &KeySet{signer: signer, keys: map[string]*signingKey{signer.kid: signer}}
+	for _, file := range verificationKeyFiles {
+		key, err := loadKeyFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := ks.keys[key.kid]; !ok {
+			ks.keys[key.kid] = key
+		}
+	}
+	return ks, nil
+}
+
+// SigningKID returns the kid of the key new tokens are signed with (empty for HS256)
+func (ks *KeySet) SigningKID() string {
+	return ks.signer.kid
+}
+
+// signToken signs a token with the signing key, setting its kid header
+func (ks *KeySet) signToken(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signer.method, claims)
+	if ks.signer.kid != "" {
+		token.Header["kid"] = ks.signer.kid
+	}
+	return token.SignedString(ks.signer.sign)
+}
+
+// keyFunc selects the verification key by the token's kid. The token's alg must match the
+// key's, so a public key can never be used as an HMAC secret.
+func (ks *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	return key.verify, nil
+}
+
+// JWK is a public key in JSON Web Key form (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set, the signing key first.
+// Shared HMAC secrets are never published.
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	var kids []string
+	for kid := range ks.keys {
+		if kid != "" && kid != ks.signer.kid {
+			kids = append(kids, kid)
+		}
+	}
+	sort.Strings(kids)
+	if ks.signer.kid != "" {
+		kids = append([]string{ks.signer.kid}, kids...)
+	}
+
+	for _, kid := range kids {
+		key := ks.keys[kid]
+		jwk := JWK{Kid: kid, Use: "sig", Alg: key.method.Alg()}
+		switch pub := key.verify.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty, jwk.N, jwk.E = "RSA", b64(pub.N.Bytes()), b64(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty, jwk.Crv, jwk.X = "OKP", "Ed25519", b64(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+var jwtKeys atomic.Pointer[KeySet]
+
+func init() {
+	jwtKeys.Store(NewHMACKeySet([]byte(config.DefaultJWTSecretKey)))
+}
+
+// SetKeySet replaces the key set tokens are signed and verified with
+func SetKeySet(ks *KeySet) {
+	jwtKeys.Store(ks)
+}
+
+// ConfigureJWT installs the key set described by the configuration: the asymmetric keys
+// when a signing key file is set, the HS256 secret otherwise
+func ConfigureJWT(cfg *config.JWTConfig) error {
+	if cfg.SigningKeyFile == "" {
+		if cfg.SecretKey == "" {
+			return errors.New("JWT_SECRET_KEY is empty")
+		}
+		SetKeySet(NewHMACKeySet([]byte(cfg.SecretKey)))
+		return nil
+	}
+
+	ks, err := LoadKeySet(cfg.SigningKeyFile, cfg.VerificationKeyFiles)
+	if err != nil {
+		return err
+	}
+	SetKeySet(ks)
+	return nil
+}
+
+// CurrentKeySet returns the key set tokens are signed and verified with
+func CurrentKeySet() *KeySet {
+	return jwtKeys.Load()
+}
+
+func loadKeyFile(path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block found", path)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	key, err := newSigningKey(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+func newSigningKey(parsed interface{}) (*signingKey, error) {
+	key := &signingKey{}
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.sign = signer
+		parsed = signer.Public()
+	}
+
+	switch pub := parsed.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("RSA key must be at least %d bits", minRSABits)
+		}
+		key.method, key.verify = jwt.SigningMethodRS256, pub
+		key.kid = thumbprint(fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, b64(big.NewInt(int64(pub.E)).Bytes()), b64(pub.N.Bytes())))
+	case ed25519.PublicKey:
+		key.method, key.verify = jwt.SigningMethodEdDSA, pub
+		key.kid = thumbprint(fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":"%s"}`, b64(pub)))
+	default:
+		return nil, fmt.Errorf("unsupported key type %T; use RSA or Ed25519", pub)
+	}
+	return key, nil
+}
+
+// thumbprint returns the RFC 7638 thumbprint of a JWK's canonical members
+func thumbprint(canonical string) string {
+	sum := sha256.Sum256([]byte(canonical))
+	return b64(sum[:])
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
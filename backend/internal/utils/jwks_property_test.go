@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"medscreen/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+	"pgregory.net/rapid"
+)
+
+// Feature: asymmetric-jwt, Property 1: Tokens Verify Exactly While Their Key Is in the Set
+// *For any* rotation of signing keys, a token SHALL carry the kid of the key that signed it
+// and SHALL verify exactly while that key is part of the installed key set.
+
+// writeKey writes a PEM key of the given kind ("rsa", "ed25519" or "ed25519-public") to dir
+func writeKey(t *testing.T, dir, name, kind string) string {
+	t.Helper()
+	var block *pem.Block
+	switch kind {
+	case "rsa":
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	default:
+		pub, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+		if kind == "ed25519-public" {
+			der, err = x509.MarshalPKIXPublicKey(pub)
+			block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testToken(t interface{ Fatalf(string, ...any) }) (string, *Claims) {
+	token, claims, err := GenerateJWT(Claims{PersonelKodu: "P000001", Role: "HEKIM", TokenType: TokenTypeAccess}, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	return token, claims
+}
+
+// TestProperty_RotationKeepsTokensValid tests Property 1
+func TestProperty_RotationKeepsTokensValid(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		writeKey(t, dir, "rsa.pem", "rsa"),
+		writeKey(t, dir, "ed1.pem", "ed25519"),
+		writeKey(t, dir, "ed2.pem", "ed25519"),
+	}
+	defer SetKeySet(NewHMACKeySet([]byte(config.DefaultJWTSecretKey)))
+
+	rapid.Check(t, func(rt *rapid.T) {
+		imzalayan := rapid.IntRange(0, len(files)-1).Draw(rt, "imzalayan")
+		ks, err := LoadKeySet(files[imzalayan], nil)
+		if err != nil {
+			rt.Fatalf("Failed to load key: %v", err)
+		}
+		SetKeySet(ks)
+		token, claims := testToken(rt)
+
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+		if err != nil || parsed.Header["kid"] != ks.SigningKID() {
+			rt.Fatalf("Expected kid %s, got %v (%v)", ks.SigningKID(), parsed.Header["kid"], err)
+		}
+
+		// Rotate to another key, keeping some of the others for verification
+		yeni := rapid.IntRange(0, len(files)-1).Draw(rt, "yeni")
+		var dogrulama []string
+		tutuldu := yeni == imzalayan
+		for i, file := range files {
+			if i != yeni && rapid.Bool().Draw(rt, "tut") {
+				dogrulama = append(dogrulama, file)
+				tutuldu = tutuldu || i == imzalayan
+			}
+		}
+		rotated, err := LoadKeySet(files[yeni], dogrulama)
+		if err != nil {
+			rt.Fatalf("Failed to load keys: %v", err)
+		}
+		SetKeySet(rotated)
+
+		got, err := ParseJWT(token)
+		if tutuldu && (err != nil || got.ID != claims.ID) {
+			rt.Fatalf("Expected the token to verify after rotation, got %v", err)
+		}
+		if !tutuldu && !errors.Is(err, ErrUnknownKey) {
+			rt.Fatalf("Expected ErrUnknownKey once the key left the set, got %v", err)
+		}
+		if len(rotated.JWKS().Keys) != len(rotated.keys) || rotated.JWKS().Keys[0].Kid != rotated.SigningKID() {
+			rt.Fatalf("Unexpected JWKS: %+v", rotated.JWKS())
+		}
+	})
+}
+
+// TestJWKS_RejectsAlgorithmConfusion verifies that an HS256 token cannot pass as an asymmetric one
+// and that verification-only keys cannot sign
+func TestJWKS_RejectsAlgorithmConfusion(t *testing.T) {
+	dir := t.TempDir()
+	public := writeKey(t, dir, "public.pem", "ed25519-public")
+	private := writeKey(t, dir, "private.pem", "ed25519")
+	defer SetKeySet(NewHMACKeySet([]byte(config.DefaultJWTSecretKey)))
+
+	if _, err := LoadKeySet(public, nil); err == nil {
+		t.Error("Expected a public key to be refused as the signing key")
+	}
+	ks, err := LoadKeySet(private, []string{public})
+	if err != nil {
+		t.Fatalf("Failed to load keys: %v", err)
+	}
+	jwks := ks.JWKS()
+	if len(jwks.Keys) != 2 || jwks.Keys[0].Kty != "OKP" || jwks.Keys[0].Alg != "EdDSA" || jwks.Keys[0].X == "" {
+		t.Fatalf("Unexpected JWKS: %+v", jwks)
+	}
+
+	// An HS256 token claiming the kid of the published key, keyed with the public key bytes
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{PersonelKodu: "P000001", RegisteredClaims: jwt.RegisteredClaims{ID: "x"}})
+	forged.Header["kid"] = ks.SigningKID()
+	signed, err := forged.SignedString([]byte(jwks.Keys[0].X))
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetKeySet(ks)
+	if _, err := ParseJWT(signed); err == nil {
+		t.Error("Expected an HS256 token to be rejected by an EdDSA key set")
+	}
+
+	// Default HS256 tokens carry no kid and are rejected once asymmetric keys are installed
+	SetKeySet(NewHMACKeySet([]byte(config.DefaultJWTSecretKey)))
+	hs, _ := testToken(t)
+	if len(NewHMACKeySet(nil).JWKS().Keys) != 0 {
+		t.Error("Expected the HMAC secret never to be published")
+	}
+	SetKeySet(ks)
+	if _, err := ParseJWT(hs); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Expected ErrUnknownKey for an HS256 token, got %v", err)
+	}
+}
@@ -1,55 +1,83 @@
 package utils
 
 import (
+	"errors"
 	"time"
 
-	"medscreen/internal/config"
-
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// JWTSecretKey is the secret key used for signing JWT tokens
-var jwtSecretKey []byte
+// Token types carried in the token_type claim
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// Login methods carried in the giris_yontemi claim
+const (
+	GirisYontemiNFC = "nfc"
+	GirisYontemiSSO = "sso"
+)
+
+// Claims holds the MedScreen JWT claims.
+// The jti (RegisteredClaims.ID) identifies a single token so it can be revoked.
+// YatakKodu binds a token minted on a bedside tablet to the patient in that bed;
+// YatakKisitiKaldirildi marks a token a HEKIM explicitly escalated out of that binding.
+// AcilErisimKodu marks a break-the-glass token; it overrides the authorization policy and
+// ties every access made with it to the emergency access grant under review.
+// IkinciFaktorZamani is when (unix seconds) the holder last proved a second factor
+// (PIN or TOTP) through the step-up flow; the policy only honours it for a short window.
+// GirisYontemi is how the session started: an NFC card (also when empty) or the hospital SSO.
+// OturumKodu ties every token of a login and its refreshes to a server-side session.
+type Claims struct {
+	PersonelKodu          string `json:"personel_kodu"`
+	Role                  string `json:"role"`
+	TabletCihazKodu       string `json:"tablet_cihaz_kodu,omitempty"`
+	BirimKodu             string `json:"birim_kodu,omitempty"`
+	YatakKodu             string `json:"yatak_kodu,omitempty"`
+	YatakKisitiKaldirildi bool   `json:"yatak_kisiti_kaldirildi,omitempty"`
+	AcilErisimKodu        string `json:"acil_erisim_kodu,omitempty"`
+	NFCKartKodu           string `json:"nfc_kart_kodu,omitempty"`
+	IkinciFaktorZamani    int64  `json:"ikinci_faktor_zamani,omitempty"`
+	GirisYontemi          string `json:"giris_yontemi,omitempty"`
+	OturumKodu            string `json:"oturum_kodu,omitempty"`
+	TokenType             string `json:"token_type"`
+	jwt.RegisteredClaims
+}
 
-// SetJWTSecretKey sets the JWT secret key from configuration
-func SetJWTSecretKey() {
-	// Load JWT secret key from configuration
-	cfg, err := config.LoadConfig()
+// GenerateJWT signs a token for the given claims with the current signing key; it expires after ttl.
+// A fresh jti, the subject and the issue/expiry times are filled in; the final claims are returned.
+func GenerateJWT(claims Claims, ttl time.Duration) (string, *Claims, error) {
+	jti, err := NewRandomID()
 	if err != nil {
-		panic("Failed to load config: " + err.Error())
+		return "", nil, err
 	}
-	jwtSecretKey = []byte(cfg.JWT.SecretKey)
-}
 
-// GenerateJWT generates a JWT token for a given user ID and role
-func GenerateJWT(userID uint, role string) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"role":    role,
-		"exp":     jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // 24 hours expiration
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        jti,
+		Subject:   claims.PersonelKodu,
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecretKey)
-}
 
-// ParseJWT parses and validates a JWT token and returns the user ID and role
-func ParseJWT(tokenString string) (uint, string, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return jwtSecretKey, nil
-	})
+	signed, err := CurrentKeySet().signToken(&claims)
 	if err != nil {
-		return 0, "", err
+		return "", nil, err
 	}
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userIDFloat, okID := claims["user_id"].(float64)
-		role, okRole := claims["role"].(string)
+	return signed, &claims, nil
+}
 
-		if okID && okRole {
-			return uint(userIDFloat), role, nil
-		}
+// ParseJWT parses and validates a JWT token and returns its claims
+func ParseJWT(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, CurrentKeySet().keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid || claims.ID == "" || claims.PersonelKodu == "" {
+		return nil, errors.New("token is missing required claims")
 	}
-	return 0, "", jwt.ErrInvalidKey
+	return claims, nil
 }
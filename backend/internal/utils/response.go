@@ -22,12 +22,14 @@ type SuccessResponse struct {
 	Meta    *Meta       `json:"meta,omitempty"`
 }
 
-// Meta represents pagination metadata
+// Meta represents pagination metadata. Cursor pages have no page number, carry the
+// cursor of the following page and only have totals when they were asked for.
 type Meta struct {
-	Page       int   `json:"page"`
-	Limit      int   `json:"limit"`
-	TotalItems int64 `json:"total_items"`
-	TotalPages int   `json:"total_pages"`
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	TotalItems *int64 `json:"total_items,omitempty"`
+	TotalPages *int   `json:"total_pages,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // SendErrorResponse sends a standardized error response
@@ -91,8 +93,18 @@ func CalculateMeta(page, limit int, totalItems int64) *Meta {
 
 	return &Meta{
 		Page:       page,
+		Limit:      limit,
+		TotalItems: &totalItems,
+		TotalPages: &totalPages,
+	}
+}
+
+// CalculateCursorMeta calculates the metadata of a cursor page; totalItems is nil when the
+// total was not counted and nextCursor is empty on the last page
+func CalculateCursorMeta(limit int, totalItems *int64, nextCursor string) *Meta {
+	return &Meta{
 		Limit:      limit,
 		TotalItems: totalItems,
-		TotalPages: totalPages,
+		NextCursor: nextCursor,
 	}
 }